@@ -0,0 +1,140 @@
+// Command tracereplay replays ChampSim or CSV memory access traces on a
+// standalone cache and reports the hit rate, the MPKI, and the predictor
+// accuracy of every replacement policy.
+//
+// Usage:
+//
+//	tracereplay [flags] trace...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sarchlab/akita/v4/mem/cache"
+	"github.com/sarchlab/akita/v4/mem/cache/replay"
+)
+
+var (
+	setsFlag      = flag.Int("sets", 2048, "Number of sets")
+	waysFlag      = flag.Int("ways", 16, "Number of ways")
+	blockSizeFlag = flag.Int("block-size", 64, "Block size in bytes")
+	policiesFlag  = flag.String("policies", "lru,perceptron",
+		"Comma-separated replacement policies")
+	warmupFlag = flag.Uint64("warmup", 0,
+		"Number of warm-up instructions that are not counted")
+	csvFlag = flag.String("csv", "",
+		"Also write the results to this CSV file")
+	seedFlag = flag.Int64("seed", 0,
+		"Seed of the randomized policies, 0 for their default")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: %s [flags] trace...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var results []replay.Result
+
+	for _, path := range flag.Args() {
+		r, err := replayTrace(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		results = append(results, r...)
+	}
+
+	printResults(results)
+
+	if *csvFlag != "" {
+		if err := writeCSV(*csvFlag, results); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func replayTrace(path string) ([]replay.Result, error) {
+	trace, err := replay.OpenTrace(path)
+	if err != nil {
+		return nil, err
+	}
+	defer trace.Close()
+
+	return replay.Run(trace, replay.Config{
+		Workload:           workloadName(path),
+		NumSets:            *setsFlag,
+		NumWays:            *waysFlag,
+		BlockSize:          *blockSizeFlag,
+		Policies:           strings.Split(*policiesFlag, ","),
+		WarmupInstructions: *warmupFlag,
+		Seed:               *seedFlag,
+	})
+}
+
+// workloadName strips the directory and the trace extensions.
+func workloadName(path string) string {
+	name := filepath.Base(path)
+	for _, ext := range []string{".gz", ".csv", ".champsimtrace", ".trace"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	return name
+}
+
+func printResults(results []replay.Result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "workload\tpolicy\taccesses\thits\thit rate\tMPKI\t"+
+		"accuracy\t")
+
+	for _, r := range results {
+		accuracy := "-"
+		if r.HasPrediction {
+			accuracy = fmt.Sprintf("%.4f", r.Prediction.Accuracy())
+		}
+
+		mpki := "-"
+		if r.Instructions > 0 {
+			mpki = fmt.Sprintf("%.4f", r.MPKI())
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.4f\t%s\t%s\t\n",
+			r.Workload, r.Policy, r.Accesses, r.Hits, r.HitRate(), mpki,
+			accuracy)
+	}
+
+	w.Flush()
+}
+
+func writeCSV(path string, results []replay.Result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	simulationResults := make([]cache.SimulationResult, len(results))
+	for i, r := range results {
+		simulationResults[i] = r.SimulationResult
+	}
+
+	if err := cache.WriteSimulationResultsCSV(f, simulationResults); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,149 @@
+package cache
+
+// AgingConfig configures the aging counters of a directory.
+type AgingConfig struct {
+	// Interval is the number of directory visits between two aging ticks.
+	Interval uint64
+}
+
+type agingState struct {
+	config   AgingConfig
+	accesses uint64
+}
+
+// SetAging enables the aging counters. Every Interval visits, the reference
+// bit of every block is shifted into the most significant bit of its Age
+// counter and cleared. A larger Age therefore means a more recent and more
+// frequent use. The reference bits are always maintained; Clock, NRU, decay,
+// and predictor features share them instead of keeping their own.
+func (d *DirectoryImpl) SetAging(config AgingConfig) {
+	if config.Interval == 0 {
+		d.aging = nil
+		return
+	}
+
+	d.aging = &agingState{config: config}
+}
+
+func (d *DirectoryImpl) tickAging() {
+	if d.aging == nil {
+		return
+	}
+
+	d.aging.accesses++
+	if d.aging.accesses%d.aging.config.Interval != 0 {
+		return
+	}
+
+	d.AgeBlocks()
+}
+
+// AgeBlocks performs one aging tick on every block.
+func (d *DirectoryImpl) AgeBlocks() {
+	for _, set := range d.Sets {
+		for _, block := range set.Blocks {
+			block.Age >>= 1
+			if block.Referenced {
+				block.Age |= 0x80
+			}
+
+			block.Referenced = false
+		}
+	}
+}
+
+// NRUVictimFinder evicts a block whose reference bit is not set (Not Recently
+// Used). The directory sets the bit of a block on every visit. Like the
+// hardware policy, when a visit leaves every bit of the set set, the bits of
+// the other blocks are cleared, so there is always a victim unless locked or
+// pinned blocks hold the only clear bits; then all the bits are cleared at
+// the victim search.
+type NRUVictimFinder struct {
+}
+
+// NewNRUVictimFinder returns a new NRU victim finder.
+func NewNRUVictimFinder() *NRUVictimFinder {
+	return &NRUVictimFinder{}
+}
+
+// Touch clears the reference bits of the other blocks if the visit set the
+// last clear bit of the set.
+func (n *NRUVictimFinder) Touch(set *Set, block *Block) {
+	for _, b := range set.Blocks {
+		if !b.Referenced {
+			return
+		}
+	}
+
+	for _, b := range set.Blocks {
+		b.Referenced = b == block
+	}
+}
+
+// FindVictim returns the first invalid block, or the first block that is not
+// referenced. Locked and pinned blocks are skipped.
+func (n *NRUVictimFinder) FindVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	for round := 0; round < 2; round++ {
+		for _, block := range set.Blocks {
+			if !block.IsLocked && !isPinned(block) && !block.Referenced {
+				return block
+			}
+		}
+
+		for _, block := range set.Blocks {
+			block.Referenced = false
+		}
+	}
+
+	return nil
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (n *NRUVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return n.FindVictim(set)
+}
+
+// FindVictims returns up to n candidates, the blocks that are not referenced
+// before the referenced ones, in way order, without clearing any bit.
+func (n *NRUVictimFinder) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	count int,
+) []*Block {
+	ways := make([]int, 0, len(set.Blocks))
+
+	for _, referenced := range []bool{false, true} {
+		for way, block := range set.Blocks {
+			if block.Referenced == referenced {
+				ways = append(ways, way)
+			}
+		}
+	}
+
+	return rankCandidates(set, ways, count)
+}
+
+// OldestBlock returns the unlocked valid block with the smallest Age counter,
+// or nil if there is none.
+func OldestBlock(set *Set) *Block {
+	var oldest *Block
+
+	for _, block := range set.Blocks {
+		if !block.IsValid || block.IsLocked {
+			continue
+		}
+
+		if oldest == nil || block.Age < oldest.Age {
+			oldest = block
+		}
+	}
+
+	return oldest
+}
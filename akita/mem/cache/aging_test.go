@@ -0,0 +1,126 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Aging", func() {
+	var (
+		d *DirectoryImpl
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 4, 64, NewClockVictimFinder())
+		d.SetAging(AgingConfig{Interval: 4})
+	})
+
+	access := func(addr uint64) *Block {
+		block := d.Lookup(0, addr)
+		if block == nil {
+			block = d.FindVictim(addr)
+			block.Tag = addr
+			block.IsValid = true
+		}
+
+		d.Visit(block)
+
+		return block
+	}
+
+	It("should shift the reference bits into the age counters", func() {
+		a := access(0x0)
+		b := access(0x40)
+		access(0x0)
+		access(0x0)
+
+		Expect(a.Age).To(Equal(uint8(0x80)))
+		Expect(a.Referenced).To(BeFalse())
+
+		for i := 0; i < 4; i++ {
+			access(0x0)
+		}
+
+		Expect(a.Age).To(Equal(uint8(0xc0)))
+		Expect(b.Age).To(Equal(uint8(0x40)))
+		Expect(OldestBlock(&d.Sets[0])).To(BeIdenticalTo(b))
+	})
+
+	It("should evict a block that is not recently used", func() {
+		for i := uint64(0); i < 4; i++ {
+			access(i * 64)
+		}
+		d.AgeBlocks()
+		access(0x80)
+
+		victim := d.FindVictim(0x100)
+
+		Expect(victim.Tag).NotTo(Equal(uint64(0x80)))
+		Expect(victim.Referenced).To(BeFalse())
+	})
+})
+
+var _ = Describe("NRUVictimFinder", func() {
+	var (
+		vf *NRUVictimFinder
+		d  *DirectoryImpl
+	)
+
+	fill := func(tag uint64) *Block {
+		block := d.FindVictim(tag)
+		block.Tag = tag
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	BeforeEach(func() {
+		vf = NewNRUVictimFinder()
+		d = NewDirectory(1, 4, 64, vf)
+	})
+
+	It("should clear the other bits when every bit is set", func() {
+		blocks := make([]*Block, 4)
+		for i := range blocks {
+			blocks[i] = fill(uint64(i) * 64)
+		}
+
+		Expect(blocks[3].Referenced).To(BeTrue())
+		for _, block := range blocks[:3] {
+			Expect(block.Referenced).To(BeFalse())
+		}
+
+		d.Visit(blocks[1])
+		Expect(fill(4 * 64)).To(BeIdenticalTo(blocks[0]))
+		Expect(fill(5 * 64)).To(BeIdenticalTo(blocks[2]))
+	})
+
+	It("should skip locked and pinned blocks", func() {
+		set := makeTestSet(3)
+		for _, block := range set.Blocks {
+			block.IsValid = true
+		}
+		set.Blocks[0].IsLocked = true
+		set.Blocks[1].IsPinned = true
+
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[2]))
+
+		set.Blocks[2].IsLocked = true
+		Expect(vf.FindVictim(set)).To(BeNil())
+	})
+
+	It("should rank the blocks that are not referenced first", func() {
+		set := makeTestSet(4)
+		for _, block := range set.Blocks {
+			block.IsValid = true
+		}
+		set.Blocks[0].Referenced = true
+		set.Blocks[2].Referenced = true
+
+		Expect(vf.FindVictims(set, nil, 4)).To(Equal([]*Block{
+			set.Blocks[1], set.Blocks[3], set.Blocks[0], set.Blocks[2],
+		}))
+		Expect(set.Blocks[0].Referenced).To(BeTrue())
+	})
+})
@@ -0,0 +1,268 @@
+package cache
+
+// arcEntry is a resident line of an ARC set.
+type arcEntry struct {
+	tag uint64
+	way int
+}
+
+// arcSet is the ARC state of one set. The lists are ordered from the least
+// to the most recently used.
+type arcSet struct {
+	// t1 holds the resident lines seen once recently, and t2 the resident
+	// lines seen at least twice.
+	t1, t2 []arcEntry
+
+	// b1 and b2 are the ghost lists: the tags of the lines recently
+	// evicted from t1 and t2.
+	b1, b2 []uint64
+
+	// p is the target size of t1.
+	p int
+}
+
+// ARCVictimFinder implements the Adaptive Replacement Cache (Megiddo and
+// Modha, FAST 2003) in every set. A set of c ways keeps the lines that were
+// referenced once in the list T1 and the lines that were referenced again in
+// T2, and remembers the tags of up to c lines evicted from each list in the
+// ghost lists B1 and B2. A miss that hits B1 means that T1 was too small and
+// grows its target size p; a miss that hits B2 shrinks it. The victim is the
+// least recently used line of T1 if T1 is larger than p, and of T2
+// otherwise, so the policy adapts between recency and frequency on every
+// set.
+//
+// The lists are stored per set by the finder, indexed by the set ID, and
+// identify the resident lines by their ways. The ghost lists are matched
+// with the line of the victim context, so the policy only adapts when the
+// caller finds victims with a context. Invalid blocks are filled first.
+type ARCVictimFinder struct {
+	sets []*arcSet
+}
+
+// NewARCVictimFinder returns a new ARC victim finder.
+func NewARCVictimFinder() *ARCVictimFinder {
+	return &ARCVictimFinder{}
+}
+
+// Target returns the target size of T1 in the set.
+func (a *ARCVictimFinder) Target(setID int) int {
+	return a.set(setID).p
+}
+
+// ListSizes returns the sizes of T1, T2, B1, and B2 in the set.
+func (a *ARCVictimFinder) ListSizes(setID int) (t1, t2, b1, b2 int) {
+	s := a.set(setID)
+
+	return len(s.t1), len(s.t2), len(s.b1), len(s.b2)
+}
+
+// FindVictim returns the victim of a miss that is in neither ghost list.
+func (a *ARCVictimFinder) FindVictim(set *Set) *Block {
+	return a.findVictim(set, nil)
+}
+
+// FindVictimWithContext returns the first invalid block, or the least
+// recently used line of T1 or T2, chosen with the target size that the miss
+// will leave.
+func (a *ARCVictimFinder) FindVictimWithContext(
+	set *Set,
+	ctx *VictimContext,
+) *Block {
+	return a.findVictim(set, ctx)
+}
+
+// FindVictims returns up to n candidates, the lines of the preferred list
+// before the others, each from the least to the most recently used.
+func (a *ARCVictimFinder) FindVictims(
+	set *Set,
+	ctx *VictimContext,
+	n int,
+) []*Block {
+	if len(set.Blocks) == 0 {
+		return nil
+	}
+
+	return rankCandidates(set, a.order(set, ctx), n)
+}
+
+// Insert moves the line that the block held to its ghost list, adapts the
+// target size if the new line is in a ghost list, and adds the new line to
+// T2 if it was a ghost and to T1 otherwise.
+func (a *ARCVictimFinder) Insert(set *Set, block *Block) {
+	s := a.set(block.SetID)
+	c := len(set.Blocks)
+
+	if i := arcFindWay(s.t1, block.WayID); i >= 0 {
+		s.b1 = append(s.b1, s.t1[i].tag)
+		s.t1 = append(s.t1[:i], s.t1[i+1:]...)
+	} else if i := arcFindWay(s.t2, block.WayID); i >= 0 {
+		s.b2 = append(s.b2, s.t2[i].tag)
+		s.t2 = append(s.t2[:i], s.t2[i+1:]...)
+	}
+
+	entry := arcEntry{tag: block.Tag, way: block.WayID}
+
+	switch {
+	case arcFindTag(s.b1, block.Tag) >= 0:
+		s.p = s.adaptedTarget(block.Tag, c)
+		s.b1 = arcRemoveTag(s.b1, block.Tag)
+		s.t2 = append(s.t2, entry)
+	case arcFindTag(s.b2, block.Tag) >= 0:
+		s.p = s.adaptedTarget(block.Tag, c)
+		s.b2 = arcRemoveTag(s.b2, block.Tag)
+		s.t2 = append(s.t2, entry)
+	default:
+		s.t1 = append(s.t1, entry)
+	}
+
+	s.trimGhosts(c)
+}
+
+// Touch moves a hit line to the most recently used position of T2.
+func (a *ARCVictimFinder) Touch(_ *Set, block *Block) {
+	s := a.set(block.SetID)
+	entry := arcEntry{tag: block.Tag, way: block.WayID}
+
+	if i := arcFindWay(s.t1, block.WayID); i >= 0 {
+		s.t1 = append(s.t1[:i], s.t1[i+1:]...)
+	} else if i := arcFindWay(s.t2, block.WayID); i >= 0 {
+		s.t2 = append(s.t2[:i], s.t2[i+1:]...)
+	}
+
+	s.t2 = append(s.t2, entry)
+}
+
+func (a *ARCVictimFinder) findVictim(set *Set, ctx *VictimContext) *Block {
+	if b := firstInvalidBlock(set); b != nil || len(set.Blocks) == 0 {
+		return b
+	}
+
+	for _, way := range a.order(set, ctx) {
+		block := set.Blocks[way]
+		if !block.IsLocked && !isPinned(block) {
+			return block
+		}
+	}
+
+	return nil
+}
+
+// order returns the ways of the lines of the list that ARC replaces from,
+// then of the other list, each from the least to the most recently used,
+// then the ways that no list tracks.
+func (a *ARCVictimFinder) order(set *Set, ctx *VictimContext) []int {
+	s := a.set(set.Blocks[0].SetID)
+	c := len(set.Blocks)
+
+	p := s.p
+	inB2 := false
+
+	if ctx != nil {
+		line := contextLine(ctx)
+		p = s.adaptedTarget(line, c)
+		inB2 = arcFindTag(s.b2, line) >= 0
+	}
+
+	first, second := s.t2, s.t1
+	if len(s.t1) > 0 && (len(s.t1) > p || inB2 && len(s.t1) == p) {
+		first, second = s.t1, s.t2
+	}
+
+	ways := make([]int, 0, c)
+	tracked := make([]bool, c)
+
+	for _, list := range [][]arcEntry{first, second} {
+		for _, e := range list {
+			ways = append(ways, e.way)
+			tracked[e.way] = true
+		}
+	}
+
+	for way := range set.Blocks {
+		if !tracked[way] {
+			ways = append(ways, way)
+		}
+	}
+
+	return ways
+}
+
+func (a *ARCVictimFinder) set(setID int) *arcSet {
+	for len(a.sets) <= setID {
+		a.sets = append(a.sets, &arcSet{})
+	}
+
+	return a.sets[setID]
+}
+
+// adaptedTarget returns the target size of T1 after a miss on the line.
+func (s *arcSet) adaptedTarget(line uint64, c int) int {
+	switch {
+	case arcFindTag(s.b1, line) >= 0:
+		delta := 1
+		if len(s.b2) > len(s.b1) {
+			delta = len(s.b2) / len(s.b1)
+		}
+
+		if s.p+delta > c {
+			return c
+		}
+
+		return s.p + delta
+	case arcFindTag(s.b2, line) >= 0:
+		delta := 1
+		if len(s.b1) > len(s.b2) {
+			delta = len(s.b1) / len(s.b2)
+		}
+
+		if s.p-delta < 0 {
+			return 0
+		}
+
+		return s.p - delta
+	default:
+		return s.p
+	}
+}
+
+// trimGhosts keeps T1 and B1 within c lines, and all four lists within 2c.
+func (s *arcSet) trimGhosts(c int) {
+	for len(s.b1) > 0 && len(s.t1)+len(s.b1) > c {
+		s.b1 = s.b1[1:]
+	}
+
+	for len(s.b1)+len(s.b2) > 0 &&
+		len(s.t1)+len(s.t2)+len(s.b1)+len(s.b2) > 2*c {
+		if len(s.b2) > 0 {
+			s.b2 = s.b2[1:]
+		} else {
+			s.b1 = s.b1[1:]
+		}
+	}
+}
+
+func arcFindWay(list []arcEntry, way int) int {
+	for i, e := range list {
+		if e.way == way {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func arcFindTag(list []uint64, tag uint64) int {
+	for i, t := range list {
+		if t == tag {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func arcRemoveTag(list []uint64, tag uint64) []uint64 {
+	i := arcFindTag(list, tag)
+
+	return append(list[:i], list[i+1:]...)
+}
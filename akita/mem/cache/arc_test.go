@@ -0,0 +1,88 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ARCVictimFinder", func() {
+	var (
+		vf *ARCVictimFinder
+		d  *DirectoryImpl
+	)
+
+	access := func(tag uint64) *Block {
+		if block := d.Lookup(0, tag); block != nil {
+			d.Visit(block)
+			return block
+		}
+
+		block := d.FindVictimWithContext(tag,
+			&VictimContext{Address: tag, CacheLineID: tag})
+		block.Tag = tag
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	sizes := func() []int {
+		t1, t2, b1, b2 := vf.ListSizes(0)
+		return []int{t1, t2, b1, b2}
+	}
+
+	const a, b, c, dd, e, f = 0x000, 0x040, 0x080, 0x0c0, 0x100, 0x140
+
+	BeforeEach(func() {
+		vf = NewARCVictimFinder()
+		d = NewDirectory(1, 4, 64, vf)
+
+		for _, tag := range []uint64{a, b, c, dd, a, b} {
+			access(tag)
+		}
+	})
+
+	It("should keep the lines seen twice in T2", func() {
+		Expect(sizes()).To(Equal([]int{2, 2, 0, 0}))
+		Expect(vf.Target(0)).To(BeZero())
+	})
+
+	It("should evict from T1 while it is above its target", func() {
+		wayOfC := d.Lookup(0, c).WayID
+
+		Expect(access(e).WayID).To(Equal(wayOfC))
+		Expect(sizes()).To(Equal([]int{2, 2, 1, 0}))
+	})
+
+	It("should grow the target of T1 on a B1 hit", func() {
+		access(e)
+		wayOfD := d.Lookup(0, dd).WayID
+
+		Expect(access(c).WayID).To(Equal(wayOfD))
+		Expect(vf.Target(0)).To(Equal(1))
+		Expect(sizes()).To(Equal([]int{1, 3, 1, 0}))
+	})
+
+	It("should shrink the target of T1 on a B2 hit", func() {
+		access(e)
+		access(c)
+		wayOfA := d.Lookup(0, a).WayID
+		Expect(access(f).WayID).To(Equal(wayOfA))
+		Expect(sizes()).To(Equal([]int{2, 2, 1, 1}))
+
+		wayOfE := d.Lookup(0, e).WayID
+		Expect(access(a).WayID).To(Equal(wayOfE))
+		Expect(vf.Target(0)).To(BeZero())
+		Expect(sizes()).To(Equal([]int{1, 3, 2, 0}))
+	})
+
+	It("should rank the preferred list first", func() {
+		set := &d.Sets[0]
+		victims := vf.FindVictims(set, nil, 4)
+
+		Expect(victims[0].Tag).To(Equal(uint64(c)))
+		Expect(victims[1].Tag).To(Equal(uint64(dd)))
+		Expect(victims[2].Tag).To(Equal(uint64(a)))
+		Expect(victims[3].Tag).To(Equal(uint64(b)))
+	})
+})
@@ -0,0 +1,152 @@
+package cache
+
+import "fmt"
+
+// A ReuseTrainer learns from the reuse outcome of cache lines. The cache
+// controller reports every hit and every eviction of a valid line.
+type ReuseTrainer interface {
+	TrainOnHitWithContext(ctx *VictimContext)
+	TrainOnEvictionWithContext(ctx *VictimContext)
+}
+
+// A BankedPerceptronConfig configures a BankedPerceptron.
+type BankedPerceptronConfig struct {
+	// Number of banks. The lines are interleaved across the banks.
+	NumBanks int
+
+	// Size of a cache line, used to find the bank of an address. Defaults
+	// to 64.
+	BlockSize int
+
+	// Every bank merges its weights with the other banks every
+	// MergeInterval predictions. Zero disables the automatic merges.
+	MergeInterval uint64
+
+	// How the weights of the banks are combined.
+	MergeStrategy MergeStrategy
+
+	// Creates the perceptron of a bank. Defaults to
+	// NewPerceptronVictimFinder.
+	NewBank func() *PerceptronVictimFinder
+}
+
+// A BankedPerceptron models the predictor of a multi-banked cache: every
+// bank trains its own perceptron on the lines it holds, and the banks
+// periodically merge their weights through a PerceptronBankGroup to benefit
+// from what the other banks learned.
+//
+// Line n belongs to bank n mod NumBanks. The bank of a set is found from its
+// set ID, which gives the same bank as long as the number of sets is a
+// multiple of the number of banks. Every bank may be driven by its own
+// goroutine, as long as a bank is never used by two goroutines at once.
+type BankedPerceptron struct {
+	config BankedPerceptronConfig
+	group  *PerceptronBankGroup
+}
+
+// NewBankedPerceptron creates the banks.
+func NewBankedPerceptron(config BankedPerceptronConfig) *BankedPerceptron {
+	if config.NumBanks <= 0 {
+		panic(fmt.Sprintf("number of banks %d must be positive",
+			config.NumBanks))
+	}
+
+	if config.BlockSize <= 0 {
+		config.BlockSize = 64
+	}
+
+	if config.NewBank == nil {
+		config.NewBank = NewPerceptronVictimFinder
+	}
+
+	b := &BankedPerceptron{
+		config: config,
+		group: NewPerceptronBankGroup(config.NumBanks, config.MergeInterval,
+			config.NewBank),
+	}
+	b.group.SetMergeStrategy(config.MergeStrategy)
+
+	return b
+}
+
+// Group returns the bank group that merges the weights.
+func (b *BankedPerceptron) Group() *PerceptronBankGroup {
+	return b.group
+}
+
+// Bank returns the perceptron of the bank.
+func (b *BankedPerceptron) Bank(i int) *PerceptronVictimFinder {
+	return b.group.Bank(i)
+}
+
+// eachPerceptron merges the banks, so that f sees the shared weights in every
+// bank. It must not run while the banks are in use.
+func (b *BankedPerceptron) eachPerceptron(
+	f func(p *PerceptronVictimFinder),
+) {
+	b.group.update(f)
+}
+
+// BankOf returns the bank of the line at the address.
+func (b *BankedPerceptron) BankOf(addr uint64) int {
+	line := addr / uint64(b.config.BlockSize)
+	return int(line % uint64(b.config.NumBanks))
+}
+
+func (b *BankedPerceptron) bankOfSet(set *Set) *PerceptronVictimFinder {
+	if len(set.Blocks) == 0 {
+		return b.group.Bank(0)
+	}
+
+	return b.group.Bank(set.Blocks[0].SetID % b.config.NumBanks)
+}
+
+func (b *BankedPerceptron) bankOfAddress(addr uint64) *PerceptronVictimFinder {
+	return b.group.Bank(b.BankOf(addr))
+}
+
+// FindVictim selects the victim with the bank of the set.
+func (b *BankedPerceptron) FindVictim(set *Set) *Block {
+	return b.bankOfSet(set).FindVictim(set)
+}
+
+// FindVictimWithContext selects the victim with the bank of the set.
+func (b *BankedPerceptron) FindVictimWithContext(
+	set *Set,
+	context *VictimContext,
+) *Block {
+	return b.bankOfSet(set).FindVictimWithContext(set, context)
+}
+
+// TrainOnHitWithContext trains the bank of the address on a hit.
+func (b *BankedPerceptron) TrainOnHitWithContext(ctx *VictimContext) {
+	b.bankOfAddress(ctx.Address).TrainOnHitWithContext(ctx)
+}
+
+// TrainOnEvictionWithContext trains the bank of the address on an eviction.
+func (b *BankedPerceptron) TrainOnEvictionWithContext(ctx *VictimContext) {
+	b.bankOfAddress(ctx.Address).TrainOnEvictionWithContext(ctx)
+}
+
+// PredictDead asks the bank of the address.
+func (b *BankedPerceptron) PredictDead(
+	addr uint64,
+	ctx *VictimContext,
+) (dead bool, confidence int32) {
+	return b.bankOfAddress(addr).PredictDead(addr, ctx)
+}
+
+// AdviseInsertion asks the bank of the address of the context.
+func (b *BankedPerceptron) AdviseInsertion(
+	ctx *VictimContext,
+) InsertionPriority {
+	return b.bankOfAddress(ctx.Address).AdviseInsertion(ctx)
+}
+
+// MergeAll merges every bank in turn. It must not run while the banks are in
+// use.
+func (b *BankedPerceptron) MergeAll() {
+	for i := 0; i < b.group.NumBanks(); i++ {
+		b.group.Merge(i)
+	}
+}
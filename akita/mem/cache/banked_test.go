@@ -0,0 +1,84 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BankedPerceptron", func() {
+	newBank := func() *PerceptronVictimFinder {
+		p := NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		p.SetHashedTables(false)
+
+		return p
+	}
+
+	It("should implement the predictor interfaces", func() {
+		b := NewBankedPerceptron(BankedPerceptronConfig{NumBanks: 2})
+
+		var (
+			_ VictimFinder       = b
+			_ ReuseTrainer       = b
+			_ DeadBlockPredictor = b
+			_ InsertionAdvisor   = b
+		)
+	})
+
+	It("should train the bank of the line", func() {
+		b := NewBankedPerceptron(BankedPerceptronConfig{
+			NumBanks: 2,
+			NewBank:  newBank,
+		})
+
+		b.TrainOnEvictionWithContext(&VictimContext{Address: 0x10040})
+
+		Expect(b.BankOf(0x10040)).To(Equal(1))
+		Expect(b.Bank(1).Weights()[6]).To(Equal(int32(2)))
+		Expect(b.Bank(0).Weights()).To(Equal([MaxPerceptronWeights]int32{}))
+	})
+
+	It("should predict with the bank of the set in a directory", func() {
+		b := NewBankedPerceptron(BankedPerceptronConfig{
+			NumBanks:      4,
+			MergeInterval: 1,
+			NewBank:       newBank,
+		})
+		d := NewDirectory(8, 2, 64, b)
+
+		d.FindVictimWithContext(0x80, &VictimContext{Address: 0x80})
+
+		predictions, _, _ := b.Bank(2).GetStats()
+		Expect(predictions).To(Equal(int64(1)))
+		Expect(b.Group().Merges()).To(Equal(uint64(1)))
+	})
+
+	It("should sum the weight changes of the banks", func() {
+		b := NewBankedPerceptron(BankedPerceptronConfig{
+			NumBanks: 2,
+			NewBank:  newBank,
+		})
+
+		b.Bank(0).TrainOnEviction(0x10001)
+		b.Bank(1).TrainOnEviction(0x10001)
+		b.MergeAll()
+
+		Expect(b.Group().Weights()[0]).To(Equal(int32(4)))
+	})
+
+	It("should average the weights of the banks", func() {
+		b := NewBankedPerceptron(BankedPerceptronConfig{
+			NumBanks:      2,
+			MergeStrategy: MergeAverage,
+			NewBank:       newBank,
+		})
+
+		b.Bank(0).TrainOnEviction(0x10001)
+		b.Bank(0).TrainOnEviction(0x10001)
+		b.MergeAll()
+
+		Expect(b.Group().Weights()[0]).To(Equal(int32(2)))
+		Expect(b.Bank(0).Weights()[0]).To(Equal(int32(2)))
+		Expect(b.Bank(1).Weights()[0]).To(Equal(int32(2)))
+	})
+})
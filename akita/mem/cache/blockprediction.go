@@ -0,0 +1,90 @@
+package cache
+
+import "sort"
+
+// SetBlockPredictions makes the directory store a reuse prediction in every
+// block it visits. The prediction is made with the context of the fill of the
+// block, at the fill and again at every hit, so that it follows the training.
+// It requires a victim finder that is a DeadBlockPredictor. When the
+// perceptron confidently predicts no reuse for an access, it evicts the
+// blocks marked dead first.
+func (d *DirectoryImpl) SetBlockPredictions(enabled bool) {
+	d.blockPredictions = enabled
+}
+
+// BlockPredictions tells if the directory stores per-block predictions.
+func (d *DirectoryImpl) BlockPredictions() bool {
+	return d.blockPredictions
+}
+
+// predictBlock updates the reuse prediction of the block. Without per-block
+// predictions, the block is left unmarked.
+func (d *DirectoryImpl) predictBlock(block *Block) {
+	block.PredictedDead = false
+	block.DeadConfidence = 0
+
+	if !d.blockPredictions {
+		return
+	}
+
+	p, ok := d.victimFinder.(DeadBlockPredictor)
+	if !ok {
+		return
+	}
+
+	block.PredictedDead, block.DeadConfidence =
+		p.PredictDead(block.Tag, residentContext(block))
+}
+
+// markedDeadBefore tells if block a is a better victim than block b among the
+// blocks marked dead: the more confident prediction first and, if the
+// perceptron is cost aware, the clean block on a tie.
+func (p *PerceptronVictimFinder) markedDeadBefore(a, b *Block) bool {
+	if a.DeadConfidence != b.DeadConfidence {
+		return a.DeadConfidence > b.DeadConfidence
+	}
+
+	return p.costAware && !a.IsDirty && b.IsDirty
+}
+
+// markedDeadVictim returns the best unlocked valid block marked dead, or nil
+// if the set has none.
+func (p *PerceptronVictimFinder) markedDeadVictim(set *Set) *Block {
+	var victim *Block
+
+	for _, block := range set.Blocks {
+		if !block.IsValid || block.IsLocked || !block.PredictedDead {
+			continue
+		}
+
+		if victim == nil || p.markedDeadBefore(block, victim) {
+			victim = block
+		}
+	}
+
+	return victim
+}
+
+// markedDeadOrder returns the ways of the valid blocks marked dead, the best
+// victim first.
+func (p *PerceptronVictimFinder) markedDeadOrder(set *Set) []int {
+	var ways []int
+
+	for i, block := range set.Blocks {
+		if block.IsValid && block.PredictedDead {
+			ways = append(ways, i)
+		}
+	}
+
+	sort.SliceStable(ways, func(i, j int) bool {
+		return p.markedDeadBefore(set.Blocks[ways[i]], set.Blocks[ways[j]])
+	})
+
+	return ways
+}
+
+// MarkedDeadVictims returns the number of victims that were selected because
+// their block was marked dead; see DirectoryImpl.SetBlockPredictions.
+func (p *PerceptronVictimFinder) MarkedDeadVictims() uint64 {
+	return p.markedDeadVictims
+}
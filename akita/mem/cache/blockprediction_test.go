@@ -0,0 +1,70 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Per-block predictions", func() {
+	var (
+		p          *PerceptronVictimFinder
+		d          *DirectoryImpl
+		dead, live uint64
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		d = NewDirectory(1, 4, 64, p)
+		d.SetBlockPredictions(true)
+
+		dead = 0x10001 << p.featureShift
+		live = 0x20002 << p.featureShift
+
+		for i := 0; i < 32; i++ {
+			p.TrainOnEviction(dead)
+			p.TrainOnHit(live)
+		}
+
+		for i, block := range d.Sets[0].Blocks {
+			block.Tag = live
+			if i == 2 {
+				block.Tag = dead
+			}
+
+			block.IsValid = true
+			d.Visit(block)
+		}
+	})
+
+	It("should mark the blocks at every visit", func() {
+		blocks := d.Sets[0].Blocks
+
+		Expect(blocks[2].PredictedDead).To(BeTrue())
+		Expect(blocks[2].DeadConfidence).To(BeNumerically(">=", p.theta))
+		Expect(blocks[0].PredictedDead).To(BeFalse())
+
+		d.SetBlockPredictions(false)
+		d.Visit(blocks[2])
+
+		Expect(blocks[2].PredictedDead).To(BeFalse())
+	})
+
+	It("should evict a block marked dead first", func() {
+		victim := p.FindVictimWithContext(&d.Sets[0], &VictimContext{Address: dead})
+
+		Expect(victim).To(BeIdenticalTo(d.Sets[0].Blocks[2]))
+		Expect(p.MarkedDeadVictims()).To(Equal(uint64(1)))
+		Expect(p.FindVictims(&d.Sets[0], &VictimContext{Address: dead}, 2)).
+			To(Equal([]*Block{d.Sets[0].Blocks[2], d.Sets[0].Blocks[0]}))
+	})
+
+	It("should not evict a locked block marked dead", func() {
+		d.Sets[0].Blocks[2].IsLocked = true
+
+		victim := p.FindVictimWithContext(&d.Sets[0], &VictimContext{Address: dead})
+
+		Expect(victim).To(BeIdenticalTo(d.Sets[0].Blocks[0]))
+		Expect(p.MarkedDeadVictims()).To(BeZero())
+	})
+})
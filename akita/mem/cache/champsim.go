@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	champSimRunsRE = regexp.MustCompile(`^CPU (\d+) runs (\S+)`)
+	champSimIPCRE  = regexp.MustCompile(
+		`CPU (\d+) (?:cumulative IPC: \S+ )?instructions: (\d+) cycles: (\d+)`)
+	champSimCacheRE = regexp.MustCompile(
+		`^(\S+) TOTAL\s+ACCESS:\s+(\d+)\s+HIT:\s+(\d+)\s+MISS:\s+(\d+)`)
+	champSimCPUPrefixRE = regexp.MustCompile(`^cpu(\d+)(?:_|->)(.+)$`)
+)
+
+// champSimROIHeader starts the section that holds the final statistics.
+const champSimROIHeader = "Region of Interest Statistics"
+
+type champSimCPU struct {
+	instructions uint64
+	cycles       uint64
+}
+
+// ParseChampSimResults reads the standard output of a ChampSim run and
+// returns one SimulationResult per cache. The TOTAL line of every cache is
+// used; if the output has a region-of-interest section, only the statistics
+// in that section count. Caches named with a "cpuN_" or "cpuN->" prefix are
+// attributed to CPU N; other caches are shared and get CPU -1, the
+// instructions of all CPUs, and the cycles of the slowest CPU. The workload
+// is the name of the first trace; the policy is not in the output and must
+// be given.
+func ParseChampSimResults(
+	r io.Reader,
+	policy string,
+) ([]SimulationResult, error) {
+	var (
+		workload string
+		cpus     = make(map[int]*champSimCPU)
+		caches   []SimulationResult
+	)
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+
+		if strings.Contains(line, champSimROIHeader) {
+			caches = caches[:0]
+			continue
+		}
+
+		if m := champSimRunsRE.FindStringSubmatch(line); m != nil {
+			if workload == "" {
+				workload = champSimTraceName(m[2])
+			}
+
+			continue
+		}
+
+		if m := champSimIPCRE.FindStringSubmatch(line); m != nil {
+			cpu, _ := strconv.Atoi(m[1])
+			c := &champSimCPU{}
+			c.instructions, _ = strconv.ParseUint(m[2], 10, 64)
+			c.cycles, _ = strconv.ParseUint(m[3], 10, 64)
+			cpus[cpu] = c
+
+			continue
+		}
+
+		if m := champSimCacheRE.FindStringSubmatch(line); m != nil {
+			caches = setChampSimCache(caches, parseChampSimCache(m))
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(caches) == 0 {
+		return nil, fmt.Errorf("no cache statistics in ChampSim output")
+	}
+
+	for i := range caches {
+		c := &caches[i]
+		c.Source = "champsim"
+		c.Workload = workload
+		c.Policy = policy
+		fillChampSimCPU(c, cpus)
+	}
+
+	sort.SliceStable(caches, func(i, j int) bool {
+		if caches[i].CPU != caches[j].CPU {
+			return caches[i].CPU < caches[j].CPU
+		}
+
+		return caches[i].Cache < caches[j].Cache
+	})
+
+	return caches, nil
+}
+
+func parseChampSimCache(m []string) SimulationResult {
+	r := SimulationResult{Cache: m[1], CPU: -1}
+
+	if p := champSimCPUPrefixRE.FindStringSubmatch(m[1]); p != nil {
+		r.CPU, _ = strconv.Atoi(p[1])
+		r.Cache = p[2]
+	}
+
+	r.Accesses, _ = strconv.ParseUint(m[2], 10, 64)
+	r.Hits, _ = strconv.ParseUint(m[3], 10, 64)
+	r.Misses, _ = strconv.ParseUint(m[4], 10, 64)
+
+	return r
+}
+
+// setChampSimCache replaces an earlier result of the same cache, so that the
+// last statistics printed win.
+func setChampSimCache(
+	caches []SimulationResult,
+	r SimulationResult,
+) []SimulationResult {
+	for i := range caches {
+		if caches[i].Cache == r.Cache && caches[i].CPU == r.CPU {
+			caches[i] = r
+			return caches
+		}
+	}
+
+	return append(caches, r)
+}
+
+func fillChampSimCPU(r *SimulationResult, cpus map[int]*champSimCPU) {
+	if r.CPU >= 0 {
+		if c, ok := cpus[r.CPU]; ok {
+			r.Instructions = c.instructions
+			r.Cycles = c.cycles
+		}
+
+		return
+	}
+
+	for _, c := range cpus {
+		r.Instructions += c.instructions
+		if c.cycles > r.Cycles {
+			r.Cycles = c.cycles
+		}
+	}
+}
+
+// champSimTraceName strips the directory and the trace extensions.
+func champSimTraceName(path string) string {
+	name := filepath.Base(path)
+	for _, ext := range []string{".xz", ".gz", ".champsimtrace", ".trace"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	return name
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const champSimOutput = `
+*** ChampSim Multicore Out-of-Order Simulator ***
+CPU 0 runs /traces/600.perlbench_s-210B.champsimtrace.xz
+
+Warmup finished CPU 0 instructions: 1000 cycles: 900 cumulative IPC: 1.1
+LLC TOTAL     ACCESS:        10  HIT:         5  MISS:         5
+Finished CPU 0 instructions: 100000 cycles: 80000 cumulative IPC: 1.25
+
+Region of Interest Statistics
+
+CPU 0 cumulative IPC: 1.25 instructions: 100000 cycles: 80000
+cpu0_L1D TOTAL     ACCESS:      40000  HIT:      36000  MISS:       4000
+cpu0_L2C TOTAL     ACCESS:       4000  HIT:       3000  MISS:       1000
+LLC TOTAL     ACCESS:       1000  HIT:        600  MISS:        400
+LLC LOAD      ACCESS:        800  HIT:        500  MISS:        300
+`
+
+var _ = Describe("ChampSim importer", func() {
+	It("should normalize the region-of-interest statistics", func() {
+		results, err := ParseChampSimResults(
+			strings.NewReader(champSimOutput), "hawkeye")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(3))
+
+		llc := results[0]
+		Expect(llc.Cache).To(Equal("LLC"))
+		Expect(llc.CPU).To(Equal(-1))
+		Expect(llc.Source).To(Equal("champsim"))
+		Expect(llc.Workload).To(Equal("600.perlbench_s-210B"))
+		Expect(llc.Policy).To(Equal("hawkeye"))
+		Expect(llc.Accesses).To(Equal(uint64(1000)))
+		Expect(llc.MissRate()).To(BeNumerically("~", 0.4))
+		Expect(llc.MPKI()).To(BeNumerically("~", 4))
+		Expect(llc.IPC()).To(BeNumerically("~", 1.25))
+
+		Expect(results[1].Cache).To(Equal("L1D"))
+		Expect(results[1].CPU).To(Equal(0))
+		Expect(results[2].Cache).To(Equal("L2C"))
+		Expect(results[2].Misses).To(Equal(uint64(1000)))
+	})
+
+	It("should fail without cache statistics", func() {
+		_, err := ParseChampSimResults(strings.NewReader("CPU 0 runs x"), "")
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should write results side by side with shadow results", func() {
+		imported, err := ParseChampSimResults(
+			strings.NewReader(champSimOutput), "lru")
+		Expect(err).NotTo(HaveOccurred())
+
+		c := NewMissRateCurve(64,
+			[]ShadowPolicy{{Name: "lru", New: func() VictimFinder {
+				return NewLRUVictimFinder()
+			}}},
+			[]ShadowGeometry{{NumSets: 2, NumWays: 2}})
+		c.Access(1, 0)
+		c.Access(1, 0)
+
+		buf := new(bytes.Buffer)
+		Expect(WriteSimulationResultsCSV(
+			buf, append(imported, c.Results("toy")...))).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		Expect(lines).To(HaveLen(5))
+		Expect(lines[4]).
+			To(Equal("akita,toy,lru,2x2,-1,2,1,1,0,0,0.500000,0.0000,0.0000"))
+	})
+})
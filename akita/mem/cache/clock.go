@@ -0,0 +1,96 @@
+package cache
+
+// An AccessObserver is a VictimFinder that keeps its own replacement state.
+// The directory calls Touch every time a block is visited.
+type AccessObserver interface {
+	Touch(set *Set, block *Block)
+}
+
+// A ContextAccessObserver is an AccessObserver that is also told the context
+// of a hit, such as the PC of the instruction that hit, when the controller
+// knows it; see DirectoryImpl.VisitWithContext.
+type ContextAccessObserver interface {
+	AccessObserver
+	TouchWithContext(set *Set, block *Block, ctx *VictimContext)
+}
+
+// ClockVictimFinder implements the Clock (second-chance) policy on the
+// reference bits maintained by the directory. Its only other state is a hand
+// per set, so it scales to fully associative structures with hundreds of
+// ways, where the PseudoLRU word is too small. A victim search advances the
+// hand over referenced blocks, clearing their bits, and stops at the first
+// block that is invalid or not referenced, which takes amortized constant
+// time.
+type ClockVictimFinder struct {
+}
+
+// NewClockVictimFinder returns a new Clock victim finder.
+func NewClockVictimFinder() *ClockVictimFinder {
+	return &ClockVictimFinder{}
+}
+
+// FindVictim advances the clock hand to the next block that is invalid or
+// not referenced. Locked blocks are skipped. It returns nil if every block is
+// locked.
+func (c *ClockVictimFinder) FindVictim(set *Set) *Block {
+	numWays := len(set.Blocks)
+	if numWays == 0 {
+		return nil
+	}
+
+	// Two rounds are enough: the first one clears all the reference bits.
+	for i := 0; i < 2*numWays; i++ {
+		block := set.Blocks[set.ClockHand%numWays]
+		set.ClockHand = (set.ClockHand + 1) % numWays
+
+		if block.IsLocked {
+			continue
+		}
+
+		if !block.IsValid || !block.Referenced {
+			return block
+		}
+
+		block.Referenced = false
+	}
+
+	// Every block is locked.
+	return nil
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (c *ClockVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return c.FindVictim(set)
+}
+
+// FindVictims returns up to n candidates in the order in which the clock hand
+// would select them, without moving the hand.
+func (c *ClockVictimFinder) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	numWays := len(set.Blocks)
+	ways := make([]int, 0, numWays)
+
+	for _, referenced := range []bool{false, true} {
+		for i := 0; i < numWays; i++ {
+			way := (set.ClockHand + i) % numWays
+			if set.Blocks[way].Referenced == referenced {
+				ways = append(ways, way)
+			}
+		}
+	}
+
+	return rankCandidates(set, ways, n)
+}
+
+// NewFullyAssociativeDirectory returns a directory with a single set of
+// numBlocks ways that uses the Clock policy, suitable for victim caches, small
+// TLBs, and other fully associative structures.
+func NewFullyAssociativeDirectory(numBlocks, blockSize int) *DirectoryImpl {
+	return NewDirectory(1, numBlocks, blockSize, NewClockVictimFinder())
+}
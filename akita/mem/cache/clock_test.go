@@ -0,0 +1,75 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClockVictimFinder", func() {
+	var (
+		d *DirectoryImpl
+	)
+
+	BeforeEach(func() {
+		d = NewFullyAssociativeDirectory(200, 64)
+	})
+
+	access := func(addr uint64) *Block {
+		block := d.Lookup(0, addr)
+		if block == nil {
+			block = d.FindVictim(addr)
+			block.Tag = addr
+			block.IsValid = true
+		}
+
+		d.Visit(block)
+
+		return block
+	}
+
+	It("should fill invalid blocks first", func() {
+		for i := uint64(0); i < 200; i++ {
+			access(i * 64)
+		}
+
+		for i := uint64(0); i < 200; i++ {
+			Expect(d.Lookup(0, i*64)).NotTo(BeNil())
+		}
+	})
+
+	It("should give referenced blocks a second chance", func() {
+		for i := uint64(0); i < 200; i++ {
+			access(i * 64)
+		}
+
+		// The first victim search clears all the bits and selects way 0.
+		access(200 * 64)
+		access(64)
+
+		victim := d.FindVictim(201 * 64)
+
+		Expect(victim.WayID).To(Equal(2))
+	})
+
+	It("should skip locked blocks", func() {
+		for i := uint64(0); i < 200; i++ {
+			access(i * 64)
+		}
+		d.Sets[0].Blocks[0].IsLocked = true
+
+		Expect(d.FindVictim(200 * 64).WayID).To(Equal(1))
+	})
+
+	It("should rank unreferenced blocks first", func() {
+		for i := uint64(0); i < 4; i++ {
+			access(i * 64)
+		}
+		for _, b := range d.Sets[0].Blocks[4:] {
+			b.IsValid = true
+		}
+
+		victims := NewClockVictimFinder().FindVictims(&d.Sets[0], nil, 3)
+
+		Expect(victims[0].WayID).To(Equal(4))
+	})
+})
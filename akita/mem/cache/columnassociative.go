@@ -0,0 +1,139 @@
+package cache
+
+import "github.com/sarchlab/akita/v4/mem/vm"
+
+// ColumnAssociativeStats counts the lookups of a ColumnAssociativeDirectory.
+type ColumnAssociativeStats struct {
+	PrimaryHits uint64
+	RehashHits  uint64
+	Swaps       uint64
+}
+
+// A ColumnAssociativeDirectory is a direct-mapped directory in which a line
+// can also be placed at a rehash location, the block whose index differs in
+// the most significant bit (Agarwal and Pudar, ISCA 1993).
+//
+// A miss replaces the primary block if it holds a line that is out of place,
+// and the rehash block otherwise. A hit at the rehash location swaps the two
+// blocks so that the next access hits the primary location. The swap only
+// exchanges tags and cache addresses, so no data is moved, and blocks that
+// are locked or being read are never swapped.
+type ColumnAssociativeDirectory struct {
+	*DirectoryImpl
+
+	stats ColumnAssociativeStats
+}
+
+// NewColumnAssociativeDirectory returns a column-associative directory with
+// the given number of blocks, which must be a power of two.
+func NewColumnAssociativeDirectory(
+	numBlocks, blockSize int,
+) *ColumnAssociativeDirectory {
+	if numBlocks < 2 || numBlocks&(numBlocks-1) != 0 {
+		panic("column-associative directory needs a power-of-two block count")
+	}
+
+	return &ColumnAssociativeDirectory{
+		DirectoryImpl: NewDirectory(
+			numBlocks, 1, blockSize, NewLRUVictimFinder()),
+	}
+}
+
+// Stats returns the lookup statistics.
+func (d *ColumnAssociativeDirectory) Stats() ColumnAssociativeStats {
+	return d.stats
+}
+
+// locations returns the primary and the rehash block of the address.
+func (d *ColumnAssociativeDirectory) locations(addr uint64) (*Block, *Block) {
+	primary := d.primaryIndex(addr)
+	rehash := primary ^ (d.NumSets / 2)
+
+	return d.Sets[primary].Blocks[0], d.Sets[rehash].Blocks[0]
+}
+
+func (d *ColumnAssociativeDirectory) primaryIndex(addr uint64) int {
+	if d.AddrConverter != nil {
+		addr = d.AddrConverter.ConvertExternalToInternal(addr)
+	}
+
+	return int(addr / uint64(d.BlockSize) % uint64(d.NumSets))
+}
+
+func (d *ColumnAssociativeDirectory) outOfPlace(block *Block) bool {
+	return block.IsValid && d.primaryIndex(block.Tag) != block.SetID
+}
+
+func (d *ColumnAssociativeDirectory) swappable(block *Block) bool {
+	return !block.IsLocked && block.ReadCount == 0
+}
+
+// Lookup checks the primary and then the rehash location of the address. A
+// hit at the rehash location swaps the two blocks if possible.
+func (d *ColumnAssociativeDirectory) Lookup(pid vm.PID, addr uint64) *Block {
+	primary, rehash := d.locations(addr)
+
+	if d.tagMatches(primary, pid, addr) {
+		d.stats.PrimaryHits++
+		return primary
+	}
+
+	if !d.tagMatches(rehash, pid, addr) {
+		return nil
+	}
+
+	d.stats.RehashHits++
+
+	if !d.swappable(primary) || !d.swappable(rehash) {
+		return rehash
+	}
+
+	swapLines(primary, rehash)
+	d.stats.Swaps++
+
+	return primary
+}
+
+// FindVictim returns the block that the line at the address should replace,
+// or nil if both locations are locked.
+func (d *ColumnAssociativeDirectory) FindVictim(addr uint64) *Block {
+	primary, rehash := d.locations(addr)
+
+	var block *Block
+
+	switch {
+	case primary.IsLocked && rehash.IsLocked:
+		return nil
+	case primary.IsLocked:
+		block = rehash
+	case !primary.IsValid || d.outOfPlace(primary) || rehash.IsLocked:
+		block = primary
+	default:
+		block = rehash
+	}
+
+	d.pendingFills[block.SetID] = block
+
+	return block
+}
+
+// FindVictimWithContext returns the same victim as FindVictim. The context is
+// not used.
+func (d *ColumnAssociativeDirectory) FindVictimWithContext(
+	addr uint64,
+	_ *VictimContext,
+) *Block {
+	return d.FindVictim(addr)
+}
+
+// swapLines exchanges the lines held by the two blocks together with their
+// cache addresses, so that the data does not move.
+func swapLines(a, b *Block) {
+	a.PID, b.PID = b.PID, a.PID
+	a.Tag, b.Tag = b.Tag, a.Tag
+	a.IsValid, b.IsValid = b.IsValid, a.IsValid
+	a.IsDirty, b.IsDirty = b.IsDirty, a.IsDirty
+	a.DirtyMask, b.DirtyMask = b.DirtyMask, a.DirtyMask
+	a.HitCount, b.HitCount = b.HitCount, a.HitCount
+	a.CacheAddress, b.CacheAddress = b.CacheAddress, a.CacheAddress
+}
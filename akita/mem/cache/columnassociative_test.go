@@ -0,0 +1,78 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ColumnAssociativeDirectory", func() {
+	var (
+		d *ColumnAssociativeDirectory
+	)
+
+	BeforeEach(func() {
+		d = NewColumnAssociativeDirectory(8, 64)
+	})
+
+	access := func(addr uint64) *Block {
+		block := d.Lookup(0, addr)
+		if block == nil {
+			block = d.FindVictim(addr)
+			block.Tag = addr
+			block.IsValid = true
+		}
+
+		d.Visit(block)
+
+		return block
+	}
+
+	It("should fill the primary location first", func() {
+		Expect(access(0x40).SetID).To(Equal(1))
+	})
+
+	It("should keep two conflicting lines", func() {
+		access(0x40)
+		access(0x240)
+
+		Expect(d.Lookup(0, 0x40)).NotTo(BeNil())
+		Expect(d.Lookup(0, 0x240)).NotTo(BeNil())
+	})
+
+	It("should swap on a rehash hit", func() {
+		access(0x40)
+		b := access(0x240)
+		b.IsDirty = true
+		cacheAddr := b.CacheAddress
+
+		block := d.Lookup(0, 0x240)
+
+		Expect(block.SetID).To(Equal(1))
+		Expect(block.CacheAddress).To(Equal(cacheAddr))
+		Expect(block.IsDirty).To(BeTrue())
+		Expect(d.Sets[5].Blocks[0].Tag).To(Equal(uint64(0x40)))
+		Expect(d.Stats().Swaps).To(Equal(uint64(1)))
+	})
+
+	It("should replace an out-of-place line first", func() {
+		access(0x40)
+		access(0x240)
+		access(0x140)
+
+		Expect(d.Lookup(0, 0x40)).NotTo(BeNil())
+		Expect(d.Lookup(0, 0x240)).To(BeNil())
+		Expect(d.Lookup(0, 0x140).SetID).To(Equal(5))
+	})
+
+	It("should not swap locked blocks", func() {
+		access(0x40).IsLocked = true
+		access(0x240)
+
+		Expect(d.Lookup(0, 0x240).SetID).To(Equal(5))
+		Expect(d.Stats().Swaps).To(BeZero())
+	})
+
+	It("should reject block counts that are not powers of two", func() {
+		Expect(func() { NewColumnAssociativeDirectory(6, 64) }).To(Panic())
+	})
+})
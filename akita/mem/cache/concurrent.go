@@ -0,0 +1,375 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// A SyncDirectory makes a directory safe to use from several goroutines, as
+// in a multi-banked cache model whose banks run in parallel. Every method
+// holds a lock while it runs. The blocks returned by the directory are
+// shared, so the callers must still agree on who updates a block; GetSets
+// returns the sets without synchronization.
+type SyncDirectory struct {
+	mu        sync.Mutex
+	directory Directory
+}
+
+// NewSyncDirectory wraps the directory.
+func NewSyncDirectory(d Directory) *SyncDirectory {
+	return &SyncDirectory{directory: d}
+}
+
+// Unwrap returns the wrapped directory.
+func (d *SyncDirectory) Unwrap() Directory {
+	return d.directory
+}
+
+// Lookup finds the block of the address, or returns nil on a miss.
+func (d *SyncDirectory) Lookup(pid vm.PID, address uint64) *Block {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.directory.Lookup(pid, address)
+}
+
+// FindVictim returns the block to fill with the address.
+func (d *SyncDirectory) FindVictim(address uint64) *Block {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.directory.FindVictim(address)
+}
+
+// FindVictimWithContext returns the block to fill with the address.
+func (d *SyncDirectory) FindVictimWithContext(
+	address uint64,
+	context *VictimContext,
+) *Block {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.directory.FindVictimWithContext(address, context)
+}
+
+// Visit records an access to the block.
+func (d *SyncDirectory) Visit(block *Block) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.directory.Visit(block)
+}
+
+// TotalSize returns the capacity of the directory in bytes.
+func (d *SyncDirectory) TotalSize() uint64 {
+	return d.directory.TotalSize()
+}
+
+// WayAssociativity returns the number of ways per set.
+func (d *SyncDirectory) WayAssociativity() int {
+	return d.directory.WayAssociativity()
+}
+
+// GetSets returns the sets of the wrapped directory.
+func (d *SyncDirectory) GetSets() []Set {
+	return d.directory.GetSets()
+}
+
+// GetVictimFinder returns the victim finder of the wrapped directory. Using
+// it directly is not synchronized.
+func (d *SyncDirectory) GetVictimFinder() VictimFinder {
+	return d.directory.GetVictimFinder()
+}
+
+// Reset invalidates all the blocks.
+func (d *SyncDirectory) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.directory.Reset()
+}
+
+// A MergeStrategy selects how a PerceptronBankGroup combines the weights of
+// its banks.
+type MergeStrategy int
+
+// Merge strategies.
+const (
+	// The shared weights accumulate the weight changes of every bank, so
+	// that the banks learn as fast as one predictor that sees all the
+	// outcomes.
+	MergeSum MergeStrategy = iota
+
+	// The shared weights are the average of the weights last published by
+	// every bank, which damps the banks that see unusual traffic.
+	MergeAverage
+)
+
+// perceptronBank is the merge state of one bank of a PerceptronBankGroup.
+// Only the goroutine of the bank touches it, except during a merge.
+type perceptronBank struct {
+	predictor   *PerceptronVictimFinder
+	baseWeights [MaxPerceptronWeights]int32
+	baseTables  [][PerceptronTableSize]int32
+	predictions uint64
+
+	// The weights published by the last merge of the bank; MergeAverage
+	published       [MaxPerceptronWeights]int32
+	publishedTables [][PerceptronTableSize]int32
+}
+
+// A PerceptronBankGroup gives every bank of a multi-banked cache its own
+// perceptron, so that the banks can run in parallel without sharing mutable
+// state. The banks learn together by merging their weights: a merge combines
+// the weights of a bank into the shared weights according to the merge
+// strategy, and loads the result into the bank.
+//
+// A bank merges itself every mergeInterval predictions, from the goroutine
+// that uses it. Merge can also be called explicitly from that goroutine.
+// Per-PID weights cannot be merged.
+type PerceptronBankGroup struct {
+	mu            sync.Mutex
+	banks         []perceptronBank
+	weights       [MaxPerceptronWeights]int32
+	tables        [][PerceptronTableSize]int32
+	mergeInterval uint64
+	strategy      MergeStrategy
+	merges        uint64
+}
+
+// NewPerceptronBankGroup creates numBanks perceptrons with newBank. A merge
+// interval of 0 disables the automatic merges. The banks must have the same
+// weight configuration.
+func NewPerceptronBankGroup(
+	numBanks int,
+	mergeInterval uint64,
+	newBank func() *PerceptronVictimFinder,
+) *PerceptronBankGroup {
+	if numBanks <= 0 {
+		panic(fmt.Sprintf("number of banks %d must be positive", numBanks))
+	}
+
+	g := &PerceptronBankGroup{
+		banks:         make([]perceptronBank, numBanks),
+		mergeInterval: mergeInterval,
+	}
+
+	for i := range g.banks {
+		p := newBank()
+		if i > 0 && p.weightConfig != g.banks[0].predictor.weightConfig {
+			panic("the banks have different weight configurations")
+		}
+
+		p.bankGroup = g
+		p.bankID = i
+		g.banks[i].predictor = p
+	}
+
+	// Start every bank from the weights of the first one.
+	first := g.banks[0].predictor
+	g.weights = first.weights
+	g.tables = append([][PerceptronTableSize]int32(nil), first.tables...)
+
+	for i := range g.banks {
+		g.load(i)
+		g.banks[i].published = g.weights
+		g.banks[i].publishedTables = append(
+			[][PerceptronTableSize]int32(nil), g.tables...)
+	}
+
+	return g
+}
+
+// SetMergeStrategy selects how the weights of the banks are combined. The
+// default is MergeSum. It must be called before the banks are used.
+func (g *PerceptronBankGroup) SetMergeStrategy(s MergeStrategy) {
+	g.strategy = s
+}
+
+// MergeStrategy returns the merge strategy.
+func (g *PerceptronBankGroup) MergeStrategy() MergeStrategy {
+	return g.strategy
+}
+
+// Bank returns the perceptron of the bank.
+func (g *PerceptronBankGroup) Bank(i int) *PerceptronVictimFinder {
+	return g.banks[i].predictor
+}
+
+// NumBanks returns the number of banks.
+func (g *PerceptronBankGroup) NumBanks() int {
+	return len(g.banks)
+}
+
+// Merges returns the number of merges so far.
+func (g *PerceptronBankGroup) Merges() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.merges
+}
+
+// Weights returns a copy of the shared weights.
+func (g *PerceptronBankGroup) Weights() [MaxPerceptronWeights]int32 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.weights
+}
+
+// Merge publishes the weight changes of the bank and loads the shared
+// weights into it. It must be called from the goroutine that uses the bank.
+func (g *PerceptronBankGroup) Merge(i int) {
+	b := &g.banks[i]
+	p := b.predictor
+
+	if p.perPID != nil {
+		panic("per-PID perceptron weights cannot be merged")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch g.strategy {
+	case MergeAverage:
+		g.mergeAverage(b)
+	default:
+		g.mergeSum(b)
+	}
+
+	g.load(i)
+	g.merges++
+}
+
+// mergeSum adds the weight changes of the bank since its previous merge to
+// the shared weights, saturating at the weight range.
+func (g *PerceptronBankGroup) mergeSum(b *perceptronBank) {
+	p := b.predictor
+
+	for j := range g.weights {
+		g.weights[j] = saturatingAdd(p.weightConfig, g.weights[j],
+			p.weights[j]-b.baseWeights[j])
+	}
+
+	g.growTables(len(p.tables))
+
+	for t := range p.tables {
+		var base [PerceptronTableSize]int32
+		if t < len(b.baseTables) {
+			base = b.baseTables[t]
+		}
+
+		for j := range p.tables[t] {
+			g.tables[t][j] = saturatingAdd(p.weightConfig, g.tables[t][j],
+				p.tables[t][j]-base[j])
+		}
+	}
+}
+
+// mergeAverage publishes the weights of the bank and makes the shared
+// weights the average of the weights published by every bank, rounded
+// toward zero.
+func (g *PerceptronBankGroup) mergeAverage(b *perceptronBank) {
+	p := b.predictor
+
+	b.published = p.weights
+	b.publishedTables = append(b.publishedTables[:0], p.tables...)
+
+	n := int64(len(g.banks))
+
+	for j := range g.weights {
+		var sum int64
+		for k := range g.banks {
+			sum += int64(g.banks[k].published[j])
+		}
+
+		g.weights[j] = int32(sum / n)
+	}
+
+	g.growTables(len(p.tables))
+
+	for t := range g.tables {
+		for j := range g.tables[t] {
+			var sum int64
+
+			for k := range g.banks {
+				if t < len(g.banks[k].publishedTables) {
+					sum += int64(g.banks[k].publishedTables[t][j])
+				}
+			}
+
+			g.tables[t][j] = int32(sum / n)
+		}
+	}
+}
+
+// update merges every bank and calls f with every bank, which then holds the
+// shared weights. The weights of the first bank after f become the shared
+// weights of every bank.
+func (g *PerceptronBankGroup) update(f func(p *PerceptronVictimFinder)) {
+	for i := range g.banks {
+		g.Merge(i)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i := range g.banks {
+		g.load(i)
+		f(g.banks[i].predictor)
+	}
+
+	first := g.banks[0].predictor
+	g.weights = first.weights
+	g.tables = append(g.tables[:0], first.tables...)
+
+	for i := range g.banks {
+		g.load(i)
+		g.banks[i].published = g.weights
+		g.banks[i].publishedTables = append(
+			g.banks[i].publishedTables[:0], g.tables...)
+	}
+}
+
+// growTables adds shared tables until there are n.
+func (g *PerceptronBankGroup) growTables(n int) {
+	for len(g.tables) < n {
+		g.tables = append(g.tables, [PerceptronTableSize]int32{})
+	}
+}
+
+// saturatingAdd adds the weight change, saturating at the weight range.
+func saturatingAdd(c PerceptronWeightConfig, w, delta int32) int32 {
+	return min(c.Max, max(c.Min, w+delta))
+}
+
+// load copies the shared weights into the bank and makes them its new base.
+func (g *PerceptronBankGroup) load(i int) {
+	b := &g.banks[i]
+	p := b.predictor
+
+	p.weights = g.weights
+	b.baseWeights = g.weights
+
+	p.tables = append(p.tables[:0], g.tables...)
+	b.baseTables = append(b.baseTables[:0], g.tables...)
+
+	p.invalidatePredictions()
+}
+
+// recordPrediction counts a prediction of the bank and merges the bank when
+// the merge interval is reached.
+func (g *PerceptronBankGroup) recordPrediction(i int) {
+	if g.mergeInterval == 0 {
+		return
+	}
+
+	b := &g.banks[i]
+
+	b.predictions++
+	if b.predictions%g.mergeInterval == 0 {
+		g.Merge(i)
+	}
+}
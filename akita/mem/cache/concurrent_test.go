@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PerceptronBankGroup", func() {
+	newBank := func() *PerceptronVictimFinder {
+		p := NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		p.SetHashedTables(false)
+
+		return p
+	}
+
+	It("should share the weight changes of every bank", func() {
+		g := NewPerceptronBankGroup(2, 0, newBank)
+
+		g.Bank(0).TrainOnEviction(0x10001)
+		g.Bank(1).TrainOnEviction(0x20002)
+		g.Bank(1).TrainOnEviction(0x10001)
+
+		g.Merge(0)
+		g.Merge(1)
+
+		w := g.Bank(1).Weights()
+		Expect(w[0]).To(Equal(int32(4)))
+		Expect(w[1]).To(Equal(int32(2)))
+		Expect(g.Weights()).To(Equal(w))
+		Expect(g.Merges()).To(Equal(uint64(2)))
+
+		// Merging again without new training changes nothing.
+		g.Merge(1)
+		Expect(g.Weights()).To(Equal(w))
+	})
+
+	It("should merge every merge interval predictions", func() {
+		g := NewPerceptronBankGroup(1, 4, newBank)
+		p := g.Bank(0)
+		set := &Set{Blocks: []*Block{{IsValid: true}, {IsValid: true, WayID: 1}}}
+
+		for i := 0; i < 9; i++ {
+			p.FindVictimWithContext(set, &VictimContext{Address: 0x40})
+		}
+
+		Expect(g.Merges()).To(Equal(uint64(2)))
+	})
+
+	It("should let the banks run in parallel", func() {
+		g := NewPerceptronBankGroup(4, 16, newBank)
+		d := NewSyncDirectory(NewDirectory(16, 4, 64, NewLRUVictimFinder()))
+
+		var wg sync.WaitGroup
+		for bank := 0; bank < g.NumBanks(); bank++ {
+			wg.Add(1)
+
+			go func(bank int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				p := g.Bank(bank)
+				set := &Set{Blocks: []*Block{{}, {WayID: 1}}}
+
+				for i := uint64(0); i < 256; i++ {
+					addr := (i*4 + uint64(bank)) * 64
+					p.FindVictimWithContext(set, &VictimContext{Address: addr})
+					p.TrainOnEviction(addr)
+
+					if d.Lookup(0, addr) == nil {
+						d.FindVictim(addr)
+					}
+				}
+			}(bank)
+		}
+
+		wg.Wait()
+
+		Expect(g.Merges()).To(Equal(uint64(4 * 256 / 16)))
+	})
+})
@@ -0,0 +1,20 @@
+package cache
+
+// SetCostAware makes the perceptron take the writeback cost of the victims
+// into account. When the perceptron confidently predicts no reuse, a clean
+// block is evicted before a dirty one whose prediction ties with it, which
+// saves a writeback. The PseudoLRU fallback is not affected.
+func (p *PerceptronVictimFinder) SetCostAware(costAware bool) {
+	p.costAware = costAware
+}
+
+// IsCostAware tells if the victim selection prefers clean blocks.
+func (p *PerceptronVictimFinder) IsCostAware() bool {
+	return p.costAware
+}
+
+// CleanVictimsPreferred returns the number of victim selections in which a
+// clean block was evicted instead of the dirty block selected otherwise.
+func (p *PerceptronVictimFinder) CleanVictimsPreferred() uint64 {
+	return p.cleanVictims
+}
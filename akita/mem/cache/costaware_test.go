@@ -0,0 +1,62 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cost-aware victim selection", func() {
+	var (
+		p   *PerceptronVictimFinder
+		set *Set
+		ctx *VictimContext
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+
+		set = makeTestSet(4)
+		for _, b := range set.Blocks {
+			b.IsValid = true
+			b.IsDirty = b.WayID < 2
+		}
+
+		ctx = &VictimContext{Address: 0x10001 << p.featureShift}
+		for i := 0; i < 32; i++ {
+			p.TrainOnEvictionWithContext(ctx)
+		}
+	})
+
+	It("should evict the first block without cost awareness", func() {
+		Expect(p.FindVictimWithContext(set, ctx)).To(BeIdenticalTo(set.Blocks[0]))
+		Expect(p.CleanVictimsPreferred()).To(BeZero())
+	})
+
+	It("should prefer a clean block among dead blocks", func() {
+		p.SetCostAware(true)
+
+		Expect(p.FindVictimWithContext(set, ctx)).To(BeIdenticalTo(set.Blocks[2]))
+		Expect(p.CleanVictimsPreferred()).To(Equal(uint64(1)))
+		Expect(p.FindVictims(set, ctx, 4)).To(Equal([]*Block{
+			set.Blocks[2], set.Blocks[3], set.Blocks[0], set.Blocks[1],
+		}))
+	})
+
+	It("should evict a dirty block if no clean block is unlocked", func() {
+		p.SetCostAware(true)
+		set.Blocks[2].IsLocked = true
+		set.Blocks[3].IsDirty = true
+
+		Expect(p.FindVictimWithContext(set, ctx)).To(BeIdenticalTo(set.Blocks[0]))
+	})
+
+	It("should keep the PseudoLRU victim when not confident", func() {
+		p.SetCostAware(true)
+		other := &VictimContext{Address: 0x20000 << p.featureShift}
+
+		Expect(p.FindVictimWithContext(set, other)).
+			To(BeIdenticalTo(pseudoLRUVictims.FindVictim(set)))
+		Expect(p.CleanVictimsPreferred()).To(BeZero())
+	})
+})
@@ -0,0 +1,234 @@
+package cache
+
+import "github.com/sarchlab/akita/v4/mem/vm"
+
+// DefaultCuckooMaxRelocations is the default length limit of a relocation
+// chain.
+const DefaultCuckooMaxRelocations = 8
+
+// CuckooStats counts the relocations performed by a CuckooDirectory.
+type CuckooStats struct {
+	Relocations      uint64
+	RelocationChains uint64
+	FailedChains     uint64
+}
+
+// A CuckooDirectory is an experimental directory in which every line can be
+// placed in two sets, selected by two different hash functions. When both
+// candidate sets are full, resident lines are moved to their alternate set,
+// cuckoo-hashing style, to free a block before a victim is evicted.
+//
+// A relocation only moves the tag. The two blocks exchange their cache
+// addresses, so the data of the relocated line stays where it is in the
+// storage. Locked blocks and blocks being read are never relocated.
+type CuckooDirectory struct {
+	*DirectoryImpl
+
+	MaxRelocations int
+
+	stats CuckooStats
+}
+
+// NewCuckooDirectory returns a new cuckoo directory.
+func NewCuckooDirectory(
+	set, way, blockSize int,
+	victimFinder VictimFinder,
+) *CuckooDirectory {
+	return &CuckooDirectory{
+		DirectoryImpl:  NewDirectory(set, way, blockSize, victimFinder),
+		MaxRelocations: DefaultCuckooMaxRelocations,
+	}
+}
+
+// Stats returns the relocation statistics.
+func (d *CuckooDirectory) Stats() CuckooStats {
+	return d.stats
+}
+
+// candidateSets returns the IDs of the two sets that can hold the address.
+// The first set uses the conventional modulo index; the second one uses a
+// hash of the line address.
+func (d *CuckooDirectory) candidateSets(addr uint64) (int, int) {
+	if d.AddrConverter != nil {
+		addr = d.AddrConverter.ConvertExternalToInternal(addr)
+	}
+
+	line := addr / uint64(d.BlockSize)
+	numSets := uint64(d.NumSets)
+
+	return int(line % numSets), int(mixLineHash(line) % numSets)
+}
+
+// Lookup searches both candidate sets of the address.
+func (d *CuckooDirectory) Lookup(pid vm.PID, addr uint64) *Block {
+	first, second := d.candidateSets(addr)
+
+	for _, setID := range []int{first, second} {
+		for _, block := range d.Sets[setID].Blocks {
+			if d.tagMatches(block, pid, addr) {
+				return block
+			}
+		}
+	}
+
+	return nil
+}
+
+// FindVictim returns a block for the address, relocating resident lines if
+// both candidate sets are full.
+func (d *CuckooDirectory) FindVictim(addr uint64) *Block {
+	return d.FindVictimWithContext(addr, nil)
+}
+
+// FindVictimWithContext returns a block for the address, relocating resident
+// lines if both candidate sets are full. If no relocation chain frees a block,
+// the victim finder selects a victim in the first candidate set.
+func (d *CuckooDirectory) FindVictimWithContext(
+	addr uint64,
+	context *VictimContext,
+) *Block {
+	first, second := d.candidateSets(addr)
+
+	block := d.freeBlock(first)
+	if block == nil {
+		block = d.freeBlock(second)
+	}
+
+	if block == nil {
+		block = d.relocate(first, second)
+	}
+
+	if block == nil {
+		set := &d.Sets[first]
+		if context != nil {
+			block = d.victimFinder.FindVictimWithContext(set, context)
+		} else {
+			block = d.victimFinder.FindVictim(set)
+		}
+	}
+
+	if block != nil {
+		d.pendingFills[block.SetID] = block
+	}
+
+	return block
+}
+
+func (d *CuckooDirectory) freeBlock(setID int) *Block {
+	for _, block := range d.Sets[setID].Blocks {
+		if !block.IsValid && !block.IsLocked {
+			return block
+		}
+	}
+
+	return nil
+}
+
+func (d *CuckooDirectory) movable(block *Block) bool {
+	return block.IsValid && !block.IsLocked && block.ReadCount == 0
+}
+
+// alternateSet returns the candidate set of the block's line other than the
+// set that the block is in.
+func (d *CuckooDirectory) alternateSet(block *Block) int {
+	first, second := d.candidateSets(block.Tag)
+	if block.SetID == first {
+		return second
+	}
+
+	return first
+}
+
+type cuckooStep struct {
+	block  *Block
+	parent int
+}
+
+// relocate searches breadth-first for the shortest chain of moves that frees
+// a block in one of the two sets, performs the moves, and returns the freed
+// block.
+func (d *CuckooDirectory) relocate(first, second int) *Block {
+	steps := make([]cuckooStep, 0)
+	visited := make(map[int]bool)
+
+	frontier := []int{}
+	for _, setID := range []int{first, second} {
+		if visited[setID] {
+			continue
+		}
+
+		visited[setID] = true
+
+		for _, block := range d.Sets[setID].Blocks {
+			if d.movable(block) {
+				steps = append(steps, cuckooStep{block: block, parent: -1})
+				frontier = append(frontier, len(steps)-1)
+			}
+		}
+	}
+
+	for depth := 0; depth < d.MaxRelocations && len(frontier) > 0; depth++ {
+		next := []int{}
+
+		for _, i := range frontier {
+			target := d.alternateSet(steps[i].block)
+			if free := d.freeBlock(target); free != nil {
+				d.stats.RelocationChains++
+				return d.applyChain(steps, i, free)
+			}
+
+			if visited[target] {
+				continue
+			}
+
+			visited[target] = true
+
+			for _, block := range d.Sets[target].Blocks {
+				if d.movable(block) {
+					steps = append(steps, cuckooStep{block: block, parent: i})
+					next = append(next, len(steps)-1)
+				}
+			}
+		}
+
+		frontier = next
+	}
+
+	d.stats.FailedChains++
+
+	return nil
+}
+
+// applyChain moves the lines along the chain that ends at step i, starting
+// with the line that moves into the free block.
+func (d *CuckooDirectory) applyChain(
+	steps []cuckooStep,
+	i int,
+	free *Block,
+) *Block {
+	for ; i >= 0; i = steps[i].parent {
+		from := steps[i].block
+		moveLine(from, free)
+		d.stats.Relocations++
+		free = from
+	}
+
+	return free
+}
+
+// moveLine moves the line in from to the invalid block to. The blocks swap
+// their cache addresses so that the data does not move.
+func moveLine(from, to *Block) {
+	to.PID = from.PID
+	to.Tag = from.Tag
+	to.IsValid = true
+	to.IsDirty = from.IsDirty
+	to.DirtyMask = from.DirtyMask
+	to.HitCount = from.HitCount
+	to.CacheAddress, from.CacheAddress = from.CacheAddress, to.CacheAddress
+
+	from.IsValid = false
+	from.IsDirty = false
+	from.DirtyMask = nil
+	from.HitCount = 0
+}
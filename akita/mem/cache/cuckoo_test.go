@@ -0,0 +1,92 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CuckooDirectory", func() {
+	var (
+		d *CuckooDirectory
+	)
+
+	BeforeEach(func() {
+		d = NewCuckooDirectory(8, 1, 64, NewLRUVictimFinder())
+	})
+
+	fill := func(addr uint64) *Block {
+		block := d.FindVictim(addr)
+		block.Tag = addr
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	// lines returns the addresses of the first n lines whose candidate sets
+	// are first and second.
+	lines := func(first, second, n int) []uint64 {
+		addrs := []uint64{}
+		for line := uint64(0); len(addrs) < n; line++ {
+			f, s := d.candidateSets(line * 64)
+			if f == first && s == second {
+				addrs = append(addrs, line*64)
+			}
+		}
+
+		return addrs
+	}
+
+	It("should find a line in either candidate set", func() {
+		a := lines(0, 1, 1)[0]
+		b := lines(0, 2, 1)[0]
+
+		fill(a)
+		fill(b)
+
+		Expect(d.Lookup(0, a).SetID).To(Equal(0))
+		Expect(d.Lookup(0, b).SetID).To(Equal(2))
+	})
+
+	It("should relocate lines instead of evicting", func() {
+		a := lines(0, 1, 1)[0]
+		bc := lines(0, 2, 2)
+
+		fill(a)
+		fill(bc[0])
+		fill(bc[1])
+
+		Expect(d.Lookup(0, a).SetID).To(Equal(1))
+		Expect(d.Lookup(0, bc[0]).SetID).To(Equal(2))
+		Expect(d.Lookup(0, bc[1]).SetID).To(Equal(0))
+		Expect(d.Stats().Relocations).To(Equal(uint64(1)))
+	})
+
+	It("should keep the data in place when relocating", func() {
+		a := lines(0, 1, 1)[0]
+		bc := lines(0, 2, 2)
+		first := fill(a)
+		first.IsDirty = true
+		cacheAddr := first.CacheAddress
+		fill(bc[0])
+		fill(bc[1])
+
+		block := d.Lookup(0, a)
+
+		Expect(block).NotTo(BeIdenticalTo(first))
+		Expect(block.CacheAddress).To(Equal(cacheAddr))
+		Expect(block.IsDirty).To(BeTrue())
+	})
+
+	It("should fall back to the victim finder if lines are locked", func() {
+		a := lines(0, 1, 1)[0]
+		bc := lines(0, 2, 2)
+		fill(a).IsLocked = true
+		fill(bc[0]).IsLocked = true
+
+		d.FindVictim(bc[1])
+
+		Expect(d.Stats().FailedChains).To(Equal(uint64(1)))
+		Expect(d.Stats().Relocations).To(BeZero())
+	})
+})
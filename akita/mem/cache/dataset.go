@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// A DatasetSample is one row of a reuse-prediction training dataset: the
+// features of a fill and whether the line was reused before its eviction.
+type DatasetSample struct {
+	PID        vm.PID
+	Address    uint64
+	AccessType string
+	Features   [6]uint32
+	Reused     bool
+}
+
+type pendingSample struct {
+	valid  bool
+	sample DatasetSample
+}
+
+// A DatasetRecorder writes labeled training samples as CSV. One out of every
+// SampleInterval fills is recorded; the sample is written when the block is
+// evicted, labeled with whether the block was hit in between. The features
+// are the address-as-PC-proxy features of the perceptron.
+type DatasetRecorder struct {
+	SampleInterval uint64
+
+	w        io.Writer
+	numWays  int
+	fills    uint64
+	next     []pendingSample
+	resident []pendingSample
+	written  uint64
+	err      error
+}
+
+// NewDatasetRecorder creates a recorder that writes to w. The header line is
+// written when the recorder is attached to a directory.
+func NewDatasetRecorder(w io.Writer) *DatasetRecorder {
+	return &DatasetRecorder{
+		SampleInterval: 1,
+		w:              w,
+	}
+}
+
+// SetDatasetRecorder attaches a dataset recorder to the directory.
+func (d *DirectoryImpl) SetDatasetRecorder(r *DatasetRecorder) {
+	d.dataset = r
+	if r == nil {
+		return
+	}
+
+	r.numWays = d.NumWays
+	r.next = make([]pendingSample, d.NumSets)
+	r.resident = make([]pendingSample, d.NumSets*d.NumWays)
+	r.writeHeader()
+}
+
+// Written returns the number of samples written.
+func (r *DatasetRecorder) Written() uint64 {
+	return r.written
+}
+
+// Err returns the first write error.
+func (r *DatasetRecorder) Err() error {
+	return r.err
+}
+
+// Close writes the samples of the resident blocks that have been hit, whose
+// label is already known, and returns the first write error. The samples of
+// resident blocks that have not been hit are dropped because their outcome is
+// unknown.
+func (r *DatasetRecorder) Close() error {
+	for i := range r.resident {
+		if r.resident[i].valid && r.resident[i].sample.Reused {
+			r.write(r.resident[i].sample)
+		}
+
+		r.resident[i] = pendingSample{}
+	}
+
+	return r.err
+}
+
+func (r *DatasetRecorder) writeHeader() {
+	_, err := fmt.Fprintln(r.w,
+		"pid,address,access_type,f0,f1,f2,f3,f4,f5,reused")
+	r.setErr(err)
+}
+
+func (r *DatasetRecorder) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *DatasetRecorder) write(s DatasetSample) {
+	label := 0
+	if s.Reused {
+		label = 1
+	}
+
+	f := s.Features
+	_, err := fmt.Fprintf(r.w, "%d,%d,%s,%d,%d,%d,%d,%d,%d,%d\n",
+		s.PID, s.Address, s.AccessType,
+		f[0], f[1], f[2], f[3], f[4], f[5], label)
+	r.setErr(err)
+	r.written++
+}
+
+// recordVictim labels and writes the sample of the evicted block and
+// prepares a sample for the upcoming fill. Calls on a nil recorder are
+// ignored.
+func (r *DatasetRecorder) recordVictim(
+	addr uint64,
+	setID int,
+	context *VictimContext,
+	victim *Block,
+) {
+	if r == nil || victim == nil {
+		return
+	}
+
+	slot := &r.resident[victim.SetID*r.numWays+victim.WayID]
+	if slot.valid && victim.IsValid {
+		slot.sample.Reused = victim.HitCount > 0
+		r.write(slot.sample)
+	}
+
+	*slot = pendingSample{}
+
+	s := DatasetSample{Address: addr, Features: reuseFeatures(addr)}
+	if context != nil {
+		s.PID = context.PID
+		s.AccessType = context.AccessType
+	}
+
+	r.next[setID] = pendingSample{valid: true, sample: s}
+}
+
+// recordAccess starts tracking a sampled fill and labels hits. Calls on a nil
+// recorder are ignored.
+func (r *DatasetRecorder) recordAccess(block *Block, isFill bool) {
+	if r == nil {
+		return
+	}
+
+	slot := &r.resident[block.SetID*r.numWays+block.WayID]
+
+	if !isFill {
+		if slot.valid {
+			slot.sample.Reused = true
+		}
+
+		return
+	}
+
+	pending := r.next[block.SetID]
+	r.next[block.SetID] = pendingSample{}
+
+	r.fills++
+	if !pending.valid || r.SampleInterval == 0 ||
+		r.fills%r.SampleInterval != 0 {
+		return
+	}
+
+	*slot = pending
+}
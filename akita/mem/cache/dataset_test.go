@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DatasetRecorder", func() {
+	var (
+		d   *DirectoryImpl
+		r   *DatasetRecorder
+		buf *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 2, 64, NewLRUVictimFinder())
+		buf = new(bytes.Buffer)
+		r = NewDatasetRecorder(buf)
+		d.SetDatasetRecorder(r)
+	})
+
+	access := func(addr uint64) {
+		block := d.Lookup(1, addr)
+		if block == nil {
+			block = d.FindVictimWithContext(addr, &VictimContext{
+				Address: addr, PID: 1, AccessType: "read",
+			})
+			block.PID = 1
+			block.Tag = addr
+			block.IsValid = true
+		}
+
+		d.Visit(block)
+	}
+
+	It("should label samples at eviction", func() {
+		access(0x1040)
+		access(0x1040)
+		access(0x2080)
+		access(0x30c0)
+		access(0x4000)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		Expect(lines[0]).To(Equal("pid,address,access_type,f0,f1,f2,f3,f4,f5,reused"))
+		Expect(lines).To(ConsistOf(
+			lines[0],
+			"1,4160,read,1,32,16,8,1,0,1",
+			"1,8320,read,2,1,32,16,2,0,0",
+		))
+		Expect(r.Written()).To(Equal(uint64(2)))
+	})
+
+	It("should sample fills", func() {
+		r.SampleInterval = 2
+		for i := uint64(0); i < 10; i++ {
+			access(i * 64)
+		}
+
+		Expect(r.Written()).To(Equal(uint64(4)))
+	})
+
+	It("should write the known labels of resident blocks on close", func() {
+		access(0x0)
+		access(0x0)
+		access(0x40)
+
+		Expect(r.Close()).To(Succeed())
+
+		Expect(r.Written()).To(Equal(uint64(1)))
+	})
+})
@@ -0,0 +1,169 @@
+package cache
+
+// A DeadBlockPredictor predicts whether a line will be referenced again
+// before it is evicted. Besides choosing victims, a cache controller can use
+// the prediction to order writebacks, to drop prefetches of dead lines, or to
+// decide which evicted lines a victim cache keeps.
+type DeadBlockPredictor interface {
+	// PredictDead predicts whether the line at the address is dead. The
+	// context, which may be nil, describes the access that the prediction
+	// is made for. The confidence is non-negative; larger values are more
+	// confident.
+	PredictDead(addr uint64, ctx *VictimContext) (dead bool, confidence int32)
+}
+
+// PredictDead predicts that a line is dead if the prediction sum reaches the
+// threshold with a magnitude of at least theta, the confidence that the
+// victim finder needs to evict a block out of PseudoLRU order. The
+// confidence is the magnitude of the sum. With per-PID weights, the weights
+// of the process of the context are used. The weight reads are not charged.
+func (p *PerceptronVictimFinder) PredictDead(
+	addr uint64,
+	ctx *VictimContext,
+) (dead bool, confidence int32) {
+	var pc uint64
+
+	if ctx != nil {
+		p.usePIDWeights(ctx.PID)
+		pc = ctx.PC
+		p.featureContext = ctx
+	}
+
+	sum := p.predictionSum(addr, pc)
+	p.featureContext = nil
+
+	return sum >= p.threshold && abs(sum) >= p.theta, abs(sum)
+}
+
+// deadBlockVoters is the number of prediction tables of the table-based
+// dead-block predictors. Each table is indexed with its own hash of the
+// signature, and the tables vote.
+const deadBlockVoters = 3
+
+// deadBlockSeeds separate the hash functions of the prediction tables.
+var deadBlockSeeds = [deadBlockVoters]uint64{
+	0x5851f42d4c957f2d, 0x14057b7ef767814f, 0x2545f4914f6cdd1d,
+}
+
+// deadBlockTables are skewed tables of saturating counters that learn
+// whether the lines of a signature are dead, as in GHRP and SDBP. A table
+// votes dead if its counter reaches the threshold.
+type deadBlockTables struct {
+	tables     [deadBlockVoters][]uint8
+	mask       uint64
+	counterMax uint8
+	threshold  uint8
+}
+
+func newDeadBlockTables(
+	tableBits, counterBits uint,
+	threshold uint8,
+) *deadBlockTables {
+	t := &deadBlockTables{
+		mask:       1<<tableBits - 1,
+		counterMax: uint8(1<<counterBits - 1),
+		threshold:  threshold,
+	}
+
+	for i := range t.tables {
+		t.tables[i] = make([]uint8, 1<<tableBits)
+	}
+
+	return t
+}
+
+// votes returns the number of tables that predict the signature dead.
+func (t *deadBlockTables) votes(sig uint32) int {
+	votes := 0
+
+	for i := range t.tables {
+		if t.tables[i][t.index(i, sig)] >= t.threshold {
+			votes++
+		}
+	}
+
+	return votes
+}
+
+// dead tells if a majority of the tables predict the signature dead.
+func (t *deadBlockTables) dead(sig uint32) bool {
+	return t.votes(sig) > deadBlockVoters/2
+}
+
+// sum returns the sum of the counters of the signature.
+func (t *deadBlockTables) sum(sig uint32) int32 {
+	sum := int32(0)
+	for i := range t.tables {
+		sum += int32(t.tables[i][t.index(i, sig)])
+	}
+
+	return sum
+}
+
+// train moves the counters of the signature toward dead or live.
+func (t *deadBlockTables) train(sig uint32, dead bool) {
+	for i := range t.tables {
+		c := &t.tables[i][t.index(i, sig)]
+
+		switch {
+		case dead && *c < t.counterMax:
+			*c++
+		case !dead && *c > 0:
+			*c--
+		}
+	}
+}
+
+func (t *deadBlockTables) index(table int, sig uint32) uint64 {
+	return mixLineHash(uint64(sig)^deadBlockSeeds[table]) & t.mask
+}
+
+// deadFirstOrder returns the ways of the valid blocks whose signature is
+// predicted dead, then of the other blocks, each in PseudoLRU order.
+func (t *deadBlockTables) deadFirstOrder(set *Set) []int {
+	plru := pseudoLRUOrder(set)
+	ways := make([]int, 0, len(plru))
+
+	for _, way := range plru {
+		block := set.Blocks[way]
+		if block.IsValid && t.dead(block.Signature) {
+			ways = append(ways, way)
+		}
+	}
+
+	for _, way := range plru {
+		block := set.Blocks[way]
+		if !block.IsValid || !t.dead(block.Signature) {
+			ways = append(ways, way)
+		}
+	}
+
+	return ways
+}
+
+// deadFirstVictim returns the first invalid block, or the first unlocked and
+// unpinned block in dead-first order.
+func (t *deadBlockTables) deadFirstVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil || len(set.Blocks) == 0 {
+		return b
+	}
+
+	for _, way := range t.deadFirstOrder(set) {
+		block := set.Blocks[way]
+		if !block.IsLocked && !isPinned(block) {
+			return block
+		}
+	}
+
+	return nil
+}
+
+// hitPC returns the PC of the hit given by ctx, or the PC of the fill if the
+// context does not give one.
+func hitPC(block *Block, ctx *VictimContext) uint64 {
+	if ctx == nil || ctx.PC == 0 {
+		return block.PC
+	}
+
+	return ctx.PC
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeadBlockPredictor", func() {
+	var p *PerceptronVictimFinder
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinderWithParams(0, 4, 1)
+		p.SetStrictMode(true)
+		p.SetHashedTables(false)
+	})
+
+	It("should be implemented by the perceptron", func() {
+		var _ DeadBlockPredictor = p
+	})
+
+	It("should only predict dead lines with confidence", func() {
+		dead, confidence := p.PredictDead(0x10001, nil)
+		Expect(dead).To(BeFalse())
+		Expect(confidence).To(BeZero())
+
+		for i := 0; i < 2; i++ {
+			p.TrainOnEviction(0x10001)
+		}
+
+		dead, confidence = p.PredictDead(0x10001, nil)
+		Expect(dead).To(BeTrue())
+		Expect(confidence).To(Equal(int32(4)))
+		Expect(p.PredictsDead(0x10001)).To(BeTrue())
+
+		p.TrainOnHit(0x10001)
+
+		dead, confidence = p.PredictDead(0x10001, nil)
+		Expect(dead).To(BeFalse())
+		Expect(confidence).To(Equal(int32(2)))
+	})
+
+	It("should use the weights of the process of the context", func() {
+		p.EnablePerPIDWeights(2)
+
+		for i := 0; i < 2; i++ {
+			p.TrainOnEvictionWithContext(&VictimContext{Address: 0x10001, PID: 1})
+		}
+
+		dead, _ := p.PredictDead(0x10001, &VictimContext{PID: 1})
+		Expect(dead).To(BeTrue())
+
+		dead, _ = p.PredictDead(0x10001, &VictimContext{PID: 2})
+		Expect(dead).To(BeFalse())
+	})
+})
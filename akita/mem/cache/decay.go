@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// A DecayMode selects how a decay moves the weights toward zero.
+type DecayMode int
+
+// Decay modes.
+const (
+	// DecayHalve halves every weight, rounding toward zero.
+	DecayHalve DecayMode = iota
+
+	// DecayDecrement moves every nonzero weight one step toward zero.
+	DecayDecrement
+)
+
+// WeightDecayConfig configures the periodic decay of the perceptron weights.
+// Saturated weights adapt slowly when a long-running kernel changes behavior;
+// decaying them lets the predictor forget the old phase.
+type WeightDecayConfig struct {
+	Mode DecayMode
+
+	// If positive, the weights decay every TrainingInterval trainings.
+	TrainingInterval uint64
+
+	// If positive, the weights decay at the first prediction or training at
+	// least Epoch after the previous decay. The time is read from
+	// TimeTeller.
+	Epoch      sim.VTimeInSec
+	TimeTeller sim.TimeTeller
+}
+
+// weightDecay is the decay state of a perceptron.
+type weightDecay struct {
+	config    WeightDecayConfig
+	trainings uint64
+	lastDecay sim.VTimeInSec
+	decays    uint64
+}
+
+// SetWeightDecay enables the periodic decay of the weights.
+func (p *PerceptronVictimFinder) SetWeightDecay(config WeightDecayConfig) {
+	if config.Mode != DecayHalve && config.Mode != DecayDecrement {
+		panic(fmt.Sprintf("unknown decay mode %d", config.Mode))
+	}
+
+	if config.Epoch > 0 && config.TimeTeller == nil {
+		panic("a decay epoch requires a time teller")
+	}
+
+	d := &weightDecay{config: config}
+	if config.TimeTeller != nil {
+		d.lastDecay = config.TimeTeller.CurrentTime()
+	}
+
+	p.decay = d
+}
+
+// WeightDecay returns the decay configuration.
+func (p *PerceptronVictimFinder) WeightDecay() WeightDecayConfig {
+	if p.decay == nil {
+		return WeightDecayConfig{}
+	}
+
+	return p.decay.config
+}
+
+// WeightDecays returns the number of decays so far, periodic or explicit.
+func (p *PerceptronVictimFinder) WeightDecays() uint64 {
+	if p.decay == nil {
+		return 0
+	}
+
+	return p.decay.decays
+}
+
+// DecayNow decays the weights with the configured mode, halving them if no
+// decay is configured. It is meant for kernel boundaries and other known
+// phase changes, and restarts the periodic decay.
+func (p *PerceptronVictimFinder) DecayNow() {
+	if p.decay == nil {
+		p.decay = &weightDecay{}
+	}
+
+	d := p.decay
+	if d.config.Mode == DecayDecrement {
+		p.mapWeights(decrementTowardZero)
+	} else {
+		p.DecayWeights(1)
+	}
+
+	d.decays++
+	d.trainings = 0
+
+	if d.config.TimeTeller != nil {
+		d.lastDecay = d.config.TimeTeller.CurrentTime()
+	}
+}
+
+func decrementTowardZero(w int32) int32 {
+	switch {
+	case w > 0:
+		return w - 1
+	case w < 0:
+		return w + 1
+	default:
+		return 0
+	}
+}
+
+// tickDecay decays the weights when an interval has passed. A training
+// counts toward the training interval.
+func (p *PerceptronVictimFinder) tickDecay(training bool) {
+	d := p.decay
+	if d == nil {
+		return
+	}
+
+	if training {
+		d.trainings++
+		if n := d.config.TrainingInterval; n > 0 && d.trainings >= n {
+			p.DecayNow()
+			return
+		}
+	}
+
+	if d.config.Epoch > 0 &&
+		d.config.TimeTeller.CurrentTime()-d.lastDecay >= d.config.Epoch {
+		p.DecayNow()
+	}
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Weight decay", func() {
+	var (
+		p *PerceptronVictimFinder
+		w [MaxPerceptronWeights]int32
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		p.SetInferenceOnly(true)
+
+		w[0], w[1], w[2] = 9, -9, 0
+		p.SetWeights(w)
+	})
+
+	It("should halve the weights by default", func() {
+		p.DecayNow()
+
+		decayed := p.Weights()
+		Expect(decayed[:3]).To(Equal([]int32{4, -4, 0}))
+		Expect(p.WeightDecays()).To(Equal(uint64(1)))
+	})
+
+	It("should decrement the weights toward zero", func() {
+		p.SetWeightDecay(WeightDecayConfig{Mode: DecayDecrement})
+		p.DecayNow()
+
+		decayed := p.Weights()
+		Expect(decayed[:3]).To(Equal([]int32{8, -8, 0}))
+	})
+
+	It("should decay every N trainings", func() {
+		p.SetWeightDecay(WeightDecayConfig{TrainingInterval: 3})
+
+		p.TrainOnHit(0x40)
+		p.TrainOnHit(0x40)
+		Expect(p.WeightDecays()).To(BeZero())
+
+		p.TrainOnHit(0x40)
+		Expect(p.WeightDecays()).To(Equal(uint64(1)))
+		Expect(p.Weights()[0]).To(Equal(int32(4)))
+	})
+
+	It("should decay every simulated epoch", func() {
+		clock := &fixedTimeTeller{now: 1}
+		p.SetWeightDecay(WeightDecayConfig{
+			Mode:       DecayDecrement,
+			Epoch:      2,
+			TimeTeller: clock,
+		})
+		set := makeTestSet(2)
+
+		clock.now = 2.5
+		p.FindVictimWithContext(set, &VictimContext{})
+		Expect(p.WeightDecays()).To(BeZero())
+
+		clock.now = 3
+		p.FindVictimWithContext(set, &VictimContext{})
+		p.FindVictimWithContext(set, &VictimContext{})
+		Expect(p.WeightDecays()).To(Equal(uint64(1)))
+	})
+
+	It("should decay at a kernel boundary", func() {
+		d := NewDirectory(1, 2, 64, p)
+		h := NewKernelBoundaryHooks(d, KernelBoundaryPolicy{
+			OnComplete: KernelBoundaryAction{DecayNow: true},
+		})
+
+		h.KernelCompleted("k0")
+
+		Expect(p.Weights()[0]).To(Equal(int32(4)))
+	})
+
+	It("should reject an epoch without a time teller", func() {
+		Expect(func() {
+			p.SetWeightDecay(WeightDecayConfig{Epoch: 1})
+		}).To(Panic())
+	})
+})
@@ -0,0 +1,385 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// A determinismRun runs a simulation with the given seed. It writes
+// everything that should be reproducible, typically the eviction trace and
+// the final statistics, to w.
+type determinismRun func(seed int64, w io.Writer) error
+
+// A nondeterminismError reports the first line at which two runs with the
+// same seed differ.
+type nondeterminismError struct {
+	Seed   int64
+	Line   int
+	First  string
+	Second string
+}
+
+func (e *nondeterminismError) Error() string {
+	return fmt.Sprintf(
+		"runs with seed %d differ at line %d: %q != %q",
+		e.Seed, e.Line, e.First, e.Second)
+}
+
+// checkDeterminism runs the simulation twice with the same seed and returns a
+// *nondeterminismError if the outputs are not byte-identical.
+func checkDeterminism(seed int64, run determinismRun) error {
+	var first, second bytes.Buffer
+
+	if err := run(seed, &first); err != nil {
+		return err
+	}
+
+	if err := run(seed, &second); err != nil {
+		return err
+	}
+
+	if bytes.Equal(first.Bytes(), second.Bytes()) {
+		return nil
+	}
+
+	return firstDifference(seed, first.Bytes(), second.Bytes())
+}
+
+func firstDifference(seed int64, a, b []byte) *nondeterminismError {
+	sa := bufio.NewScanner(bytes.NewReader(a))
+	sb := bufio.NewScanner(bytes.NewReader(b))
+
+	line := 0
+	for {
+		line++
+		okA, okB := sa.Scan(), sb.Scan()
+
+		if !okA && !okB {
+			// Only the trailing newline differs.
+			return &nondeterminismError{Seed: seed, Line: line}
+		}
+
+		if okA != okB || sa.Text() != sb.Text() {
+			return &nondeterminismError{
+				Seed:   seed,
+				Line:   line,
+				First:  sa.Text(),
+				Second: sb.Text(),
+			}
+		}
+	}
+}
+
+// A nondeterminismSource is a piece of code whose behavior may change from
+// run to run.
+type nondeterminismSource struct {
+	Pos    token.Position
+	Reason string
+}
+
+func (s nondeterminismSource) String() string {
+	return fmt.Sprintf("%s: %s", s.Pos, s.Reason)
+}
+
+// nondeterministicCalls lists the package-level functions that read the wall
+// clock or the shared random source.
+var nondeterministicCalls = map[string]map[string]bool{
+	"time": {"Now": true, "Since": true, "Until": true, "Tick": true,
+		"After": true, "NewTimer": true, "NewTicker": true},
+	"rand": {"Int": true, "Intn": true, "Int31": true, "Int31n": true,
+		"Int63": true, "Int63n": true, "Uint32": true, "Uint64": true,
+		"Float32": true, "Float64": true, "Perm": true, "Shuffle": true,
+		"Seed": true, "Read": true, "N": true, "IntN": true, "Uint64N": true},
+}
+
+// findNondeterminismSources scans the non-test Go files in the directory for
+// ranges over maps, calls that read the wall clock, and calls that use the
+// global random source. Maps are recognized syntactically by the declared
+// type of struct fields, variables, and parameters. The findings are sorted
+// by position.
+func findNondeterminismSources(dir string) ([]nondeterminismSource, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(paths))
+
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, f)
+	}
+
+	maps := collectMapNames(files)
+
+	var sources []nondeterminismSource
+	for _, f := range files {
+		sources = append(sources, scanFile(fset, f, maps)...)
+	}
+
+	sort.Slice(sources, func(i, j int) bool {
+		a, b := sources[i].Pos, sources[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+
+		return a.Line < b.Line
+	})
+
+	return sources, nil
+}
+
+// collectMapNames returns the names of the fields, variables, and parameters
+// that are declared with a map type.
+func collectMapNames(files []*ast.File) map[string]bool {
+	names := make(map[string]bool)
+
+	addIfMap := func(ids []*ast.Ident, expr ast.Expr) {
+		if !isMapExpr(expr) {
+			return
+		}
+
+		for _, id := range ids {
+			names[id.Name] = true
+		}
+	}
+
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Field:
+				addIfMap(n.Names, n.Type)
+			case *ast.ValueSpec:
+				addIfMap(n.Names, n.Type)
+				for i, v := range n.Values {
+					if i < len(n.Names) {
+						addIfMap(n.Names[i:i+1], v)
+					}
+				}
+			case *ast.AssignStmt:
+				if len(n.Lhs) != len(n.Rhs) {
+					break
+				}
+
+				for i, rhs := range n.Rhs {
+					if id, ok := n.Lhs[i].(*ast.Ident); ok {
+						addIfMap([]*ast.Ident{id}, rhs)
+					}
+				}
+			}
+
+			return true
+		})
+	}
+
+	return names
+}
+
+func isMapExpr(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.MapType:
+		return true
+	case *ast.CompositeLit:
+		return isMapExpr(e.Type)
+	case *ast.CallExpr:
+		id, ok := e.Fun.(*ast.Ident)
+		return ok && id.Name == "make" && len(e.Args) > 0 && isMapExpr(e.Args[0])
+	}
+
+	return false
+}
+
+func scanFile(
+	fset *token.FileSet,
+	f *ast.File,
+	maps map[string]bool,
+) []nondeterminismSource {
+	var sources []nondeterminismSource
+	report := func(pos token.Pos, reason string) {
+		sources = append(sources,
+			nondeterminismSource{Pos: fset.Position(pos), Reason: reason})
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.RangeStmt:
+			if name := exprName(n.X); maps[name] {
+				report(n.Pos(), fmt.Sprintf("range over map %s", name))
+			}
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok {
+				break
+			}
+
+			pkg, ok := sel.X.(*ast.Ident)
+			if ok && nondeterministicCalls[pkg.Name][sel.Sel.Name] {
+				report(n.Pos(),
+					fmt.Sprintf("call to %s.%s", pkg.Name, sel.Sel.Name))
+			}
+		}
+
+		return true
+	})
+
+	return sources
+}
+
+// exprName returns the name of an identifier or the selected name of a
+// selector expression.
+func exprName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	}
+
+	return ""
+}
+
+// deterministicSources lists the findings in the package that do not depend
+// on the iteration order, by file and reason, with the explanation.
+var deterministicSources = map[string]string{
+	"champsim.go: range over map cpus": "summing and taking the maximum " +
+		"do not depend on order",
+	"perpid.go: range over map byPID": "the use stamps are unique, and " +
+		"every entry is mapped independently",
+	"preset.go: range over map perceptronPresets":      "the names are sorted",
+	"registry.go: range over map victimFinderRegistry": "the names are sorted",
+}
+
+// runDeterminismWorkload replays a random access stream against a perceptron
+// directory and writes the eviction trace and the final statistics to w.
+func runDeterminismWorkload(seed int64, w io.Writer) error {
+	p := NewPerceptronVictimFinder()
+	d := NewDirectory(16, 4, 64, p)
+	d.SetThrashingDetector(
+		NewThrashingDetector(DefaultThrashingDetectorConfig(), 16, 4))
+	d.SetEvictionTrace(w)
+
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < 2000; i++ {
+		addr := uint64(r.Intn(256)) * 64
+		if block := d.Lookup(1, addr); block != nil {
+			p.TrainOnHit(addr)
+			d.Visit(block)
+
+			continue
+		}
+
+		victim := d.FindVictimWithContext(addr, &VictimContext{Address: addr})
+		if victim.IsValid {
+			p.TrainOnEviction(victim.Tag)
+		}
+
+		victim.PID = 1
+		victim.Tag = addr
+		victim.IsValid = true
+		victim.IsDirty = r.Intn(4) == 0
+		d.Visit(victim)
+	}
+
+	hits, misses, _ := p.GetStats()
+	fmt.Fprintf(w, "hits=%d misses=%d weights=%v\n", hits, misses, p.Weights())
+
+	return WriteDirectorySnapshot(w, d.Snapshot())
+}
+
+var _ = Describe("Determinism", func() {
+	It("should produce identical traces for the same seed", func() {
+		Expect(checkDeterminism(42, runDeterminismWorkload)).To(Succeed())
+	})
+
+	It("should report the first line that differs", func() {
+		runs := 0
+		err := checkDeterminism(1, func(seed int64, w io.Writer) error {
+			runs++
+			fmt.Fprintf(w, "same\nrun %d\n", runs)
+
+			return nil
+		})
+
+		var nerr *nondeterminismError
+		Expect(errors.As(err, &nerr)).To(BeTrue())
+		Expect(nerr.Line).To(Equal(2))
+		Expect(nerr.First).To(Equal("run 1"))
+		Expect(nerr.Second).To(Equal("run 2"))
+	})
+
+	It("should find no nondeterminism in the replacement code", func() {
+		sources, err := findNondeterminismSources(".")
+
+		Expect(err).NotTo(HaveOccurred())
+		for _, s := range sources {
+			key := filepath.Base(s.Pos.Filename) + ": " + s.Reason
+			Expect(deterministicSources).To(HaveKey(key), s.String())
+		}
+	})
+
+	It("should flag map ranges, clock reads, and the global source", func() {
+		dir := GinkgoT().TempDir()
+		src := `package x
+
+import (
+	"math/rand"
+	"time"
+)
+
+type s struct{ counts map[int]int }
+
+func f(v *s) {
+	for k := range v.counts {
+		_ = k
+	}
+
+	names := map[string]bool{}
+	for range names {
+	}
+
+	_ = time.Now()
+	_ = rand.Intn(3)
+	_ = rand.New(rand.NewSource(1)).Intn(3)
+}
+`
+		Expect(os.WriteFile(filepath.Join(dir, "x.go"), []byte(src), 0o644)).
+			To(Succeed())
+
+		sources, err := findNondeterminismSources(dir)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sources).To(HaveLen(4))
+		Expect(sources[0].Reason).To(Equal("range over map counts"))
+		Expect(sources[1].Reason).To(Equal("range over map names"))
+		Expect(sources[2].Reason).To(Equal("call to time.Now"))
+		Expect(sources[3].Reason).To(Equal("call to rand.Intn"))
+	})
+})
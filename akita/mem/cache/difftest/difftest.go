@@ -0,0 +1,252 @@
+// Package difftest runs two cache directories on the same access streams and
+// compares their behavior, to catch regressions when a replacement policy is
+// refactored. A refactoring that should not change any decision is checked
+// with Comparison.Identical; a change that should not matter much is checked
+// by bounding Comparison.MaxHitRateDifference.
+package difftest
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/mem/cache"
+	"github.com/sarchlab/akita/v4/mem/cache/replay"
+	"github.com/sarchlab/akita/v4/mem/cache/workloadgen"
+)
+
+// A DirectoryFactory creates a fresh directory. Both directories of a
+// comparison must have the same geometry.
+type DirectoryFactory func() *cache.DirectoryImpl
+
+// A Config selects the streams of a comparison.
+type Config struct {
+	// The streams to replay. Defaults to DefaultStreams for the capacity of
+	// the directories.
+	Streams []workloadgen.Config
+
+	// The number of accesses of every default stream. Defaults to 8192.
+	Accesses int
+
+	// The seed of the default streams.
+	Seed int64
+}
+
+// DefaultStreams returns random, Zipf, looping, and scan+reuse streams of
+// the given length and seed, sized for a cache of the given number of lines
+// so that every stream exercises eviction.
+func DefaultStreams(lines, accesses int, seed int64) []workloadgen.Config {
+	streams := []workloadgen.Config{
+		{Pattern: workloadgen.Random, WorkingSet: 2 * lines},
+		{Pattern: workloadgen.Zipf, WorkingSet: 8 * lines},
+		{Pattern: workloadgen.Loop, WorkingSet: lines + lines/8 + 1},
+		{
+			Pattern:       workloadgen.ScanReuse,
+			WorkingSet:    lines/2 + 1,
+			ReuseAccesses: 2 * lines,
+			ScanLength:    lines,
+		},
+	}
+
+	for i := range streams {
+		streams[i].Accesses = accesses
+		streams[i].Seed = seed
+	}
+
+	return streams
+}
+
+// A Divergence is the first access at which the directories behaved
+// differently.
+type Divergence struct {
+	Access  int
+	Address uint64
+
+	// The ways of the victims, or -1 for a hit and -2 if no victim could be
+	// found.
+	WayA, WayB int
+}
+
+// String describes the divergence.
+func (d Divergence) String() string {
+	return fmt.Sprintf("access %d to %#x: way %d vs way %d",
+		d.Access, d.Address, d.WayA, d.WayB)
+}
+
+// A StreamResult compares the directories on one stream.
+type StreamResult struct {
+	Name     string
+	Accesses uint64
+	HitsA    uint64
+	HitsB    uint64
+
+	// The first divergence, or nil if the directories made the same
+	// decisions on the whole stream.
+	FirstDivergence *Divergence
+}
+
+// HitRateDifference returns the hit rate of A minus the hit rate of B.
+func (r StreamResult) HitRateDifference() float64 {
+	if r.Accesses == 0 {
+		return 0
+	}
+
+	return (float64(r.HitsA) - float64(r.HitsB)) / float64(r.Accesses)
+}
+
+// A Comparison holds the results of all the streams.
+type Comparison struct {
+	Streams []StreamResult
+}
+
+// Identical tells if the directories made the same decisions on every
+// stream.
+func (c Comparison) Identical() bool {
+	for _, s := range c.Streams {
+		if s.FirstDivergence != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FirstDivergence returns the first divergence of the first stream that
+// diverged, described with the name of the stream, or an empty string if
+// the directories never diverged.
+func (c Comparison) FirstDivergence() string {
+	for _, s := range c.Streams {
+		if s.FirstDivergence != nil {
+			return s.Name + ": " + s.FirstDivergence.String()
+		}
+	}
+
+	return ""
+}
+
+// MaxHitRateDifference returns the largest difference of the hit rates of
+// the directories on a stream, in either direction.
+func (c Comparison) MaxHitRateDifference() float64 {
+	worst := 0.0
+
+	for _, s := range c.Streams {
+		d := s.HitRateDifference()
+		if d < 0 {
+			d = -d
+		}
+
+		if d > worst {
+			worst = d
+		}
+	}
+
+	return worst
+}
+
+// Compare replays every stream on a fresh directory from each factory. The
+// directories run independently: after the first divergence their contents
+// differ, so only their hit rates remain comparable. Like the replay
+// package, hits and evictions train the policies that predict reuse.
+func Compare(newA, newB DirectoryFactory, config Config) Comparison {
+	streams := config.Streams
+	if len(streams) == 0 {
+		d := newA()
+		accesses := config.Accesses
+		if accesses <= 0 {
+			accesses = 8192
+		}
+
+		streams = DefaultStreams(d.NumSets*d.NumWays, accesses, config.Seed)
+	}
+
+	var c Comparison
+	for _, stream := range streams {
+		c.Streams = append(c.Streams,
+			compareStream(newA(), newB(), stream))
+	}
+
+	return c
+}
+
+func compareStream(
+	a, b *cache.DirectoryImpl,
+	stream workloadgen.Config,
+) StreamResult {
+	result := StreamResult{Name: stream.Pattern.String()}
+
+	g := workloadgen.New(stream)
+	for i := 0; ; i++ {
+		access, err := g.Next()
+		if err != nil {
+			return result
+		}
+
+		wayA := accessDirectory(a, access)
+		wayB := accessDirectory(b, access)
+
+		result.Accesses++
+		if wayA == -1 {
+			result.HitsA++
+		}
+
+		if wayB == -1 {
+			result.HitsB++
+		}
+
+		if wayA != wayB && result.FirstDivergence == nil {
+			result.FirstDivergence = &Divergence{
+				Access:  i,
+				Address: access.Address,
+				WayA:    wayA,
+				WayB:    wayB,
+			}
+		}
+	}
+}
+
+// accessDirectory looks up the line of the access and fills it on a miss.
+// It returns the way of the victim, -1 on a hit, or -2 if no victim could be
+// found.
+func accessDirectory(d *cache.DirectoryImpl, a replay.Access) int {
+	blockSize := uint64(d.BlockSize)
+	line := a.Address / blockSize * blockSize
+	ctx := &cache.VictimContext{
+		Address:     a.Address,
+		CacheLineID: line,
+		PC:          a.PC,
+		AccessType:  "read",
+	}
+
+	if a.Write {
+		ctx.AccessType = "write"
+	}
+
+	trainer, _ := d.GetVictimFinder().(cache.ReuseTrainer)
+
+	if block := d.Lookup(0, line); block != nil {
+		if trainer != nil {
+			trainer.TrainOnHitWithContext(ctx)
+		}
+
+		d.VisitWithContext(block, ctx)
+
+		return -1
+	}
+
+	victim := d.FindVictimWithContext(line, ctx)
+	if victim == nil {
+		return -2
+	}
+
+	if victim.IsValid && trainer != nil {
+		trainer.TrainOnEvictionWithContext(&cache.VictimContext{
+			Address:    victim.Tag,
+			PC:         victim.PC,
+			AccessType: victim.AccessType,
+		})
+	}
+
+	victim.Tag = line
+	victim.IsValid = true
+	d.Visit(victim)
+
+	return victim.WayID
+}
@@ -0,0 +1,13 @@
+package difftest
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDifftest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Differential Test Suite")
+}
@@ -0,0 +1,97 @@
+package difftest
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/akita/v4/mem/cache"
+	"github.com/sarchlab/akita/v4/mem/cache/workloadgen"
+)
+
+func factory(ways int, newVF func() cache.VictimFinder) DirectoryFactory {
+	return func() *cache.DirectoryImpl {
+		return cache.NewDirectory(16, ways, 64, newVF())
+	}
+}
+
+var _ = Describe("Compare", func() {
+	It("should find PseudoLRU and true LRU identical on two ways", func() {
+		report := Compare(
+			factory(2, func() cache.VictimFinder {
+				return cache.NewLRUVictimFinder()
+			}),
+			factory(2, func() cache.VictimFinder {
+				return cache.NewTrueLRUVictimFinder()
+			}),
+			Config{Seed: 1},
+		)
+
+		Expect(report.Streams).To(HaveLen(4))
+		Expect(report.Identical()).To(BeTrue(), report.FirstDivergence())
+		Expect(report.MaxHitRateDifference()).To(Equal(0.0))
+	})
+
+	It("should find a trained policy identical to itself", func() {
+		newPerceptron := func() cache.VictimFinder {
+			return cache.NewPerceptronVictimFinder()
+		}
+
+		report := Compare(factory(8, newPerceptron), factory(8, newPerceptron),
+			Config{Accesses: 4096, Seed: 7})
+
+		Expect(report.Identical()).To(BeTrue(), report.FirstDivergence())
+	})
+
+	It("should bound PseudoLRU against true LRU on eight ways", func() {
+		report := Compare(
+			factory(8, func() cache.VictimFinder {
+				return cache.NewLRUVictimFinder()
+			}),
+			factory(8, func() cache.VictimFinder {
+				return cache.NewTrueLRUVictimFinder()
+			}),
+			Config{Seed: 3},
+		)
+
+		Expect(report.Identical()).To(BeFalse())
+		Expect(report.MaxHitRateDifference()).To(BeNumerically("<", 0.05))
+	})
+
+	It("should report the first divergence", func() {
+		report := Compare(
+			factory(4, func() cache.VictimFinder {
+				return cache.NewLRUVictimFinder()
+			}),
+			factory(4, func() cache.VictimFinder {
+				return cache.NewSRRIPVictimFinder()
+			}),
+			Config{Streams: []workloadgen.Config{{
+				Pattern:    workloadgen.Random,
+				Accesses:   2048,
+				WorkingSet: 256,
+				Seed:       5,
+			}}},
+		)
+
+		Expect(report.Identical()).To(BeFalse())
+		Expect(report.Streams[0].Name).To(Equal("random"))
+		Expect(report.Streams[0].Accesses).To(Equal(uint64(2048)))
+
+		d := report.Streams[0].FirstDivergence
+		Expect(d).NotTo(BeNil())
+		Expect(d.WayA).NotTo(Equal(d.WayB))
+		Expect(report.FirstDivergence()).To(HavePrefix("random: access "))
+	})
+
+	It("should default the streams to the capacity of the directory", func() {
+		streams := DefaultStreams(64, 100, 9)
+
+		Expect(streams).To(HaveLen(4))
+		for _, s := range streams {
+			Expect(s.Accesses).To(Equal(100))
+			Expect(s.Seed).To(Equal(int64(9)))
+		}
+
+		Expect(streams[0].WorkingSet).To(Equal(128))
+	})
+})
@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"io"
+
 	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/mem/vm"
 )
@@ -16,7 +18,32 @@ type Block struct {
 	IsDirty      bool
 	ReadCount    int
 	IsLocked     bool
+	IsPinned     bool // Never evicted while valid; see DirectoryImpl.Pin
 	DirtyMask    []bool
+	HitCount     int    // Number of hits since the block was filled
+	IsPrefetched bool   // The block was filled by a prefetch
+	FillTime     uint64 // Number of accesses to the set before the fill
+	Referenced   bool   // Set on every visit; cleared by aging, Clock, and NRU
+	Age          uint8  // Aging counter; see DirectoryImpl.SetAging
+	QoSClass     int    // Priority class of the access that filled the block
+	PC           uint64 // Instruction PC of the fill; 0 if unknown
+	LastAccess   uint64 // Recency stamp; see TrueLRUVictimFinder
+	RRPV         uint8  // Re-reference prediction value; see RRIPVictimFinder
+	Signature    uint32 // Reuse-predictor signature of the fill; see SHiP
+	Protected    bool   // In the protected segment; see SLRUVictimFinder
+	ETA          uint64 // Predicted time of the next access; see Mockingjay
+
+	Origin     mem.AccessOrigin // GPU requester of the fill
+	AccessSize uint64           // Bytes accessed by the fill; 0 if unknown
+	AccessType string           // "read" or "write" for the fill
+
+	// Reuse prediction of the last visit; see SetBlockPredictions
+	PredictedDead  bool
+	DeadConfidence int32
+
+	// One bit per sector; see DirectoryImpl.SetSectorSize
+	ValidSectors uint64
+	DirtySectors uint64
 	// PseudoLRU doesn't need per-block tracking - uses set-level bit tree
 }
 
@@ -25,9 +52,60 @@ type Set struct {
 	Blocks []*Block
 	// PseudoLRU: binary tree of bits for efficient LRU approximation (MICRO 2016 paper approach)
 	PseudoLRUBits uint64 // Bit vector for PseudoLRU tree (supports up to 64-way associativity)
+
+	ClockHand   int // Next way examined by the Clock policy
+	FIFOPointer int // Next way replaced by the FIFO policy
+
+	// The ways from least to most recently used, kept instead of the tree
+	// by sets with more than maxPseudoLRUWays ways; see lruWays
+	lruOrder []int
+}
+
+// maxPseudoLRUWays is the largest associativity whose PseudoLRU tree fits in
+// PseudoLRUBits. Larger sets, such as fully associative ones, keep the exact
+// LRU order of their ways instead, which the PseudoLRU victim and order
+// follow, so the policies that fall back to PseudoLRU still evict the least
+// recently used way.
+const maxPseudoLRUWays = 64
+
+// lruWays returns the ways of a set with more than maxPseudoLRUWays ways from
+// least to most recently used. A set starts in way order.
+func (s *Set) lruWays() []int {
+	if len(s.lruOrder) != len(s.Blocks) {
+		s.lruOrder = make([]int, len(s.Blocks))
+		for i := range s.lruOrder {
+			s.lruOrder[i] = i
+		}
+	}
+
+	return s.lruOrder
+}
+
+// moveLRUWay moves the way to the most recently used end of the LRU order, or
+// to the least recently used end if lru is set.
+func (s *Set) moveLRUWay(wayID int, lru bool) {
+	order := s.lruWays()
+
+	i := 0
+	for order[i] != wayID {
+		i++
+	}
+
+	if lru {
+		copy(order[1:i+1], order[:i])
+		order[0] = wayID
+
+		return
+	}
+
+	copy(order[i:], order[i+1:])
+	order[len(order)-1] = wayID
 }
 
 // A Directory stores the information about what is stored in the cache.
+//
+// FindVictim and FindVictimWithContext return nil if every block that the
+// address can be placed in is locked.
 type Directory interface {
 	Lookup(pid vm.PID, address uint64) *Block
 	FindVictim(address uint64) *Block
@@ -40,6 +118,19 @@ type Directory interface {
 	Reset()
 }
 
+// A DirectoryProber finds the block that holds a line without counting the
+// lookup as an access. A controller probes the directory to plan the work of
+// an access before it looks the line up.
+type DirectoryProber interface {
+	Probe(pid vm.PID, address uint64) *Block
+}
+
+// A DirectoryContextVisitor visits blocks with the context of the access, so
+// that the policy can attribute a hit to the PC that hit.
+type DirectoryContextVisitor interface {
+	VisitWithContext(block *Block, ctx *VictimContext)
+}
+
 // A DirectoryImpl is the default implementation of a Directory
 //
 // The directory can translate from the request address (can be either virtual
@@ -52,7 +143,73 @@ type DirectoryImpl struct {
 
 	Sets []Set
 
+	// How lines map to sets; see SetIndexFunction
+	indexFunction IndexFunction
+	indexPrime    int
+
+	// Bytes per sector; 0 if the blocks are not sectored
+	sectorSize int
+
+	// What Lookup compares, and the number of ways of every set if the sets
+	// are not uniform; see DirectoryBuilder
+	tagMode TagMode
+	setWays []int
+
 	victimFinder VictimFinder
+	observer     AccessObserver
+	inserter     InsertionObserver
+	evictions    EvictionObserver
+	energy       *EnergyMeter
+
+	thrashing      *ThrashingDetector
+	scanResistance *ScanResistance
+	dirtyPartition *DirtyPartition
+	wayPartition   *WayPartition
+	prefetch       *PrefetchProtection
+	aging          *agingState
+	hints          *EvictionHints
+	qos            *QoSPolicy
+
+	insertionAdvisor InsertionAdvisor
+	tinyLFU          *TinyLFU
+
+	prefetchFeedback *PrefetchFeedbackTracker
+	dataset          *DatasetRecorder
+	workingSet       *WorkingSetEstimator
+	hotCold          *HotColdClassifier
+	evictionTrace    io.Writer
+	sampledStats     *SampledStats
+	statsExporter    *StatsExporter
+	missClassifier   *MissClassifier
+	shadow           *ShadowDirectory
+	wayPrediction    *wayPrediction
+	lineCallbacks    LineCallbacks
+	blockPredictions bool
+
+	// The victim most recently returned for each set. The next visit to it is
+	// treated as a fill rather than a hit.
+	pendingFills   []*Block
+	pendingContext []pendingFillContext
+
+	// The number of visits to each set.
+	setAccesses []uint64
+}
+
+// pendingFillContext keeps the part of the victim context that describes the
+// block after the fill.
+type pendingFillContext struct {
+	prefetch bool
+	qosClass int
+	pc       uint64
+	evicting bool      // The victim held a valid line when it was selected
+	evicted  LineEvent // The line that the victim held, if evicting
+
+	origin     mem.AccessOrigin
+	accessSize uint64
+	accessType string
+	sectors    uint64 // The sectors that the access covers
+
+	insertion InsertionPriority
 }
 
 // NewDirectory returns a new directory object
@@ -62,6 +219,9 @@ func NewDirectory(
 ) *DirectoryImpl {
 	d := new(DirectoryImpl)
 	d.victimFinder = victimFinder
+	d.observer, _ = victimFinder.(AccessObserver)
+	d.inserter, _ = victimFinder.(InsertionObserver)
+	d.evictions, _ = victimFinder.(EvictionObserver)
 	d.Sets = make([]Set, set)
 
 	d.NumSets = set
@@ -75,7 +235,16 @@ func NewDirectory(
 
 // TotalSize returns the maximum number of bytes can be stored in the cache
 func (d *DirectoryImpl) TotalSize() uint64 {
-	return uint64(d.NumSets) * uint64(d.NumWays) * uint64(d.BlockSize)
+	if d.setWays == nil {
+		return uint64(d.NumSets) * uint64(d.NumWays) * uint64(d.BlockSize)
+	}
+
+	var blocks uint64
+	for _, ways := range d.setWays {
+		blocks += uint64(ways)
+	}
+
+	return blocks * uint64(d.BlockSize)
 }
 
 // Get the set that a certain address should store at
@@ -84,7 +253,7 @@ func (d *DirectoryImpl) getSet(reqAddr uint64) (set *Set, setID int) {
 		reqAddr = d.AddrConverter.ConvertExternalToInternal(reqAddr)
 	}
 
-	setID = int(reqAddr / uint64(d.BlockSize) % uint64(d.NumSets))
+	setID = d.setIndex(reqAddr / uint64(d.BlockSize))
 	set = &d.Sets[setID]
 
 	return
@@ -93,9 +262,24 @@ func (d *DirectoryImpl) getSet(reqAddr uint64) (set *Set, setID int) {
 // Lookup finds the block that reqAddr. If the reqAddr is valid
 // in the cache, return the block information. Otherwise, return nil
 func (d *DirectoryImpl) Lookup(PID vm.PID, reqAddr uint64) *Block {
+	block := d.findBlock(PID, reqAddr)
+	d.wayPrediction.recordLookup(d, reqAddr, block)
+
+	return block
+}
+
+// Probe finds the block that holds reqAddr like Lookup does, but leaves the
+// way prediction statistics and predictor untouched.
+func (d *DirectoryImpl) Probe(PID vm.PID, reqAddr uint64) *Block {
+	return d.findBlock(PID, reqAddr)
+}
+
+// findBlock returns the block that holds the line, without counting the
+// lookup as an access.
+func (d *DirectoryImpl) findBlock(PID vm.PID, reqAddr uint64) *Block {
 	set, _ := d.getSet(reqAddr)
 	for _, block := range set.Blocks {
-		if block.IsValid && block.Tag == reqAddr && block.PID == PID {
+		if d.tagMatches(block, PID, reqAddr) {
 			return block
 		}
 	}
@@ -108,122 +292,212 @@ func (d *DirectoryImpl) Lookup(PID vm.PID, reqAddr uint64) *Block {
 // If it is valid, the cache controller need to decide what to do to evict the
 // the data in the block
 func (d *DirectoryImpl) FindVictim(addr uint64) *Block {
-	set, _ := d.getSet(addr)
+	set, setID := d.getSet(addr)
 	block := d.victimFinder.FindVictim(set)
 
-	return block
+	return d.adjustVictim(addr, set, setID, nil, block)
 }
 
 // FindVictimWithContext returns a block that can be used to stored data at address addr.
-// Uses context information for perceptron-based victim selection.
+// Uses context information for prediction-based victim selection.
 func (d *DirectoryImpl) FindVictimWithContext(addr uint64, context *VictimContext) *Block {
-	set, _ := d.getSet(addr)
+	set, setID := d.getSet(addr)
+
+	block := d.victimFinder.FindVictimWithContext(set, context)
+
+	return d.adjustVictim(addr, set, setID, context, block)
+}
 
-	// Try perceptron victim finder first
-	if perceptronVF, ok := d.victimFinder.(*PerceptronVictimFinder); ok {
-		return perceptronVF.FindVictimWithContext(set, context)
+// adjustVictim applies the partitioning options to the victim selected by the
+// victim finder and remembers the final victim as the pending fill of the set.
+// The way partition is applied first, so that every later option ranks only
+// the ways that the access may fill.
+func (d *DirectoryImpl) adjustVictim(
+	addr uint64,
+	set *Set,
+	setID int,
+	context *VictimContext,
+	block *Block,
+) *Block {
+	vf, block := d.applyWayPartition(set, context, block)
+	block = d.applyQoS(vf, set, context, block)
+	block = d.applyEvictionHints(vf, set, context, block)
+	block = d.applyPrefetchProtection(vf, set, setID, context, block)
+	block = d.applyDirtyPartition(vf, set, context, block)
+	block = d.applyScanResistance(vf, set, context, block)
+	block = d.applyPinning(vf, set, context, block)
+	d.wayPartition.recordVictim(context, block)
+	d.dirtyPartition.recordVictim(block)
+	d.prefetch.recordVictim(block)
+	d.prefetchFeedback.recordVictim(addr, context, block)
+	d.dataset.recordVictim(addr, setID, context, block)
+	d.traceEviction(addr, setID, block)
+	d.sampledStats.recordVictim(setID, d.setAccesses[setID], block)
+	d.statsExporter.recordVictim(setID, block)
+	d.pendingFills[setID] = block
+	d.pendingContext[setID] = pendingFillContext{}
+	if context != nil {
+		d.pendingContext[setID] = pendingFillContext{
+			prefetch: context.IsPrefetch,
+			qosClass: context.QoSClass,
+			pc:       context.PC,
+
+			origin:     context.AccessOrigin,
+			accessSize: context.AccessSize,
+			accessType: context.AccessType,
+			sectors:    d.sectorMask(context.Address, context.AccessSize),
+
+			insertion: d.adviseInsertion(context),
+		}
+	}
+	d.applyAdmission(addr, setID, block)
+	d.pendingContext[setID].evicting = block != nil && block.IsValid
+	if d.pendingContext[setID].evicting {
+		d.pendingContext[setID].evicted = lineEvent(block)
 	}
 
-	// Fallback to regular FindVictim
-	return d.victimFinder.FindVictim(set)
+	return block
 }
 
 // Visit updates PseudoLRU bits (MICRO 2016 paper approach - very efficient)
 func (d *DirectoryImpl) Visit(block *Block) {
+	d.VisitWithContext(block, nil)
+}
+
+// VisitWithContext is Visit for an access whose context is known. The context
+// of a hit is passed to a ContextAccessObserver; a fill keeps the context
+// given to FindVictimWithContext. ctx may be nil.
+func (d *DirectoryImpl) VisitWithContext(block *Block, ctx *VictimContext) {
 	// PseudoLRU: Update binary tree bits to mark this way as recently used
 	set := &d.Sets[block.SetID]
+
+	isFill := d.pendingFills[block.SetID] == block
+	insertion := d.pendingContext[block.SetID].insertion
+
+	if isFill && d.evictions != nil && d.pendingContext[block.SetID].evicting {
+		d.evictions.Evict(set, block)
+	}
+
+	if isFill {
+		d.pendingFills[block.SetID] = nil
+		block.HitCount = 0
+		block.FillTime = d.setAccesses[block.SetID]
+		block.IsPrefetched = d.pendingContext[block.SetID].prefetch
+		block.QoSClass = d.pendingContext[block.SetID].qosClass
+		block.PC = d.pendingContext[block.SetID].pc
+		block.Origin = d.pendingContext[block.SetID].origin
+		block.AccessSize = d.pendingContext[block.SetID].accessSize
+		block.AccessType = d.pendingContext[block.SetID].accessType
+		block.IsPinned = false
+		d.wayPrediction.recordFill(d, block)
+		d.fillSectors(block, d.pendingContext[block.SetID].sectors)
+		d.notifyFill(block, d.pendingContext[block.SetID])
+		d.pendingContext[block.SetID] = pendingFillContext{}
+	} else {
+		block.HitCount++
+	}
+
+	block.Referenced = true
+	if isFill {
+		block.Age = 0
+	}
+
+	d.setAccesses[block.SetID]++
+	d.tickAging()
+	d.prefetch.recordAccess(block, isFill)
+	d.qos.recordAccess(block, isFill)
+	d.dataset.recordAccess(block, isFill)
+	d.sampledStats.recordAccess(block, isFill)
+	d.missClassifier.recordAccess(block, isFill)
+	d.tinyLFU.recordAccess(block)
+	d.shadow.recordAccess(block, isFill, d.energy)
+	d.predictBlock(block)
+
+	d.workingSet.Record(block.PID, block.Tag)
+	d.recordHotColdAccess()
+
+	if d.thrashing != nil && !d.recordThrashingAccess(block.SetID, isFill) {
+		return
+	}
+
+	if isFill && d.inserter != nil {
+		d.inserter.Insert(set, block)
+	} else if d.observer != nil {
+		d.touch(set, block, ctx)
+	}
+
+	if isFill && insertion != InsertMRU {
+		d.demoteFill(set, block)
+		d.energy.Charge(EnergyPLRUUpdate, 1)
+
+		return
+	}
+
 	d.updatePseudoLRU(set, block.WayID)
+	d.energy.Charge(EnergyPLRUUpdate, 1)
 }
 
-// updatePseudoLRU updates the PseudoLRU tree bits for a given way
-func (d *DirectoryImpl) updatePseudoLRU(set *Set, wayID int) {
-	numWays := len(set.Blocks)
-
-	// For common associativities, use optimized bit patterns
-	switch numWays {
-	case 2:
-		// 2-way: 1 bit (bit 0)
-		// Way 0 accessed -> set bit 0 to 1, Way 1 accessed -> set bit 0 to 0
-		if wayID == 0 {
-			set.PseudoLRUBits |= 1 // Set bit 0
-		} else {
-			set.PseudoLRUBits &= ^uint64(1) // Clear bit 0
-		}
-	case 4:
-		// 4-way: 3 bits (tree structure)
-		//     bit0
-		//    /    \
-		//  bit1   bit2
-		//  / \    / \
-		// W0 W1  W2 W3
-		if wayID < 2 {
-			set.PseudoLRUBits &= ^uint64(1) // Clear bit 0 (left subtree)
-			if wayID == 0 {
-				set.PseudoLRUBits |= (1 << 1) // Set bit 1
-			} else {
-				set.PseudoLRUBits &= ^uint64(1 << 1) // Clear bit 1
-			}
-		} else {
-			set.PseudoLRUBits |= 1 // Set bit 0 (right subtree)
-			if wayID == 2 {
-				set.PseudoLRUBits |= (1 << 2) // Set bit 2
-			} else {
-				set.PseudoLRUBits &= ^uint64(1 << 2) // Clear bit 2
-			}
-		}
-	case 8:
-		// 8-way: 7 bits (full binary tree)
-		d.updatePseudoLRU8Way(set, wayID)
-	default:
-		// Fallback: use simple round-robin for other associativities
-		set.PseudoLRUBits = (set.PseudoLRUBits + 1) % uint64(numWays)
+// touch tells the observer about a hit, with its context if the observer
+// takes one.
+func (d *DirectoryImpl) touch(set *Set, block *Block, ctx *VictimContext) {
+	if o, ok := d.observer.(ContextAccessObserver); ok && ctx != nil {
+		o.TouchWithContext(set, block, ctx)
+		return
 	}
+
+	d.observer.Touch(set, block)
 }
 
-// updatePseudoLRU8Way handles 8-way associative PseudoLRU
-func (d *DirectoryImpl) updatePseudoLRU8Way(set *Set, wayID int) {
-	// 8-way PseudoLRU tree: 7 bits
-	//        bit0
-	//      /      \
-	//    bit1     bit2
-	//   /   \    /    \
-	// bit3 bit4 bit5 bit6
-	// /|   |\ /|   |\
-	//W0W1 W2W3W4W5 W6W7
-
-	if wayID < 4 {
-		set.PseudoLRUBits &= ^uint64(1) // Clear bit 0 (left subtree)
-		if wayID < 2 {
-			set.PseudoLRUBits &= ^uint64(1 << 1) // Clear bit 1
-			if wayID == 0 {
-				set.PseudoLRUBits |= (1 << 3) // Set bit 3
-			} else {
-				set.PseudoLRUBits &= ^uint64(1 << 3) // Clear bit 3
-			}
-		} else {
-			set.PseudoLRUBits |= (1 << 1) // Set bit 1
-			if wayID == 2 {
-				set.PseudoLRUBits |= (1 << 4) // Set bit 4
-			} else {
-				set.PseudoLRUBits &= ^uint64(1 << 4) // Clear bit 4
-			}
-		}
-	} else {
-		set.PseudoLRUBits |= 1 // Set bit 0 (right subtree)
-		if wayID < 6 {
-			set.PseudoLRUBits &= ^uint64(1 << 2) // Clear bit 2
-			if wayID == 4 {
-				set.PseudoLRUBits |= (1 << 5) // Set bit 5
-			} else {
-				set.PseudoLRUBits &= ^uint64(1 << 5) // Clear bit 5
-			}
+// SetThrashingDetector attaches a thrashing detector. Thrashing sets switch to
+// bimodal insertion, where most fills are left at the LRU position.
+func (d *DirectoryImpl) SetThrashingDetector(t *ThrashingDetector) {
+	d.thrashing = t
+}
+
+// ThrashingDetector returns the attached thrashing detector, if any.
+func (d *DirectoryImpl) ThrashingDetector() *ThrashingDetector {
+	return d.thrashing
+}
+
+// recordThrashingAccess reports the access to the thrashing detector and
+// returns false if the block should be left at the LRU position.
+func (d *DirectoryImpl) recordThrashingAccess(setID int, isFill bool) bool {
+	if !isFill {
+		d.thrashing.RecordHit(setID)
+		return true
+	}
+
+	d.thrashing.RecordMiss(setID)
+
+	return !d.thrashing.InsertAtLRU(setID)
+}
+
+// SetEnergyMeter attaches an energy meter that is charged for every PseudoLRU
+// state update and every access to the shadow directory.
+func (d *DirectoryImpl) SetEnergyMeter(m *EnergyMeter) {
+	d.energy = m
+}
+
+// updatePseudoLRU updates the PseudoLRU tree bits for a given way. Every node
+// on the path to the way is pointed at the other subtree, so the way is not
+// the next victim. See getPseudoLRUVictim for the tree layout.
+func (d *DirectoryImpl) updatePseudoLRU(set *Set, wayID int) {
+	if len(set.Blocks) > maxPseudoLRUWays {
+		set.moveLRUWay(wayID, false)
+		return
+	}
+
+	node, lo, hi := 0, 0, len(set.Blocks)
+
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+
+		if wayID < mid {
+			set.PseudoLRUBits |= 1 << uint(node)
+			node, hi = 2*node+1, mid
 		} else {
-			set.PseudoLRUBits |= (1 << 2) // Set bit 2
-			if wayID == 6 {
-				set.PseudoLRUBits |= (1 << 6) // Set bit 6
-			} else {
-				set.PseudoLRUBits &= ^uint64(1 << 6) // Clear bit 6
-			}
+			set.PseudoLRUBits &^= 1 << uint(node)
+			node, lo = 2*node+2, mid
 		}
 	}
 }
@@ -235,9 +509,12 @@ func (d *DirectoryImpl) GetSets() []Set {
 
 // Reset will mark all the blocks in the directory invalid
 func (d *DirectoryImpl) Reset() {
+	d.pendingFills = make([]*Block, d.NumSets)
+	d.pendingContext = make([]pendingFillContext, d.NumSets)
+	d.setAccesses = make([]uint64, d.NumSets)
 	d.Sets = make([]Set, d.NumSets)
 	for i := 0; i < d.NumSets; i++ {
-		for j := 0; j < d.NumWays; j++ {
+		for j := 0; j < d.waysOf(i); j++ {
 			block := new(Block)
 			block.IsValid = false
 			block.SetID = i
@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// TagMode selects what a directory compares to find a line.
+type TagMode int
+
+// Tag modes.
+const (
+	// TagVirtual matches the address and the PID, since the same virtual
+	// address of two processes names two different lines.
+	TagVirtual TagMode = iota
+
+	// TagPhysical matches the address only, so that the processes that
+	// share physical memory share its lines.
+	TagPhysical
+)
+
+// TagMode returns what the directory compares to find a line.
+func (d *DirectoryImpl) TagMode() TagMode {
+	return d.tagMode
+}
+
+// tagMatches tells if the block holds the line of the address for the
+// process.
+func (d *DirectoryImpl) tagMatches(block *Block, pid vm.PID, addr uint64) bool {
+	return block.IsValid && block.Tag == addr &&
+		(d.tagMode == TagPhysical || block.PID == pid)
+}
+
+// waysOf returns the number of ways of the set.
+func (d *DirectoryImpl) waysOf(setID int) int {
+	if d.setWays == nil {
+		return d.NumWays
+	}
+
+	return d.setWays[setID]
+}
+
+// A SetRegion gives NumSets consecutive sets, from FirstSet, fewer ways than
+// the rest of the directory.
+type SetRegion struct {
+	FirstSet int
+	NumSets  int
+	NumWays  int
+}
+
+// A DirectoryBuilder builds DirectoryImpls and validates their
+// configuration.
+type DirectoryBuilder struct {
+	numSets       int
+	numWays       int
+	blockSize     int
+	victimFinder  VictimFinder
+	indexFunction IndexFunction
+	addrConverter mem.AddressConverter
+	tagMode       TagMode
+	regions       []SetRegion
+}
+
+// MakeDirectoryBuilder creates a builder of a 64-set, 4-way directory of
+// 64-byte blocks with PseudoLRU replacement.
+func MakeDirectoryBuilder() DirectoryBuilder {
+	return DirectoryBuilder{
+		numSets:   64,
+		numWays:   4,
+		blockSize: 64,
+	}
+}
+
+// WithNumSets sets the number of sets.
+func (b DirectoryBuilder) WithNumSets(n int) DirectoryBuilder {
+	b.numSets = n
+	return b
+}
+
+// WithNumWays sets the number of ways of every set outside the set regions.
+func (b DirectoryBuilder) WithNumWays(n int) DirectoryBuilder {
+	b.numWays = n
+	return b
+}
+
+// WithBlockSize sets the number of bytes per block, which must be a power of
+// two.
+func (b DirectoryBuilder) WithBlockSize(n int) DirectoryBuilder {
+	b.blockSize = n
+	return b
+}
+
+// WithVictimFinder sets the replacement policy.
+func (b DirectoryBuilder) WithVictimFinder(vf VictimFinder) DirectoryBuilder {
+	b.victimFinder = vf
+	return b
+}
+
+// WithIndexFunction sets how lines are mapped to sets.
+func (b DirectoryBuilder) WithIndexFunction(f IndexFunction) DirectoryBuilder {
+	b.indexFunction = f
+	return b
+}
+
+// WithAddressConverter sets the conversion applied to the addresses before
+// the set index is extracted.
+func (b DirectoryBuilder) WithAddressConverter(
+	c mem.AddressConverter,
+) DirectoryBuilder {
+	b.addrConverter = c
+	return b
+}
+
+// WithTagMode sets what the directory compares to find a line. The default
+// is TagVirtual.
+func (b DirectoryBuilder) WithTagMode(m TagMode) DirectoryBuilder {
+	b.tagMode = m
+	return b
+}
+
+// WithSetRegion gives a range of sets fewer ways, e.g., for the sets of a
+// way-partitioned design that lend ways to another structure. Regions must
+// not overlap.
+func (b DirectoryBuilder) WithSetRegion(
+	firstSet, numSets, numWays int,
+) DirectoryBuilder {
+	b.regions = append(append([]SetRegion(nil), b.regions...),
+		SetRegion{FirstSet: firstSet, NumSets: numSets, NumWays: numWays})
+
+	return b
+}
+
+// Build creates the directory. It panics if the configuration is invalid.
+func (b DirectoryBuilder) Build() *DirectoryImpl {
+	setWays := b.validate()
+
+	vf := b.victimFinder
+	if vf == nil {
+		vf = NewLRUVictimFinder()
+	}
+
+	d := NewDirectory(b.numSets, b.numWays, b.blockSize, vf)
+	d.AddrConverter = b.addrConverter
+	d.tagMode = b.tagMode
+	d.SetIndexFunction(b.indexFunction)
+
+	if setWays != nil {
+		d.setWays = setWays
+		d.Reset()
+	}
+
+	return d
+}
+
+// validate panics if the configuration is invalid. It returns the number of
+// ways of every set, or nil if all the sets have the same number of ways.
+func (b DirectoryBuilder) validate() []int {
+	if b.numSets <= 0 || b.numWays <= 0 {
+		panic(fmt.Sprintf("a directory needs at least one set and way, "+
+			"not %d sets and %d ways", b.numSets, b.numWays))
+	}
+
+	if b.blockSize <= 0 || bits.OnesCount(uint(b.blockSize)) != 1 {
+		panic(fmt.Sprintf("block size %d is not a power of two", b.blockSize))
+	}
+
+	if err := checkIndexFunction(b.indexFunction, b.numSets); err != nil {
+		panic(err)
+	}
+
+	if b.tagMode != TagVirtual && b.tagMode != TagPhysical {
+		panic(fmt.Sprintf("unknown tag mode %d", b.tagMode))
+	}
+
+	if len(b.regions) == 0 {
+		return nil
+	}
+
+	setWays := make([]int, b.numSets)
+	for i := range setWays {
+		setWays[i] = b.numWays
+	}
+
+	inRegion := make([]bool, b.numSets)
+
+	for _, r := range b.regions {
+		if r.FirstSet < 0 || r.NumSets <= 0 ||
+			r.FirstSet+r.NumSets > b.numSets {
+			panic(fmt.Sprintf("set region %+v is out of the %d sets",
+				r, b.numSets))
+		}
+
+		if r.NumWays <= 0 || r.NumWays > b.numWays {
+			panic(fmt.Sprintf("set region %+v needs 1 to %d ways",
+				r, b.numWays))
+		}
+
+		for i := r.FirstSet; i < r.FirstSet+r.NumSets; i++ {
+			if inRegion[i] {
+				panic(fmt.Sprintf("set region %+v overlaps another one", r))
+			}
+
+			inRegion[i] = true
+			setWays[i] = r.NumWays
+		}
+	}
+
+	return setWays
+}
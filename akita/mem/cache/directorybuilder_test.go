@@ -0,0 +1,71 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DirectoryBuilder", func() {
+	It("should build a directory with the defaults", func() {
+		d := MakeDirectoryBuilder().Build()
+
+		Expect(d.NumSets).To(Equal(64))
+		Expect(d.WayAssociativity()).To(Equal(4))
+		Expect(d.TotalSize()).To(Equal(uint64(64 * 4 * 64)))
+		Expect(d.TagMode()).To(Equal(TagVirtual))
+	})
+
+	It("should reject invalid configurations", func() {
+		Expect(func() {
+			MakeDirectoryBuilder().WithBlockSize(48).Build()
+		}).To(PanicWith(ContainSubstring("power of two")))
+
+		Expect(func() {
+			MakeDirectoryBuilder().WithNumSets(0).Build()
+		}).To(Panic())
+
+		Expect(func() {
+			MakeDirectoryBuilder().
+				WithNumSets(12).
+				WithIndexFunction(IndexBitSelect).
+				Build()
+		}).To(Panic())
+
+		Expect(func() {
+			MakeDirectoryBuilder().
+				WithSetRegion(0, 8, 2).
+				WithSetRegion(4, 8, 2).
+				Build()
+		}).To(PanicWith(ContainSubstring("overlaps")))
+	})
+
+	It("should give a set region fewer ways", func() {
+		d := MakeDirectoryBuilder().
+			WithNumSets(4).
+			WithSetRegion(2, 2, 1).
+			Build()
+
+		Expect(d.Sets[1].Blocks).To(HaveLen(4))
+		Expect(d.Sets[2].Blocks).To(HaveLen(1))
+		Expect(d.TotalSize()).To(Equal(uint64(10 * 64)))
+
+		victim := d.FindVictim(2 * 64)
+		Expect(victim.SetID).To(Equal(2))
+	})
+
+	It("should share lines between processes with physical tags", func() {
+		virtual := MakeDirectoryBuilder().Build()
+		physical := MakeDirectoryBuilder().WithTagMode(TagPhysical).Build()
+
+		for _, d := range []*DirectoryImpl{virtual, physical} {
+			block := d.FindVictim(0x40)
+			block.Tag = 0x40
+			block.PID = 1
+			block.IsValid = true
+			d.Visit(block)
+		}
+
+		Expect(virtual.Lookup(2, 0x40)).To(BeNil())
+		Expect(physical.Lookup(2, 0x40)).NotTo(BeNil())
+	})
+})
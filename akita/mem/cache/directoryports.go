@@ -0,0 +1,139 @@
+package cache
+
+// A DirectoryPortOp is an operation that occupies a directory port for one
+// cycle.
+type DirectoryPortOp int
+
+// Operations that use a directory port.
+const (
+	PortLookup DirectoryPortOp = iota
+	PortVictimSelection
+)
+
+// A PortArbitration decides what happens to the operations that find all the
+// ports busy.
+type PortArbitration int
+
+// Arbitration policies of DirectoryPorts.
+const (
+	// Excess operations wait and try again in the next cycle.
+	PortArbitrationQueue PortArbitration = iota
+
+	// Excess operations are rejected and the requester retries after
+	// RetryCycles cycles.
+	PortArbitrationReject
+)
+
+// A PortGrant is the outcome of a port request.
+type PortGrant int
+
+// Outcomes of DirectoryPorts.Acquire.
+const (
+	PortGranted PortGrant = iota
+	PortQueued
+	PortRejected
+)
+
+// DirectoryPortConfig configures DirectoryPorts.
+type DirectoryPortConfig struct {
+	NumPorts    int
+	Arbitration PortArbitration
+
+	// Cycles that a rejected requester waits before retrying. Defaults to 1.
+	RetryCycles int
+}
+
+// DirectoryPortStats counts the port usage and conflicts.
+type DirectoryPortStats struct {
+	Cycles           uint64
+	Lookups          uint64
+	VictimSelections uint64
+
+	// Number of port-cycles in use.
+	BusyPortCycles uint64
+
+	// Requests that found all the ports busy, split by the outcome.
+	Queued   uint64
+	Rejected uint64
+}
+
+// DirectoryPorts models a directory that serves a limited number of lookups
+// and victim selections per cycle. A nil DirectoryPorts grants every
+// request.
+type DirectoryPorts struct {
+	config DirectoryPortConfig
+	used   int
+	stats  DirectoryPortStats
+}
+
+// NewDirectoryPorts creates a port model.
+func NewDirectoryPorts(config DirectoryPortConfig) *DirectoryPorts {
+	if config.NumPorts <= 0 {
+		panic("directory must have at least one port")
+	}
+
+	if config.RetryCycles <= 0 {
+		config.RetryCycles = 1
+	}
+
+	return &DirectoryPorts{config: config}
+}
+
+// Config returns the configuration of the ports.
+func (p *DirectoryPorts) Config() DirectoryPortConfig {
+	return p.config
+}
+
+// NextCycle releases all the ports.
+func (p *DirectoryPorts) NextCycle() {
+	if p == nil {
+		return
+	}
+
+	p.stats.Cycles++
+	p.stats.BusyPortCycles += uint64(p.used)
+	p.used = 0
+}
+
+// Acquire requests one port for every operation in the current cycle. Either
+// all the operations are granted or none is.
+func (p *DirectoryPorts) Acquire(ops ...DirectoryPortOp) PortGrant {
+	if p == nil {
+		return PortGranted
+	}
+
+	if p.used+len(ops) > p.config.NumPorts && p.used > 0 {
+		if p.config.Arbitration == PortArbitrationReject {
+			p.stats.Rejected++
+			return PortRejected
+		}
+
+		p.stats.Queued++
+
+		return PortQueued
+	}
+
+	// An idle directory serves an operation group that is wider than the
+	// port count in a single cycle, so that it cannot starve.
+	p.used += len(ops)
+
+	for _, op := range ops {
+		switch op {
+		case PortLookup:
+			p.stats.Lookups++
+		case PortVictimSelection:
+			p.stats.VictimSelections++
+		}
+	}
+
+	return PortGranted
+}
+
+// Stats returns the port statistics.
+func (p *DirectoryPorts) Stats() DirectoryPortStats {
+	if p == nil {
+		return DirectoryPortStats{}
+	}
+
+	return p.stats
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DirectoryPorts", func() {
+	It("should queue the operations beyond the port count", func() {
+		p := NewDirectoryPorts(DirectoryPortConfig{NumPorts: 2})
+
+		Expect(p.Acquire(PortLookup)).To(Equal(PortGranted))
+		Expect(p.Acquire(PortLookup, PortVictimSelection)).
+			To(Equal(PortQueued))
+		Expect(p.Acquire(PortLookup)).To(Equal(PortGranted))
+		p.NextCycle()
+		Expect(p.Acquire(PortLookup, PortVictimSelection)).
+			To(Equal(PortGranted))
+		p.NextCycle()
+
+		Expect(p.Stats()).To(Equal(DirectoryPortStats{
+			Cycles:           2,
+			Lookups:          3,
+			VictimSelections: 1,
+			BusyPortCycles:   4,
+			Queued:           1,
+		}))
+	})
+
+	It("should reject the operations beyond the port count", func() {
+		p := NewDirectoryPorts(DirectoryPortConfig{
+			NumPorts:    1,
+			Arbitration: PortArbitrationReject,
+		})
+
+		Expect(p.Acquire(PortLookup)).To(Equal(PortGranted))
+		Expect(p.Acquire(PortLookup)).To(Equal(PortRejected))
+		Expect(p.Config().RetryCycles).To(Equal(1))
+		Expect(p.Stats().Rejected).To(Equal(uint64(1)))
+	})
+
+	It("should grant a wide operation group on an idle directory", func() {
+		p := NewDirectoryPorts(DirectoryPortConfig{NumPorts: 1})
+
+		Expect(p.Acquire(PortLookup, PortVictimSelection)).
+			To(Equal(PortGranted))
+	})
+
+	It("should grant everything if nil", func() {
+		var p *DirectoryPorts
+
+		p.NextCycle()
+
+		Expect(p.Acquire(PortLookup)).To(Equal(PortGranted))
+		Expect(p.Stats()).To(BeZero())
+	})
+})
@@ -0,0 +1,127 @@
+package cache
+
+// DirtyPartitionStats measures how evenly the writebacks caused by evictions
+// are spread over time.
+type DirtyPartitionStats struct {
+	Victims    uint64
+	Writebacks uint64
+
+	// Windows is the number of completed windows; PeakWritebacks is the
+	// largest number of writebacks in any of them.
+	Windows        uint64
+	PeakWritebacks uint64
+}
+
+// PeakToMean returns the ratio between the writebacks of the worst window and
+// the average window. Values close to 1 mean that writebacks are smooth.
+func (s DirtyPartitionStats) PeakToMean() float64 {
+	if s.Windows == 0 || s.Writebacks == 0 {
+		return 0
+	}
+
+	mean := float64(s.Writebacks) / float64(s.Windows)
+
+	return float64(s.PeakWritebacks) / mean
+}
+
+// A DirtyPartition steers dirty data to the first Ways ways of every set.
+// Fills for writes replace blocks in the dirty ways and fills for reads
+// replace blocks in the other ways, so that writebacks only happen when new
+// dirty data arrives instead of in bursts when clean data streams in. Blocks
+// that become dirty by write hits stay where they are.
+//
+// With Ways set to 0, the victims are not changed, and only the writeback
+// statistics are collected.
+type DirtyPartition struct {
+	Ways int
+
+	// Window is the number of victim selections per statistics window.
+	Window uint64
+
+	stats            DirtyPartitionStats
+	windowVictims    uint64
+	windowWritebacks uint64
+}
+
+// NewDirtyPartition creates a partition that reserves the given number of
+// ways for dirty data.
+func NewDirtyPartition(ways int) *DirtyPartition {
+	return &DirtyPartition{
+		Ways:   ways,
+		Window: 256,
+	}
+}
+
+// Stats returns the writeback statistics.
+func (p *DirtyPartition) Stats() DirtyPartitionStats {
+	return p.stats
+}
+
+// SetDirtyPartition attaches a dirty-way partition to the directory. Passing
+// nil removes it.
+func (d *DirectoryImpl) SetDirtyPartition(p *DirtyPartition) {
+	if p != nil && (p.Ways < 0 || p.Ways >= d.NumWays) {
+		panic("dirty partition must leave at least one clean way")
+	}
+
+	d.dirtyPartition = p
+}
+
+// applyDirtyPartition replaces the victim with the highest ranked candidate in
+// the partition that matches the access type.
+func (d *DirectoryImpl) applyDirtyPartition(
+	vf VictimFinder,
+	set *Set,
+	context *VictimContext,
+	victim *Block,
+) *Block {
+	p := d.dirtyPartition
+	if p == nil || p.Ways == 0 {
+		return victim
+	}
+
+	wantDirtyWay := context != nil && context.AccessType == "write"
+	inPartition := func(b *Block) bool {
+		return (b.WayID < p.Ways) == wantDirtyWay
+	}
+
+	if victim != nil && !victim.IsLocked && inPartition(victim) {
+		return victim
+	}
+
+	for _, block := range FindVictims(vf, set, context, len(set.Blocks)) {
+		if inPartition(block) {
+			return block
+		}
+	}
+
+	return victim
+}
+
+// recordVictim updates the writeback statistics. Calls on a nil partition are
+// ignored.
+func (p *DirtyPartition) recordVictim(victim *Block) {
+	if p == nil || victim == nil {
+		return
+	}
+
+	p.stats.Victims++
+	p.windowVictims++
+
+	if victim.IsValid && victim.IsDirty {
+		p.stats.Writebacks++
+		p.windowWritebacks++
+	}
+
+	if p.Window == 0 || p.windowVictims < p.Window {
+		return
+	}
+
+	p.stats.Windows++
+	if p.windowWritebacks > p.stats.PeakWritebacks {
+		p.stats.PeakWritebacks = p.windowWritebacks
+	}
+
+	p.windowVictims = 0
+	p.windowWritebacks = 0
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DirtyPartition", func() {
+	var (
+		d *DirectoryImpl
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 4, 64, NewLRUVictimFinder())
+	})
+
+	access := func(addr uint64, write bool) *Block {
+		block := d.Lookup(0, addr)
+		if block == nil {
+			ctx := &VictimContext{Address: addr, AccessType: "read"}
+			if write {
+				ctx.AccessType = "write"
+			}
+
+			block = d.FindVictimWithContext(addr, ctx)
+			block.Tag = addr
+			block.IsValid = true
+			block.IsDirty = false
+		}
+
+		if write {
+			block.IsDirty = true
+		}
+
+		d.Visit(block)
+
+		return block
+	}
+
+	It("should steer write fills to the dirty ways", func() {
+		d.SetDirtyPartition(NewDirtyPartition(1))
+
+		for i := uint64(0); i < 16; i++ {
+			block := access(i*64, i%4 == 0)
+			if i%4 == 0 {
+				Expect(block.WayID).To(Equal(0))
+			} else {
+				Expect(block.WayID).NotTo(Equal(0))
+			}
+		}
+	})
+
+	It("should limit the writebacks of a read burst", func() {
+		burst := func(ways int) uint64 {
+			d = NewDirectory(1, 4, 64, NewLRUVictimFinder())
+			p := NewDirtyPartition(ways)
+			d.SetDirtyPartition(p)
+
+			for i := uint64(0); i < 8; i++ {
+				access(i*64, true)
+			}
+
+			before := p.Stats().Writebacks
+			for i := uint64(8); i < 12; i++ {
+				access(i*64, false)
+			}
+
+			return p.Stats().Writebacks - before
+		}
+
+		Expect(burst(0)).NotTo(BeZero())
+		Expect(burst(1)).To(BeZero())
+	})
+
+	It("should track the peak writebacks per window", func() {
+		p := NewDirtyPartition(0)
+		p.Window = 4
+		dirty := &Block{IsValid: true, IsDirty: true}
+		clean := &Block{IsValid: true}
+
+		for i := 0; i < 16; i++ {
+			if i < 4 || i%4 == 0 {
+				p.recordVictim(dirty)
+			} else {
+				p.recordVictim(clean)
+			}
+		}
+
+		Expect(p.Stats().Windows).To(Equal(uint64(4)))
+		Expect(p.Stats().Writebacks).To(Equal(uint64(7)))
+		Expect(p.Stats().PeakWritebacks).To(Equal(uint64(4)))
+		Expect(p.Stats().PeakToMean()).To(BeNumerically("~", 16.0/7))
+	})
+
+	It("should reject partitions without clean ways", func() {
+		Expect(func() { d.SetDirtyPartition(NewDirtyPartition(4)) }).
+			To(Panic())
+	})
+})
@@ -0,0 +1,208 @@
+package cache
+
+import "fmt"
+
+// DuelingPolicy is the policy used by a set of a DuelingVictimFinder.
+type DuelingPolicy int
+
+// Dueling policies.
+const (
+	DuelPerceptron DuelingPolicy = iota
+	DuelPseudoLRU
+)
+
+func (p DuelingPolicy) String() string {
+	switch p {
+	case DuelPerceptron:
+		return "perceptron"
+	case DuelPseudoLRU:
+		return "plru"
+	default:
+		return fmt.Sprintf("DuelingPolicy(%d)", int(p))
+	}
+}
+
+const (
+	// duelPSELMax is the saturation value of the 10-bit policy selector.
+	duelPSELMax = 1023
+
+	// defaultDuelLeaderInterval makes one set out of every 32 a perceptron
+	// leader, and the next one a PseudoLRU leader.
+	defaultDuelLeaderInterval = 32
+)
+
+// DuelStats describes the state of a set duel.
+type DuelStats struct {
+	PSEL int
+
+	// The policy currently used by the follower sets.
+	Winner DuelingPolicy
+
+	// Misses of the leader sets of every policy.
+	PerceptronLeaderMisses uint64
+	PseudoLRULeaderMisses  uint64
+
+	// Victims selected in the follower sets by every policy.
+	PerceptronFollowerVictims uint64
+	PseudoLRUFollowerVictims  uint64
+}
+
+// A DuelingVictimFinder chooses between a perceptron and PseudoLRU with set
+// dueling (Qureshi et al., ISCA 2007), so that a workload on which the
+// perceptron mispredicts falls back to PseudoLRU. A few leader sets always
+// use the perceptron and as many always use PseudoLRU. A miss in a leader set
+// moves the policy selector toward the other policy, and the follower sets
+// use the policy that the selector favors.
+//
+// The selector updates are charged to the energy meter of the perceptron as
+// shadow accesses. The perceptron keeps training on every set through the
+// ReuseTrainer methods. The insertion advice of the perceptron is not forwarded, so that
+// the PseudoLRU sets stay pure.
+type DuelingVictimFinder struct {
+	perceptron     *PerceptronVictimFinder
+	leaderInterval int
+	stats          DuelStats
+}
+
+// NewDuelingVictimFinder creates a duel between the perceptron and
+// PseudoLRU. One set out of every leaderInterval leads for the perceptron,
+// and the next one for PseudoLRU; 0 selects 32. The interval must be at
+// least 2.
+func NewDuelingVictimFinder(
+	p *PerceptronVictimFinder,
+	leaderInterval int,
+) *DuelingVictimFinder {
+	if leaderInterval == 0 {
+		leaderInterval = defaultDuelLeaderInterval
+	}
+
+	if leaderInterval < 2 {
+		panic(fmt.Sprintf("leader interval %d must be at least 2",
+			leaderInterval))
+	}
+
+	return &DuelingVictimFinder{
+		perceptron:     p,
+		leaderInterval: leaderInterval,
+		stats:          DuelStats{PSEL: duelPSELMax / 2},
+	}
+}
+
+// Perceptron returns the perceptron of the duel.
+func (d *DuelingVictimFinder) Perceptron() *PerceptronVictimFinder {
+	return d.perceptron
+}
+
+// eachPerceptron calls f with the perceptron of the duel.
+func (d *DuelingVictimFinder) eachPerceptron(
+	f func(p *PerceptronVictimFinder),
+) {
+	f(d.perceptron)
+}
+
+// DuelStats returns the state of the duel.
+func (d *DuelingVictimFinder) DuelStats() DuelStats {
+	s := d.stats
+	s.Winner = d.winner()
+
+	return s
+}
+
+// winner returns the policy of the follower sets. Values of the selector
+// above the midpoint favor PseudoLRU.
+func (d *DuelingVictimFinder) winner() DuelingPolicy {
+	if d.stats.PSEL > duelPSELMax/2 {
+		return DuelPseudoLRU
+	}
+
+	return DuelPerceptron
+}
+
+// PolicyOf returns the policy that the set currently uses.
+func (d *DuelingVictimFinder) PolicyOf(setID int) DuelingPolicy {
+	switch setID % d.leaderInterval {
+	case 0:
+		return DuelPerceptron
+	case 1:
+		return DuelPseudoLRU
+	}
+
+	return d.winner()
+}
+
+// duel records a miss in the set, which needs a victim, and returns the
+// policy that selects the victim.
+func (d *DuelingVictimFinder) duel(set *Set) DuelingPolicy {
+	if len(set.Blocks) == 0 {
+		return d.winner()
+	}
+
+	s := &d.stats
+
+	switch set.Blocks[0].SetID % d.leaderInterval {
+	case 0:
+		d.perceptron.energy.Charge(EnergyShadowAccess, 1)
+		s.PerceptronLeaderMisses++
+		if s.PSEL < duelPSELMax {
+			s.PSEL++
+		}
+
+		return DuelPerceptron
+	case 1:
+		d.perceptron.energy.Charge(EnergyShadowAccess, 1)
+		s.PseudoLRULeaderMisses++
+		if s.PSEL > 0 {
+			s.PSEL--
+		}
+
+		return DuelPseudoLRU
+	}
+
+	policy := d.winner()
+	if policy == DuelPerceptron {
+		s.PerceptronFollowerVictims++
+	} else {
+		s.PseudoLRUFollowerVictims++
+	}
+
+	return policy
+}
+
+// FindVictim selects the victim with the policy of the set.
+func (d *DuelingVictimFinder) FindVictim(set *Set) *Block {
+	if d.duel(set) == DuelPseudoLRU {
+		return pseudoLRUVictims.FindVictim(set)
+	}
+
+	return d.perceptron.FindVictim(set)
+}
+
+// FindVictimWithContext selects the victim with the policy of the set.
+func (d *DuelingVictimFinder) FindVictimWithContext(
+	set *Set,
+	context *VictimContext,
+) *Block {
+	if d.duel(set) == DuelPseudoLRU {
+		return pseudoLRUVictims.FindVictim(set)
+	}
+
+	return d.perceptron.FindVictimWithContext(set, context)
+}
+
+// TrainOnHitWithContext trains the perceptron on a hit.
+func (d *DuelingVictimFinder) TrainOnHitWithContext(ctx *VictimContext) {
+	d.perceptron.TrainOnHitWithContext(ctx)
+}
+
+// TrainOnEvictionWithContext trains the perceptron on an eviction.
+func (d *DuelingVictimFinder) TrainOnEvictionWithContext(ctx *VictimContext) {
+	d.perceptron.TrainOnEvictionWithContext(ctx)
+}
+
+// PredictDead asks the perceptron.
+func (d *DuelingVictimFinder) PredictDead(
+	addr uint64,
+	ctx *VictimContext,
+) (dead bool, confidence int32) {
+	return d.perceptron.PredictDead(addr, ctx)
+}
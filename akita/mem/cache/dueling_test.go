@@ -0,0 +1,78 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DuelingVictimFinder", func() {
+	var (
+		p     *PerceptronVictimFinder
+		duel  *DuelingVictimFinder
+		d     *DirectoryImpl
+		ctx   *VictimContext
+		total func() int64
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		duel = NewDuelingVictimFinder(p, 4)
+		d = NewDirectory(8, 2, 64, duel)
+		ctx = &VictimContext{}
+		total = func() int64 {
+			n, _, _ := p.GetStats()
+			return n
+		}
+	})
+
+	It("should dedicate leader sets to every policy", func() {
+		Expect(duel.PolicyOf(0)).To(Equal(DuelPerceptron))
+		Expect(duel.PolicyOf(4)).To(Equal(DuelPerceptron))
+		Expect(duel.PolicyOf(1)).To(Equal(DuelPseudoLRU))
+		Expect(duel.PolicyOf(5)).To(Equal(DuelPseudoLRU))
+		Expect(duel.PolicyOf(2)).To(Equal(DuelPerceptron))
+	})
+
+	It("should only consult the perceptron in its sets", func() {
+		d.FindVictimWithContext(1*64, ctx)
+		Expect(total()).To(BeZero())
+
+		d.FindVictimWithContext(0, ctx)
+		Expect(total()).To(Equal(int64(1)))
+	})
+
+	It("should make the followers use the winning leader", func() {
+		d.FindVictimWithContext(0, ctx)
+		Expect(duel.DuelStats().Winner).To(Equal(DuelPseudoLRU))
+		Expect(duel.PolicyOf(2)).To(Equal(DuelPseudoLRU))
+
+		d.FindVictimWithContext(2*64, ctx)
+		Expect(total()).To(Equal(int64(1)))
+
+		d.FindVictimWithContext(1*64, ctx)
+		d.FindVictimWithContext(5*64, ctx)
+		d.FindVictimWithContext(3*64, ctx)
+		Expect(total()).To(Equal(int64(2)))
+
+		Expect(duel.DuelStats()).To(Equal(DuelStats{
+			PSEL:                      duelPSELMax/2 - 1,
+			Winner:                    DuelPerceptron,
+			PerceptronLeaderMisses:    1,
+			PseudoLRULeaderMisses:     2,
+			PerceptronFollowerVictims: 1,
+			PseudoLRUFollowerVictims:  1,
+		}))
+	})
+
+	It("should saturate the policy selector", func() {
+		for i := 0; i < 2*duelPSELMax; i++ {
+			d.FindVictimWithContext(0, ctx)
+		}
+
+		Expect(duel.DuelStats().PSEL).To(Equal(duelPSELMax))
+	})
+
+	It("should reject a leader interval below 2", func() {
+		Expect(func() { NewDuelingVictimFinder(p, 1) }).To(Panic())
+	})
+})
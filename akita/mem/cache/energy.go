@@ -0,0 +1,153 @@
+package cache
+
+// An EnergyEvent is a kind of replacement-metadata or predictor access that
+// consumes energy.
+type EnergyEvent int
+
+// Energy events charged by the replacement policies and the directory.
+const (
+	EnergyWeightRead EnergyEvent = iota
+	EnergyWeightUpdate
+	EnergyPLRUUpdate
+
+	// An access to a shadow structure: the tags of a shadow directory or a
+	// sampled set, the OPTgen history of Hawkeye, or the selector of a set
+	// duel.
+	EnergyShadowAccess
+	numEnergyEvents
+)
+
+var energyEventNames = [numEnergyEvents]string{
+	"weight_read",
+	"weight_update",
+	"plru_update",
+	"shadow_access",
+}
+
+// String returns the name of the energy event.
+func (e EnergyEvent) String() string {
+	if e < 0 || e >= numEnergyEvents {
+		return "unknown"
+	}
+
+	return energyEventNames[e]
+}
+
+// EnergyModel defines the energy (in pJ) charged for each access to the
+// replacement metadata.
+type EnergyModel struct {
+	WeightReadPJ   float64
+	WeightUpdatePJ float64
+	PLRUUpdatePJ   float64
+	ShadowAccessPJ float64
+}
+
+// DefaultEnergyModel returns an energy model with per-access costs of small
+// SRAM tables in a modern process node.
+func DefaultEnergyModel() EnergyModel {
+	return EnergyModel{
+		WeightReadPJ:   0.05,
+		WeightUpdatePJ: 0.08,
+		PLRUUpdatePJ:   0.01,
+		ShadowAccessPJ: 0.5,
+	}
+}
+
+func (m EnergyModel) cost(e EnergyEvent) float64 {
+	switch e {
+	case EnergyWeightRead:
+		return m.WeightReadPJ
+	case EnergyWeightUpdate:
+		return m.WeightUpdatePJ
+	case EnergyPLRUUpdate:
+		return m.PLRUUpdatePJ
+	case EnergyShadowAccess:
+		return m.ShadowAccessPJ
+	default:
+		return 0
+	}
+}
+
+// An EnergyMeter counts the energy events of a policy and converts them into
+// an energy estimate. A nil EnergyMeter ignores all the charges.
+type EnergyMeter struct {
+	model  EnergyModel
+	counts [numEnergyEvents]uint64
+}
+
+// NewEnergyMeter creates a new energy meter that uses the given model.
+func NewEnergyMeter(model EnergyModel) *EnergyMeter {
+	return &EnergyMeter{model: model}
+}
+
+// Charge records n occurrences of the event.
+func (m *EnergyMeter) Charge(e EnergyEvent, n uint64) {
+	if m == nil || e < 0 || e >= numEnergyEvents {
+		return
+	}
+
+	m.counts[e] += n
+}
+
+// Count returns the number of times that the event has been charged.
+func (m *EnergyMeter) Count(e EnergyEvent) uint64 {
+	if m == nil || e < 0 || e >= numEnergyEvents {
+		return 0
+	}
+
+	return m.counts[e]
+}
+
+// EnergyPJ returns the energy consumed by the event in pJ.
+func (m *EnergyMeter) EnergyPJ(e EnergyEvent) float64 {
+	if m == nil {
+		return 0
+	}
+
+	return float64(m.Count(e)) * m.model.cost(e)
+}
+
+// TotalPJ returns the total energy consumed in pJ.
+func (m *EnergyMeter) TotalPJ() float64 {
+	total := 0.0
+	for e := EnergyEvent(0); e < numEnergyEvents; e++ {
+		total += m.EnergyPJ(e)
+	}
+
+	return total
+}
+
+// Reset clears all the counters.
+func (m *EnergyMeter) Reset() {
+	if m == nil {
+		return
+	}
+
+	m.counts = [numEnergyEvents]uint64{}
+}
+
+// EnergyReport summarizes the energy overhead of a policy.
+type EnergyReport struct {
+	Policy   string
+	Counts   map[string]uint64
+	EnergyPJ map[string]float64
+	TotalPJ  float64
+}
+
+// Report creates an energy report labeled with the policy name.
+func (m *EnergyMeter) Report(policy string) EnergyReport {
+	r := EnergyReport{
+		Policy:   policy,
+		Counts:   make(map[string]uint64),
+		EnergyPJ: make(map[string]float64),
+	}
+
+	for e := EnergyEvent(0); e < numEnergyEvents; e++ {
+		r.Counts[e.String()] = m.Count(e)
+		r.EnergyPJ[e.String()] = m.EnergyPJ(e)
+	}
+
+	r.TotalPJ = m.TotalPJ()
+
+	return r
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EnergyMeter", func() {
+	It("should ignore charges when nil", func() {
+		var m *EnergyMeter
+
+		m.Charge(EnergyWeightRead, 1)
+
+		Expect(m.TotalPJ()).To(Equal(0.0))
+	})
+
+	It("should convert counts to energy", func() {
+		m := NewEnergyMeter(EnergyModel{WeightReadPJ: 2, PLRUUpdatePJ: 1})
+
+		m.Charge(EnergyWeightRead, 3)
+		m.Charge(EnergyPLRUUpdate, 4)
+
+		Expect(m.TotalPJ()).To(Equal(10.0))
+		Expect(m.Report("perceptron").Counts["weight_read"]).
+			To(Equal(uint64(3)))
+	})
+
+	It("should charge the perceptron weight-table accesses", func() {
+		m := NewEnergyMeter(DefaultEnergyModel())
+		p := NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		p.SetEnergyMeter(m)
+
+		p.TrainOnEviction(0x7)
+
+		Expect(m.Count(EnergyWeightRead)).To(Equal(uint64(PerceptronNumTables)))
+		Expect(m.Count(EnergyWeightUpdate)).
+			To(Equal(uint64(PerceptronNumTables)))
+	})
+
+	It("should not charge the rankings and way scores", func() {
+		m := NewEnergyMeter(DefaultEnergyModel())
+		p := NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		for i := 0; i < 20; i++ {
+			p.TrainOnEviction(0xffff)
+		}
+		p.SetEnergyMeter(m)
+
+		set := makeTestSet(4)
+		for i, block := range set.Blocks {
+			block.IsValid = true
+			block.Tag = uint64(i) << 16
+		}
+		ctx := &VictimContext{Address: 0xffff}
+
+		Expect(p.FindVictims(set, ctx, 4)).To(HaveLen(4))
+		Expect(p.ScoreWays(set, ctx)).To(HaveLen(4))
+		Expect(m.Count(EnergyWeightRead)).To(BeZero())
+	})
+
+	It("should charge the PseudoLRU updates", func() {
+		m := NewEnergyMeter(DefaultEnergyModel())
+		d := NewDirectory(4, 4, 64, NewLRUVictimFinder())
+		d.SetEnergyMeter(m)
+
+		d.Visit(d.Sets[1].Blocks[2])
+
+		Expect(m.Count(EnergyPLRUUpdate)).To(Equal(uint64(1)))
+	})
+
+	It("should charge the shadow directory accesses", func() {
+		m := NewEnergyMeter(DefaultEnergyModel())
+		d := NewDirectory(4, 4, 64, NewLRUVictimFinder())
+		d.SetShadowDirectory(NewShadowDirectory(d, NewLRUVictimFinder()))
+		d.SetEnergyMeter(m)
+
+		d.Visit(d.Sets[1].Blocks[2])
+
+		Expect(m.Count(EnergyShadowAccess)).To(Equal(uint64(1)))
+	})
+
+	It("should charge the sampler accesses of the sampled sets", func() {
+		m := NewEnergyMeter(DefaultEnergyModel())
+		p := NewPerceptronVictimFinder()
+		p.EnableSampler(SamplerConfig{NumSampledSets: 1, NumCacheSets: 2})
+		p.SetEnergyMeter(m)
+		sdbp := NewSDBPVictimFinderWithConfig(SDBPConfig{
+			Sampler: SamplerConfig{NumSampledSets: 1, NumCacheSets: 2},
+		})
+		sdbp.SetEnergyMeter(m)
+
+		p.TrainOnHit(0x0)
+		p.TrainOnHit(0x40)
+		sdbp.Insert(nil, &Block{Tag: 0x80})
+		sdbp.Insert(nil, &Block{Tag: 0xc0})
+
+		Expect(m.Count(EnergyShadowAccess)).To(Equal(uint64(2)))
+	})
+
+	It("should charge the OPTgen and set dueling accesses", func() {
+		m := NewEnergyMeter(DefaultEnergyModel())
+		hawkeye := NewHawkeyeVictimFinder()
+		hawkeye.SetEnergyMeter(m)
+		d := NewDirectory(1, 2, 64, hawkeye)
+
+		d.Visit(d.Sets[0].Blocks[0])
+		Expect(m.Count(EnergyShadowAccess)).To(Equal(uint64(1)))
+
+		m.Reset()
+		p := NewPerceptronVictimFinder()
+		p.SetEnergyMeter(m)
+		d = NewDirectory(4, 2, 64, NewDuelingVictimFinder(p, 4))
+
+		for set := uint64(0); set < 4; set++ {
+			d.FindVictimWithContext(set*64, &VictimContext{})
+		}
+
+		Expect(m.Count(EnergyShadowAccess)).To(Equal(uint64(2)))
+	})
+})
@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+)
+
+// SetEvictionTrace makes the directory write one line for every victim it
+// selects. Each line lists the set, the way, and the address that triggers
+// the eviction, followed by the PID, tag, and state of the evicted block.
+// Passing nil disables the trace.
+func (d *DirectoryImpl) SetEvictionTrace(w io.Writer) {
+	d.evictionTrace = w
+}
+
+func (d *DirectoryImpl) traceEviction(addr uint64, setID int, block *Block) {
+	if d.evictionTrace == nil {
+		return
+	}
+
+	if block == nil {
+		fmt.Fprintf(d.evictionTrace, "%d,-,0x%x\n", setID, addr)
+		return
+	}
+
+	fmt.Fprintf(d.evictionTrace, "%d,%d,0x%x,%d,0x%x,%t,%t\n",
+		setID, block.WayID, addr,
+		block.PID, block.Tag, block.IsValid, block.IsDirty)
+}
@@ -0,0 +1,234 @@
+package cache
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxFeatures is the number of features that can be disabled: the weights of
+// the weight vector, or the hashed tables.
+const maxFeatures = 64
+
+// A FeatureImportance reports how much one feature of the perceptron matters.
+// A feature is a weight of the weight vector, whose input is one PC or tag
+// bit, or a hashed table.
+type FeatureImportance struct {
+	Feature int
+
+	// Group is "pc" or "tag" for the weights of the PC and tag halves of the
+	// weight vector, and "table" for the hashed tables.
+	Group string
+
+	// MeanContribution is the mean of |w·x| over the predictions, where x
+	// is 1 if the feature is active for the access and 0 otherwise. The
+	// contribution of a disabled feature is 0.
+	MeanContribution float64
+
+	// Correlation is the phi coefficient between the feature being active
+	// and the line not being reused, over the training outcomes. Hashed
+	// tables are always active, so their correlation is 0.
+	Correlation float64
+
+	Disabled bool
+}
+
+// featureCounters accumulates the statistics of one feature.
+type featureCounters struct {
+	contribution float64
+
+	active        uint64 // Outcomes for which the feature was active
+	activeAndDead uint64
+}
+
+// featureImportance is the importance tracking state of a perceptron.
+type featureImportance struct {
+	features    []featureCounters
+	predictions uint64
+	outcomes    uint64
+	dead        uint64
+}
+
+func (t *featureImportance) counters(feature int) *featureCounters {
+	for len(t.features) <= feature {
+		t.features = append(t.features, featureCounters{})
+	}
+
+	return &t.features[feature]
+}
+
+// NumFeatures returns the number of features of the perceptron.
+func (p *PerceptronVictimFinder) NumFeatures() int {
+	if p.hashed {
+		return len(p.tables)
+	}
+
+	return p.weightConfig.NumWeights
+}
+
+// DisableFeature removes the feature from the predictions and from training,
+// as if its weight were always 0, so that its effect on the accuracy can be
+// measured. Features can be disabled at any time.
+func (p *PerceptronVictimFinder) DisableFeature(feature int) {
+	checkFeature(feature)
+
+	p.disabledFeatures |= 1 << uint(feature)
+	p.invalidatePredictions()
+}
+
+// EnableFeature restores a disabled feature.
+func (p *PerceptronVictimFinder) EnableFeature(feature int) {
+	checkFeature(feature)
+
+	p.disabledFeatures &^= 1 << uint(feature)
+	p.invalidatePredictions()
+}
+
+// FeatureDisabled tells if the feature is disabled.
+func (p *PerceptronVictimFinder) FeatureDisabled(feature int) bool {
+	return !p.featureEnabled(feature)
+}
+
+func checkFeature(feature int) {
+	if feature < 0 || feature >= maxFeatures {
+		panic(fmt.Sprintf("feature %d is out of range", feature))
+	}
+}
+
+func (p *PerceptronVictimFinder) featureEnabled(feature int) bool {
+	return feature >= maxFeatures || p.disabledFeatures>>uint(feature)&1 == 0
+}
+
+// EnableFeatureImportance starts collecting the feature importance
+// statistics, discarding the previous ones. The collection costs a pass over
+// the features at every prediction and training.
+func (p *PerceptronVictimFinder) EnableFeatureImportance() {
+	p.importance = &featureImportance{}
+}
+
+// forEachActiveFeature calls f with every feature that is active for the
+// access and its weight.
+func (p *PerceptronVictimFinder) forEachActiveFeature(
+	addr, pc uint64,
+	f func(feature int, weight int32),
+) {
+	if p.hashed {
+		for i, idx := range p.tableIndices(addr, pc) {
+			f(i, p.tables[i][idx])
+		}
+
+		return
+	}
+
+	pcBits := p.pcBits(addr, pc)
+	tagBits := addr >> p.featureShift
+	half := p.weightConfig.NumWeights / 2
+
+	for i := 0; i < half; i++ {
+		if (pcBits>>uint(i))&1 == 1 {
+			f(i, p.weights[i])
+		}
+
+		if (tagBits>>uint(i+16))&1 == 1 {
+			f(i+half, p.weights[i+half])
+		}
+	}
+}
+
+// recordImportancePrediction adds the contributions of the features to a
+// prediction.
+func (p *PerceptronVictimFinder) recordImportancePrediction(addr, pc uint64) {
+	t := p.importance
+	if t == nil {
+		return
+	}
+
+	t.predictions++
+
+	p.forEachActiveFeature(addr, pc, func(feature int, weight int32) {
+		if p.featureEnabled(feature) {
+			t.counters(feature).contribution += math.Abs(float64(weight))
+		}
+	})
+}
+
+// recordImportanceOutcome correlates the active features with an outcome.
+func (p *PerceptronVictimFinder) recordImportanceOutcome(
+	addr, pc uint64,
+	dead bool,
+) {
+	t := p.importance
+	if t == nil {
+		return
+	}
+
+	t.outcomes++
+	if dead {
+		t.dead++
+	}
+
+	p.forEachActiveFeature(addr, pc, func(feature int, _ int32) {
+		c := t.counters(feature)
+		c.active++
+
+		if dead {
+			c.activeAndDead++
+		}
+	})
+}
+
+// FeatureImportance returns the importance of every feature, or nil if the
+// collection is not enabled.
+func (p *PerceptronVictimFinder) FeatureImportance() []FeatureImportance {
+	t := p.importance
+	if t == nil {
+		return nil
+	}
+
+	n := p.NumFeatures()
+	report := make([]FeatureImportance, n)
+
+	for i := range report {
+		r := &report[i]
+		r.Feature = i
+		r.Disabled = !p.featureEnabled(i)
+		r.Group = p.featureGroup(i)
+
+		if i >= len(t.features) {
+			continue
+		}
+
+		c := t.features[i]
+		if t.predictions > 0 {
+			r.MeanContribution = c.contribution / float64(t.predictions)
+		}
+
+		r.Correlation = phi(t.outcomes, c.active, t.dead, c.activeAndDead)
+	}
+
+	return report
+}
+
+func (p *PerceptronVictimFinder) featureGroup(feature int) string {
+	switch {
+	case p.hashed:
+		return "table"
+	case feature < p.weightConfig.NumWeights/2:
+		return "pc"
+	default:
+		return "tag"
+	}
+}
+
+// phi returns the phi coefficient of two binary variables from the number of
+// samples n, the number of samples with x set, with y set, and with both set.
+// It returns 0 if either variable is constant.
+func phi(n, x, y, xy uint64) float64 {
+	fn, fx, fy, fxy := float64(n), float64(x), float64(y), float64(xy)
+
+	d := fx * (fn - fx) * fy * (fn - fy)
+	if d == 0 {
+		return 0
+	}
+
+	return (fn*fxy - fx*fy) / math.Sqrt(d)
+}
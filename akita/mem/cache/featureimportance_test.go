@@ -0,0 +1,90 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Feature importance", func() {
+	var (
+		p        *PerceptronVictimFinder
+		set      *Set
+		deadAddr uint64
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		p.SetHashedTables(false)
+		p.EnableFeatureImportance()
+		set = makeTestSet(2)
+
+		// Only the first tag bit, feature 16, is active.
+		deadAddr = 1 << (16 + p.featureShift)
+	})
+
+	train := func() {
+		for i := 0; i < 4; i++ {
+			p.TrainOnEviction(deadAddr)
+			p.TrainOnHit(0)
+		}
+	}
+
+	It("should correlate the features with the outcomes", func() {
+		train()
+
+		report := p.FeatureImportance()
+		Expect(report).To(HaveLen(32))
+		Expect(report[16].Group).To(Equal("tag"))
+		Expect(report[16].Correlation).To(Equal(1.0))
+		Expect(report[0].Group).To(Equal("pc"))
+		Expect(report[0].Correlation).To(BeZero())
+	})
+
+	It("should report the mean contribution of the features", func() {
+		train()
+		weight := p.Weights()[16]
+
+		p.FindVictimWithContext(set, &VictimContext{Address: deadAddr})
+		p.FindVictimWithContext(set, &VictimContext{Address: 0})
+
+		report := p.FeatureImportance()
+		Expect(weight).To(BeNumerically(">", 0))
+		Expect(report[16].MeanContribution).To(Equal(float64(weight) / 2))
+		Expect(report[1].MeanContribution).To(BeZero())
+	})
+
+	It("should leave disabled features out of predictions and training", func() {
+		p.DisableFeature(16)
+		train()
+
+		Expect(p.Weights()[16]).To(BeZero())
+		Expect(p.FeatureDisabled(16)).To(BeTrue())
+		Expect(p.FeatureImportance()[16].Disabled).To(BeTrue())
+
+		p.EnableFeature(16)
+		train()
+
+		Expect(p.predictionSum(deadAddr, 0)).To(BeNumerically(">", 0))
+
+		p.DisableFeature(16)
+		Expect(p.predictionSum(deadAddr, 0)).To(BeZero())
+	})
+
+	It("should disable hashed tables", func() {
+		p.SetHashedTables(true)
+		train()
+		Expect(p.predictionSum(deadAddr, 0)).NotTo(BeZero())
+
+		for i := 0; i < p.NumFeatures(); i++ {
+			p.DisableFeature(i)
+		}
+
+		Expect(p.predictionSum(deadAddr, 0)).To(BeZero())
+		Expect(p.FeatureImportance()[0].Group).To(Equal("table"))
+	})
+
+	It("should report nothing unless enabled", func() {
+		Expect(NewPerceptronVictimFinder().FeatureImportance()).To(BeNil())
+	})
+})
@@ -0,0 +1,117 @@
+package cache
+
+// A FeatureSource selects where the perceptron takes its inputs from when no
+// custom FeatureExtractor is given.
+type FeatureSource int
+
+// Feature sources.
+const (
+	// The address bits stand in for the PC. This is the default, as most
+	// requesters do not supply a PC.
+	FeatureSourceAddress FeatureSource = iota
+
+	// The PC in VictimContext is used when it is known, as in the MICRO 2016
+	// paper. Accesses without a PC fall back to the address features.
+	FeatureSourcePC
+)
+
+// Instructions are at least 4-byte aligned, so the lowest PC bits carry no
+// information.
+const pcAlignBits = 2
+
+// SetFeatureSource selects the source of the perceptron inputs.
+func (p *PerceptronVictimFinder) SetFeatureSource(source FeatureSource) {
+	p.featureSource = source
+	p.invalidatePredictions()
+}
+
+// FeatureSource returns the source of the perceptron inputs.
+func (p *PerceptronVictimFinder) FeatureSource() FeatureSource {
+	return p.featureSource
+}
+
+// usesPC tells if the features of an access with the given PC come from the
+// PC.
+func (p *PerceptronVictimFinder) usesPC(pc uint64) bool {
+	return p.featureSource == FeatureSourcePC && pc != 0
+}
+
+// pcBits returns the bits that drive the PC half of the weight vector: the
+// aligned PC if it is used, and the shifted address otherwise.
+func (p *PerceptronVictimFinder) pcBits(addr, pc uint64) uint64 {
+	if p.usesPC(pc) {
+		return pc >> pcAlignBits
+	}
+
+	return addr >> p.featureShift
+}
+
+// pcFeatures extracts the 6 hashed-table features of an access with a known
+// PC: the PC at three shifts, the PC XORed with the line address, and the tag
+// and page bits of the address.
+func pcFeatures(addr, pc uint64) [6]uint32 {
+	pc >>= pcAlignBits
+
+	return [6]uint32{
+		uint32(pc),
+		uint32(pc >> 1),
+		uint32(pc >> 3),
+		uint32(pc ^ addr>>6),
+		uint32((addr >> 12) & 0x3F),
+		uint32((addr >> 15) & 0x3F),
+	}
+}
+
+// A FeatureExtractor computes the features of an access. Every feature
+// indexes its own hashed weight table, so an extractor must always return the
+// same number of features. Only the Address and the PC of the context are set
+// when the perceptron is used without a context, as by TrainOnHit or by the
+// sampler.
+type FeatureExtractor interface {
+	Extract(ctx *VictimContext) []uint32
+}
+
+// AddressFeatureExtractor extracts the built-in address slices, with the
+// address as a PC proxy. It is the default feature extractor.
+type AddressFeatureExtractor struct{}
+
+// Extract returns the address features.
+func (AddressFeatureExtractor) Extract(ctx *VictimContext) []uint32 {
+	features := reuseFeatures(ctx.Address)
+	return features[:]
+}
+
+// PCFeatureExtractor extracts the PC features of accesses with a known PC and
+// the address features of the others. With FeatureSourcePC, the PC also
+// replaces the address when the features are hashed into table indices.
+type PCFeatureExtractor struct{}
+
+// Extract returns the PC or the address features.
+func (PCFeatureExtractor) Extract(ctx *VictimContext) []uint32 {
+	if ctx.PC == 0 {
+		return AddressFeatureExtractor{}.Extract(ctx)
+	}
+
+	features := pcFeatures(ctx.Address, ctx.PC)
+
+	return features[:]
+}
+
+// NewPerceptronVictimFinderWithExtractor creates a perceptron with the MICRO
+// 2016 parameters that predicts with hashed weight tables indexed by the
+// features of the extractor.
+func NewPerceptronVictimFinderWithExtractor(
+	extractor FeatureExtractor,
+) *PerceptronVictimFinder {
+	p := NewPerceptronVictimFinder()
+	p.extractor = extractor
+	p.hashed = true
+
+	return p
+}
+
+// FeatureExtractor returns the custom feature extractor, or nil if the
+// built-in features of the feature source are used.
+func (p *PerceptronVictimFinder) FeatureExtractor() FeatureExtractor {
+	return p.extractor
+}
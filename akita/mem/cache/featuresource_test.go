@@ -0,0 +1,131 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FeatureSource", func() {
+	var p *PerceptronVictimFinder
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+	})
+
+	It("should ignore the PC by default", func() {
+		for i := 0; i < 4; i++ {
+			p.TrainOnEvictionWithPC(0x12340, 0x1000)
+		}
+
+		Expect(p.predictionSum(0x12340, 0x2000)).
+			To(Equal(p.predictionSum(0x12340, 0)))
+	})
+
+	It("should learn per PC", func() {
+		p.SetFeatureSource(FeatureSourcePC)
+		p.SetHashedTables(false)
+
+		for i := 0; i < 4; i++ {
+			p.TrainOnEvictionWithPC(0x40, 0x1000)
+		}
+
+		Expect(p.predictionSum(0x40, 0x1000)).To(BeNumerically(">", 0))
+		Expect(p.predictionSum(0x40, 0x2000)).To(BeZero())
+	})
+
+	It("should learn per PC in the hashed tables", func() {
+		p.SetFeatureSource(FeatureSourcePC)
+
+		for i := 0; i < 4; i++ {
+			p.TrainOnEvictionWithPC(0x40, 0x1000)
+		}
+
+		Expect(p.predictionSum(0x40, 0x1000)).
+			To(BeNumerically(">", p.predictionSum(0x40, 0x2000)))
+	})
+
+	It("should fall back to the address without a PC", func() {
+		addressOnly := NewPerceptronVictimFinder()
+		addressOnly.SetStrictMode(true)
+		p.SetFeatureSource(FeatureSourcePC)
+
+		p.TrainOnEviction(0x12340)
+		addressOnly.TrainOnEviction(0x12340)
+
+		Expect(p.weights).To(Equal(addressOnly.weights))
+		Expect(p.tables).To(Equal(addressOnly.tables))
+	})
+
+	It("should record the PC of the fill in the block", func() {
+		d := NewDirectory(1, 4, 64, p)
+
+		block := d.FindVictimWithContext(0x40, &VictimContext{
+			Address: 0x40,
+			PC:      0x1234,
+		})
+		d.Visit(block)
+
+		Expect(block.PC).To(Equal(uint64(0x1234)))
+	})
+})
+
+// lowBitsExtractor uses each of the n lowest line-address bits as a feature.
+type lowBitsExtractor struct {
+	n int
+}
+
+func (e lowBitsExtractor) Extract(ctx *VictimContext) []uint32 {
+	features := make([]uint32, e.n)
+	for i := range features {
+		features[i] = uint32(ctx.Address>>(6+i)) & 1
+	}
+
+	return features
+}
+
+var _ = Describe("FeatureExtractor", func() {
+	It("should keep the built-in features by default", func() {
+		p := NewPerceptronVictimFinder()
+
+		Expect(p.FeatureExtractor()).To(BeNil())
+		Expect(p.ExtractFeatures(&VictimContext{Address: 0x12340})).
+			To(Equal(AddressFeatureExtractor{}.Extract(
+				&VictimContext{Address: 0x12340})))
+	})
+
+	It("should add a table per extra feature", func() {
+		p := NewPerceptronVictimFinderWithExtractor(lowBitsExtractor{n: 8})
+		p.SetStrictMode(true)
+
+		p.TrainOnEviction(0x40)
+
+		Expect(p.IsHashedTables()).To(BeTrue())
+		Expect(p.TableWeights()).To(HaveLen(8))
+		Expect(p.predictionSum(0x40, 0)).To(Equal(8 * p.learningRate))
+	})
+
+	It("should only use the tables of the returned features", func() {
+		p := NewPerceptronVictimFinderWithExtractor(lowBitsExtractor{n: 2})
+		p.SetStrictMode(true)
+		m := NewEnergyMeter(DefaultEnergyModel())
+		p.SetEnergyMeter(m)
+
+		p.TrainOnEviction(0x40)
+
+		Expect(p.predictionSum(0x40, 0)).To(Equal(2 * p.learningRate))
+		Expect(m.Count(EnergyWeightRead)).To(Equal(uint64(2)))
+		Expect(m.Count(EnergyWeightUpdate)).To(Equal(uint64(2)))
+	})
+
+	It("should use the PC features of accesses with a PC", func() {
+		ctx := &VictimContext{Address: 0x40, PC: 0x1000}
+
+		Expect(PCFeatureExtractor{}.Extract(ctx)).
+			NotTo(Equal(AddressFeatureExtractor{}.Extract(ctx)))
+
+		ctx.PC = 0
+		Expect(PCFeatureExtractor{}.Extract(ctx)).
+			To(Equal(AddressFeatureExtractor{}.Extract(ctx)))
+	})
+})
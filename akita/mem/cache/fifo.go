@@ -0,0 +1,70 @@
+package cache
+
+// FIFOVictimFinder evicts the blocks of a set in the order in which they
+// were filled, ignoring hits. Its only state is an insertion pointer per
+// set: invalid blocks are filled first, and the valid ones are replaced in
+// way order starting from the pointer, which then moves past the victim.
+// Cold sets fill their ways in order, so the pointer always names the
+// oldest block unless a locked block had to be skipped. Like
+// RandomVictimFinder, it is a baseline that replacement studies compare
+// against.
+type FIFOVictimFinder struct {
+}
+
+// NewFIFOVictimFinder returns a new FIFO victim finder.
+func NewFIFOVictimFinder() *FIFOVictimFinder {
+	return &FIFOVictimFinder{}
+}
+
+// FindVictim returns the first invalid block, or the first unlocked block
+// from the insertion pointer, and moves the pointer past it. It returns nil
+// if every block is locked.
+func (f *FIFOVictimFinder) FindVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	ways := f.order(set)
+	for _, way := range ways {
+		block := set.Blocks[way]
+		if block.IsLocked || isPinned(block) {
+			continue
+		}
+
+		set.FIFOPointer = (way + 1) % len(set.Blocks)
+
+		return block
+	}
+
+	return nil
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (f *FIFOVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return f.FindVictim(set)
+}
+
+// FindVictims returns up to n candidates from the oldest to the newest,
+// without moving the insertion pointer.
+func (f *FIFOVictimFinder) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	return rankCandidates(set, f.order(set), n)
+}
+
+// order returns the ways of the set starting from the insertion pointer.
+func (f *FIFOVictimFinder) order(set *Set) []int {
+	numWays := len(set.Blocks)
+	ways := make([]int, numWays)
+
+	for i := range ways {
+		ways[i] = (set.FIFOPointer + i) % numWays
+	}
+
+	return ways
+}
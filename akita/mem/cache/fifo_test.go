@@ -0,0 +1,69 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FIFOVictimFinder", func() {
+	var (
+		vf *FIFOVictimFinder
+		d  *DirectoryImpl
+	)
+
+	fill := func(tag uint64) *Block {
+		block := d.FindVictim(tag)
+		block.Tag = tag
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	BeforeEach(func() {
+		vf = NewFIFOVictimFinder()
+		d = NewDirectory(1, 4, 64, vf)
+	})
+
+	It("should evict in fill order regardless of hits", func() {
+		for i := uint64(0); i < 4; i++ {
+			Expect(fill(i * 64).WayID).To(Equal(int(i)))
+		}
+
+		d.Visit(d.Lookup(0, 0))
+		d.Visit(d.Lookup(0, 64))
+
+		Expect(fill(4 * 64).WayID).To(Equal(0))
+		Expect(fill(5 * 64).WayID).To(Equal(1))
+		Expect(fill(6 * 64).WayID).To(Equal(2))
+		Expect(fill(7 * 64).WayID).To(Equal(3))
+		Expect(fill(8 * 64).WayID).To(Equal(0))
+	})
+
+	It("should skip locked blocks", func() {
+		for i := uint64(0); i < 4; i++ {
+			fill(i * 64)
+		}
+
+		d.Lookup(0, 0).IsLocked = true
+		Expect(fill(4 * 64).WayID).To(Equal(1))
+
+		for _, block := range d.GetSets()[0].Blocks {
+			block.IsLocked = true
+		}
+		Expect(d.FindVictim(5 * 64)).To(BeNil())
+	})
+
+	It("should rank from the oldest block without moving the pointer", func() {
+		set := makeTestSet(4)
+		for _, block := range set.Blocks {
+			block.IsValid = true
+		}
+		set.FIFOPointer = 2
+
+		Expect(vf.FindVictims(set, nil, 3)).To(Equal([]*Block{
+			set.Blocks[2], set.Blocks[3], set.Blocks[0],
+		}))
+		Expect(set.FIFOPointer).To(Equal(2))
+	})
+})
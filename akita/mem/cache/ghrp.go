@@ -0,0 +1,221 @@
+package cache
+
+// A GHRPConfig configures a GHRPVictimFinder.
+type GHRPConfig struct {
+	// Every prediction table has 2^TableBits counters. Defaults to 12.
+	TableBits uint
+
+	// The counters saturate at 2^CounterBits - 1. Defaults to 2.
+	CounterBits uint
+
+	// A table votes dead if its counter is at least DeadThreshold. Defaults
+	// to the upper half of the counter range.
+	DeadThreshold uint8
+
+	// The global history keeps HistoryBits bits, four per access. Defaults
+	// to 16.
+	HistoryBits uint
+}
+
+// GHRPStats counts the training events of a GHRPVictimFinder.
+type GHRPStats struct {
+	// Reuses counts the hits, which train the signature of the previous
+	// access as live, and DeadEvictions the evictions, which train it as
+	// dead.
+	Reuses        uint64
+	DeadEvictions uint64
+}
+
+// GHRPVictimFinder implements the Global History Reuse Predictor
+// (Mirbagher-Ajorpaz et al., MICRO 2018), a dead-block predictor. The
+// signature of an access is a hash of its PC, or of the memory region of the
+// line if the PC is unknown, XORed with a global history of the low bits of
+// the recent PCs. Every block stores the signature of its last access in
+// Block.Signature. A hit trains that signature as live and an eviction as
+// dead, in three tables of saturating counters indexed by skewed hashes of
+// the signature. A block is predicted dead if a majority of the tables vote
+// dead.
+//
+// The victim is the first invalid block, or the least recently used dead
+// block in PseudoLRU order, or the PseudoLRU victim. The finder is also a
+// DeadBlockPredictor, for the writeback and prefetch decisions, and an
+// InsertionAdvisor, which bypasses the lines that every table predicts dead.
+type GHRPVictimFinder struct {
+	config  GHRPConfig
+	tables  *deadBlockTables
+	history uint64
+	stats   GHRPStats
+}
+
+// NewGHRPVictimFinder returns a GHRP victim finder with the default
+// configuration.
+func NewGHRPVictimFinder() *GHRPVictimFinder {
+	return NewGHRPVictimFinderWithConfig(GHRPConfig{})
+}
+
+// NewGHRPVictimFinderWithConfig returns a GHRP victim finder with the
+// configuration.
+func NewGHRPVictimFinderWithConfig(config GHRPConfig) *GHRPVictimFinder {
+	if config.TableBits == 0 {
+		config.TableBits = 12
+	}
+
+	if config.CounterBits == 0 {
+		config.CounterBits = 2
+	}
+
+	if config.DeadThreshold == 0 {
+		config.DeadThreshold = uint8(1 << (config.CounterBits - 1))
+	}
+
+	if config.HistoryBits == 0 {
+		config.HistoryBits = 16
+	}
+
+	return &GHRPVictimFinder{
+		config: config,
+		tables: newDeadBlockTables(config.TableBits, config.CounterBits,
+			config.DeadThreshold),
+	}
+}
+
+// Config returns the configuration.
+func (g *GHRPVictimFinder) Config() GHRPConfig {
+	return g.config
+}
+
+// Stats returns the training statistics.
+func (g *GHRPVictimFinder) Stats() GHRPStats {
+	return g.stats
+}
+
+// History returns the global history register.
+func (g *GHRPVictimFinder) History() uint64 {
+	return g.history
+}
+
+// Signature returns the signature of an access with the PC to the line,
+// under the current global history.
+func (g *GHRPVictimFinder) Signature(pc, line uint64) uint32 {
+	key := line >> shipRegionShift
+	if pc != 0 {
+		key = pc
+	}
+
+	return uint32(mixLineHash(key) ^ g.history)
+}
+
+// Votes returns the number of tables that predict the lines of the
+// signature dead.
+func (g *GHRPVictimFinder) Votes(sig uint32) int {
+	return g.tables.votes(sig)
+}
+
+// PredictDead predicts whether an access with the context to the line is
+// the last one. The confidence is the sum of the counters of the signature.
+func (g *GHRPVictimFinder) PredictDead(
+	addr uint64,
+	ctx *VictimContext,
+) (dead bool, confidence int32) {
+	var pc uint64
+	if ctx != nil {
+		pc = ctx.PC
+	}
+
+	sig := g.Signature(pc, addr)
+
+	return g.tables.dead(sig), g.tables.sum(sig)
+}
+
+// AdviseInsertion recommends bypassing the missing line if every table
+// predicts it dead, and inserting it at the LRU position if a majority
+// does.
+func (g *GHRPVictimFinder) AdviseInsertion(
+	ctx *VictimContext,
+) InsertionPriority {
+	votes := g.Votes(g.Signature(ctx.PC, contextLine(ctx)))
+
+	switch {
+	case votes == deadBlockVoters:
+		return InsertBypass
+	case votes > deadBlockVoters/2:
+		return InsertLRU
+	default:
+		return InsertMRU
+	}
+}
+
+// Insert records the signature of the fill and shifts its PC into the
+// global history.
+func (g *GHRPVictimFinder) Insert(_ *Set, block *Block) {
+	g.access(block, block.PC)
+}
+
+// Touch trains the signature of the previous access to the block as live,
+// then records the signature of a hit whose PC is unknown. The hit is
+// attributed to the PC of the fill, which is the only PC that the block
+// remembers.
+func (g *GHRPVictimFinder) Touch(set *Set, block *Block) {
+	g.TouchWithContext(set, block, nil)
+}
+
+// TouchWithContext is Touch for a hit whose context is known. The hit is
+// attributed to the PC that hit.
+func (g *GHRPVictimFinder) TouchWithContext(
+	_ *Set,
+	block *Block,
+	ctx *VictimContext,
+) {
+	g.tables.train(block.Signature, false)
+	g.stats.Reuses++
+
+	g.access(block, hitPC(block, ctx))
+}
+
+// Evict trains the signature of the last access to the evicted block as
+// dead.
+func (g *GHRPVictimFinder) Evict(_ *Set, block *Block) {
+	g.tables.train(block.Signature, true)
+	g.stats.DeadEvictions++
+}
+
+// FindVictim returns the first invalid block, the least recently used
+// unlocked block predicted dead, or the PseudoLRU victim.
+func (g *GHRPVictimFinder) FindVictim(set *Set) *Block {
+	return g.tables.deadFirstVictim(set)
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (g *GHRPVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return g.FindVictim(set)
+}
+
+// FindVictims returns up to n candidates, the blocks predicted dead before
+// the others, each in PseudoLRU order.
+func (g *GHRPVictimFinder) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	if len(set.Blocks) == 0 {
+		return nil
+	}
+
+	return rankCandidates(set, g.tables.deadFirstOrder(set), n)
+}
+
+// access stores the signature of the access in the block and shifts four
+// bits of the PC, or of the line, into the history.
+func (g *GHRPVictimFinder) access(block *Block, pc uint64) {
+	block.Signature = g.Signature(pc, block.Tag)
+
+	bits := block.Tag >> 6
+	if pc != 0 {
+		bits = pc >> 2
+	}
+
+	g.history = (g.history<<4 | bits&0xf) & (1<<g.config.HistoryBits - 1)
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GHRPVictimFinder", func() {
+	var vf *GHRPVictimFinder
+
+	BeforeEach(func() {
+		vf = NewGHRPVictimFinder()
+	})
+
+	It("should fill in the defaults", func() {
+		Expect(vf.Config()).To(Equal(GHRPConfig{
+			TableBits:     12,
+			CounterBits:   2,
+			DeadThreshold: 2,
+			HistoryBits:   16,
+		}))
+
+		var _ DeadBlockPredictor = vf
+		var _ InsertionAdvisor = vf
+	})
+
+	It("should train the signature of the last access", func() {
+		sig := vf.Signature(0x20, 0x1000)
+		block := &Block{Tag: 0x1000, Signature: sig}
+
+		vf.Evict(nil, block)
+		Expect(vf.Votes(sig)).To(BeZero())
+
+		vf.Evict(nil, block)
+		Expect(vf.Votes(sig)).To(Equal(3))
+
+		dead, confidence := vf.PredictDead(0x1000, &VictimContext{PC: 0x20})
+		Expect(dead).To(BeTrue())
+		Expect(confidence).To(Equal(int32(6)))
+		Expect(vf.AdviseInsertion(&VictimContext{Address: 0x1000, PC: 0x20})).
+			To(Equal(InsertBypass))
+
+		vf.Touch(nil, block)
+		Expect(vf.Votes(sig)).To(BeZero())
+		Expect(vf.Stats()).To(Equal(GHRPStats{Reuses: 1, DeadEvictions: 2}))
+		Expect(block.Signature).NotTo(Equal(sig))
+	})
+
+	It("should record the hits under the PC that hit", func() {
+		d := NewDirectory(1, 2, 64, vf)
+		block := d.FindVictimWithContext(0x40,
+			&VictimContext{Address: 0x40, PC: 0x10})
+		block.Tag = 0x40
+		block.IsValid = true
+		d.Visit(block)
+
+		sig := vf.Signature(0x30, 0x40)
+		d.VisitWithContext(block, &VictimContext{Address: 0x40, PC: 0x30})
+		Expect(block.Signature).To(Equal(sig))
+		Expect(vf.History() & 0xf).To(Equal(uint64(0x30 >> 2 & 0xf)))
+
+		sig = vf.Signature(0x10, 0x40)
+		d.Visit(block)
+		Expect(block.Signature).To(Equal(sig))
+	})
+
+	It("should shift the PCs into the global history", func() {
+		vf.Insert(nil, &Block{Tag: 0x40, PC: 0x14})
+		vf.Insert(nil, &Block{Tag: 0x80, PC: 0x18})
+		Expect(vf.History()).To(Equal(uint64(0x56)))
+
+		for i := 0; i < 8; i++ {
+			vf.Insert(nil, &Block{Tag: 0x40, PC: 0x3c})
+		}
+		Expect(vf.History()).To(Equal(uint64(0xffff)))
+	})
+
+	It("should evict the blocks predicted dead first", func() {
+		set := makeTestSet(4)
+		for _, block := range set.Blocks {
+			block.IsValid = true
+		}
+
+		dead := vf.Signature(0x20, 0)
+		for i := 0; i < 2; i++ {
+			vf.Evict(nil, &Block{Signature: dead})
+		}
+
+		set.Blocks[1].Signature = dead
+		set.Blocks[3].Signature = dead
+
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[1]))
+		Expect(vf.FindVictims(set, nil, 4)).To(Equal([]*Block{
+			set.Blocks[1], set.Blocks[3], set.Blocks[0], set.Blocks[2],
+		}))
+
+		set.Blocks[1].IsLocked = true
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[3]))
+	})
+
+	It("should keep more reused lines of a mixed stream than LRU", func() {
+		hits := func(vf VictimFinder) int {
+			d := NewDirectory(1, 4, 64, vf)
+			access := func(addr, pc uint64) bool {
+				if block := d.Lookup(0, addr); block != nil {
+					d.VisitWithContext(block,
+						&VictimContext{Address: addr, PC: pc})
+					return true
+				}
+
+				block := d.FindVictimWithContext(addr,
+					&VictimContext{Address: addr, PC: pc})
+				block.Tag = addr
+				block.IsValid = true
+				d.Visit(block)
+
+				return false
+			}
+
+			hits := 0
+			stream := uint64(0x100000)
+
+			for i := 0; i < 300; i++ {
+				for _, addr := range []uint64{0x0, 0x40, 0x80} {
+					if access(addr, 0x10) && i >= 200 {
+						hits++
+					}
+				}
+
+				for j := 0; j < 2; j++ {
+					access(stream, 0x20)
+					stream += 64
+				}
+			}
+
+			return hits
+		}
+
+		lru := hits(NewLRUVictimFinder())
+		Expect(hits(vf)).To(BeNumerically(">=", lru+150))
+	})
+})
@@ -0,0 +1,167 @@
+package cache
+
+import "fmt"
+
+// A HistoryKind selects what the global history register of a perceptron
+// remembers.
+type HistoryKind int
+
+// History kinds.
+const (
+	// HistoryNone disables the global history.
+	HistoryNone HistoryKind = iota
+
+	// HistoryOutcomes remembers whether the recent accesses hit or missed,
+	// one bit per access.
+	HistoryOutcomes
+
+	// HistoryAddresses remembers the line addresses of the recent accesses.
+	HistoryAddresses
+)
+
+// Longest histories of every kind.
+const (
+	MaxOutcomeHistory = 64
+	MaxAddressHistory = 32
+)
+
+func (k HistoryKind) String() string {
+	switch k {
+	case HistoryNone:
+		return "none"
+	case HistoryOutcomes:
+		return "outcomes"
+	case HistoryAddresses:
+		return "addresses"
+	default:
+		return fmt.Sprintf("HistoryKind(%d)", int(k))
+	}
+}
+
+// globalHistory is a shift register of the recent accesses seen by a
+// perceptron.
+type globalHistory struct {
+	kind   HistoryKind
+	length int
+
+	outcomes uint64   // Bit 0 is the most recent access; 1 for a hit
+	lines    []uint64 // Most recent first
+}
+
+// SetGlobalHistory makes the perceptron remember the last length accesses and
+// use hashed slices of that history as additional features, as in
+// hashed-perceptron branch predictors. The slices cover the most recent
+// quarter, half, and whole of the history, and every slice gets its own
+// hashed weight table, so the perceptron predicts with hashed weight tables.
+// Hits are the hits it is trained on, and misses the victim selections. The
+// kind HistoryNone or a zero length disables the history. It panics if the
+// length is too long for the kind.
+func (p *PerceptronVictimFinder) SetGlobalHistory(kind HistoryKind, length int) {
+	if err := checkGlobalHistory(kind, length); err != nil {
+		panic(err)
+	}
+
+	p.invalidatePredictions()
+
+	if kind == HistoryNone || length == 0 {
+		p.history = nil
+		return
+	}
+
+	p.history = &globalHistory{kind: kind, length: length}
+	p.hashed = true
+}
+
+// GlobalHistory returns the kind and the length of the global history.
+func (p *PerceptronVictimFinder) GlobalHistory() (HistoryKind, int) {
+	if p.history == nil {
+		return HistoryNone, 0
+	}
+
+	return p.history.kind, p.history.length
+}
+
+func checkGlobalHistory(kind HistoryKind, length int) error {
+	maxLength := 0
+
+	switch kind {
+	case HistoryNone:
+		return nil
+	case HistoryOutcomes:
+		maxLength = MaxOutcomeHistory
+	case HistoryAddresses:
+		maxLength = MaxAddressHistory
+	default:
+		return fmt.Errorf("unknown history kind %d", int(kind))
+	}
+
+	if length < 0 || length > maxLength {
+		return fmt.Errorf("%s history length %d is not in [0, %d]",
+			kind, length, maxLength)
+	}
+
+	return nil
+}
+
+// record shifts an access into the history. Calls on a nil history are
+// ignored.
+func (h *globalHistory) record(addr uint64, hit bool) {
+	if h == nil {
+		return
+	}
+
+	switch h.kind {
+	case HistoryOutcomes:
+		h.outcomes <<= 1
+		if hit {
+			h.outcomes |= 1
+		}
+	case HistoryAddresses:
+		if len(h.lines) < h.length {
+			h.lines = append(h.lines, 0)
+		}
+
+		copy(h.lines[1:], h.lines)
+		h.lines[0] = addr >> 6
+	}
+}
+
+// sliceLengths returns the lengths of the history slices used as features.
+func (h *globalHistory) sliceLengths() [3]int {
+	return [3]int{(h.length + 3) / 4, (h.length + 1) / 2, h.length}
+}
+
+// appendFeatures appends the hashed history slices to the features. Calls on
+// a nil history return the features unchanged.
+func (h *globalHistory) appendFeatures(features []uint32) []uint32 {
+	if h == nil {
+		return features
+	}
+
+	for _, n := range h.sliceLengths() {
+		features = append(features, h.sliceFeature(n))
+	}
+
+	return features
+}
+
+// sliceFeature hashes the n most recent accesses of the history.
+func (h *globalHistory) sliceFeature(n int) uint32 {
+	hash := uint64(n)
+
+	switch h.kind {
+	case HistoryOutcomes:
+		bits := h.outcomes
+		if n < 64 {
+			bits &= 1<<uint(n) - 1
+		}
+
+		hash = mixLineHash(hash<<56 ^ bits)
+	case HistoryAddresses:
+		for i := 0; i < n && i < len(h.lines); i++ {
+			hash = mixLineHash(hash ^ h.lines[i])
+		}
+	}
+
+	return uint32(hash)
+}
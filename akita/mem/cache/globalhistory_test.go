@@ -0,0 +1,88 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Global history", func() {
+	ctx := &VictimContext{Address: 0x4000}
+
+	It("should add hashed history slices to the features", func() {
+		p := MakePerceptronBuilder().
+			WithGlobalHistory(HistoryOutcomes, 8).
+			Build()
+		Expect(p.IsHashedTables()).To(BeTrue())
+
+		before := p.ExtractFeatures(ctx)
+		Expect(before).To(HaveLen(6 + 3))
+
+		p.TrainOnHit(0x8000)
+		after := p.ExtractFeatures(ctx)
+
+		Expect(after[:6]).To(Equal(before[:6]))
+		Expect(after[6:]).NotTo(Equal(before[6:]))
+	})
+
+	It("should tell hits from misses", func() {
+		hit := NewPerceptronVictimFinder()
+		hit.SetGlobalHistory(HistoryOutcomes, 4)
+		hit.TrainOnHit(0x8000)
+
+		miss := NewPerceptronVictimFinder()
+		miss.SetGlobalHistory(HistoryOutcomes, 4)
+		miss.FindVictimWithContext(makeTestSet(2), &VictimContext{
+			Address: 0x8000,
+		})
+
+		Expect(hit.ExtractFeatures(ctx)).
+			NotTo(Equal(miss.ExtractFeatures(ctx)))
+	})
+
+	It("should remember the recent addresses", func() {
+		a := NewPerceptronVictimFinder()
+		a.SetGlobalHistory(HistoryAddresses, 2)
+		b := NewPerceptronVictimFinder()
+		b.SetGlobalHistory(HistoryAddresses, 2)
+
+		a.TrainOnHit(0x1000)
+		a.TrainOnHit(0x2000)
+		b.TrainOnHit(0x3000)
+		b.TrainOnHit(0x2000)
+		Expect(a.ExtractFeatures(ctx)[6]).To(Equal(b.ExtractFeatures(ctx)[6]))
+		Expect(a.ExtractFeatures(ctx)[8]).
+			NotTo(Equal(b.ExtractFeatures(ctx)[8]))
+
+		a.TrainOnHit(0x4000)
+		b.TrainOnHit(0x4000)
+		Expect(a.ExtractFeatures(ctx)).To(Equal(b.ExtractFeatures(ctx)))
+	})
+
+	It("should export the history configuration", func() {
+		p := MakePerceptronBuilder().
+			WithGlobalHistory(HistoryAddresses, 16).
+			Build()
+		p.TrainOnHit(0x1000)
+
+		w := p.ExportWeights()
+		Expect(w.HistoryKind).To(Equal(HistoryAddresses))
+		Expect(w.HistoryLength).To(Equal(16))
+
+		q := NewPerceptronVictimFinder()
+		Expect(q.ImportWeights(w)).To(Succeed())
+		kind, length := q.GlobalHistory()
+		Expect(kind).To(Equal(HistoryAddresses))
+		Expect(length).To(Equal(16))
+		Expect(q.ExportWeights()).To(Equal(w))
+	})
+
+	It("should reject histories that are too long", func() {
+		p := NewPerceptronVictimFinder()
+		Expect(func() { p.SetGlobalHistory(HistoryAddresses, 33) }).To(Panic())
+
+		w := p.ExportWeights()
+		w.HistoryKind = HistoryOutcomes
+		w.HistoryLength = 65
+		Expect(w.Validate()).To(MatchError(ContainSubstring("65")))
+	})
+})
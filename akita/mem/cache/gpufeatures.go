@@ -0,0 +1,105 @@
+package cache
+
+// GPUFeature selects the GPU context fields used as perceptron features.
+type GPUFeature uint32
+
+// GPU features.
+const (
+	GPUFeatureCU GPUFeature = 1 << iota
+	GPUFeatureWavefront
+	GPUFeatureKernel
+	GPUFeatureAccessSize
+	GPUFeatureMemorySpace
+	GPUFeatureAccessType // Whether the access is a read or a write
+	GPUFeaturePrefetch   // Whether the access is a prefetch
+
+	GPUFeatureAll = GPUFeatureCU | GPUFeatureWavefront | GPUFeatureKernel |
+		GPUFeatureAccessSize | GPUFeatureMemorySpace | GPUFeatureAccessType |
+		GPUFeaturePrefetch
+)
+
+// A GPUFeatureExtractor adds the selected GPU context fields of an access to
+// the features of a base extractor. Every selected field gets its own hashed
+// weight table. The wavefront ID is combined with the CU ID, since wavefront
+// IDs are only unique within a compute unit.
+//
+// The GPU fields are only known when the perceptron is used through the
+// methods that take a VictimContext. The directory remembers the fields of
+// every fill in the Block, so that evictions can be trained with them.
+type GPUFeatureExtractor struct {
+	Base     FeatureExtractor // AddressFeatureExtractor if nil
+	Features GPUFeature
+}
+
+// Extract returns the base features followed by the selected GPU features.
+func (e GPUFeatureExtractor) Extract(ctx *VictimContext) []uint32 {
+	base := e.Base
+	if base == nil {
+		base = AddressFeatureExtractor{}
+	}
+
+	features := base.Extract(ctx)
+
+	if e.Features&GPUFeatureCU != 0 {
+		features = append(features, uint32(ctx.CUID))
+	}
+
+	if e.Features&GPUFeatureWavefront != 0 {
+		features = append(features,
+			uint32(ctx.CUID)<<16^uint32(ctx.WavefrontID))
+	}
+
+	if e.Features&GPUFeatureKernel != 0 {
+		features = append(features, uint32(ctx.KernelID))
+	}
+
+	if e.Features&GPUFeatureAccessSize != 0 {
+		features = append(features, uint32(ctx.AccessSize))
+	}
+
+	if e.Features&GPUFeatureMemorySpace != 0 {
+		features = append(features, uint32(ctx.MemorySpace))
+	}
+
+	if e.Features&GPUFeatureAccessType != 0 {
+		features = append(features, accessTypeFeature(ctx.AccessType))
+	}
+
+	if e.Features&GPUFeaturePrefetch != 0 {
+		features = append(features, prefetchFeature(ctx.IsPrefetch))
+	}
+
+	return features
+}
+
+// accessTypeFeature returns 1 for writes and 0 for reads and unknown
+// accesses.
+func accessTypeFeature(accessType string) uint32 {
+	if accessType == "write" {
+		return 1
+	}
+
+	return 0
+}
+
+// prefetchFeature returns 1 for prefetches and 0 for demand accesses.
+func prefetchFeature(prefetch bool) uint32 {
+	if prefetch {
+		return 1
+	}
+
+	return 0
+}
+
+// featureContextFor returns the context whose features are extracted for the
+// access: the context given to the current call if it describes the access,
+// and a context with only the address and the PC otherwise.
+func (p *PerceptronVictimFinder) featureContextFor(
+	addr, pc uint64,
+) *VictimContext {
+	if c := p.featureContext; c != nil && c.Address == addr && c.PC == pc {
+		return c
+	}
+
+	return &VictimContext{Address: addr, PC: pc}
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/mem"
+)
+
+var _ = Describe("GPU features", func() {
+	gpuContext := func(cu int) *VictimContext {
+		return &VictimContext{
+			Address: 0x1000,
+			AccessOrigin: mem.AccessOrigin{
+				CUID:        cu,
+				WavefrontID: 5,
+				KernelID:    7,
+				MemorySpace: mem.MemorySpaceShared,
+			},
+			AccessSize: 64,
+			AccessType: "write",
+			IsPrefetch: true,
+		}
+	}
+
+	It("should append the selected fields to the base features", func() {
+		ctx := gpuContext(3)
+		base := AddressFeatureExtractor{}.Extract(ctx)
+
+		all := GPUFeatureExtractor{Features: GPUFeatureAll}.Extract(ctx)
+		Expect(all[:len(base)]).To(Equal(base))
+		Expect(all[len(base):]).To(Equal([]uint32{
+			3, 3<<16 ^ 5, 7, 64, uint32(mem.MemorySpaceShared), 1, 1,
+		}))
+
+		cu := GPUFeatureExtractor{Features: GPUFeatureCU}.Extract(ctx)
+		Expect(cu).To(HaveLen(len(base) + 1))
+	})
+
+	It("should tell apart the accesses of different compute units", func() {
+		p := MakePerceptronBuilder().
+			WithTheta(16).
+			WithTrainingSampleRate(1).
+			WithGPUFeatures(GPUFeatureCU).
+			Build()
+		p.SetStrictMode(true)
+		Expect(p.IsHashedTables()).To(BeTrue())
+
+		for i := 0; i < 10; i++ {
+			p.TrainOnEvictionWithContext(gpuContext(1))
+			p.TrainOnHitWithContext(gpuContext(2))
+		}
+
+		dead, _ := p.PredictDead(0x1000, gpuContext(1))
+		Expect(dead).To(BeTrue())
+
+		dead, _ = p.PredictDead(0x1000, gpuContext(2))
+		Expect(dead).To(BeFalse())
+	})
+
+	It("should remember the origin of a fill", func() {
+		d := NewDirectory(1, 2, 64, NewLRUVictimFinder())
+		ctx := gpuContext(4)
+
+		block := d.FindVictimWithContext(ctx.Address, ctx)
+		block.Tag = ctx.Address
+		block.IsValid = true
+		d.Visit(block)
+
+		Expect(block.Origin).To(Equal(ctx.AccessOrigin))
+		Expect(block.AccessSize).To(Equal(uint64(64)))
+		Expect(block.AccessType).To(Equal("write"))
+	})
+})
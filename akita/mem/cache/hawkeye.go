@@ -0,0 +1,314 @@
+package cache
+
+// hawkeyeMaxRRPV is the RRPV of cache-averse lines. Hawkeye uses 3-bit RRPVs.
+const hawkeyeMaxRRPV = 7
+
+// A HawkeyeConfig configures a HawkeyeVictimFinder.
+type HawkeyeConfig struct {
+	// One set out of every SampleInterval sets feeds OPTgen. Defaults to
+	// 32.
+	SampleInterval int
+
+	// The OPTgen history of a sampled set covers HistoryFactor times the
+	// associativity accesses. Defaults to 8.
+	HistoryFactor int
+
+	// The predictor has 2^PredictorBits 3-bit counters. Defaults to 11.
+	PredictorBits uint
+}
+
+// HawkeyeStats counts the decisions reconstructed by OPTgen. The OPT misses
+// include the first access to every line.
+type HawkeyeStats struct {
+	SampledAccesses uint64
+	OPTHits         uint64
+	OPTMisses       uint64
+}
+
+// optgenEntry is the last access to a line of a sampled set.
+type optgenEntry struct {
+	valid bool
+	addr  uint64
+	sig   uint32
+	time  uint64
+}
+
+// optgen reconstructs the decisions of Belady's optimal policy for one set.
+// The occupancy vector counts the lines that OPT keeps in the cache at every
+// past access of the set. A reuse is an OPT hit if the interval since the
+// previous access to the line has room at every step.
+type optgen struct {
+	capacity  int
+	now       uint64
+	occupancy []int
+	history   []optgenEntry
+	next      int
+}
+
+func newOPTgen(capacity, window int) *optgen {
+	return &optgen{
+		capacity:  capacity,
+		occupancy: make([]int, window),
+		history:   make([]optgenEntry, window),
+	}
+}
+
+// access records an access and reports the outcome of the previous access to
+// every line whose fate is now known: the reused line, if OPT would have hit,
+// and the line that leaves the history window without being reused.
+func (o *optgen) access(
+	addr uint64,
+	sig uint32,
+	train func(sig uint32, friendly bool),
+) (reused, optHit bool) {
+	window := uint64(len(o.occupancy))
+	o.occupancy[o.now%window] = 0
+
+	if prev := o.find(addr); prev != nil {
+		reused, optHit = true, true
+
+		for t := prev.time; t < o.now; t++ {
+			if o.occupancy[t%window] >= o.capacity {
+				optHit = false
+				break
+			}
+		}
+
+		if optHit {
+			for t := prev.time; t < o.now; t++ {
+				o.occupancy[t%window]++
+			}
+		}
+
+		train(prev.sig, optHit)
+		prev.valid = false
+	}
+
+	// The history holds one entry per access, so the overwritten entry is
+	// exactly one window old.
+	oldest := &o.history[o.next]
+	if oldest.valid {
+		train(oldest.sig, false)
+	}
+
+	*oldest = optgenEntry{valid: true, addr: addr, sig: sig, time: o.now}
+	o.next = (o.next + 1) % len(o.history)
+	o.now++
+
+	return reused, optHit
+}
+
+func (o *optgen) find(addr uint64) *optgenEntry {
+	for i := range o.history {
+		if o.history[i].valid && o.history[i].addr == addr {
+			return &o.history[i]
+		}
+	}
+
+	return nil
+}
+
+// HawkeyeVictimFinder implements Hawkeye (Jain and Lin, ISCA 2016). OPTgen
+// replays the accesses of the sampled sets to learn which fills Belady's
+// optimal policy would have kept, and trains a table of 3-bit counters
+// indexed by the PC signature of the fill. Lines of cache-friendly
+// signatures are inserted at RRPV 0 and age the other friendly lines; lines
+// of cache-averse signatures are inserted at RRPV 7 and are evicted first.
+// Evicting a friendly line detrains its signature.
+//
+// The directory does not know the PC of a hit, so hits are attributed to the
+// signature of the fill, which is stored in Block.Signature. Without a PC,
+// the signature is the memory region of the line.
+type HawkeyeVictimFinder struct {
+	config    HawkeyeConfig
+	predictor []uint8
+	sampled   map[int]*optgen
+	stats     HawkeyeStats
+	energy    *EnergyMeter
+}
+
+// NewHawkeyeVictimFinder returns a Hawkeye victim finder with the default
+// configuration.
+func NewHawkeyeVictimFinder() *HawkeyeVictimFinder {
+	return NewHawkeyeVictimFinderWithConfig(HawkeyeConfig{})
+}
+
+// NewHawkeyeVictimFinderWithConfig returns a Hawkeye victim finder with the
+// configuration.
+func NewHawkeyeVictimFinderWithConfig(config HawkeyeConfig) *HawkeyeVictimFinder {
+	if config.SampleInterval <= 0 {
+		config.SampleInterval = 32
+	}
+
+	if config.HistoryFactor <= 0 {
+		config.HistoryFactor = 8
+	}
+
+	if config.PredictorBits == 0 {
+		config.PredictorBits = 11
+	}
+
+	h := &HawkeyeVictimFinder{
+		config:    config,
+		predictor: make([]uint8, 1<<config.PredictorBits),
+		sampled:   make(map[int]*optgen),
+	}
+
+	// Start weakly friendly, so that lines are cached until OPTgen has
+	// learned otherwise.
+	for i := range h.predictor {
+		h.predictor[i] = 4
+	}
+
+	return h
+}
+
+// Stats returns the OPTgen statistics.
+func (h *HawkeyeVictimFinder) Stats() HawkeyeStats {
+	return h.stats
+}
+
+// SetEnergyMeter attaches an energy meter that is charged for every access
+// to the OPTgen history of a sampled set.
+func (h *HawkeyeVictimFinder) SetEnergyMeter(m *EnergyMeter) {
+	h.energy = m
+}
+
+// EnergyMeter returns the attached energy meter, or nil if there is none.
+func (h *HawkeyeVictimFinder) EnergyMeter() *EnergyMeter {
+	return h.energy
+}
+
+// Friendly tells if the predictor considers the lines of the signature
+// cache-friendly.
+func (h *HawkeyeVictimFinder) Friendly(sig uint32) bool {
+	return h.predictor[sig] >= 4
+}
+
+func (h *HawkeyeVictimFinder) signature(block *Block) uint32 {
+	key := block.Tag >> shipRegionShift
+	if block.PC != 0 {
+		key = block.PC
+	}
+
+	return uint32(mixLineHash(key) & (1<<h.config.PredictorBits - 1))
+}
+
+func (h *HawkeyeVictimFinder) train(sig uint32, friendly bool) {
+	if friendly && h.predictor[sig] < 7 {
+		h.predictor[sig]++
+	} else if !friendly && h.predictor[sig] > 0 {
+		h.predictor[sig]--
+	}
+}
+
+// sample feeds the access to OPTgen if the set of the block is sampled.
+func (h *HawkeyeVictimFinder) sample(set *Set, block *Block) {
+	if block.SetID%h.config.SampleInterval != 0 {
+		return
+	}
+
+	o, ok := h.sampled[block.SetID]
+	if !ok {
+		ways := len(set.Blocks)
+		o = newOPTgen(ways, ways*h.config.HistoryFactor)
+		h.sampled[block.SetID] = o
+	}
+
+	reused, optHit := o.access(block.Tag, block.Signature, h.train)
+	h.energy.Charge(EnergyShadowAccess, 1)
+
+	h.stats.SampledAccesses++
+	if reused && optHit {
+		h.stats.OPTHits++
+	} else {
+		h.stats.OPTMisses++
+	}
+}
+
+// Touch promotes a hit line if its signature is friendly and demotes it
+// otherwise.
+func (h *HawkeyeVictimFinder) Touch(set *Set, block *Block) {
+	h.sample(set, block)
+
+	block.RRPV = hawkeyeMaxRRPV
+	if h.Friendly(block.Signature) {
+		block.RRPV = 0
+	}
+}
+
+// Insert records the signature of a filled line and inserts it according to
+// the prediction.
+func (h *HawkeyeVictimFinder) Insert(set *Set, block *Block) {
+	block.Signature = h.signature(block)
+	h.sample(set, block)
+
+	if !h.Friendly(block.Signature) {
+		block.RRPV = hawkeyeMaxRRPV
+		return
+	}
+
+	for _, b := range set.Blocks {
+		if b != block && b.RRPV < hawkeyeMaxRRPV-1 {
+			b.RRPV++
+		}
+	}
+
+	block.RRPV = 0
+}
+
+// Evict detrains the signature of an evicted cache-friendly line.
+func (h *HawkeyeVictimFinder) Evict(_ *Set, block *Block) {
+	if block.RRPV < hawkeyeMaxRRPV {
+		h.train(block.Signature, false)
+	}
+}
+
+// FindVictim returns the first invalid block, or the unlocked block with the
+// highest RRPV. It returns nil if every block is locked.
+func (h *HawkeyeVictimFinder) FindVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	var victim *Block
+
+	for _, block := range set.Blocks {
+		if block.IsLocked {
+			continue
+		}
+
+		if victim == nil || block.RRPV > victim.RRPV {
+			victim = block
+		}
+	}
+
+	return victim
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (h *HawkeyeVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return h.FindVictim(set)
+}
+
+// FindVictims returns up to n candidates from the highest to the lowest RRPV.
+func (h *HawkeyeVictimFinder) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	ways := make([]int, 0, len(set.Blocks))
+
+	for rrpv := hawkeyeMaxRRPV; rrpv >= 0; rrpv-- {
+		for way, block := range set.Blocks {
+			if int(block.RRPV) == rrpv {
+				ways = append(ways, way)
+			}
+		}
+	}
+
+	return rankCandidates(set, ways, n)
+}
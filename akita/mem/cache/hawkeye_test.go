@@ -0,0 +1,117 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OPTgen", func() {
+	type outcome struct {
+		sig      uint32
+		friendly bool
+	}
+
+	var (
+		o        *optgen
+		outcomes []outcome
+	)
+
+	train := func(sig uint32, friendly bool) {
+		outcomes = append(outcomes, outcome{sig, friendly})
+	}
+
+	BeforeEach(func() {
+		o = newOPTgen(1, 8)
+		outcomes = nil
+	})
+
+	It("should hit when the interval has room", func() {
+		o.access(0x0, 1, train)
+		o.access(0x40, 2, train)
+		reused, optHit := o.access(0x0, 1, train)
+
+		Expect(reused).To(BeTrue())
+		Expect(optHit).To(BeTrue())
+		Expect(outcomes).To(Equal([]outcome{{1, true}}))
+	})
+
+	It("should miss when OPT already uses the capacity", func() {
+		o.access(0x0, 1, train)
+		o.access(0x40, 2, train)
+		o.access(0x0, 1, train)
+		reused, optHit := o.access(0x40, 2, train)
+
+		Expect(reused).To(BeTrue())
+		Expect(optHit).To(BeFalse())
+		Expect(outcomes).To(Equal([]outcome{{1, true}, {2, false}}))
+	})
+
+	It("should train the lines that leave the window as averse", func() {
+		o = newOPTgen(1, 2)
+
+		o.access(0x0, 1, train)
+		o.access(0x40, 2, train)
+		o.access(0x80, 3, train)
+
+		Expect(outcomes).To(Equal([]outcome{{1, false}}))
+	})
+})
+
+var _ = Describe("HawkeyeVictimFinder", func() {
+	var (
+		vf *HawkeyeVictimFinder
+		d  *DirectoryImpl
+	)
+
+	fill := func(addr, pc uint64) *Block {
+		block := d.FindVictimWithContext(addr, &VictimContext{
+			Address: addr,
+			PC:      pc,
+		})
+		block.Tag = addr
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	BeforeEach(func() {
+		vf = NewHawkeyeVictimFinder()
+		d = NewDirectory(1, 2, 64, vf)
+	})
+
+	It("should insert the lines of a streaming PC as averse", func() {
+		var block *Block
+		for i := uint64(0); i < 40; i++ {
+			block = fill(i*64, 0x100)
+		}
+
+		Expect(vf.Friendly(block.Signature)).To(BeFalse())
+		Expect(block.RRPV).To(Equal(uint8(hawkeyeMaxRRPV)))
+		Expect(vf.Stats().OPTHits).To(BeZero())
+	})
+
+	It("should keep the lines of a reused PC friendly", func() {
+		block := fill(0x0, 0x200)
+		for i := 0; i < 10; i++ {
+			d.Visit(block)
+		}
+
+		Expect(vf.Friendly(block.Signature)).To(BeTrue())
+		Expect(block.RRPV).To(BeZero())
+		Expect(vf.Stats().OPTHits).To(Equal(uint64(10)))
+	})
+
+	It("should evict averse lines first", func() {
+		set := makeTestSet(3)
+		for i, rrpv := range []uint8{0, hawkeyeMaxRRPV, 2} {
+			set.Blocks[i].IsValid = true
+			set.Blocks[i].RRPV = rrpv
+		}
+
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[1]))
+		Expect(vf.FindVictims(set, nil, 3)).To(Equal([]*Block{
+			set.Blocks[1], set.Blocks[2], set.Blocks[0],
+		}))
+	})
+})
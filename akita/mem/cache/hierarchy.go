@@ -0,0 +1,346 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// Inclusion policies between the L1s and the shared L2 of a Hierarchy.
+const (
+	// Lines may live in either level or both. L2 evictions do not affect
+	// the L1s.
+	InclusionNonInclusive = "non-inclusive"
+
+	// Every L1 line is also in the L2. An L2 eviction invalidates the line
+	// in all the L1s.
+	InclusionInclusive = "inclusive"
+
+	// A line lives in at most one level. The L2 is filled with L1 victims
+	// only, and an L2 hit moves the line into the L1.
+	InclusionExclusive = "exclusive"
+)
+
+// A HierarchyLevelConfig describes one level of a Hierarchy. The policy is
+// any name accepted by NewVictimFinderByName.
+type HierarchyLevelConfig struct {
+	NumSets int    `json:"sets"`
+	NumWays int    `json:"ways"`
+	Policy  string `json:"policy"`
+
+	// Index is the name of the index function; see IndexFunction. The
+	// default is modulo.
+	Index string `json:"index,omitempty"`
+}
+
+// A HierarchyConfig describes a set of private L1 caches sharing one L2.
+type HierarchyConfig struct {
+	BlockSize int                  `json:"block_size"`
+	NumL1s    int                  `json:"num_l1s"`
+	L1        HierarchyLevelConfig `json:"l1"`
+	L2        HierarchyLevelConfig `json:"l2"`
+	Inclusion string               `json:"inclusion,omitempty"`
+
+	// SharedTraining lists the levels whose outcomes train the predictors
+	// of other levels; see TrainingBus.
+	SharedTraining []TrainingRoute `json:"shared_training,omitempty"`
+}
+
+// A TrainingRoute makes the perceptrons of level To learn from the hits and
+// evictions of level From. The levels are 1 for the L1s and 2 for the L2.
+type TrainingRoute struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// ReadHierarchyConfig decodes a JSON hierarchy description.
+func ReadHierarchyConfig(r io.Reader) (HierarchyConfig, error) {
+	var c HierarchyConfig
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&c); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+// LoadHierarchyConfig reads a JSON hierarchy description from the file.
+func LoadHierarchyConfig(path string) (HierarchyConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return HierarchyConfig{}, err
+	}
+	defer f.Close()
+
+	c, err := ReadHierarchyConfig(f)
+	if err != nil {
+		return c, fmt.Errorf("reading hierarchy config %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// HierarchyLevelStats counts the accesses to one level. The counts of the
+// L1s are summed.
+type HierarchyLevelStats struct {
+	Accesses          uint64
+	Hits              uint64
+	Misses            uint64
+	BackInvalidations uint64
+}
+
+// A Hierarchy is a tag-only model of private L1s and a shared L2. It lets
+// hierarchy-sensitive policies be evaluated on a trace without a full
+// simulation.
+type Hierarchy struct {
+	inclusion string
+	l1s       []*DirectoryImpl
+	l2        *DirectoryImpl
+
+	trainingBus *TrainingBus
+
+	l1Stats HierarchyLevelStats
+	l2Stats HierarchyLevelStats
+}
+
+// NewHierarchy creates a hierarchy from the configuration.
+func NewHierarchy(c HierarchyConfig) (*Hierarchy, error) {
+	if c.BlockSize <= 0 {
+		return nil, fmt.Errorf("block size must be positive")
+	}
+
+	if c.NumL1s <= 0 {
+		return nil, fmt.Errorf("the hierarchy needs at least one L1")
+	}
+
+	h := &Hierarchy{inclusion: c.Inclusion}
+	switch c.Inclusion {
+	case "":
+		h.inclusion = InclusionNonInclusive
+	case InclusionNonInclusive, InclusionInclusive, InclusionExclusive:
+	default:
+		return nil, fmt.Errorf("unknown inclusion policy %q", c.Inclusion)
+	}
+
+	for i := 0; i < c.NumL1s; i++ {
+		l1, err := newHierarchyLevel(c.L1, c.BlockSize)
+		if err != nil {
+			return nil, fmt.Errorf("l1: %w", err)
+		}
+
+		h.l1s = append(h.l1s, l1)
+	}
+
+	l2, err := newHierarchyLevel(c.L2, c.BlockSize)
+	if err != nil {
+		return nil, fmt.Errorf("l2: %w", err)
+	}
+
+	h.l2 = l2
+	if h.inclusion == InclusionInclusive {
+		h.l2.SetLineCallbacks(LineCallbacks{OnEvict: h.backInvalidate})
+	}
+
+	if len(c.SharedTraining) > 0 {
+		if err := h.connectTraining(c.SharedTraining); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// connectTraining attaches the perceptrons of the levels of the routes to a
+// training bus.
+func (h *Hierarchy) connectTraining(routes []TrainingRoute) error {
+	bus := NewTrainingBus()
+
+	for _, route := range routes {
+		if err := h.attachLevel(bus, route.From); err != nil {
+			return err
+		}
+
+		if err := h.attachLevel(bus, route.To); err != nil {
+			return err
+		}
+
+		for _, d := range h.level(route.To) {
+			bus.Connect(route.From, d.victimFinder.(*PerceptronVictimFinder))
+		}
+	}
+
+	h.trainingBus = bus
+
+	return nil
+}
+
+func (h *Hierarchy) attachLevel(bus *TrainingBus, level int) error {
+	dirs := h.level(level)
+	if dirs == nil {
+		return fmt.Errorf("unknown cache level %d", level)
+	}
+
+	for _, d := range dirs {
+		p, ok := d.victimFinder.(*PerceptronVictimFinder)
+		if !ok {
+			return fmt.Errorf(
+				"shared training needs a perceptron policy in level %d", level)
+		}
+
+		p.SetTrainingBus(bus, level)
+	}
+
+	return nil
+}
+
+// level returns the directories of the level, or nil if there is no such
+// level.
+func (h *Hierarchy) level(level int) []*DirectoryImpl {
+	switch level {
+	case 1:
+		return h.l1s
+	case 2:
+		return []*DirectoryImpl{h.l2}
+	default:
+		return nil
+	}
+}
+
+func newHierarchyLevel(
+	c HierarchyLevelConfig,
+	blockSize int,
+) (*DirectoryImpl, error) {
+	if c.NumSets <= 0 || c.NumWays <= 0 {
+		return nil, fmt.Errorf("a level needs at least one set and way")
+	}
+
+	vf, err := NewVictimFinderByName(c.Policy, PolicyConfig{
+		NumSets:   c.NumSets,
+		NumWays:   c.NumWays,
+		BlockSize: blockSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index := IndexModulo
+	if c.Index != "" {
+		index, err = ParseIndexFunction(c.Index)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkIndexFunction(index, c.NumSets); err != nil {
+		return nil, err
+	}
+
+	d := NewDirectory(c.NumSets, c.NumWays, blockSize, vf)
+	d.SetIndexFunction(index)
+
+	return d, nil
+}
+
+// Access sends an access from the given L1 through the hierarchy. It returns
+// the level that serves the access: 1 or 2 for a hit, or 3 for a miss in
+// both levels.
+func (h *Hierarchy) Access(l1 int, pid vm.PID, addr uint64) int {
+	d := h.l1s[l1]
+
+	h.l1Stats.Accesses++
+
+	hit, evicted := accessTagOnly(d, pid, addr)
+	if hit {
+		h.l1Stats.Hits++
+		return 1
+	}
+
+	h.l1Stats.Misses++
+
+	level := h.accessL2(pid, addr)
+
+	if evicted != nil && h.inclusion == InclusionExclusive {
+		h.insertL1Victim(evicted)
+	}
+
+	return level
+}
+
+func (h *Hierarchy) accessL2(pid vm.PID, addr uint64) int {
+	h.l2Stats.Accesses++
+
+	tag := addr / uint64(h.l2.BlockSize) * uint64(h.l2.BlockSize)
+
+	if h.inclusion == InclusionExclusive {
+		block := h.l2.Lookup(pid, tag)
+		if block == nil {
+			h.l2Stats.Misses++
+			return 3
+		}
+
+		h.l2Stats.Hits++
+		block.IsValid = false
+
+		return 2
+	}
+
+	hit, _ := accessTagOnly(h.l2, pid, tag)
+	if hit {
+		h.l2Stats.Hits++
+		return 2
+	}
+
+	h.l2Stats.Misses++
+
+	return 3
+}
+
+func (h *Hierarchy) insertL1Victim(line *tagOnlyLine) {
+	if h.l2.Lookup(line.pid, line.tag) != nil {
+		return
+	}
+
+	fillTagOnly(h.l2, line.pid, line.tag)
+}
+
+// backInvalidate drops a line evicted from an inclusive L2 from all the L1s.
+func (h *Hierarchy) backInvalidate(line LineEvent) {
+	for _, l1 := range h.l1s {
+		if l1.Invalidate(line.PID, line.Tag) {
+			h.l2Stats.BackInvalidations++
+		}
+	}
+}
+
+// L1Stats returns the summed statistics of the L1s.
+func (h *Hierarchy) L1Stats() HierarchyLevelStats {
+	return h.l1Stats
+}
+
+// L2Stats returns the statistics of the L2. Back-invalidations count the L1
+// lines invalidated by L2 evictions.
+func (h *Hierarchy) L2Stats() HierarchyLevelStats {
+	return h.l2Stats
+}
+
+// TrainingBus returns the bus that shares the training outcomes between the
+// levels, or nil if the configuration has no shared training.
+func (h *Hierarchy) TrainingBus() *TrainingBus {
+	return h.trainingBus
+}
+
+// L1 returns the directory of the given L1.
+func (h *Hierarchy) L1(i int) *DirectoryImpl {
+	return h.l1s[i]
+}
+
+// L2 returns the directory of the shared L2.
+func (h *Hierarchy) L2() *DirectoryImpl {
+	return h.l2
+}
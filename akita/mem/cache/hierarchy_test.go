@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hierarchy", func() {
+	config := func(inclusion string, l1Ways, l2Ways int) HierarchyConfig {
+		return HierarchyConfig{
+			BlockSize: 64,
+			NumL1s:    2,
+			L1:        HierarchyLevelConfig{NumSets: 1, NumWays: l1Ways},
+			L2: HierarchyLevelConfig{
+				NumSets: 1, NumWays: l2Ways, Policy: "perceptron",
+			},
+			Inclusion: inclusion,
+		}
+	}
+
+	It("should read the configuration", func() {
+		c, err := ReadHierarchyConfig(strings.NewReader(`{
+			"block_size": 64, "num_l1s": 4,
+			"l1": {"sets": 16, "ways": 4, "policy": "lru"},
+			"l2": {"sets": 256, "ways": 16, "policy": "gpu-l2"},
+			"inclusion": "exclusive"
+		}`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.L2.Policy).To(Equal("gpu-l2"))
+
+		h, err := NewHierarchy(c)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(h.L2().GetVictimFinder()).
+			To(BeAssignableToTypeOf(&PerceptronVictimFinder{}))
+	})
+
+	It("should reject unknown policies and inclusion settings", func() {
+		c := config("", 2, 2)
+		c.L1.Policy = "belady"
+		_, err := NewHierarchy(c)
+		Expect(err).To(MatchError(ContainSubstring("belady")))
+
+		_, err = NewHierarchy(config("mostly", 2, 2))
+		Expect(err).To(MatchError(ContainSubstring("mostly")))
+	})
+
+	It("should share the L2 between the L1s", func() {
+		h, err := NewHierarchy(config(InclusionNonInclusive, 2, 4))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(h.Access(0, 1, 0x40)).To(Equal(3))
+		Expect(h.Access(1, 1, 0x40)).To(Equal(2))
+		Expect(h.Access(0, 1, 0x48)).To(Equal(1))
+
+		Expect(h.L1Stats()).To(Equal(HierarchyLevelStats{
+			Accesses: 3, Hits: 1, Misses: 2,
+		}))
+		Expect(h.L2Stats()).To(Equal(HierarchyLevelStats{
+			Accesses: 2, Hits: 1, Misses: 1,
+		}))
+	})
+
+	It("should back-invalidate the L1s if inclusive", func() {
+		h, err := NewHierarchy(config(InclusionInclusive, 2, 1))
+		Expect(err).NotTo(HaveOccurred())
+
+		h.Access(0, 1, 0x40)
+		h.Access(1, 1, 0x40)
+		h.Access(0, 1, 0x80)
+
+		Expect(h.L1(0).Lookup(1, 0x40)).To(BeNil())
+		Expect(h.L1(1).Lookup(1, 0x40)).To(BeNil())
+		Expect(h.L2Stats().BackInvalidations).To(Equal(uint64(2)))
+		Expect(h.Access(0, 1, 0x40)).To(Equal(3))
+	})
+
+	It("should fill the L2 with L1 victims if exclusive", func() {
+		h, err := NewHierarchy(config(InclusionExclusive, 1, 2))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(h.Access(0, 1, 0x40)).To(Equal(3))
+		Expect(h.L2().Lookup(1, 0x40)).To(BeNil())
+
+		Expect(h.Access(0, 1, 0x80)).To(Equal(3))
+		Expect(h.L2().Lookup(1, 0x40)).NotTo(BeNil())
+
+		Expect(h.Access(0, 1, 0x40)).To(Equal(2))
+		Expect(h.L2().Lookup(1, 0x40)).To(BeNil())
+		Expect(h.L2().Lookup(1, 0x80)).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,150 @@
+package cache
+
+import "github.com/sarchlab/akita/v4/mem/vm"
+
+// An EvictionHintKind is a software hint about the reuse of an address range.
+type EvictionHintKind int
+
+// The eviction hints.
+const (
+	// HintNoReuse marks data that will not be reused. Its blocks are evicted
+	// first, and write misses to it bypass the cache.
+	HintNoReuse EvictionHintKind = iota + 1
+
+	// HintKeep marks data that should stay resident. Its blocks are only
+	// evicted if no other block can be.
+	HintKeep
+)
+
+// An EvictionHint applies a hint to the address range [Start, Start+Size) of
+// a process.
+type EvictionHint struct {
+	PID   vm.PID
+	Start uint64
+	Size  uint64
+	Kind  EvictionHintKind
+}
+
+// EvictionHints holds the hints issued by the workload or the controller,
+// modeling the cache-control hints of real GPUs. Later hints take precedence
+// over earlier ones that overlap.
+type EvictionHints struct {
+	hints []EvictionHint
+}
+
+// NewEvictionHints creates an empty hint table.
+func NewEvictionHints() *EvictionHints {
+	return &EvictionHints{}
+}
+
+// Add registers a hint.
+func (h *EvictionHints) Add(hint EvictionHint) {
+	h.hints = append(h.hints, hint)
+}
+
+// Remove drops all the hints of the process that overlap the range.
+func (h *EvictionHints) Remove(pid vm.PID, start, size uint64) {
+	kept := h.hints[:0]
+
+	for _, hint := range h.hints {
+		if hint.PID == pid &&
+			hint.Start < start+size && start < hint.Start+hint.Size {
+			continue
+		}
+
+		kept = append(kept, hint)
+	}
+
+	h.hints = kept
+}
+
+// Clear drops all the hints.
+func (h *EvictionHints) Clear() {
+	h.hints = nil
+}
+
+// Lookup returns the hint that applies to the address, or 0 if there is none.
+// Calls on a nil table return 0.
+func (h *EvictionHints) Lookup(pid vm.PID, addr uint64) EvictionHintKind {
+	if h == nil {
+		return 0
+	}
+
+	for i := len(h.hints) - 1; i >= 0; i-- {
+		hint := h.hints[i]
+		if hint.PID == pid && addr >= hint.Start &&
+			addr < hint.Start+hint.Size {
+			return hint.Kind
+		}
+	}
+
+	return 0
+}
+
+// ShouldBypass returns true if a miss to the address should not allocate a
+// block. Calls on a nil table return false.
+func (h *EvictionHints) ShouldBypass(pid vm.PID, addr uint64) bool {
+	return h.Lookup(pid, addr) == HintNoReuse
+}
+
+// SetEvictionHints makes victim selection honor the hints. Passing nil
+// disables the hints.
+func (d *DirectoryImpl) SetEvictionHints(h *EvictionHints) {
+	d.hints = h
+}
+
+// EvictionHints returns the hint table of the directory, if any.
+func (d *DirectoryImpl) EvictionHints() *EvictionHints {
+	return d.hints
+}
+
+// applyEvictionHints prefers blocks hinted as not reused and avoids blocks
+// hinted to be kept.
+func (d *DirectoryImpl) applyEvictionHints(
+	vf VictimFinder,
+	set *Set,
+	context *VictimContext,
+	victim *Block,
+) *Block {
+	if d.hints == nil || len(d.hints.hints) == 0 ||
+		victim == nil || !victim.IsValid {
+		return victim
+	}
+
+	hint := func(b *Block) EvictionHintKind {
+		return d.hints.Lookup(b.PID, b.Tag)
+	}
+
+	if hint(victim) == HintNoReuse {
+		return victim
+	}
+
+	// Only rank the candidates if the victim has to be replaced: by a block
+	// hinted as not reused, or because it is hinted to be kept.
+	noReuse := func(b *Block) bool {
+		return b.IsValid && hint(b) == HintNoReuse
+	}
+	if hint(victim) != HintKeep && !hasBlock(set, noReuse) {
+		return victim
+	}
+
+	candidates := FindVictims(vf, set, context, len(set.Blocks))
+
+	for _, block := range candidates {
+		if hint(block) == HintNoReuse {
+			return block
+		}
+	}
+
+	if hint(victim) != HintKeep {
+		return victim
+	}
+
+	for _, block := range candidates {
+		if hint(block) != HintKeep {
+			return block
+		}
+	}
+
+	return victim
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EvictionHints", func() {
+	var (
+		d     *DirectoryImpl
+		hints *EvictionHints
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 4, 64, NewLRUVictimFinder())
+		hints = NewEvictionHints()
+		d.SetEvictionHints(hints)
+
+		for i, b := range d.Sets[0].Blocks {
+			b.IsValid = true
+			b.PID = 1
+			b.Tag = uint64(i) * 64
+		}
+	})
+
+	It("should let later hints take precedence", func() {
+		hints.Add(EvictionHint{PID: 1, Start: 0, Size: 0x1000, Kind: HintKeep})
+		hints.Add(EvictionHint{PID: 1, Start: 0x40, Size: 0x40,
+			Kind: HintNoReuse})
+
+		Expect(hints.Lookup(1, 0x40)).To(Equal(HintNoReuse))
+		Expect(hints.Lookup(1, 0x80)).To(Equal(HintKeep))
+		Expect(hints.Lookup(2, 0x80)).To(BeZero())
+		Expect(hints.ShouldBypass(1, 0x40)).To(BeTrue())
+	})
+
+	It("should evict blocks hinted as not reused first", func() {
+		hints.Add(EvictionHint{PID: 1, Start: 0xc0, Size: 0x40,
+			Kind: HintNoReuse})
+
+		Expect(d.FindVictim(0x1000).Tag).To(Equal(uint64(0xc0)))
+	})
+
+	It("should keep blocks hinted to be kept", func() {
+		hints.Add(EvictionHint{PID: 1, Start: 0, Size: 0xc0, Kind: HintKeep})
+
+		Expect(d.FindVictim(0x1000).Tag).To(Equal(uint64(0xc0)))
+	})
+
+	It("should remove overlapping hints", func() {
+		hints.Add(EvictionHint{PID: 1, Start: 0, Size: 0xc0, Kind: HintKeep})
+		hints.Remove(1, 0x80, 0x10)
+
+		Expect(hints.Lookup(1, 0x0)).To(BeZero())
+	})
+
+	It("should only rank the candidates if a hint applies", func() {
+		vf := &rankCounter{LRUVictimFinder: NewLRUVictimFinder()}
+		d = NewDirectory(1, 4, 64, vf)
+		d.SetEvictionHints(hints)
+		for i, b := range d.Sets[0].Blocks {
+			b.IsValid = true
+			b.PID = 1
+			b.Tag = uint64(i) * 64
+		}
+
+		hints.Add(EvictionHint{PID: 2, Start: 0, Size: 0x1000, Kind: HintKeep})
+		Expect(d.FindVictim(0x1000).Tag).To(Equal(uint64(0)))
+		Expect(vf.rankings).To(BeZero())
+
+		hints.Add(EvictionHint{PID: 1, Start: 0xc0, Size: 0x40,
+			Kind: HintNoReuse})
+		Expect(d.FindVictim(0x1000).Tag).To(Equal(uint64(0xc0)))
+		Expect(vf.rankings).To(Equal(1))
+	})
+})
+
+// rankCounter is an LRU policy that counts the rankings of its candidates.
+type rankCounter struct {
+	*LRUVictimFinder
+	rankings int
+}
+
+func (r *rankCounter) FindVictims(
+	set *Set,
+	context *VictimContext,
+	n int,
+) []*Block {
+	r.rankings++
+	return r.LRUVictimFinder.FindVictims(set, context, n)
+}
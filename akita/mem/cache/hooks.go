@@ -0,0 +1,82 @@
+package cache
+
+import "github.com/sarchlab/akita/v4/sim"
+
+// Hook positions of the perceptron victim finder. The hooks are only invoked
+// if there is at least one, so an unobserved predictor pays nothing.
+var (
+	// HookPosPredictionMade marks a prediction made to select a victim.
+	// The item is a PerceptronPrediction.
+	HookPosPredictionMade = &sim.HookPos{Name: "Perceptron Prediction Made"}
+
+	// HookPosVictimSelected marks the selection of a victim. The item is
+	// the victim block, which may be nil, and the detail is the
+	// PerceptronPrediction that selected it.
+	HookPosVictimSelected = &sim.HookPos{Name: "Perceptron Victim Selected"}
+
+	// HookPosTrainingApplied marks a training outcome, whether or not it
+	// changed the weights. The item is a PerceptronTraining.
+	HookPosTrainingApplied = &sim.HookPos{Name: "Perceptron Training Applied"}
+
+	// HookPosBypassDecided marks an insertion advice. The item is a
+	// PerceptronInsertionDecision.
+	HookPosBypassDecided = &sim.HookPos{Name: "Perceptron Bypass Decided"}
+)
+
+// A PerceptronPrediction describes a prediction of the perceptron.
+type PerceptronPrediction struct {
+	Address        uint64
+	PC             uint64
+	Sum            int32
+	Threshold      int32
+	Theta          int32
+	PredictNoReuse bool
+}
+
+// A PerceptronTraining describes a training outcome of the perceptron.
+type PerceptronTraining struct {
+	Address          uint64
+	PC               uint64
+	Sum              int32
+	Threshold        int32
+	PredictedNoReuse bool
+	ActualReuse      bool
+	Updated          bool // The weights were changed
+}
+
+// A PerceptronInsertionDecision describes an insertion advice of the
+// perceptron.
+type PerceptronInsertionDecision struct {
+	Address    uint64
+	PC         uint64
+	Dead       bool
+	Confidence int32
+	Threshold  int32
+	Priority   InsertionPriority
+}
+
+func (p *PerceptronVictimFinder) prediction(
+	addr, pc uint64,
+	sum int32,
+) PerceptronPrediction {
+	return PerceptronPrediction{
+		Address:        addr,
+		PC:             pc,
+		Sum:            sum,
+		Threshold:      p.threshold,
+		Theta:          p.theta,
+		PredictNoReuse: sum >= p.threshold,
+	}
+}
+
+func (p *PerceptronVictimFinder) invokeHook(
+	pos *sim.HookPos,
+	item, detail interface{},
+) {
+	p.InvokeHook(sim.HookCtx{
+		Domain: p,
+		Pos:    pos,
+		Item:   item,
+		Detail: detail,
+	})
+}
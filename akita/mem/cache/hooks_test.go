@@ -0,0 +1,88 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+type recordingHook struct {
+	ctxs []sim.HookCtx
+}
+
+func (h *recordingHook) Func(ctx sim.HookCtx) {
+	h.ctxs = append(h.ctxs, ctx)
+}
+
+func (h *recordingHook) at(pos *sim.HookPos) []sim.HookCtx {
+	var ctxs []sim.HookCtx
+
+	for _, ctx := range h.ctxs {
+		if ctx.Pos == pos {
+			ctxs = append(ctxs, ctx)
+		}
+	}
+
+	return ctxs
+}
+
+var _ = Describe("Perceptron hooks", func() {
+	var (
+		p    *PerceptronVictimFinder
+		hook *recordingHook
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		hook = &recordingHook{}
+		p.AcceptHook(hook)
+	})
+
+	It("should report predictions and victims", func() {
+		set := &Set{Blocks: []*Block{{IsValid: true}, {IsValid: true, WayID: 1}}}
+
+		victim := p.FindVictimWithContext(set,
+			&VictimContext{Address: 0x40, PC: 0x100})
+
+		predictions := hook.at(HookPosPredictionMade)
+		Expect(predictions).To(HaveLen(1))
+		Expect(predictions[0].Domain).To(BeIdenticalTo(p))
+		Expect(predictions[0].Item).To(Equal(PerceptronPrediction{
+			Address:        0x40,
+			PC:             0x100,
+			Threshold:      0,
+			Theta:          32,
+			PredictNoReuse: true,
+		}))
+
+		victims := hook.at(HookPosVictimSelected)
+		Expect(victims).To(HaveLen(1))
+		Expect(victims[0].Item).To(BeIdenticalTo(victim))
+		Expect(victims[0].Detail).To(Equal(predictions[0].Item))
+	})
+
+	It("should report training outcomes with the original address", func() {
+		p.SetFeatureShift(6)
+		p.TrainOnHit(0x10040)
+
+		trainings := hook.at(HookPosTrainingApplied)
+		Expect(trainings).To(HaveLen(1))
+		Expect(trainings[0].Item).To(Equal(PerceptronTraining{
+			Address:          0x10040,
+			Threshold:        0,
+			PredictedNoReuse: true,
+			ActualReuse:      true,
+			Updated:          true,
+		}))
+	})
+
+	It("should report insertion decisions", func() {
+		priority := p.AdviseInsertion(&VictimContext{Address: 0x40})
+
+		decisions := hook.at(HookPosBypassDecided)
+		Expect(decisions).To(HaveLen(1))
+		Expect(decisions[0].Item.(PerceptronInsertionDecision).Priority).
+			To(Equal(priority))
+	})
+})
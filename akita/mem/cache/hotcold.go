@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+)
+
+// BlockTemperature classifies how likely a resident block is to be reused.
+type BlockTemperature int
+
+// The block temperatures.
+const (
+	BlockInvalid BlockTemperature = iota
+	BlockDead
+	BlockWarm
+	BlockHot
+)
+
+var blockTemperatureNames = [...]string{"invalid", "dead", "warm", "hot"}
+
+// String returns the name of the temperature.
+func (t BlockTemperature) String() string {
+	return blockTemperatureNames[t]
+}
+
+var blockTemperatureSymbols = [...]byte{'.', 'D', 'W', 'H'}
+
+// A HotColdSample is the distribution of the block temperatures at one point
+// in time.
+type HotColdSample struct {
+	Access  uint64
+	Invalid int
+	Dead    int
+	Warm    int
+	Hot     int
+}
+
+// A HotColdClassifier periodically classifies the resident blocks of a
+// directory. A block is hot if it has been hit at least HotHits times since it
+// was filled. Otherwise, it is dead if the perceptron confidently predicts no
+// reuse for it, or, without a perceptron, if it has never been hit. All other
+// valid blocks are warm.
+type HotColdClassifier struct {
+	HotHits  int
+	Interval uint64
+
+	accesses uint64
+	samples  []HotColdSample
+}
+
+// NewHotColdClassifier creates a classifier that takes a sample every
+// interval directory visits.
+func NewHotColdClassifier(interval uint64) *HotColdClassifier {
+	return &HotColdClassifier{
+		HotHits:  2,
+		Interval: interval,
+	}
+}
+
+// SetHotColdClassifier attaches a classifier to the directory. Passing nil
+// detaches it.
+func (d *DirectoryImpl) SetHotColdClassifier(c *HotColdClassifier) {
+	d.hotCold = c
+}
+
+// recordHotColdAccess counts the visit and takes a sample at the end of each
+// interval.
+func (d *DirectoryImpl) recordHotColdAccess() {
+	c := d.hotCold
+	if c == nil || c.Interval == 0 {
+		return
+	}
+
+	c.accesses++
+	if c.accesses%c.Interval == 0 {
+		c.samples = append(c.samples, d.classifyBlocks())
+	}
+}
+
+// ClassifyBlock returns the temperature of the block.
+func (d *DirectoryImpl) ClassifyBlock(block *Block) BlockTemperature {
+	hotHits := 2
+	if d.hotCold != nil {
+		hotHits = d.hotCold.HotHits
+	}
+
+	switch {
+	case !block.IsValid:
+		return BlockInvalid
+	case block.HitCount >= hotHits:
+		return BlockHot
+	}
+
+	if p, ok := d.victimFinder.(DeadBlockPredictor); ok {
+		dead, _ := p.PredictDead(block.Tag, residentContext(block))
+		if dead {
+			return BlockDead
+		}
+
+		return BlockWarm
+	}
+
+	if block.HitCount == 0 {
+		return BlockDead
+	}
+
+	return BlockWarm
+}
+
+func (d *DirectoryImpl) classifyBlocks() HotColdSample {
+	s := HotColdSample{}
+	if d.hotCold != nil {
+		s.Access = d.hotCold.accesses
+	}
+
+	for _, set := range d.Sets {
+		for _, block := range set.Blocks {
+			switch d.ClassifyBlock(block) {
+			case BlockInvalid:
+				s.Invalid++
+			case BlockDead:
+				s.Dead++
+			case BlockWarm:
+				s.Warm++
+			case BlockHot:
+				s.Hot++
+			}
+		}
+	}
+
+	return s
+}
+
+// HotColdSamples returns the samples taken so far by the attached classifier.
+func (d *DirectoryImpl) HotColdSamples() []HotColdSample {
+	if d.hotCold == nil {
+		return nil
+	}
+
+	return d.hotCold.samples
+}
+
+// WriteHotColdCSV writes the samples as CSV with a header line.
+func WriteHotColdCSV(w io.Writer, samples []HotColdSample) error {
+	if _, err := fmt.Fprintln(w, "access,invalid,dead,warm,hot"); err != nil {
+		return err
+	}
+
+	for _, s := range samples {
+		_, err := fmt.Fprintf(w, "%d,%d,%d,%d,%d\n",
+			s.Access, s.Invalid, s.Dead, s.Warm, s.Hot)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DumpSetMap writes one line per set with one character per way: H for hot,
+// W for warm, D for dead, and . for invalid blocks.
+func (d *DirectoryImpl) DumpSetMap(w io.Writer) error {
+	line := make([]byte, d.NumWays+1)
+	line[d.NumWays] = '\n'
+
+	for _, set := range d.Sets {
+		for i, block := range set.Blocks {
+			line[i] = blockTemperatureSymbols[d.ClassifyBlock(block)]
+		}
+
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HotColdClassifier", func() {
+	var (
+		d *DirectoryImpl
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(2, 2, 64, NewLRUVictimFinder())
+		d.SetHotColdClassifier(NewHotColdClassifier(4))
+	})
+
+	access := func(addr uint64) {
+		block := d.Lookup(0, addr)
+		if block == nil {
+			block = d.FindVictim(addr)
+			block.Tag = addr
+			block.IsValid = true
+		}
+
+		d.Visit(block)
+	}
+
+	It("should classify blocks by their hits", func() {
+		access(0x0)
+		access(0x0)
+		access(0x0)
+		access(0x40)
+		access(0x40)
+		access(0x80)
+
+		Expect(d.ClassifyBlock(d.Lookup(0, 0x0))).To(Equal(BlockHot))
+		Expect(d.ClassifyBlock(d.Lookup(0, 0x40))).To(Equal(BlockWarm))
+		Expect(d.ClassifyBlock(d.Lookup(0, 0x80))).To(Equal(BlockDead))
+	})
+
+	It("should classify with the perceptron confidence", func() {
+		p := NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		d = NewDirectory(2, 2, 64, p)
+		access(0x1c0)
+		for i := 0; i < 50; i++ {
+			p.TrainOnEviction(0x1c0)
+		}
+
+		Expect(d.ClassifyBlock(d.Lookup(0, 0x1c0))).To(Equal(BlockDead))
+	})
+
+	It("should sample the distribution periodically", func() {
+		for i := 0; i < 8; i++ {
+			access(uint64(i%3) * 0x40)
+		}
+
+		samples := d.HotColdSamples()
+
+		Expect(samples).To(HaveLen(2))
+		Expect(samples[1].Access).To(Equal(uint64(8)))
+		Expect(samples[1].Invalid + samples[1].Dead +
+			samples[1].Warm + samples[1].Hot).To(Equal(4))
+	})
+
+	It("should dump the set map and the samples", func() {
+		access(0x0)
+		access(0x0)
+		access(0x0)
+		access(0x40)
+		buf := new(bytes.Buffer)
+
+		Expect(d.DumpSetMap(buf)).To(Succeed())
+		Expect(buf.String()).To(Equal("H.\nD.\n"))
+
+		buf.Reset()
+		Expect(WriteHotColdCSV(buf, d.HotColdSamples())).To(Succeed())
+		Expect(buf.String()).To(Equal("access,invalid,dead,warm,hot\n4,2,1,0,1\n"))
+	})
+})
@@ -0,0 +1,141 @@
+package cache
+
+// InsertionPriority is the position at which a filled block enters its set.
+type InsertionPriority int
+
+// Insertion priorities.
+const (
+	// The block is inserted as the most recently used one, as usual.
+	InsertMRU InsertionPriority = iota
+
+	// The block is inserted as the next victim of the set.
+	InsertLRU
+
+	// The block should not be allocated at all. The directory cannot refuse
+	// a fill, so it treats the advice like InsertLRU; a controller that can
+	// bypass the cache should ask the advisor before allocating.
+	InsertBypass
+)
+
+// An InsertionAdvisor recommends the insertion priority of a fill, given the
+// context of the miss that causes it.
+type InsertionAdvisor interface {
+	AdviseInsertion(ctx *VictimContext) InsertionPriority
+}
+
+// distantRRPVPolicy is implemented by the policies that keep a
+// re-reference prediction value in Block.RRPV. A block with the distant
+// value is evicted first.
+type distantRRPVPolicy interface {
+	distantRRPV() uint8
+}
+
+func (r *RRIPVictimFinder) distantRRPV() uint8 { return rripMaxRRPV }
+
+func (s *SHiPVictimFinder) distantRRPV() uint8 { return rripMaxRRPV }
+
+func (h *HawkeyeVictimFinder) distantRRPV() uint8 { return hawkeyeMaxRRPV }
+
+// AdviseInsertion inserts the lines that are confidently predicted dead at
+// the LRU position, and recommends bypassing the ones whose confidence is at
+// least twice the training threshold theta. The other lines are inserted at
+// the MRU position.
+func (p *PerceptronVictimFinder) AdviseInsertion(
+	ctx *VictimContext,
+) InsertionPriority {
+	dead, confidence := p.PredictDead(ctx.Address, ctx)
+
+	priority := InsertLRU
+
+	switch {
+	case !dead:
+		priority = InsertMRU
+	case p.theta > 0 && confidence >= 2*p.theta:
+		priority = InsertBypass
+	}
+
+	if p.NumHooks() > 0 {
+		p.invokeHook(HookPosBypassDecided, PerceptronInsertionDecision{
+			Address:    ctx.Address,
+			PC:         ctx.PC,
+			Dead:       dead,
+			Confidence: confidence,
+			Threshold:  p.threshold,
+			Priority:   priority,
+		}, nil)
+	}
+
+	return priority
+}
+
+// SetInsertionAdvisor makes the directory ask the advisor for the insertion
+// priority of every fill that is selected with a victim context. Fills
+// without a context are inserted at the MRU position.
+func (d *DirectoryImpl) SetInsertionAdvisor(a InsertionAdvisor) {
+	d.insertionAdvisor = a
+}
+
+// InsertionAdvisor returns the insertion advisor, if any.
+func (d *DirectoryImpl) InsertionAdvisor() InsertionAdvisor {
+	return d.insertionAdvisor
+}
+
+// PendingInsertion returns the insertion priority of the fill of the block,
+// if it is the victim most recently found in its set, and InsertMRU
+// otherwise. A controller that can bypass the cache checks it after finding
+// the victim, to honor the advisor and the admission filter.
+func (d *DirectoryImpl) PendingInsertion(block *Block) InsertionPriority {
+	if d.pendingFills[block.SetID] != block {
+		return InsertMRU
+	}
+
+	return d.pendingContext[block.SetID].insertion
+}
+
+// adviseInsertion returns the insertion priority of the fill that follows a
+// victim selection.
+func (d *DirectoryImpl) adviseInsertion(context *VictimContext) InsertionPriority {
+	if d.insertionAdvisor == nil || context == nil {
+		return InsertMRU
+	}
+
+	return d.insertionAdvisor.AdviseInsertion(context)
+}
+
+// demoteFill makes a filled block the next victim of its set under the
+// recency state of every policy: the PseudoLRU tree, the access stamp of
+// exact LRU, the reference bit of Clock and NRU, and the RRPV of the RRIP
+// family.
+func (d *DirectoryImpl) demoteFill(set *Set, block *Block) {
+	block.LastAccess = 0
+	block.Referenced = false
+
+	if r, ok := d.victimFinder.(distantRRPVPolicy); ok {
+		block.RRPV = r.distantRRPV()
+	}
+
+	d.pointPseudoLRUAt(set, block.WayID)
+}
+
+// pointPseudoLRUAt points every node on the path to the way at it, so that
+// the way is the next PseudoLRU victim.
+func (d *DirectoryImpl) pointPseudoLRUAt(set *Set, wayID int) {
+	if len(set.Blocks) > maxPseudoLRUWays {
+		set.moveLRUWay(wayID, true)
+		return
+	}
+
+	node, lo, hi := 0, 0, len(set.Blocks)
+
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+
+		if wayID < mid {
+			set.PseudoLRUBits &^= 1 << uint(node)
+			node, hi = 2*node+1, mid
+		} else {
+			set.PseudoLRUBits |= 1 << uint(node)
+			node, lo = 2*node+2, mid
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fixedInsertionAdvisor struct {
+	priority InsertionPriority
+	asked    int
+}
+
+func (a *fixedInsertionAdvisor) AdviseInsertion(*VictimContext) InsertionPriority {
+	a.asked++
+	return a.priority
+}
+
+var _ = Describe("InsertionAdvisor", func() {
+	var advisor *fixedInsertionAdvisor
+
+	fill := func(d *DirectoryImpl, addr uint64) *Block {
+		block := d.FindVictimWithContext(addr, &VictimContext{Address: addr})
+		block.Tag = addr
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	BeforeEach(func() {
+		advisor = &fixedInsertionAdvisor{}
+	})
+
+	It("should insert at the MRU position by default", func() {
+		d := NewDirectory(1, 4, 64, NewLRUVictimFinder())
+		d.SetInsertionAdvisor(advisor)
+
+		var last *Block
+		for i := uint64(0); i < 5; i++ {
+			last = fill(d, i*64)
+		}
+
+		Expect(advisor.asked).To(Equal(5))
+		Expect(d.FindVictim(0x1000)).NotTo(BeIdenticalTo(last))
+	})
+
+	for _, priority := range []InsertionPriority{InsertLRU, InsertBypass} {
+		priority := priority
+
+		It("should make a demoted fill the next PseudoLRU victim", func() {
+			d := NewDirectory(1, 8, 64, NewLRUVictimFinder())
+			for i := uint64(0); i < 8; i++ {
+				fill(d, i*64)
+			}
+
+			d.SetInsertionAdvisor(advisor)
+			advisor.priority = priority
+
+			block := fill(d, 0x1000)
+
+			Expect(d.FindVictim(0x2000)).To(BeIdenticalTo(block))
+		})
+	}
+
+	It("should keep hits at the MRU position", func() {
+		d := NewDirectory(1, 4, 64, NewLRUVictimFinder())
+		for i := uint64(0); i < 4; i++ {
+			fill(d, i*64)
+		}
+
+		d.SetInsertionAdvisor(advisor)
+		advisor.priority = InsertLRU
+
+		block := d.Lookup(0, 0)
+		d.Visit(block)
+
+		Expect(d.FindVictim(0x1000)).NotTo(BeIdenticalTo(block))
+	})
+
+	It("should insert at the distant RRPV", func() {
+		d := NewDirectory(1, 4, 64, NewSRRIPVictimFinder())
+		d.SetInsertionAdvisor(advisor)
+		advisor.priority = InsertLRU
+
+		block := fill(d, 0)
+
+		Expect(block.RRPV).To(Equal(uint8(rripMaxRRPV)))
+	})
+
+	It("should make a demoted fill the next exact LRU victim", func() {
+		d := NewDirectory(1, 4, 64, NewTrueLRUVictimFinder())
+		for i := uint64(0); i < 4; i++ {
+			fill(d, i*64)
+		}
+
+		d.SetInsertionAdvisor(advisor)
+		advisor.priority = InsertLRU
+
+		block := fill(d, 0x1000)
+
+		Expect(d.FindVictim(0x2000)).To(BeIdenticalTo(block))
+	})
+
+	It("should derive the advice from the perceptron confidence", func() {
+		p := NewPerceptronVictimFinderWithParams(0, 4, 1)
+		p.SetStrictMode(true)
+		p.SetHashedTables(false)
+		ctx := &VictimContext{Address: 0x10001}
+
+		Expect(p.AdviseInsertion(ctx)).To(Equal(InsertMRU))
+
+		for i := 0; i < 2; i++ {
+			p.TrainOnEviction(ctx.Address)
+		}
+
+		Expect(p.AdviseInsertion(ctx)).To(Equal(InsertLRU))
+
+		// Training stops at theta, so the weights are set directly.
+		var weights [MaxPerceptronWeights]int32
+		weights[0], weights[16] = 4, 4
+		p.SetWeights(weights)
+
+		Expect(p.AdviseInsertion(ctx)).To(Equal(InsertBypass))
+	})
+})
@@ -0,0 +1,131 @@
+package cache
+
+// A KernelBoundaryAction lists what to do with the predictor state at a
+// kernel boundary.
+type KernelBoundaryAction struct {
+	// DecayShift shifts the perceptron weights right by this many bits. 0
+	// keeps the weights, and 31 or more clears them.
+	DecayShift uint
+
+	// DecayNow decays the perceptron weights with their configured decay
+	// mode; see PerceptronVictimFinder.DecayNow.
+	DecayNow bool
+
+	// SnapshotWeights records a copy of the perceptron weights.
+	SnapshotWeights bool
+
+	// ResetStats clears the prediction statistics so that they cover one
+	// kernel.
+	ResetStats bool
+
+	// EndPhase records the prediction statistics under the kernel name and
+	// then clears them; see PerceptronVictimFinder.Phases.
+	EndPhase bool
+
+	// FlushBypass ends the fallback mode of all the sets in the thrashing
+	// detector, so that bypass decisions do not carry over.
+	FlushBypass bool
+}
+
+// A KernelBoundaryPolicy selects the actions taken at kernel launch and
+// completion.
+type KernelBoundaryPolicy struct {
+	OnLaunch   KernelBoundaryAction
+	OnComplete KernelBoundaryAction
+}
+
+// A KernelWeightSnapshot is a copy of the perceptron weights taken at a kernel
+// boundary.
+type KernelWeightSnapshot struct {
+	Kernel  string
+	Launch  bool
+	Weights [MaxPerceptronWeights]int32
+	Tables  [][PerceptronTableSize]int32
+}
+
+// A perceptronOwner is a victim finder that predicts with one or more
+// perceptrons, such as a perceptron itself or a policy that wraps one.
+type perceptronOwner interface {
+	// eachPerceptron calls f with every perceptron of the victim finder.
+	// The perceptrons hold the same weights while f runs, and the weight
+	// changes made by f are kept.
+	eachPerceptron(f func(p *PerceptronVictimFinder))
+}
+
+// KernelBoundaryHooks apply a KernelBoundaryPolicy to the predictor state of a
+// directory. A GPU runner calls KernelLaunched and KernelCompleted around
+// every kernel.
+type KernelBoundaryHooks struct {
+	Policy KernelBoundaryPolicy
+
+	directory *DirectoryImpl
+	snapshots []KernelWeightSnapshot
+}
+
+// NewKernelBoundaryHooks creates hooks for the directory.
+func NewKernelBoundaryHooks(
+	d *DirectoryImpl,
+	policy KernelBoundaryPolicy,
+) *KernelBoundaryHooks {
+	return &KernelBoundaryHooks{
+		Policy:    policy,
+		directory: d,
+	}
+}
+
+// KernelLaunched applies the launch actions.
+func (h *KernelBoundaryHooks) KernelLaunched(kernel string) {
+	h.apply(kernel, true, h.Policy.OnLaunch)
+}
+
+// KernelCompleted applies the completion actions.
+func (h *KernelBoundaryHooks) KernelCompleted(kernel string) {
+	h.apply(kernel, false, h.Policy.OnComplete)
+}
+
+// Snapshots returns the weight snapshots taken so far.
+func (h *KernelBoundaryHooks) Snapshots() []KernelWeightSnapshot {
+	return h.snapshots
+}
+
+func (h *KernelBoundaryHooks) apply(
+	kernel string,
+	launch bool,
+	action KernelBoundaryAction,
+) {
+	if action.FlushBypass && h.directory.thrashing != nil {
+		h.directory.thrashing.Reset()
+	}
+
+	owner, ok := h.directory.victimFinder.(perceptronOwner)
+	if !ok {
+		return
+	}
+
+	snapshot := action.SnapshotWeights
+	owner.eachPerceptron(func(p *PerceptronVictimFinder) {
+		if snapshot {
+			h.snapshots = append(h.snapshots, KernelWeightSnapshot{
+				Kernel:  kernel,
+				Launch:  launch,
+				Weights: p.Weights(),
+				Tables:  p.TableWeights(),
+			})
+			snapshot = false
+		}
+
+		p.DecayWeights(action.DecayShift)
+
+		if action.DecayNow {
+			p.DecayNow()
+		}
+
+		if action.EndPhase {
+			p.EndPhase(kernel)
+		}
+
+		if action.ResetStats {
+			p.ResetStats()
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KernelBoundaryHooks", func() {
+	var (
+		p *PerceptronVictimFinder
+		d *DirectoryImpl
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		d = NewDirectory(4, 4, 64, p)
+
+		// Train both the weight vector and the hashed tables.
+		for _, hashed := range []bool{false, true} {
+			p.SetHashedTables(hashed)
+			for i := 0; i < 20; i++ {
+				p.TrainOnEviction(0xffff)
+			}
+		}
+	})
+
+	It("should snapshot the weights before decaying them", func() {
+		weights := p.Weights()
+		tables := p.TableWeights()
+		h := NewKernelBoundaryHooks(d, KernelBoundaryPolicy{
+			OnComplete: KernelBoundaryAction{
+				SnapshotWeights: true,
+				DecayShift:      1,
+			},
+		})
+
+		h.KernelCompleted("k0")
+
+		Expect(h.Snapshots()).To(HaveLen(1))
+		Expect(h.Snapshots()[0].Weights).To(Equal(weights))
+		Expect(h.Snapshots()[0].Tables).To(Equal(tables))
+		Expect(h.Snapshots()[0].Kernel).To(Equal("k0"))
+		for i, w := range p.Weights() {
+			Expect(w).To(Equal(weights[i] / 2))
+		}
+		for i, idx := range p.tableIndices(0xffff, 0) {
+			Expect(p.tables[i][idx]).To(Equal(tables[i][idx] / 2))
+		}
+	})
+
+	It("should clear the weights with a shift of 32 or more", func() {
+		Expect(p.Weights()).NotTo(HaveEach(BeZero()))
+		Expect(p.predictionSum(0xffff, 0)).NotTo(BeZero())
+		h := NewKernelBoundaryHooks(d, KernelBoundaryPolicy{
+			OnComplete: KernelBoundaryAction{DecayShift: 32},
+		})
+
+		h.KernelCompleted("k0")
+
+		Expect(p.Weights()).To(HaveEach(BeZero()))
+		Expect(p.predictionSum(0xffff, 0)).To(BeZero())
+	})
+
+	It("should decay the perceptron of a duel", func() {
+		d = NewDirectory(4, 4, 64, NewDuelingVictimFinder(p, 4))
+		h := NewKernelBoundaryHooks(d, KernelBoundaryPolicy{
+			OnComplete: KernelBoundaryAction{
+				SnapshotWeights: true,
+				DecayShift:      32,
+			},
+		})
+
+		h.KernelCompleted("k0")
+
+		Expect(h.Snapshots()).To(HaveLen(1))
+		Expect(p.predictionSum(0xffff, 0)).To(BeZero())
+	})
+
+	It("should decay the shared weights of the banks", func() {
+		b := NewBankedPerceptron(BankedPerceptronConfig{
+			NumBanks: 2,
+			NewBank: func() *PerceptronVictimFinder {
+				bank := NewPerceptronVictimFinder()
+				bank.SetStrictMode(true)
+
+				return bank
+			},
+		})
+		for i := 0; i < 4; i++ {
+			b.Bank(0).TrainOnEviction(0x10000)
+			b.Bank(1).TrainOnEviction(0x10040)
+		}
+		d = NewDirectory(4, 4, 64, b)
+		h := NewKernelBoundaryHooks(d, KernelBoundaryPolicy{
+			OnComplete: KernelBoundaryAction{
+				SnapshotWeights: true,
+				DecayShift:      1,
+			},
+		})
+
+		h.KernelCompleted("k0")
+
+		Expect(h.Snapshots()).To(HaveLen(1))
+		tables := h.Snapshots()[0].Tables
+		Expect(tables).NotTo(Equal(NewPerceptronVictimFinder().tables))
+		for i, idx := range b.Bank(0).tableIndices(0x10040, 0) {
+			Expect(tables[i][idx]).To(BeNumerically(">=", 4))
+		}
+
+		b.MergeAll()
+
+		Expect(b.Bank(1).TableWeights()).To(Equal(b.Bank(0).TableWeights()))
+		for i, table := range b.Bank(0).TableWeights() {
+			for j, w := range table {
+				Expect(w).To(Equal(tables[i][j] / 2))
+			}
+		}
+	})
+
+	It("should reset the statistics at launch", func() {
+		set := makeTestSet(4)
+		p.FindVictimWithContext(set, &VictimContext{Address: 0x40})
+		h := NewKernelBoundaryHooks(d, KernelBoundaryPolicy{
+			OnLaunch: KernelBoundaryAction{ResetStats: true},
+		})
+
+		h.KernelLaunched("k1")
+
+		total, _, _ := p.GetStats()
+		Expect(total).To(BeZero())
+	})
+
+	It("should flush the bypass state", func() {
+		config := DefaultThrashingDetectorConfig()
+		config.Interval = 4
+		config.MissFactor = 0
+		config.Bypass = true
+		t := NewThrashingDetector(config, 4, 4)
+		d.SetThrashingDetector(t)
+		for i := 0; i < 4; i++ {
+			t.RecordMiss(0)
+		}
+		Expect(t.ShouldBypass(0)).To(BeTrue())
+		h := NewKernelBoundaryHooks(d, KernelBoundaryPolicy{
+			OnComplete: KernelBoundaryAction{FlushBypass: true},
+		})
+
+		h.KernelCompleted("k0")
+
+		Expect(t.ShouldBypass(0)).To(BeFalse())
+	})
+})
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// LinearModelFormat identifies the linear model file format.
+const LinearModelFormat = "linear-v1"
+
+// A LinearModel is a reuse predictor trained offline. Its inputs are the 32
+// low bits of the line address shifted right by FeatureShift, the same
+// features as the perceptron; input i is 1 if bit i is set. The model predicts
+// no reuse when Bias plus the weights of the set bits is at least Threshold.
+//
+// The weights are quantized to integers after multiplying by Scale, which
+// defaults to 1. Theta is the confidence threshold below which the victim
+// finder falls back to PseudoLRU.
+type LinearModel struct {
+	Format       string    `json:"format"`
+	FeatureShift uint      `json:"feature_shift"`
+	Weights      []float64 `json:"weights"`
+	Bias         float64   `json:"bias"`
+	Threshold    float64   `json:"threshold"`
+	Theta        float64   `json:"theta"`
+	Scale        float64   `json:"scale,omitempty"`
+}
+
+// Validate checks the format and the number of weights.
+func (m LinearModel) Validate() error {
+	if m.Format != LinearModelFormat {
+		return fmt.Errorf("unsupported linear model format %q", m.Format)
+	}
+
+	if len(m.Weights) != 32 {
+		return fmt.Errorf("linear model has %d weights, want 32",
+			len(m.Weights))
+	}
+
+	return nil
+}
+
+// ReadLinearModel decodes and validates a JSON linear model.
+func ReadLinearModel(r io.Reader) (LinearModel, error) {
+	var m LinearModel
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&m); err != nil {
+		return m, err
+	}
+
+	return m, m.Validate()
+}
+
+// ReadLinearModelFile reads a JSON linear model from the file.
+func ReadLinearModelFile(path string) (LinearModel, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return LinearModel{}, err
+	}
+	defer f.Close()
+
+	m, err := ReadLinearModel(f)
+	if err != nil {
+		return m, fmt.Errorf("reading linear model %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// SetInferenceOnly enables or disables inference-only mode. In this mode, the
+// weights are never updated, but the prediction statistics still are.
+func (p *PerceptronVictimFinder) SetInferenceOnly(inferenceOnly bool) {
+	p.inferenceOnly = inferenceOnly
+}
+
+// IsInferenceOnly reports whether inference-only mode is enabled.
+func (p *PerceptronVictimFinder) IsInferenceOnly() bool {
+	return p.inferenceOnly
+}
+
+// LoadLinearModel replaces the weights with the quantized model and enables
+// inference-only mode. The model uses the bit-indexed weight vector, so the
+// hashed tables are disabled.
+func (p *PerceptronVictimFinder) LoadLinearModel(m LinearModel) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	scale := m.Scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	quantize := func(v float64) int32 {
+		q := math.Round(v * scale)
+		return int32(math.Max(math.MinInt32, math.Min(math.MaxInt32, q)))
+	}
+
+	var weights [32]int32
+	for i, w := range m.Weights {
+		weights[i] = quantize(w)
+	}
+
+	p.SetWeights(weights)
+	p.bias = quantize(m.Bias)
+	p.threshold = quantize(m.Threshold)
+	p.theta = quantize(m.Theta)
+	p.featureShift = m.FeatureShift
+	p.hashed = false
+	p.inferenceOnly = true
+	p.invalidatePredictions()
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LinearModel", func() {
+	model := func() LinearModel {
+		m := LinearModel{
+			Format:       LinearModelFormat,
+			FeatureShift: 6,
+			Weights:      make([]float64, 32),
+			Bias:         -0.5,
+			Scale:        10,
+		}
+		m.Weights[0] = 1.04
+
+		return m
+	}
+
+	It("should quantize the model into the perceptron", func() {
+		p := NewPerceptronVictimFinder()
+
+		Expect(p.LoadLinearModel(model())).To(Succeed())
+
+		Expect(p.Weights()[0]).To(Equal(int32(10)))
+		Expect(p.IsInferenceOnly()).To(BeTrue())
+		Expect(p.predictsDead(0x40, 0)).To(BeTrue())
+		Expect(p.predictsDead(0x80, 0)).To(BeFalse())
+	})
+
+	It("should not train in inference-only mode", func() {
+		p := NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		Expect(p.LoadLinearModel(model())).To(Succeed())
+		weights := p.Weights()
+
+		for i := 0; i < 10; i++ {
+			p.TrainOnHit(0x40)
+		}
+
+		Expect(p.Weights()).To(Equal(weights))
+	})
+
+	It("should read a JSON model", func() {
+		m, err := ReadLinearModel(strings.NewReader(`{
+			"format": "linear-v1",
+			"weights": [` + strings.Repeat("0.5, ", 31) + `0.5],
+			"bias": 1
+		}`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Weights).To(HaveLen(32))
+	})
+
+	It("should reject models with the wrong shape", func() {
+		_, err := ReadLinearModel(strings.NewReader(
+			`{"format": "linear-v1", "weights": [1, 2]}`))
+
+		Expect(err).To(MatchError(ContainSubstring("2 weights")))
+	})
+})
@@ -0,0 +1,80 @@
+package cache
+
+import "github.com/sarchlab/akita/v4/mem/vm"
+
+// A LineEvent describes a line that enters or leaves a directory.
+type LineEvent struct {
+	PID     vm.PID
+	Tag     uint64
+	SetID   int
+	WayID   int
+	IsDirty bool
+}
+
+func lineEvent(block *Block) LineEvent {
+	return LineEvent{
+		PID:     block.PID,
+		Tag:     block.Tag,
+		SetID:   block.SetID,
+		WayID:   block.WayID,
+		IsDirty: block.IsDirty,
+	}
+}
+
+// LineCallbacks are called when lines enter or leave a directory, so that a
+// multi-level model can keep its levels inclusive or exclusive. Nil callbacks
+// are not called.
+type LineCallbacks struct {
+	// OnFill is called when a visit fills a block returned by FindVictim.
+	OnFill func(LineEvent)
+
+	// OnEvict is called when a fill replaces a valid line, before the
+	// OnFill of the new line. The event describes the evicted line as it
+	// was when the victim was selected.
+	OnEvict func(LineEvent)
+
+	// OnInvalidate is called by Invalidate, before the line is dropped.
+	OnInvalidate func(LineEvent)
+}
+
+// SetLineCallbacks replaces the line callbacks of the directory.
+func (d *DirectoryImpl) SetLineCallbacks(c LineCallbacks) {
+	d.lineCallbacks = c
+}
+
+// LineCallbacks returns the line callbacks of the directory.
+func (d *DirectoryImpl) LineCallbacks() LineCallbacks {
+	return d.lineCallbacks
+}
+
+// Invalidate drops the line at addr from the directory, for example to
+// back-invalidate it when a higher level evicts it. It returns false if the
+// line is not resident. The controller is responsible for writing back the
+// data of a dirty line; OnInvalidate reports whether the line was dirty.
+func (d *DirectoryImpl) Invalidate(pid vm.PID, addr uint64) bool {
+	block := d.findBlock(pid, addr)
+	if block == nil {
+		return false
+	}
+
+	if d.lineCallbacks.OnInvalidate != nil {
+		d.lineCallbacks.OnInvalidate(lineEvent(block))
+	}
+
+	block.IsValid = false
+	block.IsDirty = false
+	block.ValidSectors = 0
+	block.DirtySectors = 0
+
+	return true
+}
+
+func (d *DirectoryImpl) notifyFill(block *Block, fill pendingFillContext) {
+	if fill.evicting && d.lineCallbacks.OnEvict != nil {
+		d.lineCallbacks.OnEvict(fill.evicted)
+	}
+
+	if d.lineCallbacks.OnFill != nil {
+		d.lineCallbacks.OnFill(lineEvent(block))
+	}
+}
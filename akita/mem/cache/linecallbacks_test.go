@@ -0,0 +1,77 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Line callbacks", func() {
+	var (
+		d      *DirectoryImpl
+		events []string
+	)
+
+	fill := func(addr uint64) *Block {
+		block := d.FindVictim(addr)
+		block.Tag = addr
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	record := func(kind string) func(LineEvent) {
+		return func(e LineEvent) {
+			events = append(events, kind)
+			Expect(e.SetID).To(Equal(0))
+		}
+	}
+
+	BeforeEach(func() {
+		events = nil
+		d = NewDirectory(1, 2, 64, NewLRUVictimFinder())
+		d.SetLineCallbacks(LineCallbacks{
+			OnFill:       record("fill"),
+			OnEvict:      record("evict"),
+			OnInvalidate: record("invalidate"),
+		})
+	})
+
+	It("should report the evicted line before the fill", func() {
+		fill(0).IsDirty = true
+		fill(64)
+		fill(128)
+
+		Expect(events).To(Equal([]string{"fill", "fill", "evict", "fill"}))
+
+		var evicted LineEvent
+		d.SetLineCallbacks(LineCallbacks{
+			OnEvict: func(e LineEvent) { evicted = e },
+		})
+		d.Sets[0].Blocks[1].IsDirty = true
+		fill(192)
+
+		Expect(evicted.Tag).To(Equal(uint64(64)))
+		Expect(evicted.IsDirty).To(BeTrue())
+	})
+
+	It("should not report hits", func() {
+		block := fill(0)
+		d.Visit(block)
+
+		Expect(events).To(Equal([]string{"fill"}))
+	})
+
+	It("should invalidate resident lines", func() {
+		block := fill(0)
+		block.IsDirty = true
+
+		Expect(d.Invalidate(0, 0)).To(BeTrue())
+		Expect(block.IsValid).To(BeFalse())
+		Expect(block.IsDirty).To(BeFalse())
+		Expect(d.Lookup(0, 0)).To(BeNil())
+
+		Expect(d.Invalidate(0, 0)).To(BeFalse())
+		Expect(events).To(Equal([]string{"fill", "invalidate"}))
+	})
+})
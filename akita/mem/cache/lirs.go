@@ -0,0 +1,339 @@
+package cache
+
+// A LIRSConfig configures a LIRSVictimFinder.
+type LIRSConfig struct {
+	// The number of ways of a set that hold resident HIR lines. Defaults to
+	// one sixteenth of the ways, and to at least one.
+	HIRWays int
+
+	// The number of non-resident HIR lines that a stack remembers. Defaults
+	// to the number of ways.
+	NonResidentLines int
+}
+
+// lirsEntry is a line that a LIRS set knows about.
+type lirsEntry struct {
+	tag      uint64
+	way      int
+	lir      bool
+	resident bool
+	inStack  bool
+}
+
+// lirsSet is the LIRS state of one set.
+type lirsSet struct {
+	// stack is the LIRS stack S from the bottom to the top. Its bottom is
+	// always a LIR line.
+	stack []*lirsEntry
+
+	// queue is the list Q of the resident HIR lines, the next victim first.
+	queue []*lirsEntry
+
+	// resident[way] is the line in the way, or nil.
+	resident []*lirsEntry
+
+	lirLines int
+}
+
+// LIRSVictimFinder implements Low Inter-reference Recency Set replacement
+// (Jiang and Zhang, SIGMETRICS 2002) in every set. Lines with a low
+// inter-reference recency (LIR) hold most of the ways, and the few other
+// ways hold high inter-reference recency (HIR) lines, which are the victims.
+// The stack S orders the recently referenced lines, including some HIR
+// lines that are no longer resident; a HIR line that is referenced again
+// while still in S has a shorter reuse distance than the oldest LIR line, so
+// it becomes LIR and the LIR line at the bottom of S becomes HIR. Lines
+// referenced once do not disturb the LIR lines, which makes the policy
+// resistant to scans and loops larger than the cache.
+//
+// The stack and the queue are stored per set by the finder, indexed by the
+// set ID. Invalid blocks are filled first. If every resident HIR line is
+// locked, the oldest unlocked LIR line is evicted.
+type LIRSVictimFinder struct {
+	config LIRSConfig
+	sets   []*lirsSet
+}
+
+// NewLIRSVictimFinder returns a LIRS victim finder with the default
+// configuration.
+func NewLIRSVictimFinder() *LIRSVictimFinder {
+	return NewLIRSVictimFinderWithConfig(LIRSConfig{})
+}
+
+// NewLIRSVictimFinderWithConfig returns a LIRS victim finder with the
+// configuration.
+func NewLIRSVictimFinderWithConfig(config LIRSConfig) *LIRSVictimFinder {
+	return &LIRSVictimFinder{config: config}
+}
+
+// Config returns the configuration.
+func (l *LIRSVictimFinder) Config() LIRSConfig {
+	return l.config
+}
+
+// IsLIR tells if the block holds a LIR line.
+func (l *LIRSVictimFinder) IsLIR(set *Set, block *Block) bool {
+	e := l.set(set).resident[block.WayID]
+
+	return e != nil && e.lir
+}
+
+// FindVictim returns the first invalid block, or the resident HIR line at
+// the front of the queue.
+func (l *LIRSVictimFinder) FindVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil || len(set.Blocks) == 0 {
+		return b
+	}
+
+	for _, way := range l.order(set) {
+		block := set.Blocks[way]
+		if !block.IsLocked && !isPinned(block) {
+			return block
+		}
+	}
+
+	return nil
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (l *LIRSVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return l.FindVictim(set)
+}
+
+// FindVictims returns up to n candidates, the resident HIR lines in queue
+// order before the LIR lines from the bottom of the stack.
+func (l *LIRSVictimFinder) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	if len(set.Blocks) == 0 {
+		return nil
+	}
+
+	return rankCandidates(set, l.order(set), n)
+}
+
+// Insert records a fill. The line that the block held is no longer
+// resident. The new line becomes LIR if the LIR lines do not fill their
+// ways yet or if it is a HIR line still in the stack, and a resident HIR
+// line otherwise.
+func (l *LIRSVictimFinder) Insert(set *Set, block *Block) {
+	s := l.set(set)
+
+	if old := s.resident[block.WayID]; old != nil {
+		s.resident[block.WayID] = nil
+		old.resident = false
+		s.queue = lirsRemove(s.queue, old)
+
+		if old.lir {
+			old.lir = false
+			s.lirLines--
+		}
+	}
+
+	e := s.stackEntry(block.Tag)
+	if e == nil {
+		e = &lirsEntry{tag: block.Tag}
+	}
+
+	e.way = block.WayID
+	e.resident = true
+	s.resident[block.WayID] = e
+
+	if e.inStack || s.lirLines < l.lirWays(set) {
+		s.makeLIR(e, l.lirWays(set))
+	} else {
+		s.push(e)
+		s.queue = append(s.queue, e)
+	}
+
+	s.prune()
+	s.limitNonResident(l.nonResidentLines(set))
+}
+
+// Touch records a hit. A LIR line moves to the top of the stack. A resident
+// HIR line becomes LIR if it is still in the stack, and moves to the top of
+// the stack and to the end of the queue otherwise.
+func (l *LIRSVictimFinder) Touch(set *Set, block *Block) {
+	s := l.set(set)
+
+	e := s.resident[block.WayID]
+	if e == nil || e.tag != block.Tag {
+		l.Insert(set, block)
+
+		return
+	}
+
+	switch {
+	case e.lir:
+		s.push(e)
+	case e.inStack:
+		s.queue = lirsRemove(s.queue, e)
+		s.makeLIR(e, l.lirWays(set))
+	default:
+		s.push(e)
+		s.queue = append(lirsRemove(s.queue, e), e)
+	}
+
+	s.prune()
+}
+
+func (l *LIRSVictimFinder) set(set *Set) *lirsSet {
+	setID := set.Blocks[0].SetID
+	for len(l.sets) <= setID {
+		l.sets = append(l.sets, nil)
+	}
+
+	if l.sets[setID] == nil {
+		l.sets[setID] = &lirsSet{resident: make([]*lirsEntry, len(set.Blocks))}
+	}
+
+	return l.sets[setID]
+}
+
+func (l *LIRSVictimFinder) lirWays(set *Set) int {
+	hir := l.config.HIRWays
+	if hir <= 0 {
+		hir = len(set.Blocks) / 16
+	}
+
+	if hir < 1 {
+		hir = 1
+	}
+
+	if hir > len(set.Blocks) {
+		hir = len(set.Blocks)
+	}
+
+	return len(set.Blocks) - hir
+}
+
+func (l *LIRSVictimFinder) nonResidentLines(set *Set) int {
+	if l.config.NonResidentLines > 0 {
+		return l.config.NonResidentLines
+	}
+
+	return len(set.Blocks)
+}
+
+// order returns the ways of the resident HIR lines in queue order, then of
+// the LIR lines from the bottom of the stack, then the ways that are not
+// tracked.
+func (l *LIRSVictimFinder) order(set *Set) []int {
+	s := l.set(set)
+	ways := make([]int, 0, len(set.Blocks))
+	tracked := make([]bool, len(set.Blocks))
+
+	for _, e := range s.queue {
+		ways = append(ways, e.way)
+		tracked[e.way] = true
+	}
+
+	for _, e := range s.stack {
+		if e.lir && e.resident && !tracked[e.way] {
+			ways = append(ways, e.way)
+			tracked[e.way] = true
+		}
+	}
+
+	for way := range set.Blocks {
+		if !tracked[way] {
+			ways = append(ways, way)
+		}
+	}
+
+	return ways
+}
+
+// makeLIR turns the line into a LIR line at the top of the stack, and
+// demotes the LIR line at the bottom of the stack if there are too many.
+func (s *lirsSet) makeLIR(e *lirsEntry, lirWays int) {
+	if !e.lir {
+		e.lir = true
+		s.lirLines++
+	}
+
+	s.push(e)
+
+	if s.lirLines <= lirWays {
+		return
+	}
+
+	s.prune()
+
+	bottom := s.stack[0]
+	bottom.lir = false
+	s.lirLines--
+	s.stack = s.stack[1:]
+	bottom.inStack = false
+
+	if bottom.resident {
+		s.queue = append(s.queue, bottom)
+	}
+}
+
+// push moves the line to the top of the stack.
+func (s *lirsSet) push(e *lirsEntry) {
+	if e.inStack {
+		s.stack = lirsRemove(s.stack, e)
+	}
+
+	e.inStack = true
+	s.stack = append(s.stack, e)
+}
+
+// prune removes the HIR lines from the bottom of the stack, so that its
+// bottom is a LIR line.
+func (s *lirsSet) prune() {
+	for len(s.stack) > 0 && !s.stack[0].lir {
+		s.stack[0].inStack = false
+		s.stack = s.stack[1:]
+	}
+}
+
+// limitNonResident forgets the oldest non-resident lines of the stack beyond
+// the limit.
+func (s *lirsSet) limitNonResident(limit int) {
+	nonResident := 0
+	for _, e := range s.stack {
+		if !e.resident {
+			nonResident++
+		}
+	}
+
+	for i := 0; nonResident > limit && i < len(s.stack); {
+		e := s.stack[i]
+		if e.resident {
+			i++
+			continue
+		}
+
+		e.inStack = false
+		s.stack = append(s.stack[:i], s.stack[i+1:]...)
+		nonResident--
+	}
+}
+
+func (s *lirsSet) stackEntry(tag uint64) *lirsEntry {
+	for _, e := range s.stack {
+		if e.tag == tag {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func lirsRemove(list []*lirsEntry, e *lirsEntry) []*lirsEntry {
+	for i, x := range list {
+		if x == e {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LIRSVictimFinder", func() {
+	var (
+		vf *LIRSVictimFinder
+		d  *DirectoryImpl
+	)
+
+	access := func(tag uint64) *Block {
+		if block := d.Lookup(0, tag); block != nil {
+			d.Visit(block)
+			return block
+		}
+
+		block := d.FindVictim(tag)
+		block.Tag = tag
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	isLIR := func(tag uint64) bool {
+		block := d.Lookup(0, tag)
+		Expect(block).NotTo(BeNil())
+
+		return vf.IsLIR(&d.Sets[0], block)
+	}
+
+	BeforeEach(func() {
+		vf = NewLIRSVictimFinder()
+		d = NewDirectory(1, 4, 64, vf)
+	})
+
+	It("should fill the LIR ways first", func() {
+		for i := uint64(0); i < 4; i++ {
+			access(i * 64)
+		}
+
+		Expect(isLIR(0x00)).To(BeTrue())
+		Expect(isLIR(0x40)).To(BeTrue())
+		Expect(isLIR(0x80)).To(BeTrue())
+		Expect(isLIR(0xc0)).To(BeFalse())
+	})
+
+	It("should promote a HIR line that is reused while in the stack", func() {
+		for i := uint64(0); i < 4; i++ {
+			access(i * 64)
+		}
+
+		wayOfD := d.Lookup(0, 0xc0).WayID
+		e := access(0x100)
+		Expect(e.WayID).To(Equal(wayOfD))
+
+		Expect(access(0xc0).WayID).To(Equal(e.WayID))
+		Expect(isLIR(0xc0)).To(BeTrue())
+		Expect(isLIR(0x00)).To(BeFalse())
+
+		wayOfA := d.Lookup(0, 0x00).WayID
+		Expect(access(0x140).WayID).To(Equal(wayOfA))
+	})
+
+	It("should keep the LIR lines through a scan", func() {
+		for i := uint64(0); i < 3; i++ {
+			access(i * 64)
+			access(i * 64)
+		}
+
+		for i := uint64(3); i < 40; i++ {
+			access(i * 64)
+		}
+
+		for i := uint64(0); i < 3; i++ {
+			Expect(d.Lookup(0, i*64)).NotTo(BeNil())
+		}
+	})
+
+	It("should honor the HIR ways of the configuration", func() {
+		vf = NewLIRSVictimFinderWithConfig(LIRSConfig{HIRWays: 2})
+		d = NewDirectory(1, 4, 64, vf)
+
+		for i := uint64(0); i < 4; i++ {
+			access(i * 64)
+		}
+
+		Expect(isLIR(0x40)).To(BeTrue())
+		Expect(isLIR(0x80)).To(BeFalse())
+	})
+})
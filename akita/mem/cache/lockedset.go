@@ -0,0 +1,56 @@
+package cache
+
+import "fmt"
+
+// A SetLockedError reports that every block an address can be placed in is
+// locked by an in-flight transaction. The request has to be retried after one
+// of the transactions completes.
+type SetLockedError struct {
+	Address uint64
+	SetID   int
+}
+
+func (e *SetLockedError) Error() string {
+	return fmt.Sprintf("all blocks of set %d are locked (address 0x%x)",
+		e.SetID, e.Address)
+}
+
+// A VictimRestrictedError reports that the set of an address has unlocked
+// blocks, but the way partition, the QoS policy or the pinned blocks leave
+// none of them to evict. Retrying does not help until the restrictions or the
+// blocks change.
+type VictimRestrictedError struct {
+	Address uint64
+	SetID   int
+}
+
+func (e *VictimRestrictedError) Error() string {
+	return fmt.Sprintf("no block of set %d may be evicted (address 0x%x)",
+		e.SetID, e.Address)
+}
+
+// TryFindVictim returns the victim for the address. If every block of the set
+// is locked, it returns a *SetLockedError instead, and if the unlocked blocks
+// may not be evicted, a *VictimRestrictedError. The context may be nil.
+func (d *DirectoryImpl) TryFindVictim(
+	addr uint64,
+	context *VictimContext,
+) (*Block, error) {
+	var block *Block
+	if context != nil {
+		block = d.FindVictimWithContext(addr, context)
+	} else {
+		block = d.FindVictim(addr)
+	}
+
+	if block == nil {
+		set, setID := d.getSet(addr)
+		if hasBlock(set, func(*Block) bool { return true }) {
+			return nil, &VictimRestrictedError{Address: addr, SetID: setID}
+		}
+
+		return nil, &SetLockedError{Address: addr, SetID: setID}
+	}
+
+	return block, nil
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"errors"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locked sets", func() {
+	for _, name := range RegisteredVictimFinders() {
+		name := name
+		newFinder := func() VictimFinder {
+			vf, err := NewVictimFinderByName(name, PolicyConfig{})
+			Expect(err).NotTo(HaveOccurred())
+
+			return vf
+		}
+
+		It("should report a fully locked set with "+name, func() {
+			d := NewDirectory(2, 4, 64, newFinder())
+			for _, b := range d.Sets[0].Blocks {
+				b.IsValid = true
+				b.IsLocked = true
+			}
+
+			block, err := d.TryFindVictim(0x0, &VictimContext{Address: 0x0})
+
+			Expect(block).To(BeNil())
+			var lockedErr *SetLockedError
+			Expect(errors.As(err, &lockedErr)).To(BeTrue())
+			Expect(lockedErr.SetID).To(Equal(0))
+			Expect(d.FindVictim(0x0)).To(BeNil())
+		})
+
+		It("should never select a locked block with "+name, func() {
+			r := rand.New(rand.NewSource(1))
+			d := NewDirectory(4, 8, 64, newFinder())
+
+			for i := 0; i < 5000; i++ {
+				addr := uint64(r.Intn(256)) * 64
+				set, _ := d.getSet(addr)
+				for _, b := range set.Blocks {
+					b.IsLocked = r.Intn(8) != 0
+				}
+
+				block, err := d.TryFindVictim(addr,
+					&VictimContext{Address: addr, AccessType: "read"})
+				if err != nil {
+					for _, b := range set.Blocks {
+						Expect(b.IsLocked).To(BeTrue())
+					}
+
+					continue
+				}
+
+				Expect(block.IsLocked).To(BeFalse())
+				block.Tag = addr
+				block.IsValid = true
+				d.Visit(block)
+			}
+		})
+	}
+
+	It("should report a set whose unlocked blocks may not be evicted", func() {
+		d := NewDirectory(1, 4, 64, NewLRUVictimFinder())
+		for i, b := range d.Sets[0].Blocks {
+			b.IsValid = true
+			b.Tag = uint64(i) * 64
+		}
+		for i := uint64(0); i < 3; i++ {
+			Expect(d.Pin(0, i*64)).To(BeTrue())
+		}
+		d.Sets[0].Blocks[3].IsLocked = true
+
+		block, err := d.TryFindVictim(0x1000, nil)
+
+		Expect(block).To(BeNil())
+		var restrictedErr *VictimRestrictedError
+		Expect(errors.As(err, &restrictedErr)).To(BeTrue())
+		Expect(restrictedErr.SetID).To(BeZero())
+		var lockedErr *SetLockedError
+		Expect(errors.As(err, &lockedErr)).To(BeFalse())
+	})
+})
@@ -0,0 +1,336 @@
+package cache
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Fixed-point formats of the logistic regression predictor. The weights and
+// the logit have logisticWeightBits fractional bits; the probabilities have
+// logisticProbBits.
+const (
+	logisticWeightBits = 8
+	logisticProbBits   = 16
+	logisticProbOne    = 1 << logisticProbBits
+	logisticWeightMax  = 8<<logisticWeightBits - 1
+	logisticWeightMin  = -8 << logisticWeightBits
+
+	// The sigmoid table covers logits in [-8, 8) in steps of 1/16.
+	logisticSigmoidSteps = 256
+	logisticSigmoidShift = logisticWeightBits - 4
+)
+
+// LogisticTableSize is the number of weights in every table of a
+// LogisticVictimFinder.
+const LogisticTableSize = PerceptronTableSize
+
+// logisticSigmoid holds the sigmoid of the table logits, as a fixed-point
+// probability. Hardware would hold it in a small ROM.
+var logisticSigmoid = func() [logisticSigmoidSteps]int32 {
+	var table [logisticSigmoidSteps]int32
+
+	for i := range table {
+		z := float64(i-logisticSigmoidSteps/2) / 16
+		table[i] = int32(math.Round(logisticProbOne / (1 + math.Exp(-z))))
+	}
+
+	return table
+}()
+
+// A LogisticVictimFinder predicts the reuse of lines with online logistic
+// regression in fixed point. It takes the same features as the hashed
+// perceptron, is trained by the same outcomes, and selects victims the same
+// way, so the two learning algorithms can be compared on identical inputs.
+//
+// Every feature indexes its own weight table. The prediction is the
+// probability that the line is dead, the sigmoid of the sum of the weights.
+// Training moves every active weight by the learning rate times the
+// prediction error.
+type LogisticVictimFinder struct {
+	extractor    FeatureExtractor
+	featureShift uint
+	tables       [][LogisticTableSize]int32
+	bias         int32
+
+	// The step of the updates is the error divided by 2^learningShift.
+	learningShift uint
+
+	// Lines whose probability of being dead is at least threshold are
+	// predicted dead, and victims are selected by their probability once
+	// the probability is at least confidence.
+	threshold  int32
+	confidence int32
+
+	trainingSampleInterval uint64
+	trainingSampleCounter  uint64
+
+	stats predictionStatsState
+
+	indexBuffer []uint32
+}
+
+// NewLogisticVictimFinder creates a logistic regression predictor with the
+// address features of the perceptron. Lines are predicted dead at a
+// probability of 0.5, and selected by their probability at 0.75.
+func NewLogisticVictimFinder() *LogisticVictimFinder {
+	return &LogisticVictimFinder{
+		extractor:              AddressFeatureExtractor{},
+		learningShift:          2,
+		threshold:              logisticProbOne / 2,
+		confidence:             logisticProbOne * 3 / 4,
+		trainingSampleInterval: 1,
+	}
+}
+
+// NewLogisticVictimFinderWithExtractor creates a logistic regression
+// predictor that takes the features of the extractor.
+func NewLogisticVictimFinderWithExtractor(
+	extractor FeatureExtractor,
+) *LogisticVictimFinder {
+	l := NewLogisticVictimFinder()
+	l.extractor = extractor
+
+	return l
+}
+
+// SetFeatureShift sets the number of low address bits that are dropped when
+// the features are hashed with the address, like
+// PerceptronVictimFinder.SetFeatureShift.
+func (l *LogisticVictimFinder) SetFeatureShift(shift uint) {
+	l.featureShift = shift
+}
+
+// SetLearningRate sets the learning rate to 2^-shift.
+func (l *LogisticVictimFinder) SetLearningRate(shift uint) {
+	if shift > logisticProbBits {
+		panic(fmt.Sprintf("learning rate shift %d is too large", shift))
+	}
+
+	l.learningShift = shift
+}
+
+// SetThresholds sets the probabilities at which a line is predicted dead and
+// at which the victims are selected by their probability. It panics unless
+// 0 < threshold <= confidence <= 1.
+func (l *LogisticVictimFinder) SetThresholds(threshold, confidence float64) {
+	if threshold <= 0 || threshold > confidence || confidence > 1 {
+		panic(fmt.Sprintf("invalid thresholds %v and %v",
+			threshold, confidence))
+	}
+
+	l.threshold = int32(threshold * logisticProbOne)
+	l.confidence = int32(confidence * logisticProbOne)
+}
+
+// SetTrainingSampleInterval makes the predictor train on one out of every n
+// outcomes, like PerceptronVictimFinder.SetTrainingSampleInterval. The
+// default of 1 trains on every outcome.
+func (l *LogisticVictimFinder) SetTrainingSampleInterval(n uint64) {
+	if n == 0 {
+		panic("training sample interval must be positive")
+	}
+
+	l.trainingSampleInterval = n
+}
+
+// PredictionStats returns the prediction statistics.
+func (l *LogisticVictimFinder) PredictionStats() PredictionStats {
+	return l.stats.current
+}
+
+// Probability returns the predicted probability that the line of the access
+// will not be reused.
+func (l *LogisticVictimFinder) Probability(ctx *VictimContext) float64 {
+	return float64(l.probability(ctx)) / logisticProbOne
+}
+
+// PredictDead predicts that a line is dead if its probability of not being
+// reused reaches the threshold. The confidence is the distance of the
+// probability from one half, in units of 2^-16.
+func (l *LogisticVictimFinder) PredictDead(
+	addr uint64,
+	ctx *VictimContext,
+) (dead bool, confidence int32) {
+	prob := l.probability(contextFor(addr, ctx))
+
+	return prob >= l.threshold, abs(prob - logisticProbOne/2)
+}
+
+// FindVictim selects the first invalid or unlocked block in way order, like
+// the perceptron without a context.
+func (l *LogisticVictimFinder) FindVictim(set *Set) *Block {
+	return wayOrderVictims.FindVictim(set)
+}
+
+// FindVictimWithContext evicts the resident block most likely to be dead if
+// the access is confidently predicted dead, and falls back to PseudoLRU
+// otherwise. Invalid blocks come first.
+func (l *LogisticVictimFinder) FindVictimWithContext(
+	set *Set,
+	context *VictimContext,
+) *Block {
+	confident := l.probability(context) >= l.confidence
+	l.stats.recordPrediction(confident)
+
+	if !confident {
+		return pseudoLRUVictims.FindVictim(set)
+	}
+
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	ways := l.residentOrder(set)
+	if len(ways) == 0 {
+		return nil
+	}
+
+	return set.Blocks[ways[0]]
+}
+
+// FindVictims returns up to n candidates in the order of
+// FindVictimWithContext, without updating the statistics.
+func (l *LogisticVictimFinder) FindVictims(
+	set *Set,
+	context *VictimContext,
+	n int,
+) []*Block {
+	if context == nil {
+		return FindVictims(wayOrderVictims, set, nil, n)
+	}
+
+	if l.probability(context) >= l.confidence {
+		return rankCandidates(set, l.residentOrder(set), n)
+	}
+
+	return rankCandidates(set, pseudoLRUOrder(set), n)
+}
+
+// TrainOnHit trains the predictor on a hit of the line at the address.
+func (l *LogisticVictimFinder) TrainOnHit(addr uint64) {
+	l.TrainOnHitWithContext(&VictimContext{Address: addr})
+}
+
+// TrainOnEviction trains the predictor on the eviction of the line at the
+// address.
+func (l *LogisticVictimFinder) TrainOnEviction(addr uint64) {
+	l.TrainOnEvictionWithContext(&VictimContext{Address: addr})
+}
+
+// TrainOnHitWithContext trains the predictor on a hit by the access.
+func (l *LogisticVictimFinder) TrainOnHitWithContext(ctx *VictimContext) {
+	l.train(ctx, false)
+}
+
+// TrainOnEvictionWithContext trains the predictor on the eviction of the line
+// at the address of the context.
+func (l *LogisticVictimFinder) TrainOnEvictionWithContext(
+	ctx *VictimContext,
+) {
+	l.train(ctx, true)
+}
+
+func (l *LogisticVictimFinder) train(ctx *VictimContext, dead bool) {
+	l.trainingSampleCounter++
+	if l.trainingSampleCounter%l.trainingSampleInterval != 0 {
+		return
+	}
+
+	prob := l.probability(ctx)
+	l.stats.recordOutcome(prob >= l.threshold, !dead)
+
+	target := int32(0)
+	if dead {
+		target = logisticProbOne
+	}
+
+	// The error is in units of 2^-16; the weights have 8 fractional bits.
+	step := (target - prob) >> (logisticProbBits - logisticWeightBits) >>
+		l.learningShift
+
+	for i, idx := range l.tableIndices(ctx) {
+		w := &l.tables[i][idx]
+		*w = max(min(*w+step, logisticWeightMax), logisticWeightMin)
+	}
+
+	l.bias = max(min(l.bias+step, logisticWeightMax), logisticWeightMin)
+}
+
+// probability returns the fixed-point probability that the line of the
+// access is dead.
+func (l *LogisticVictimFinder) probability(ctx *VictimContext) int32 {
+	logit := l.bias
+	for i, idx := range l.tableIndices(ctx) {
+		logit += l.tables[i][idx]
+	}
+
+	step := logit>>logisticSigmoidShift + logisticSigmoidSteps/2
+	step = max(min(step, logisticSigmoidSteps-1), 0)
+
+	return logisticSigmoid[step]
+}
+
+// tableIndices returns the entry of every weight table for the access,
+// adding tables if there are more features than tables. The returned slice
+// is reused by the next call.
+func (l *LogisticVictimFinder) tableIndices(ctx *VictimContext) []uint32 {
+	features := l.extractor.Extract(ctx)
+
+	for len(l.tables) < len(features) {
+		l.tables = append(l.tables, [LogisticTableSize]int32{})
+	}
+
+	addrBits := uint32(ctx.Address>>l.featureShift) & 0xFF
+
+	l.indexBuffer = l.indexBuffer[:0]
+	for _, feature := range features {
+		idx := (hash32(uint64(feature)) ^ addrBits) % LogisticTableSize
+		l.indexBuffer = append(l.indexBuffer, idx)
+	}
+
+	return l.indexBuffer
+}
+
+// contextFor returns the context of the access, or a context with only the
+// address.
+func contextFor(
+	addr uint64,
+	ctx *VictimContext,
+) *VictimContext {
+	if ctx != nil && ctx.Address == addr {
+		return ctx
+	}
+
+	c := VictimContext{Address: addr}
+	if ctx != nil {
+		c = *ctx
+		c.Address = addr
+	}
+
+	return &c
+}
+
+// residentOrder returns the ways of the valid, unlocked blocks from the most
+// to the least likely to be dead, in PseudoLRU order on ties. The blocks are
+// predicted with their fill context.
+func (l *LogisticVictimFinder) residentOrder(set *Set) []int {
+	probs := make([]int32, len(set.Blocks))
+	ways := make([]int, 0, len(set.Blocks))
+
+	for _, way := range pseudoLRUOrder(set) {
+		block := set.Blocks[way]
+		if !block.IsValid || block.IsLocked {
+			continue
+		}
+
+		probs[way] = l.probability(residentContext(block))
+		ways = append(ways, way)
+	}
+
+	sort.SliceStable(ways, func(i, j int) bool {
+		return probs[ways[i]] > probs[ways[j]]
+	})
+
+	return ways
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LogisticVictimFinder", func() {
+	var (
+		l          *LogisticVictimFinder
+		dead, live *VictimContext
+	)
+
+	BeforeEach(func() {
+		l = NewLogisticVictimFinder()
+		dead = &VictimContext{Address: 0x10040}
+		live = &VictimContext{Address: 0x23080}
+	})
+
+	It("should start undecided", func() {
+		Expect(l.Probability(dead)).To(BeNumerically("~", 0.5, 0.01))
+
+		predicted, confidence := l.PredictDead(dead.Address, nil)
+		Expect(predicted).To(BeTrue())
+		Expect(confidence).To(BeZero())
+	})
+
+	It("should learn the probability of reuse", func() {
+		for i := 0; i < 64; i++ {
+			l.TrainOnEvictionWithContext(dead)
+			l.TrainOnHitWithContext(live)
+		}
+
+		Expect(l.Probability(dead)).To(BeNumerically(">", 0.9))
+		Expect(l.Probability(live)).To(BeNumerically("<", 0.1))
+
+		predicted, _ := l.PredictDead(live.Address, nil)
+		Expect(predicted).To(BeFalse())
+
+		stats := l.PredictionStats()
+		Expect(stats.Outcomes).To(Equal(uint64(128)))
+		Expect(stats.Accuracy()).To(BeNumerically(">", 0.9))
+	})
+
+	It("should evict the block most likely to be dead", func() {
+		for i := 0; i < 64; i++ {
+			l.TrainOnEvictionWithContext(dead)
+			l.TrainOnHitWithContext(live)
+		}
+
+		set := makeTestSet(4)
+		for _, b := range set.Blocks {
+			b.IsValid = true
+			b.Tag = live.Address
+		}
+		set.Blocks[2].Tag = dead.Address
+
+		Expect(l.FindVictimWithContext(set, dead)).
+			To(BeIdenticalTo(set.Blocks[2]))
+		Expect(l.FindVictimWithContext(set, live)).
+			To(BeIdenticalTo(set.Blocks[0]))
+		Expect(l.FindVictims(set, dead, 2)[0]).To(BeIdenticalTo(set.Blocks[2]))
+		Expect(l.PredictionStats().ConfidentPredictions).To(Equal(uint64(1)))
+	})
+
+	It("should train on a sample of the outcomes", func() {
+		l.SetTrainingSampleInterval(4)
+		for i := 0; i < 8; i++ {
+			l.TrainOnEviction(dead.Address)
+		}
+
+		Expect(l.PredictionStats().Outcomes).To(Equal(uint64(2)))
+	})
+})
@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// A MissClass is the cause of a miss.
+type MissClass int
+
+// Miss classes.
+const (
+	// A compulsory miss is the first access to a line.
+	MissCompulsory MissClass = iota
+
+	// A capacity miss would also miss in a fully associative LRU cache of
+	// the same capacity.
+	MissCapacity
+
+	// A conflict miss would hit in a fully associative LRU cache of the same
+	// capacity, so it is caused by the mapping to sets or by the replacement
+	// policy.
+	MissConflict
+)
+
+func (c MissClass) String() string {
+	switch c {
+	case MissCompulsory:
+		return "compulsory"
+	case MissCapacity:
+		return "capacity"
+	case MissConflict:
+		return "conflict"
+	default:
+		return fmt.Sprintf("MissClass(%d)", int(c))
+	}
+}
+
+// MissClassCounts counts the misses of every class.
+type MissClassCounts struct {
+	Compulsory uint64
+	Capacity   uint64
+	Conflict   uint64
+}
+
+// Total returns the number of misses.
+func (c MissClassCounts) Total() uint64 {
+	return c.Compulsory + c.Capacity + c.Conflict
+}
+
+// A MissClassifier classifies every miss of a directory as compulsory,
+// capacity, or conflict. It remembers every line ever accessed, like an
+// infinite cache, to find the compulsory misses, and keeps a tag-only fully
+// associative LRU cache of the capacity of the directory to separate the
+// capacity misses from the conflict misses.
+//
+// The memory used grows with the number of distinct lines accessed.
+type MissClassifier struct {
+	capacity int
+	touched  map[tagOnlyLine]struct{}
+	lru      *list.List
+	inShadow map[tagOnlyLine]*list.Element
+	counts   MissClassCounts
+}
+
+// NewMissClassifier creates a classifier for a cache of capacityLines lines.
+func NewMissClassifier(capacityLines int) *MissClassifier {
+	if capacityLines <= 0 {
+		panic(fmt.Sprintf("capacity %d must be positive", capacityLines))
+	}
+
+	return &MissClassifier{
+		capacity: capacityLines,
+		touched:  make(map[tagOnlyLine]struct{}),
+		lru:      list.New(),
+		inShadow: make(map[tagOnlyLine]*list.Element),
+	}
+}
+
+// SetMissClassifier attaches a classifier of the misses of the directory. A
+// nil classifier creates one of the capacity of the directory.
+func (d *DirectoryImpl) SetMissClassifier(c *MissClassifier) {
+	if c == nil {
+		c = NewMissClassifier(d.NumSets * d.NumWays)
+	}
+
+	d.missClassifier = c
+}
+
+// MissClassifier returns the attached classifier, if any.
+func (d *DirectoryImpl) MissClassifier() *MissClassifier {
+	return d.missClassifier
+}
+
+// Access records an access to the line, which is a miss if isMiss is true,
+// and returns the class of the miss. The class of a hit is meaningless.
+func (c *MissClassifier) Access(
+	pid vm.PID,
+	tag uint64,
+	isMiss bool,
+) MissClass {
+	line := tagOnlyLine{pid: pid, tag: tag}
+
+	class := MissCapacity
+	if _, ok := c.touched[line]; !ok {
+		class = MissCompulsory
+		c.touched[line] = struct{}{}
+	} else if _, ok := c.inShadow[line]; ok {
+		class = MissConflict
+	}
+
+	c.touch(line)
+
+	if !isMiss {
+		return class
+	}
+
+	switch class {
+	case MissCompulsory:
+		c.counts.Compulsory++
+	case MissCapacity:
+		c.counts.Capacity++
+	default:
+		c.counts.Conflict++
+	}
+
+	return class
+}
+
+// touch makes the line the most recently used line of the fully associative
+// cache.
+func (c *MissClassifier) touch(line tagOnlyLine) {
+	if e, ok := c.inShadow[line]; ok {
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	if c.lru.Len() >= c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.inShadow, oldest.Value.(tagOnlyLine))
+	}
+
+	c.inShadow[line] = c.lru.PushFront(line)
+}
+
+func (c *MissClassifier) recordAccess(block *Block, isFill bool) {
+	if c == nil {
+		return
+	}
+
+	c.Access(block.PID, block.Tag, isFill)
+}
+
+// Counts returns the number of misses of every class.
+func (c *MissClassifier) Counts() MissClassCounts {
+	return c.counts
+}
+
+// Reset forgets every line and clears the counters.
+func (c *MissClassifier) Reset() {
+	*c = *NewMissClassifier(c.capacity)
+}
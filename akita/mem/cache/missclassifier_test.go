@@ -0,0 +1,65 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MissClassifier", func() {
+	var d *DirectoryImpl
+
+	BeforeEach(func() {
+		// Two direct-mapped sets of 64-byte lines.
+		d = NewDirectory(2, 1, 64, NewLRUVictimFinder())
+		d.SetMissClassifier(nil)
+	})
+
+	It("should classify the first access to a line as compulsory", func() {
+		accessTagOnly(d, 1, 0)
+		accessTagOnly(d, 2, 0)
+
+		Expect(d.MissClassifier().Counts()).To(Equal(MissClassCounts{
+			Compulsory: 2,
+		}))
+	})
+
+	It("should classify the misses of a fitting working set as conflict", func() {
+		accessTagOnly(d, 0, 0)
+		accessTagOnly(d, 0, 128)
+		accessTagOnly(d, 0, 0)
+
+		Expect(d.MissClassifier().Counts()).To(Equal(MissClassCounts{
+			Compulsory: 2,
+			Conflict:   1,
+		}))
+	})
+
+	It("should classify the misses of a large working set as capacity", func() {
+		for _, addr := range []uint64{0, 64, 128, 192, 0} {
+			accessTagOnly(d, 0, addr)
+		}
+
+		c := d.MissClassifier().Counts()
+		Expect(c.Compulsory).To(Equal(uint64(4)))
+		Expect(c.Capacity).To(Equal(uint64(1)))
+		Expect(c.Total()).To(Equal(uint64(5)))
+	})
+
+	It("should keep hits in the fully associative cache", func() {
+		accessTagOnly(d, 0, 0)
+		accessTagOnly(d, 0, 64)
+		accessTagOnly(d, 0, 0)
+		accessTagOnly(d, 0, 128)
+		accessTagOnly(d, 0, 0)
+
+		// The hit on 0 made 64 the LRU line, so the line 0 stays resident
+		// and its miss after 128 is a conflict.
+		Expect(d.MissClassifier().Counts().Conflict).To(Equal(uint64(1)))
+	})
+
+	It("should name the classes", func() {
+		Expect(MissCompulsory.String()).To(Equal("compulsory"))
+		Expect(MissCapacity.String()).To(Equal("capacity"))
+		Expect(MissConflict.String()).To(Equal("conflict"))
+	})
+})
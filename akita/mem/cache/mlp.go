@@ -0,0 +1,387 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Quantization of the multilayer predictor. The weights are 6-bit like the
+// perceptron weights, and the hidden activations are 6-bit unsigned.
+const (
+	mlpWeightMax     = 31
+	mlpWeightMin     = -32
+	mlpActivationMax = 63
+
+	// The hidden activations are summed with their output weights and
+	// shifted right by mlpOutputShift, so the output has the range of a
+	// perceptron sum.
+	mlpOutputShift = 3
+
+	// Every hidden unit starts with this bias, so that all units are active
+	// and learn from the first outcomes. The hidden weights start at
+	// pseudo-random values in [-mlpInitialSpread, mlpInitialSpread], so that
+	// the units do not all learn the same function.
+	mlpInitialBias   = 8
+	mlpInitialSpread = 8
+
+	// The output weight of an active unit moves by one plus the activation
+	// of the unit shifted right by mlpOutputStepShift.
+	mlpOutputStepShift = 4
+)
+
+// MLPTableSize is the number of entries in every table of an
+// MLPVictimFinder.
+const MLPTableSize = PerceptronTableSize
+
+// DefaultMLPWidth is the default number of hidden units of an
+// MLPVictimFinder.
+const DefaultMLPWidth = 8
+
+// An MLPVictimFinder predicts the reuse of lines with a two-layer neural
+// network with integer weights. It takes the same features as the hashed
+// perceptron, is trained by the same outcomes, and selects victims the same
+// way, so it shows whether a nonlinear predictor is worth its cost.
+//
+// Every feature indexes its own table, whose entries hold one weight for
+// every hidden unit. A hidden unit sums its weights and bias and clamps the
+// sum to [0, 63]. The output is the weighted sum of the hidden units plus
+// the output bias. Like the perceptron, the network predicts that a line is
+// dead if the output reaches the threshold, and it is only trained on a
+// misprediction or when the output is below theta. Training backpropagates
+// the sign of the error: the output weight of every active unit moves
+// toward the outcome by a step that grows with the activation of the unit,
+// and the weights of an active unit move in the direction that its output
+// weight asks for.
+type MLPVictimFinder struct {
+	extractor    FeatureExtractor
+	featureShift uint
+	width        int
+
+	// hidden[t][i*width+j] is the weight of entry i of table t for unit j.
+	hidden     [][]int32
+	hiddenBias []int32
+	output     []int32
+	outputBias int32
+
+	threshold int32
+	theta     int32
+
+	trainingSampleInterval uint64
+	trainingSampleCounter  uint64
+
+	stats predictionStatsState
+
+	// The state of the generator of the initial hidden weights.
+	seed uint32
+
+	indexBuffer      []uint32
+	activationBuffer []int32
+}
+
+// NewMLPVictimFinder creates a multilayer predictor with the given number
+// of hidden units and the address features of the perceptron. It panics if
+// the width is not positive.
+func NewMLPVictimFinder(width int) *MLPVictimFinder {
+	return NewMLPVictimFinderWithExtractor(width, AddressFeatureExtractor{})
+}
+
+// NewMLPVictimFinderWithExtractor creates a multilayer predictor that takes
+// the features of the extractor.
+func NewMLPVictimFinderWithExtractor(
+	width int,
+	extractor FeatureExtractor,
+) *MLPVictimFinder {
+	if width <= 0 {
+		panic(fmt.Sprintf("invalid hidden layer width %d", width))
+	}
+
+	m := &MLPVictimFinder{
+		extractor:              extractor,
+		width:                  width,
+		hiddenBias:             make([]int32, width),
+		output:                 make([]int32, width),
+		theta:                  32,
+		trainingSampleInterval: 1,
+		seed:                   1,
+		activationBuffer:       make([]int32, width),
+	}
+
+	// Half of the units start voting dead and half live.
+	for j := range m.output {
+		m.hiddenBias[j] = mlpInitialBias
+		m.output[j] = 1 - 2*int32(j%2)
+	}
+
+	return m
+}
+
+// Width returns the number of hidden units.
+func (m *MLPVictimFinder) Width() int {
+	return m.width
+}
+
+// SetFeatureShift sets the number of low address bits that are dropped when
+// the features are hashed with the address, like
+// PerceptronVictimFinder.SetFeatureShift.
+func (m *MLPVictimFinder) SetFeatureShift(shift uint) {
+	m.featureShift = shift
+}
+
+// SetThresholds sets the prediction threshold and the training threshold.
+// It panics if theta is negative.
+func (m *MLPVictimFinder) SetThresholds(threshold, theta int32) {
+	if theta < 0 {
+		panic(fmt.Sprintf("invalid training threshold %d", theta))
+	}
+
+	m.threshold = threshold
+	m.theta = theta
+}
+
+// SetTrainingSampleInterval makes the predictor train on one out of every n
+// outcomes, like PerceptronVictimFinder.SetTrainingSampleInterval. The
+// default of 1 trains on every outcome.
+func (m *MLPVictimFinder) SetTrainingSampleInterval(n uint64) {
+	if n == 0 {
+		panic("training sample interval must be positive")
+	}
+
+	m.trainingSampleInterval = n
+}
+
+// PredictionStats returns the prediction statistics.
+func (m *MLPVictimFinder) PredictionStats() PredictionStats {
+	return m.stats.current
+}
+
+// Output returns the output of the network for the access. Higher outputs
+// mean that the line is less likely to be reused.
+func (m *MLPVictimFinder) Output(ctx *VictimContext) int32 {
+	return m.forward(ctx)
+}
+
+// PredictDead predicts that a line is dead if the output reaches the
+// threshold. The confidence is the magnitude of the output.
+func (m *MLPVictimFinder) PredictDead(
+	addr uint64,
+	ctx *VictimContext,
+) (dead bool, confidence int32) {
+	out := m.forward(contextFor(addr, ctx))
+
+	return out >= m.threshold, abs(out)
+}
+
+// FindVictim selects the first invalid or unlocked block in way order, like
+// the perceptron without a context.
+func (m *MLPVictimFinder) FindVictim(set *Set) *Block {
+	return wayOrderVictims.FindVictim(set)
+}
+
+// FindVictimWithContext evicts the resident block with the highest output
+// if the access is confidently predicted dead, and falls back to PseudoLRU
+// otherwise. Invalid blocks come first.
+func (m *MLPVictimFinder) FindVictimWithContext(
+	set *Set,
+	context *VictimContext,
+) *Block {
+	confident := m.confidentlyDead(context)
+	m.stats.recordPrediction(confident)
+
+	if !confident {
+		return pseudoLRUVictims.FindVictim(set)
+	}
+
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	ways := m.residentOrder(set)
+	if len(ways) == 0 {
+		return nil
+	}
+
+	return set.Blocks[ways[0]]
+}
+
+// FindVictims returns up to n candidates in the order of
+// FindVictimWithContext, without updating the statistics.
+func (m *MLPVictimFinder) FindVictims(
+	set *Set,
+	context *VictimContext,
+	n int,
+) []*Block {
+	if context == nil {
+		return FindVictims(wayOrderVictims, set, nil, n)
+	}
+
+	if m.confidentlyDead(context) {
+		return rankCandidates(set, m.residentOrder(set), n)
+	}
+
+	return rankCandidates(set, pseudoLRUOrder(set), n)
+}
+
+// TrainOnHit trains the predictor on a hit of the line at the address.
+func (m *MLPVictimFinder) TrainOnHit(addr uint64) {
+	m.TrainOnHitWithContext(&VictimContext{Address: addr})
+}
+
+// TrainOnEviction trains the predictor on the eviction of the line at the
+// address.
+func (m *MLPVictimFinder) TrainOnEviction(addr uint64) {
+	m.TrainOnEvictionWithContext(&VictimContext{Address: addr})
+}
+
+// TrainOnHitWithContext trains the predictor on a hit by the access.
+func (m *MLPVictimFinder) TrainOnHitWithContext(ctx *VictimContext) {
+	m.train(ctx, false)
+}
+
+// TrainOnEvictionWithContext trains the predictor on the eviction of the line
+// at the address of the context.
+func (m *MLPVictimFinder) TrainOnEvictionWithContext(ctx *VictimContext) {
+	m.train(ctx, true)
+}
+
+func (m *MLPVictimFinder) train(ctx *VictimContext, dead bool) {
+	m.trainingSampleCounter++
+	if m.trainingSampleCounter%m.trainingSampleInterval != 0 {
+		return
+	}
+
+	out := m.forward(ctx)
+	predictedDead := out >= m.threshold
+	m.stats.recordOutcome(predictedDead, !dead)
+
+	if predictedDead == dead && abs(out) >= m.theta {
+		return
+	}
+
+	target := int32(-1)
+	if dead {
+		target = 1
+	}
+
+	// forward left the activations and the table indices of the access in
+	// the buffers.
+	for j, a := range m.activationBuffer {
+		if a == 0 {
+			continue
+		}
+
+		delta := target * sign(m.output[j])
+		m.output[j] = clampMLPWeight(
+			m.output[j] + target*(1+a>>mlpOutputStepShift))
+
+		if delta == 0 {
+			continue
+		}
+
+		for t, idx := range m.indexBuffer {
+			w := &m.hidden[t][int(idx)*m.width+j]
+			*w = clampMLPWeight(*w + delta)
+		}
+
+		m.hiddenBias[j] = clampMLPWeight(m.hiddenBias[j] + delta)
+	}
+
+	m.outputBias = clampMLPWeight(m.outputBias + target)
+}
+
+func (m *MLPVictimFinder) confidentlyDead(ctx *VictimContext) bool {
+	out := m.forward(ctx)
+
+	return out >= m.threshold && abs(out) >= m.theta
+}
+
+// forward returns the output of the network for the access. It leaves the
+// table indices and the hidden activations in the buffers, which are reused
+// by the next call.
+func (m *MLPVictimFinder) forward(ctx *VictimContext) int32 {
+	m.tableIndices(ctx)
+
+	sum := int32(0)
+	for j := range m.activationBuffer {
+		a := m.hiddenBias[j]
+		for t, idx := range m.indexBuffer {
+			a += m.hidden[t][int(idx)*m.width+j]
+		}
+
+		a = max(min(a, mlpActivationMax), 0)
+		m.activationBuffer[j] = a
+		sum += m.output[j] * a
+	}
+
+	return m.outputBias + sum>>mlpOutputShift
+}
+
+// tableIndices fills the index buffer with the entry of every table for the
+// access, adding tables if there are more features than tables.
+func (m *MLPVictimFinder) tableIndices(ctx *VictimContext) {
+	features := m.extractor.Extract(ctx)
+
+	for len(m.hidden) < len(features) {
+		m.hidden = append(m.hidden, m.newTable())
+	}
+
+	addrBits := uint32(ctx.Address>>m.featureShift) & 0xFF
+
+	m.indexBuffer = m.indexBuffer[:0]
+	for _, feature := range features {
+		idx := (hash32(uint64(feature)) ^ addrBits) % MLPTableSize
+		m.indexBuffer = append(m.indexBuffer, idx)
+	}
+}
+
+// newTable returns a table of pseudo-random initial weights. The weights
+// are drawn from a linear congruential generator, so the predictor is
+// deterministic.
+func (m *MLPVictimFinder) newTable() []int32 {
+	table := make([]int32, MLPTableSize*m.width)
+
+	for i := range table {
+		m.seed = m.seed*1664525 + 1013904223
+		table[i] = int32(m.seed>>16)%(2*mlpInitialSpread+1) - mlpInitialSpread
+	}
+
+	return table
+}
+
+// residentOrder returns the ways of the valid, unlocked blocks from the
+// highest to the lowest output, in PseudoLRU order on ties. The blocks are
+// predicted with their fill context.
+func (m *MLPVictimFinder) residentOrder(set *Set) []int {
+	outputs := make([]int32, len(set.Blocks))
+	ways := make([]int, 0, len(set.Blocks))
+
+	for _, way := range pseudoLRUOrder(set) {
+		block := set.Blocks[way]
+		if !block.IsValid || block.IsLocked {
+			continue
+		}
+
+		outputs[way] = m.forward(residentContext(block))
+		ways = append(ways, way)
+	}
+
+	sort.SliceStable(ways, func(i, j int) bool {
+		return outputs[ways[i]] > outputs[ways[j]]
+	})
+
+	return ways
+}
+
+func clampMLPWeight(w int32) int32 {
+	return max(min(w, mlpWeightMax), mlpWeightMin)
+}
+
+func sign(x int32) int32 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return 0
+	}
+}
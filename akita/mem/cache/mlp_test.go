@@ -0,0 +1,101 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/mem"
+)
+
+// originFeatures takes only the compute unit and the kernel of an access.
+type originFeatures struct{}
+
+func (originFeatures) Extract(ctx *VictimContext) []uint32 {
+	return []uint32{uint32(ctx.CUID), uint32(ctx.KernelID) + 0x100}
+}
+
+var _ = Describe("MLPVictimFinder", func() {
+	var (
+		m          *MLPVictimFinder
+		dead, live *VictimContext
+	)
+
+	BeforeEach(func() {
+		m = NewMLPVictimFinder(DefaultMLPWidth)
+		dead = &VictimContext{Address: 0x10040}
+		live = &VictimContext{Address: 0x23080}
+	})
+
+	It("should have the configured width", func() {
+		Expect(m.Width()).To(Equal(DefaultMLPWidth))
+		Expect(NewMLPVictimFinder(2).Width()).To(Equal(2))
+		Expect(func() { NewMLPVictimFinder(0) }).To(Panic())
+	})
+
+	It("should learn which lines are dead", func() {
+		for i := 0; i < 32; i++ {
+			m.TrainOnEvictionWithContext(dead)
+			m.TrainOnHitWithContext(live)
+		}
+
+		predicted, confidence := m.PredictDead(dead.Address, nil)
+		Expect(predicted).To(BeTrue())
+		Expect(confidence).To(BeNumerically(">=", 32))
+
+		predicted, _ = m.PredictDead(live.Address, nil)
+		Expect(predicted).To(BeFalse())
+
+		stats := m.PredictionStats()
+		Expect(stats.Outcomes).To(Equal(uint64(64)))
+		Expect(stats.Accuracy()).To(BeNumerically(">", 0.8))
+	})
+
+	It("should learn a function that is not linear in the features", func() {
+		m = NewMLPVictimFinderWithExtractor(DefaultMLPWidth, originFeatures{})
+		access := func(cu, kernel int) *VictimContext {
+			return &VictimContext{
+				Address:      0x1000,
+				AccessOrigin: mem.AccessOrigin{CUID: cu, KernelID: uint64(kernel)},
+			}
+		}
+
+		for i := 0; i < 256; i++ {
+			for cu := 0; cu < 2; cu++ {
+				for kernel := 0; kernel < 2; kernel++ {
+					if cu == kernel {
+						m.TrainOnEvictionWithContext(access(cu, kernel))
+					} else {
+						m.TrainOnHitWithContext(access(cu, kernel))
+					}
+				}
+			}
+		}
+
+		for cu := 0; cu < 2; cu++ {
+			for kernel := 0; kernel < 2; kernel++ {
+				predicted, _ := m.PredictDead(0x1000, access(cu, kernel))
+				Expect(predicted).To(Equal(cu == kernel), "%d %d", cu, kernel)
+			}
+		}
+	})
+
+	It("should evict the block predicted dead", func() {
+		for i := 0; i < 32; i++ {
+			m.TrainOnEvictionWithContext(dead)
+			m.TrainOnHitWithContext(live)
+		}
+
+		set := makeTestSet(4)
+		for _, b := range set.Blocks {
+			b.IsValid = true
+			b.Tag = live.Address
+		}
+		set.Blocks[2].Tag = dead.Address
+
+		Expect(m.FindVictimWithContext(set, dead)).
+			To(BeIdenticalTo(set.Blocks[2]))
+		Expect(m.FindVictimWithContext(set, live)).
+			To(BeIdenticalTo(set.Blocks[0]))
+		Expect(m.FindVictims(set, dead, 2)[0]).To(BeIdenticalTo(set.Blocks[2]))
+		Expect(m.PredictionStats().ConfidentPredictions).To(Equal(uint64(1)))
+	})
+})
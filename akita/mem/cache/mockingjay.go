@@ -0,0 +1,379 @@
+package cache
+
+import "sort"
+
+// The reuse distances of Mockingjay are quantized to 7 bits. The largest
+// value means that no reuse is expected within the sampled history.
+const (
+	mockingjayMaxDistance      = 126
+	mockingjayInfiniteDistance = 127
+)
+
+// A MockingjayConfig configures a MockingjayVictimFinder.
+type MockingjayConfig struct {
+	// One set out of every SampleInterval sets trains the predictor.
+	// Defaults to 32.
+	SampleInterval int
+
+	// The history of a sampled set covers HistoryFactor times the
+	// associativity accesses. Reuses beyond it are predicted as infinite.
+	// Defaults to 8.
+	HistoryFactor int
+
+	// The predictor has 2^PredictorBits entries. Defaults to 11.
+	PredictorBits uint
+
+	// The reuse distances, in accesses to the set, are shifted right by
+	// DistanceShift before they are stored. Defaults to the smallest shift
+	// that fits the history in the quantized range.
+	DistanceShift uint
+}
+
+// MockingjayStats counts the training events of a MockingjayVictimFinder.
+type MockingjayStats struct {
+	SampledAccesses uint64
+
+	// Reuses counts the reuses observed in the sampled history, and
+	// Expirations the lines that left the history without being reused.
+	Reuses      uint64
+	Expirations uint64
+}
+
+// mockingjayEntry is the last access to a line of a sampled set.
+type mockingjayEntry struct {
+	valid bool
+	addr  uint64
+	sig   uint32
+	time  uint64
+}
+
+// mockingjaySampler remembers the recent accesses of a sampled set.
+type mockingjaySampler struct {
+	history []mockingjayEntry
+	next    int
+}
+
+// MockingjayVictimFinder implements Mockingjay (Shah et al., HPCA 2022),
+// which mimics Belady's policy by predicting when every line will be
+// reused. The sampled sets measure the reuse distance of their lines, in
+// accesses to the set, and train a table of quantized distances indexed by
+// the PC signature of the access. Every resident line carries an estimated
+// time of arrival, Block.ETA, which is the time of its last access plus its
+// predicted distance. The victim is the line whose ETA is the furthest from
+// now, in the future or in the past: a line long overdue is probably dead.
+//
+// Like Hawkeye, hits are attributed to the signature of the fill, which is
+// stored in Block.Signature, and the signature is the memory region of the
+// line if the PC is unknown. A line whose predicted distance is further
+// than the ETA of every resident line is reported to the insertion advisor
+// as a bypass.
+type MockingjayVictimFinder struct {
+	config MockingjayConfig
+
+	// The predicted distances, allocated at the first fill, when the
+	// associativity is known.
+	predictor []uint8
+
+	sampled map[int]*mockingjaySampler
+	clocks  []uint64
+	stats   MockingjayStats
+
+	// The remaining distance of the last victim with a context, for the
+	// insertion advice.
+	lastVictimDistance uint64
+	lastVictimValid    bool
+}
+
+// NewMockingjayVictimFinder returns a Mockingjay victim finder with the
+// default configuration.
+func NewMockingjayVictimFinder() *MockingjayVictimFinder {
+	return NewMockingjayVictimFinderWithConfig(MockingjayConfig{})
+}
+
+// NewMockingjayVictimFinderWithConfig returns a Mockingjay victim finder
+// with the configuration.
+func NewMockingjayVictimFinderWithConfig(
+	config MockingjayConfig,
+) *MockingjayVictimFinder {
+	if config.SampleInterval <= 0 {
+		config.SampleInterval = 32
+	}
+
+	if config.HistoryFactor <= 0 {
+		config.HistoryFactor = 8
+	}
+
+	if config.PredictorBits == 0 {
+		config.PredictorBits = 11
+	}
+
+	return &MockingjayVictimFinder{
+		config:  config,
+		sampled: make(map[int]*mockingjaySampler),
+	}
+}
+
+// Config returns the configuration. The distance shift is only filled in
+// after the first fill.
+func (m *MockingjayVictimFinder) Config() MockingjayConfig {
+	return m.config
+}
+
+// Stats returns the training statistics.
+func (m *MockingjayVictimFinder) Stats() MockingjayStats {
+	return m.stats
+}
+
+// PredictedDistance returns the predicted reuse distance of the lines of
+// the signature, in accesses to their set. It returns false if no reuse is
+// expected, or if nothing has been filled yet.
+func (m *MockingjayVictimFinder) PredictedDistance(sig uint32) (uint64, bool) {
+	if m.predictor == nil {
+		return 0, false
+	}
+
+	q := m.predictor[sig]
+	if q == mockingjayInfiniteDistance {
+		return 0, false
+	}
+
+	return uint64(q) << m.config.DistanceShift, true
+}
+
+// Signature returns the predictor signature of an access with the PC to
+// the line.
+func (m *MockingjayVictimFinder) Signature(pc, line uint64) uint32 {
+	key := line >> shipRegionShift
+	if pc != 0 {
+		key = pc
+	}
+
+	return uint32(mixLineHash(key) & (1<<m.config.PredictorBits - 1))
+}
+
+// Insert records the signature of a filled line, trains the predictor if
+// the set is sampled, and sets the ETA of the line.
+func (m *MockingjayVictimFinder) Insert(set *Set, block *Block) {
+	m.init(set)
+
+	block.Signature = m.Signature(block.PC, block.Tag)
+	m.access(set, block)
+}
+
+// Touch trains the predictor if the set is sampled and sets the ETA of the
+// hit line.
+func (m *MockingjayVictimFinder) Touch(set *Set, block *Block) {
+	m.init(set)
+	m.access(set, block)
+}
+
+// FindVictim returns the first invalid block, or the unlocked block whose
+// ETA is the furthest from now. It returns nil if every block is locked.
+func (m *MockingjayVictimFinder) FindVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil || len(set.Blocks) == 0 {
+		return b
+	}
+
+	ways := m.order(set)
+	for _, way := range ways {
+		block := set.Blocks[way]
+		if !block.IsLocked && !isPinned(block) {
+			return block
+		}
+	}
+
+	return nil
+}
+
+// FindVictimWithContext returns the same victim as FindVictim, and
+// remembers how far its ETA is for the insertion advice.
+func (m *MockingjayVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	victim := m.FindVictim(set)
+
+	m.lastVictimValid = victim != nil && victim.IsValid
+	if m.lastVictimValid {
+		m.lastVictimDistance = m.remaining(set, victim)
+	}
+
+	return victim
+}
+
+// FindVictims returns up to n candidates from the furthest to the nearest
+// ETA.
+func (m *MockingjayVictimFinder) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	if len(set.Blocks) == 0 {
+		return nil
+	}
+
+	return rankCandidates(set, m.order(set), n)
+}
+
+// AdviseInsertion recommends bypassing the missing line if no reuse is
+// expected or if it is predicted to be reused later than the last victim.
+func (m *MockingjayVictimFinder) AdviseInsertion(
+	ctx *VictimContext,
+) InsertionPriority {
+	if m.predictor == nil || !m.lastVictimValid {
+		return InsertMRU
+	}
+
+	distance, ok := m.PredictedDistance(m.Signature(ctx.PC, contextLine(ctx)))
+	if !ok || distance > m.lastVictimDistance {
+		return InsertBypass
+	}
+
+	return InsertMRU
+}
+
+// init allocates the predictor and the clocks when the geometry is first
+// seen. Every signature starts with a distance of one associativity, so an
+// untrained predictor keeps the lines for about as long as LRU does.
+func (m *MockingjayVictimFinder) init(set *Set) {
+	setID := set.Blocks[0].SetID
+	for len(m.clocks) <= setID {
+		m.clocks = append(m.clocks, 0)
+	}
+
+	if m.predictor != nil {
+		return
+	}
+
+	window := uint64(len(set.Blocks) * m.config.HistoryFactor)
+	if m.config.DistanceShift == 0 {
+		for window>>m.config.DistanceShift > mockingjayMaxDistance {
+			m.config.DistanceShift++
+		}
+	}
+
+	m.predictor = make([]uint8, 1<<m.config.PredictorBits)
+	for i := range m.predictor {
+		m.predictor[i] = m.quantize(uint64(len(set.Blocks)))
+	}
+}
+
+// access advances the clock of the set, trains the predictor if the set is
+// sampled, and sets the ETA of the block.
+func (m *MockingjayVictimFinder) access(set *Set, block *Block) {
+	now := m.clocks[block.SetID]
+	m.clocks[block.SetID]++
+
+	if block.SetID%m.config.SampleInterval == 0 {
+		m.sample(set, block, now)
+	}
+
+	distance, ok := m.PredictedDistance(block.Signature)
+	if !ok {
+		distance = uint64(mockingjayInfiniteDistance) << m.config.DistanceShift
+	}
+
+	block.ETA = now + distance
+}
+
+// sample records the access in the history of the set, and trains the
+// predictor with the reuse distance of the previous access to the line, or
+// with an infinite distance for the entry that leaves the history.
+func (m *MockingjayVictimFinder) sample(set *Set, block *Block, now uint64) {
+	s, ok := m.sampled[block.SetID]
+	if !ok {
+		s = &mockingjaySampler{
+			history: make([]mockingjayEntry,
+				len(set.Blocks)*m.config.HistoryFactor),
+		}
+		m.sampled[block.SetID] = s
+	}
+
+	m.stats.SampledAccesses++
+
+	for i := range s.history {
+		prev := &s.history[i]
+		if prev.valid && prev.addr == block.Tag {
+			m.train(prev.sig, m.quantize(now-prev.time))
+			m.stats.Reuses++
+			prev.valid = false
+
+			break
+		}
+	}
+
+	oldest := &s.history[s.next]
+	if oldest.valid {
+		m.train(oldest.sig, mockingjayInfiniteDistance)
+		m.stats.Expirations++
+	}
+
+	*oldest = mockingjayEntry{
+		valid: true,
+		addr:  block.Tag,
+		sig:   block.Signature,
+		time:  now,
+	}
+	s.next = (s.next + 1) % len(s.history)
+}
+
+// train moves the predicted distance of the signature a quarter of the way
+// toward the observed one, and by at least one.
+func (m *MockingjayVictimFinder) train(sig uint32, observed uint8) {
+	p := &m.predictor[sig]
+	diff := int(observed) - int(*p)
+
+	step := diff / 4
+	switch {
+	case step == 0 && diff > 0:
+		step = 1
+	case step == 0 && diff < 0:
+		step = -1
+	}
+
+	*p = uint8(int(*p) + step)
+}
+
+func (m *MockingjayVictimFinder) quantize(distance uint64) uint8 {
+	q := distance >> m.config.DistanceShift
+	if q > mockingjayMaxDistance {
+		return mockingjayMaxDistance
+	}
+
+	return uint8(q)
+}
+
+// remaining returns how far the ETA of the block is from now, in either
+// direction.
+func (m *MockingjayVictimFinder) remaining(set *Set, block *Block) uint64 {
+	now := uint64(0)
+	if setID := set.Blocks[0].SetID; setID < len(m.clocks) {
+		now = m.clocks[setID]
+	}
+
+	if block.ETA >= now {
+		return block.ETA - now
+	}
+
+	return now - block.ETA
+}
+
+// order returns the ways of the set from the furthest to the nearest ETA,
+// in way order on ties.
+func (m *MockingjayVictimFinder) order(set *Set) []int {
+	ways := make([]int, len(set.Blocks))
+	for i := range ways {
+		ways[i] = i
+	}
+
+	distances := make([]uint64, len(set.Blocks))
+	for way, block := range set.Blocks {
+		distances[way] = m.remaining(set, block)
+	}
+
+	sort.SliceStable(ways, func(i, j int) bool {
+		return distances[ways[i]] > distances[ways[j]]
+	})
+
+	return ways
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MockingjayVictimFinder", func() {
+	var (
+		vf *MockingjayVictimFinder
+		d  *DirectoryImpl
+	)
+
+	access := func(addr, pc uint64) bool {
+		if block := d.Lookup(0, addr); block != nil {
+			d.Visit(block)
+			return true
+		}
+
+		block := d.FindVictimWithContext(addr, &VictimContext{
+			Address: addr,
+			PC:      pc,
+		})
+		block.Tag = addr
+		block.IsValid = true
+		d.Visit(block)
+
+		return false
+	}
+
+	BeforeEach(func() {
+		vf = NewMockingjayVictimFinder()
+		d = NewDirectory(1, 4, 64, vf)
+	})
+
+	It("should fill in the defaults", func() {
+		access(0x0, 0x10)
+
+		config := vf.Config()
+		Expect(config.SampleInterval).To(Equal(32))
+		Expect(config.HistoryFactor).To(Equal(8))
+		Expect(config.PredictorBits).To(Equal(uint(11)))
+		Expect(config.DistanceShift).To(BeZero())
+
+		distance, ok := vf.PredictedDistance(vf.Signature(0x20, 0))
+		Expect(ok).To(BeTrue())
+		Expect(distance).To(Equal(uint64(4)))
+	})
+
+	It("should learn the reuse distance of a PC", func() {
+		for i := 0; i < 100; i++ {
+			access(0x0, 0x10)
+			access(0x40, 0x10)
+		}
+
+		distance, ok := vf.PredictedDistance(vf.Signature(0x10, 0))
+		Expect(ok).To(BeTrue())
+		Expect(distance).To(Equal(uint64(2)))
+		Expect(vf.Stats().Reuses).To(BeNumerically(">", 100))
+	})
+
+	It("should learn that a streaming PC is not reused", func() {
+		for addr := uint64(0); addr < 400*64; addr += 64 {
+			access(addr, 0x20)
+		}
+
+		_, ok := vf.PredictedDistance(vf.Signature(0x20, 0))
+		Expect(ok).To(BeFalse())
+		Expect(vf.Stats().Expirations).To(BeNumerically(">", 0))
+
+		d.FindVictimWithContext(0x100000,
+			&VictimContext{Address: 0x100000, PC: 0x20})
+		Expect(vf.AdviseInsertion(&VictimContext{Address: 0x100000, PC: 0x20})).
+			To(Equal(InsertBypass))
+	})
+
+	It("should keep the reused lines of a mixed stream", func() {
+		hits := 0
+		stream := uint64(0x100000)
+
+		for i := 0; i < 300; i++ {
+			for _, addr := range []uint64{0x0, 0x40, 0x80} {
+				if access(addr, 0x10) && i >= 200 {
+					hits++
+				}
+			}
+
+			for j := 0; j < 2; j++ {
+				access(stream, 0x20)
+				stream += 64
+			}
+		}
+
+		Expect(hits).To(BeNumerically(">", 250))
+	})
+
+	It("should evict the block whose ETA is the furthest", func() {
+		set := makeTestSet(4)
+		for i, eta := range []uint64{3, 9, 1, 5} {
+			set.Blocks[i].IsValid = true
+			set.Blocks[i].ETA = eta
+		}
+
+		Expect(vf.FindVictims(set, nil, 4)).To(Equal([]*Block{
+			set.Blocks[1], set.Blocks[3], set.Blocks[0], set.Blocks[2],
+		}))
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[1]))
+	})
+})
@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// A ShadowGeometry is the shape of one shadow directory.
+type ShadowGeometry struct {
+	NumSets int
+	NumWays int
+}
+
+// CapacityLines returns the number of blocks in the geometry.
+func (g ShadowGeometry) CapacityLines() int {
+	return g.NumSets * g.NumWays
+}
+
+// A ShadowPolicy names a replacement policy. New is called once per shadow
+// directory, so that the shadows do not share predictor state.
+type ShadowPolicy struct {
+	Name string
+	New  func() VictimFinder
+}
+
+// A MissRatePoint is the result of one shadow directory.
+type MissRatePoint struct {
+	ShadowGeometry
+	Accesses uint64
+	Misses   uint64
+}
+
+// MissRate returns the fraction of accesses that missed.
+func (p MissRatePoint) MissRate() float64 {
+	if p.Accesses == 0 {
+		return 0
+	}
+
+	return float64(p.Misses) / float64(p.Accesses)
+}
+
+// A PolicyCurve is the miss-rate-vs-capacity curve of one policy. The points
+// are ordered by capacity, then by associativity.
+type PolicyCurve struct {
+	Policy string
+	Points []MissRatePoint
+}
+
+type curveShadow struct {
+	dir   *DirectoryImpl
+	point MissRatePoint
+}
+
+// A MissRateCurve feeds one access stream into tag-only shadow directories of
+// every policy and geometry, producing the miss-rate curves of all the
+// policies in a single pass.
+type MissRateCurve struct {
+	policies []ShadowPolicy
+	shadows  [][]*curveShadow
+}
+
+// NewMissRateCurve creates a shadow directory for every combination of policy
+// and geometry.
+func NewMissRateCurve(
+	blockSize int,
+	policies []ShadowPolicy,
+	geometries []ShadowGeometry,
+) *MissRateCurve {
+	if blockSize <= 0 {
+		panic("block size must be positive")
+	}
+
+	c := &MissRateCurve{
+		policies: policies,
+		shadows:  make([][]*curveShadow, len(policies)),
+	}
+
+	for i, policy := range policies {
+		for _, g := range geometries {
+			if g.NumSets <= 0 || g.NumWays <= 0 {
+				panic("shadow geometry must have at least one set and way")
+			}
+
+			c.shadows[i] = append(c.shadows[i], &curveShadow{
+				dir: NewDirectory(
+					g.NumSets, g.NumWays, blockSize, policy.New()),
+				point: MissRatePoint{ShadowGeometry: g},
+			})
+		}
+	}
+
+	return c
+}
+
+// Access feeds one access into all the shadow directories.
+func (c *MissRateCurve) Access(pid vm.PID, addr uint64) {
+	for _, shadows := range c.shadows {
+		for _, s := range shadows {
+			s.access(pid, addr)
+		}
+	}
+}
+
+func (s *curveShadow) access(pid vm.PID, addr uint64) {
+	s.point.Accesses++
+
+	if hit, _ := accessTagOnly(s.dir, pid, addr); !hit {
+		s.point.Misses++
+	}
+}
+
+// A tagOnlyLine identifies a line in a tag-only directory.
+type tagOnlyLine struct {
+	pid vm.PID
+	tag uint64
+}
+
+// accessTagOnly looks up the line of the address in a directory that keeps
+// tags only, and fills the line on a miss. It reports whether the access hit
+// and returns the valid line that the fill evicted, if any.
+func accessTagOnly(
+	d *DirectoryImpl,
+	pid vm.PID,
+	addr uint64,
+) (hit bool, evicted *tagOnlyLine) {
+	tag := addr / uint64(d.BlockSize) * uint64(d.BlockSize)
+
+	if block := d.Lookup(pid, tag); block != nil {
+		if perceptron, ok := d.victimFinder.(*PerceptronVictimFinder); ok {
+			perceptron.TrainOnHit(tag)
+		}
+
+		d.Visit(block)
+
+		return true, nil
+	}
+
+	return false, fillTagOnly(d, pid, tag)
+}
+
+// fillTagOnly places the line in a tag-only directory and returns the valid
+// line that it evicted, if any. The line must not be resident.
+func fillTagOnly(d *DirectoryImpl, pid vm.PID, tag uint64) *tagOnlyLine {
+	victim := d.FindVictimWithContext(tag, &VictimContext{
+		Address: tag,
+		PID:     pid,
+	})
+	if victim == nil {
+		return nil
+	}
+
+	var evicted *tagOnlyLine
+	if victim.IsValid {
+		evicted = &tagOnlyLine{pid: victim.PID, tag: victim.Tag}
+
+		if perceptron, ok := d.victimFinder.(*PerceptronVictimFinder); ok {
+			perceptron.TrainOnEviction(victim.Tag)
+		}
+	}
+
+	victim.PID = pid
+	victim.Tag = tag
+	victim.IsValid = true
+	d.Visit(victim)
+
+	return evicted
+}
+
+// Curves returns the miss-rate curve of every policy, in the order in which
+// the policies were given.
+func (c *MissRateCurve) Curves() []PolicyCurve {
+	curves := make([]PolicyCurve, len(c.policies))
+
+	for i, policy := range c.policies {
+		points := make([]MissRatePoint, len(c.shadows[i]))
+		for j, s := range c.shadows[i] {
+			points[j] = s.point
+		}
+
+		sort.SliceStable(points, func(a, b int) bool {
+			ca, cb := points[a].CapacityLines(), points[b].CapacityLines()
+			if ca != cb {
+				return ca < cb
+			}
+
+			return points[a].NumWays < points[b].NumWays
+		})
+
+		curves[i] = PolicyCurve{Policy: policy.Name, Points: points}
+	}
+
+	return curves
+}
+
+// WriteCSV writes one row per policy and geometry.
+func (c *MissRateCurve) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{
+		"policy", "sets", "ways", "capacity_lines",
+		"accesses", "misses", "miss_rate",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, curve := range c.Curves() {
+		for _, p := range curve.Points {
+			err := cw.Write([]string{
+				curve.Policy,
+				strconv.Itoa(p.NumSets),
+				strconv.Itoa(p.NumWays),
+				strconv.Itoa(p.CapacityLines()),
+				strconv.FormatUint(p.Accesses, 10),
+				strconv.FormatUint(p.Misses, 10),
+				strconv.FormatFloat(p.MissRate(), 'f', 6, 64),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// Results converts the curves into the common result schema. The cache of
+// each result is named after its geometry, as "<sets>x<ways>".
+func (c *MissRateCurve) Results(workload string) []SimulationResult {
+	var results []SimulationResult
+
+	for _, curve := range c.Curves() {
+		for _, p := range curve.Points {
+			results = append(results, SimulationResult{
+				Source:   "akita",
+				Workload: workload,
+				Policy:   curve.Policy,
+				Cache:    fmt.Sprintf("%dx%d", p.NumSets, p.NumWays),
+				CPU:      -1,
+				Accesses: p.Accesses,
+				Hits:     p.Accesses - p.Misses,
+				Misses:   p.Misses,
+			})
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MissRateCurve", func() {
+	var (
+		c *MissRateCurve
+	)
+
+	BeforeEach(func() {
+		c = NewMissRateCurve(64,
+			[]ShadowPolicy{
+				{Name: "lru", New: func() VictimFinder {
+					return NewLRUVictimFinder()
+				}},
+				{Name: "perceptron", New: func() VictimFinder {
+					return NewPerceptronVictimFinder()
+				}},
+			},
+			[]ShadowGeometry{
+				{NumSets: 4, NumWays: 4},
+				{NumSets: 1, NumWays: 4},
+				{NumSets: 1, NumWays: 8},
+			})
+
+		for round := 0; round < 10; round++ {
+			for line := uint64(0); line < 6; line++ {
+				c.Access(1, line*64+8)
+			}
+		}
+	})
+
+	It("should order the points by capacity", func() {
+		curves := c.Curves()
+
+		Expect(curves).To(HaveLen(2))
+		Expect(curves[0].Policy).To(Equal("lru"))
+		Expect(curves[1].Policy).To(Equal("perceptron"))
+		for _, curve := range curves {
+			Expect(curve.Points).To(HaveLen(3))
+			Expect(curve.Points[0].CapacityLines()).To(Equal(4))
+			Expect(curve.Points[1].CapacityLines()).To(Equal(8))
+			Expect(curve.Points[2].CapacityLines()).To(Equal(16))
+		}
+	})
+
+	It("should only see compulsory misses if the stream fits", func() {
+		for _, curve := range c.Curves() {
+			for _, p := range curve.Points[1:] {
+				Expect(p.Accesses).To(Equal(uint64(60)))
+				Expect(p.Misses).To(Equal(uint64(6)))
+			}
+
+			Expect(curve.Points[0].Misses).
+				To(BeNumerically(">", curve.Points[1].Misses))
+		}
+	})
+
+	It("should write a CSV row per policy and geometry", func() {
+		buf := new(bytes.Buffer)
+
+		Expect(c.WriteCSV(buf)).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		Expect(lines).To(HaveLen(7))
+		Expect(lines[0]).To(HavePrefix("policy,sets,ways"))
+		Expect(lines[2]).To(Equal("lru,1,8,8,60,6,0.100000"))
+	})
+})
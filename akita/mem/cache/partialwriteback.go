@@ -0,0 +1,139 @@
+package cache
+
+// A WritebackGranularity decides how much of a dirty line is written back.
+type WritebackGranularity int
+
+// Writeback granularities.
+const (
+	// The whole line is written back.
+	WritebackFullLine WritebackGranularity = iota
+
+	// Only the sectors that hold dirty bytes are written back.
+	WritebackSectors
+
+	// Sectors are written back if the line is predicted dead or if few of
+	// its sectors are dirty; otherwise, the whole line is written back.
+	WritebackAdaptive
+)
+
+// PartialWritebackPolicy configures a WritebackPlanner.
+type PartialWritebackPolicy struct {
+	Granularity WritebackGranularity
+
+	// Bytes per sector. Defaults to 32.
+	SectorSize int
+
+	// With WritebackAdaptive, a line that is not predicted dead is written
+	// back by sectors only if at most this fraction of its sectors is dirty.
+	MaxDirtyFraction float64
+}
+
+// WritebackTrafficStats counts the writebacks and the bytes they move.
+type WritebackTrafficStats struct {
+	Writebacks        uint64
+	PartialWritebacks uint64
+
+	// Bytes sent to the lower level, and the dirty bytes among them.
+	BytesWritten uint64
+	DirtyBytes   uint64
+}
+
+// A WritebackPlanner uses the dirty mask of evicted lines to decide between
+// partial and full writebacks and accounts the traffic byte-accurately. The
+// dirty mask sent to the lower level is not changed, so the decision only
+// affects the modeled traffic. A nil WritebackPlanner ignores all the calls.
+type WritebackPlanner struct {
+	policy PartialWritebackPolicy
+	stats  WritebackTrafficStats
+}
+
+// NewWritebackPlanner creates a planner with the given policy.
+func NewWritebackPlanner(policy PartialWritebackPolicy) *WritebackPlanner {
+	if policy.SectorSize <= 0 {
+		policy.SectorSize = 32
+	}
+
+	return &WritebackPlanner{policy: policy}
+}
+
+// NeedsPrediction tells if Plan uses the dead-block prediction, so that the
+// caller can skip querying the predictor otherwise.
+func (w *WritebackPlanner) NeedsPrediction() bool {
+	return w != nil && w.policy.Granularity == WritebackAdaptive
+}
+
+// Plan records the writeback of a line of blockSize bytes. A nil dirty mask
+// means that the whole line is dirty. It returns whether the writeback is
+// partial and the number of bytes that it writes.
+func (w *WritebackPlanner) Plan(
+	dirtyMask []bool,
+	blockSize int,
+	predictedDead bool,
+) (partial bool, bytes uint64) {
+	if w == nil {
+		return false, uint64(blockSize)
+	}
+
+	sector := w.policy.SectorSize
+	if sector > blockSize {
+		sector = blockSize
+	}
+
+	numSectors := (blockSize + sector - 1) / sector
+	dirtySectors, dirtyBytes := countDirty(dirtyMask, blockSize, sector)
+
+	switch w.policy.Granularity {
+	case WritebackSectors:
+		partial = dirtySectors < numSectors
+	case WritebackAdaptive:
+		fraction := float64(dirtySectors) / float64(numSectors)
+		partial = dirtySectors < numSectors &&
+			(predictedDead || fraction <= w.policy.MaxDirtyFraction)
+	}
+
+	bytes = uint64(blockSize)
+	if partial {
+		bytes = uint64(dirtySectors * sector)
+		w.stats.PartialWritebacks++
+	}
+
+	w.stats.Writebacks++
+	w.stats.BytesWritten += bytes
+	w.stats.DirtyBytes += uint64(dirtyBytes)
+
+	return partial, bytes
+}
+
+// countDirty returns the number of sectors with at least one dirty byte and
+// the number of dirty bytes.
+func countDirty(dirtyMask []bool, blockSize, sector int) (sectors, bytes int) {
+	if dirtyMask == nil {
+		return (blockSize + sector - 1) / sector, blockSize
+	}
+
+	for start := 0; start < blockSize; start += sector {
+		dirty := false
+
+		for i := start; i < start+sector && i < len(dirtyMask); i++ {
+			if dirtyMask[i] {
+				dirty = true
+				bytes++
+			}
+		}
+
+		if dirty {
+			sectors++
+		}
+	}
+
+	return sectors, bytes
+}
+
+// Stats returns the writeback traffic recorded so far.
+func (w *WritebackPlanner) Stats() WritebackTrafficStats {
+	if w == nil {
+		return WritebackTrafficStats{}
+	}
+
+	return w.stats
+}
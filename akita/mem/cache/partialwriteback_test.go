@@ -0,0 +1,98 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WritebackPlanner", func() {
+	// oneDirtySector marks the first 8 bytes of a 64-byte line dirty.
+	oneDirtySector := func() []bool {
+		mask := make([]bool, 64)
+		for i := 0; i < 8; i++ {
+			mask[i] = true
+		}
+
+		return mask
+	}
+
+	It("should write the full line by default", func() {
+		p := NewWritebackPlanner(PartialWritebackPolicy{})
+
+		partial, bytes := p.Plan(oneDirtySector(), 64, true)
+
+		Expect(partial).To(BeFalse())
+		Expect(bytes).To(Equal(uint64(64)))
+		Expect(p.Stats()).To(Equal(WritebackTrafficStats{
+			Writebacks:   1,
+			BytesWritten: 64,
+			DirtyBytes:   8,
+		}))
+	})
+
+	It("should write only the dirty sectors", func() {
+		p := NewWritebackPlanner(PartialWritebackPolicy{
+			Granularity: WritebackSectors,
+			SectorSize:  16,
+		})
+
+		partial, bytes := p.Plan(oneDirtySector(), 64, false)
+
+		Expect(partial).To(BeTrue())
+		Expect(bytes).To(Equal(uint64(16)))
+		Expect(p.Stats().PartialWritebacks).To(Equal(uint64(1)))
+	})
+
+	It("should treat a nil mask as fully dirty", func() {
+		p := NewWritebackPlanner(PartialWritebackPolicy{
+			Granularity: WritebackSectors,
+		})
+
+		partial, bytes := p.Plan(nil, 64, false)
+
+		Expect(partial).To(BeFalse())
+		Expect(bytes).To(Equal(uint64(64)))
+		Expect(p.Stats().DirtyBytes).To(Equal(uint64(64)))
+	})
+
+	It("should write dead lines by sectors in adaptive mode", func() {
+		p := NewWritebackPlanner(PartialWritebackPolicy{
+			Granularity:      WritebackAdaptive,
+			SectorSize:       16,
+			MaxDirtyFraction: 0.1,
+		})
+
+		Expect(p.NeedsPrediction()).To(BeTrue())
+
+		partial, _ := p.Plan(oneDirtySector(), 64, false)
+		Expect(partial).To(BeFalse())
+
+		partial, bytes := p.Plan(oneDirtySector(), 64, true)
+		Expect(partial).To(BeTrue())
+		Expect(bytes).To(Equal(uint64(16)))
+	})
+
+	It("should write sparse lines by sectors in adaptive mode", func() {
+		p := NewWritebackPlanner(PartialWritebackPolicy{
+			Granularity:      WritebackAdaptive,
+			SectorSize:       16,
+			MaxDirtyFraction: 0.25,
+		})
+
+		partial, bytes := p.Plan(oneDirtySector(), 64, false)
+
+		Expect(partial).To(BeTrue())
+		Expect(bytes).To(Equal(uint64(16)))
+	})
+
+	It("should ignore calls on a nil planner", func() {
+		var p *WritebackPlanner
+
+		partial, bytes := p.Plan(oneDirtySector(), 64, true)
+
+		Expect(partial).To(BeFalse())
+		Expect(bytes).To(Equal(uint64(64)))
+		Expect(p.NeedsPrediction()).To(BeFalse())
+		Expect(p.Stats()).To(Equal(WritebackTrafficStats{}))
+	})
+})
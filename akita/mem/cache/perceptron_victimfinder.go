@@ -1,7 +1,11 @@
 package cache
 
 import (
+	"math/bits"
+
+	"github.com/sarchlab/akita/v4/mem/mem"
 	"github.com/sarchlab/akita/v4/mem/vm"
+	"github.com/sarchlab/akita/v4/sim"
 )
 
 // VictimContext contains context information for victim selection
@@ -10,15 +14,28 @@ type VictimContext struct {
 	PID         vm.PID
 	AccessType  string // "read" or "write"
 	CacheLineID uint64
+	IsPrefetch  bool   // The fill is caused by a prefetch
+	QoSClass    int    // Priority class of the access; see QoSPolicy
+	PC          uint64 // Instruction PC of the access; 0 if unknown
+
+	// The GPU requester of the access and the number of bytes accessed;
+	// zero if unknown. See GPUFeatureExtractor.
+	mem.AccessOrigin
+	AccessSize uint64
 }
 
 // PerceptronVictimFinder implements perceptron-based cache replacement
 // Based on MICRO 2016 paper "Perceptron Learning for Reuse Prediction"
 // Uses address-as-PC-proxy since we don't have direct PC access in GPU
 type PerceptronVictimFinder struct {
+	// Hooks observe predictions and training; see HookPosPredictionMade
+	sim.HookableBase
+
 	// 32 weights as used in earlier successful implementation
-	// Each weight is 6-bit signed (-32 to +31)
-	weights [32]int32
+	// Each weight is 6-bit signed (-32 to +31) unless configured otherwise;
+	// only the first weightConfig.NumWeights are used
+	weights      [MaxPerceptronWeights]int32
+	weightConfig PerceptronWeightConfig
 
 	// Prediction threshold (τ from MICRO 2016)
 	// If sum >= threshold, predict no reuse (evict block)
@@ -34,22 +51,110 @@ type PerceptronVictimFinder struct {
 	// Statistics for monitoring
 	totalPredictions   int64
 	correctPredictions int64
+	stats              predictionStatsState
 
-	// Pre-allocated feature array to avoid repeated allocations
-	// OPTIMIZATION: Reuse this array instead of allocating on each call
+	// Pre-allocated feature and table-index arrays to avoid repeated allocations
+	// OPTIMIZATION: Reuse these arrays instead of allocating on each call
 	featureBuffer [6]uint32
+	indexBuffer   []uint32
 
 	// REMOVED: Set sampling - now apply perceptron to all sets for accurate measurement
 
 	// OPTIMIZATION: Training sampling - only train on subset of outcomes to reduce overhead
-	trainingSampleCounter uint64 // Counter for training sampling
+	trainingSampleCounter  uint64 // Counter for training sampling
+	trainingSampleInterval uint64 // Train on one out of every N outcomes
+
+	// Number of low address bits dropped before the bits are used as features
+	featureShift uint
+
+	// Sums of recent predictions, reused by training; see
+	// SetPredictionTableSize
+	predictions predictionTable
+
+	// Strict mode disables the prediction cache and training sampling so that
+	// every outcome is trained with a freshly computed sum, as in the paper.
+	strict bool
+
+	// Optional energy accounting for weight-table accesses
+	energy *EnergyMeter
+
+	// Constant added to every prediction sum, used by imported models
+	bias int32
+
+	// Inference-only mode keeps the weights fixed; only statistics are updated
+	inferenceOnly bool
+
+	// Hashed mode replaces the weight vector with the hashed weight tables
+	// of MICRO 2016, one per feature. The sum is one weight per table.
+	hashed bool
+	tables [][PerceptronTableSize]int32
+
+	// Where the features come from; see FeatureSource
+	featureSource FeatureSource
+
+	// Custom features for the hashed tables; nil uses the feature source
+	extractor FeatureExtractor
+
+	// Optional sampler that replaces training sampling; see EnableSampler
+	sampler *perceptronSampler
 
-	// OPTIMIZATION: Cache last prediction to eliminate duplicate calculations
-	lastPredictionAddr uint64 // Address of last prediction
-	lastPredictionSum  int32  // Cached sum from last prediction
+	// Optional per-process weights; see EnablePerPIDWeights
+	perPID *pidWeightTables
+
+	// The group that merges the weights of the banks of a multi-banked
+	// cache; see PerceptronBankGroup
+	bankGroup *PerceptronBankGroup
+	bankID    int
+
+	// The bus that shares the outcomes with the predictors of other cache
+	// levels; see SetTrainingBus
+	trainingBus *TrainingBus
+	busLevel    int
+
+	// Optional periodic weight decay; see SetWeightDecay
+	decay *weightDecay
+
+	// Features left out of the predictions and training, one bit per
+	// feature, and the optional importance statistics; see DisableFeature
+	// and EnableFeatureImportance
+	disabledFeatures uint64
+	importance       *featureImportance
+
+	// The context of the call in progress, whose GPU fields the feature
+	// extractor may use; see featureContextFor
+	featureContext *VictimContext
+
+	// Cost-aware victim selection prefers clean dead blocks; see
+	// SetCostAware
+	costAware    bool
+	cleanVictims uint64
+
+	// Victims selected because their block was marked dead; see
+	// DirectoryImpl.SetBlockPredictions
+	markedDeadVictims uint64
+
+	// Optional shift register of the recent accesses; see
+	// SetGlobalHistory
+	history *globalHistory
+
+	// Optional reuse bucket thresholds and the fills predicted in every
+	// bucket; see SetReuseBuckets
+	buckets      *ReuseBucketThresholds
+	bucketCounts [numReuseBuckets]uint64
+
+	// Unused prefetches are evicted first; see SetPreferUnusedPrefetches
+	preferUnusedPrefetches bool
+	unusedPrefetchVictims  uint64
 }
 
-// NewPerceptronVictimFinder creates a new perceptron victim finder with MICRO 2016 paper parameters
+// Size of the hashed weight tables used with the built-in features.
+const (
+	PerceptronNumTables = 6
+	PerceptronTableSize = 256
+)
+
+// NewPerceptronVictimFinder creates a new perceptron victim finder with MICRO 2016 paper parameters.
+// It predicts with the six hashed weight tables of the paper.
 func NewPerceptronVictimFinder() *PerceptronVictimFinder {
 	return NewPerceptronVictimFinderWithParams(0, 32, 2) // MICRO 2016 paper parameters: τ=0, θ=32, lr=1
 }
@@ -60,6 +165,15 @@ func NewPerceptronVictimFinderWithParams(threshold, theta, learningRate int32) *
 		threshold:    threshold,
 		theta:        theta,
 		learningRate: learningRate,
+
+		trainingSampleInterval: 5,
+
+		hashed: true,
+		tables: make([][PerceptronTableSize]int32, PerceptronNumTables),
+
+		predictions: newPredictionTable(defaultPredictionTableSize),
+
+		weightConfig: PerceptronWeightConfig{}.normalize(),
 	}
 
 	// Initialize 32 weights to 0 (matching earlier successful implementation)
@@ -79,32 +193,88 @@ func (p *PerceptronVictimFinder) shouldUsePerceptron(setID int) bool {
 	return true
 }
 
+// SetStrictMode enables or disables strict-correctness mode. In strict mode,
+// the last-prediction cache and training sampling are bypassed, so the results
+// can be compared against the optimized path.
+func (p *PerceptronVictimFinder) SetStrictMode(strict bool) {
+	p.strict = strict
+}
+
+// SetTrainingSampleInterval makes the predictor train on one out of every n
+// outcomes. An interval of 1 trains on every outcome.
+func (p *PerceptronVictimFinder) SetTrainingSampleInterval(n uint64) {
+	if n == 0 {
+		panic("training sample interval must be positive")
+	}
+
+	p.trainingSampleInterval = n
+}
+
+// SetFeatureShift sets the number of low address bits that are dropped
+// before the address bits are used as perceptron inputs. Setting it to the
+// log2 of the block size avoids spending weights on the always-zero offset
+// bits.
+func (p *PerceptronVictimFinder) SetFeatureShift(shift uint) {
+	p.featureShift = shift
+	p.invalidatePredictions()
+}
+
+// SetHashedTables switches between the single bit-indexed weight vector and
+// the six hashed weight tables of the MICRO 2016 paper. Each table is indexed
+// by one feature hashed with the address, and the prediction sums one weight
+// per table. The hashed tables are the default. Both weight sets are kept, so
+// switching does not lose training.
+func (p *PerceptronVictimFinder) SetHashedTables(hashed bool) {
+	p.hashed = hashed
+	p.invalidatePredictions()
+}
+
+// IsHashedTables returns true if the predictor uses the hashed weight tables.
+func (p *PerceptronVictimFinder) IsHashedTables() bool {
+	return p.hashed
+}
+
+// IsStrictMode returns true if the predictor runs in strict-correctness mode.
+func (p *PerceptronVictimFinder) IsStrictMode() bool {
+	return p.strict
+}
+
+// SetEnergyMeter attaches an energy meter that is charged for every
+// weight-table read and update, and for every access to a sampled set of the
+// sampler.
+func (p *PerceptronVictimFinder) SetEnergyMeter(m *EnergyMeter) {
+	p.energy = m
+}
+
+// EnergyMeter returns the attached energy meter, or nil if there is none.
+func (p *PerceptronVictimFinder) EnergyMeter() *EnergyMeter {
+	return p.energy
+}
+
 // shouldTrain determines if we should train on this outcome (20% balanced sampling for better learning)
 func (p *PerceptronVictimFinder) shouldTrain() bool {
+	if p.strict {
+		return true
+	}
+
 	p.trainingSampleCounter++
-	return p.trainingSampleCounter%5 == 0 // Train on every 5th outcome (20% balanced training sampling)
+	return p.trainingSampleCounter%p.trainingSampleInterval == 0 // Train on every Nth outcome (default 20% balanced training sampling)
 }
 
 // FindVictim implements the VictimFinder interface
 // Uses direct block traversal (no LRU maintenance)
 func (p *PerceptronVictimFinder) FindVictim(set *Set) *Block {
-	// Direct block traversal when no context is provided
-	for _, block := range set.Blocks {
-		if !block.IsValid && !block.IsLocked {
-			return block
-		}
+	if b := p.unusedPrefetchVictim(set); b != nil {
+		p.unusedPrefetchVictims++
+		return b
 	}
 
-	for _, block := range set.Blocks {
-		if !block.IsLocked {
-			return block
-		}
+	if p.buckets != nil {
+		return p.bucketVictim(set)
 	}
 
-	if len(set.Blocks) > 0 {
-		return set.Blocks[0]
-	}
-	return nil
+	// Direct block traversal when no context is provided
+	return wayOrderVictims.FindVictim(set)
 }
 
 // FindVictimWithContext implements perceptron-based victim selection with set sampling
@@ -114,83 +284,160 @@ func (p *PerceptronVictimFinder) FindVictimWithContext(set *Set, context *Victim
 	// All sets now use perceptron prediction with confidence threshold
 
 	// For all sets, use full perceptron logic
+	p.usePIDWeights(context.PID)
+	p.featureContext = context
+	defer func() { p.featureContext = nil }()
+
 	// Calculate prediction sum using direct PC and tag bits (like earlier implementation)
-	sum := p.calculatePredictionSum(context.Address)
+	sum := p.calculatePredictionSum(context.Address, context.PC)
 
 	// OPTIMIZATION: Cache prediction sum to eliminate duplicate calculation in training
-	p.lastPredictionAddr = context.Address
-	p.lastPredictionSum = sum
+	p.predictions.store(context.Address, context.PC, sum)
+	p.recordImportancePrediction(context.Address, context.PC)
+
+	// A victim is only needed on a miss, which the sampler records
+	if p.sampler != nil {
+		p.sampler.access(p, context.Address, context.PC)
+	}
 
 	// Make prediction: if sum >= threshold, predict no reuse (evict block)
 	// if sum < threshold, predict reuse (keep block)
 	predictNoReuse := sum >= p.threshold
 
+	if p.NumHooks() > 0 {
+		p.invokeHook(HookPosPredictionMade,
+			p.prediction(context.Address, context.PC, sum), nil)
+	}
+
 	// DIRECT TRAINING: Cached sum will be reused in training to eliminate duplicate calculation
 
 	// Find best victim based on prediction and confidence (HYBRID APPROACH)
 	victim := p.selectVictim(set, predictNoReuse, sum)
 
+	if p.NumHooks() > 0 {
+		p.invokeHook(HookPosVictimSelected, victim,
+			p.prediction(context.Address, context.PC, sum))
+	}
+
+	p.tickDecay(false)
+	p.history.record(context.Address, false)
+
 	// Update statistics
 	p.totalPredictions++
+	p.stats.recordPrediction(abs(sum) >= p.theta)
+
+	if p.bankGroup != nil {
+		p.bankGroup.recordPrediction(p.bankID)
+	}
 
 	return victim
 }
 
-// ExtractFeatures extracts 6 features using address-as-PC-proxy (public method)
-// Based on MICRO 2016 paper Section IV-F, adapted for GPU context
-func (p *PerceptronVictimFinder) ExtractFeatures(context *VictimContext) [6]uint32 {
-	return p.extractFeatures(context)
+// ExtractFeatures returns the features of the access that index the hashed
+// tables (public method)
+func (p *PerceptronVictimFinder) ExtractFeatures(context *VictimContext) []uint32 {
+	features := p.extractFeatures(context)
+	return append([]uint32(nil), features...)
 }
 
-// extractFeatures extracts 6 features using address-as-PC-proxy (internal method)
-// Based on MICRO 2016 paper Section IV-F, adapted for GPU context
+// extractFeatures returns the features from the custom extractor, or the
+// built-in features of the feature source (internal method)
 // OPTIMIZATION: Uses pre-allocated buffer to avoid repeated allocations
-func (p *PerceptronVictimFinder) extractFeatures(context *VictimContext) [6]uint32 {
-	addr := context.Address
-
-	// Use pre-allocated buffer to avoid allocation overhead
-	// Feature 1: Address bits 6-11 (PC proxy shifted by 2)
-	p.featureBuffer[0] = uint32((addr >> 6) & 0x3F)
+func (p *PerceptronVictimFinder) extractFeatures(context *VictimContext) []uint32 {
+	if p.extractor != nil {
+		return p.history.appendFeatures(p.extractor.Extract(context))
+	}
 
-	// Feature 2: Address bits 7-12 (PC proxy shifted by 1)
-	p.featureBuffer[1] = uint32((addr >> 7) & 0x3F)
+	if p.usesPC(context.PC) {
+		p.featureBuffer = pcFeatures(context.Address, context.PC)
+	} else {
+		p.featureBuffer = reuseFeatures(context.Address)
+	}
 
-	// Feature 3: Address bits 8-13 (PC proxy shifted by 2)
-	p.featureBuffer[2] = uint32((addr >> 8) & 0x3F)
+	return p.history.appendFeatures(p.featureBuffer[:])
+}
 
-	// Feature 4: Address bits 9-14 (PC proxy shifted by 3)
-	p.featureBuffer[3] = uint32((addr >> 9) & 0x3F)
+// reuseFeatures extracts the 6 address-as-PC-proxy features of an address.
+func reuseFeatures(addr uint64) [6]uint32 {
+	return [6]uint32{
+		// Feature 1: Address bits 6-11 (PC proxy shifted by 2)
+		uint32((addr >> 6) & 0x3F),
+		// Feature 2: Address bits 7-12 (PC proxy shifted by 1)
+		uint32((addr >> 7) & 0x3F),
+		// Feature 3: Address bits 8-13 (PC proxy shifted by 2)
+		uint32((addr >> 8) & 0x3F),
+		// Feature 4: Address bits 9-14 (PC proxy shifted by 3)
+		uint32((addr >> 9) & 0x3F),
+		// Feature 5: Tag bits (address bits 12-17)
+		uint32((addr >> 12) & 0x3F),
+		// Feature 6: Page bits (address bits 15-20)
+		uint32((addr >> 15) & 0x3F),
+	}
+}
 
-	// Feature 5: Tag bits (address bits 12-17)
-	p.featureBuffer[4] = uint32((addr >> 12) & 0x3F)
+// calculatePredictionSum calculates the sum using direct PC and tag bits (like earlier implementation)
+func (p *PerceptronVictimFinder) calculatePredictionSum(addr, pc uint64) int32 {
+	sum := p.predictionSum(addr, pc)
 
-	// Feature 6: Page bits (address bits 15-20)
-	p.featureBuffer[5] = uint32((addr >> 15) & 0x3F)
+	if p.hashed {
+		p.energy.Charge(EnergyWeightRead, uint64(len(p.indexBuffer)))
+	} else {
+		p.energy.Charge(EnergyWeightRead, uint64(p.weightConfig.NumWeights))
+	}
 
-	return p.featureBuffer
+	return sum
 }
 
-// calculatePredictionSum calculates the sum using direct PC and tag bits (like earlier implementation)
-func (p *PerceptronVictimFinder) calculatePredictionSum(addr uint64) int32 {
-	sum := int32(0)
+// predictionSum computes the prediction sum without charging the weight
+// reads, for diagnostics that are not part of the modeled hardware.
+func (p *PerceptronVictimFinder) predictionSum(addr, pc uint64) int32 {
+	sum := p.bias
+
+	if p.hashed {
+		for i, idx := range p.tableIndices(addr, pc) {
+			if p.featureEnabled(i) {
+				sum += p.tables[i][idx]
+			}
+		}
+
+		return sum
+	}
 
-	// Use direct PC bits (16 bits from address)
-	for i := 0; i < 16; i++ {
-		if (addr>>uint(i))&1 == 1 {
+	pcBits := p.pcBits(addr, pc)
+	addr >>= p.featureShift
+	half := p.weightConfig.NumWeights / 2
+
+	// Use direct PC bits (half the weights, from the PC or the address)
+	for i := 0; i < half; i++ {
+		if (pcBits>>uint(i))&1 == 1 && p.featureEnabled(i) {
 			sum += p.weights[i]
 		}
 	}
 
-	// Use tag bits (16 bits from higher address bits)
-	for i := 0; i < 16; i++ {
-		if (addr>>uint(i+16))&1 == 1 {
-			sum += p.weights[i+16]
+	// Use tag bits (half the weights, from address bit 16 up)
+	for i := 0; i < half; i++ {
+		if (addr>>uint(i+16))&1 == 1 && p.featureEnabled(i+half) {
+			sum += p.weights[i+half]
 		}
 	}
 
 	return sum
 }
 
+// PredictsDead tells if the perceptron confidently predicts that the line of
+// the address will not be reused. It does not charge the weight reads.
+func (p *PerceptronVictimFinder) PredictsDead(addr uint64) bool {
+	return p.predictsDead(addr, 0)
+}
+
+// predictsDead returns true if the perceptron confidently predicts that the
+// line will not be reused. It neither updates the statistics nor charges
+// energy.
+func (p *PerceptronVictimFinder) predictsDead(addr, pc uint64) bool {
+	sum := p.predictionSum(addr, pc)
+	return sum >= p.threshold && abs(sum) >= p.theta
+}
+
 // getTableIndex computes table index using hashing + XOR as per MICRO 2016
 func (p *PerceptronVictimFinder) getTableIndex(feature uint32, addr uint64) uint32 {
 	// Hash the feature to 8 bits (as per paper)
@@ -199,147 +446,55 @@ func (p *PerceptronVictimFinder) getTableIndex(feature uint32, addr uint64) uint
 	// XOR with lower 8 bits of address (instead of PC)
 	addrBits := uint32(addr & 0xFF)
 
-	return (hashedFeature ^ addrBits) % 256
+	return (hashedFeature ^ addrBits) % PerceptronTableSize
 }
 
-// selectVictim selects the best victim using HYBRID approach from MICRO 2016 paper
-func (p *PerceptronVictimFinder) selectVictim(set *Set, predictNoReuse bool, predictionSum int32) *Block {
-	// MICRO 2016 HYBRID APPROACH: Use perceptron when confident, LRU baseline when not
+// tableIndices returns the entry of every hashed table used for the access,
+// adding tables if the extractor returns more features than there are tables.
+// Without a PC, the address shifted by the feature shift takes the place of
+// the PC. The returned slice is reused by the next call.
+func (p *PerceptronVictimFinder) tableIndices(addr, pc uint64) []uint32 {
+	features := p.extractFeatures(p.featureContextFor(addr, pc))
 
-	// First pass: Always prefer invalid blocks (regardless of prediction)
-	for _, block := range set.Blocks {
-		if !block.IsValid && !block.IsLocked {
-			return block
-		}
+	pcProxy := addr >> p.featureShift
+	if p.usesPC(pc) {
+		pcProxy = pc >> pcAlignBits
 	}
 
-	// Check prediction confidence using theta threshold (like MICRO 2016 paper)
-	isConfident := abs(predictionSum) >= p.theta
-
-	if isConfident {
-		// HIGH CONFIDENCE: Use perceptron prediction
-		if predictNoReuse {
-			// Perceptron says "no reuse" - find any unlocked block to evict
-			for _, block := range set.Blocks {
-				if !block.IsLocked {
-					return block
-				}
-			}
-		} else {
-			// Perceptron says "reuse likely" - use PseudoLRU baseline to preserve locality
-			return p.findPseudoLRUVictim(set)
-		}
-	} else {
-		// LOW CONFIDENCE: Fall back to PseudoLRU baseline (like MICRO 2016 paper)
-		return p.findPseudoLRUVictim(set)
+	for len(p.tables) < len(features) {
+		p.tables = append(p.tables, [PerceptronTableSize]int32{})
 	}
 
-	// Final fallback
-	if len(set.Blocks) > 0 {
-		return set.Blocks[0]
+	p.indexBuffer = p.indexBuffer[:0]
+	for _, feature := range features {
+		p.indexBuffer = append(p.indexBuffer, p.getTableIndex(feature, pcProxy))
 	}
-	return nil
-}
 
-// findPseudoLRUVictim implements PseudoLRU victim selection (MICRO 2016 paper baseline)
-func (p *PerceptronVictimFinder) findPseudoLRUVictim(set *Set) *Block {
-	numWays := len(set.Blocks)
-	victimWay := p.getPseudoLRUVictim(set, numWays)
+	return p.indexBuffer
+}
 
-	// Return the victim block if it's not locked
-	if victimWay < numWays && !set.Blocks[victimWay].IsLocked {
-		return set.Blocks[victimWay]
+// selectVictim selects the best victim using HYBRID approach from MICRO 2016 paper
+func (p *PerceptronVictimFinder) selectVictim(set *Set, predictNoReuse bool, predictionSum int32) *Block {
+	// MICRO 2016 HYBRID APPROACH: Use perceptron when confident, LRU baseline when not.
+	// Both paths prefer invalid blocks and never select locked blocks.
+	if b := p.unusedPrefetchVictim(set); b != nil {
+		p.unusedPrefetchVictims++
+		return b
 	}
 
-	// Fallback: return first unlocked block
-	for _, block := range set.Blocks {
-		if !block.IsLocked {
-			return block
-		}
+	if p.buckets != nil {
+		return p.bucketVictim(set)
 	}
 
-	// CRITICAL FIX: Never return nil - return first block as final fallback
-	// This matches the original LRU behavior and prevents crashes
-	if len(set.Blocks) > 0 {
-		return set.Blocks[0]
+	if abs(predictionSum) >= p.theta && predictNoReuse {
+		// HIGH CONFIDENCE: Perceptron says "no reuse" - evict the resident
+		// block least likely to be reused
+		return p.deadBlockVictim(set)
 	}
 
-	return nil // Should never happen if set has blocks
-}
-
-// getPseudoLRUVictim returns the way ID of the PseudoLRU victim
-func (p *PerceptronVictimFinder) getPseudoLRUVictim(set *Set, numWays int) int {
-	switch numWays {
-	case 2:
-		// 2-way: bit 0 indicates which way to replace
-		if (set.PseudoLRUBits & 1) == 0 {
-			return 0
-		}
-		return 1
-	case 4:
-		// 4-way: follow the tree bits to find victim
-		//     bit0
-		//    /    \
-		//  bit1   bit2
-		//  / \    / \
-		// W0 W1  W2 W3
-		if (set.PseudoLRUBits & 1) == 0 {
-			// Left subtree
-			if (set.PseudoLRUBits & (1 << 1)) == 0 {
-				return 0
-			}
-			return 1
-		} else {
-			// Right subtree
-			if (set.PseudoLRUBits & (1 << 2)) == 0 {
-				return 2
-			}
-			return 3
-		}
-	case 8:
-		// 8-way: follow the 7-bit tree
-		return p.getPseudoLRUVictim8Way(set)
-	default:
-		// Fallback: round-robin
-		return int(set.PseudoLRUBits % uint64(numWays))
-	}
-}
-
-// getPseudoLRUVictim8Way returns victim way for 8-way associative cache
-func (p *PerceptronVictimFinder) getPseudoLRUVictim8Way(set *Set) int {
-	bits := set.PseudoLRUBits
-
-	if (bits & 1) == 0 {
-		// Left subtree (ways 0-3)
-		if (bits & (1 << 1)) == 0 {
-			// Left-left subtree (ways 0-1)
-			if (bits & (1 << 3)) == 0 {
-				return 0
-			}
-			return 1
-		} else {
-			// Left-right subtree (ways 2-3)
-			if (bits & (1 << 4)) == 0 {
-				return 2
-			}
-			return 3
-		}
-	} else {
-		// Right subtree (ways 4-7)
-		if (bits & (1 << 2)) == 0 {
-			// Right-left subtree (ways 4-5)
-			if (bits & (1 << 5)) == 0 {
-				return 4
-			}
-			return 5
-		} else {
-			// Right-right subtree (ways 6-7)
-			if (bits & (1 << 6)) == 0 {
-				return 6
-			}
-			return 7
-		}
-	}
+	// "Reuse likely" or LOW CONFIDENCE: Fall back to PseudoLRU baseline
+	// (like MICRO 2016 paper)
+	return pseudoLRUVictims.FindVictim(set)
 }
 
 // Training methods
@@ -347,85 +502,115 @@ func (p *PerceptronVictimFinder) getPseudoLRUVictim8Way(set *Set) int {
 // TrainOnHit trains the predictor when a block is hit (reused)
 // OPTIMIZATION: Use cached prediction sum to eliminate duplicate calculation
 func (p *PerceptronVictimFinder) TrainOnHit(addr uint64) {
+	p.TrainOnHitWithPC(addr, 0)
+}
+
+// TrainOnHitWithPC trains the predictor on a hit by the instruction at pc. A
+// zero pc means that the PC is unknown.
+func (p *PerceptronVictimFinder) TrainOnHitWithPC(addr, pc uint64) {
+	p.trainingBus.publish(p, p.busLevel, p.busContext(addr, pc), true)
+	defer p.history.record(addr, true)
+
+	if p.sampler != nil {
+		p.sampler.access(p, addr, pc)
+		return
+	}
+
 	// OPTIMIZATION: Ultra-aggressive training sampling - only train on 5% of outcomes
 	if !p.shouldTrain() {
 		return
 	}
 
-	// OPTIMIZATION: Use cached sum if available, otherwise calculate (eliminates 50% of calculations!)
-	var sum int32
-	var predictNoReuse bool
-	if p.lastPredictionAddr == addr {
-		// Use cached prediction sum - MAJOR OPTIMIZATION!
-		sum = p.lastPredictionSum
-		predictNoReuse = sum >= p.threshold
-	} else {
-		// Fallback: calculate if cache miss (shouldn't happen often)
-		sum = p.calculatePredictionSum(addr)
-		predictNoReuse = sum >= p.threshold
-	}
+	sum := p.trainingSum(addr, pc)
+	predictNoReuse := sum >= p.threshold
 
 	// Train with actual outcome: hit means reuse (actualReuse = true)
-	p.trainWithSum(addr, predictNoReuse, sum, true)
+	p.trainWithSum(addr, pc, predictNoReuse, sum, true)
 }
 
 // TrainOnEviction trains the predictor when a block is evicted (not reused)
 // OPTIMIZATION: Use cached prediction sum to eliminate duplicate calculation
 func (p *PerceptronVictimFinder) TrainOnEviction(addr uint64) {
+	p.TrainOnEvictionWithPC(addr, 0)
+}
+
+// TrainOnEvictionWithPC trains the predictor on the eviction of a block that
+// was filled by the instruction at pc, usually Block.PC. A zero pc means that
+// the PC is unknown.
+func (p *PerceptronVictimFinder) TrainOnEvictionWithPC(addr, pc uint64) {
+	p.trainingBus.publish(p, p.busLevel, p.busContext(addr, pc), false)
+
+	if p.sampler != nil {
+		return
+	}
+
 	// OPTIMIZATION: Ultra-aggressive training sampling - only train on 5% of outcomes
 	if !p.shouldTrain() {
 		return
 	}
 
-	// OPTIMIZATION: Use cached sum if available, otherwise calculate (eliminates 50% of calculations!)
-	var sum int32
-	var predictNoReuse bool
-	if p.lastPredictionAddr == addr {
-		// Use cached prediction sum - MAJOR OPTIMIZATION!
-		sum = p.lastPredictionSum
-		predictNoReuse = sum >= p.threshold
-	} else {
-		// Fallback: calculate if cache miss (shouldn't happen often)
-		sum = p.calculatePredictionSum(addr)
-		predictNoReuse = sum >= p.threshold
-	}
+	sum := p.trainingSum(addr, pc)
+	predictNoReuse := sum >= p.threshold
 
 	// Train with actual outcome: eviction means no reuse (actualReuse = false)
-	p.trainWithSum(addr, predictNoReuse, sum, false)
+	p.trainWithSum(addr, pc, predictNoReuse, sum, false)
+}
+
+// trainingSum returns the prediction sum used for training. It reuses the
+// sum of a recent prediction from the prediction table unless strict mode is
+// enabled.
+func (p *PerceptronVictimFinder) trainingSum(addr, pc uint64) int32 {
+	if p.strict {
+		return p.calculatePredictionSum(addr, pc)
+	}
+
+	if sum, ok := p.predictions.lookup(addr, pc); ok {
+		return sum
+	}
+
+	return p.calculatePredictionSum(addr, pc)
 }
 
 // trainWithSum implements the perceptron learning algorithm using cached sum (OPTIMIZED)
-func (p *PerceptronVictimFinder) trainWithSum(addr uint64, predictedNoReuse bool, sum int32, actualReuse bool) {
+func (p *PerceptronVictimFinder) trainWithSum(addr, pc uint64, predictedNoReuse bool, sum int32, actualReuse bool) {
 	// Use the cached sum instead of recalculating (PERFORMANCE OPTIMIZATION)
 
 	// Convert to consistent semantics: actualNoReuse = !actualReuse
 	actualNoReuse := !actualReuse
 
 	// Update weights if prediction was wrong or confidence is low
-	if predictedNoReuse != actualNoReuse || abs(sum) < p.theta {
-		// Update weights based on PC bits (16 bits from address)
-		for i := 0; i < 16; i++ {
-			if (addr>>uint(i))&1 == 1 {
-				if actualReuse {
-					// Block was reused - decrement weight (make it less likely to predict no reuse)
-					p.weights[i] = max(-32, p.weights[i]-p.learningRate)
-				} else {
-					// Block was not reused - increment weight (make it more likely to predict no reuse)
-					p.weights[i] = min(31, p.weights[i]+p.learningRate)
-				}
+	update := !p.inferenceOnly &&
+		(predictedNoReuse != actualNoReuse || abs(sum) < p.theta)
+
+	if update {
+		p.invalidatePredictions()
+	}
+
+	if update && p.hashed {
+		p.updateTables(addr, pc, actualReuse)
+		p.energy.Charge(EnergyWeightUpdate, uint64(len(p.indexBuffer)))
+	} else if update {
+		pcBits := p.pcBits(addr, pc)
+		tagBits := addr >> p.featureShift
+		half := p.weightConfig.NumWeights / 2
+		mask := uint32(1)<<uint(half) - 1
+		p.energy.Charge(EnergyWeightUpdate,
+			uint64(bits.OnesCount32(uint32(pcBits)&mask)+
+				bits.OnesCount32(uint32(tagBits>>16)&mask)))
+
+		// Update weights based on PC bits (half the weights).
+		// A reuse decrements the weight (less likely to predict no reuse), no
+		// reuse increments it.
+		for i := 0; i < half; i++ {
+			if (pcBits>>uint(i))&1 == 1 && p.featureEnabled(i) {
+				p.weights[i] = p.saturate(p.weights[i], actualReuse)
 			}
 		}
 
-		// Update weights based on tag bits (16 bits from higher address bits)
-		for i := 0; i < 16; i++ {
-			if (addr>>uint(i+16))&1 == 1 {
-				if actualReuse {
-					// Block was reused - decrement weight
-					p.weights[i+16] = max(-32, p.weights[i+16]-p.learningRate)
-				} else {
-					// Block was not reused - increment weight
-					p.weights[i+16] = min(31, p.weights[i+16]+p.learningRate)
-				}
+		// Update weights based on tag bits (the other half)
+		for i := 0; i < half; i++ {
+			if (tagBits>>uint(i+16))&1 == 1 && p.featureEnabled(i+half) {
+				p.weights[i+half] = p.saturate(p.weights[i+half], actualReuse)
 			}
 		}
 	}
@@ -434,39 +619,60 @@ func (p *PerceptronVictimFinder) trainWithSum(addr uint64, predictedNoReuse bool
 	if predictedNoReuse == actualNoReuse {
 		p.correctPredictions++
 	}
+
+	p.stats.recordOutcome(predictedNoReuse, actualReuse)
+	p.recordImportanceOutcome(addr, pc, !actualReuse)
+	p.tickDecay(true)
+
+	if p.NumHooks() > 0 {
+		p.invokeHook(HookPosTrainingApplied, PerceptronTraining{
+			Address:          addr,
+			PC:               pc,
+			Sum:              sum,
+			Threshold:        p.threshold,
+			PredictedNoReuse: predictedNoReuse,
+			ActualReuse:      actualReuse,
+			Updated:          update,
+		}, nil)
+	}
+}
+
+// updateTables moves the weight used in every hashed table toward the
+// outcome.
+func (p *PerceptronVictimFinder) updateTables(addr, pc uint64, actualReuse bool) {
+	for i, idx := range p.tableIndices(addr, pc) {
+		if p.featureEnabled(i) {
+			p.tables[i][idx] = p.saturate(p.tables[i][idx], actualReuse)
+		}
+	}
 }
 
 // Access method for direct training on cache hits (like earlier implementation)
 // OPTIMIZATION: Use cached prediction sum to eliminate duplicate calculation
 func (p *PerceptronVictimFinder) Access(addr uint64) {
+	if p.sampler != nil {
+		p.sampler.access(p, addr, 0)
+		return
+	}
+
 	// OPTIMIZATION: Ultra-aggressive training sampling - only train on 5% of outcomes
 	if !p.shouldTrain() {
 		return
 	}
 
-	// OPTIMIZATION: Use cached sum if available, otherwise calculate (eliminates 50% of calculations!)
-	var sum int32
-	var predictNoReuse bool
-	if p.lastPredictionAddr == addr {
-		// Use cached prediction sum - MAJOR OPTIMIZATION!
-		sum = p.lastPredictionSum
-		predictNoReuse = sum >= p.threshold
-	} else {
-		// Fallback: calculate if cache miss (shouldn't happen often)
-		sum = p.calculatePredictionSum(addr)
-		predictNoReuse = sum >= p.threshold
-	}
+	sum := p.trainingSum(addr, 0)
+	predictNoReuse := sum >= p.threshold
 
 	// Train with actual outcome: access means reuse (actualReuse = true)
-	p.trainWithSum(addr, predictNoReuse, sum, true)
+	p.trainWithSum(addr, 0, predictNoReuse, sum, true)
 }
 
 // train implements the perceptron learning algorithm (fallback method for compatibility)
 func (p *PerceptronVictimFinder) train(addr uint64, predictedNoReuse bool, actualReuse bool) {
 	// Calculate current prediction confidence (this is the old non-optimized version)
-	sum := p.calculatePredictionSum(addr)
+	sum := p.calculatePredictionSum(addr, 0)
 	// Delegate to optimized version
-	p.trainWithSum(addr, predictedNoReuse, sum, actualReuse)
+	p.trainWithSum(addr, 0, predictedNoReuse, sum, actualReuse)
 }
 
 // Utility functions
@@ -506,6 +712,82 @@ func min(a, b int32) int32 {
 	return b
 }
 
+// Weights returns a copy of the weight table.
+func (p *PerceptronVictimFinder) Weights() [MaxPerceptronWeights]int32 {
+	return p.weights
+}
+
+// SetWeights replaces the weight table, for example with a snapshot taken
+// earlier.
+func (p *PerceptronVictimFinder) SetWeights(w [MaxPerceptronWeights]int32) {
+	p.weights = w
+	p.invalidatePredictions()
+}
+
+// TableWeights returns a copy of the hashed weight tables.
+func (p *PerceptronVictimFinder) TableWeights() [][PerceptronTableSize]int32 {
+	return append([][PerceptronTableSize]int32(nil), p.tables...)
+}
+
+// eachPerceptron calls f with the perceptron itself.
+func (p *PerceptronVictimFinder) eachPerceptron(
+	f func(p *PerceptronVictimFinder),
+) {
+	f(p)
+}
+
+// DecayWeights moves every weight toward zero by shifting it right by the
+// given number of bits. A shift of 0 leaves the weights unchanged, and a
+// shift of 31 or more clears them.
+func (p *PerceptronVictimFinder) DecayWeights(shift uint) {
+	if shift == 0 {
+		return
+	}
+
+	if shift >= 31 {
+		p.mapWeights(func(int32) int32 { return 0 })
+		return
+	}
+
+	divisor := int32(1) << shift
+	p.mapWeights(func(w int32) int32 { return w / divisor })
+}
+
+// mapWeights replaces every weight, including the weights of the inactive
+// processes, with the result of f.
+func (p *PerceptronVictimFinder) mapWeights(f func(int32) int32) {
+	for i := range p.weights {
+		p.weights[i] = f(p.weights[i])
+	}
+
+	for i := range p.tables {
+		for j := range p.tables[i] {
+			p.tables[i][j] = f(p.tables[i][j])
+		}
+	}
+
+	if p.perPID != nil {
+		p.perPID.mapInactive(f)
+	}
+
+	p.invalidatePredictions()
+}
+
+// ResetStats clears the prediction statistics.
+func (p *PerceptronVictimFinder) ResetStats() {
+	p.totalPredictions = 0
+	p.correctPredictions = 0
+	p.stats.current = PredictionStats{}
+	p.cleanVictims = 0
+	p.markedDeadVictims = 0
+	p.unusedPrefetchVictims = 0
+	p.bucketCounts = [numReuseBuckets]uint64{}
+
+	if p.stats.window != nil {
+		p.stats.window = newAccuracyWindow(len(p.stats.window.correct))
+	}
+}
+
 // GetAccuracy returns the prediction accuracy
 func (p *PerceptronVictimFinder) GetAccuracy() float64 {
 	if p.totalPredictions == 0 {
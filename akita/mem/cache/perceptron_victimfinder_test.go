@@ -0,0 +1,201 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func makeTestSet(numWays int) *Set {
+	set := &Set{}
+	for i := 0; i < numWays; i++ {
+		set.Blocks = append(set.Blocks, &Block{WayID: i})
+	}
+
+	return set
+}
+
+type perceptronTestEvent struct {
+	addr uint64
+	hit  bool
+}
+
+func perceptronTestStream(n int) []perceptronTestEvent {
+	events := make([]perceptronTestEvent, 0, n)
+	addr := uint64(0x12345)
+	for i := 0; i < n; i++ {
+		addr = addr*6364136223846793005 + 1442695040888963407
+		events = append(events, perceptronTestEvent{
+			addr: (addr >> 20) << 6,
+			hit:  i%3 == 0,
+		})
+	}
+
+	return events
+}
+
+func replayPerceptronEvent(
+	p *PerceptronVictimFinder,
+	set *Set,
+	e perceptronTestEvent,
+) {
+	p.FindVictimWithContext(set, &VictimContext{Address: e.addr})
+	if e.hit {
+		p.TrainOnHit(e.addr)
+	} else {
+		p.TrainOnEviction(e.addr)
+	}
+}
+
+var _ = Describe("PerceptronVictimFinder", func() {
+	var (
+		set *Set
+	)
+
+	BeforeEach(func() {
+		set = makeTestSet(4)
+		for _, b := range set.Blocks {
+			b.IsValid = true
+		}
+	})
+
+	Context("strict mode", func() {
+		It("should train on every outcome", func() {
+			optimized := NewPerceptronVictimFinder()
+			strict := NewPerceptronVictimFinder()
+			strict.SetStrictMode(true)
+
+			optimized.TrainOnEviction(0xffff)
+			strict.TrainOnEviction(0xffff)
+
+			untrained := NewPerceptronVictimFinder().tables
+			Expect(optimized.tables).To(Equal(untrained))
+			Expect(strict.tables).NotTo(Equal(untrained))
+		})
+
+		It("should not reuse a stale cached sum", func() {
+			p := NewPerceptronVictimFinder()
+			p.SetStrictMode(true)
+			p.SetHashedTables(false)
+
+			p.FindVictimWithContext(set, &VictimContext{Address: 0xf0})
+			cached, _ := p.predictions.lookup(0xf0, 0)
+			p.TrainOnEviction(0x30)
+
+			Expect(p.trainingSum(0xf0, 0)).
+				To(Equal(p.calculatePredictionSum(0xf0, 0)))
+			Expect(p.trainingSum(0xf0, 0)).NotTo(Equal(cached))
+		})
+
+		It("should match the optimized path on the sampled outcomes", func() {
+			events := perceptronTestStream(1000)
+
+			optimized := NewPerceptronVictimFinder()
+			for _, e := range events {
+				replayPerceptronEvent(optimized, set, e)
+			}
+
+			strict := NewPerceptronVictimFinder()
+			strict.SetStrictMode(true)
+			for i, e := range events {
+				if (i+1)%5 == 0 {
+					replayPerceptronEvent(strict, set, e)
+				}
+			}
+
+			Expect(strict.tables).To(Equal(optimized.tables))
+			Expect(strict.correctPredictions).
+				To(Equal(optimized.correctPredictions))
+		})
+
+		It("should diverge from the optimized path on the full stream", func() {
+			events := perceptronTestStream(1000)
+
+			optimized := NewPerceptronVictimFinder()
+			strict := NewPerceptronVictimFinder()
+			strict.SetStrictMode(true)
+
+			for _, e := range events {
+				replayPerceptronEvent(optimized, set, e)
+				replayPerceptronEvent(strict, set, e)
+			}
+
+			Expect(strict.tables).NotTo(Equal(optimized.tables))
+		})
+	})
+})
+
+var _ = Describe("PerceptronPreset", func() {
+	It("should look up presets by name", func() {
+		preset, ok := LookupPerceptronPreset("GPU_L1")
+
+		Expect(ok).To(BeTrue())
+		Expect(preset.Name).To(Equal(PresetGPUL1))
+		Expect(PerceptronPresetNames()).To(ConsistOf(
+			PresetGPUL1, PresetGPUL2, PresetCPULLC))
+	})
+
+	It("should configure the perceptron from a preset", func() {
+		preset, _ := LookupPerceptronPreset(PresetCPULLC)
+
+		p := NewPerceptronVictimFinderFromPreset(preset)
+
+		Expect(p.theta).To(Equal(int32(68)))
+		Expect(p.trainingSampleInterval).To(Equal(uint64(1)))
+		Expect(p.featureShift).To(Equal(uint(6)))
+		Expect(p.IsHashedTables()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Hashed perceptron tables", func() {
+	var p *PerceptronVictimFinder
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+	})
+
+	It("should be the default", func() {
+		Expect(p.IsHashedTables()).To(BeTrue())
+		Expect(p.tables).To(HaveLen(PerceptronNumTables))
+	})
+
+	It("should sum one weight per table", func() {
+		for i, idx := range p.tableIndices(0x12340, 0) {
+			p.tables[i][idx] = int32(i + 1)
+		}
+
+		Expect(p.predictionSum(0x12340, 0)).To(Equal(int32(21)))
+	})
+
+	It("should train the hashed tables only", func() {
+		p.TrainOnEviction(0x12340)
+
+		Expect(p.weights).To(Equal([32]int32{}))
+		for i, idx := range p.tableIndices(0x12340, 0) {
+			Expect(p.tables[i][idx]).To(Equal(p.learningRate))
+		}
+		Expect(p.predictionSum(0x12340, 0)).
+			To(Equal(PerceptronNumTables * p.learningRate))
+	})
+
+	It("should charge one weight read per table", func() {
+		m := NewEnergyMeter(DefaultEnergyModel())
+		p.SetEnergyMeter(m)
+
+		p.calculatePredictionSum(0x40, 0)
+
+		Expect(m.Count(EnergyWeightRead)).
+			To(Equal(uint64(PerceptronNumTables)))
+	})
+
+	It("should keep the tables when switching modes", func() {
+		p.TrainOnEviction(0x12340)
+		p.SetHashedTables(false)
+
+		Expect(p.predictionSum(0x12340, 0)).To(BeZero())
+
+		p.SetHashedTables(true)
+
+		Expect(p.predictionSum(0x12340, 0)).NotTo(BeZero())
+	})
+})
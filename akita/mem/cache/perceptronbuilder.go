@@ -0,0 +1,200 @@
+package cache
+
+import "fmt"
+
+// A PerceptronBuilder builds PerceptronVictimFinders. The defaults are the
+// parameters of NewPerceptronVictimFinder.
+type PerceptronBuilder struct {
+	threshold          int32
+	theta              int32
+	learningRate       int32
+	numWeights         int
+	weightBits         uint
+	weightMin          int32
+	weightMax          int32
+	extractor          FeatureExtractor
+	gpuFeatures        GPUFeature
+	trainingSampleRate uint64
+	historyKind        HistoryKind
+	historyLength      int
+	buckets            *ReuseBucketThresholds
+}
+
+// MakePerceptronBuilder creates a perceptron builder with the default
+// parameters.
+func MakePerceptronBuilder() PerceptronBuilder {
+	return PerceptronBuilder{
+		threshold:          0,
+		theta:              32,
+		learningRate:       2,
+		trainingSampleRate: 5,
+	}
+}
+
+// WithThreshold sets the prediction threshold. Blocks whose sum reaches the
+// threshold are predicted dead.
+func (b PerceptronBuilder) WithThreshold(threshold int32) PerceptronBuilder {
+	b.threshold = threshold
+	return b
+}
+
+// WithTheta sets the training threshold. Correct predictions whose sum
+// magnitude reaches theta do not train the weights.
+func (b PerceptronBuilder) WithTheta(theta int32) PerceptronBuilder {
+	b.theta = theta
+	return b
+}
+
+// WithLearningRate sets the step of every weight update.
+func (b PerceptronBuilder) WithLearningRate(rate int32) PerceptronBuilder {
+	b.learningRate = rate
+	return b
+}
+
+// WithNumWeights sets the number of weights that a prediction can sum: the
+// length of the weight vector, which must be even and at most
+// MaxPerceptronWeights, or, with a feature extractor, the number of hashed
+// weight tables. Without a feature extractor, the perceptron then predicts
+// with the weight vector instead of the built-in hashed tables.
+func (b PerceptronBuilder) WithNumWeights(n int) PerceptronBuilder {
+	b.numWeights = n
+	return b
+}
+
+// WithWeightBits sets the width of the saturating weights.
+func (b PerceptronBuilder) WithWeightBits(bits uint) PerceptronBuilder {
+	b.weightBits = bits
+	return b
+}
+
+// WithWeightRange sets the saturation range of the weights, which replaces
+// the range of the width.
+func (b PerceptronBuilder) WithWeightRange(lo, hi int32) PerceptronBuilder {
+	b.weightMin = lo
+	b.weightMax = hi
+
+	return b
+}
+
+// WithFeatureExtractor makes the perceptron predict with hashed weight tables
+// indexed by the features of the extractor.
+func (b PerceptronBuilder) WithFeatureExtractor(
+	e FeatureExtractor,
+) PerceptronBuilder {
+	b.extractor = e
+	return b
+}
+
+// WithGPUFeatures adds the selected GPU context fields to the features of the
+// feature extractor; see GPUFeatureExtractor. The perceptron predicts with
+// hashed weight tables.
+func (b PerceptronBuilder) WithGPUFeatures(f GPUFeature) PerceptronBuilder {
+	b.gpuFeatures = f
+	return b
+}
+
+// WithGlobalHistory adds hashed slices of a history of the last length
+// accesses to the features; see SetGlobalHistory.
+func (b PerceptronBuilder) WithGlobalHistory(
+	kind HistoryKind,
+	length int,
+) PerceptronBuilder {
+	b.historyKind = kind
+	b.historyLength = length
+
+	return b
+}
+
+// WithReuseBuckets makes the perceptron predict reuse buckets; see
+// SetReuseBuckets.
+func (b PerceptronBuilder) WithReuseBuckets(
+	t ReuseBucketThresholds,
+) PerceptronBuilder {
+	b.buckets = &t
+	return b
+}
+
+// WithTrainingSampleRate makes the perceptron train on one out of every n
+// outcomes. A rate of 1 trains on every outcome.
+func (b PerceptronBuilder) WithTrainingSampleRate(n uint64) PerceptronBuilder {
+	b.trainingSampleRate = n
+	return b
+}
+
+// Build creates the perceptron victim finder. It panics if the parameters
+// are invalid.
+func (b PerceptronBuilder) Build() *PerceptronVictimFinder {
+	b.validate()
+
+	p := NewPerceptronVictimFinderWithParams(
+		b.threshold, b.theta, b.learningRate)
+	p.SetTrainingSampleInterval(b.trainingSampleRate)
+	p.SetWeightConfig(b.weightConfig())
+
+	extractor := b.extractor
+	if b.gpuFeatures != 0 {
+		extractor = GPUFeatureExtractor{Base: extractor, Features: b.gpuFeatures}
+	}
+
+	switch {
+	case extractor != nil:
+		p.extractor = extractor
+
+		if b.numWeights > 0 {
+			p.tables = make([][PerceptronTableSize]int32, b.numWeights)
+		}
+	case b.numWeights > 0:
+		p.hashed = false
+	}
+
+	p.SetGlobalHistory(b.historyKind, b.historyLength)
+	p.SetReuseBuckets(b.buckets)
+
+	return p
+}
+
+func (b PerceptronBuilder) validate() {
+	if b.theta < 0 {
+		panic(fmt.Sprintf("perceptron theta %d is negative", b.theta))
+	}
+
+	if b.learningRate <= 0 {
+		panic(fmt.Sprintf(
+			"perceptron learning rate %d must be positive", b.learningRate))
+	}
+
+	if b.trainingSampleRate == 0 {
+		panic("training sample rate must be positive")
+	}
+
+	if b.numWeights < 0 {
+		panic(fmt.Sprintf("number of weights %d is negative", b.numWeights))
+	}
+
+	if err := checkGlobalHistory(b.historyKind, b.historyLength); err != nil {
+		panic(err)
+	}
+
+	if b.buckets != nil {
+		if err := b.buckets.validate(); err != nil {
+			panic(err)
+		}
+	}
+
+	// normalize panics if the weight configuration is invalid.
+	b.weightConfig().normalize()
+}
+
+func (b PerceptronBuilder) weightConfig() PerceptronWeightConfig {
+	c := PerceptronWeightConfig{
+		Bits: b.weightBits,
+		Min:  b.weightMin,
+		Max:  b.weightMax,
+	}
+
+	if b.extractor == nil && b.gpuFeatures == 0 {
+		c.NumWeights = b.numWeights
+	}
+
+	return c
+}
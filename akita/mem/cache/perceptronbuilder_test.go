@@ -0,0 +1,75 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type constantFeatureExtractor struct{}
+
+func (constantFeatureExtractor) Extract(*VictimContext) []uint32 {
+	return []uint32{1, 2, 3}
+}
+
+var _ = Describe("PerceptronBuilder", func() {
+	It("should default to the parameters of NewPerceptronVictimFinder", func() {
+		p := MakePerceptronBuilder().Build()
+		ref := NewPerceptronVictimFinder()
+
+		Expect(p.threshold).To(Equal(ref.threshold))
+		Expect(p.theta).To(Equal(ref.theta))
+		Expect(p.learningRate).To(Equal(ref.learningRate))
+		Expect(p.trainingSampleInterval).To(Equal(ref.trainingSampleInterval))
+		Expect(p.IsHashedTables()).To(BeTrue())
+	})
+
+	It("should predict with the weight vector of the given length", func() {
+		p := MakePerceptronBuilder().WithNumWeights(8).Build()
+
+		Expect(p.IsHashedTables()).To(BeFalse())
+		Expect(p.WeightConfig().NumWeights).To(Equal(8))
+	})
+
+	It("should apply the chained settings", func() {
+		e := constantFeatureExtractor{}
+
+		p := MakePerceptronBuilder().
+			WithThreshold(3).
+			WithTheta(68).
+			WithLearningRate(1).
+			WithTrainingSampleRate(1).
+			WithFeatureExtractor(e).
+			WithNumWeights(3).
+			Build()
+
+		Expect(p.threshold).To(Equal(int32(3)))
+		Expect(p.theta).To(Equal(int32(68)))
+		Expect(p.learningRate).To(Equal(int32(1)))
+		Expect(p.trainingSampleInterval).To(Equal(uint64(1)))
+		Expect(p.FeatureExtractor()).To(Equal(e))
+		Expect(p.IsHashedTables()).To(BeTrue())
+		Expect(p.TableWeights()).To(HaveLen(3))
+	})
+
+	It("should not change the builder it was derived from", func() {
+		base := MakePerceptronBuilder()
+		_ = base.WithTheta(100)
+
+		Expect(base.Build().theta).To(Equal(int32(32)))
+	})
+
+	It("should reject invalid parameters", func() {
+		Expect(func() {
+			MakePerceptronBuilder().WithLearningRate(0).Build()
+		}).To(Panic())
+		Expect(func() {
+			MakePerceptronBuilder().WithWeightRange(1, 8).Build()
+		}).To(Panic())
+		Expect(func() {
+			MakePerceptronBuilder().WithTrainingSampleRate(0).Build()
+		}).To(Panic())
+		Expect(func() {
+			MakePerceptronBuilder().WithNumWeights(33).Build()
+		}).To(Panic())
+	})
+})
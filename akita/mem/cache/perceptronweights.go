@@ -0,0 +1,98 @@
+package cache
+
+import "fmt"
+
+// MaxPerceptronWeights is the length of the weight vector of a perceptron
+// that does not use hashed tables.
+const MaxPerceptronWeights = 32
+
+// A PerceptronWeightConfig sizes the weights of a perceptron, for studies of
+// the predictor cost. The zero value is the configuration of the MICRO 2016
+// paper: 32 weights of 6 bits.
+type PerceptronWeightConfig struct {
+	// Length of the weight vector. Half of the weights follow the PC bits
+	// and half follow the tag bits, so it must be even. Defaults to 32,
+	// which is also the maximum.
+	NumWeights int `json:"num_weights,omitempty"`
+
+	// Width of the saturating two's complement weights. Defaults to 6.
+	Bits uint `json:"bits,omitempty"`
+
+	// Saturation range. If either bound is set, it replaces the range of
+	// the width.
+	Min int32 `json:"min,omitempty"`
+	Max int32 `json:"max,omitempty"`
+}
+
+// normalize fills in the defaults and panics if the configuration is
+// invalid.
+func (c PerceptronWeightConfig) normalize() PerceptronWeightConfig {
+	c, err := c.withDefaults()
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// withDefaults fills in the defaults and checks the configuration.
+func (c PerceptronWeightConfig) withDefaults() (PerceptronWeightConfig, error) {
+	if c.NumWeights == 0 {
+		c.NumWeights = MaxPerceptronWeights
+	}
+
+	if c.NumWeights < 0 || c.NumWeights > MaxPerceptronWeights ||
+		c.NumWeights%2 != 0 {
+		return c, fmt.Errorf(
+			"the number of weights must be even and at most %d, not %d",
+			MaxPerceptronWeights, c.NumWeights)
+	}
+
+	if c.Bits == 0 {
+		c.Bits = 6
+	}
+
+	if c.Bits < 2 || c.Bits > 31 {
+		return c, fmt.Errorf("weight width %d is not in [2, 31]", c.Bits)
+	}
+
+	if c.Min == 0 && c.Max == 0 {
+		c.Min = -1 << (c.Bits - 1)
+		c.Max = 1<<(c.Bits-1) - 1
+	}
+
+	if c.Min > 0 || c.Max < 0 || c.Min >= c.Max {
+		return c, fmt.Errorf(
+			"weight range [%d, %d] must contain zero", c.Min, c.Max)
+	}
+
+	return c, nil
+}
+
+// SetWeightConfig resizes the weights and clears them, along with the hashed
+// tables, which saturate at the same range.
+func (p *PerceptronVictimFinder) SetWeightConfig(c PerceptronWeightConfig) {
+	p.weightConfig = c.normalize()
+	p.weights = [MaxPerceptronWeights]int32{}
+
+	for i := range p.tables {
+		p.tables[i] = [PerceptronTableSize]int32{}
+	}
+
+	p.invalidatePredictions()
+}
+
+// WeightConfig returns the weight configuration, with the defaults filled in.
+func (p *PerceptronVictimFinder) WeightConfig() PerceptronWeightConfig {
+	return p.weightConfig
+}
+
+// saturate moves the weight one learning step toward the outcome, without
+// passing the bound it moves toward.
+func (p *PerceptronVictimFinder) saturate(w int32, actualReuse bool) int32 {
+	if actualReuse {
+		return max(p.weightConfig.Min, w-p.learningRate)
+	}
+
+	return min(p.weightConfig.Max, w+p.learningRate)
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PerceptronWeightConfig", func() {
+	var p *PerceptronVictimFinder
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		p.SetHashedTables(false)
+	})
+
+	It("should default to 32 weights of 6 bits", func() {
+		Expect(p.WeightConfig()).To(Equal(PerceptronWeightConfig{
+			NumWeights: 32,
+			Bits:       6,
+			Min:        -32,
+			Max:        31,
+		}))
+	})
+
+	It("should saturate at the range of the width", func() {
+		p.SetWeightConfig(PerceptronWeightConfig{Bits: 4})
+
+		for i := 0; i < 20; i++ {
+			p.TrainOnEviction(0x10001)
+		}
+
+		Expect(p.Weights()[0]).To(Equal(int32(7)))
+
+		for i := 0; i < 20; i++ {
+			p.TrainOnHit(0x10001)
+		}
+
+		Expect(p.Weights()[0]).To(Equal(int32(-8)))
+	})
+
+	It("should saturate at an explicit range", func() {
+		p.SetWeightConfig(PerceptronWeightConfig{Min: -3, Max: 100})
+
+		for i := 0; i < 20; i++ {
+			p.TrainOnHit(0x1)
+		}
+
+		Expect(p.Weights()[0]).To(Equal(int32(-3)))
+	})
+
+	It("should only use the configured number of weights", func() {
+		p.SetWeightConfig(PerceptronWeightConfig{NumWeights: 8})
+
+		// Bit 5 of the PC proxy and bit 21 of the address are beyond the
+		// four PC and four tag weights.
+		for i := 0; i < 4; i++ {
+			p.TrainOnEviction(0x200020)
+		}
+
+		Expect(p.Weights()).To(Equal([MaxPerceptronWeights]int32{}))
+		Expect(p.predictionSum(0x200020, 0)).To(BeZero())
+
+		p.TrainOnEviction(0x10001)
+
+		Expect(p.Weights()[0]).To(Equal(int32(2)))
+		Expect(p.Weights()[4]).To(Equal(int32(2)))
+	})
+
+	It("should reject invalid configurations", func() {
+		Expect(func() {
+			p.SetWeightConfig(PerceptronWeightConfig{NumWeights: 7})
+		}).To(Panic())
+		Expect(func() {
+			p.SetWeightConfig(PerceptronWeightConfig{Bits: 1})
+		}).To(Panic())
+		Expect(func() {
+			p.SetWeightConfig(PerceptronWeightConfig{Min: 2, Max: 4})
+		}).To(Panic())
+	})
+})
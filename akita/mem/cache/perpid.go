@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// pidWeights is the weight state of one process.
+type pidWeights struct {
+	weights [MaxPerceptronWeights]int32
+	tables  [][PerceptronTableSize]int32
+	lastUse uint64
+}
+
+// pidWeightTables keeps a weight vector and a set of hashed tables per
+// process. The state of the active process lives in the perceptron itself and
+// is stored back when another process becomes active. When there are more
+// processes than tables, the least recently used tables are dropped.
+type pidWeightTables struct {
+	max       int
+	byPID     map[vm.PID]*pidWeights
+	active    vm.PID
+	hasActive bool
+	now       uint64
+	evictions uint64
+}
+
+// EnablePerPIDWeights gives every process its own weights, so that the
+// access pattern of one process does not train the predictions of another.
+// At most maxTables processes keep their weights; the weights of the least
+// recently active process are dropped to make room for a new one. The
+// weights learned so far are discarded, and every process starts from zero.
+//
+// The process is taken from VictimContext.PID by FindVictimWithContext,
+// TrainOnHitWithContext, and TrainOnEvictionWithContext. The other training
+// methods train the weights of the last of those processes.
+func (p *PerceptronVictimFinder) EnablePerPIDWeights(maxTables int) {
+	if maxTables <= 0 {
+		panic(fmt.Sprintf("number of weight tables %d must be positive",
+			maxTables))
+	}
+
+	p.perPID = &pidWeightTables{
+		max:   maxTables,
+		byPID: make(map[vm.PID]*pidWeights),
+	}
+
+	p.SetWeightConfig(p.weightConfig)
+}
+
+// NumPIDWeightTables returns the number of processes that currently have
+// their own weights, or 0 if per-PID weights are disabled.
+func (p *PerceptronVictimFinder) NumPIDWeightTables() int {
+	if p.perPID == nil {
+		return 0
+	}
+
+	return len(p.perPID.byPID)
+}
+
+// PIDWeightTableEvictions returns the number of times that the weights of a
+// process were dropped to make room for another process.
+func (p *PerceptronVictimFinder) PIDWeightTableEvictions() uint64 {
+	if p.perPID == nil {
+		return 0
+	}
+
+	return p.perPID.evictions
+}
+
+// usePIDWeights makes the weights of the process active. It does nothing if
+// per-PID weights are disabled.
+func (p *PerceptronVictimFinder) usePIDWeights(pid vm.PID) {
+	t := p.perPID
+	if t == nil {
+		return
+	}
+
+	t.now++
+
+	if t.hasActive && t.active == pid {
+		t.byPID[pid].lastUse = t.now
+		return
+	}
+
+	if t.hasActive {
+		active := t.byPID[t.active]
+		active.weights = p.weights
+		active.tables = p.tables
+	}
+
+	entry, ok := t.byPID[pid]
+	if !ok {
+		if len(t.byPID) >= t.max {
+			t.evictLRU()
+		}
+
+		entry = &pidWeights{
+			tables: make([][PerceptronTableSize]int32, len(p.tables)),
+		}
+		t.byPID[pid] = entry
+	}
+
+	entry.lastUse = t.now
+	p.weights = entry.weights
+	p.tables = entry.tables
+	t.active = pid
+	t.hasActive = true
+
+	p.invalidatePredictions()
+}
+
+// activePID returns the process whose weights are active.
+func (p *PerceptronVictimFinder) activePID() vm.PID {
+	if p.perPID == nil {
+		return 0
+	}
+
+	return p.perPID.active
+}
+
+func (t *pidWeightTables) evictLRU() {
+	var (
+		victim vm.PID
+		oldest *pidWeights
+	)
+
+	for pid, entry := range t.byPID {
+		if oldest == nil || entry.lastUse < oldest.lastUse {
+			victim, oldest = pid, entry
+		}
+	}
+
+	delete(t.byPID, victim)
+	t.evictions++
+
+	if t.hasActive && t.active == victim {
+		t.hasActive = false
+	}
+}
+
+// mapInactive replaces the weights of the processes that are not active with
+// the result of f.
+func (t *pidWeightTables) mapInactive(f func(int32) int32) {
+	for pid, entry := range t.byPID {
+		if t.hasActive && pid == t.active {
+			continue
+		}
+
+		for i := range entry.weights {
+			entry.weights[i] = f(entry.weights[i])
+		}
+
+		for i := range entry.tables {
+			for j := range entry.tables[i] {
+				entry.tables[i][j] = f(entry.tables[i][j])
+			}
+		}
+	}
+}
+
+// TrainOnHitWithContext trains the weights of the process of the context on a
+// hit by the access.
+func (p *PerceptronVictimFinder) TrainOnHitWithContext(ctx *VictimContext) {
+	p.usePIDWeights(ctx.PID)
+	p.featureContext = ctx
+	p.TrainOnHitWithPC(ctx.Address, ctx.PC)
+	p.featureContext = nil
+}
+
+// TrainOnEvictionWithContext trains the weights of the process of the context
+// on the eviction of the line at the address of the context.
+func (p *PerceptronVictimFinder) TrainOnEvictionWithContext(ctx *VictimContext) {
+	p.usePIDWeights(ctx.PID)
+	p.featureContext = ctx
+	p.TrainOnEvictionWithPC(ctx.Address, ctx.PC)
+	p.featureContext = nil
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+var _ = Describe("Per-PID perceptron weights", func() {
+	var p *PerceptronVictimFinder
+
+	evict := func(pid vm.PID, addr uint64) {
+		p.TrainOnEvictionWithContext(&VictimContext{Address: addr, PID: pid})
+	}
+
+	weightsOf := func(pid vm.PID) [MaxPerceptronWeights]int32 {
+		p.usePIDWeights(pid)
+		return p.Weights()
+	}
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		p.SetHashedTables(false)
+		p.EnablePerPIDWeights(2)
+	})
+
+	It("should keep the training of each process separate", func() {
+		for i := 0; i < 4; i++ {
+			evict(1, 0x10001)
+		}
+
+		Expect(weightsOf(2)).To(Equal([MaxPerceptronWeights]int32{}))
+		Expect(weightsOf(1)[0]).To(Equal(int32(8)))
+		Expect(p.NumPIDWeightTables()).To(Equal(2))
+	})
+
+	It("should predict with the weights of the process", func() {
+		for i := 0; i < 8; i++ {
+			evict(1, 0x10001)
+		}
+
+		set := &Set{}
+		for i := 0; i < 2; i++ {
+			set.Blocks = append(set.Blocks, &Block{IsValid: true, WayID: i})
+		}
+
+		p.FindVictimWithContext(set, &VictimContext{Address: 0x10001, PID: 1})
+		sum, ok := p.predictions.lookup(0x10001, 0)
+		Expect(ok).To(BeTrue())
+		Expect(sum).To(BeNumerically(">", 0))
+
+		p.FindVictimWithContext(set, &VictimContext{Address: 0x10001, PID: 2})
+		sum, ok = p.predictions.lookup(0x10001, 0)
+		Expect(ok).To(BeTrue())
+		Expect(sum).To(BeZero())
+	})
+
+	It("should drop the weights of the least recently active process", func() {
+		evict(1, 0x10001)
+		evict(2, 0x10001)
+		evict(1, 0x10001)
+		evict(3, 0x10001)
+
+		Expect(p.NumPIDWeightTables()).To(Equal(2))
+		Expect(p.PIDWeightTableEvictions()).To(Equal(uint64(1)))
+		Expect(weightsOf(1)[0]).To(Equal(int32(4)))
+		Expect(weightsOf(2)).To(Equal([MaxPerceptronWeights]int32{}))
+	})
+
+	It("should decay the weights of every process", func() {
+		for i := 0; i < 4; i++ {
+			evict(1, 0x10001)
+			evict(2, 0x10001)
+		}
+
+		p.DecayWeights(1)
+
+		Expect(weightsOf(1)[0]).To(Equal(int32(4)))
+		Expect(weightsOf(2)[0]).To(Equal(int32(4)))
+	})
+})
@@ -0,0 +1,92 @@
+package cache
+
+import "github.com/sarchlab/akita/v4/mem/vm"
+
+// A Pinner can pin cache lines so that they are never evicted.
+type Pinner interface {
+	// Pin marks the resident line as non-evictable and reports whether it
+	// did.
+	Pin(pid vm.PID, addr uint64) bool
+
+	// Unpin makes the line evictable again and reports whether it was
+	// pinned.
+	Unpin(pid vm.PID, addr uint64) bool
+}
+
+// isPinned returns true if the block holds a pinned line. Invalidating a
+// pinned block releases the pin.
+func isPinned(block *Block) bool {
+	return block.IsPinned && block.IsValid
+}
+
+// Pin marks the resident line at addr as non-evictable. Unlike IsLocked,
+// which the controller holds while a transaction is in flight, the pin lasts
+// until Unpin. Pin refuses to pin the last unpinned way of a set, so that
+// every set can still take fills. It returns false if the line is not
+// resident or cannot be pinned.
+func (d *DirectoryImpl) Pin(pid vm.PID, addr uint64) bool {
+	block := d.findBlock(pid, addr)
+	if block == nil {
+		return false
+	}
+
+	if block.IsPinned {
+		return true
+	}
+
+	set, _ := d.getSet(addr)
+	if d.PinnedWays(addr) >= len(set.Blocks)-1 {
+		return false
+	}
+
+	block.IsPinned = true
+
+	return true
+}
+
+// Unpin makes the line at addr evictable again. It returns false if the line
+// is not resident or not pinned.
+func (d *DirectoryImpl) Unpin(pid vm.PID, addr uint64) bool {
+	block := d.findBlock(pid, addr)
+	if block == nil || !block.IsPinned {
+		return false
+	}
+
+	block.IsPinned = false
+
+	return true
+}
+
+// PinnedWays returns the number of pinned blocks in the set of addr.
+func (d *DirectoryImpl) PinnedWays(addr uint64) int {
+	set, _ := d.getSet(addr)
+
+	n := 0
+	for _, block := range set.Blocks {
+		if isPinned(block) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// applyPinning replaces a pinned victim with the highest ranked unpinned
+// candidate. It returns nil if there is none.
+func (d *DirectoryImpl) applyPinning(
+	vf VictimFinder,
+	set *Set,
+	context *VictimContext,
+	victim *Block,
+) *Block {
+	if victim == nil || !isPinned(victim) {
+		return victim
+	}
+
+	candidates := FindVictims(vf, set, context, 1)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return candidates[0]
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pinning", func() {
+	var d *DirectoryImpl
+
+	fill := func(addr uint64) *Block {
+		block := d.FindVictim(addr)
+		block.Tag = addr
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 4, 64, NewLRUVictimFinder())
+		for i := uint64(0); i < 4; i++ {
+			fill(i * 64)
+		}
+	})
+
+	It("should never evict a pinned line", func() {
+		Expect(d.FindVictim(0x1000).WayID).To(Equal(0))
+
+		Expect(d.Pin(0, 0)).To(BeTrue())
+		Expect(d.FindVictim(0x1000).WayID).To(Equal(1))
+
+		Expect(d.Unpin(0, 0)).To(BeTrue())
+		Expect(d.FindVictim(0x1000).WayID).To(Equal(0))
+	})
+
+	It("should keep pins apart from transaction locks", func() {
+		Expect(d.Pin(0, 0)).To(BeTrue())
+		d.Sets[0].Blocks[1].IsLocked = true
+
+		Expect(d.FindVictim(0x1000).WayID).To(Equal(2))
+		Expect(d.Sets[0].Blocks[0].IsLocked).To(BeFalse())
+	})
+
+	It("should keep one way of every set unpinned", func() {
+		Expect(d.Pin(0, 0)).To(BeTrue())
+		Expect(d.Pin(0, 64)).To(BeTrue())
+		Expect(d.Pin(0, 128)).To(BeTrue())
+		Expect(d.Pin(0, 192)).To(BeFalse())
+		Expect(d.PinnedWays(0)).To(Equal(3))
+
+		Expect(d.FindVictim(0x1000).WayID).To(Equal(3))
+	})
+
+	It("should not pin lines that are not resident", func() {
+		Expect(d.Pin(0, 0x1000)).To(BeFalse())
+		Expect(d.Unpin(0, 0)).To(BeFalse())
+	})
+
+	It("should release the pin when the line is invalidated", func() {
+		Expect(d.Pin(0, 0)).To(BeTrue())
+		d.Sets[0].Blocks[0].IsValid = false
+
+		block := fill(0x1000)
+		Expect(block.WayID).To(Equal(0))
+		Expect(block.IsPinned).To(BeFalse())
+	})
+})
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"fmt"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// recencyReference tracks the exact recency order of the lines resident in a
+// directory, so that the PseudoLRU victim can be compared with the true LRU
+// victim of the same contents.
+type recencyReference struct {
+	now    uint64
+	stamps [][]uint64
+}
+
+func newRecencyReference(numSets, numWays int) *recencyReference {
+	r := &recencyReference{stamps: make([][]uint64, numSets)}
+	for i := range r.stamps {
+		r.stamps[i] = make([]uint64, numWays)
+	}
+
+	return r
+}
+
+func (r *recencyReference) touch(b *Block) {
+	r.now++
+	r.stamps[b.SetID][b.WayID] = r.now
+}
+
+// rank returns the position of the way in the recency order of the set, from
+// 0 for the least recently used way to numWays-1 for the most recently used.
+func (r *recencyReference) rank(setID, way int) int {
+	rank := 0
+	for _, s := range r.stamps[setID] {
+		if s < r.stamps[setID][way] {
+			rank++
+		}
+	}
+
+	return rank
+}
+
+// plruDifferential is the outcome of replaying one trace.
+type plruDifferential struct {
+	fullSetMisses uint64
+	divergences   uint64
+	mruVictims    uint64
+}
+
+func (d plruDifferential) divergenceRate() float64 {
+	if d.fullSetMisses == 0 {
+		return 0
+	}
+
+	return float64(d.divergences) / float64(d.fullSetMisses)
+}
+
+// replayLRUDifferential replays the trace through a directory that uses the
+// victim finder and compares every victim taken from a full set with the
+// exact LRU way.
+func replayLRUDifferential(
+	vf VictimFinder,
+	numSets, numWays int,
+	trace []uint64,
+) plruDifferential {
+	var result plruDifferential
+
+	d := NewDirectory(numSets, numWays, 64, vf)
+	ref := newRecencyReference(numSets, numWays)
+
+	for _, addr := range trace {
+		if b := d.Lookup(1, addr); b != nil {
+			d.Visit(b)
+			ref.touch(b)
+
+			continue
+		}
+
+		victim := d.FindVictim(addr)
+		if victim.IsValid {
+			result.fullSetMisses++
+
+			switch ref.rank(victim.SetID, victim.WayID) {
+			case 0:
+			case numWays - 1:
+				result.mruVictims++
+				result.divergences++
+			default:
+				result.divergences++
+			}
+		}
+
+		victim.PID, victim.Tag, victim.IsValid = 1, addr, true
+		d.Visit(victim)
+		ref.touch(victim)
+	}
+
+	return result
+}
+
+func differentialTraces(numLines int) map[string][]uint64 {
+	r := rand.New(rand.NewSource(1))
+	traces := map[string][]uint64{}
+
+	random := make([]uint64, 4000)
+	for i := range random {
+		random[i] = uint64(r.Intn(4*numLines)) * 64
+	}
+	traces["random"] = random
+
+	var cyclic, strided, hotCold []uint64
+	for i := 0; i < 4000; i++ {
+		cyclic = append(cyclic, uint64(i%(numLines+1))*64)
+		strided = append(strided, uint64(i*3%(2*numLines))*64)
+
+		if r.Intn(4) == 0 {
+			hotCold = append(hotCold, uint64(numLines+r.Intn(8*numLines))*64)
+		} else {
+			hotCold = append(hotCold, uint64(r.Intn(numLines/2))*64)
+		}
+	}
+	traces["cyclic"] = cyclic
+	traces["strided"] = strided
+	traces["hot-cold"] = hotCold
+
+	return traces
+}
+
+var _ = Describe("PseudoLRU differential", func() {
+	const numSets = 4
+
+	for _, numWays := range []int{2, 4, 5, 7, 8, 16, 32} {
+		numWays := numWays
+
+		It(fmt.Sprintf("should never evict the MRU way with %d ways", numWays),
+			func() {
+				traces := differentialTraces(numSets * numWays)
+				for _, name := range []string{
+					"random", "cyclic", "strided", "hot-cold",
+				} {
+					result := replayLRUDifferential(NewLRUVictimFinder(),
+						numSets, numWays, traces[name])
+
+					AddReportEntry(
+						fmt.Sprintf("%d-way %s divergence", numWays, name),
+						result.divergenceRate())
+
+					Expect(result.fullSetMisses).To(BeNumerically(">", 0))
+					Expect(result.mruVictims).To(BeZero(), name)
+					if numWays == 2 {
+						Expect(result.divergences).To(BeZero(), name)
+					}
+				}
+			})
+	}
+})
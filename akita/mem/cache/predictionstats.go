@@ -0,0 +1,172 @@
+package cache
+
+// defaultStatsWindow is the number of outcomes in the accuracy window.
+const defaultStatsWindow = 1024
+
+// PredictionStats breaks down the predictions of a perceptron and their
+// outcomes. Predictions are counted when a victim is selected, outcomes when
+// the predictor trains on a hit or an eviction. A positive prediction is a
+// prediction of no reuse, that is, of a dead line.
+type PredictionStats struct {
+	Predictions          uint64 // Predictions made to select a victim
+	ConfidentPredictions uint64 // Predictions whose magnitude reached theta
+
+	Outcomes       uint64 // Training outcomes
+	TruePositives  uint64 // Predicted dead, not reused
+	TrueNegatives  uint64 // Predicted live, reused
+	FalsePositives uint64 // Predicted dead, reused
+	FalseNegatives uint64 // Predicted live, not reused
+}
+
+// Accuracy returns the fraction of the outcomes that were predicted
+// correctly, or 0 if there are none.
+func (s PredictionStats) Accuracy() float64 {
+	return ratio(s.TruePositives+s.TrueNegatives, s.Outcomes)
+}
+
+// Coverage returns the fraction of the predictions that were confident
+// enough to override PseudoLRU, or 0 if there are none.
+func (s PredictionStats) Coverage() float64 {
+	return ratio(s.ConfidentPredictions, s.Predictions)
+}
+
+// FalsePositiveRate returns the fraction of the reused lines that were
+// predicted dead.
+func (s PredictionStats) FalsePositiveRate() float64 {
+	return ratio(s.FalsePositives, s.FalsePositives+s.TrueNegatives)
+}
+
+// FalseNegativeRate returns the fraction of the dead lines that were
+// predicted live.
+func (s PredictionStats) FalseNegativeRate() float64 {
+	return ratio(s.FalseNegatives, s.FalseNegatives+s.TruePositives)
+}
+
+func ratio(n, d uint64) float64 {
+	if d == 0 {
+		return 0
+	}
+
+	return float64(n) / float64(d)
+}
+
+// A PhaseStats holds the statistics of one phase, such as a kernel.
+type PhaseStats struct {
+	Name  string
+	Stats PredictionStats
+}
+
+// accuracyWindow remembers whether the last outcomes were predicted
+// correctly.
+type accuracyWindow struct {
+	correct []bool
+	next    int
+	filled  int
+	hits    int
+}
+
+func newAccuracyWindow(size int) *accuracyWindow {
+	return &accuracyWindow{correct: make([]bool, size)}
+}
+
+func (w *accuracyWindow) record(correct bool) {
+	if w.filled == len(w.correct) {
+		if w.correct[w.next] {
+			w.hits--
+		}
+	} else {
+		w.filled++
+	}
+
+	w.correct[w.next] = correct
+	if correct {
+		w.hits++
+	}
+
+	w.next = (w.next + 1) % len(w.correct)
+}
+
+func (w *accuracyWindow) accuracy() float64 {
+	return ratio(uint64(w.hits), uint64(w.filled))
+}
+
+// predictionStatsState is the statistics state of a perceptron.
+type predictionStatsState struct {
+	current PredictionStats
+	window  *accuracyWindow
+	phases  []PhaseStats
+}
+
+func (s *predictionStatsState) ensureWindow() {
+	if s.window == nil {
+		s.window = newAccuracyWindow(defaultStatsWindow)
+	}
+}
+
+func (s *predictionStatsState) recordPrediction(confident bool) {
+	s.current.Predictions++
+	if confident {
+		s.current.ConfidentPredictions++
+	}
+}
+
+func (s *predictionStatsState) recordOutcome(predictedDead, reused bool) {
+	s.ensureWindow()
+
+	c := &s.current
+	c.Outcomes++
+
+	switch {
+	case predictedDead && !reused:
+		c.TruePositives++
+	case !predictedDead && reused:
+		c.TrueNegatives++
+	case predictedDead:
+		c.FalsePositives++
+	default:
+		c.FalseNegatives++
+	}
+
+	s.window.record(predictedDead != reused)
+}
+
+// PredictionStats returns the statistics since the last reset or phase.
+func (p *PerceptronVictimFinder) PredictionStats() PredictionStats {
+	return p.stats.current
+}
+
+// SetStatsWindow sets the number of recent outcomes covered by
+// WindowedAccuracy. The default is 1024. The window starts empty.
+func (p *PerceptronVictimFinder) SetStatsWindow(size int) {
+	if size <= 0 {
+		panic("stats window size must be positive")
+	}
+
+	p.stats.window = newAccuracyWindow(size)
+}
+
+// WindowedAccuracy returns the accuracy of the outcomes in the window, which
+// follows phase behavior that the lifetime accuracy hides.
+func (p *PerceptronVictimFinder) WindowedAccuracy() float64 {
+	if p.stats.window == nil {
+		return 0
+	}
+
+	return p.stats.window.accuracy()
+}
+
+// EndPhase records the statistics of the phase that ends under the given
+// name, and resets the statistics, including the window, for the next phase.
+func (p *PerceptronVictimFinder) EndPhase(name string) {
+	p.stats.phases = append(p.stats.phases, PhaseStats{
+		Name:  name,
+		Stats: p.stats.current,
+	})
+
+	p.ResetStats()
+}
+
+// Phases returns the statistics of the phases ended so far.
+func (p *PerceptronVictimFinder) Phases() []PhaseStats {
+	return p.stats.phases
+}
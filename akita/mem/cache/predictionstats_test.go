@@ -0,0 +1,95 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PredictionStats", func() {
+	var p *PerceptronVictimFinder
+
+	BeforeEach(func() {
+		// With zero weights and no learning, every line is predicted dead.
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		p.SetTrainingSampleInterval(1)
+		p.SetInferenceOnly(true)
+	})
+
+	It("should break down the outcomes", func() {
+		p.TrainOnHit(0x40)
+		p.TrainOnEviction(0x80)
+		p.TrainOnEviction(0xc0)
+		p.TrainOnEviction(0x100)
+
+		s := p.PredictionStats()
+		Expect(s.Outcomes).To(Equal(uint64(4)))
+		Expect(s.FalsePositives).To(Equal(uint64(1)))
+		Expect(s.TruePositives).To(Equal(uint64(3)))
+		Expect(s.FalseNegatives).To(BeZero())
+		Expect(s.Accuracy()).To(Equal(0.75))
+		Expect(s.FalsePositiveRate()).To(Equal(1.0))
+		Expect(s.FalseNegativeRate()).To(BeZero())
+	})
+
+	It("should count the confident predictions", func() {
+		p.SetHashedTables(false)
+		set := makeTestSet(4)
+		p.FindVictimWithContext(set, &VictimContext{Address: 0x40})
+
+		var w [MaxPerceptronWeights]int32
+		w[0] = 64
+		p.SetWeights(w)
+		p.FindVictimWithContext(set, &VictimContext{Address: 1 << p.featureShift})
+
+		s := p.PredictionStats()
+		Expect(s.Predictions).To(Equal(uint64(2)))
+		Expect(s.ConfidentPredictions).To(Equal(uint64(1)))
+		Expect(s.Coverage()).To(Equal(0.5))
+	})
+
+	It("should report the accuracy of the recent outcomes", func() {
+		p.SetStatsWindow(2)
+
+		p.TrainOnHit(0x40)
+		p.TrainOnHit(0x40)
+		Expect(p.WindowedAccuracy()).To(BeZero())
+
+		p.TrainOnEviction(0x40)
+		Expect(p.WindowedAccuracy()).To(Equal(0.5))
+
+		p.TrainOnEviction(0x40)
+		Expect(p.WindowedAccuracy()).To(Equal(1.0))
+		Expect(p.PredictionStats().Accuracy()).To(Equal(0.5))
+	})
+
+	It("should record the statistics of every phase", func() {
+		p.TrainOnEviction(0x40)
+		p.EndPhase("k0")
+		p.TrainOnHit(0x40)
+		p.EndPhase("k1")
+
+		phases := p.Phases()
+		Expect(phases).To(HaveLen(2))
+		Expect(phases[0].Name).To(Equal("k0"))
+		Expect(phases[0].Stats.Accuracy()).To(Equal(1.0))
+		Expect(phases[1].Name).To(Equal("k1"))
+		Expect(phases[1].Stats.Accuracy()).To(BeZero())
+		Expect(p.PredictionStats()).To(Equal(PredictionStats{}))
+		Expect(p.WindowedAccuracy()).To(BeZero())
+	})
+
+	It("should end a phase at a kernel boundary", func() {
+		d := NewDirectory(4, 4, 64, p)
+		h := NewKernelBoundaryHooks(d, KernelBoundaryPolicy{
+			OnComplete: KernelBoundaryAction{EndPhase: true},
+		})
+
+		p.TrainOnEviction(0x40)
+		h.KernelCompleted("k0")
+
+		Expect(p.Phases()).To(HaveLen(1))
+		Expect(p.Phases()[0].Name).To(Equal("k0"))
+		Expect(p.Phases()[0].Stats.Outcomes).To(Equal(uint64(1)))
+	})
+})
@@ -0,0 +1,95 @@
+package cache
+
+import "fmt"
+
+// defaultPredictionTableSize is the number of entries of the prediction
+// table.
+const defaultPredictionTableSize = 64
+
+// predictionEntry is a prediction sum computed with the weights of an epoch.
+type predictionEntry struct {
+	addr  uint64
+	pc    uint64
+	sum   int32
+	epoch uint64
+	valid bool
+}
+
+// predictionTable remembers the sums of recent predictions so that training
+// on the outcome does not compute them again. It is direct-mapped. The epoch
+// advances whenever the weights or the features change, which invalidates
+// every entry at once.
+type predictionTable struct {
+	entries []predictionEntry
+	epoch   uint64
+	hits    uint64
+	misses  uint64
+}
+
+func newPredictionTable(size int) predictionTable {
+	return predictionTable{entries: make([]predictionEntry, size)}
+}
+
+func (t *predictionTable) index(addr, pc uint64) int {
+	return int(mixLineHash(addr^pc<<32) % uint64(len(t.entries)))
+}
+
+// lookup returns the sum of the prediction for the access, if it is still
+// valid.
+func (t *predictionTable) lookup(addr, pc uint64) (int32, bool) {
+	if len(t.entries) == 0 {
+		return 0, false
+	}
+
+	e := &t.entries[t.index(addr, pc)]
+	if !e.valid || e.epoch != t.epoch || e.addr != addr || e.pc != pc {
+		t.misses++
+		return 0, false
+	}
+
+	t.hits++
+
+	return e.sum, true
+}
+
+func (t *predictionTable) store(addr, pc uint64, sum int32) {
+	if len(t.entries) == 0 {
+		return
+	}
+
+	t.entries[t.index(addr, pc)] = predictionEntry{
+		addr:  addr,
+		pc:    pc,
+		sum:   sum,
+		epoch: t.epoch,
+		valid: true,
+	}
+}
+
+// invalidatePredictions forgets the cached prediction sums. It must be
+// called whenever the weights, the bias, or the features change.
+func (p *PerceptronVictimFinder) invalidatePredictions() {
+	p.predictions.epoch++
+}
+
+// SetPredictionTableSize sets the number of entries of the table that caches
+// the prediction sums for training. A size of 0 disables the table, so that
+// every training computes the sum again. The cached sums are dropped.
+func (p *PerceptronVictimFinder) SetPredictionTableSize(n int) {
+	if n < 0 {
+		panic(fmt.Sprintf("prediction table size %d is negative", n))
+	}
+
+	p.predictions = newPredictionTable(n)
+}
+
+// PredictionTableSize returns the number of entries of the prediction table.
+func (p *PerceptronVictimFinder) PredictionTableSize() int {
+	return len(p.predictions.entries)
+}
+
+// PredictionTableHits returns the number of trainings that reused a cached
+// prediction sum, and the number that had to compute it.
+func (p *PerceptronVictimFinder) PredictionTableHits() (hits, misses uint64) {
+	return p.predictions.hits, p.predictions.misses
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prediction table", func() {
+	var (
+		p   *PerceptronVictimFinder
+		set *Set
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetTrainingSampleInterval(1)
+		set = makeTestSet(2)
+	})
+
+	predict := func(addr uint64) {
+		p.FindVictimWithContext(set, &VictimContext{Address: addr})
+	}
+
+	It("should reuse the sums of several recent predictions", func() {
+		p.SetInferenceOnly(true)
+
+		predict(0x1000)
+		predict(0x2000)
+		p.TrainOnHit(0x1000)
+		p.TrainOnHit(0x2000)
+
+		hits, misses := p.PredictionTableHits()
+		Expect(hits).To(Equal(uint64(2)))
+		Expect(misses).To(BeZero())
+	})
+
+	It("should drop the sums when the weights change", func() {
+		predict(0x1000)
+		predict(0x2000)
+		p.TrainOnEviction(0x1000)
+		p.TrainOnEviction(0x2000)
+
+		hits, misses := p.PredictionTableHits()
+		Expect(hits).To(Equal(uint64(1)))
+		Expect(misses).To(Equal(uint64(1)))
+	})
+
+	It("should never train with a stale sum", func() {
+		var w [MaxPerceptronWeights]int32
+		predict(0xffff0000)
+
+		w[0] = 50
+		p.SetWeights(w)
+
+		Expect(p.trainingSum(0xffff0000, 0)).
+			To(Equal(p.calculatePredictionSum(0xffff0000, 0)))
+	})
+
+	It("should compute every sum when disabled", func() {
+		p.SetPredictionTableSize(0)
+		p.SetInferenceOnly(true)
+
+		predict(0x1000)
+		p.TrainOnHit(0x1000)
+
+		Expect(p.PredictionTableSize()).To(BeZero())
+		hits, _ := p.PredictionTableHits()
+		Expect(hits).To(BeZero())
+	})
+})
@@ -0,0 +1,342 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// A PredictionQuery describes a cache line whose reuse is to be predicted.
+type PredictionQuery struct {
+	PID        vm.PID `json:"pid"`
+	Address    uint64 `json:"address"`
+	AccessType string `json:"access_type,omitempty"`
+}
+
+// A PredictionOutcome is the observed reuse of a line, used for training.
+type PredictionOutcome struct {
+	PID     vm.PID `json:"pid"`
+	Address uint64 `json:"address"`
+	Reused  bool   `json:"reused"`
+}
+
+// A PredictorBackend serves reuse predictions. Predict returns one score per
+// query; a higher score means the line is less likely to be reused.
+type PredictorBackend interface {
+	Predict(queries []PredictionQuery) ([]float64, error)
+	Train(outcomes []PredictionOutcome) error
+	Close() error
+}
+
+// predictorMessage is one line of the stream protocol.
+type predictorMessage struct {
+	Op       string              `json:"op"`
+	Queries  []PredictionQuery   `json:"queries,omitempty"`
+	Outcomes []PredictionOutcome `json:"outcomes,omitempty"`
+}
+
+// predictorResponse is the reply to a predictorMessage.
+type predictorResponse struct {
+	Scores []float64 `json:"scores,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// A StreamPredictorBackend talks to a predictor over a pair of streams with a
+// line-delimited JSON protocol. Every request is one JSON object on a line:
+//
+//	{"op": "predict", "queries": [{"pid": 1, "address": 4096}, ...]}
+//	{"op": "train", "outcomes": [{"pid": 1, "address": 4096, "reused": true}]}
+//	{"op": "close"}
+//
+// and is answered by one line {"scores": [...]} or {"error": "..."}. A
+// predictor written in any language can implement the protocol on its
+// standard input and output.
+type StreamPredictorBackend struct {
+	enc *json.Encoder
+	dec *json.Decoder
+}
+
+// NewStreamPredictorBackend creates a backend that writes requests to w and
+// reads responses from r.
+func NewStreamPredictorBackend(
+	r io.Reader,
+	w io.Writer,
+) *StreamPredictorBackend {
+	return &StreamPredictorBackend{
+		enc: json.NewEncoder(w),
+		dec: json.NewDecoder(bufio.NewReader(r)),
+	}
+}
+
+func (b *StreamPredictorBackend) call(msg predictorMessage) ([]float64, error) {
+	if err := b.enc.Encode(msg); err != nil {
+		return nil, err
+	}
+
+	var rsp predictorResponse
+	if err := b.dec.Decode(&rsp); err != nil {
+		return nil, err
+	}
+
+	if rsp.Error != "" {
+		return nil, errors.New(rsp.Error)
+	}
+
+	return rsp.Scores, nil
+}
+
+// Predict sends the queries as one batch.
+func (b *StreamPredictorBackend) Predict(
+	queries []PredictionQuery,
+) ([]float64, error) {
+	scores, err := b.call(predictorMessage{Op: "predict", Queries: queries})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scores) != len(queries) {
+		return nil, fmt.Errorf("predictor returned %d scores for %d queries",
+			len(scores), len(queries))
+	}
+
+	return scores, nil
+}
+
+// Train sends the outcomes as one batch.
+func (b *StreamPredictorBackend) Train(outcomes []PredictionOutcome) error {
+	_, err := b.call(predictorMessage{Op: "train", Outcomes: outcomes})
+	return err
+}
+
+// Close tells the predictor that no more requests follow.
+func (b *StreamPredictorBackend) Close() error {
+	_, err := b.call(predictorMessage{Op: "close"})
+	return err
+}
+
+// A ProcessPredictorBackend runs a predictor as a child process that speaks
+// the StreamPredictorBackend protocol on its standard input and output.
+type ProcessPredictorBackend struct {
+	*StreamPredictorBackend
+
+	cmd *exec.Cmd
+}
+
+// StartProcessPredictorBackend starts the command and connects to it.
+func StartProcessPredictorBackend(
+	name string,
+	args ...string,
+) (*ProcessPredictorBackend, error) {
+	cmd := exec.Command(name, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &ProcessPredictorBackend{
+		StreamPredictorBackend: NewStreamPredictorBackend(stdout, stdin),
+		cmd:                    cmd,
+	}, nil
+}
+
+// Close ends the session and waits for the process to exit.
+func (b *ProcessPredictorBackend) Close() error {
+	err := b.StreamPredictorBackend.Close()
+	if waitErr := b.cmd.Wait(); err == nil {
+		err = waitErr
+	}
+
+	return err
+}
+
+// ServePredictor answers StreamPredictorBackend requests read from r with the
+// backend until a close request or the end of the input. It allows Go
+// predictors to run out of process and serves as a reference implementation
+// of the protocol.
+func ServePredictor(r io.Reader, w io.Writer, backend PredictorBackend) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	enc := json.NewEncoder(w)
+
+	for {
+		var msg predictorMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		var rsp predictorResponse
+
+		var err error
+
+		switch msg.Op {
+		case "predict":
+			rsp.Scores, err = backend.Predict(msg.Queries)
+		case "train":
+			err = backend.Train(msg.Outcomes)
+		case "close":
+			err = backend.Close()
+		default:
+			err = fmt.Errorf("unknown op %q", msg.Op)
+		}
+
+		if err != nil {
+			rsp.Error = err.Error()
+		}
+
+		if err := enc.Encode(rsp); err != nil {
+			return err
+		}
+
+		if msg.Op == "close" {
+			return nil
+		}
+	}
+}
+
+// BackendVictimFinder selects victims with a PredictorBackend. Each victim
+// selection scores all the valid blocks of the set in one batched call, and
+// training outcomes are buffered and sent in batches of TrainBatch.
+//
+// If the backend fails, the finder falls back to PseudoLRU and records the
+// error; see Err.
+type BackendVictimFinder struct {
+	TrainBatch int
+
+	backend  PredictorBackend
+	pending  []PredictionOutcome
+	err      error
+	fallback *LRUVictimFinder
+}
+
+// NewBackendVictimFinder creates a victim finder that consults the backend.
+func NewBackendVictimFinder(backend PredictorBackend) *BackendVictimFinder {
+	return &BackendVictimFinder{
+		TrainBatch: 64,
+		backend:    backend,
+		fallback:   NewLRUVictimFinder(),
+	}
+}
+
+// Err returns the first error returned by the backend.
+func (f *BackendVictimFinder) Err() error {
+	return f.err
+}
+
+func (f *BackendVictimFinder) setErr(err error) {
+	if f.err == nil {
+		f.err = err
+	}
+}
+
+// FindVictim selects the victim without request information.
+func (f *BackendVictimFinder) FindVictim(set *Set) *Block {
+	return f.FindVictimWithContext(set, nil)
+}
+
+// FindVictimWithContext returns an invalid block if there is one, and the
+// valid unlocked block with the highest score otherwise.
+func (f *BackendVictimFinder) FindVictimWithContext(
+	set *Set,
+	context *VictimContext,
+) *Block {
+	for _, block := range set.Blocks {
+		if !block.IsValid && !block.IsLocked {
+			return block
+		}
+	}
+
+	if f.err != nil {
+		return f.fallback.FindVictim(set)
+	}
+
+	accessType := ""
+	if context != nil {
+		accessType = context.AccessType
+	}
+
+	blocks := make([]*Block, 0, len(set.Blocks))
+	queries := make([]PredictionQuery, 0, len(set.Blocks))
+
+	for _, block := range set.Blocks {
+		if block.IsLocked {
+			continue
+		}
+
+		blocks = append(blocks, block)
+		queries = append(queries, PredictionQuery{
+			PID:        block.PID,
+			Address:    block.Tag,
+			AccessType: accessType,
+		})
+	}
+
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	scores, err := f.backend.Predict(queries)
+	if err != nil {
+		f.setErr(err)
+		return f.fallback.FindVictim(set)
+	}
+
+	best := 0
+	for i := range scores {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+
+	return blocks[best]
+}
+
+// Record buffers a training outcome and sends the buffer when it is full.
+func (f *BackendVictimFinder) Record(outcome PredictionOutcome) {
+	f.pending = append(f.pending, outcome)
+	if len(f.pending) >= f.TrainBatch {
+		f.Flush()
+	}
+}
+
+// Flush sends the buffered training outcomes.
+func (f *BackendVictimFinder) Flush() {
+	if len(f.pending) == 0 || f.err != nil {
+		f.pending = f.pending[:0]
+		return
+	}
+
+	if err := f.backend.Train(f.pending); err != nil {
+		f.setErr(err)
+	}
+
+	f.pending = f.pending[:0]
+}
+
+// Close flushes the buffered outcomes and closes the backend.
+func (f *BackendVictimFinder) Close() error {
+	f.Flush()
+
+	if err := f.backend.Close(); err != nil {
+		return err
+	}
+
+	return f.err
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// tagScoreBackend scores lines by their address and counts the calls.
+type tagScoreBackend struct {
+	predictCalls int
+	trained      []PredictionOutcome
+	closed       bool
+}
+
+func (b *tagScoreBackend) Predict(q []PredictionQuery) ([]float64, error) {
+	b.predictCalls++
+
+	scores := make([]float64, len(q))
+	for i := range q {
+		scores[i] = float64(q[i].Address)
+	}
+
+	return scores, nil
+}
+
+func (b *tagScoreBackend) Train(o []PredictionOutcome) error {
+	b.trained = append(b.trained, o...)
+	return nil
+}
+
+func (b *tagScoreBackend) Close() error {
+	b.closed = true
+	return nil
+}
+
+type failingBackend struct{ tagScoreBackend }
+
+func (b *failingBackend) Predict([]PredictionQuery) ([]float64, error) {
+	return nil, errors.New("model crashed")
+}
+
+var _ = Describe("PredictorBackend", func() {
+	var (
+		set *Set
+	)
+
+	BeforeEach(func() {
+		set = makeTestSet(4)
+		for i, b := range set.Blocks {
+			b.IsValid = true
+			b.Tag = []uint64{0x100, 0x400, 0x200, 0x300}[i]
+		}
+	})
+
+	It("should evict the block with the highest score in one call", func() {
+		backend := &tagScoreBackend{}
+		f := NewBackendVictimFinder(backend)
+
+		victim := f.FindVictimWithContext(set, &VictimContext{})
+
+		Expect(victim.Tag).To(Equal(uint64(0x400)))
+		Expect(backend.predictCalls).To(Equal(1))
+	})
+
+	It("should batch training outcomes", func() {
+		backend := &tagScoreBackend{}
+		f := NewBackendVictimFinder(backend)
+		f.TrainBatch = 3
+
+		f.Record(PredictionOutcome{Address: 0x40})
+		f.Record(PredictionOutcome{Address: 0x80})
+		Expect(backend.trained).To(BeEmpty())
+
+		f.Record(PredictionOutcome{Address: 0xc0, Reused: true})
+		Expect(backend.trained).To(HaveLen(3))
+
+		f.Record(PredictionOutcome{Address: 0x100})
+		Expect(f.Close()).To(Succeed())
+		Expect(backend.trained).To(HaveLen(4))
+		Expect(backend.closed).To(BeTrue())
+	})
+
+	It("should fall back to PseudoLRU if the backend fails", func() {
+		f := NewBackendVictimFinder(&failingBackend{})
+
+		victim := f.FindVictim(set)
+
+		Expect(victim).To(BeIdenticalTo(NewLRUVictimFinder().FindVictim(set)))
+		Expect(f.Err()).To(MatchError("model crashed"))
+	})
+
+	It("should talk to an out-of-process predictor over streams", func() {
+		reqR, reqW := io.Pipe()
+		rspR, rspW := io.Pipe()
+		server := &tagScoreBackend{}
+		done := make(chan error)
+		go func() {
+			done <- ServePredictor(reqR, rspW, server)
+		}()
+		f := NewBackendVictimFinder(NewStreamPredictorBackend(rspR, reqW))
+		f.TrainBatch = 1
+
+		victim := f.FindVictim(set)
+		f.Record(PredictionOutcome{Address: 0x400})
+
+		Expect(victim.Tag).To(Equal(uint64(0x400)))
+		Expect(f.Close()).To(Succeed())
+		Expect(<-done).To(Succeed())
+		Expect(server.trained).To(HaveLen(1))
+		Expect(server.closed).To(BeTrue())
+	})
+})
@@ -0,0 +1,79 @@
+package cache
+
+// PredictorLatency defines the cycles that the replacement predictor adds to
+// a cache access.
+type PredictorLatency struct {
+	// Cycles to read the predictor tables, charged on every access.
+	LookupCycles int
+
+	// Cycles to select a victim, charged on every access that needs one.
+	VictimSelectionCycles int
+
+	// If false, the predictor works in parallel with the tag lookup. The
+	// cycles are counted but the access does not wait for them.
+	OnCriticalPath bool
+}
+
+// PredictorLatencyStats counts the predictor work charged by a
+// PredictorTimer.
+type PredictorLatencyStats struct {
+	Lookups          uint64
+	VictimSelections uint64
+
+	// Cycles that the predictor was busy, and the part of them that the
+	// accesses waited for.
+	BusyCycles  uint64
+	StallCycles uint64
+}
+
+// A PredictorTimer charges the predictor latency to cache accesses. A nil
+// PredictorTimer charges nothing.
+type PredictorTimer struct {
+	latency PredictorLatency
+	stats   PredictorLatencyStats
+}
+
+// NewPredictorTimer creates a timer with the given latency.
+func NewPredictorTimer(latency PredictorLatency) *PredictorTimer {
+	return &PredictorTimer{latency: latency}
+}
+
+// Latency returns the latency configuration of the timer.
+func (t *PredictorTimer) Latency() PredictorLatency {
+	return t.latency
+}
+
+// Charge records one access and returns the number of cycles that the access
+// must wait for the predictor.
+func (t *PredictorTimer) Charge(needsVictim bool) int {
+	if t == nil {
+		return 0
+	}
+
+	cycles := t.latency.LookupCycles
+	t.stats.Lookups++
+
+	if needsVictim {
+		cycles += t.latency.VictimSelectionCycles
+		t.stats.VictimSelections++
+	}
+
+	t.stats.BusyCycles += uint64(cycles)
+
+	if !t.latency.OnCriticalPath {
+		return 0
+	}
+
+	t.stats.StallCycles += uint64(cycles)
+
+	return cycles
+}
+
+// Stats returns the counters of the timer.
+func (t *PredictorTimer) Stats() PredictorLatencyStats {
+	if t == nil {
+		return PredictorLatencyStats{}
+	}
+
+	return t.stats
+}
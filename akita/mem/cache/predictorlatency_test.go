@@ -0,0 +1,39 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PredictorTimer", func() {
+	latency := PredictorLatency{LookupCycles: 1, VictimSelectionCycles: 3}
+
+	It("should charge the lookup and the victim selection", func() {
+		onPath := latency
+		onPath.OnCriticalPath = true
+		t := NewPredictorTimer(onPath)
+
+		Expect(t.Charge(false)).To(Equal(1))
+		Expect(t.Charge(true)).To(Equal(4))
+
+		Expect(t.Stats()).To(Equal(PredictorLatencyStats{
+			Lookups: 2, VictimSelections: 1, BusyCycles: 5, StallCycles: 5,
+		}))
+	})
+
+	It("should only count the cycles off the critical path", func() {
+		t := NewPredictorTimer(latency)
+
+		Expect(t.Charge(true)).To(Equal(0))
+
+		Expect(t.Stats().BusyCycles).To(Equal(uint64(4)))
+		Expect(t.Stats().StallCycles).To(BeZero())
+	})
+
+	It("should charge nothing if nil", func() {
+		var t *PredictorTimer
+
+		Expect(t.Charge(true)).To(Equal(0))
+		Expect(t.Stats()).To(BeZero())
+	})
+})
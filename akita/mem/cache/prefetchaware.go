@@ -0,0 +1,64 @@
+package cache
+
+// SetPreferUnusedPrefetches makes the perceptron evict the prefetched blocks
+// that have not been hit before any other valid block, in PseudoLRU order.
+// Invalid blocks still come first. Unlike PrefetchProtection, which works
+// with any victim finder, unused prefetches are evicted without waiting.
+func (p *PerceptronVictimFinder) SetPreferUnusedPrefetches(prefer bool) {
+	p.preferUnusedPrefetches = prefer
+}
+
+// PrefersUnusedPrefetches tells if unused prefetches are evicted first.
+func (p *PerceptronVictimFinder) PrefersUnusedPrefetches() bool {
+	return p.preferUnusedPrefetches
+}
+
+// UnusedPrefetchVictims returns the number of victim selections that evicted
+// an unused prefetch ahead of the perceptron's choice.
+func (p *PerceptronVictimFinder) UnusedPrefetchVictims() uint64 {
+	return p.unusedPrefetchVictims
+}
+
+// unusedPrefetchVictim returns the first unused prefetch in PseudoLRU order,
+// or nil if the set has an invalid block or no unused prefetch, or if unused
+// prefetches are not preferred.
+func (p *PerceptronVictimFinder) unusedPrefetchVictim(set *Set) *Block {
+	if !p.preferUnusedPrefetches || firstInvalidBlock(set) != nil {
+		return nil
+	}
+
+	ways := unusedPrefetchOrder(set)
+	if len(ways) == 0 {
+		return nil
+	}
+
+	return set.Blocks[ways[0]]
+}
+
+// withUnusedPrefetches puts the unused prefetches ahead of the ways if they
+// are preferred.
+func (p *PerceptronVictimFinder) withUnusedPrefetches(
+	set *Set,
+	ways []int,
+) []int {
+	if !p.preferUnusedPrefetches {
+		return ways
+	}
+
+	return append(unusedPrefetchOrder(set), ways...)
+}
+
+// unusedPrefetchOrder returns the ways of the evictable unused prefetches in
+// PseudoLRU order.
+func unusedPrefetchOrder(set *Set) []int {
+	var ways []int
+
+	for _, way := range pseudoLRUOrder(set) {
+		block := set.Blocks[way]
+		if unusedPrefetch(block) && !block.IsLocked && !isPinned(block) {
+			ways = append(ways, way)
+		}
+	}
+
+	return ways
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prefetch-aware victim selection", func() {
+	var (
+		p   *PerceptronVictimFinder
+		set *Set
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+
+		set = makeTestSet(4)
+		for _, b := range set.Blocks {
+			b.IsValid = true
+		}
+
+		set.Blocks[2].IsPrefetched = true
+		set.Blocks[3].IsPrefetched = true
+		set.Blocks[3].HitCount = 1
+	})
+
+	It("should ignore prefetches by default", func() {
+		Expect(p.FindVictim(set)).To(BeIdenticalTo(set.Blocks[0]))
+		Expect(p.UnusedPrefetchVictims()).To(BeZero())
+	})
+
+	It("should evict unused prefetches first", func() {
+		p.SetPreferUnusedPrefetches(true)
+
+		Expect(p.FindVictim(set)).To(BeIdenticalTo(set.Blocks[2]))
+		Expect(p.UnusedPrefetchVictims()).To(Equal(uint64(1)))
+		Expect(p.FindVictims(set, nil, 4)).To(Equal([]*Block{
+			set.Blocks[2], set.Blocks[0], set.Blocks[1], set.Blocks[3],
+		}))
+	})
+
+	It("should still evict invalid and skip locked blocks first", func() {
+		p.SetPreferUnusedPrefetches(true)
+		set.Blocks[2].IsLocked = true
+
+		Expect(p.FindVictim(set)).To(BeIdenticalTo(set.Blocks[0]))
+
+		set.Blocks[2].IsLocked = false
+		set.Blocks[1].IsValid = false
+
+		Expect(p.FindVictim(set)).To(BeIdenticalTo(set.Blocks[1]))
+		Expect(p.UnusedPrefetchVictims()).To(BeZero())
+	})
+
+	It("should remember that a resident block was prefetched", func() {
+		Expect(residentContext(set.Blocks[2]).IsPrefetch).To(BeTrue())
+		Expect(residentContext(set.Blocks[0]).IsPrefetch).To(BeFalse())
+	})
+})
@@ -0,0 +1,182 @@
+package cache
+
+import "github.com/sarchlab/akita/v4/mem/vm"
+
+// A PrefetchFeedbackReport summarizes the cache pollution caused by
+// prefetches.
+type PrefetchFeedbackReport struct {
+	PrefetchFills uint64
+	DemandMisses  uint64
+
+	// PrefetchedEvictions counts evicted blocks that were filled by a
+	// prefetch; PrefetchedDead counts the ones among them that were never
+	// hit.
+	PrefetchedEvictions uint64
+	PrefetchedDead      uint64
+
+	// PollutionMisses counts demand misses to lines that a prefetch fill had
+	// evicted.
+	PollutionMisses uint64
+}
+
+// DeadRate returns the fraction of evicted prefetched blocks that were never
+// hit.
+func (r PrefetchFeedbackReport) DeadRate() float64 {
+	if r.PrefetchedEvictions == 0 {
+		return 0
+	}
+
+	return float64(r.PrefetchedDead) / float64(r.PrefetchedEvictions)
+}
+
+// PollutionRate returns the fraction of demand misses caused by prefetch
+// evictions.
+func (r PrefetchFeedbackReport) PollutionRate() float64 {
+	if r.DemandMisses == 0 {
+		return 0
+	}
+
+	return float64(r.PollutionMisses) / float64(r.DemandMisses)
+}
+
+// A PrefetchFeedbackListener receives prefetch feedback, typically to throttle
+// a prefetcher.
+type PrefetchFeedbackListener interface {
+	PrefetchFeedback(report PrefetchFeedbackReport)
+}
+
+type lineKey struct {
+	pid  vm.PID
+	line uint64
+}
+
+// A PrefetchFeedbackTracker attributes evictions to prefetches and reports
+// the pollution to its listeners at the end of every interval.
+//
+// The lines evicted by prefetch fills are remembered in a FIFO of limited
+// capacity, so pollution misses are only detected for the most recent
+// evictions.
+type PrefetchFeedbackTracker struct {
+	// Interval is the number of victim selections per report.
+	Interval uint64
+
+	listeners []PrefetchFeedbackListener
+
+	total    PrefetchFeedbackReport
+	interval PrefetchFeedbackReport
+	selected uint64
+
+	ghosts     map[lineKey]bool
+	ghostQueue []lineKey
+	ghostCap   int
+}
+
+// NewPrefetchFeedbackTracker creates a tracker that remembers up to ghostCap
+// lines evicted by prefetches.
+func NewPrefetchFeedbackTracker(
+	interval uint64,
+	ghostCap int,
+) *PrefetchFeedbackTracker {
+	return &PrefetchFeedbackTracker{
+		Interval: interval,
+		ghosts:   make(map[lineKey]bool),
+		ghostCap: ghostCap,
+	}
+}
+
+// Subscribe registers a listener for the interval reports.
+func (t *PrefetchFeedbackTracker) Subscribe(l PrefetchFeedbackListener) {
+	t.listeners = append(t.listeners, l)
+}
+
+// Report returns the feedback accumulated since the tracker was created.
+func (t *PrefetchFeedbackTracker) Report() PrefetchFeedbackReport {
+	return t.total
+}
+
+// SetPrefetchFeedbackTracker attaches a tracker to the directory. Prefetch
+// fills are identified through VictimContext.IsPrefetch.
+func (d *DirectoryImpl) SetPrefetchFeedbackTracker(t *PrefetchFeedbackTracker) {
+	d.prefetchFeedback = t
+}
+
+// recordVictim attributes the victim selection for a miss to the address.
+// Calls on a nil tracker are ignored.
+func (t *PrefetchFeedbackTracker) recordVictim(
+	addr uint64,
+	context *VictimContext,
+	victim *Block,
+) {
+	if t == nil || victim == nil {
+		return
+	}
+
+	prefetch := context != nil && context.IsPrefetch
+
+	var delta PrefetchFeedbackReport
+	if prefetch {
+		delta.PrefetchFills++
+	} else {
+		delta.DemandMisses++
+
+		key := lineKey{line: addr}
+		if context != nil {
+			key.pid = context.PID
+		}
+
+		if t.ghosts[key] {
+			delete(t.ghosts, key)
+			delta.PollutionMisses++
+		}
+	}
+
+	if victim.IsValid {
+		if victim.IsPrefetched {
+			delta.PrefetchedEvictions++
+			if victim.HitCount == 0 {
+				delta.PrefetchedDead++
+			}
+		}
+
+		if prefetch {
+			t.addGhost(lineKey{pid: victim.PID, line: victim.Tag})
+		}
+	}
+
+	t.add(delta)
+}
+
+func (t *PrefetchFeedbackTracker) addGhost(key lineKey) {
+	if t.ghostCap <= 0 || t.ghosts[key] {
+		return
+	}
+
+	for len(t.ghostQueue) >= t.ghostCap {
+		delete(t.ghosts, t.ghostQueue[0])
+		t.ghostQueue = t.ghostQueue[1:]
+	}
+
+	t.ghosts[key] = true
+	t.ghostQueue = append(t.ghostQueue, key)
+}
+
+func (t *PrefetchFeedbackTracker) add(delta PrefetchFeedbackReport) {
+	for _, r := range []*PrefetchFeedbackReport{&t.total, &t.interval} {
+		r.PrefetchFills += delta.PrefetchFills
+		r.DemandMisses += delta.DemandMisses
+		r.PrefetchedEvictions += delta.PrefetchedEvictions
+		r.PrefetchedDead += delta.PrefetchedDead
+		r.PollutionMisses += delta.PollutionMisses
+	}
+
+	t.selected++
+	if t.Interval == 0 || t.selected%t.Interval != 0 {
+		return
+	}
+
+	for _, l := range t.listeners {
+		l.PrefetchFeedback(t.interval)
+	}
+
+	t.interval = PrefetchFeedbackReport{}
+}
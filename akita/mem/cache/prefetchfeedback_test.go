@@ -0,0 +1,70 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type recordingFeedbackListener struct {
+	reports []PrefetchFeedbackReport
+}
+
+func (l *recordingFeedbackListener) PrefetchFeedback(r PrefetchFeedbackReport) {
+	l.reports = append(l.reports, r)
+}
+
+var _ = Describe("PrefetchFeedbackTracker", func() {
+	var (
+		d        *DirectoryImpl
+		t        *PrefetchFeedbackTracker
+		listener *recordingFeedbackListener
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 2, 64, NewLRUVictimFinder())
+		t = NewPrefetchFeedbackTracker(4, 16)
+		listener = &recordingFeedbackListener{}
+		t.Subscribe(listener)
+		d.SetPrefetchFeedbackTracker(t)
+	})
+
+	access := func(addr uint64, prefetch bool) {
+		block := d.Lookup(0, addr)
+		if block == nil {
+			block = d.FindVictimWithContext(addr, &VictimContext{
+				Address: addr, CacheLineID: addr, IsPrefetch: prefetch,
+			})
+			block.Tag = addr
+			block.IsValid = true
+		}
+
+		d.Visit(block)
+	}
+
+	It("should attribute demand misses to prefetch evictions", func() {
+		access(0x0, false)
+		access(0x40, false)
+		access(0x80, true)
+		access(0xc0, true)
+
+		access(0x0, false)
+		access(0x40, false)
+
+		r := t.Report()
+		Expect(r.PrefetchFills).To(Equal(uint64(2)))
+		Expect(r.DemandMisses).To(Equal(uint64(4)))
+		Expect(r.PollutionMisses).To(Equal(uint64(2)))
+		Expect(r.PrefetchedEvictions).To(Equal(uint64(2)))
+		Expect(r.DeadRate()).To(Equal(1.0))
+		Expect(r.PollutionRate()).To(Equal(0.5))
+	})
+
+	It("should report to the listeners every interval", func() {
+		for i := uint64(0); i < 8; i++ {
+			access(i*64, i%2 == 0)
+		}
+
+		Expect(listener.reports).To(HaveLen(2))
+		Expect(listener.reports[1].PrefetchFills).To(Equal(uint64(2)))
+	})
+})
@@ -0,0 +1,144 @@
+package cache
+
+// PrefetchStats counts the outcome of prefetched fills.
+type PrefetchStats struct {
+	Fills uint64
+
+	// Useful counts the prefetched blocks that were hit at least once.
+	Useful uint64
+
+	// Unused counts the prefetched blocks evicted before their first hit.
+	// DroppedEarly counts the ones among them that were evicted ahead of the
+	// victim finder's choice because their protection window had expired.
+	Unused       uint64
+	DroppedEarly uint64
+
+	// Protected counts the victim selections that were redirected to keep a
+	// prefetched block inside its protection window.
+	Protected uint64
+}
+
+// A PrefetchProtection keeps prefetched blocks for a window of accesses to
+// their set and expels them quickly if they are not hit in that window.
+//
+// During the window, a prefetched block that has not been hit is not evicted
+// unless no other block can be. Once the window is over, a prefetched block
+// that has still not been hit becomes the preferred victim of its set. A hit
+// turns a prefetched block into a normal block.
+type PrefetchProtection struct {
+	Window uint64
+
+	stats PrefetchStats
+}
+
+// NewPrefetchProtection creates a protection with a window of the given
+// number of set accesses.
+func NewPrefetchProtection(window uint64) *PrefetchProtection {
+	return &PrefetchProtection{Window: window}
+}
+
+// Stats returns the prefetch statistics.
+func (p *PrefetchProtection) Stats() PrefetchStats {
+	return p.stats
+}
+
+// SetPrefetchProtection attaches a prefetch protection to the directory.
+// Fills are marked as prefetches through VictimContext.IsPrefetch. Passing nil
+// removes the protection.
+func (d *DirectoryImpl) SetPrefetchProtection(p *PrefetchProtection) {
+	d.prefetch = p
+}
+
+func (d *DirectoryImpl) prefetchAge(setID int, block *Block) uint64 {
+	return d.setAccesses[setID] - block.FillTime
+}
+
+func unusedPrefetch(block *Block) bool {
+	return block.IsValid && block.IsPrefetched && block.HitCount == 0
+}
+
+// applyPrefetchProtection prefers expired unused prefetches as victims and
+// keeps protected ones.
+func (d *DirectoryImpl) applyPrefetchProtection(
+	vf VictimFinder,
+	set *Set,
+	setID int,
+	context *VictimContext,
+	victim *Block,
+) *Block {
+	p := d.prefetch
+	if p == nil || victim == nil || !victim.IsValid {
+		return victim
+	}
+
+	protected := func(b *Block) bool {
+		return unusedPrefetch(b) && d.prefetchAge(setID, b) < p.Window
+	}
+	expired := func(b *Block) bool {
+		return unusedPrefetch(b) && !protected(b)
+	}
+
+	if expired(victim) {
+		return victim
+	}
+
+	// Only rank the candidates if the victim has to be replaced: by an
+	// expired prefetch, or because it is protected.
+	if !protected(victim) && !hasBlock(set, expired) {
+		return victim
+	}
+
+	candidates := FindVictims(vf, set, context, len(set.Blocks))
+
+	for _, block := range candidates {
+		if expired(block) {
+			if block != victim {
+				p.stats.DroppedEarly++
+			}
+
+			return block
+		}
+	}
+
+	if !protected(victim) {
+		return victim
+	}
+
+	for _, block := range candidates {
+		if !protected(block) {
+			p.stats.Protected++
+			return block
+		}
+	}
+
+	return victim
+}
+
+// recordAccess updates the statistics for a visit. Calls on a nil protection
+// are ignored.
+func (p *PrefetchProtection) recordAccess(block *Block, isFill bool) {
+	if p == nil || !block.IsPrefetched {
+		return
+	}
+
+	if isFill {
+		p.stats.Fills++
+		return
+	}
+
+	if block.HitCount == 1 {
+		p.stats.Useful++
+	}
+}
+
+// recordVictim updates the statistics for an eviction. Calls on a nil
+// protection are ignored.
+func (p *PrefetchProtection) recordVictim(victim *Block) {
+	if p == nil || victim == nil {
+		return
+	}
+
+	if unusedPrefetch(victim) {
+		p.stats.Unused++
+	}
+}
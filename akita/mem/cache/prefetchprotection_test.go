@@ -0,0 +1,96 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PrefetchProtection", func() {
+	var (
+		d *DirectoryImpl
+		p *PrefetchProtection
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 2, 64, NewLRUVictimFinder())
+		p = NewPrefetchProtection(4)
+		d.SetPrefetchProtection(p)
+	})
+
+	access := func(addr uint64, prefetch bool) *Block {
+		block := d.Lookup(0, addr)
+		if block == nil {
+			block = d.FindVictimWithContext(addr, &VictimContext{
+				Address:    addr,
+				AccessType: "read",
+				IsPrefetch: prefetch,
+			})
+			block.Tag = addr
+			block.IsValid = true
+		}
+
+		d.Visit(block)
+
+		return block
+	}
+
+	It("should mark prefetched fills", func() {
+		Expect(access(0x0, true).IsPrefetched).To(BeTrue())
+		Expect(access(0x40, false).IsPrefetched).To(BeFalse())
+		Expect(p.Stats().Fills).To(Equal(uint64(1)))
+	})
+
+	It("should protect a prefetch during the window", func() {
+		access(0x0, true)
+		access(0x40, false)
+
+		access(0x80, false)
+		access(0xc0, false)
+
+		Expect(d.Lookup(0, 0x0)).NotTo(BeNil())
+		Expect(p.Stats().Protected).NotTo(BeZero())
+	})
+
+	It("should drop an unused prefetch after the window", func() {
+		access(0x0, true)
+		access(0x40, false)
+		for i := 0; i < 4; i++ {
+			access(0x40, false)
+		}
+
+		access(0x80, false)
+
+		Expect(d.Lookup(0, 0x0)).To(BeNil())
+		Expect(d.Lookup(0, 0x40)).NotTo(BeNil())
+		Expect(p.Stats().Unused).To(Equal(uint64(1)))
+	})
+
+	It("should treat a hit prefetch as a normal block", func() {
+		access(0x0, true)
+		access(0x0, false)
+		access(0x40, false)
+		for i := 0; i < 4; i++ {
+			access(0x40, false)
+		}
+
+		access(0x80, false)
+
+		Expect(p.Stats().Useful).To(Equal(uint64(1)))
+		Expect(p.Stats().Unused).To(BeZero())
+	})
+
+	It("should only rank the candidates for a protected victim", func() {
+		vf := &rankCounter{LRUVictimFinder: NewLRUVictimFinder()}
+		d = NewDirectory(1, 2, 64, vf)
+		d.SetPrefetchProtection(p)
+
+		for _, addr := range []uint64{0x0, 0x40, 0x80, 0xc0} {
+			access(addr, addr == 0x80)
+		}
+		Expect(vf.rankings).To(BeZero())
+
+		access(0x100, false)
+		Expect(vf.rankings).To(Equal(1))
+		Expect(d.Lookup(0, 0x80)).NotTo(BeNil())
+	})
+})
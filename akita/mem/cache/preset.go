@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+)
+
+// A PerceptronPreset bundles the recommended perceptron configuration for a
+// level of the memory hierarchy.
+type PerceptronPreset struct {
+	Name string
+
+	// Prediction threshold (τ), training threshold (θ), and learning rate.
+	Threshold    int32
+	Theta        int32
+	LearningRate int32
+
+	// The predictor trains on one out of every TrainingSampleInterval
+	// outcomes.
+	TrainingSampleInterval uint64
+
+	// Number of low address bits dropped before extracting features.
+	FeatureShift uint
+
+	// Recommended way associativity of the cache that uses the preset.
+	WayAssociativity int
+}
+
+// Names of the built-in presets.
+const (
+	PresetGPUL1  = "gpu-l1"
+	PresetGPUL2  = "gpu-l2"
+	PresetCPULLC = "cpu-llc"
+)
+
+var perceptronPresets = map[string]PerceptronPreset{
+	// Small, low-associativity caches see little reuse per line. A lower
+	// training threshold and training on every outcome let the predictor
+	// converge within the short lifetime of a kernel.
+	PresetGPUL1: {
+		Name:                   PresetGPUL1,
+		Threshold:              0,
+		Theta:                  8,
+		LearningRate:           1,
+		TrainingSampleInterval: 1,
+		FeatureShift:           7,
+		WayAssociativity:       4,
+	},
+	// The configuration used by the r9nano L2 experiments.
+	PresetGPUL2: {
+		Name:                   PresetGPUL2,
+		Threshold:              0,
+		Theta:                  32,
+		LearningRate:           2,
+		TrainingSampleInterval: 5,
+		FeatureShift:           0,
+		WayAssociativity:       16,
+	},
+	// The last-level-cache parameters from the MICRO 2016 paper.
+	PresetCPULLC: {
+		Name:                   PresetCPULLC,
+		Threshold:              3,
+		Theta:                  68,
+		LearningRate:           1,
+		TrainingSampleInterval: 1,
+		FeatureShift:           6,
+		WayAssociativity:       16,
+	},
+}
+
+// LookupPerceptronPreset returns the preset with the given name. The lookup
+// is case-insensitive and accepts underscores in place of dashes.
+func LookupPerceptronPreset(name string) (PerceptronPreset, bool) {
+	key := strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	preset, ok := perceptronPresets[key]
+
+	return preset, ok
+}
+
+// PerceptronPresetNames returns the names of all the built-in presets.
+func PerceptronPresetNames() []string {
+	names := make([]string, 0, len(perceptronPresets))
+	for name := range perceptronPresets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// NewPerceptronVictimFinderFromPreset creates a perceptron victim finder
+// configured by the preset.
+func NewPerceptronVictimFinderFromPreset(
+	preset PerceptronPreset,
+) *PerceptronVictimFinder {
+	p := NewPerceptronVictimFinderWithParams(
+		preset.Threshold, preset.Theta, preset.LearningRate)
+
+	if preset.TrainingSampleInterval > 0 {
+		p.SetTrainingSampleInterval(preset.TrainingSampleInterval)
+	}
+
+	p.SetFeatureShift(preset.FeatureShift)
+
+	return p
+}
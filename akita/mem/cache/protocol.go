@@ -264,3 +264,148 @@ func (b RestartRspBuilder) Build() *RestartRsp {
 
 	return r
 }
+
+// CleanReq is the request sent to a cache unit to write back the dirty cache
+// lines without invalidating them. A CleanReq with zero Size cleans all the
+// cache lines.
+type CleanReq struct {
+	sim.MsgMeta
+	Start uint64
+	Size  uint64
+}
+
+// Meta returns the meta data associated with the message.
+func (r *CleanReq) Meta() *sim.MsgMeta {
+	return &r.MsgMeta
+}
+
+// Clone returns cloned CleanReq with different ID
+func (r *CleanReq) Clone() sim.Msg {
+	cloneMsg := *r
+	cloneMsg.ID = sim.GetIDGenerator().Generate()
+
+	return &cloneMsg
+}
+
+// GenerateRsp generates the CleanRsp for the request.
+func (r *CleanReq) GenerateRsp() sim.Rsp {
+	rsp := CleanRspBuilder{}.
+		WithSrc(r.Dst).
+		WithDst(r.Src).
+		WithRspTo(r.ID).
+		Build()
+
+	return rsp
+}
+
+// IsCleanAll returns true if the request cleans all the cache lines.
+func (r *CleanReq) IsCleanAll() bool {
+	return r.Size == 0
+}
+
+// Covers returns true if the address is within the range to clean.
+func (r *CleanReq) Covers(addr uint64) bool {
+	if r.IsCleanAll() {
+		return true
+	}
+
+	return addr >= r.Start && addr < r.Start+r.Size
+}
+
+// CleanReqBuilder can build clean requests.
+type CleanReqBuilder struct {
+	src, dst    sim.RemotePort
+	start, size uint64
+}
+
+// WithSrc sets the source of the message to build
+func (b CleanReqBuilder) WithSrc(src sim.RemotePort) CleanReqBuilder {
+	b.src = src
+	return b
+}
+
+// WithDst sets the destination of the message to build.
+func (b CleanReqBuilder) WithDst(dst sim.RemotePort) CleanReqBuilder {
+	b.dst = dst
+	return b
+}
+
+// WithAddressRange limits the request to build to the cache lines that
+// start within [start, start+size).
+func (b CleanReqBuilder) WithAddressRange(start, size uint64) CleanReqBuilder {
+	b.start = start
+	b.size = size
+
+	return b
+}
+
+// Build creates a new CleanReq
+func (b CleanReqBuilder) Build() *CleanReq {
+	r := &CleanReq{}
+	r.ID = sim.GetIDGenerator().Generate()
+	r.Src = b.src
+	r.Dst = b.dst
+	r.Start = b.start
+	r.Size = b.size
+
+	return r
+}
+
+// CleanRsp is the respond sent from a cache unit for finishing a clean.
+type CleanRsp struct {
+	sim.MsgMeta
+	RspTo string
+}
+
+// Meta returns the meta data associated with the message.
+func (r *CleanRsp) Meta() *sim.MsgMeta {
+	return &r.MsgMeta
+}
+
+// Clone returns cloned CleanRsp with different ID
+func (r *CleanRsp) Clone() sim.Msg {
+	cloneMsg := *r
+	cloneMsg.ID = sim.GetIDGenerator().Generate()
+
+	return &cloneMsg
+}
+
+// GetRspTo returns the ID of the request that the respond is replying to.
+func (r *CleanRsp) GetRspTo() string {
+	return r.RspTo
+}
+
+// CleanRspBuilder can build clean responds.
+type CleanRspBuilder struct {
+	src, dst sim.RemotePort
+	rspTo    string
+}
+
+// WithSrc sets the source of the respond to build.
+func (b CleanRspBuilder) WithSrc(src sim.RemotePort) CleanRspBuilder {
+	b.src = src
+	return b
+}
+
+// WithDst sets the destination of the respond to build.
+func (b CleanRspBuilder) WithDst(dst sim.RemotePort) CleanRspBuilder {
+	b.dst = dst
+	return b
+}
+
+// WithRspTo sets ID of the request that the respond to build is replying to.
+func (b CleanRspBuilder) WithRspTo(id string) CleanRspBuilder {
+	b.rspTo = id
+	return b
+}
+
+// Build creates a new CleanRsp
+func (b CleanRspBuilder) Build() *CleanRsp {
+	r := &CleanRsp{}
+	r.ID = sim.GetIDGenerator().Generate()
+	r.Src = b.src
+	r.Dst = b.dst
+	r.RspTo = b.rspTo
+
+	return r
+}
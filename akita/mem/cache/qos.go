@@ -0,0 +1,158 @@
+package cache
+
+// A QoSClassConfig configures one priority class.
+type QoSClassConfig struct {
+	// OccupancyTarget is the number of ways per set that the class may
+	// occupy. Once it is reached, fills of the class replace the class's own
+	// blocks. 0 means no limit.
+	OccupancyTarget int
+
+	// ProtectionLevel protects the blocks of the class from fills of classes
+	// with a lower level. If every candidate is protected, no victim is
+	// returned and the fill has to be retried. Give protected classes an
+	// occupancy target below the associativity so that lower classes always
+	// find a victim.
+	ProtectionLevel int
+}
+
+// QoSClassStats counts the accesses of a priority class.
+type QoSClassStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRate returns the fraction of accesses that hit.
+func (s QoSClassStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(total)
+}
+
+// A QoSPolicy enforces per-class occupancy targets and protection levels
+// during victim selection. Accesses are tagged with their class through
+// VictimContext.QoSClass. Classes without a configuration use the zero
+// configuration.
+type QoSPolicy struct {
+	Classes map[int]QoSClassConfig
+
+	stats map[int]*QoSClassStats
+}
+
+// NewQoSPolicy creates a policy with the given class configurations.
+func NewQoSPolicy(classes map[int]QoSClassConfig) *QoSPolicy {
+	return &QoSPolicy{
+		Classes: classes,
+		stats:   make(map[int]*QoSClassStats),
+	}
+}
+
+// Stats returns the statistics of the class.
+func (q *QoSPolicy) Stats(class int) QoSClassStats {
+	if s, ok := q.stats[class]; ok {
+		return *s
+	}
+
+	return QoSClassStats{}
+}
+
+// SetQoSPolicy enables priority classes in victim selection. Passing nil
+// disables them.
+func (d *DirectoryImpl) SetQoSPolicy(q *QoSPolicy) {
+	d.qos = q
+}
+
+// applyQoS restricts the victim to the requester's own blocks if the class is
+// at its occupancy target, and to blocks that the class may evict otherwise.
+func (d *DirectoryImpl) applyQoS(
+	vf VictimFinder,
+	set *Set,
+	context *VictimContext,
+	victim *Block,
+) *Block {
+	q := d.qos
+	if q == nil {
+		return victim
+	}
+
+	class := 0
+	if context != nil {
+		class = context.QoSClass
+	}
+
+	config := q.Classes[class]
+
+	evictable := func(b *Block) bool {
+		return !b.IsValid ||
+			q.Classes[b.QoSClass].ProtectionLevel <= config.ProtectionLevel
+	}
+
+	ownBlock := func(b *Block) bool {
+		return b.IsValid && b.QoSClass == class
+	}
+
+	atTarget := config.OccupancyTarget > 0 &&
+		d.classOccupancy(set, class) >= config.OccupancyTarget
+
+	// Only rank the candidates if the victim breaks the policy, as ranking
+	// costs a pass over the set.
+	if victim != nil && (ownBlock(victim) || !atTarget && evictable(victim)) {
+		return victim
+	}
+
+	candidates := FindVictims(vf, set, context, len(set.Blocks))
+
+	if atTarget {
+		for _, block := range candidates {
+			if ownBlock(block) {
+				return block
+			}
+		}
+	}
+
+	if victim != nil && evictable(victim) {
+		return victim
+	}
+
+	for _, block := range candidates {
+		if evictable(block) {
+			return block
+		}
+	}
+
+	return nil
+}
+
+func (d *DirectoryImpl) classOccupancy(set *Set, class int) int {
+	n := 0
+
+	for _, block := range set.Blocks {
+		if block.IsValid && block.QoSClass == class {
+			n++
+		}
+	}
+
+	return n
+}
+
+// recordAccess attributes a hit to the class of the block and a fill to the
+// class of the access that missed. Calls on a nil policy are ignored.
+func (q *QoSPolicy) recordAccess(block *Block, isFill bool) {
+	if q == nil {
+		return
+	}
+
+	s, ok := q.stats[block.QoSClass]
+	if !ok {
+		s = &QoSClassStats{}
+		q.stats[block.QoSClass] = s
+	}
+
+	if isFill {
+		s.Misses++
+	} else {
+		s.Hits++
+	}
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QoSPolicy", func() {
+	var (
+		d *DirectoryImpl
+		q *QoSPolicy
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 4, 64, NewLRUVictimFinder())
+		q = NewQoSPolicy(map[int]QoSClassConfig{
+			0: {},
+			1: {OccupancyTarget: 2, ProtectionLevel: 1},
+		})
+		d.SetQoSPolicy(q)
+	})
+
+	access := func(addr uint64, class int) *Block {
+		block := d.Lookup(0, addr)
+		if block == nil {
+			block = d.FindVictimWithContext(addr, &VictimContext{
+				Address: addr, AccessType: "read", QoSClass: class,
+			})
+			if block == nil {
+				return nil
+			}
+
+			block.Tag = addr
+			block.IsValid = true
+		}
+
+		d.Visit(block)
+
+		return block
+	}
+
+	It("should protect the blocks of a higher class", func() {
+		access(0x0, 1)
+		access(0x40, 1)
+
+		for i := uint64(2); i < 20; i++ {
+			access(i*64, 0)
+		}
+
+		Expect(d.Lookup(0, 0x0)).NotTo(BeNil())
+		Expect(d.Lookup(0, 0x40)).NotTo(BeNil())
+	})
+
+	It("should cap the occupancy of a class", func() {
+		for i := uint64(0); i < 8; i++ {
+			Expect(access(i*64, 1)).NotTo(BeNil())
+		}
+
+		Expect(d.classOccupancy(&d.Sets[0], 1)).To(Equal(2))
+	})
+
+	It("should return no victim if every block is protected", func() {
+		q.Classes[1] = QoSClassConfig{ProtectionLevel: 1}
+		for i := uint64(0); i < 4; i++ {
+			access(i*64, 1)
+		}
+
+		Expect(access(0x1000, 0)).To(BeNil())
+	})
+
+	It("should count hits per class", func() {
+		access(0x0, 1)
+		access(0x0, 1)
+		access(0x40, 0)
+
+		Expect(q.Stats(1).HitRate()).To(Equal(0.5))
+		Expect(q.Stats(0).Misses).To(Equal(uint64(1)))
+	})
+
+	It("should select the victim of a stateful policy once per fill", func() {
+		p := NewPerceptronVictimFinder()
+		duel := NewDuelingVictimFinder(p, 4)
+		d = NewDirectory(4, 4, 64, duel)
+		d.SetQoSPolicy(q)
+
+		d.FindVictimWithContext(0, &VictimContext{QoSClass: 1})
+
+		total, _, _ := p.GetStats()
+		Expect(total).To(Equal(int64(1)))
+		Expect(duel.DuelStats().PerceptronLeaderMisses).To(Equal(uint64(1)))
+	})
+})
@@ -0,0 +1,90 @@
+package cache
+
+import "math/rand"
+
+// A RandomizedVictimFinder is a VictimFinder that draws some of its
+// decisions from a pseudo-random source. Every such policy owns its source,
+// so two caches never share one and a run is reproducible from the seeds of
+// its policies alone.
+type RandomizedVictimFinder interface {
+	VictimFinder
+
+	// SetRandSource replaces the source of the policy.
+	SetRandSource(src rand.Source)
+}
+
+// SeedVictimFinder gives the victim finder a source seeded with the seed if
+// it is randomized, and tells if it is.
+func SeedVictimFinder(vf VictimFinder, seed int64) bool {
+	r, ok := vf.(RandomizedVictimFinder)
+	if ok {
+		r.SetRandSource(rand.NewSource(seed))
+	}
+
+	return ok
+}
+
+// randomValue returns a pseudo-random value in [0, n) from the source.
+func randomValue(src rand.Source, n int) int {
+	return int(uint64(src.Int63()) % uint64(n))
+}
+
+// RandomVictimFinder evicts a pseudo-random unlocked, unpinned block. It is
+// the baseline that every replacement policy should beat. Invalid blocks are
+// filled first. The source is seeded with 1 unless SetRandSource replaces
+// it.
+type RandomVictimFinder struct {
+	src rand.Source
+}
+
+// NewRandomVictimFinder returns a random victim finder seeded with 1.
+func NewRandomVictimFinder() *RandomVictimFinder {
+	return NewRandomVictimFinderWithSeed(1)
+}
+
+// NewRandomVictimFinderWithSeed returns a random victim finder seeded with
+// the seed.
+func NewRandomVictimFinderWithSeed(seed int64) *RandomVictimFinder {
+	return &RandomVictimFinder{src: rand.NewSource(seed)}
+}
+
+// SetRandSource replaces the source of the victims.
+func (r *RandomVictimFinder) SetRandSource(src rand.Source) {
+	r.src = src
+}
+
+// FindVictim returns the first invalid block, or a pseudo-random unlocked
+// block. It returns nil if every block is locked.
+func (r *RandomVictimFinder) FindVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	candidates := r.candidates(set)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return set.Blocks[candidates[randomValue(r.src, len(candidates))]]
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (r *RandomVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return r.FindVictim(set)
+}
+
+// candidates returns the ways of the unlocked, unpinned blocks in way order.
+func (r *RandomVictimFinder) candidates(set *Set) []int {
+	ways := make([]int, 0, len(set.Blocks))
+
+	for _, block := range set.Blocks {
+		if !block.IsLocked && !isPinned(block) {
+			ways = append(ways, block.WayID)
+		}
+	}
+
+	return ways
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RandomVictimFinder", func() {
+	validSet := func(numWays int) *Set {
+		set := makeTestSet(numWays)
+		for _, block := range set.Blocks {
+			block.IsValid = true
+		}
+
+		return set
+	}
+
+	victims := func(vf VictimFinder, n int) []int {
+		set := validSet(8)
+		ways := make([]int, n)
+
+		for i := range ways {
+			ways[i] = vf.FindVictim(set).WayID
+		}
+
+		return ways
+	}
+
+	It("should fill invalid blocks first", func() {
+		set := validSet(4)
+		set.Blocks[2].IsValid = false
+
+		Expect(NewRandomVictimFinder().FindVictim(set)).
+			To(BeIdenticalTo(set.Blocks[2]))
+	})
+
+	It("should never evict a locked block", func() {
+		vf := NewRandomVictimFinder()
+		set := validSet(4)
+		set.Blocks[0].IsLocked = true
+		set.Blocks[3].IsLocked = true
+
+		for i := 0; i < 100; i++ {
+			Expect(vf.FindVictim(set).WayID).To(BeElementOf(1, 2))
+		}
+
+		set.Blocks[1].IsLocked = true
+		set.Blocks[2].IsLocked = true
+		Expect(vf.FindVictim(set)).To(BeNil())
+	})
+
+	It("should repeat its victims with the same seed", func() {
+		a := NewRandomVictimFinder()
+		b := NewRandomVictimFinder()
+		Expect(victims(a, 64)).To(Equal(victims(b, 64)))
+
+		Expect(SeedVictimFinder(a, 42)).To(BeTrue())
+		Expect(SeedVictimFinder(b, 42)).To(BeTrue())
+		Expect(victims(a, 64)).To(Equal(victims(b, 64)))
+
+		SeedVictimFinder(b, 43)
+		Expect(victims(a, 64)).NotTo(Equal(victims(b, 64)))
+
+		Expect(victims(NewRandomVictimFinderWithSeed(9), 64)).
+			To(Equal(victims(NewRandomVictimFinderWithSeed(9), 64)))
+	})
+
+	It("should use every way", func() {
+		ways := victims(NewRandomVictimFinder(), 400)
+
+		for way := 0; way < 8; way++ {
+			Expect(ways).To(ContainElement(way))
+		}
+	})
+})
+
+var _ = Describe("Seeded policies", func() {
+	It("should only seed the randomized policies", func() {
+		Expect(SeedVictimFinder(NewBRRIPVictimFinder(), 1)).To(BeTrue())
+		Expect(SeedVictimFinder(NewRLVictimFinder(), 1)).To(BeTrue())
+		Expect(SeedVictimFinder(NewLRUVictimFinder(), 1)).To(BeFalse())
+	})
+
+	It("should draw the bimodal fills from the source", func() {
+		fills := func(seed int64) []uint8 {
+			vf := NewBRRIPVictimFinder()
+			vf.SetRandSource(rand.NewSource(seed))
+
+			rrpvs := make([]uint8, 1024)
+			for i := range rrpvs {
+				block := &Block{}
+				vf.Insert(nil, block)
+				rrpvs[i] = block.RRPV
+			}
+
+			return rrpvs
+		}
+
+		long := 0
+		for _, rrpv := range fills(7) {
+			if rrpv == rripMaxRRPV-1 {
+				long++
+			}
+		}
+
+		Expect(fills(7)).To(Equal(fills(7)))
+		Expect(fills(7)).NotTo(Equal(fills(8)))
+		Expect(long).To(BeNumerically("~", 1024/rripBimodalInterval, 16))
+	})
+
+	It("should seed the policies created by name", func() {
+		fillsByName := func(seed int64) []uint8 {
+			vf, err := NewVictimFinderByName("brrip", PolicyConfig{Seed: seed})
+			Expect(err).NotTo(HaveOccurred())
+
+			rrpvs := make([]uint8, 256)
+			for i := range rrpvs {
+				block := &Block{}
+				vf.(InsertionObserver).Insert(nil, block)
+				rrpvs[i] = block.RRPV
+			}
+
+			return rrpvs
+		}
+
+		Expect(fillsByName(5)).To(Equal(fillsByName(5)))
+		Expect(fillsByName(5)).NotTo(Equal(fillsByName(6)))
+	})
+})
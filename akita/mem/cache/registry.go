@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A PolicyConfig describes the cache that a registered replacement policy is
+// created for. Factories that do not depend on the geometry ignore it.
+type PolicyConfig struct {
+	NumSets   int
+	NumWays   int
+	BlockSize int
+
+	// The seed of the randomized policies; see RandomizedVictimFinder. Zero
+	// keeps the default source of the policy.
+	Seed int64
+}
+
+// A VictimFinderFactory creates a victim finder for a cache.
+type VictimFinderFactory func(cfg PolicyConfig) VictimFinder
+
+var (
+	victimFinderRegistryMu sync.RWMutex
+	victimFinderRegistry   = make(map[string]VictimFinderFactory)
+)
+
+// normalizePolicyName makes policy names case-insensitive and accepts
+// underscores in place of dashes, like the perceptron preset names.
+func normalizePolicyName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// RegisterVictimFinder makes a replacement policy available by name to
+// NewVictimFinderByName. It panics if the name is empty, the factory is nil,
+// or the name is already registered.
+func RegisterVictimFinder(name string, factory VictimFinderFactory) {
+	key := normalizePolicyName(name)
+	if key == "" {
+		panic("replacement policy name is empty")
+	}
+
+	if factory == nil {
+		panic(fmt.Sprintf("replacement policy %q has a nil factory", name))
+	}
+
+	victimFinderRegistryMu.Lock()
+	defer victimFinderRegistryMu.Unlock()
+
+	if _, dup := victimFinderRegistry[key]; dup {
+		panic(fmt.Sprintf("replacement policy %q is already registered", name))
+	}
+
+	victimFinderRegistry[key] = factory
+}
+
+// NewVictimFinderByName creates the replacement policy registered under the
+// name. An empty name selects LRU, and the names of the perceptron presets
+// are accepted as well. A randomized policy is seeded with the seed of the
+// configuration, if it is set.
+func NewVictimFinderByName(name string, cfg PolicyConfig) (VictimFinder, error) {
+	key := normalizePolicyName(name)
+	if key == "" {
+		key = "lru"
+	}
+
+	victimFinderRegistryMu.RLock()
+	factory, ok := victimFinderRegistry[key]
+	victimFinderRegistryMu.RUnlock()
+
+	if ok {
+		vf := factory(cfg)
+		if cfg.Seed != 0 {
+			SeedVictimFinder(vf, cfg.Seed)
+		}
+
+		return vf, nil
+	}
+
+	if preset, ok := LookupPerceptronPreset(key); ok {
+		return NewPerceptronVictimFinderFromPreset(preset), nil
+	}
+
+	return nil, fmt.Errorf("unknown replacement policy %q", name)
+}
+
+// RegisteredVictimFinders returns the sorted names of the registered
+// replacement policies. The perceptron presets are not included; see
+// PerceptronPresetNames.
+func RegisteredVictimFinders() []string {
+	victimFinderRegistryMu.RLock()
+	defer victimFinderRegistryMu.RUnlock()
+
+	names := make([]string, 0, len(victimFinderRegistry))
+	for name := range victimFinderRegistry {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func init() {
+	lru := func(PolicyConfig) VictimFinder { return NewLRUVictimFinder() }
+	srrip := func(PolicyConfig) VictimFinder { return NewSRRIPVictimFinder() }
+
+	// The default LRU is the PseudoLRU tree, hence the alias.
+	RegisterVictimFinder("lru", lru)
+	RegisterVictimFinder("plru", lru)
+	RegisterVictimFinder("true-lru", func(PolicyConfig) VictimFinder {
+		return NewTrueLRUVictimFinder()
+	})
+	// The perceptron uses the hashed weight tables, hence the alias.
+	perceptron := func(PolicyConfig) VictimFinder {
+		return NewPerceptronVictimFinder()
+	}
+	RegisterVictimFinder("perceptron", perceptron)
+	RegisterVictimFinder("hashed-perceptron", perceptron)
+	RegisterVictimFinder("logistic", func(PolicyConfig) VictimFinder {
+		return NewLogisticVictimFinder()
+	})
+	RegisterVictimFinder("mlp", func(PolicyConfig) VictimFinder {
+		return NewMLPVictimFinder(DefaultMLPWidth)
+	})
+	RegisterVictimFinder("dueling-perceptron", func(PolicyConfig) VictimFinder {
+		return NewDuelingVictimFinder(NewPerceptronVictimFinder(), 0)
+	})
+	RegisterVictimFinder("clock", func(PolicyConfig) VictimFinder {
+		return NewClockVictimFinder()
+	})
+	RegisterVictimFinder("nru", func(PolicyConfig) VictimFinder {
+		return NewNRUVictimFinder()
+	})
+	RegisterVictimFinder("rrip", srrip)
+	RegisterVictimFinder("srrip", srrip)
+	RegisterVictimFinder("brrip", func(PolicyConfig) VictimFinder {
+		return NewBRRIPVictimFinder()
+	})
+	RegisterVictimFinder("drrip", func(PolicyConfig) VictimFinder {
+		return NewDRRIPVictimFinder()
+	})
+	RegisterVictimFinder("random", func(PolicyConfig) VictimFinder {
+		return NewRandomVictimFinder()
+	})
+	RegisterVictimFinder("slru", func(PolicyConfig) VictimFinder {
+		return NewSLRUVictimFinder()
+	})
+	RegisterVictimFinder("arc", func(PolicyConfig) VictimFinder {
+		return NewARCVictimFinder()
+	})
+	RegisterVictimFinder("lirs", func(PolicyConfig) VictimFinder {
+		return NewLIRSVictimFinder()
+	})
+	RegisterVictimFinder("fifo", func(PolicyConfig) VictimFinder {
+		return NewFIFOVictimFinder()
+	})
+	RegisterVictimFinder("ship", func(PolicyConfig) VictimFinder {
+		return NewSHiPVictimFinder()
+	})
+	RegisterVictimFinder("mockingjay", func(PolicyConfig) VictimFinder {
+		return NewMockingjayVictimFinder()
+	})
+	RegisterVictimFinder("ghrp", func(PolicyConfig) VictimFinder {
+		return NewGHRPVictimFinder()
+	})
+	RegisterVictimFinder("sdbp", func(cfg PolicyConfig) VictimFinder {
+		return NewSDBPVictimFinderWithConfig(SDBPConfig{
+			Sampler: SamplerConfig{
+				NumCacheSets: cfg.NumSets,
+				BlockSize:    cfg.BlockSize,
+			},
+		})
+	})
+	RegisterVictimFinder("rl", func(PolicyConfig) VictimFinder {
+		return NewRLVictimFinder()
+	})
+	RegisterVictimFinder("hawkeye", func(PolicyConfig) VictimFinder {
+		return NewHawkeyeVictimFinder()
+	})
+}
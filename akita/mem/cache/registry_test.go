@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"sort"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Victim finder registry", func() {
+	It("should create the built-in policies by name", func() {
+		expected := map[string]VictimFinder{
+			"":                   &LRUVictimFinder{},
+			"plru":               &LRUVictimFinder{},
+			"True_LRU":           &TrueLRUVictimFinder{},
+			"rrip":               &RRIPVictimFinder{},
+			"ship":               &SHiPVictimFinder{},
+			"hawkeye":            &HawkeyeVictimFinder{},
+			"mockingjay":         &MockingjayVictimFinder{},
+			"ghrp":               &GHRPVictimFinder{},
+			"sdbp":               &SDBPVictimFinder{},
+			"rl":                 &RLVictimFinder{},
+			"random":             &RandomVictimFinder{},
+			"FIFO":               &FIFOVictimFinder{},
+			"slru":               &SLRUVictimFinder{},
+			"arc":                &ARCVictimFinder{},
+			"lirs":               &LIRSVictimFinder{},
+			"cpu-llc":            &PerceptronVictimFinder{},
+			"logistic":           &LogisticVictimFinder{},
+			"mlp":                &MLPVictimFinder{},
+			"dueling-perceptron": &DuelingVictimFinder{},
+		}
+
+		for name, want := range expected {
+			vf, err := NewVictimFinderByName(name, PolicyConfig{})
+
+			Expect(err).NotTo(HaveOccurred(), name)
+			Expect(vf).To(BeAssignableToTypeOf(want), name)
+		}
+	})
+
+	It("should reject unknown names", func() {
+		_, err := NewVictimFinderByName("belady", PolicyConfig{})
+
+		Expect(err).To(MatchError(ContainSubstring("belady")))
+	})
+
+	It("should pass the cache geometry to registered factories", func() {
+		var got PolicyConfig
+
+		RegisterVictimFinder("registry-test", func(cfg PolicyConfig) VictimFinder {
+			got = cfg
+			return NewLRUVictimFinder()
+		})
+
+		cfg := PolicyConfig{NumSets: 8, NumWays: 4, BlockSize: 64}
+		_, err := NewVictimFinderByName("Registry_Test", cfg)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(cfg))
+		Expect(RegisteredVictimFinders()).To(ContainElement("registry-test"))
+		Expect(func() {
+			RegisterVictimFinder("registry-test", func(PolicyConfig) VictimFinder {
+				return NewLRUVictimFinder()
+			})
+		}).To(Panic())
+	})
+
+	It("should list the names in order", func() {
+		names := RegisteredVictimFinders()
+
+		Expect(names).To(ContainElements("lru", "perceptron", "drrip"))
+		Expect(sort.StringsAreSorted(names)).To(BeTrue())
+	})
+})
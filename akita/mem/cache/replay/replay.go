@@ -0,0 +1,212 @@
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sarchlab/akita/v4/mem/cache"
+)
+
+// A Config describes the cache that a trace is replayed on and the policies
+// to compare.
+type Config struct {
+	// The name of the trace, reported as the workload of the results.
+	Workload string
+
+	// The geometry of the cache. Defaults to the 2 MB, 16-way last-level
+	// cache of ChampSim: 2048 sets of 64-byte blocks.
+	NumSets   int
+	NumWays   int
+	BlockSize int
+
+	// The names of the policies, as accepted by cache.NewVictimFinderByName.
+	// Defaults to lru and perceptron.
+	Policies []string
+
+	// The accesses of the first WarmupInstructions instructions train the
+	// policies but are not counted in the results.
+	WarmupInstructions uint64
+
+	// The seed of the randomized policies, as in cache.PolicyConfig.
+	Seed int64
+}
+
+// A Result is the outcome of one policy on a trace.
+type Result struct {
+	cache.SimulationResult
+
+	// The prediction statistics of the policy, if it predicts the reuse of
+	// lines.
+	Prediction    cache.PredictionStats
+	HasPrediction bool
+}
+
+// HitRate returns the fraction of the counted accesses that hit.
+func (r Result) HitRate() float64 {
+	if r.Accesses == 0 {
+		return 0
+	}
+
+	return float64(r.Hits) / float64(r.Accesses)
+}
+
+// predictor is implemented by the policies that report prediction
+// statistics.
+type predictor interface {
+	PredictionStats() cache.PredictionStats
+}
+
+// policyRun is the cache of one policy.
+type policyRun struct {
+	directory *cache.DirectoryImpl
+	trainer   cache.ReuseTrainer
+	result    Result
+}
+
+// Run replays the trace once, driving one cache per policy, and returns the
+// results of the policies in order. Hits train the policies that predict
+// reuse, and so do the evictions of valid blocks, like the write-back cache
+// does.
+func Run(trace TraceReader, config Config) ([]Result, error) {
+	config = withDefaults(config)
+
+	runs := make([]*policyRun, 0, len(config.Policies))
+	for _, name := range config.Policies {
+		vf, err := cache.NewVictimFinderByName(name, cache.PolicyConfig{
+			NumSets:   config.NumSets,
+			NumWays:   config.NumWays,
+			BlockSize: config.BlockSize,
+			Seed:      config.Seed,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		run := &policyRun{
+			directory: cache.NewDirectory(
+				config.NumSets, config.NumWays, config.BlockSize, vf),
+		}
+		run.trainer, _ = vf.(cache.ReuseTrainer)
+		run.result.Source = "replay"
+		run.result.Workload = config.Workload
+		run.result.Policy = name
+		run.result.Cache = "LLC"
+		run.result.CPU = -1
+
+		runs = append(runs, run)
+	}
+
+	for {
+		a, err := trace.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", config.Workload, err)
+		}
+
+		counted := a.Instruction >= config.WarmupInstructions
+		for _, run := range runs {
+			run.access(a, config.BlockSize, counted)
+		}
+	}
+
+	results := make([]Result, len(runs))
+	for i, run := range runs {
+		if trace.Instructions() > config.WarmupInstructions {
+			run.result.Instructions =
+				trace.Instructions() - config.WarmupInstructions
+		}
+
+		if p, ok := run.directory.GetVictimFinder().(predictor); ok {
+			run.result.Prediction = p.PredictionStats()
+			run.result.HasPrediction = true
+		}
+
+		results[i] = run.result
+	}
+
+	return results, nil
+}
+
+func withDefaults(config Config) Config {
+	if config.NumSets <= 0 {
+		config.NumSets = 2048
+	}
+
+	if config.NumWays <= 0 {
+		config.NumWays = 16
+	}
+
+	if config.BlockSize <= 0 {
+		config.BlockSize = 64
+	}
+
+	if len(config.Policies) == 0 {
+		config.Policies = []string{"lru", "perceptron"}
+	}
+
+	return config
+}
+
+// access looks up the line of the access and fills it on a miss.
+func (r *policyRun) access(a Access, blockSize int, counted bool) {
+	line := a.Address / uint64(blockSize) * uint64(blockSize)
+	ctx := &cache.VictimContext{
+		Address:     a.Address,
+		CacheLineID: line,
+		PC:          a.PC,
+		AccessType:  accessType(a),
+	}
+
+	if counted {
+		r.result.Accesses++
+	}
+
+	if block := r.directory.Lookup(0, line); block != nil {
+		if r.trainer != nil {
+			r.trainer.TrainOnHitWithContext(ctx)
+		}
+
+		block.IsDirty = block.IsDirty || a.Write
+		r.directory.VisitWithContext(block, ctx)
+
+		if counted {
+			r.result.Hits++
+		}
+
+		return
+	}
+
+	if counted {
+		r.result.Misses++
+	}
+
+	victim := r.directory.FindVictimWithContext(line, ctx)
+	if victim == nil {
+		return
+	}
+
+	if victim.IsValid && r.trainer != nil {
+		r.trainer.TrainOnEvictionWithContext(&cache.VictimContext{
+			Address:    victim.Tag,
+			PC:         victim.PC,
+			AccessType: victim.AccessType,
+		})
+	}
+
+	victim.Tag = line
+	victim.IsValid = true
+	victim.IsDirty = a.Write
+	r.directory.Visit(victim)
+}
+
+func accessType(a Access) string {
+	if a.Write {
+		return "write"
+	}
+
+	return "read"
+}
@@ -0,0 +1,70 @@
+package replay
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Run", func() {
+	// loop returns a CSV trace that loops over n lines, one instruction per
+	// access.
+	loop := func(n, rounds int) TraceReader {
+		var sb strings.Builder
+
+		for i := 0; i < rounds*n; i++ {
+			fmt.Fprintf(&sb, "%d,0x400,R,%d\n", (i%n)*64, i)
+		}
+
+		return NewCSVReader(strings.NewReader(sb.String()))
+	}
+
+	It("should report every policy on the same accesses", func() {
+		results, err := Run(loop(4, 10), Config{
+			Workload: "loop",
+			NumSets:  1,
+			NumWays:  4,
+			Policies: []string{"lru", "perceptron"},
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+
+		lru := results[0]
+		Expect(lru.Policy).To(Equal("lru"))
+		Expect(lru.Workload).To(Equal("loop"))
+		Expect(lru.Accesses).To(Equal(uint64(40)))
+		Expect(lru.Misses).To(Equal(uint64(4)))
+		Expect(lru.HitRate()).To(BeNumerically("~", 0.9))
+		Expect(lru.Instructions).To(Equal(uint64(40)))
+		Expect(lru.MPKI()).To(BeNumerically("~", 100))
+		Expect(lru.HasPrediction).To(BeFalse())
+
+		perceptron := results[1]
+		Expect(perceptron.Accesses).To(Equal(uint64(40)))
+		Expect(perceptron.HasPrediction).To(BeTrue())
+		Expect(perceptron.Prediction.Outcomes).NotTo(BeZero())
+	})
+
+	It("should not count the warm-up instructions", func() {
+		results, err := Run(loop(4, 10), Config{
+			NumSets:            1,
+			NumWays:            4,
+			Policies:           []string{"lru"},
+			WarmupInstructions: 8,
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results[0].Accesses).To(Equal(uint64(32)))
+		Expect(results[0].Misses).To(BeZero())
+		Expect(results[0].Instructions).To(Equal(uint64(32)))
+	})
+
+	It("should reject unknown policies", func() {
+		_, err := Run(loop(1, 1), Config{Policies: []string{"belady"}})
+
+		Expect(err).To(MatchError(ContainSubstring("belady")))
+	})
+})
@@ -0,0 +1,282 @@
+// Package replay replays memory access traces on a standalone cache
+// directory, to evaluate replacement policies without a full simulation.
+package replay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// An Access is one memory access of a trace.
+type Access struct {
+	// The index of the instruction that made the access, counted from 0.
+	Instruction uint64
+
+	PC      uint64
+	Address uint64
+	Write   bool
+}
+
+// A TraceReader reads the accesses of a trace in order. Next returns io.EOF
+// after the last access.
+type TraceReader interface {
+	Next() (Access, error)
+
+	// Instructions returns the number of instructions read so far, or 0 if
+	// the trace does not count instructions.
+	Instructions() uint64
+}
+
+// The layout of the instructions of a ChampSim trace.
+const (
+	champSimDestinations = 2
+	champSimSources      = 4
+	champSimRecordSize   = 64
+
+	champSimDestinationOffset = 16
+	champSimSourceOffset      = champSimDestinationOffset +
+		8*champSimDestinations
+)
+
+// A ChampSimReader reads the uncompressed instruction traces of ChampSim.
+// Every instruction is a 64-byte record with up to four source and two
+// destination memory operands. The loads of an instruction are read before
+// its stores; instructions without memory operands only count toward the
+// instructions.
+type ChampSimReader struct {
+	r            *bufio.Reader
+	record       [champSimRecordSize]byte
+	instructions uint64
+	pending      []Access
+}
+
+// NewChampSimReader reads a ChampSim trace from r.
+func NewChampSimReader(r io.Reader) *ChampSimReader {
+	return &ChampSimReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next memory access.
+func (c *ChampSimReader) Next() (Access, error) {
+	for len(c.pending) == 0 {
+		if err := c.readInstruction(); err != nil {
+			return Access{}, err
+		}
+	}
+
+	a := c.pending[0]
+	c.pending = c.pending[1:]
+
+	return a, nil
+}
+
+// Instructions returns the number of instructions read so far.
+func (c *ChampSimReader) Instructions() uint64 {
+	return c.instructions
+}
+
+func (c *ChampSimReader) readInstruction() error {
+	_, err := io.ReadFull(c.r, c.record[:])
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("truncated ChampSim record at instruction %d",
+			c.instructions)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	pc := binary.LittleEndian.Uint64(c.record[0:8])
+	index := c.instructions
+	c.instructions++
+
+	operand := func(i int) uint64 {
+		return binary.LittleEndian.Uint64(c.record[i : i+8])
+	}
+
+	for i := 0; i < champSimSources; i++ {
+		if addr := operand(champSimSourceOffset + 8*i); addr != 0 {
+			c.pending = append(c.pending,
+				Access{Instruction: index, PC: pc, Address: addr})
+		}
+	}
+
+	for i := 0; i < champSimDestinations; i++ {
+		if addr := operand(champSimDestinationOffset + 8*i); addr != 0 {
+			c.pending = append(c.pending,
+				Access{Instruction: index, PC: pc, Address: addr, Write: true})
+		}
+	}
+
+	return nil
+}
+
+// A CSVReader reads traces with one access per line:
+//
+//	address[,pc[,type[,instruction]]]
+//
+// The numbers are decimal, or hexadecimal with a 0x prefix. The type is R or
+// W, or read, write, load, or store, and defaults to a read. The instruction
+// is the index of the instruction that made the access; without it, the
+// trace does not count instructions. Lines starting with # are comments, and
+// a first line that does not start with a number is a header.
+type CSVReader struct {
+	r            *csv.Reader
+	line         int
+	instructions uint64
+}
+
+// NewCSVReader reads a CSV trace from r.
+func NewCSVReader(r io.Reader) *CSVReader {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	return &CSVReader{r: cr}
+}
+
+// Next returns the next memory access.
+func (c *CSVReader) Next() (Access, error) {
+	for {
+		record, err := c.r.Read()
+		if err != nil {
+			return Access{}, err
+		}
+
+		c.line++
+
+		a, err := c.parse(record)
+		if err != nil && c.line == 1 {
+			continue
+		}
+
+		if err != nil {
+			return Access{}, fmt.Errorf("line %d: %w", c.line, err)
+		}
+
+		return a, nil
+	}
+}
+
+// Instructions returns the number of instructions up to the last access
+// read, or 0 if the trace does not count instructions.
+func (c *CSVReader) Instructions() uint64 {
+	return c.instructions
+}
+
+func (c *CSVReader) parse(record []string) (Access, error) {
+	var (
+		a   Access
+		err error
+	)
+
+	a.Address, err = strconv.ParseUint(record[0], 0, 64)
+	if err != nil {
+		return a, fmt.Errorf("invalid address %q", record[0])
+	}
+
+	if len(record) > 1 && record[1] != "" {
+		a.PC, err = strconv.ParseUint(record[1], 0, 64)
+		if err != nil {
+			return a, fmt.Errorf("invalid PC %q", record[1])
+		}
+	}
+
+	if len(record) > 2 {
+		a.Write, err = parseAccessType(record[2])
+		if err != nil {
+			return a, err
+		}
+	}
+
+	if len(record) > 3 {
+		a.Instruction, err = strconv.ParseUint(record[3], 0, 64)
+		if err != nil {
+			return a, fmt.Errorf("invalid instruction %q", record[3])
+		}
+
+		if a.Instruction >= c.instructions {
+			c.instructions = a.Instruction + 1
+		}
+	}
+
+	return a, nil
+}
+
+func parseAccessType(s string) (write bool, err error) {
+	switch strings.ToLower(s) {
+	case "", "r", "read", "load":
+		return false, nil
+	case "w", "write", "store":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid access type %q", s)
+	}
+}
+
+// A Trace is an open trace file.
+type Trace struct {
+	TraceReader
+
+	closers []io.Closer
+}
+
+// Close closes the trace file.
+func (t *Trace) Close() error {
+	var err error
+
+	for i := len(t.closers) - 1; i >= 0; i-- {
+		if e := t.closers[i].Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// OpenTrace opens a trace file. Files ending in .csv, possibly followed by
+// .gz, are CSV traces; other files are ChampSim traces. Files ending in .gz
+// are decompressed. Traces compressed with xz must be decompressed first.
+func OpenTrace(path string) (*Trace, error) {
+	name := strings.ToLower(path)
+	if strings.HasSuffix(name, ".xz") {
+		return nil, fmt.Errorf("%s: xz traces are not supported, "+
+			"decompress the trace with xz -d", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Trace{closers: []io.Closer{f}}
+
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		t.closers = append(t.closers, gz)
+		r = gz
+		name = strings.TrimSuffix(name, ".gz")
+	}
+
+	if strings.HasSuffix(name, ".csv") {
+		t.TraceReader = NewCSVReader(r)
+	} else {
+		t.TraceReader = NewChampSimReader(r)
+	}
+
+	return t, nil
+}
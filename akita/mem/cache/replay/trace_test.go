@@ -0,0 +1,119 @@
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// champSimRecord encodes an instruction of a ChampSim trace.
+func champSimRecord(pc uint64, loads, stores []uint64) []byte {
+	record := make([]byte, champSimRecordSize)
+	binary.LittleEndian.PutUint64(record, pc)
+
+	for i, addr := range stores {
+		binary.LittleEndian.PutUint64(
+			record[champSimDestinationOffset+8*i:], addr)
+	}
+
+	for i, addr := range loads {
+		binary.LittleEndian.PutUint64(record[champSimSourceOffset+8*i:], addr)
+	}
+
+	return record
+}
+
+func readAll(t TraceReader) []Access {
+	var accesses []Access
+
+	for {
+		a, err := t.Next()
+		if err == io.EOF {
+			return accesses
+		}
+
+		Expect(err).NotTo(HaveOccurred())
+		accesses = append(accesses, a)
+	}
+}
+
+var _ = Describe("Traces", func() {
+	It("should read the memory operands of ChampSim instructions", func() {
+		var buf bytes.Buffer
+		buf.Write(champSimRecord(0x400, []uint64{0x1000, 0x2000}, []uint64{0x3000}))
+		buf.Write(champSimRecord(0x404, nil, nil))
+		buf.Write(champSimRecord(0x408, []uint64{0x1040}, nil))
+
+		r := NewChampSimReader(&buf)
+
+		Expect(readAll(r)).To(Equal([]Access{
+			{Instruction: 0, PC: 0x400, Address: 0x1000},
+			{Instruction: 0, PC: 0x400, Address: 0x2000},
+			{Instruction: 0, PC: 0x400, Address: 0x3000, Write: true},
+			{Instruction: 2, PC: 0x408, Address: 0x1040},
+		}))
+		Expect(r.Instructions()).To(Equal(uint64(3)))
+	})
+
+	It("should reject a truncated ChampSim record", func() {
+		record := champSimRecord(0x400, []uint64{0x1000}, nil)
+		r := NewChampSimReader(bytes.NewReader(record[:32]))
+
+		_, err := r.Next()
+		Expect(err).To(MatchError(ContainSubstring("truncated")))
+	})
+
+	It("should read CSV traces", func() {
+		r := NewCSVReader(strings.NewReader(
+			"address,pc,type,instruction\n" +
+				"# comment\n" +
+				"0x1000,0x400,R,0\n" +
+				"4160, 0x404, store, 7\n" +
+				"0x2000\n"))
+
+		Expect(readAll(r)).To(Equal([]Access{
+			{Instruction: 0, PC: 0x400, Address: 0x1000},
+			{Instruction: 7, PC: 0x404, Address: 0x1040, Write: true},
+			{Address: 0x2000},
+		}))
+		Expect(r.Instructions()).To(Equal(uint64(8)))
+	})
+
+	It("should report the line of a malformed CSV access", func() {
+		r := NewCSVReader(strings.NewReader("0x1000\n0x2000,0x400,X\n"))
+
+		_, err := r.Next()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = r.Next()
+		Expect(err).To(MatchError(ContainSubstring("line 2")))
+	})
+
+	It("should open compressed traces by their extension", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "trace.csv.gz")
+
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write([]byte("0x1000\n0x2000\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gz.Close()).To(Succeed())
+		Expect(os.WriteFile(path, buf.Bytes(), 0o644)).To(Succeed())
+
+		trace, err := OpenTrace(path)
+		Expect(err).NotTo(HaveOccurred())
+		defer trace.Close()
+
+		Expect(trace.TraceReader).To(BeAssignableToTypeOf(&CSVReader{}))
+		Expect(readAll(trace)).To(HaveLen(2))
+
+		_, err = OpenTrace("trace.champsimtrace.xz")
+		Expect(err).To(MatchError(ContainSubstring("xz -d")))
+	})
+})
@@ -0,0 +1,111 @@
+package cache
+
+import "sort"
+
+// deadBlockVictim selects the victim when the perceptron confidently
+// predicts no reuse for the access. Invalid blocks come first and blocks
+// marked dead next. Otherwise, the resident block with the highest
+// prediction sum, the least likely to be reused, is evicted. Ties are broken
+// in PseudoLRU order, after the clean blocks if the perceptron is cost aware.
+func (p *PerceptronVictimFinder) deadBlockVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	if b := p.markedDeadVictim(set); b != nil {
+		p.markedDeadVictims++
+		return b
+	}
+
+	ways, sums := p.residentOrder(set, true)
+	if len(ways) == 0 {
+		return nil
+	}
+
+	victim := set.Blocks[ways[0]]
+
+	if p.costAware && !victim.IsDirty {
+		p.countCleanVictim(set, victim, sums)
+	}
+
+	return victim
+}
+
+// countCleanVictim counts the clean victim if the first block in PseudoLRU
+// order among the ones that tie with it is dirty.
+func (p *PerceptronVictimFinder) countCleanVictim(
+	set *Set,
+	victim *Block,
+	sums []int32,
+) {
+	for _, way := range pseudoLRUOrder(set) {
+		block := set.Blocks[way]
+		if block.IsLocked || sums[way] != sums[victim.WayID] {
+			continue
+		}
+
+		if block.IsDirty {
+			p.cleanVictims++
+		}
+
+		return
+	}
+}
+
+// residentOrder returns the ways of the valid, unlocked blocks from the least
+// to the most likely to be reused, and the prediction sum of every way. The
+// resident blocks are predicted with their fill context and the weights of
+// the current access. The weight reads are charged if charge is set.
+func (p *PerceptronVictimFinder) residentOrder(
+	set *Set,
+	charge bool,
+) ([]int, []int32) {
+	sums := make([]int32, len(set.Blocks))
+	ways := make([]int, 0, len(set.Blocks))
+
+	for _, way := range pseudoLRUOrder(set) {
+		block := set.Blocks[way]
+		if !block.IsValid || block.IsLocked {
+			continue
+		}
+
+		sums[way] = p.residentSum(block, charge)
+		ways = append(ways, way)
+	}
+
+	sort.SliceStable(ways, func(i, j int) bool {
+		a, b := ways[i], ways[j]
+		if sums[a] != sums[b] {
+			return sums[a] > sums[b]
+		}
+
+		return p.costAware && !set.Blocks[a].IsDirty && set.Blocks[b].IsDirty
+	})
+
+	return ways, sums
+}
+
+// residentSum computes the prediction sum of a resident block.
+func (p *PerceptronVictimFinder) residentSum(block *Block, charge bool) int32 {
+	saved := p.featureContext
+	p.featureContext = residentContext(block)
+
+	var sum int32
+	if charge {
+		sum = p.calculatePredictionSum(block.Tag, block.PC)
+	} else {
+		sum = p.predictionSum(block.Tag, block.PC)
+	}
+
+	p.featureContext = saved
+
+	return sum
+}
+
+// deadBlockOrder returns the ways of the set in the order that dead blocks
+// are evicted: the blocks marked dead first, then the other resident blocks
+// as selected by deadBlockVictim.
+func (p *PerceptronVictimFinder) deadBlockOrder(set *Set) []int {
+	ways, _ := p.residentOrder(set, false)
+	return append(p.markedDeadOrder(set), ways...)
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resident victim selection", func() {
+	var (
+		p          *PerceptronVictimFinder
+		set        *Set
+		dead, live uint64
+		ctx        *VictimContext
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+
+		dead = 0x10001 << p.featureShift
+		live = 0x20002 << p.featureShift
+		ctx = &VictimContext{Address: dead}
+
+		for i := 0; i < 32; i++ {
+			p.TrainOnEviction(dead)
+			p.TrainOnHit(live)
+		}
+
+		set = makeTestSet(4)
+		for _, b := range set.Blocks {
+			b.IsValid = true
+			b.Tag = live
+		}
+	})
+
+	It("should evict the block least likely to be reused", func() {
+		set.Blocks[3].Tag = dead
+
+		Expect(p.FindVictimWithContext(set, ctx)).To(BeIdenticalTo(set.Blocks[3]))
+		Expect(p.FindVictims(set, ctx, 2)[0]).To(BeIdenticalTo(set.Blocks[3]))
+	})
+
+	It("should fall back to PseudoLRU order on ties", func() {
+		set.PseudoLRUBits = 1
+		victim := pseudoLRUVictims.FindVictim(set)
+		Expect(victim).NotTo(BeIdenticalTo(set.Blocks[0]))
+
+		Expect(p.FindVictimWithContext(set, ctx)).To(BeIdenticalTo(victim))
+	})
+
+	It("should prefer a clean block on ties if cost aware", func() {
+		p.SetCostAware(true)
+		set.Blocks[0].IsDirty = true
+
+		Expect(p.FindVictimWithContext(set, ctx)).To(BeIdenticalTo(set.Blocks[1]))
+		Expect(p.CleanVictimsPreferred()).To(Equal(uint64(1)))
+	})
+})
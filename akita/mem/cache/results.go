@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// A SimulationResult is the normalized outcome of one cache in one run. It is
+// the common schema for results produced here and results imported from other
+// simulators.
+type SimulationResult struct {
+	// Where the result comes from, e.g. "akita" or "champsim".
+	Source   string
+	Workload string
+	Policy   string
+
+	// The cache that the counters describe, e.g. "L2" or "LLC", and the CPU
+	// that owns it. CPU is -1 for a shared cache.
+	Cache string
+	CPU   int
+
+	Accesses uint64
+	Hits     uint64
+	Misses   uint64
+
+	// Instructions and cycles of the CPU. Zero if unknown.
+	Instructions uint64
+	Cycles       uint64
+}
+
+// MissRate returns the fraction of accesses that missed.
+func (r SimulationResult) MissRate() float64 {
+	if r.Accesses == 0 {
+		return 0
+	}
+
+	return float64(r.Misses) / float64(r.Accesses)
+}
+
+// MPKI returns the misses per thousand instructions, or 0 if the number of
+// instructions is unknown.
+func (r SimulationResult) MPKI() float64 {
+	if r.Instructions == 0 {
+		return 0
+	}
+
+	return float64(r.Misses) * 1000 / float64(r.Instructions)
+}
+
+// IPC returns the instructions per cycle, or 0 if the number of cycles is
+// unknown.
+func (r SimulationResult) IPC() float64 {
+	if r.Cycles == 0 {
+		return 0
+	}
+
+	return float64(r.Instructions) / float64(r.Cycles)
+}
+
+// WriteSimulationResultsCSV writes the results with the derived metrics, one
+// row per result.
+func WriteSimulationResultsCSV(w io.Writer, results []SimulationResult) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{
+		"source", "workload", "policy", "cache", "cpu",
+		"accesses", "hits", "misses", "instructions", "cycles",
+		"miss_rate", "mpki", "ipc",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		err := cw.Write([]string{
+			r.Source, r.Workload, r.Policy, r.Cache,
+			strconv.Itoa(r.CPU),
+			strconv.FormatUint(r.Accesses, 10),
+			strconv.FormatUint(r.Hits, 10),
+			strconv.FormatUint(r.Misses, 10),
+			strconv.FormatUint(r.Instructions, 10),
+			strconv.FormatUint(r.Cycles, 10),
+			strconv.FormatFloat(r.MissRate(), 'f', 6, 64),
+			strconv.FormatFloat(r.MPKI(), 'f', 4, 64),
+			strconv.FormatFloat(r.IPC(), 'f', 4, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,207 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A ReuseBucket is a coarse prediction of the reuse distance of a line.
+type ReuseBucket int
+
+// Reuse buckets, from the soonest to the latest reuse.
+const (
+	ReuseImmediate ReuseBucket = iota
+	ReuseNear
+	ReuseFar
+	ReuseNever
+	numReuseBuckets
+)
+
+func (b ReuseBucket) String() string {
+	switch b {
+	case ReuseImmediate:
+		return "immediate"
+	case ReuseNear:
+		return "near"
+	case ReuseFar:
+		return "far"
+	case ReuseNever:
+		return "never"
+	default:
+		return fmt.Sprintf("ReuseBucket(%d)", int(b))
+	}
+}
+
+// RRPV returns the re-reference prediction value of the bucket: 0 for
+// immediate reuse up to the distant value of RRIP for no reuse.
+func (b ReuseBucket) RRPV() uint8 {
+	return uint8(b)
+}
+
+// ReuseBucketThresholds split the prediction sums into reuse buckets. Sums
+// below Near predict immediate reuse, sums below Far near reuse, sums below
+// Never far reuse, and the other sums no reuse.
+type ReuseBucketThresholds struct {
+	Near  int32
+	Far   int32
+	Never int32
+}
+
+func (t ReuseBucketThresholds) bucket(sum int32) ReuseBucket {
+	switch {
+	case sum < t.Near:
+		return ReuseImmediate
+	case sum < t.Far:
+		return ReuseNear
+	case sum < t.Never:
+		return ReuseFar
+	default:
+		return ReuseNever
+	}
+}
+
+func (t ReuseBucketThresholds) validate() error {
+	if t.Near >= t.Far || t.Far >= t.Never {
+		return fmt.Errorf("reuse bucket thresholds %+v are not increasing", t)
+	}
+
+	return nil
+}
+
+// SetReuseBuckets makes the perceptron predict a reuse bucket rather than a
+// binary decision. Every filled block gets the RRPV of the bucket predicted
+// with its fill context, a hit resets it to 0, and victims are selected like
+// RRIP: the block with the highest RRPV, in PseudoLRU order on ties, is
+// evicted and the other blocks age by the distance of the victim to the
+// distant RRPV. Passing nil returns to binary predictions. It panics if the
+// thresholds are not increasing.
+func (p *PerceptronVictimFinder) SetReuseBuckets(t *ReuseBucketThresholds) {
+	if t == nil {
+		p.buckets = nil
+		return
+	}
+
+	if err := t.validate(); err != nil {
+		panic(err)
+	}
+
+	thresholds := *t
+	p.buckets = &thresholds
+}
+
+// ReuseBuckets returns the reuse bucket thresholds, or nil if the perceptron
+// makes binary predictions.
+func (p *PerceptronVictimFinder) ReuseBuckets() *ReuseBucketThresholds {
+	if p.buckets == nil {
+		return nil
+	}
+
+	thresholds := *p.buckets
+
+	return &thresholds
+}
+
+// BucketCounts returns the number of fills predicted in every reuse bucket.
+func (p *PerceptronVictimFinder) BucketCounts() [numReuseBuckets]uint64 {
+	return p.bucketCounts
+}
+
+// PredictReuseBucket predicts the reuse bucket of the line at the address,
+// with the context of the access, which may be nil. Without thresholds, the
+// lines predicted dead are in ReuseNever and the others in ReuseImmediate.
+// The weight reads are not charged.
+func (p *PerceptronVictimFinder) PredictReuseBucket(
+	addr uint64,
+	ctx *VictimContext,
+) ReuseBucket {
+	if p.buckets == nil {
+		if dead, _ := p.PredictDead(addr, ctx); dead {
+			return ReuseNever
+		}
+
+		return ReuseImmediate
+	}
+
+	var pc uint64
+
+	if ctx != nil {
+		p.usePIDWeights(ctx.PID)
+		pc = ctx.PC
+		p.featureContext = ctx
+	}
+
+	sum := p.predictionSum(addr, pc)
+	p.featureContext = nil
+
+	return p.buckets.bucket(sum)
+}
+
+// Insert gives the filled block the RRPV of its predicted reuse bucket.
+func (p *PerceptronVictimFinder) Insert(_ *Set, block *Block) {
+	if p.buckets == nil {
+		return
+	}
+
+	b := p.PredictReuseBucket(block.Tag, residentContext(block))
+	block.RRPV = b.RRPV()
+	p.bucketCounts[b]++
+}
+
+// Touch predicts immediate reuse for the block on a hit.
+func (p *PerceptronVictimFinder) Touch(_ *Set, block *Block) {
+	if p.buckets == nil {
+		return
+	}
+
+	block.RRPV = ReuseImmediate.RRPV()
+}
+
+func (p *PerceptronVictimFinder) distantRRPV() uint8 {
+	return ReuseNever.RRPV()
+}
+
+// bucketVictim returns the first invalid block, or the unlocked block with
+// the highest RRPV, and ages the set so that the victim has the distant
+// RRPV.
+func (p *PerceptronVictimFinder) bucketVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	ways := p.bucketOrder(set)
+	if len(ways) == 0 {
+		return nil
+	}
+
+	victim := set.Blocks[ways[0]]
+
+	distant := int32(p.distantRRPV())
+	age := distant - min(int32(victim.RRPV), distant)
+
+	for _, block := range set.Blocks {
+		if block.IsValid {
+			block.RRPV = uint8(min(int32(block.RRPV)+age, distant))
+		}
+	}
+
+	return victim
+}
+
+// bucketOrder returns the ways of the valid, unlocked blocks from the highest
+// to the lowest RRPV, in PseudoLRU order on ties.
+func (p *PerceptronVictimFinder) bucketOrder(set *Set) []int {
+	ways := make([]int, 0, len(set.Blocks))
+
+	for _, way := range pseudoLRUOrder(set) {
+		block := set.Blocks[way]
+		if block.IsValid && !block.IsLocked {
+			ways = append(ways, way)
+		}
+	}
+
+	sort.SliceStable(ways, func(i, j int) bool {
+		return set.Blocks[ways[i]].RRPV > set.Blocks[ways[j]].RRPV
+	})
+
+	return ways
+}
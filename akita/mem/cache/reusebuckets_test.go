@@ -0,0 +1,92 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reuse buckets", func() {
+	var (
+		p          *PerceptronVictimFinder
+		dead, live uint64
+	)
+
+	BeforeEach(func() {
+		p = MakePerceptronBuilder().
+			WithTheta(16).
+			WithTrainingSampleRate(1).
+			WithReuseBuckets(ReuseBucketThresholds{
+				Near: -8, Far: 0, Never: 8,
+			}).
+			Build()
+		p.SetStrictMode(true)
+
+		dead = 0x10001 << p.featureShift
+		live = 0x20002 << p.featureShift
+		for i := 0; i < 32; i++ {
+			p.TrainOnEviction(dead)
+			p.TrainOnHit(live)
+		}
+	})
+
+	It("should split the prediction sums into buckets", func() {
+		Expect(p.PredictReuseBucket(dead, nil)).To(Equal(ReuseNever))
+		Expect(p.PredictReuseBucket(live, nil)).To(Equal(ReuseImmediate))
+		Expect(p.PredictReuseBucket(0, nil)).To(Equal(ReuseFar))
+
+		p.SetReuseBuckets(nil)
+		Expect(p.PredictReuseBucket(dead, nil)).To(Equal(ReuseNever))
+		Expect(p.PredictReuseBucket(0, nil)).To(Equal(ReuseImmediate))
+	})
+
+	It("should insert the fills with the RRPV of their bucket", func() {
+		d := NewDirectory(1, 4, 64, p)
+
+		fill := func(addr uint64) *Block {
+			block := d.FindVictim(addr)
+			block.Tag = addr
+			block.IsValid = true
+			d.Visit(block)
+
+			return block
+		}
+
+		Expect(fill(dead).RRPV).To(Equal(ReuseNever.RRPV()))
+		Expect(fill(0).RRPV).To(Equal(ReuseFar.RRPV()))
+
+		block := fill(live)
+		Expect(block.RRPV).To(Equal(ReuseImmediate.RRPV()))
+
+		counts := p.BucketCounts()
+		Expect(counts[ReuseNever]).To(Equal(uint64(1)))
+		Expect(counts[ReuseFar]).To(Equal(uint64(1)))
+		Expect(counts[ReuseImmediate]).To(Equal(uint64(1)))
+
+		block = d.Lookup(0, 0)
+		d.Visit(block)
+		Expect(block.RRPV).To(Equal(ReuseImmediate.RRPV()))
+	})
+
+	It("should evict the highest RRPV and age the set", func() {
+		set := makeTestSet(4)
+		for i, rrpv := range []uint8{1, 2, 0, 1} {
+			set.Blocks[i].IsValid = true
+			set.Blocks[i].RRPV = rrpv
+		}
+
+		Expect(p.FindVictims(set, nil, 4)).To(Equal([]*Block{
+			set.Blocks[1], set.Blocks[0], set.Blocks[3], set.Blocks[2],
+		}))
+
+		Expect(p.FindVictim(set)).To(BeIdenticalTo(set.Blocks[1]))
+		for i, rrpv := range []uint8{2, 3, 1, 2} {
+			Expect(set.Blocks[i].RRPV).To(Equal(rrpv))
+		}
+	})
+
+	It("should reject thresholds that are not increasing", func() {
+		Expect(func() {
+			p.SetReuseBuckets(&ReuseBucketThresholds{Near: 0, Far: 0, Never: 1})
+		}).To(Panic())
+	})
+})
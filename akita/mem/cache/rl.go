@@ -0,0 +1,418 @@
+package cache
+
+import (
+	"math/rand"
+	"sort"
+)
+
+const (
+	// The Q-values are fixed point with rlValueBits fractional bits, and a
+	// reward of one is rlReward.
+	rlValueBits = 8
+	rlReward    = 1 << rlValueBits
+
+	// The Q-values saturate at ±rlValueMax.
+	rlValueMax = 1 << 20
+)
+
+// An RLConfig configures an RLVictimFinder.
+type RLConfig struct {
+	// The Q-table has 2^StateBits rows. Defaults to 12.
+	StateBits uint
+
+	// The Q-values move by 2^-LearningShift of the temporal-difference
+	// error. Defaults to 2.
+	LearningShift uint
+
+	// Future rewards are discounted by 1 - 2^-DiscountShift. Defaults to 3.
+	DiscountShift uint
+
+	// One decision out of every ExplorationInterval takes a pseudo-random
+	// action. Defaults to 16; a negative value disables exploration.
+	ExplorationInterval int
+}
+
+// RLStats counts the decisions of an RLVictimFinder and the rewards they
+// earned.
+type RLStats struct {
+	Decisions    uint64
+	Explorations uint64
+	Bypasses     uint64
+
+	// Hits counts the hits on lines filled by a decision, which are
+	// rewarded. Rereferences counts the misses on lines that a decision
+	// evicted or bypassed, which are penalized.
+	Hits         uint64
+	Rereferences uint64
+}
+
+// rlDecision is the last decision made in a set. Its reward accumulates
+// until the next decision in the set, which updates its Q-value.
+type rlDecision struct {
+	state  uint32
+	action int
+
+	// The tags of the set when the decision was made, to find the line
+	// that a fill replaced.
+	tags []uint64
+
+	// The line that the decision gave up: the evicted line, or the missing
+	// line if the decision was to bypass.
+	gaveUp       uint64
+	hasGivenUp   bool
+	awaitingFill bool
+
+	inserted    *Block
+	insertedTag uint64
+
+	reward int32
+}
+
+// RLVictimFinder is a tabular Q-learning replacement agent, a reinforcement
+// learning baseline for the supervised predictors. The state of a decision
+// hashes the number of resident lines that have been reused, the PseudoLRU
+// victim way, and the signature of the missing line, which is its PC or, if
+// the PC is unknown, its memory region. The actions are to evict way i or to
+// bypass the fill.
+//
+// A decision earns a reward of one for every hit on the line that it filled,
+// and a penalty of one if the line that it evicted or bypassed misses again
+// before the next decision in the set. The next decision in the set updates
+// the Q-value of the previous one with its reward and the discounted best
+// Q-value of the new state.
+//
+// All Q-values start at zero and ties go to the PseudoLRU order, so an
+// untrained agent behaves like PseudoLRU. Bypassing is only chosen if it is
+// strictly better than every eviction. The directory cannot refuse a fill,
+// so a bypass decision evicts the best way and is reported through
+// AdviseInsertion; whichever way is finally filled is the action that is
+// trained. Invalid blocks are filled without a decision.
+type RLVictimFinder struct {
+	config RLConfig
+
+	// q[state*numActions+action] is a Q-value. The table is allocated at
+	// the first decision, when the associativity is known.
+	q          []int32
+	numActions int
+
+	decisions map[int]*rlDecision
+	last      *rlDecision
+
+	explorationCounter uint64
+	src                rand.Source
+
+	stats RLStats
+}
+
+// NewRLVictimFinder returns a Q-learning victim finder with the default
+// configuration.
+func NewRLVictimFinder() *RLVictimFinder {
+	return NewRLVictimFinderWithConfig(RLConfig{})
+}
+
+// NewRLVictimFinderWithConfig returns a Q-learning victim finder with the
+// configuration.
+func NewRLVictimFinderWithConfig(config RLConfig) *RLVictimFinder {
+	if config.StateBits == 0 {
+		config.StateBits = 12
+	}
+
+	if config.LearningShift == 0 {
+		config.LearningShift = 2
+	}
+
+	if config.DiscountShift == 0 {
+		config.DiscountShift = 3
+	}
+
+	if config.ExplorationInterval == 0 {
+		config.ExplorationInterval = 16
+	}
+
+	return &RLVictimFinder{
+		config:    config,
+		decisions: make(map[int]*rlDecision),
+		src:       rand.NewSource(1),
+	}
+}
+
+// Config returns the configuration, with the defaults filled in.
+func (r *RLVictimFinder) Config() RLConfig {
+	return r.config
+}
+
+// Stats returns the decision statistics.
+func (r *RLVictimFinder) Stats() RLStats {
+	return r.stats
+}
+
+// SetRandSource replaces the source of the exploratory actions, which is
+// seeded with 1 by default.
+func (r *RLVictimFinder) SetRandSource(src rand.Source) {
+	r.src = src
+}
+
+// QValue returns the Q-value of an action in the state of the access to the
+// set. The actions are the ways of the set, followed by the bypass action.
+func (r *RLVictimFinder) QValue(
+	set *Set,
+	ctx *VictimContext,
+	action int,
+) float64 {
+	if r.q == nil {
+		return 0
+	}
+
+	return float64(r.q[r.index(r.state(set, ctx), action)]) / rlReward
+}
+
+// FindVictim returns the PseudoLRU victim. Without a context there is no
+// state to decide on.
+func (r *RLVictimFinder) FindVictim(set *Set) *Block {
+	return pseudoLRUVictims.FindVictim(set)
+}
+
+// FindVictimWithContext penalizes the last decision of the set if it gave up
+// the missing line, updates its Q-value, and decides on the new miss.
+func (r *RLVictimFinder) FindVictimWithContext(
+	set *Set,
+	ctx *VictimContext,
+) *Block {
+	if ctx == nil || len(set.Blocks) == 0 {
+		return r.FindVictim(set)
+	}
+
+	setID := set.Blocks[0].SetID
+	prev := r.decisions[setID]
+
+	if prev != nil && prev.hasGivenUp && prev.gaveUp == contextLine(ctx) {
+		prev.reward -= rlReward
+		prev.hasGivenUp = false
+		r.stats.Rereferences++
+	}
+
+	if b := firstInvalidBlock(set); b != nil {
+		if prev != nil {
+			prev.awaitingFill = false
+		}
+
+		return b
+	}
+
+	ways := r.candidates(set)
+	if len(ways) == 0 {
+		return nil
+	}
+
+	if r.q == nil {
+		r.numActions = len(set.Blocks) + 1
+		r.q = make([]int32, r.numActions<<r.config.StateBits)
+	}
+
+	state := r.state(set, ctx)
+	if prev != nil {
+		r.update(prev, state)
+	}
+
+	d := r.decide(set, ctx, state, ways)
+	r.decisions[setID] = d
+	r.last = d
+
+	if d.action == r.bypassAction() {
+		return set.Blocks[r.bestWay(state, ways)]
+	}
+
+	return set.Blocks[d.action]
+}
+
+// FindVictims returns up to n candidates from the highest to the lowest
+// Q-value of evicting them, in PseudoLRU order on ties, without making a
+// decision.
+func (r *RLVictimFinder) FindVictims(
+	set *Set,
+	ctx *VictimContext,
+	n int,
+) []*Block {
+	if ctx == nil || r.q == nil {
+		return rankCandidates(set, pseudoLRUOrder(set), n)
+	}
+
+	state := r.state(set, ctx)
+	ways := r.candidates(set)
+
+	sort.SliceStable(ways, func(i, j int) bool {
+		return r.q[r.index(state, ways[i])] > r.q[r.index(state, ways[j])]
+	})
+
+	return rankCandidates(set, ways, n)
+}
+
+// AdviseInsertion recommends bypassing the fill if the last decision was to
+// bypass the line of the access.
+func (r *RLVictimFinder) AdviseInsertion(
+	ctx *VictimContext,
+) InsertionPriority {
+	d := r.last
+	if d != nil && d.awaitingFill && d.action == r.bypassAction() &&
+		d.gaveUp == contextLine(ctx) {
+		return InsertBypass
+	}
+
+	return InsertMRU
+}
+
+// Insert records the way that the last decision of the set finally filled.
+func (r *RLVictimFinder) Insert(_ *Set, block *Block) {
+	d := r.decisions[block.SetID]
+	if d == nil || !d.awaitingFill {
+		return
+	}
+
+	d.awaitingFill = false
+	d.action = block.WayID
+	d.gaveUp = d.tags[block.WayID]
+	d.hasGivenUp = true
+	d.inserted = block
+	d.insertedTag = block.Tag
+}
+
+// Touch rewards the last decision of the set for a hit on the line that it
+// filled.
+func (r *RLVictimFinder) Touch(_ *Set, block *Block) {
+	d := r.decisions[block.SetID]
+	if d == nil || d.inserted != block || d.insertedTag != block.Tag {
+		return
+	}
+
+	d.reward += rlReward
+	r.stats.Hits++
+}
+
+func (r *RLVictimFinder) bypassAction() int {
+	return r.numActions - 1
+}
+
+func (r *RLVictimFinder) index(state uint32, action int) int {
+	return int(state)*r.numActions + action
+}
+
+// state hashes the number of reused resident lines, the PseudoLRU victim
+// way, and the signature of the missing line.
+func (r *RLVictimFinder) state(set *Set, ctx *VictimContext) uint32 {
+	reused := uint64(0)
+	for _, block := range set.Blocks {
+		if block.IsValid && block.HitCount > 0 {
+			reused++
+		}
+	}
+
+	recency := uint64(pseudoLRUOrder(set)[0])
+
+	signature := ctx.Address >> shipRegionShift
+	if ctx.PC != 0 {
+		signature = ctx.PC
+	}
+
+	key := mixLineHash(signature) ^ reused<<8 ^ recency
+
+	return uint32(mixLineHash(key) & (1<<r.config.StateBits - 1))
+}
+
+// candidates returns the ways that can be evicted, in PseudoLRU order.
+func (r *RLVictimFinder) candidates(set *Set) []int {
+	ways := make([]int, 0, len(set.Blocks))
+
+	for _, way := range pseudoLRUOrder(set) {
+		block := set.Blocks[way]
+		if block.IsValid && !block.IsLocked && !isPinned(block) {
+			ways = append(ways, way)
+		}
+	}
+
+	return ways
+}
+
+// decide takes the best action in the state, or a pseudo-random one when it
+// is time to explore.
+func (r *RLVictimFinder) decide(
+	set *Set,
+	ctx *VictimContext,
+	state uint32,
+	ways []int,
+) *rlDecision {
+	d := &rlDecision{
+		state:        state,
+		tags:         make([]uint64, len(set.Blocks)),
+		awaitingFill: true,
+	}
+
+	for i, block := range set.Blocks {
+		d.tags[i] = block.Tag
+	}
+
+	r.stats.Decisions++
+	r.explorationCounter++
+
+	interval := r.config.ExplorationInterval
+	if interval > 0 && r.explorationCounter%uint64(interval) == 0 {
+		r.stats.Explorations++
+		choice := randomValue(r.src, len(ways)+1)
+		if choice < len(ways) {
+			d.action = ways[choice]
+		} else {
+			d.action = r.bypassAction()
+		}
+	} else {
+		d.action = r.bestWay(state, ways)
+		if r.q[r.index(state, r.bypassAction())] > r.q[r.index(state, d.action)] {
+			d.action = r.bypassAction()
+		}
+	}
+
+	if d.action == r.bypassAction() {
+		d.gaveUp = contextLine(ctx)
+		d.hasGivenUp = true
+		r.stats.Bypasses++
+	}
+
+	return d
+}
+
+// bestWay returns the way with the highest Q-value, the first one in
+// PseudoLRU order on ties.
+func (r *RLVictimFinder) bestWay(state uint32, ways []int) int {
+	best := ways[0]
+
+	for _, way := range ways[1:] {
+		if r.q[r.index(state, way)] > r.q[r.index(state, best)] {
+			best = way
+		}
+	}
+
+	return best
+}
+
+// update moves the Q-value of the decision toward its reward plus the
+// discounted best Q-value of the next state.
+func (r *RLVictimFinder) update(d *rlDecision, next uint32) {
+	best := r.q[r.index(next, 0)]
+	for action := 1; action < r.numActions; action++ {
+		best = max(best, r.q[r.index(next, action)])
+	}
+
+	target := d.reward + best - best>>r.config.DiscountShift
+
+	q := &r.q[r.index(d.state, d.action)]
+	*q += (target - *q) >> r.config.LearningShift
+	*q = max(min(*q, rlValueMax), -rlValueMax)
+}
+
+// contextLine returns the cache line of the access, or its address if the
+// line is not set.
+func contextLine(ctx *VictimContext) uint64 {
+	if ctx.CacheLineID != 0 {
+		return ctx.CacheLineID
+	}
+
+	return ctx.Address
+}
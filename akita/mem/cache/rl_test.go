@@ -0,0 +1,116 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RLVictimFinder", func() {
+	var (
+		vf *RLVictimFinder
+		d  *DirectoryImpl
+	)
+
+	// access looks up the line and fills it on a miss, unless the agent
+	// asks to bypass it. It reports whether the access hit.
+	access := func(addr, pc uint64) bool {
+		if block := d.Lookup(0, addr); block != nil {
+			d.Visit(block)
+			return true
+		}
+
+		ctx := &VictimContext{Address: addr, CacheLineID: addr, PC: pc}
+
+		block := d.FindVictimWithContext(addr, ctx)
+		if vf.AdviseInsertion(ctx) == InsertBypass {
+			return false
+		}
+
+		block.Tag = addr
+		block.IsValid = true
+		d.Visit(block)
+
+		return false
+	}
+
+	BeforeEach(func() {
+		vf = NewRLVictimFinderWithConfig(RLConfig{ExplorationInterval: -1})
+		d = NewDirectory(1, 2, 64, vf)
+	})
+
+	It("should fill in the defaults", func() {
+		config := NewRLVictimFinder().Config()
+
+		Expect(config.StateBits).To(Equal(uint(12)))
+		Expect(config.LearningShift).To(Equal(uint(2)))
+		Expect(config.DiscountShift).To(Equal(uint(3)))
+		Expect(config.ExplorationInterval).To(Equal(16))
+	})
+
+	It("should start as PseudoLRU", func() {
+		access(0x0, 0x10)
+		access(0x40, 0x10)
+		access(0x0, 0x10)
+
+		victim := d.FindVictimWithContext(0x80,
+			&VictimContext{Address: 0x80, PC: 0x10})
+		Expect(victim.Tag).To(Equal(uint64(0x40)))
+	})
+
+	It("should learn to keep the line that is reused", func() {
+		misses := 0
+
+		for i := uint64(0); i < 64; i++ {
+			hit := access(0x0, 0x10)
+			if i >= 32 && !hit {
+				misses++
+			}
+
+			access(0x10000+2*i*64, 0x20)
+			access(0x10000+(2*i+1)*64, 0x20)
+		}
+
+		Expect(misses).To(BeZero())
+		Expect(vf.Stats().Rereferences).NotTo(BeZero())
+	})
+
+	It("should learn to bypass a stream", func() {
+		misses := 0
+
+		for i := uint64(0); i < 64; i++ {
+			hitA := access(0x0, 0x10)
+			hitC := access(0x40, 0x30)
+			if i >= 32 && !(hitA && hitC) {
+				misses++
+			}
+
+			access(0x10000+i*64, 0x20)
+		}
+
+		Expect(misses).To(BeZero())
+		Expect(vf.Stats().Bypasses).NotTo(BeZero())
+	})
+
+	It("should reward the hits on the filled line", func() {
+		access(0x0, 0x10)
+		access(0x40, 0x10)
+		access(0x80, 0x10)
+		access(0x80, 0x10)
+		access(0x80, 0x10)
+
+		Expect(vf.Stats().Decisions).To(Equal(uint64(1)))
+		Expect(vf.Stats().Hits).To(Equal(uint64(2)))
+	})
+
+	It("should explore", func() {
+		vf = NewRLVictimFinderWithConfig(RLConfig{ExplorationInterval: 2})
+		d = NewDirectory(1, 2, 64, vf)
+
+		for i := uint64(0); i < 10; i++ {
+			access(i*64, 0x10)
+		}
+
+		Expect(vf.Stats().Decisions).To(Equal(uint64(8)))
+		Expect(vf.Stats().Explorations).To(Equal(uint64(4)))
+	})
+})
@@ -0,0 +1,219 @@
+package cache
+
+import "math/rand"
+
+// An InsertionObserver is an AccessObserver that handles fills differently
+// from hits. The directory calls Insert instead of Touch when a visit fills
+// the block.
+type InsertionObserver interface {
+	AccessObserver
+	Insert(set *Set, block *Block)
+}
+
+// An EvictionObserver is a VictimFinder that learns from evictions. When a
+// visit fills a block whose previous line was valid, the directory calls
+// Evict before the fill resets the block metadata, so that the block still
+// describes the evicted line, except for its tag.
+type EvictionObserver interface {
+	Evict(set *Set, block *Block)
+}
+
+// RRIPInsertion selects how an RRIPVictimFinder inserts new blocks.
+type RRIPInsertion int
+
+// RRIP insertion policies.
+const (
+	// Static RRIP inserts every block with a long re-reference interval.
+	RRIPStatic RRIPInsertion = iota
+
+	// Bimodal RRIP inserts most blocks with a distant re-reference
+	// interval, which protects the cache from thrashing.
+	RRIPBimodal
+
+	// Dynamic RRIP chooses between static and bimodal insertion with set
+	// dueling.
+	RRIPDynamic
+)
+
+const (
+	// rripMaxRRPV is the distant re-reference prediction value of a 2-bit
+	// RRPV.
+	rripMaxRRPV = 3
+
+	// Bimodal insertion uses the long interval for one out of every
+	// rripBimodalInterval fills.
+	rripBimodalInterval = 32
+
+	// One set out of every rripLeaderInterval leads for static insertion,
+	// and the next one for bimodal insertion.
+	rripLeaderInterval = 32
+
+	// rripPSELMax is the saturation value of the 10-bit policy selector.
+	rripPSELMax = 1023
+)
+
+// RRIPVictimFinder implements the RRIP family of policies (Jaleel et al.,
+// ISCA 2010) with 2-bit re-reference prediction values stored in
+// Block.RRPV. Hits promote the block to RRPV 0. The victim is the first
+// block with the distant RRPV; if there is none, all the RRPVs are aged
+// first. The bimodal fills are spread deterministically instead of randomly,
+// unless SetRandSource gives the policy a source to draw them from.
+type RRIPVictimFinder struct {
+	insertion RRIPInsertion
+
+	bimodalFills uint64
+	psel         int
+
+	// The source of the bimodal fills, or nil to spread them evenly.
+	src rand.Source
+}
+
+// NewSRRIPVictimFinder returns a static RRIP victim finder.
+func NewSRRIPVictimFinder() *RRIPVictimFinder {
+	return &RRIPVictimFinder{insertion: RRIPStatic}
+}
+
+// NewBRRIPVictimFinder returns a bimodal RRIP victim finder.
+func NewBRRIPVictimFinder() *RRIPVictimFinder {
+	return &RRIPVictimFinder{insertion: RRIPBimodal}
+}
+
+// NewDRRIPVictimFinder returns a dynamic RRIP victim finder.
+func NewDRRIPVictimFinder() *RRIPVictimFinder {
+	return &RRIPVictimFinder{
+		insertion: RRIPDynamic,
+		psel:      rripPSELMax / 2,
+	}
+}
+
+// Insertion returns the insertion policy.
+func (r *RRIPVictimFinder) Insertion() RRIPInsertion {
+	return r.insertion
+}
+
+// PSEL returns the policy selector of dynamic RRIP. Values above the midpoint
+// make the follower sets use bimodal insertion.
+func (r *RRIPVictimFinder) PSEL() int {
+	return r.psel
+}
+
+// SetRandSource makes the bimodal insertion draw the long interval with a
+// probability of 1/32 from the source, like the original proposal, instead
+// of using it for every 32nd fill.
+func (r *RRIPVictimFinder) SetRandSource(src rand.Source) {
+	r.src = src
+}
+
+// Touch promotes a hit block to the near-immediate re-reference interval.
+func (r *RRIPVictimFinder) Touch(_ *Set, block *Block) {
+	block.RRPV = 0
+}
+
+// Insert sets the RRPV of a filled block according to the insertion policy.
+func (r *RRIPVictimFinder) Insert(_ *Set, block *Block) {
+	insertion := r.insertion
+
+	if insertion == RRIPDynamic {
+		insertion = r.duel(block.SetID)
+	}
+
+	block.RRPV = rripMaxRRPV - 1
+	if insertion == RRIPBimodal {
+		if !r.longBimodalFill() {
+			block.RRPV = rripMaxRRPV
+		}
+	}
+}
+
+// longBimodalFill tells if a bimodal fill gets the long re-reference
+// interval.
+func (r *RRIPVictimFinder) longBimodalFill() bool {
+	if r.src != nil {
+		return randomValue(r.src, rripBimodalInterval) == 0
+	}
+
+	r.bimodalFills++
+
+	return r.bimodalFills%rripBimodalInterval == 0
+}
+
+// duel records a fill, which follows a miss, and returns the insertion
+// policy of the set. The leader sets always use their own policy and move
+// the selector toward the other one when they miss.
+func (r *RRIPVictimFinder) duel(setID int) RRIPInsertion {
+	switch setID % rripLeaderInterval {
+	case 0:
+		if r.psel < rripPSELMax {
+			r.psel++
+		}
+
+		return RRIPStatic
+	case 1:
+		if r.psel > 0 {
+			r.psel--
+		}
+
+		return RRIPBimodal
+	}
+
+	if r.psel > rripPSELMax/2 {
+		return RRIPBimodal
+	}
+
+	return RRIPStatic
+}
+
+// FindVictim returns the first invalid block, or the first unlocked block
+// with the distant RRPV, aging the set until there is one. It returns nil if
+// every block is locked.
+func (r *RRIPVictimFinder) FindVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	for round := 0; round <= rripMaxRRPV; round++ {
+		for _, block := range set.Blocks {
+			if !block.IsLocked && block.RRPV >= rripMaxRRPV {
+				return block
+			}
+		}
+
+		for _, block := range set.Blocks {
+			if block.RRPV < rripMaxRRPV {
+				block.RRPV++
+			}
+		}
+	}
+
+	// Every block is locked.
+	return nil
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (r *RRIPVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return r.FindVictim(set)
+}
+
+// FindVictims returns up to n candidates from the most distant to the most
+// imminent RRPV, in way order within each RRPV, without aging the set.
+func (r *RRIPVictimFinder) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	ways := make([]int, 0, len(set.Blocks))
+
+	for rrpv := rripMaxRRPV; rrpv >= 0; rrpv-- {
+		for way, block := range set.Blocks {
+			if int(block.RRPV) == rrpv ||
+				(rrpv == rripMaxRRPV && block.RRPV > rripMaxRRPV) {
+				ways = append(ways, way)
+			}
+		}
+	}
+
+	return rankCandidates(set, ways, n)
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RRIPVictimFinder", func() {
+	fill := func(d *DirectoryImpl, setID int) *Block {
+		addr := uint64(setID) * uint64(d.BlockSize)
+		block := d.FindVictim(addr)
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	It("should insert with a long interval and promote on hits", func() {
+		d := NewDirectory(1, 4, 64, NewSRRIPVictimFinder())
+
+		block := fill(d, 0)
+		Expect(block.RRPV).To(Equal(uint8(2)))
+
+		d.Visit(block)
+		Expect(block.RRPV).To(BeZero())
+	})
+
+	It("should age the set until a block is distant", func() {
+		vf := NewSRRIPVictimFinder()
+		set := makeTestSet(4)
+		for i, rrpv := range []uint8{0, 2, 1, 2} {
+			set.Blocks[i].IsValid = true
+			set.Blocks[i].RRPV = rrpv
+		}
+
+		Expect(vf.FindVictims(set, nil, 4)).To(Equal([]*Block{
+			set.Blocks[1], set.Blocks[3], set.Blocks[2], set.Blocks[0],
+		}))
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[1]))
+		Expect(set.Blocks[0].RRPV).To(Equal(uint8(1)))
+
+		set.Blocks[1].IsLocked = true
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[3]))
+	})
+
+	It("should return nil if every block is locked", func() {
+		set := makeTestSet(2)
+		for _, b := range set.Blocks {
+			b.IsValid = true
+			b.IsLocked = true
+		}
+
+		Expect(NewSRRIPVictimFinder().FindVictim(set)).To(BeNil())
+	})
+
+	It("should insert most blocks as distant in bimodal mode", func() {
+		d := NewDirectory(1, 4, 64, NewBRRIPVictimFinder())
+
+		distant := 0
+		for i := 0; i < 64; i++ {
+			if fill(d, 0).RRPV == rripMaxRRPV {
+				distant++
+			}
+		}
+
+		Expect(distant).To(Equal(62))
+	})
+
+	It("should follow the leader sets that miss less", func() {
+		vf := NewDRRIPVictimFinder()
+		d := NewDirectory(64, 2, 64, vf)
+
+		Expect(fill(d, 2).RRPV).To(Equal(uint8(2)))
+
+		for i := 0; i < 10; i++ {
+			fill(d, 0)
+		}
+
+		Expect(vf.PSEL()).To(BeNumerically(">", rripPSELMax/2))
+		Expect(fill(d, 2).RRPV).To(Equal(uint8(rripMaxRRPV)))
+		Expect(fill(d, 32).RRPV).To(Equal(uint8(2)))
+	})
+})
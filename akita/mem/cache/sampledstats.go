@@ -0,0 +1,216 @@
+package cache
+
+import "sort"
+
+// A Reservoir keeps a uniform random sample of at most a fixed number of
+// values out of a stream of unknown length. The sample is reproducible for a
+// given seed.
+type Reservoir struct {
+	samples []float64
+	seen    uint64
+	state   uint64
+}
+
+// NewReservoir creates a reservoir that keeps up to capacity values.
+func NewReservoir(capacity int, seed uint64) *Reservoir {
+	if capacity <= 0 {
+		panic("reservoir capacity must be positive")
+	}
+
+	return &Reservoir{
+		samples: make([]float64, 0, capacity),
+		state:   seed,
+	}
+}
+
+// Add offers a value to the reservoir.
+func (r *Reservoir) Add(v float64) {
+	r.seen++
+
+	if len(r.samples) < cap(r.samples) {
+		r.samples = append(r.samples, v)
+		return
+	}
+
+	r.state += 0x9e3779b97f4a7c15
+	if i := mixLineHash(r.state) % r.seen; i < uint64(len(r.samples)) {
+		r.samples[i] = v
+	}
+}
+
+// Seen returns the number of values offered to the reservoir.
+func (r *Reservoir) Seen() uint64 {
+	return r.seen
+}
+
+// Samples returns the values currently kept, in no particular order.
+func (r *Reservoir) Samples() []float64 {
+	return r.samples
+}
+
+// Quantile estimates the q-quantile (0 <= q <= 1) of the stream. It returns
+// 0 if no value has been added.
+func (r *Reservoir) Quantile(q float64) float64 {
+	if len(r.samples) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), r.samples...)
+	sort.Float64s(sorted)
+
+	i := int(q * float64(len(sorted)-1))
+	if i < 0 {
+		i = 0
+	}
+
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+
+	return sorted[i]
+}
+
+// SetGroupCounters are the access counters of a group of consecutive sets.
+type SetGroupCounters struct {
+	Accesses uint64
+	Hits     uint64
+	Misses   uint64
+}
+
+// sampledStatsGroupBytes and sampledStatsSampleBytes are the sizes used to
+// fit the statistics in the memory budget.
+const (
+	sampledStatsGroupBytes  = 24
+	sampledStatsSampleBytes = 8
+)
+
+// SampledStatsConfig configures SampledStats.
+type SampledStatsConfig struct {
+	// Approximate number of bytes that the statistics may use. Half of the
+	// budget goes to the per-set-group counters and half to the reservoirs.
+	MaxBytes int
+
+	// Seed of the reservoir sampling.
+	Seed uint64
+}
+
+// SampledStats is a memory-bounded alternative to full-resolution statistics
+// for simulations with many cache instances. Per-set counters are aggregated
+// into as many set groups as the budget allows, down to a single global
+// group, and the distributions of block reuse and lifetime are kept as
+// reservoir samples.
+type SampledStats struct {
+	setsPerGroup int
+	groups       []SetGroupCounters
+
+	// Hits received by each evicted block, and the number of accesses to its
+	// set between its fill and its eviction.
+	reuse    *Reservoir
+	lifetime *Reservoir
+}
+
+// NewSampledStats creates statistics for a directory with numSets sets.
+func NewSampledStats(config SampledStatsConfig, numSets int) *SampledStats {
+	half := config.MaxBytes / 2
+
+	numGroups := half / sampledStatsGroupBytes
+	if numGroups > numSets {
+		numGroups = numSets
+	}
+
+	if numGroups < 1 {
+		numGroups = 1
+	}
+
+	numSamples := half / 2 / sampledStatsSampleBytes
+	if numSamples < 1 {
+		numSamples = 1
+	}
+
+	return &SampledStats{
+		setsPerGroup: (numSets + numGroups - 1) / numGroups,
+		groups:       make([]SetGroupCounters, numGroups),
+		reuse:        NewReservoir(numSamples, config.Seed),
+		lifetime:     NewReservoir(numSamples, config.Seed+1),
+	}
+}
+
+// SetSampledStats attaches memory-bounded statistics to the directory.
+func (d *DirectoryImpl) SetSampledStats(s *SampledStats) {
+	d.sampledStats = s
+}
+
+// SampledStats returns the attached statistics, if any.
+func (d *DirectoryImpl) SampledStats() *SampledStats {
+	return d.sampledStats
+}
+
+func (s *SampledStats) group(setID int) *SetGroupCounters {
+	return &s.groups[setID/s.setsPerGroup]
+}
+
+func (s *SampledStats) recordAccess(block *Block, isFill bool) {
+	if s == nil || isFill {
+		return
+	}
+
+	g := s.group(block.SetID)
+	g.Accesses++
+	g.Hits++
+}
+
+func (s *SampledStats) recordVictim(setID int, setAccesses uint64, block *Block) {
+	if s == nil {
+		return
+	}
+
+	g := s.group(setID)
+	g.Accesses++
+	g.Misses++
+
+	if block == nil || !block.IsValid {
+		return
+	}
+
+	s.reuse.Add(float64(block.HitCount))
+	s.lifetime.Add(float64(setAccesses - block.FillTime))
+}
+
+// SetsPerGroup returns the number of sets aggregated into one group.
+func (s *SampledStats) SetsPerGroup() int {
+	return s.setsPerGroup
+}
+
+// Groups returns the counters of all the set groups.
+func (s *SampledStats) Groups() []SetGroupCounters {
+	return s.groups
+}
+
+// Total returns the counters summed over all the sets.
+func (s *SampledStats) Total() SetGroupCounters {
+	var t SetGroupCounters
+	for _, g := range s.groups {
+		t.Accesses += g.Accesses
+		t.Hits += g.Hits
+		t.Misses += g.Misses
+	}
+
+	return t
+}
+
+// Reuse returns the sample of the number of hits of evicted blocks.
+func (s *SampledStats) Reuse() *Reservoir {
+	return s.reuse
+}
+
+// Lifetime returns the sample of the number of set accesses between the fill
+// and the eviction of evicted blocks.
+func (s *SampledStats) Lifetime() *Reservoir {
+	return s.lifetime
+}
+
+// MemoryBytes returns the approximate memory used by the statistics.
+func (s *SampledStats) MemoryBytes() int {
+	return len(s.groups)*sampledStatsGroupBytes +
+		(cap(s.reuse.samples)+cap(s.lifetime.samples))*sampledStatsSampleBytes
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reservoir", func() {
+	It("should keep a bounded, reproducible sample", func() {
+		a := NewReservoir(100, 7)
+		b := NewReservoir(100, 7)
+		for i := 0; i < 10000; i++ {
+			a.Add(float64(i))
+			b.Add(float64(i))
+		}
+
+		Expect(a.Samples()).To(HaveLen(100))
+		Expect(a.Seen()).To(Equal(uint64(10000)))
+		Expect(a.Samples()).To(Equal(b.Samples()))
+		Expect(a.Quantile(0.5)).To(BeNumerically("~", 5000, 1500))
+	})
+})
+
+var _ = Describe("SampledStats", func() {
+	It("should aggregate sets to fit the budget", func() {
+		s := NewSampledStats(SampledStatsConfig{MaxBytes: 4 * 24 * 2}, 64)
+
+		Expect(s.Groups()).To(HaveLen(4))
+		Expect(s.SetsPerGroup()).To(Equal(16))
+		Expect(s.MemoryBytes()).To(BeNumerically("<=", 4*24*2))
+
+		global := NewSampledStats(SampledStatsConfig{}, 64)
+		Expect(global.Groups()).To(HaveLen(1))
+	})
+
+	It("should count the directory accesses", func() {
+		d := NewDirectory(4, 2, 64, NewLRUVictimFinder())
+		s := NewSampledStats(SampledStatsConfig{MaxBytes: 1024}, 4)
+		d.SetSampledStats(s)
+
+		access := func(addr uint64) {
+			if b := d.Lookup(1, addr); b != nil {
+				d.Visit(b)
+				return
+			}
+
+			b := d.FindVictim(addr)
+			b.PID, b.Tag, b.IsValid = 1, addr, true
+			d.Visit(b)
+		}
+
+		for _, addr := range []uint64{0x0, 0x0, 0x100, 0x200, 0x300} {
+			access(addr)
+		}
+
+		Expect(s.Total()).To(Equal(SetGroupCounters{
+			Accesses: 5, Hits: 1, Misses: 4,
+		}))
+		Expect(s.Reuse().Seen()).To(Equal(uint64(2)))
+		Expect(s.Lifetime().Seen()).To(Equal(uint64(2)))
+	})
+})
@@ -0,0 +1,241 @@
+package cache
+
+import "github.com/sarchlab/akita/v4/mem/vm"
+
+// A SamplerConfig describes the sampler of a perceptron predictor.
+type SamplerConfig struct {
+	// Number of cache sets that are sampled. Defaults to 64, or to the
+	// number of cache sets if there are fewer.
+	NumSampledSets int
+
+	// Number of entries per sampled set. Defaults to 16.
+	Associativity int
+
+	// Number of tag bits kept per entry. Defaults to 15.
+	PartialTagBits uint
+
+	// Geometry of the cache, used to find the set of an address. The block
+	// size defaults to 64.
+	NumCacheSets int
+	BlockSize    int
+}
+
+// SamplerStats counts the sampler activity.
+type SamplerStats struct {
+	Accesses  uint64 // Accesses to sampled sets
+	Hits      uint64 // Accesses that hit a sampler entry
+	Evictions uint64 // Valid entries replaced on a sampler miss
+}
+
+// samplerEntry remembers the last access to a line of a sampled set and the
+// prediction made for it.
+type samplerEntry struct {
+	valid    bool
+	tag      uint64
+	addr, pc uint64
+	pid      vm.PID
+	sum      int32
+	lastUse  uint64
+}
+
+// A tagSampler tracks the lines of a few sampled sets with partial tags and
+// LRU replacement, as in the sampling dead-block predictor of Khan et al.
+// The predictors that train through it learn from the outcome of the access
+// recorded in an entry when the entry is reused or replaced.
+type tagSampler struct {
+	config  SamplerConfig
+	stride  int
+	tagMask uint64
+	sets    [][]samplerEntry
+	now     uint64
+	stats   SamplerStats
+}
+
+func newTagSampler(config SamplerConfig) *tagSampler {
+	if config.NumCacheSets <= 0 {
+		panic("sampler needs the number of cache sets")
+	}
+
+	if config.NumSampledSets <= 0 {
+		config.NumSampledSets = 64
+	}
+
+	if config.NumSampledSets > config.NumCacheSets {
+		config.NumSampledSets = config.NumCacheSets
+	}
+
+	if config.Associativity <= 0 {
+		config.Associativity = 16
+	}
+
+	if config.PartialTagBits == 0 {
+		config.PartialTagBits = 15
+	}
+
+	if config.BlockSize <= 0 {
+		config.BlockSize = 64
+	}
+
+	s := &tagSampler{
+		config:  config,
+		stride:  config.NumCacheSets / config.NumSampledSets,
+		tagMask: uint64(1)<<config.PartialTagBits - 1,
+		sets:    make([][]samplerEntry, config.NumSampledSets),
+	}
+
+	for i := range s.sets {
+		s.sets[i] = make([]samplerEntry, config.Associativity)
+	}
+
+	return s
+}
+
+// samplerSet returns the sampled set of the address, or nil if the cache set
+// of the address is not sampled.
+func (s *tagSampler) samplerSet(addr uint64) ([]samplerEntry, uint64) {
+	line := addr / uint64(s.config.BlockSize)
+	cacheSet := int(line % uint64(s.config.NumCacheSets))
+
+	if cacheSet%s.stride != 0 || cacheSet/s.stride >= len(s.sets) {
+		return nil, 0
+	}
+
+	tag := (line / uint64(s.config.NumCacheSets)) & s.tagMask
+
+	return s.sets[cacheSet/s.stride], tag
+}
+
+// find records an access to the address. It returns the entry of the line
+// and true if the line is in the sampler, or the entry that the line
+// replaces and false. The entry is nil if the set is not sampled. The caller
+// trains with the previous content of the entry and then refills it with
+// fill.
+func (s *tagSampler) find(addr uint64) (*samplerEntry, uint64, bool) {
+	set, tag := s.samplerSet(addr)
+	if set == nil {
+		return nil, 0, false
+	}
+
+	s.now++
+	s.stats.Accesses++
+
+	if entry := s.lookup(set, tag); entry != nil {
+		s.stats.Hits++
+		return entry, tag, true
+	}
+
+	entry := s.victim(set)
+	if entry.valid {
+		s.stats.Evictions++
+	}
+
+	return entry, tag, false
+}
+
+// fill makes the entry record the access with the tag.
+func (s *tagSampler) fill(entry *samplerEntry, tag uint64) {
+	entry.valid = true
+	entry.tag = tag
+	entry.lastUse = s.now
+}
+
+func (s *tagSampler) lookup(set []samplerEntry, tag uint64) *samplerEntry {
+	for i := range set {
+		if set[i].valid && set[i].tag == tag {
+			return &set[i]
+		}
+	}
+
+	return nil
+}
+
+func (s *tagSampler) victim(set []samplerEntry) *samplerEntry {
+	victim := &set[0]
+
+	for i := range set {
+		if !set[i].valid {
+			return &set[i]
+		}
+
+		if set[i].lastUse < victim.lastUse {
+			victim = &set[i]
+		}
+	}
+
+	return victim
+}
+
+// A perceptronSampler is the sampler of the MICRO 2016 paper, a tagSampler
+// that trains the weights of a perceptron.
+type perceptronSampler struct {
+	*tagSampler
+}
+
+func newPerceptronSampler(config SamplerConfig) *perceptronSampler {
+	return &perceptronSampler{tagSampler: newTagSampler(config)}
+}
+
+// access records an access and trains the predictor with the outcome of the
+// previous access recorded in the sampler: reuse if the line is found, no
+// reuse for the entry that it replaces. With per-PID weights, the outcome
+// trains the weights of the process of the recorded access.
+func (s *perceptronSampler) access(p *PerceptronVictimFinder, addr, pc uint64) {
+	entry, tag, hit := s.find(addr)
+	if entry == nil {
+		return
+	}
+
+	p.energy.Charge(EnergyShadowAccess, 1)
+
+	pid := p.activePID()
+
+	if hit || entry.valid {
+		s.train(p, entry, hit)
+	}
+
+	p.usePIDWeights(pid)
+
+	*entry = samplerEntry{
+		addr: addr,
+		pc:   pc,
+		pid:  pid,
+		sum:  p.trainingSum(addr, pc),
+	}
+	s.fill(entry, tag)
+}
+
+func (s *perceptronSampler) train(
+	p *PerceptronVictimFinder,
+	entry *samplerEntry,
+	actualReuse bool,
+) {
+	if p.perPID != nil {
+		// The weights that made the prediction may have been dropped.
+		if _, ok := p.perPID.byPID[entry.pid]; !ok {
+			return
+		}
+
+		p.usePIDWeights(entry.pid)
+	}
+
+	p.trainWithSum(entry.addr, entry.pc,
+		entry.sum >= p.threshold, entry.sum, actualReuse)
+}
+
+// EnableSampler makes the predictor train through a sampler instead of
+// training on one out of every N outcomes. Hits and misses of the sampled
+// sets are recorded, and the outcomes reported by TrainOnEviction are
+// ignored, since the sampler detects them itself.
+func (p *PerceptronVictimFinder) EnableSampler(config SamplerConfig) {
+	p.sampler = newPerceptronSampler(config)
+}
+
+// SamplerStats returns the sampler statistics, or zeros if there is no
+// sampler.
+func (p *PerceptronVictimFinder) SamplerStats() SamplerStats {
+	if p.sampler == nil {
+		return SamplerStats{}
+	}
+
+	return p.sampler.stats
+}
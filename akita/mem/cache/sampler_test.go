@@ -0,0 +1,58 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Perceptron sampler", func() {
+	var p *PerceptronVictimFinder
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetHashedTables(false)
+		p.EnableSampler(SamplerConfig{
+			NumSampledSets: 4,
+			Associativity:  2,
+			NumCacheSets:   16,
+		})
+	})
+
+	It("should ignore the sets that are not sampled", func() {
+		p.TrainOnHit(0x40)
+		p.TrainOnHit(0x40)
+
+		Expect(p.SamplerStats()).To(Equal(SamplerStats{}))
+		Expect(p.Weights()).To(Equal([32]int32{}))
+	})
+
+	It("should train reuse when an entry hits", func() {
+		p.TrainOnHit(0x400)
+		p.TrainOnHit(0x400)
+
+		Expect(p.SamplerStats()).To(Equal(SamplerStats{Accesses: 2, Hits: 1}))
+		Expect(p.predictionSum(0x400, 0)).To(BeNumerically("<", 0))
+	})
+
+	It("should train no reuse when an entry is replaced", func() {
+		set := makeTestSet(4)
+		for _, addr := range []uint64{0x400, 0x800, 0xc00} {
+			p.FindVictimWithContext(set, &VictimContext{Address: addr})
+		}
+
+		Expect(p.SamplerStats().Evictions).To(Equal(uint64(1)))
+		Expect(p.predictionSum(0x400, 0)).To(BeNumerically(">", 0))
+		Expect(p.predictionSum(0x800, 0)).To(BeZero())
+	})
+
+	It("should leave the eviction outcomes to the sampler", func() {
+		p.TrainOnEviction(0x400)
+
+		Expect(p.SamplerStats().Accesses).To(BeZero())
+		Expect(p.Weights()).To(Equal([32]int32{}))
+	})
+
+	It("should need the number of cache sets", func() {
+		Expect(func() { p.EnableSampler(SamplerConfig{}) }).To(Panic())
+	})
+})
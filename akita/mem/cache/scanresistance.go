@@ -0,0 +1,67 @@
+package cache
+
+// ScanResistance reserves a number of ways in every set for blocks that have
+// been hit at least once since they were filled. Blocks that have not been
+// reused are confined to the remaining ways, so a one-pass scan can only
+// replace other single-use blocks.
+type ScanResistance struct {
+	ProtectedWays int
+}
+
+// NewScanResistance creates a scan-resistance configuration that protects
+// the given fraction of the ways.
+func NewScanResistance(fraction float64, numWays int) *ScanResistance {
+	if fraction < 0 || fraction >= 1 {
+		panic("protected fraction must be in [0, 1)")
+	}
+
+	return &ScanResistance{ProtectedWays: int(fraction * float64(numWays))}
+}
+
+// SetScanResistance enables scan resistance on the directory. Passing nil
+// disables it.
+func (d *DirectoryImpl) SetScanResistance(s *ScanResistance) {
+	if s != nil && s.ProtectedWays >= d.NumWays {
+		panic("scan resistance must leave at least one unprotected way")
+	}
+
+	d.scanResistance = s
+}
+
+// applyScanResistance replaces the victim if the set already holds as many
+// single-use blocks as the unprotected ways allow. The new victim is the
+// single-use block that the victim finder ranks highest.
+func (d *DirectoryImpl) applyScanResistance(
+	vf VictimFinder,
+	set *Set,
+	context *VictimContext,
+	victim *Block,
+) *Block {
+	if d.scanResistance == nil || d.scanResistance.ProtectedWays == 0 {
+		return victim
+	}
+
+	singleUse := 0
+	for _, block := range set.Blocks {
+		if block.IsValid && block.HitCount == 0 {
+			singleUse++
+		}
+	}
+
+	if singleUse < len(set.Blocks)-d.scanResistance.ProtectedWays {
+		return victim
+	}
+
+	if victim != nil && victim.IsValid && victim.HitCount == 0 &&
+		!victim.IsLocked {
+		return victim
+	}
+
+	for _, block := range FindVictims(vf, set, context, len(set.Blocks)) {
+		if block.IsValid && block.HitCount == 0 {
+			return block
+		}
+	}
+
+	return victim
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ScanResistance", func() {
+	var (
+		d *DirectoryImpl
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 4, 64, NewLRUVictimFinder())
+		d.SetScanResistance(NewScanResistance(0.5, 4))
+	})
+
+	access := func(addr uint64) {
+		block := d.Lookup(0, addr)
+		if block == nil {
+			block = d.FindVictim(addr)
+			block.Tag = addr
+			block.IsValid = true
+		}
+
+		d.Visit(block)
+	}
+
+	It("should keep the reused working set during a scan", func() {
+		access(0x0)
+		access(0x0)
+		access(0x40)
+		access(0x40)
+
+		for addr := uint64(0x1000); addr < 0x2000; addr += 0x40 {
+			access(addr)
+		}
+
+		Expect(d.Lookup(0, 0x0)).NotTo(BeNil())
+		Expect(d.Lookup(0, 0x40)).NotTo(BeNil())
+	})
+
+	It("should confine single-use blocks to the unprotected ways", func() {
+		for addr := uint64(0x1000); addr < 0x2000; addr += 0x40 {
+			access(addr)
+		}
+
+		valid := 0
+		for _, block := range d.Sets[0].Blocks {
+			if block.IsValid {
+				valid++
+			}
+		}
+
+		Expect(valid).To(Equal(2))
+	})
+
+	It("should reject protecting all the ways", func() {
+		Expect(func() {
+			d.SetScanResistance(&ScanResistance{ProtectedWays: 4})
+		}).To(Panic())
+	})
+})
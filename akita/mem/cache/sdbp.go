@@ -0,0 +1,231 @@
+package cache
+
+// sdbpTraceBits is the width of the PC trace that the sampler and the
+// blocks store.
+const sdbpTraceBits = 15
+
+// An SDBPConfig configures an SDBPVictimFinder.
+type SDBPConfig struct {
+	// The sampler. The number of sampled sets defaults to 32 and the
+	// associativity to 12. The geometry should be the one of the cache; if
+	// the number of cache sets is unknown, every access is sampled in one
+	// set.
+	Sampler SamplerConfig
+
+	// Every prediction table has 2^TableBits counters. Defaults to 12.
+	TableBits uint
+
+	// The counters saturate at 2^CounterBits - 1. Defaults to 2.
+	CounterBits uint
+
+	// A table votes dead if its counter is at least DeadThreshold. Defaults
+	// to the upper half of the counter range.
+	DeadThreshold uint8
+}
+
+// SDBPVictimFinder implements the sampling dead-block predictor (Khan et
+// al., MICRO 2010). The predictor only learns from a sampler, which tracks
+// the lines of a few sets with partial tags, LRU replacement, and more ways
+// than the cache, and which records the PC trace of the last access to every
+// line. A sampler hit trains the trace of the previous access as live, and
+// a sampler replacement trains it as dead, in three tables of saturating
+// counters indexed by skewed hashes of the trace. A block is predicted dead
+// if a majority of the tables vote dead for the trace stored in
+// Block.Signature.
+//
+// The trace of an access is the PC of the fill, or the memory region of the
+// line if the PC is unknown. The victim is the first invalid block, or the
+// least recently used dead block in PseudoLRU order, or the PseudoLRU
+// victim. Like GHRPVictimFinder, it is also a DeadBlockPredictor and an
+// InsertionAdvisor.
+type SDBPVictimFinder struct {
+	config  SDBPConfig
+	sampler *tagSampler
+	tables  *deadBlockTables
+	energy  *EnergyMeter
+}
+
+// NewSDBPVictimFinder returns an SDBP victim finder with the default
+// configuration, which samples every access in one set.
+func NewSDBPVictimFinder() *SDBPVictimFinder {
+	return NewSDBPVictimFinderWithConfig(SDBPConfig{})
+}
+
+// NewSDBPVictimFinderWithConfig returns an SDBP victim finder with the
+// configuration.
+func NewSDBPVictimFinderWithConfig(config SDBPConfig) *SDBPVictimFinder {
+	if config.Sampler.NumCacheSets <= 0 {
+		config.Sampler.NumCacheSets = 1
+	}
+
+	if config.Sampler.NumSampledSets <= 0 {
+		config.Sampler.NumSampledSets = 32
+	}
+
+	if config.Sampler.Associativity <= 0 {
+		config.Sampler.Associativity = 12
+	}
+
+	if config.TableBits == 0 {
+		config.TableBits = 12
+	}
+
+	if config.CounterBits == 0 {
+		config.CounterBits = 2
+	}
+
+	if config.DeadThreshold == 0 {
+		config.DeadThreshold = uint8(1 << (config.CounterBits - 1))
+	}
+
+	sampler := newTagSampler(config.Sampler)
+	config.Sampler = sampler.config
+
+	return &SDBPVictimFinder{
+		config:  config,
+		sampler: sampler,
+		tables: newDeadBlockTables(config.TableBits, config.CounterBits,
+			config.DeadThreshold),
+	}
+}
+
+// Config returns the configuration.
+func (s *SDBPVictimFinder) Config() SDBPConfig {
+	return s.config
+}
+
+// SamplerStats returns the sampler statistics.
+func (s *SDBPVictimFinder) SamplerStats() SamplerStats {
+	return s.sampler.stats
+}
+
+// SetEnergyMeter attaches an energy meter that is charged for every access
+// to a sampled set of the sampler.
+func (s *SDBPVictimFinder) SetEnergyMeter(m *EnergyMeter) {
+	s.energy = m
+}
+
+// EnergyMeter returns the attached energy meter, or nil if there is none.
+func (s *SDBPVictimFinder) EnergyMeter() *EnergyMeter {
+	return s.energy
+}
+
+// Trace returns the trace of an access with the PC to the line.
+func (s *SDBPVictimFinder) Trace(pc, line uint64) uint32 {
+	key := line >> shipRegionShift
+	if pc != 0 {
+		key = pc
+	}
+
+	return uint32(mixLineHash(key) & (1<<sdbpTraceBits - 1))
+}
+
+// Votes returns the number of tables that predict the lines of the trace
+// dead.
+func (s *SDBPVictimFinder) Votes(trace uint32) int {
+	return s.tables.votes(trace)
+}
+
+// PredictDead predicts whether an access with the context to the line is
+// the last one. The confidence is the sum of the counters of the trace.
+func (s *SDBPVictimFinder) PredictDead(
+	addr uint64,
+	ctx *VictimContext,
+) (dead bool, confidence int32) {
+	var pc uint64
+	if ctx != nil {
+		pc = ctx.PC
+	}
+
+	trace := s.Trace(pc, addr)
+
+	return s.tables.dead(trace), s.tables.sum(trace)
+}
+
+// AdviseInsertion recommends bypassing the missing line if every table
+// predicts it dead, and inserting it at the LRU position if a majority
+// does.
+func (s *SDBPVictimFinder) AdviseInsertion(
+	ctx *VictimContext,
+) InsertionPriority {
+	votes := s.Votes(s.Trace(ctx.PC, contextLine(ctx)))
+
+	switch {
+	case votes == deadBlockVoters:
+		return InsertBypass
+	case votes > deadBlockVoters/2:
+		return InsertLRU
+	default:
+		return InsertMRU
+	}
+}
+
+// Insert records the trace of the fill and samples the access.
+func (s *SDBPVictimFinder) Insert(_ *Set, block *Block) {
+	s.access(block, block.PC)
+}
+
+// Touch records the trace of a hit whose PC is unknown, which is attributed
+// to the PC of the fill, and samples the access.
+func (s *SDBPVictimFinder) Touch(set *Set, block *Block) {
+	s.TouchWithContext(set, block, nil)
+}
+
+// TouchWithContext records the trace of the hit under the PC that hit, and
+// samples the access.
+func (s *SDBPVictimFinder) TouchWithContext(
+	_ *Set,
+	block *Block,
+	ctx *VictimContext,
+) {
+	s.access(block, hitPC(block, ctx))
+}
+
+// FindVictim returns the first invalid block, the least recently used
+// unlocked block predicted dead, or the PseudoLRU victim.
+func (s *SDBPVictimFinder) FindVictim(set *Set) *Block {
+	return s.tables.deadFirstVictim(set)
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (s *SDBPVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return s.FindVictim(set)
+}
+
+// FindVictims returns up to n candidates, the blocks predicted dead before
+// the others, each in PseudoLRU order.
+func (s *SDBPVictimFinder) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	if len(set.Blocks) == 0 {
+		return nil
+	}
+
+	return rankCandidates(set, s.tables.deadFirstOrder(set), n)
+}
+
+// access stores the trace in the block and, if the set of the line is
+// sampled, trains the tables with the outcome of the access recorded in the
+// sampler: live if the line is found, dead for the entry that it replaces.
+func (s *SDBPVictimFinder) access(block *Block, pc uint64) {
+	block.Signature = s.Trace(pc, block.Tag)
+
+	entry, tag, hit := s.sampler.find(block.Tag)
+	if entry == nil {
+		return
+	}
+
+	s.energy.Charge(EnergyShadowAccess, 1)
+
+	if hit || entry.valid {
+		s.tables.train(s.Trace(entry.pc, entry.addr), !hit)
+	}
+
+	*entry = samplerEntry{addr: block.Tag, pc: pc}
+	s.sampler.fill(entry, tag)
+}
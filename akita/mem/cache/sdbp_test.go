@@ -0,0 +1,164 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SDBPVictimFinder", func() {
+	var vf *SDBPVictimFinder
+
+	BeforeEach(func() {
+		vf = NewSDBPVictimFinderWithConfig(SDBPConfig{
+			Sampler: SamplerConfig{
+				NumSampledSets: 4,
+				Associativity:  2,
+				NumCacheSets:   16,
+			},
+		})
+	})
+
+	It("should fill in the defaults", func() {
+		Expect(NewSDBPVictimFinder().Config()).To(Equal(SDBPConfig{
+			Sampler: SamplerConfig{
+				NumSampledSets: 1,
+				Associativity:  12,
+				PartialTagBits: 15,
+				NumCacheSets:   1,
+				BlockSize:      64,
+			},
+			TableBits:     12,
+			CounterBits:   2,
+			DeadThreshold: 2,
+		}))
+
+		var _ DeadBlockPredictor = vf
+		var _ InsertionAdvisor = vf
+	})
+
+	It("should only sample the sampled sets", func() {
+		block := &Block{Tag: 0x40, PC: 0x10}
+		vf.Insert(nil, block)
+
+		Expect(block.Signature).To(Equal(vf.Trace(0x10, 0x40)))
+		Expect(vf.SamplerStats()).To(Equal(SamplerStats{}))
+	})
+
+	It("should train the traces replaced in the sampler as dead", func() {
+		dead := vf.Trace(0x20, 0)
+
+		for i, addr := range []uint64{0x400, 0x800, 0xc00, 0x1000, 0x1400} {
+			pc := uint64(0x30)
+			if i < 2 {
+				pc = 0x20
+			}
+
+			vf.Insert(nil, &Block{Tag: addr, PC: pc})
+		}
+
+		Expect(vf.SamplerStats()).To(Equal(SamplerStats{
+			Accesses: 5, Evictions: 3,
+		}))
+		Expect(vf.Votes(dead)).To(Equal(3))
+
+		isDead, confidence := vf.PredictDead(0x400, &VictimContext{PC: 0x20})
+		Expect(isDead).To(BeTrue())
+		Expect(confidence).To(Equal(int32(6)))
+		Expect(vf.AdviseInsertion(&VictimContext{Address: 0x400, PC: 0x20})).
+			To(Equal(InsertBypass))
+	})
+
+	It("should train the traces reused in the sampler as live", func() {
+		live := vf.Trace(0x30, 0)
+		for _, addr := range []uint64{0x400, 0x800, 0xc00} {
+			vf.Insert(nil, &Block{Tag: addr, PC: 0x30})
+		}
+		Expect(vf.Votes(live)).To(BeZero())
+
+		vf.Insert(nil, &Block{Tag: 0x1000, PC: 0x30})
+		Expect(vf.Votes(live)).To(Equal(3))
+
+		vf.Touch(nil, &Block{Tag: 0x1000, PC: 0x30})
+		vf.Touch(nil, &Block{Tag: 0x1000, PC: 0x30})
+		Expect(vf.SamplerStats().Hits).To(Equal(uint64(2)))
+		Expect(vf.Votes(live)).To(BeZero())
+	})
+
+	It("should trace the hits under the PC that hit", func() {
+		d := NewDirectory(16, 2, 64, vf)
+		block := d.FindVictimWithContext(0x40,
+			&VictimContext{Address: 0x40, PC: 0x10})
+		block.Tag = 0x40
+		block.IsValid = true
+		d.Visit(block)
+
+		d.VisitWithContext(block, &VictimContext{Address: 0x40, PC: 0x30})
+		Expect(block.Signature).To(Equal(vf.Trace(0x30, 0x40)))
+
+		d.Visit(block)
+		Expect(block.Signature).To(Equal(vf.Trace(0x10, 0x40)))
+	})
+
+	It("should evict the blocks predicted dead first", func() {
+		for _, addr := range []uint64{0x400, 0x800, 0xc00, 0x1000} {
+			vf.Insert(nil, &Block{Tag: addr, PC: 0x20})
+		}
+
+		set := makeTestSet(4)
+		for _, block := range set.Blocks {
+			block.IsValid = true
+		}
+
+		set.Blocks[2].Signature = vf.Trace(0x20, 0)
+
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[2]))
+		Expect(vf.FindVictims(set, nil, 2)).To(Equal([]*Block{
+			set.Blocks[2], set.Blocks[0],
+		}))
+	})
+
+	It("should keep more reused lines of a mixed stream than LRU", func() {
+		hits := func(name string) int {
+			vf, err := NewVictimFinderByName(name,
+				PolicyConfig{NumSets: 1, NumWays: 4, BlockSize: 64})
+			Expect(err).NotTo(HaveOccurred())
+
+			d := NewDirectory(1, 4, 64, vf)
+			access := func(addr, pc uint64) bool {
+				if block := d.Lookup(0, addr); block != nil {
+					d.VisitWithContext(block,
+						&VictimContext{Address: addr, PC: pc})
+					return true
+				}
+
+				block := d.FindVictimWithContext(addr,
+					&VictimContext{Address: addr, PC: pc})
+				block.Tag = addr
+				block.IsValid = true
+				d.Visit(block)
+
+				return false
+			}
+
+			hits := 0
+			stream := uint64(0x100000)
+
+			for i := 0; i < 300; i++ {
+				for _, addr := range []uint64{0x0, 0x40, 0x80} {
+					if access(addr, 0x10) && i >= 200 {
+						hits++
+					}
+				}
+
+				for j := 0; j < 2; j++ {
+					access(stream, 0x20)
+					stream += 64
+				}
+			}
+
+			return hits
+		}
+
+		Expect(hits("sdbp")).To(BeNumerically(">=", hits("lru")+250))
+	})
+})
@@ -0,0 +1,244 @@
+package cache
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// MaxSectors is the largest number of sectors per block.
+const MaxSectors = 64
+
+// SetSectorSize divides every block into sectors of the given number of
+// bytes, each with its own valid and dirty bit in Block.ValidSectors and
+// Block.DirtySectors. A block is valid as long as one of its sectors is. A
+// size of 0 turns sectoring off. It panics if the size is not a power of two
+// that divides the block into at most MaxSectors sectors.
+//
+// A fill with a victim context only validates the sectors that the access
+// covers; a fill without a context validates the whole block. Controllers
+// that fetch more sectors report them with FillSectors.
+func (d *DirectoryImpl) SetSectorSize(size int) {
+	if size != 0 && (bits.OnesCount(uint(size)) != 1 ||
+		size > d.BlockSize || d.BlockSize/size > MaxSectors) {
+		panic(fmt.Sprintf("invalid sector size %d for %d-byte blocks",
+			size, d.BlockSize))
+	}
+
+	d.sectorSize = size
+}
+
+// SectorSize returns the number of bytes per sector, or 0 if the blocks are
+// not sectored.
+func (d *DirectoryImpl) SectorSize() int {
+	return d.sectorSize
+}
+
+// NumSectors returns the number of sectors per block; 1 if the blocks are not
+// sectored.
+func (d *DirectoryImpl) NumSectors() int {
+	if d.sectorSize == 0 {
+		return 1
+	}
+
+	return d.BlockSize / d.sectorSize
+}
+
+// allSectors returns the mask of all the sectors of a block.
+func (d *DirectoryImpl) allSectors() uint64 {
+	n := d.NumSectors()
+	if n == MaxSectors {
+		return ^uint64(0)
+	}
+
+	return uint64(1)<<n - 1
+}
+
+// sectorMask returns the mask of the sectors covered by size bytes at addr.
+// A zero size covers one byte.
+func (d *DirectoryImpl) sectorMask(addr, size uint64) uint64 {
+	if d.sectorSize == 0 {
+		return 1
+	}
+
+	if size == 0 {
+		size = 1
+	}
+
+	blockSize := uint64(d.BlockSize)
+	sector := uint64(d.sectorSize)
+
+	first := addr % blockSize / sector
+	last := (addr%blockSize + size - 1) / sector
+
+	if last >= uint64(d.NumSectors()) {
+		last = uint64(d.NumSectors()) - 1
+	}
+
+	var mask uint64
+	for s := first; s <= last; s++ {
+		mask |= 1 << s
+	}
+
+	return mask
+}
+
+// fillSectors sets the sectors of a filled block. A zero mask, for a fill
+// without a context, validates every sector.
+func (d *DirectoryImpl) fillSectors(block *Block, sectors uint64) {
+	if d.sectorSize == 0 {
+		return
+	}
+
+	if sectors == 0 {
+		sectors = d.allSectors()
+	}
+
+	block.ValidSectors = sectors
+	block.DirtySectors = 0
+}
+
+// LookupSector finds the block that holds the line of the address, like
+// Lookup, and tells if the sector of the address is valid. A block can be
+// found while its sector is missing, in which case only the sector has to be
+// fetched.
+func (d *DirectoryImpl) LookupSector(
+	pid vm.PID,
+	addr uint64,
+) (block *Block, sectorValid bool) {
+	line := addr / uint64(d.BlockSize) * uint64(d.BlockSize)
+
+	block = d.Lookup(pid, line)
+	if block == nil {
+		return nil, false
+	}
+
+	if d.sectorSize == 0 {
+		return block, true
+	}
+
+	mask := d.sectorMask(addr, 1)
+
+	return block, block.ValidSectors&mask == mask
+}
+
+// FillSectors marks the sectors covered by size bytes at addr valid.
+func (d *DirectoryImpl) FillSectors(block *Block, addr, size uint64) {
+	block.ValidSectors |= d.sectorMask(addr, size)
+	block.IsValid = true
+}
+
+// WriteSectors marks the sectors covered by size bytes at addr valid and
+// dirty.
+func (d *DirectoryImpl) WriteSectors(block *Block, addr, size uint64) {
+	mask := d.sectorMask(addr, size)
+	block.ValidSectors |= mask
+	block.DirtySectors |= mask
+	block.IsValid = true
+	block.IsDirty = true
+}
+
+// InvalidateSectors invalidates the sectors covered by size bytes at addr.
+// The block is invalidated with its last valid sector.
+func (d *DirectoryImpl) InvalidateSectors(block *Block, addr, size uint64) {
+	mask := d.sectorMask(addr, size)
+	block.ValidSectors &^= mask
+	block.DirtySectors &^= mask
+
+	if block.DirtySectors == 0 {
+		block.IsDirty = false
+	}
+
+	if block.ValidSectors == 0 || d.sectorSize == 0 {
+		block.IsValid = false
+		block.IsDirty = false
+		block.ValidSectors = 0
+		block.DirtySectors = 0
+	}
+}
+
+// validSectorCount returns the number of valid sectors of the block, and 1
+// for a valid block that is not sectored.
+func validSectorCount(block *Block) int {
+	if !block.IsValid {
+		return 0
+	}
+
+	if block.ValidSectors == 0 {
+		return 1
+	}
+
+	return bits.OnesCount64(block.ValidSectors)
+}
+
+// preferSparse replaces the victim of the wrapped finder with a candidate of
+// its ranking that holds fewer valid sectors.
+type preferSparse struct {
+	next   VictimFinder
+	window int
+}
+
+// PreferSparseVictims returns a filter for sectored blocks. Among the first
+// window candidates of the wrapped finder's ranking, it evicts the block with
+// the fewest valid sectors, the earliest ranked on ties, so that less data is
+// lost. Invalid blocks still come first.
+func PreferSparseVictims(window int) VictimFilter {
+	return func(next VictimFinder) VictimFinder {
+		return &preferSparse{next: next, window: window}
+	}
+}
+
+func (f *preferSparse) FindVictim(set *Set) *Block {
+	return f.pick(set, nil, f.next.FindVictim(set))
+}
+
+func (f *preferSparse) FindVictimWithContext(
+	set *Set,
+	context *VictimContext,
+) *Block {
+	return f.pick(set, context, f.next.FindVictimWithContext(set, context))
+}
+
+func (f *preferSparse) pick(
+	set *Set,
+	context *VictimContext,
+	victim *Block,
+) *Block {
+	if victim == nil || !victim.IsValid {
+		return victim
+	}
+
+	for _, b := range FindVictims(f.next, set, context, f.window) {
+		if validSectorCount(b) < validSectorCount(victim) {
+			victim = b
+		}
+	}
+
+	return victim
+}
+
+func (f *preferSparse) FindVictims(
+	set *Set,
+	context *VictimContext,
+	n int,
+) []*Block {
+	ranking := FindVictims(f.next, set, context, len(set.Blocks))
+
+	window := f.window
+	if window > len(ranking) {
+		window = len(ranking)
+	}
+
+	head := ranking[:window]
+	sort.SliceStable(head, func(i, j int) bool {
+		return validSectorCount(head[i]) < validSectorCount(head[j])
+	})
+
+	if len(ranking) > n {
+		ranking = ranking[:n]
+	}
+
+	return ranking
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sectored blocks", func() {
+	var d *DirectoryImpl
+
+	BeforeEach(func() {
+		d = NewDirectory(2, 4, 128, NewLRUVictimFinder())
+		d.SetSectorSize(32)
+	})
+
+	fill := func(addr uint64, context *VictimContext) *Block {
+		block := d.FindVictimWithContext(addr, context)
+		block.Tag = addr / 128 * 128
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	It("should reject invalid sector sizes", func() {
+		Expect(d.NumSectors()).To(Equal(4))
+		Expect(func() { d.SetSectorSize(24) }).To(Panic())
+		Expect(func() { d.SetSectorSize(256) }).To(Panic())
+	})
+
+	It("should only validate the sectors of the access", func() {
+		block := fill(0x140, &VictimContext{Address: 0x140, AccessSize: 48})
+
+		Expect(block.ValidSectors).To(Equal(uint64(0b1100)))
+
+		found, ok := d.LookupSector(0, 0x150)
+		Expect(found).To(BeIdenticalTo(block))
+		Expect(ok).To(BeTrue())
+
+		found, ok = d.LookupSector(0, 0x100)
+		Expect(found).To(BeIdenticalTo(block))
+		Expect(ok).To(BeFalse())
+
+		d.FillSectors(block, 0x100, 32)
+		_, ok = d.LookupSector(0, 0x100)
+		Expect(ok).To(BeTrue())
+	})
+
+	It("should track dirty sectors and invalidate the empty block", func() {
+		block := fill(0x0, nil)
+		Expect(block.ValidSectors).To(Equal(uint64(0b1111)))
+
+		d.WriteSectors(block, 0x20, 4)
+		Expect(block.DirtySectors).To(Equal(uint64(0b0010)))
+		Expect(block.IsDirty).To(BeTrue())
+
+		d.InvalidateSectors(block, 0x20, 32)
+		Expect(block.IsDirty).To(BeFalse())
+		Expect(block.IsValid).To(BeTrue())
+
+		d.InvalidateSectors(block, 0x0, 128)
+		Expect(block.IsValid).To(BeFalse())
+	})
+
+	It("should evict the sparsest block among the first candidates", func() {
+		set := makeTestSet(4)
+		for i, b := range set.Blocks {
+			b.IsValid = true
+			b.ValidSectors = 0b1111 >> uint(i%3)
+		}
+
+		vf := ChainVictimFinder(wayOrderPolicy{}, PreferSparseVictims(3))
+
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[2]))
+		Expect(FindVictims(vf, set, nil, 4)).To(Equal([]*Block{
+			set.Blocks[2], set.Blocks[1], set.Blocks[0], set.Blocks[3],
+		}))
+	})
+})
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// An IndexFunction maps a cache line to the set of the directory that holds
+// it.
+type IndexFunction int
+
+// Index functions.
+const (
+	// IndexModulo takes the line number modulo the number of sets. It works
+	// with any number of sets and is the same as IndexBitSelect for a power
+	// of two.
+	IndexModulo IndexFunction = iota
+
+	// IndexBitSelect takes the low bits of the line number. It requires a
+	// power-of-two number of sets.
+	IndexBitSelect
+
+	// IndexXORFold XORs all the index-sized fields of the line number, so
+	// that strides that are multiples of the number of sets spread over the
+	// sets. With a number of sets that is not a power of two, the folded
+	// value is taken modulo the number of sets.
+	IndexXORFold
+
+	// IndexPrimeModulo takes the line number modulo the largest prime not
+	// greater than the number of sets. The sets above the prime are never
+	// used.
+	IndexPrimeModulo
+)
+
+var indexFunctionNames = [...]string{
+	"modulo", "bit-select", "xor-fold", "prime-modulo",
+}
+
+// String returns the name of the index function.
+func (f IndexFunction) String() string {
+	if f < 0 || int(f) >= len(indexFunctionNames) {
+		return fmt.Sprintf("IndexFunction(%d)", int(f))
+	}
+
+	return indexFunctionNames[f]
+}
+
+// ParseIndexFunction returns the index function with the name.
+func ParseIndexFunction(name string) (IndexFunction, error) {
+	for i, n := range indexFunctionNames {
+		if n == name {
+			return IndexFunction(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown index function %q", name)
+}
+
+// SetIndexFunction selects how the directory maps lines to sets. It panics if
+// the function does not support the number of sets. The index function is
+// applied after the address converter.
+func (d *DirectoryImpl) SetIndexFunction(f IndexFunction) {
+	if err := checkIndexFunction(f, d.NumSets); err != nil {
+		panic(err)
+	}
+
+	d.indexFunction = f
+	d.indexPrime = largestPrime(d.NumSets)
+}
+
+// checkIndexFunction returns an error if the index function does not support
+// the number of sets.
+func checkIndexFunction(f IndexFunction, numSets int) error {
+	switch f {
+	case IndexModulo, IndexXORFold:
+		return nil
+	case IndexBitSelect:
+		if bits.OnesCount(uint(numSets)) != 1 {
+			return fmt.Errorf("bit-select indexing needs a power-of-two "+
+				"number of sets, not %d", numSets)
+		}
+
+		return nil
+	case IndexPrimeModulo:
+		if numSets < 2 {
+			return fmt.Errorf("prime-modulo indexing needs at least two sets")
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown index function %d", f)
+	}
+}
+
+// IndexFunction returns how the directory maps lines to sets.
+func (d *DirectoryImpl) IndexFunction() IndexFunction {
+	return d.indexFunction
+}
+
+// setIndex returns the set of the line number.
+func (d *DirectoryImpl) setIndex(line uint64) int {
+	numSets := uint64(d.NumSets)
+
+	switch d.indexFunction {
+	case IndexBitSelect:
+		return int(line & (numSets - 1))
+	case IndexXORFold:
+		return int(xorFold(line, numSets) % numSets)
+	case IndexPrimeModulo:
+		return int(line % uint64(d.indexPrime))
+	default:
+		return int(line % numSets)
+	}
+}
+
+// xorFold XORs the fields of the line number that are as wide as the index of
+// numSets sets.
+func xorFold(line, numSets uint64) uint64 {
+	width := uint(bits.Len64(numSets - 1))
+	if width == 0 {
+		return 0
+	}
+
+	mask := uint64(1)<<width - 1
+
+	var folded uint64
+	for ; line != 0; line >>= width {
+		folded ^= line & mask
+	}
+
+	return folded
+}
+
+// largestPrime returns the largest prime not greater than n, or 0 if there is
+// none.
+func largestPrime(n int) int {
+	for p := n; p >= 2; p-- {
+		if isPrime(p) {
+			return p
+		}
+	}
+
+	return 0
+}
+
+func isPrime(n int) bool {
+	for i := 2; i*i <= n; i++ {
+		if n%i == 0 {
+			return false
+		}
+	}
+
+	return n >= 2
+}
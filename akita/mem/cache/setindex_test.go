@@ -0,0 +1,61 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Index functions", func() {
+	setOf := func(d *DirectoryImpl, line uint64) int {
+		_, setID := d.getSet(line * 64)
+		return setID
+	}
+
+	It("should index any number of sets by modulo", func() {
+		d := NewDirectory(6, 2, 64, NewLRUVictimFinder())
+
+		Expect(d.IndexFunction()).To(Equal(IndexModulo))
+		Expect(setOf(d, 7)).To(Equal(1))
+		Expect(func() { d.SetIndexFunction(IndexBitSelect) }).To(Panic())
+	})
+
+	It("should spread power-of-two strides by XOR folding", func() {
+		d := NewDirectory(8, 2, 64, NewLRUVictimFinder())
+		d.SetIndexFunction(IndexXORFold)
+
+		sets := map[int]bool{}
+		for i := uint64(0); i < 8; i++ {
+			sets[setOf(d, i*8)] = true
+		}
+
+		Expect(sets).To(HaveLen(8))
+		Expect(setOf(d, 0b101_011)).To(Equal(0b110))
+	})
+
+	It("should index by the largest prime with prime modulo", func() {
+		d := NewDirectory(8, 2, 64, NewLRUVictimFinder())
+		d.SetIndexFunction(IndexPrimeModulo)
+
+		Expect(setOf(d, 7)).To(Equal(0))
+		for i := uint64(0); i < 64; i++ {
+			Expect(setOf(d, i)).To(BeNumerically("<", 7))
+		}
+	})
+
+	It("should configure the index function of a hierarchy level", func() {
+		f, err := ParseIndexFunction("xor-fold")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f).To(Equal(IndexXORFold))
+		Expect(f.String()).To(Equal("xor-fold"))
+
+		_, err = NewHierarchy(HierarchyConfig{
+			BlockSize: 64,
+			NumL1s:    1,
+			L1: HierarchyLevelConfig{
+				NumSets: 6, NumWays: 2, Index: "bit-select",
+			},
+			L2: HierarchyLevelConfig{NumSets: 8, NumWays: 2},
+		})
+		Expect(err).To(MatchError(ContainSubstring("power-of-two")))
+	})
+})
@@ -0,0 +1,110 @@
+package cache
+
+// A ShadowComparison compares the hits of a directory with the hits of a
+// baseline policy on the same accesses.
+type ShadowComparison struct {
+	Accesses     uint64
+	Hits         uint64
+	BaselineHits uint64
+
+	// HitsGained counts the accesses that hit in the directory but missed
+	// in the baseline, and HitsLost the opposite.
+	HitsGained uint64
+	HitsLost   uint64
+}
+
+// NetGain returns the hits gained minus the hits lost.
+func (c ShadowComparison) NetGain() int64 {
+	return int64(c.HitsGained) - int64(c.HitsLost)
+}
+
+// A ShadowDirectory simulates a baseline replacement policy, such as
+// PseudoLRU, next to a directory. It keeps tags only and sees the accesses of
+// the directory, so a single run reports the hits gained and lost against the
+// baseline, per set and overall, without a second full simulation.
+type ShadowDirectory struct {
+	dir     *DirectoryImpl
+	sets    []ShadowComparison
+	overall ShadowComparison
+}
+
+// NewShadowDirectory creates a shadow with the geometry and the address
+// converter of the directory, which replaces blocks with the baseline.
+func NewShadowDirectory(
+	d *DirectoryImpl,
+	baseline VictimFinder,
+) *ShadowDirectory {
+	if baseline == nil {
+		panic("shadow directory needs a baseline victim finder")
+	}
+
+	dir := NewDirectory(d.NumSets, d.NumWays, d.BlockSize, baseline)
+	dir.AddrConverter = d.AddrConverter
+
+	return &ShadowDirectory{
+		dir:  dir,
+		sets: make([]ShadowComparison, d.NumSets),
+	}
+}
+
+// SetShadowDirectory attaches a shadow that sees every visited line.
+func (d *DirectoryImpl) SetShadowDirectory(s *ShadowDirectory) {
+	d.shadow = s
+}
+
+// ShadowDirectory returns the attached shadow, if any.
+func (d *DirectoryImpl) ShadowDirectory() *ShadowDirectory {
+	return d.shadow
+}
+
+func (s *ShadowDirectory) recordAccess(
+	block *Block,
+	isFill bool,
+	energy *EnergyMeter,
+) {
+	if s == nil {
+		return
+	}
+
+	energy.Charge(EnergyShadowAccess, 1)
+
+	hit := !isFill
+	baselineHit, _ := accessTagOnly(s.dir, block.PID, block.Tag)
+
+	s.sets[block.SetID].record(hit, baselineHit)
+	s.overall.record(hit, baselineHit)
+}
+
+func (c *ShadowComparison) record(hit, baselineHit bool) {
+	c.Accesses++
+
+	if hit {
+		c.Hits++
+	}
+
+	if baselineHit {
+		c.BaselineHits++
+	}
+
+	switch {
+	case hit && !baselineHit:
+		c.HitsGained++
+	case !hit && baselineHit:
+		c.HitsLost++
+	}
+}
+
+// Overall returns the comparison summed over all the sets.
+func (s *ShadowDirectory) Overall() ShadowComparison {
+	return s.overall
+}
+
+// Sets returns the comparison of every set.
+func (s *ShadowDirectory) Sets() []ShadowComparison {
+	return s.sets
+}
+
+// Baseline returns the tag-only directory of the baseline.
+func (s *ShadowDirectory) Baseline() *DirectoryImpl {
+	return s.dir
+}
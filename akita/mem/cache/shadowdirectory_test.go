@@ -0,0 +1,65 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// firstWayVictimFinder always evicts the first way.
+type firstWayVictimFinder struct{}
+
+func (firstWayVictimFinder) FindVictim(set *Set) *Block {
+	return set.Blocks[0]
+}
+
+func (firstWayVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return set.Blocks[0]
+}
+
+var _ = Describe("ShadowDirectory", func() {
+	var d *DirectoryImpl
+
+	BeforeEach(func() {
+		d = NewDirectory(2, 2, 64, NewLRUVictimFinder())
+	})
+
+	It("should match a directory with the same policy", func() {
+		s := NewShadowDirectory(d, NewLRUVictimFinder())
+		d.SetShadowDirectory(s)
+
+		for _, addr := range []uint64{0, 128, 0, 256, 128, 0} {
+			accessTagOnly(d, 0, addr)
+		}
+
+		o := s.Overall()
+		Expect(o.Accesses).To(Equal(uint64(6)))
+		Expect(o.BaselineHits).To(Equal(o.Hits))
+		Expect(o.HitsGained).To(BeZero())
+		Expect(o.HitsLost).To(BeZero())
+	})
+
+	It("should count the hits gained and lost per set", func() {
+		s := NewShadowDirectory(d, firstWayVictimFinder{})
+		d.SetShadowDirectory(s)
+
+		// The baseline keeps replacing the first way of set 0.
+		for _, addr := range []uint64{0, 128, 0} {
+			accessTagOnly(d, 0, addr)
+		}
+
+		Expect(s.Sets()[0]).To(Equal(ShadowComparison{
+			Accesses:   3,
+			Hits:       1,
+			HitsGained: 1,
+		}))
+		Expect(s.Sets()[1]).To(Equal(ShadowComparison{}))
+		Expect(s.Overall().NetGain()).To(Equal(int64(1)))
+	})
+
+	It("should require a baseline", func() {
+		Expect(func() { NewShadowDirectory(d, nil) }).To(Panic())
+	})
+})
@@ -0,0 +1,107 @@
+package cache
+
+const (
+	// shipTableBits is the log2 of the number of SHCT entries.
+	shipTableBits = 14
+
+	// shipCounterMax is the saturation value of the 3-bit SHCT counters.
+	shipCounterMax = 7
+
+	// Without a PC, the signature is the memory region of the line, as in
+	// SHiP-Mem.
+	shipRegionShift = 14
+)
+
+// SHiPVictimFinder implements Signature-based Hit Prediction (Wu et al.,
+// MICRO 2011) on top of static RRIP. A signature history counter table
+// (SHCT), indexed by a hash of the PC of the fill, or of its memory region if
+// the PC is unknown, learns whether the lines of a signature are reused.
+// Lines whose signature has a zero counter are inserted with the distant RRPV.
+//
+// Hits increment the counter of the line signature, and evictions of lines
+// that were never hit decrement it. The directory drives both through the
+// AccessObserver, InsertionObserver, and EvictionObserver hooks.
+type SHiPVictimFinder struct {
+	rrip RRIPVictimFinder
+	shct []uint8
+}
+
+// NewSHiPVictimFinder returns a new SHiP victim finder with a 16K-entry SHCT.
+func NewSHiPVictimFinder() *SHiPVictimFinder {
+	s := &SHiPVictimFinder{
+		rrip: RRIPVictimFinder{insertion: RRIPStatic},
+		shct: make([]uint8, 1<<shipTableBits),
+	}
+
+	// Start weakly reused, so that the first lines get the normal insertion.
+	for i := range s.shct {
+		s.shct[i] = 1
+	}
+
+	return s
+}
+
+// signature hashes the PC or the memory region of the block.
+func (s *SHiPVictimFinder) signature(block *Block) uint32 {
+	key := block.Tag >> shipRegionShift
+	if block.PC != 0 {
+		key = block.PC
+	}
+
+	return uint32(mixLineHash(key) & (1<<shipTableBits - 1))
+}
+
+// Counter returns the SHCT counter of a signature.
+func (s *SHiPVictimFinder) Counter(signature uint32) uint8 {
+	return s.shct[signature]
+}
+
+// Touch promotes a hit block and trains its signature as reused.
+func (s *SHiPVictimFinder) Touch(set *Set, block *Block) {
+	s.rrip.Touch(set, block)
+
+	if s.shct[block.Signature] < shipCounterMax {
+		s.shct[block.Signature]++
+	}
+}
+
+// Insert records the signature of a filled block and inserts it with the
+// distant RRPV if the signature is predicted not to be reused.
+func (s *SHiPVictimFinder) Insert(set *Set, block *Block) {
+	block.Signature = s.signature(block)
+
+	s.rrip.Insert(set, block)
+	if s.shct[block.Signature] == 0 {
+		block.RRPV = rripMaxRRPV
+	}
+}
+
+// Evict trains the signature of an evicted block that was never hit as not
+// reused.
+func (s *SHiPVictimFinder) Evict(_ *Set, block *Block) {
+	if block.HitCount == 0 && s.shct[block.Signature] > 0 {
+		s.shct[block.Signature]--
+	}
+}
+
+// FindVictim returns the RRIP victim.
+func (s *SHiPVictimFinder) FindVictim(set *Set) *Block {
+	return s.rrip.FindVictim(set)
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (s *SHiPVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return s.FindVictim(set)
+}
+
+// FindVictims returns up to n candidates in RRIP order.
+func (s *SHiPVictimFinder) FindVictims(
+	set *Set,
+	context *VictimContext,
+	n int,
+) []*Block {
+	return s.rrip.FindVictims(set, context, n)
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SHiPVictimFinder", func() {
+	var (
+		vf *SHiPVictimFinder
+		d  *DirectoryImpl
+	)
+
+	fill := func(addr, pc uint64) *Block {
+		block := d.FindVictimWithContext(addr, &VictimContext{
+			Address: addr,
+			PC:      pc,
+		})
+		block.Tag = addr
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	BeforeEach(func() {
+		vf = NewSHiPVictimFinder()
+		d = NewDirectory(1, 2, 64, vf)
+	})
+
+	It("should insert the lines of a streaming PC as distant", func() {
+		var block *Block
+		for i := uint64(0); i < 4; i++ {
+			block = fill(i*64, 0x100)
+		}
+
+		Expect(vf.Counter(block.Signature)).To(BeZero())
+		Expect(block.RRPV).To(Equal(uint8(rripMaxRRPV)))
+	})
+
+	It("should learn the reuse of a PC from its hits", func() {
+		block := fill(0, 0x200)
+		Expect(block.RRPV).To(Equal(uint8(rripMaxRRPV - 1)))
+
+		d.Visit(block)
+		d.Visit(block)
+
+		Expect(vf.Counter(block.Signature)).To(Equal(uint8(3)))
+		Expect(block.RRPV).To(BeZero())
+	})
+
+	It("should not train on the fill of an invalid block", func() {
+		block := fill(0, 0x300)
+
+		Expect(vf.Counter(block.Signature)).To(Equal(uint8(1)))
+	})
+
+	It("should sign accesses without a PC by memory region", func() {
+		a := fill(0x0, 0)
+		b := fill(0x40, 0)
+
+		Expect(a.Signature).To(Equal(b.Signature))
+	})
+})
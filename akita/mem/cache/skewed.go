@@ -0,0 +1,96 @@
+package cache
+
+import "github.com/sarchlab/akita/v4/mem/vm"
+
+// skewSeed separates the index functions of the ways.
+const skewSeed = 0x9e3779b97f4a7c15
+
+// A SkewedDirectory is a skewed-associative directory (Seznec, ISCA 1993).
+// Every way is indexed by its own hash of the line address, so two lines that
+// conflict in one way rarely conflict in the others. The candidate blocks of
+// a line are the blocks at its index in every way; Sets[i].Blocks[w] is the
+// block at index i of way w.
+//
+// The candidate blocks of a line do not form a set, so there is no
+// per-set state such as the PseudoLRU tree. The victim finder sees the
+// candidates as one set, ordered by way, and has to keep its state in the
+// blocks, as TrueLRUVictimFinder and RRIPVictimFinder do.
+type SkewedDirectory struct {
+	*DirectoryImpl
+}
+
+// NewSkewedDirectory returns a skewed-associative directory with numIndices
+// blocks per way. A nil victim finder selects a TrueLRUVictimFinder.
+func NewSkewedDirectory(
+	numIndices, way, blockSize int,
+	victimFinder VictimFinder,
+) *SkewedDirectory {
+	if victimFinder == nil {
+		victimFinder = NewTrueLRUVictimFinder()
+	}
+
+	return &SkewedDirectory{
+		DirectoryImpl: NewDirectory(numIndices, way, blockSize, victimFinder),
+	}
+}
+
+// skewIndex returns the index of the line in the way.
+func (d *SkewedDirectory) skewIndex(line uint64, way int) int {
+	h := mixLineHash(line ^ uint64(way)*skewSeed)
+	return int(h % uint64(d.NumSets))
+}
+
+// candidates returns the blocks that can hold the address, one per way.
+func (d *SkewedDirectory) candidates(addr uint64) *Set {
+	if d.AddrConverter != nil {
+		addr = d.AddrConverter.ConvertExternalToInternal(addr)
+	}
+
+	line := addr / uint64(d.BlockSize)
+	set := &Set{Blocks: make([]*Block, d.NumWays)}
+
+	for way := range set.Blocks {
+		set.Blocks[way] = d.Sets[d.skewIndex(line, way)].Blocks[way]
+	}
+
+	return set
+}
+
+// Lookup searches the candidate blocks of the address.
+func (d *SkewedDirectory) Lookup(pid vm.PID, addr uint64) *Block {
+	for _, block := range d.candidates(addr).Blocks {
+		if d.tagMatches(block, pid, addr) {
+			return block
+		}
+	}
+
+	return nil
+}
+
+// FindVictim returns the block among the candidates of the address that the
+// victim finder selects.
+func (d *SkewedDirectory) FindVictim(addr uint64) *Block {
+	return d.FindVictimWithContext(addr, nil)
+}
+
+// FindVictimWithContext returns the block among the candidates of the address
+// that the victim finder selects. It returns nil if every candidate is locked.
+func (d *SkewedDirectory) FindVictimWithContext(
+	addr uint64,
+	context *VictimContext,
+) *Block {
+	set := d.candidates(addr)
+
+	var block *Block
+	if context != nil {
+		block = d.victimFinder.FindVictimWithContext(set, context)
+	} else {
+		block = d.victimFinder.FindVictim(set)
+	}
+
+	if block != nil {
+		d.pendingFills[block.SetID] = block
+	}
+
+	return block
+}
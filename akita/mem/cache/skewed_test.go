@@ -0,0 +1,73 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SkewedDirectory", func() {
+	var d *SkewedDirectory
+
+	BeforeEach(func() {
+		d = NewSkewedDirectory(16, 2, 64, nil)
+	})
+
+	fill := func(addr uint64) *Block {
+		block := d.FindVictim(addr)
+		block.Tag = addr
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	It("should index every way with a different function", func() {
+		differ := false
+		for line := uint64(0); line < 16; line++ {
+			if d.skewIndex(line, 0) != d.skewIndex(line, 1) {
+				differ = true
+			}
+		}
+
+		Expect(differ).To(BeTrue())
+	})
+
+	It("should find the lines it holds", func() {
+		for i := uint64(0); i < 8; i++ {
+			fill(i * 64)
+
+			block := d.Lookup(0, i*64)
+			Expect(block).NotTo(BeNil())
+			Expect(block.SetID).To(Equal(d.skewIndex(i, block.WayID)))
+		}
+
+		Expect(d.Lookup(1, 0)).To(BeNil())
+	})
+
+	It("should evict the least recently used candidate", func() {
+		var other uint64
+		for line := uint64(1); ; line++ {
+			if d.skewIndex(line, 0) == d.skewIndex(0, 0) &&
+				d.skewIndex(line, 1) == d.skewIndex(0, 1) {
+				other = line * 64
+				break
+			}
+		}
+
+		a := fill(0)
+		b := fill(other)
+		d.Visit(b)
+
+		Expect(b.WayID).NotTo(Equal(a.WayID))
+		Expect(d.FindVictim(0x0)).To(BeIdenticalTo(a))
+	})
+
+	It("should return nil if every candidate is locked", func() {
+		for _, b := range d.candidates(0).Blocks {
+			b.IsValid = true
+			b.IsLocked = true
+		}
+
+		Expect(d.FindVictim(0)).To(BeNil())
+	})
+})
@@ -0,0 +1,161 @@
+package cache
+
+import "sort"
+
+// An SLRUConfig configures an SLRUVictimFinder.
+type SLRUConfig struct {
+	// The number of ways of a set that the protected segment may hold; the
+	// others form the probation segment. Defaults to half of the ways. The
+	// protected segment never takes the last way, so that fills always have
+	// room in probation.
+	ProtectedWays int
+}
+
+// SLRUVictimFinder implements Segmented LRU (Karedla et al., 1994). Every set
+// is split into a probation and a protected segment. Fills enter probation,
+// and a hit promotes the block to protected. When the protected segment
+// outgrows its ways, its least recently used block is demoted to the most
+// recently used position of probation. Victims are taken from probation
+// first, so a scan only churns the probation segment and the lines that were
+// reused stay resident. The segments share the recency stamps of
+// Block.LastAccess, and the segment of a block is Block.Protected.
+type SLRUVictimFinder struct {
+	config SLRUConfig
+	now    uint64
+}
+
+// NewSLRUVictimFinder returns an SLRU victim finder with half of every set
+// protected.
+func NewSLRUVictimFinder() *SLRUVictimFinder {
+	return NewSLRUVictimFinderWithConfig(SLRUConfig{})
+}
+
+// NewSLRUVictimFinderWithConfig returns an SLRU victim finder with the
+// configuration.
+func NewSLRUVictimFinderWithConfig(config SLRUConfig) *SLRUVictimFinder {
+	return &SLRUVictimFinder{config: config}
+}
+
+// Config returns the configuration.
+func (s *SLRUVictimFinder) Config() SLRUConfig {
+	return s.config
+}
+
+// Insert places a filled block at the most recently used position of
+// probation.
+func (s *SLRUVictimFinder) Insert(_ *Set, block *Block) {
+	s.now++
+	block.LastAccess = s.now
+	block.Protected = false
+}
+
+// Touch promotes a hit block to the most recently used position of the
+// protected segment, and demotes the least recently used protected block if
+// the segment is full.
+func (s *SLRUVictimFinder) Touch(set *Set, block *Block) {
+	s.now++
+	block.LastAccess = s.now
+
+	if block.Protected {
+		return
+	}
+
+	block.Protected = true
+
+	var (
+		protected int
+		oldest    *Block
+	)
+
+	for _, b := range set.Blocks {
+		if !b.IsValid || !b.Protected {
+			continue
+		}
+
+		protected++
+		if oldest == nil || b.LastAccess < oldest.LastAccess {
+			oldest = b
+		}
+	}
+
+	if protected > s.protectedWays(set) {
+		s.now++
+		oldest.LastAccess = s.now
+		oldest.Protected = false
+	}
+}
+
+// FindVictim returns the first invalid block, or the least recently used
+// unlocked block of probation, or of the protected segment if probation has
+// none. It returns nil if every block is locked.
+func (s *SLRUVictimFinder) FindVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	for _, way := range s.order(set) {
+		block := set.Blocks[way]
+		if !block.IsLocked && !isPinned(block) {
+			return block
+		}
+	}
+
+	return nil
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (s *SLRUVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return s.FindVictim(set)
+}
+
+// FindVictims returns up to n candidates, the probation blocks before the
+// protected ones, each from the least to the most recently used.
+func (s *SLRUVictimFinder) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	return rankCandidates(set, s.order(set), n)
+}
+
+// ProtectedBlocks returns the number of valid blocks of the set in the
+// protected segment.
+func (s *SLRUVictimFinder) ProtectedBlocks(set *Set) int {
+	protected := 0
+
+	for _, block := range set.Blocks {
+		if block.IsValid && block.Protected {
+			protected++
+		}
+	}
+
+	return protected
+}
+
+func (s *SLRUVictimFinder) protectedWays(set *Set) int {
+	ways := s.config.ProtectedWays
+	if ways <= 0 {
+		ways = len(set.Blocks) / 2
+	}
+
+	if ways >= len(set.Blocks) {
+		ways = len(set.Blocks) - 1
+	}
+
+	return ways
+}
+
+// order returns the ways of the set, the probation blocks before the
+// protected ones, each from the least to the most recently used.
+func (s *SLRUVictimFinder) order(set *Set) []int {
+	ways := trueLRUOrder(set)
+
+	sort.SliceStable(ways, func(i, j int) bool {
+		return !set.Blocks[ways[i]].Protected && set.Blocks[ways[j]].Protected
+	})
+
+	return ways
+}
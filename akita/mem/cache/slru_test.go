@@ -0,0 +1,97 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SLRUVictimFinder", func() {
+	var (
+		vf *SLRUVictimFinder
+		d  *DirectoryImpl
+	)
+
+	access := func(tag uint64) *Block {
+		block := d.Lookup(0, tag)
+		if block == nil {
+			block = d.FindVictim(tag)
+			block.Tag = tag
+			block.IsValid = true
+		}
+
+		d.Visit(block)
+
+		return block
+	}
+
+	BeforeEach(func() {
+		vf = NewSLRUVictimFinder()
+		d = NewDirectory(1, 4, 64, vf)
+	})
+
+	It("should fill probation and promote on hits", func() {
+		a := access(0x0)
+		Expect(a.Protected).To(BeFalse())
+
+		access(0x0)
+		Expect(a.Protected).To(BeTrue())
+		Expect(vf.ProtectedBlocks(&d.Sets[0])).To(Equal(1))
+	})
+
+	It("should keep reused lines through a scan", func() {
+		a := access(0x0)
+		b := access(0x40)
+		access(0x0)
+		access(0x40)
+
+		for i := uint64(2); i < 20; i++ {
+			access(i * 64)
+		}
+
+		Expect(d.Lookup(0, 0x0)).To(BeIdenticalTo(a))
+		Expect(d.Lookup(0, 0x40)).To(BeIdenticalTo(b))
+	})
+
+	It("should demote the oldest protected block when the segment is full",
+		func() {
+			a := access(0x0)
+			b := access(0x40)
+			c := access(0x80)
+			access(0x0)
+			access(0x40)
+			access(0x80)
+
+			Expect(a.Protected).To(BeFalse())
+			Expect(b.Protected).To(BeTrue())
+			Expect(c.Protected).To(BeTrue())
+
+			access(0xc0)
+			Expect(access(0x100)).To(BeIdenticalTo(a))
+		})
+
+	It("should take the configured protected ways", func() {
+		vf = NewSLRUVictimFinderWithConfig(SLRUConfig{ProtectedWays: 8})
+		d = NewDirectory(1, 4, 64, vf)
+
+		for i := uint64(0); i < 4; i++ {
+			access(i * 64)
+			access(i * 64)
+		}
+
+		Expect(vf.ProtectedBlocks(&d.Sets[0])).To(Equal(3))
+	})
+
+	It("should rank probation before the protected segment", func() {
+		set := makeTestSet(4)
+		for i, block := range set.Blocks {
+			block.IsValid = true
+			block.LastAccess = uint64(4 - i)
+		}
+		set.Blocks[3].Protected = true
+
+		Expect(vf.FindVictims(set, nil, 4)).To(Equal([]*Block{
+			set.Blocks[2], set.Blocks[1], set.Blocks[0], set.Blocks[3],
+		}))
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[2]))
+	})
+})
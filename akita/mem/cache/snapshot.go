@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// DirectorySnapshotVersion is the version of the snapshot file format.
+const DirectorySnapshotVersion = 1
+
+// A SnapshotEntry describes a resident cache line.
+type SnapshotEntry struct {
+	PID   vm.PID `json:"pid"`
+	Tag   uint64 `json:"tag"`
+	Dirty bool   `json:"dirty,omitempty"`
+}
+
+// A SnapshotRange describes a synthetic working set of consecutive cache
+// lines that are all resident.
+type SnapshotRange struct {
+	PID   vm.PID `json:"pid"`
+	Start uint64 `json:"start"`
+	Size  uint64 `json:"size"`
+	Dirty bool   `json:"dirty,omitempty"`
+}
+
+// A DirectorySnapshot lists the cache lines that are resident in a
+// directory. It can be produced from a previous run with
+// DirectoryImpl.Snapshot, or written by hand to describe a synthetic working
+// set.
+type DirectorySnapshot struct {
+	Version int             `json:"version"`
+	Entries []SnapshotEntry `json:"entries,omitempty"`
+	Ranges  []SnapshotRange `json:"ranges,omitempty"`
+}
+
+// Snapshot captures the valid blocks of the directory, ordered by set and
+// way.
+func (d *DirectoryImpl) Snapshot() DirectorySnapshot {
+	s := DirectorySnapshot{Version: DirectorySnapshotVersion}
+
+	for _, set := range d.Sets {
+		for _, block := range set.Blocks {
+			if !block.IsValid {
+				continue
+			}
+
+			s.Entries = append(s.Entries, SnapshotEntry{
+				PID:   block.PID,
+				Tag:   block.Tag,
+				Dirty: block.IsDirty,
+			})
+		}
+	}
+
+	return s
+}
+
+// Preload fills the directory with the cache lines in the snapshot. Entries
+// are placed in the order given, ranges after the entries. Lines that are
+// already resident are skipped. An error is returned if a line maps to a set
+// that has no invalid block left.
+func (d *DirectoryImpl) Preload(s DirectorySnapshot) error {
+	if s.Version > DirectorySnapshotVersion {
+		return fmt.Errorf("unsupported directory snapshot version %d",
+			s.Version)
+	}
+
+	for _, e := range s.Entries {
+		if err := d.preloadLine(e.PID, e.Tag, e.Dirty); err != nil {
+			return err
+		}
+	}
+
+	blockSize := uint64(d.BlockSize)
+	for _, r := range s.Ranges {
+		start := r.Start / blockSize * blockSize
+		for addr := start; addr < r.Start+r.Size; addr += blockSize {
+			if err := d.preloadLine(r.PID, addr, r.Dirty); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *DirectoryImpl) preloadLine(pid vm.PID, addr uint64, dirty bool) error {
+	tag := addr / uint64(d.BlockSize) * uint64(d.BlockSize)
+	if d.findBlock(pid, tag) != nil {
+		return nil
+	}
+
+	set, setID := d.getSet(tag)
+	for _, block := range set.Blocks {
+		if block.IsValid {
+			continue
+		}
+
+		block.PID = pid
+		block.Tag = tag
+		block.IsValid = true
+		block.IsDirty = dirty
+		d.Visit(block)
+
+		return nil
+	}
+
+	return fmt.Errorf("cannot preload line 0x%x: set %d is full", tag, setID)
+}
+
+// ReadDirectorySnapshot decodes a JSON snapshot.
+func ReadDirectorySnapshot(r io.Reader) (DirectorySnapshot, error) {
+	var s DirectorySnapshot
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&s); err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// WriteDirectorySnapshot encodes the snapshot as JSON.
+func WriteDirectorySnapshot(w io.Writer, s DirectorySnapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(s)
+}
+
+// PreloadFromFile reads a JSON snapshot from the file and preloads it into
+// the directory.
+func (d *DirectoryImpl) PreloadFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s, err := ReadDirectorySnapshot(f)
+	if err != nil {
+		return fmt.Errorf("reading snapshot %s: %w", path, err)
+	}
+
+	return d.Preload(s)
+}
+
+// SaveSnapshotToFile writes the snapshot of the directory to the file.
+func (d *DirectoryImpl) SaveSnapshotToFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = WriteDirectorySnapshot(f, d.Snapshot())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DirectorySnapshot", func() {
+	var (
+		d *DirectoryImpl
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(4, 2, 64, NewLRUVictimFinder())
+	})
+
+	It("should preload entries and ranges", func() {
+		s := DirectorySnapshot{
+			Version: DirectorySnapshotVersion,
+			Entries: []SnapshotEntry{{PID: 1, Tag: 0x1000, Dirty: true}},
+			Ranges:  []SnapshotRange{{PID: 2, Start: 0x2000, Size: 0x100}},
+		}
+
+		Expect(d.Preload(s)).To(Succeed())
+
+		Expect(d.Lookup(1, 0x1000).IsDirty).To(BeTrue())
+		for addr := uint64(0x2000); addr < 0x2100; addr += 64 {
+			Expect(d.Lookup(2, addr)).NotTo(BeNil())
+		}
+	})
+
+	It("should fail if a set overflows", func() {
+		s := DirectorySnapshot{
+			Ranges: []SnapshotRange{{PID: 1, Start: 0, Size: 64 * 4 * 3}},
+		}
+
+		Expect(d.Preload(s)).To(MatchError(ContainSubstring("is full")))
+	})
+
+	It("should round trip through a file", func() {
+		Expect(d.Preload(DirectorySnapshot{
+			Entries: []SnapshotEntry{{PID: 1, Tag: 0x40}, {PID: 1, Tag: 0x80}},
+		})).To(Succeed())
+		path := filepath.Join(GinkgoT().TempDir(), "snapshot.json")
+
+		Expect(d.SaveSnapshotToFile(path)).To(Succeed())
+		warm := NewDirectory(4, 2, 64, NewLRUVictimFinder())
+		Expect(warm.PreloadFromFile(path)).To(Succeed())
+
+		Expect(warm.Snapshot()).To(Equal(d.Snapshot()))
+	})
+
+	It("should reject unknown fields", func() {
+		_, err := ReadDirectorySnapshot(strings.NewReader(`{"tags": []}`))
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should write the snapshot as JSON", func() {
+		buf := new(bytes.Buffer)
+
+		Expect(WriteDirectorySnapshot(buf, d.Snapshot())).To(Succeed())
+
+		Expect(buf.String()).To(ContainSubstring(`"version": 1`))
+	})
+})
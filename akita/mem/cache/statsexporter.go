@@ -0,0 +1,400 @@
+package cache
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+// StatsFormat selects the file format of a StatsExporter.
+type StatsFormat int
+
+// Statistics file formats.
+const (
+	// StatsFormatCSV writes a header row and then one row per dump.
+	StatsFormatCSV StatsFormat = iota
+
+	// StatsFormatJSON writes one JSON object per dump and per line.
+	StatsFormatJSON
+)
+
+// StatsExporterConfig configures a StatsExporter.
+type StatsExporterConfig struct {
+	Format StatsFormat
+
+	// If positive, a dump is written every PredictionInterval victim
+	// selections.
+	PredictionInterval uint64
+
+	// If positive, a dump is written at the first victim selection at least
+	// TimeInterval after the previous dump. The time is read from
+	// TimeTeller.
+	TimeInterval sim.VTimeInSec
+	TimeTeller   sim.TimeTeller
+
+	// The number of buckets of the weight and confidence histograms. The
+	// default is 16.
+	HistogramBuckets int
+
+	// The confidence histogram covers the magnitudes of the prediction sums
+	// below MaxConfidence; larger magnitudes fall in the last bucket. The
+	// default is 256.
+	MaxConfidence int32
+}
+
+// A StatsDump holds the statistics of the interval since the previous dump.
+// The weight histogram is a snapshot taken at the dump.
+type StatsDump struct {
+	Dump        uint64         `json:"dump"`
+	Time        sim.VTimeInSec `json:"time"`
+	Selections  uint64         `json:"selections"`
+	Predictions uint64         `json:"predictions"`
+	Confident   uint64         `json:"confident"`
+	Outcomes    uint64         `json:"outcomes"`
+	Correct     uint64         `json:"correct"`
+
+	// Accuracy is the fraction of the outcomes that were predicted
+	// correctly, and Coverage the fraction of the predictions whose
+	// magnitude reached theta.
+	Accuracy float64 `json:"accuracy"`
+	Coverage float64 `json:"coverage"`
+
+	// WeightHistogram counts the weights in use, the weight vector or the
+	// hashed table entries, in equal buckets of the weight range. It is
+	// empty if the victim finder does not export weights.
+	WeightHistogram []uint64 `json:"weight_histogram,omitempty"`
+
+	// SetEvictions counts the evictions of valid lines of every set. It is
+	// empty if the exporter is not attached to a directory.
+	SetEvictions []uint64 `json:"set_evictions,omitempty"`
+
+	ConfidenceHistogram []uint64 `json:"confidence_histogram"`
+}
+
+// weightExporter is implemented by the victim finders whose weights can be
+// exported.
+type weightExporter interface {
+	ExportWeights() PerceptronWeights
+}
+
+// A StatsExporter periodically writes the statistics of a victim finder and
+// a directory to a CSV or JSON stream for offline plotting. It observes the
+// victim finder through its hooks, so the prediction, accuracy, and
+// confidence statistics are only available for victim finders that invoke
+// the perceptron hooks. The directory provides the victim selections and the
+// evictions per set.
+//
+// Since hooks cannot return errors, the first write error is kept and
+// returned by Err and Flush, and no more dumps are written after it.
+type StatsExporter struct {
+	config  StatsExporterConfig
+	writer  io.Writer
+	csv     *csv.Writer
+	weights weightExporter
+
+	directory bool
+	header    bool
+	dumps     uint64
+	lastDump  sim.VTimeInSec
+	err       error
+
+	current StatsDump
+}
+
+// NewStatsExporter creates an exporter that writes to the writer.
+func NewStatsExporter(
+	w io.Writer,
+	config StatsExporterConfig,
+) (*StatsExporter, error) {
+	if config.Format != StatsFormatCSV && config.Format != StatsFormatJSON {
+		return nil, fmt.Errorf("unknown statistics format %d", config.Format)
+	}
+
+	if config.TimeInterval > 0 && config.TimeTeller == nil {
+		return nil, fmt.Errorf("a time interval requires a time teller")
+	}
+
+	if config.HistogramBuckets < 0 || config.MaxConfidence < 0 {
+		return nil, fmt.Errorf("histogram parameters must not be negative")
+	}
+
+	if config.HistogramBuckets == 0 {
+		config.HistogramBuckets = 16
+	}
+
+	if config.MaxConfidence == 0 {
+		config.MaxConfidence = 256
+	}
+
+	e := &StatsExporter{config: config, writer: w}
+	if config.Format == StatsFormatCSV {
+		e.csv = csv.NewWriter(w)
+	}
+
+	e.current = e.newDump(0)
+
+	return e, nil
+}
+
+// Attach observes the victim finder. It registers the exporter as a hook of
+// the victim finder if it is hookable, and snapshots its weights if they can
+// be exported.
+func (e *StatsExporter) Attach(vf VictimFinder) {
+	if h, ok := vf.(sim.Hookable); ok {
+		h.AcceptHook(e)
+	}
+
+	e.weights, _ = vf.(weightExporter)
+}
+
+// SetStatsExporter attaches the exporter to the directory and to its victim
+// finder.
+func (d *DirectoryImpl) SetStatsExporter(e *StatsExporter) {
+	d.statsExporter = e
+	if e == nil {
+		return
+	}
+
+	e.directory = true
+	e.current.SetEvictions = make([]uint64, d.NumSets)
+	e.Attach(d.victimFinder)
+}
+
+// StatsExporter returns the attached exporter, if any.
+func (d *DirectoryImpl) StatsExporter() *StatsExporter {
+	return d.statsExporter
+}
+
+// Func records the perceptron events.
+func (e *StatsExporter) Func(ctx sim.HookCtx) {
+	switch ctx.Pos {
+	case HookPosPredictionMade:
+		prediction := ctx.Item.(PerceptronPrediction)
+		e.recordPrediction(prediction)
+	case HookPosTrainingApplied:
+		training := ctx.Item.(PerceptronTraining)
+		e.current.Outcomes++
+
+		if training.PredictedNoReuse != training.ActualReuse {
+			e.current.Correct++
+		}
+	}
+}
+
+func (e *StatsExporter) recordPrediction(prediction PerceptronPrediction) {
+	c := &e.current
+	c.Predictions++
+
+	confidence := abs(prediction.Sum)
+	if confidence >= prediction.Theta {
+		c.Confident++
+	}
+
+	c.ConfidenceHistogram[bucket(confidence, 0,
+		e.config.MaxConfidence-1, len(c.ConfidenceHistogram))]++
+
+	// Without a directory, every prediction selects a victim.
+	if !e.directory {
+		e.recordSelection()
+	}
+}
+
+func (e *StatsExporter) recordVictim(setID int, block *Block) {
+	if e == nil {
+		return
+	}
+
+	if block != nil && block.IsValid {
+		e.current.SetEvictions[setID]++
+	}
+
+	e.recordSelection()
+}
+
+func (e *StatsExporter) recordSelection() {
+	e.current.Selections++
+
+	if n := e.config.PredictionInterval; n > 0 && e.current.Selections >= n {
+		e.dump()
+		return
+	}
+
+	if e.config.TimeInterval > 0 &&
+		e.config.TimeTeller.CurrentTime()-e.lastDump >= e.config.TimeInterval {
+		e.dump()
+	}
+}
+
+// bucket returns the bucket of the value among n equal buckets of [lo, hi].
+// Values out of the range fall in the first or the last bucket.
+func bucket(v, lo, hi int32, n int) int {
+	width := (int64(hi) - int64(lo) + int64(n)) / int64(n)
+
+	i := (int64(v) - int64(lo)) / width
+	if i < 0 {
+		return 0
+	}
+
+	if i >= int64(n) {
+		return n - 1
+	}
+
+	return int(i)
+}
+
+func (e *StatsExporter) newDump(dump uint64) StatsDump {
+	d := StatsDump{
+		Dump:                dump,
+		ConfidenceHistogram: make([]uint64, e.config.HistogramBuckets),
+	}
+
+	if e.directory {
+		d.SetEvictions = make([]uint64, len(e.current.SetEvictions))
+	}
+
+	return d
+}
+
+// weightHistogram returns the histogram of the current weights.
+func (e *StatsExporter) weightHistogram() []uint64 {
+	if e.weights == nil {
+		return nil
+	}
+
+	w := e.weights.ExportWeights()
+	config, _ := w.WeightConfig.withDefaults()
+	h := make([]uint64, e.config.HistogramBuckets)
+
+	if !w.Hashed {
+		for _, v := range w.Weights {
+			h[bucket(v, config.Min, config.Max, len(h))]++
+		}
+
+		return h
+	}
+
+	for _, t := range w.Tables {
+		for _, v := range t {
+			h[bucket(v, config.Min, config.Max, len(h))]++
+		}
+	}
+
+	return h
+}
+
+// dump writes the statistics of the current interval and starts the next.
+func (e *StatsExporter) dump() {
+	if e.config.TimeTeller != nil {
+		e.lastDump = e.config.TimeTeller.CurrentTime()
+	}
+
+	c := &e.current
+	c.Time = e.lastDump
+	c.Accuracy = ratio(c.Correct, c.Outcomes)
+	c.Coverage = ratio(c.Confident, c.Predictions)
+	c.WeightHistogram = e.weightHistogram()
+
+	if e.err == nil {
+		e.err = e.write(c)
+	}
+
+	e.dumps++
+	e.current = e.newDump(e.dumps)
+}
+
+func (e *StatsExporter) write(d *StatsDump) error {
+	if e.config.Format == StatsFormatJSON {
+		line, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+
+		_, err = e.writer.Write(append(line, '\n'))
+
+		return err
+	}
+
+	if !e.header {
+		e.header = true
+		if err := e.csv.Write(e.csvHeader(d)); err != nil {
+			return err
+		}
+	}
+
+	if err := e.csv.Write(csvRow(d)); err != nil {
+		return err
+	}
+
+	e.csv.Flush()
+
+	return e.csv.Error()
+}
+
+func (e *StatsExporter) csvHeader(d *StatsDump) []string {
+	header := []string{
+		"dump", "time", "selections", "predictions", "confident",
+		"outcomes", "correct", "accuracy", "coverage",
+	}
+
+	for i := range d.WeightHistogram {
+		header = append(header, fmt.Sprintf("weight_bucket_%d", i))
+	}
+
+	for i := range d.ConfidenceHistogram {
+		header = append(header, fmt.Sprintf("confidence_bucket_%d", i))
+	}
+
+	for i := range d.SetEvictions {
+		header = append(header, fmt.Sprintf("set_%d_evictions", i))
+	}
+
+	return header
+}
+
+func csvRow(d *StatsDump) []string {
+	row := []string{
+		strconv.FormatUint(d.Dump, 10),
+		strconv.FormatFloat(float64(d.Time), 'g', -1, 64),
+		strconv.FormatUint(d.Selections, 10),
+		strconv.FormatUint(d.Predictions, 10),
+		strconv.FormatUint(d.Confident, 10),
+		strconv.FormatUint(d.Outcomes, 10),
+		strconv.FormatUint(d.Correct, 10),
+		strconv.FormatFloat(d.Accuracy, 'g', -1, 64),
+		strconv.FormatFloat(d.Coverage, 'g', -1, 64),
+	}
+
+	for _, counts := range [][]uint64{
+		d.WeightHistogram, d.ConfidenceHistogram, d.SetEvictions,
+	} {
+		for _, n := range counts {
+			row = append(row, strconv.FormatUint(n, 10))
+		}
+	}
+
+	return row
+}
+
+// Flush writes the statistics of the current interval, if there are any,
+// and returns the first write error.
+func (e *StatsExporter) Flush() error {
+	if e.current.Selections > 0 || e.current.Outcomes > 0 {
+		e.dump()
+	}
+
+	return e.err
+}
+
+// Dumps returns the number of dumps written so far.
+func (e *StatsExporter) Dumps() uint64 {
+	return e.dumps
+}
+
+// Err returns the first write error.
+func (e *StatsExporter) Err() error {
+	return e.err
+}
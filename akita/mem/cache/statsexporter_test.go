@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/sim"
+)
+
+type fixedTimeTeller struct {
+	now sim.VTimeInSec
+}
+
+func (t *fixedTimeTeller) CurrentTime() sim.VTimeInSec {
+	return t.now
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func sumCounts(counts []uint64) uint64 {
+	var n uint64
+	for _, c := range counts {
+		n += c
+	}
+
+	return n
+}
+
+var _ = Describe("StatsExporter", func() {
+	var (
+		p   *PerceptronVictimFinder
+		d   *DirectoryImpl
+		buf *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		p.SetTrainingSampleInterval(1)
+		d = NewDirectory(4, 2, 64, p)
+		buf = &bytes.Buffer{}
+
+		for i := range d.Sets {
+			for _, b := range d.Sets[i].Blocks {
+				b.IsValid = true
+			}
+		}
+	})
+
+	It("should write a JSON dump every N victim selections", func() {
+		e, err := NewStatsExporter(buf, StatsExporterConfig{
+			Format:             StatsFormatJSON,
+			PredictionInterval: 2,
+			HistogramBuckets:   4,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		d.SetStatsExporter(e)
+
+		for i := 0; i < 5; i++ {
+			d.FindVictimWithContext(uint64(i)*64, &VictimContext{})
+		}
+		p.TrainOnEviction(0x40)
+
+		Expect(e.Dumps()).To(Equal(uint64(2)))
+		Expect(e.Flush()).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		Expect(lines).To(HaveLen(3))
+
+		var dumps []StatsDump
+		for _, line := range lines {
+			var s StatsDump
+			Expect(json.Unmarshal([]byte(line), &s)).To(Succeed())
+			dumps = append(dumps, s)
+		}
+
+		Expect(dumps[0].Dump).To(BeZero())
+		Expect(dumps[0].Selections).To(Equal(uint64(2)))
+		Expect(dumps[0].Predictions).To(Equal(uint64(2)))
+		Expect(dumps[0].SetEvictions).To(Equal([]uint64{1, 1, 0, 0}))
+		Expect(sumCounts(dumps[0].ConfidenceHistogram)).To(Equal(uint64(2)))
+		Expect(sumCounts(dumps[0].WeightHistogram)).To(
+			Equal(uint64(PerceptronNumTables * PerceptronTableSize)))
+
+		Expect(dumps[2].Selections).To(Equal(uint64(1)))
+		Expect(dumps[2].Outcomes).To(Equal(uint64(1)))
+		Expect(dumps[2].Accuracy).To(Equal(1.0))
+	})
+
+	It("should write a CSV dump every simulated interval", func() {
+		clock := &fixedTimeTeller{}
+		e, err := NewStatsExporter(buf, StatsExporterConfig{
+			Format:           StatsFormatCSV,
+			TimeInterval:     1,
+			TimeTeller:       clock,
+			HistogramBuckets: 2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		lru := NewDirectory(2, 2, 64, NewLRUVictimFinder())
+		lru.SetStatsExporter(e)
+
+		lru.FindVictim(0)
+		clock.now = 1.5
+		lru.FindVictim(64)
+		lru.FindVictim(0)
+		Expect(e.Flush()).To(Succeed())
+
+		rows, err := csv.NewReader(buf).ReadAll()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rows).To(HaveLen(3))
+		Expect(rows[0]).To(Equal([]string{
+			"dump", "time", "selections", "predictions", "confident",
+			"outcomes", "correct", "accuracy", "coverage",
+			"confidence_bucket_0", "confidence_bucket_1",
+			"set_0_evictions", "set_1_evictions",
+		}))
+		Expect(rows[1][:3]).To(Equal([]string{"0", "1.5", "2"}))
+		Expect(rows[2][:3]).To(Equal([]string{"1", "1.5", "1"}))
+	})
+
+	It("should reject an invalid configuration", func() {
+		_, err := NewStatsExporter(buf, StatsExporterConfig{Format: 2})
+		Expect(err).To(HaveOccurred())
+
+		_, err = NewStatsExporter(buf, StatsExporterConfig{TimeInterval: 1})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should keep the first write error", func() {
+		e, err := NewStatsExporter(failingWriter{}, StatsExporterConfig{
+			Format:             StatsFormatJSON,
+			PredictionInterval: 1,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		d.SetStatsExporter(e)
+
+		d.FindVictimWithContext(0, &VictimContext{})
+
+		Expect(e.Err()).To(MatchError("disk full"))
+		Expect(e.Flush()).To(MatchError("disk full"))
+	})
+})
@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// An IntervalRecord holds the statistics of one component over one interval.
+// Values are encoded with sorted keys, so identical records produce identical
+// lines.
+type IntervalRecord struct {
+	Interval  uint64             `json:"interval"`
+	Component string             `json:"component"`
+	Values    map[string]float64 `json:"values"`
+}
+
+// RotatingStatsConfig configures a RotatingStatsSink.
+type RotatingStatsConfig struct {
+	// Files are named <Prefix>.<index>.jsonl, with a ".gz" suffix once
+	// compressed.
+	Prefix string
+
+	// A file is rotated once it holds at least MaxBytes bytes.
+	MaxBytes int64
+
+	// If positive, only the newest MaxFiles rotated files are kept.
+	MaxFiles int
+
+	// Compress gzips every rotated file.
+	Compress bool
+}
+
+// A RotatingStatsSink appends interval records, one JSON object per line, to
+// a series of size-limited files. Nothing but the current file is kept in
+// memory, so long simulations can record statistics at a fine resolution.
+type RotatingStatsSink struct {
+	config  RotatingStatsConfig
+	index   int
+	file    *os.File
+	size    int64
+	rotated []string
+}
+
+// NewRotatingStatsSink creates the directory of the prefix, if needed, and
+// opens the first file.
+func NewRotatingStatsSink(config RotatingStatsConfig) (*RotatingStatsSink, error) {
+	if config.MaxBytes <= 0 {
+		return nil, fmt.Errorf("stats file size limit must be positive")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(config.Prefix), 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &RotatingStatsSink{config: config}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *RotatingStatsSink) currentPath() string {
+	return fmt.Sprintf("%s.%06d.jsonl", s.config.Prefix, s.index)
+}
+
+func (s *RotatingStatsSink) open() error {
+	f, err := os.Create(s.currentPath())
+	if err != nil {
+		return err
+	}
+
+	s.file = f
+	s.size = 0
+
+	return nil
+}
+
+// Write appends a record. Any value that encodes to JSON is accepted.
+func (s *RotatingStatsSink) Write(record interface{}) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+
+	if err != nil {
+		return err
+	}
+
+	if s.size >= s.config.MaxBytes {
+		return s.Rotate()
+	}
+
+	return nil
+}
+
+// Rotate closes the current file and starts the next one.
+func (s *RotatingStatsSink) Rotate() error {
+	path, err := s.closeCurrent()
+	if err != nil {
+		return err
+	}
+
+	s.rotated = append(s.rotated, path)
+	if err := s.prune(); err != nil {
+		return err
+	}
+
+	s.index++
+
+	return s.open()
+}
+
+func (s *RotatingStatsSink) closeCurrent() (string, error) {
+	path := s.currentPath()
+	if err := s.file.Close(); err != nil {
+		return "", err
+	}
+
+	if !s.config.Compress {
+		return path, nil
+	}
+
+	if err := gzipFile(path); err != nil {
+		return "", err
+	}
+
+	return path + ".gz", nil
+}
+
+func (s *RotatingStatsSink) prune() error {
+	if s.config.MaxFiles <= 0 {
+		return nil
+	}
+
+	for len(s.rotated) > s.config.MaxFiles {
+		if err := os.Remove(s.rotated[0]); err != nil {
+			return err
+		}
+
+		s.rotated = s.rotated[1:]
+	}
+
+	return nil
+}
+
+// Files returns the rotated files that are kept, oldest first.
+func (s *RotatingStatsSink) Files() []string {
+	return s.rotated
+}
+
+// Close closes the current file. The last file is compressed like the
+// rotated ones but does not count toward MaxFiles.
+func (s *RotatingStatsSink) Close() error {
+	path, err := s.closeCurrent()
+	if err != nil {
+		return err
+	}
+
+	s.rotated = append(s.rotated, path)
+
+	return nil
+}
+
+// gzipFile compresses the file into <path>.gz and removes the original.
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
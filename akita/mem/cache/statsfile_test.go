@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RotatingStatsSink", func() {
+	var (
+		prefix string
+	)
+
+	BeforeEach(func() {
+		prefix = filepath.Join(GinkgoT().TempDir(), "stats", "l2")
+	})
+
+	writeRecords := func(s *RotatingStatsSink, n int) {
+		for i := 0; i < n; i++ {
+			Expect(s.Write(IntervalRecord{
+				Interval:  uint64(i),
+				Component: "L2",
+				Values:    map[string]float64{"misses": 1, "hits": 2},
+			})).To(Succeed())
+		}
+	}
+
+	It("should rotate files by size", func() {
+		s, err := NewRotatingStatsSink(RotatingStatsConfig{
+			Prefix: prefix, MaxBytes: 150,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		writeRecords(s, 5)
+		Expect(s.Close()).To(Succeed())
+
+		Expect(s.Files()).To(HaveLen(2))
+		data, err := os.ReadFile(s.Files()[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(HavePrefix(
+			`{"interval":0,"component":"L2","values":{"hits":2,"misses":1}}`))
+		Expect(strings.Count(string(data), "\n")).To(Equal(3))
+	})
+
+	It("should compress rotated files and keep the newest", func() {
+		s, err := NewRotatingStatsSink(RotatingStatsConfig{
+			Prefix: prefix, MaxBytes: 1, MaxFiles: 2, Compress: true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		writeRecords(s, 4)
+
+		files := s.Files()
+		Expect(files).To(HaveLen(2))
+		Expect(files[0]).To(HaveSuffix(".000002.jsonl.gz"))
+		_, err = os.Stat(prefix + ".000000.jsonl.gz")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		f, err := os.Open(files[1])
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+		zr, err := gzip.NewReader(f)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := io.ReadAll(zr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`"interval":3`))
+
+		Expect(s.Close()).To(Succeed())
+	})
+})
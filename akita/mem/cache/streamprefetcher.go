@@ -0,0 +1,254 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v4/mem/vm"
+)
+
+// A StreamPrefetcherConfig configures a StreamPrefetcher.
+type StreamPrefetcherConfig struct {
+	// Number of streams tracked at once. Defaults to 16.
+	NumStreams int
+
+	// Number of lines prefetched ahead of a confirmed stream. Defaults to 2.
+	Degree int
+
+	// Number of accesses that must repeat the stride before the stream
+	// prefetches. Defaults to 2.
+	Confirmations int
+
+	// Largest stride, in lines, that continues a stream. Defaults to 16.
+	Window int
+}
+
+// StreamPrefetchStats counts the prefetches of a StreamPrefetcher.
+type StreamPrefetchStats struct {
+	Issued    uint64 // Prefetch hints returned
+	Redundant uint64 // Candidates already resident
+	Throttled uint64 // Candidates dropped because their set was all live
+	Useful    uint64 // Prefetched lines hit by a demand access
+
+	// Demand misses of the directory, and the ones among them to lines
+	// evicted by prefetch fills. They are only counted if the directory has
+	// a PrefetchFeedbackTracker.
+	DemandMisses    uint64
+	PollutionMisses uint64
+}
+
+// Accuracy returns the fraction of the issued prefetches that were used.
+func (s StreamPrefetchStats) Accuracy() float64 {
+	return ratio(s.Useful, s.Issued)
+}
+
+// PollutionRate returns the fraction of demand misses caused by prefetch
+// evictions.
+func (s StreamPrefetchStats) PollutionRate() float64 {
+	return ratio(s.PollutionMisses, s.DemandMisses)
+}
+
+type prefetchStream struct {
+	pid          vm.PID
+	lastLine     uint64
+	stride       int64
+	confirmed    int
+	covered      int // Lines ahead of the stream already prefetched
+	lastAccessed uint64
+}
+
+// A StreamPrefetcher detects constant-stride streams of accesses of every
+// process and returns prefetch hints for the lines ahead of them. The cache
+// controller fetches the hinted lines and fills them with
+// VictimContext.IsPrefetch set.
+//
+// If the victim finder of the directory is a DeadBlockPredictor, the
+// prefetches into sets whose blocks are all valid and predicted live are
+// dropped, since they would evict a line that is likely to be reused.
+type StreamPrefetcher struct {
+	directory *DirectoryImpl
+	config    StreamPrefetcherConfig
+
+	streams  []prefetchStream
+	accesses uint64
+	stats    StreamPrefetchStats
+}
+
+// NewStreamPrefetcher creates a stream prefetcher for the directory.
+func NewStreamPrefetcher(
+	d *DirectoryImpl,
+	c StreamPrefetcherConfig,
+) *StreamPrefetcher {
+	if c.NumStreams == 0 {
+		c.NumStreams = 16
+	}
+
+	if c.Degree == 0 {
+		c.Degree = 2
+	}
+
+	if c.Confirmations == 0 {
+		c.Confirmations = 2
+	}
+
+	if c.Window == 0 {
+		c.Window = 16
+	}
+
+	if c.NumStreams < 0 || c.Degree < 0 || c.Confirmations < 0 ||
+		c.Window < 0 {
+		panic(fmt.Sprintf("invalid stream prefetcher config %+v", c))
+	}
+
+	return &StreamPrefetcher{
+		directory: d,
+		config:    c,
+		streams:   make([]prefetchStream, 0, c.NumStreams),
+	}
+}
+
+// Access observes a demand access and returns the addresses of the lines to
+// prefetch. A confirmed stream prefetches the Degree lines ahead of it, minus
+// the ones hinted by its earlier accesses. Access must be called before the directory visits the block of the
+// access, so that hits to prefetched lines are counted as useful.
+func (p *StreamPrefetcher) Access(pid vm.PID, addr uint64) []uint64 {
+	blockSize := uint64(p.directory.BlockSize)
+	line := addr / blockSize
+
+	p.accesses++
+	p.recordUse(pid, line*blockSize)
+
+	s := p.train(pid, line)
+	if s == nil || s.confirmed < p.config.Confirmations {
+		return nil
+	}
+
+	// The stream advanced by one stride past a line that earlier
+	// prefetches covered.
+	if s.covered > 0 {
+		s.covered--
+	}
+
+	var hints []uint64
+
+	for i := s.covered + 1; i <= p.config.Degree; i++ {
+		next := int64(line) + int64(i)*s.stride
+		if next < 0 {
+			break
+		}
+
+		tag := uint64(next) * blockSize
+
+		if p.directory.findBlock(pid, tag) != nil {
+			p.stats.Redundant++
+		} else if p.setAllLive(tag) {
+			p.stats.Throttled++
+			break
+		} else {
+			p.stats.Issued++
+			hints = append(hints, tag)
+		}
+
+		s.covered = i
+	}
+
+	return hints
+}
+
+// Stats returns the prefetch statistics.
+func (p *StreamPrefetcher) Stats() StreamPrefetchStats {
+	s := p.stats
+
+	if t := p.directory.prefetchFeedback; t != nil {
+		r := t.Report()
+		s.DemandMisses = r.DemandMisses
+		s.PollutionMisses = r.PollutionMisses
+	}
+
+	return s
+}
+
+// recordUse counts the first demand access to a prefetched line.
+func (p *StreamPrefetcher) recordUse(pid vm.PID, tag uint64) {
+	block := p.directory.findBlock(pid, tag)
+	if block != nil && unusedPrefetch(block) {
+		p.stats.Useful++
+	}
+}
+
+// train updates the stream that the access continues, or allocates a new
+// stream in place of the least recently used one. It returns the stream that
+// the access continues, if any.
+func (p *StreamPrefetcher) train(pid vm.PID, line uint64) *prefetchStream {
+	window := int64(p.config.Window)
+
+	for i := range p.streams {
+		s := &p.streams[i]
+		if s.pid != pid {
+			continue
+		}
+
+		delta := int64(line - s.lastLine)
+		if delta == 0 || delta > window || delta < -window {
+			continue
+		}
+
+		if delta == s.stride {
+			s.confirmed++
+		} else {
+			s.stride = delta
+			s.confirmed = 0
+			s.covered = 0
+		}
+
+		s.lastLine = line
+		s.lastAccessed = p.accesses
+
+		return s
+	}
+
+	p.allocate(prefetchStream{
+		pid:          pid,
+		lastLine:     line,
+		lastAccessed: p.accesses,
+	})
+
+	return nil
+}
+
+func (p *StreamPrefetcher) allocate(s prefetchStream) {
+	if len(p.streams) < p.config.NumStreams {
+		p.streams = append(p.streams, s)
+		return
+	}
+
+	lru := 0
+	for i := range p.streams {
+		if p.streams[i].lastAccessed < p.streams[lru].lastAccessed {
+			lru = i
+		}
+	}
+
+	p.streams[lru] = s
+}
+
+// setAllLive tells if every block of the set of the address is valid and
+// predicted live, or locked. It is false without a DeadBlockPredictor.
+func (p *StreamPrefetcher) setAllLive(addr uint64) bool {
+	d := p.directory
+	if _, ok := d.victimFinder.(DeadBlockPredictor); !ok {
+		return false
+	}
+
+	set, _ := d.getSet(addr)
+	if firstInvalidBlock(set) != nil {
+		return false
+	}
+
+	for _, r := range d.RankDeadBlocks(addr) {
+		if r.Dead {
+			return false
+		}
+	}
+
+	return true
+}
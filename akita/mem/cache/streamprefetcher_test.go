@@ -0,0 +1,79 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StreamPrefetcher", func() {
+	stream := func(p *StreamPrefetcher, first, n uint64) []uint64 {
+		var hints []uint64
+		for i := first; i < first+n; i++ {
+			hints = p.Access(1, i*64)
+		}
+
+		return hints
+	}
+
+	It("should prefetch ahead of a confirmed stream", func() {
+		d := NewDirectory(16, 4, 64, NewLRUVictimFinder())
+		p := NewStreamPrefetcher(d, StreamPrefetcherConfig{})
+
+		Expect(stream(p, 0, 3)).To(BeEmpty())
+		Expect(p.Access(1, 3*64)).To(Equal([]uint64{4 * 64, 5 * 64}))
+
+		block := d.FindVictimWithContext(4*64, &VictimContext{
+			Address:    4 * 64,
+			PID:        1,
+			IsPrefetch: true,
+		})
+		block.PID = 1
+		block.Tag = 4 * 64
+		block.IsValid = true
+		d.Visit(block)
+
+		Expect(p.Access(1, 4*64)).To(Equal([]uint64{6 * 64}))
+		Expect(p.Stats()).To(Equal(StreamPrefetchStats{
+			Issued: 3,
+			Useful: 1,
+		}))
+		Expect(p.Stats().Accuracy()).To(BeNumerically("~", 1.0/3))
+	})
+
+	It("should keep the streams of processes apart", func() {
+		d := NewDirectory(16, 4, 64, NewLRUVictimFinder())
+		p := NewStreamPrefetcher(d, StreamPrefetcherConfig{Degree: 1})
+
+		for i := uint64(0); i < 3; i++ {
+			Expect(p.Access(1, i*64)).To(BeEmpty())
+			Expect(p.Access(2, i*0x10000)).To(BeEmpty())
+		}
+
+		Expect(p.Access(1, 3*64)).To(Equal([]uint64{4 * 64}))
+		Expect(p.Access(2, 3*0x10000)).To(BeEmpty())
+	})
+
+	It("should not prefetch into sets of live blocks", func() {
+		vf := MakePerceptronBuilder().
+			WithTheta(16).
+			WithTrainingSampleRate(1).
+			Build()
+		vf.SetStrictMode(true)
+
+		d := NewDirectory(1, 2, 64, vf)
+		for i, b := range d.Sets[0].Blocks {
+			b.Tag = 0x10000 + uint64(i)*64
+			b.IsValid = true
+		}
+
+		p := NewStreamPrefetcher(d, StreamPrefetcherConfig{})
+		Expect(stream(p, 0, 4)).To(BeEmpty())
+		Expect(p.Stats().Throttled).To(Equal(uint64(1)))
+
+		for i := 0; i < 32; i++ {
+			vf.TrainOnEvictionWithContext(residentContext(d.Sets[0].Blocks[0]))
+		}
+
+		Expect(p.Access(1, 4*64)).To(HaveLen(2))
+	})
+})
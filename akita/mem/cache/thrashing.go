@@ -0,0 +1,187 @@
+package cache
+
+// ThrashingDetectorConfig configures a ThrashingDetector.
+type ThrashingDetectorConfig struct {
+	// Number of consecutive sets that share one detector. A value of 1 tracks
+	// every set individually.
+	SetsPerGroup int
+
+	// Number of accesses to a set group that form one interval.
+	Interval uint64
+
+	// A group is thrashing if the misses in an interval exceed MissFactor
+	// times the number of blocks in the group.
+	MissFactor float64
+
+	// Number of intervals that a group stays in fallback mode after
+	// thrashing is detected.
+	CooldownIntervals int
+
+	// In fallback mode, one out of every BIPEpsilon fills is inserted at the
+	// MRU position; the others are inserted at the LRU position.
+	BIPEpsilon uint64
+
+	// If set, ShouldBypass reports true for groups in fallback mode so that
+	// the controller can skip allocation altogether.
+	Bypass bool
+}
+
+// DefaultThrashingDetectorConfig returns a per-set detector configuration.
+func DefaultThrashingDetectorConfig() ThrashingDetectorConfig {
+	return ThrashingDetectorConfig{
+		SetsPerGroup:      1,
+		Interval:          64,
+		MissFactor:        2,
+		CooldownIntervals: 4,
+		BIPEpsilon:        32,
+	}
+}
+
+// A ThrashingEvent records a set group entering or leaving the fallback mode.
+type ThrashingEvent struct {
+	Group    int
+	Enter    bool
+	Misses   uint64
+	Accesses uint64
+}
+
+type thrashingGroup struct {
+	accesses  uint64
+	misses    uint64
+	cooldown  int
+	fillCount uint64
+}
+
+// A ThrashingDetector watches the miss rate of set groups and switches the
+// insertion of thrashing groups to bimodal insertion (BIP).
+type ThrashingDetector struct {
+	config  ThrashingDetectorConfig
+	numWays int
+	groups  []thrashingGroup
+	events  []ThrashingEvent
+
+	// OnEvent, if set, is called every time a group enters or leaves the
+	// fallback mode.
+	OnEvent func(ThrashingEvent)
+}
+
+// NewThrashingDetector creates a detector for a directory with the given
+// geometry.
+func NewThrashingDetector(
+	config ThrashingDetectorConfig,
+	numSets, numWays int,
+) *ThrashingDetector {
+	if config.SetsPerGroup <= 0 {
+		config.SetsPerGroup = 1
+	}
+
+	if config.BIPEpsilon == 0 {
+		config.BIPEpsilon = 32
+	}
+
+	numGroups := (numSets + config.SetsPerGroup - 1) / config.SetsPerGroup
+
+	return &ThrashingDetector{
+		config:  config,
+		numWays: numWays,
+		groups:  make([]thrashingGroup, numGroups),
+	}
+}
+
+func (t *ThrashingDetector) group(setID int) (*thrashingGroup, int) {
+	g := setID / t.config.SetsPerGroup
+	return &t.groups[g], g
+}
+
+// RecordHit records a hit in the set.
+func (t *ThrashingDetector) RecordHit(setID int) {
+	g, id := t.group(setID)
+	g.accesses++
+	t.endIntervalIfNeeded(g, id)
+}
+
+// RecordMiss records a miss in the set.
+func (t *ThrashingDetector) RecordMiss(setID int) {
+	g, id := t.group(setID)
+	g.accesses++
+	g.misses++
+	t.endIntervalIfNeeded(g, id)
+}
+
+func (t *ThrashingDetector) endIntervalIfNeeded(g *thrashingGroup, id int) {
+	if g.accesses < t.config.Interval {
+		return
+	}
+
+	capacity := float64(t.numWays * t.config.SetsPerGroup)
+	thrashing := float64(g.misses) > t.config.MissFactor*capacity
+
+	switch {
+	case thrashing:
+		if g.cooldown == 0 {
+			t.emit(ThrashingEvent{
+				Group: id, Enter: true,
+				Misses: g.misses, Accesses: g.accesses,
+			})
+		}
+
+		g.cooldown = t.config.CooldownIntervals
+	case g.cooldown > 0:
+		g.cooldown--
+		if g.cooldown == 0 {
+			t.emit(ThrashingEvent{
+				Group: id, Enter: false,
+				Misses: g.misses, Accesses: g.accesses,
+			})
+		}
+	}
+
+	g.accesses = 0
+	g.misses = 0
+}
+
+func (t *ThrashingDetector) emit(e ThrashingEvent) {
+	t.events = append(t.events, e)
+
+	if t.OnEvent != nil {
+		t.OnEvent(e)
+	}
+}
+
+// IsThrashing returns true if the set belongs to a group in fallback mode.
+func (t *ThrashingDetector) IsThrashing(setID int) bool {
+	g, _ := t.group(setID)
+	return g.cooldown > 0
+}
+
+// ShouldBypass returns true if the controller should not allocate a block for
+// a miss in the set.
+func (t *ThrashingDetector) ShouldBypass(setID int) bool {
+	return t.config.Bypass && t.IsThrashing(setID)
+}
+
+// InsertAtLRU decides the insertion position of a fill into the set. It
+// returns true if the new block should stay at the LRU position.
+func (t *ThrashingDetector) InsertAtLRU(setID int) bool {
+	g, _ := t.group(setID)
+	if g.cooldown == 0 {
+		return false
+	}
+
+	g.fillCount++
+
+	return g.fillCount%t.config.BIPEpsilon != 0
+}
+
+// Events returns the events recorded so far.
+func (t *ThrashingDetector) Events() []ThrashingEvent {
+	return t.events
+}
+
+// Reset ends the fallback mode of all the groups and clears the counters.
+// The recorded events are kept.
+func (t *ThrashingDetector) Reset() {
+	for i := range t.groups {
+		t.groups[i] = thrashingGroup{}
+	}
+}
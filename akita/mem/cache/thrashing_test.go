@@ -0,0 +1,76 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ThrashingDetector", func() {
+	var (
+		d  *DirectoryImpl
+		td *ThrashingDetector
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(4, 4, 64, NewLRUVictimFinder())
+		config := DefaultThrashingDetectorConfig()
+		config.Interval = 16
+		config.CooldownIntervals = 1
+		td = NewThrashingDetector(config, 4, 4)
+		d.SetThrashingDetector(td)
+	})
+
+	fill := func(addr uint64) *Block {
+		victim := d.FindVictim(addr)
+		victim.Tag = addr
+		victim.IsValid = true
+		d.Visit(victim)
+
+		return victim
+	}
+
+	It("should detect a cyclic working set larger than the set", func() {
+		var events []ThrashingEvent
+		td.OnEvent = func(e ThrashingEvent) { events = append(events, e) }
+
+		for i := 0; i < 16; i++ {
+			fill(uint64(i%6) * 4 * 64)
+		}
+
+		Expect(td.IsThrashing(0)).To(BeTrue())
+		Expect(td.IsThrashing(1)).To(BeFalse())
+		Expect(events).To(Equal([]ThrashingEvent{
+			{Group: 0, Enter: true, Misses: 16, Accesses: 16},
+		}))
+	})
+
+	It("should insert most fills at the LRU position when thrashing", func() {
+		for i := 0; i < 16; i++ {
+			fill(uint64(i%6) * 4 * 64)
+		}
+
+		victim := d.FindVictim(0x10000)
+		fill(0x10000)
+
+		Expect(d.FindVictim(0x20000)).To(BeIdenticalTo(victim))
+	})
+
+	It("should leave the fallback mode after a calm interval", func() {
+		for i := 0; i < 16; i++ {
+			fill(uint64(i%6) * 4 * 64)
+		}
+
+		block := d.Lookup(0, 0)
+		if block == nil {
+			block = fill(0)
+		}
+
+		for i := 0; i < 16; i++ {
+			d.Visit(block)
+		}
+
+		Expect(td.IsThrashing(0)).To(BeFalse())
+		Expect(td.Events()).To(HaveLen(2))
+		Expect(td.Events()[1].Enter).To(BeFalse())
+	})
+})
@@ -0,0 +1,192 @@
+package cache
+
+// The shape of a frequency sketch: every line is counted in one 4-bit
+// counter of each row.
+const (
+	sketchRows       = 4
+	sketchCounterMax = 15
+)
+
+// sketchSeeds separate the hash functions of the rows.
+var sketchSeeds = [sketchRows]uint64{
+	0x9e3779b97f4a7c15, 0xc2b2ae3d27d4eb4f, 0x165667b19e3779f9,
+	0xd6e8feb86659fd93,
+}
+
+// A FrequencySketch is a count-min sketch of the recent access frequencies
+// of lines, with 4-bit saturating counters. After every SampleSize
+// increments, all the counters are halved, so that the sketch forgets old
+// accesses.
+type FrequencySketch struct {
+	counters   [sketchRows][]uint8
+	mask       uint64
+	sampleSize int
+	increments int
+}
+
+// NewFrequencySketch creates a sketch with the given number of counters per
+// row, rounded up to a power of two, that ages after sampleSize increments.
+// It panics if either is not positive.
+func NewFrequencySketch(width, sampleSize int) *FrequencySketch {
+	if width <= 0 || sampleSize <= 0 {
+		panic("sketch width and sample size must be positive")
+	}
+
+	size := 1
+	for size < width {
+		size <<= 1
+	}
+
+	s := &FrequencySketch{mask: uint64(size - 1), sampleSize: sampleSize}
+	for i := range s.counters {
+		s.counters[i] = make([]uint8, size)
+	}
+
+	return s
+}
+
+// Increment counts an access to the line.
+func (s *FrequencySketch) Increment(line uint64) {
+	for i := range s.counters {
+		c := &s.counters[i][s.index(i, line)]
+		if *c < sketchCounterMax {
+			*c++
+		}
+	}
+
+	s.increments++
+	if s.increments >= s.sampleSize {
+		s.age()
+	}
+}
+
+// Estimate returns the estimated number of recent accesses to the line. The
+// estimate may be too high, but never too low, except after aging.
+func (s *FrequencySketch) Estimate(line uint64) int {
+	estimate := sketchCounterMax
+
+	for i := range s.counters {
+		if c := int(s.counters[i][s.index(i, line)]); c < estimate {
+			estimate = c
+		}
+	}
+
+	return estimate
+}
+
+func (s *FrequencySketch) index(row int, line uint64) uint64 {
+	return mixLineHash(line^sketchSeeds[row]) & s.mask
+}
+
+// age halves every counter.
+func (s *FrequencySketch) age() {
+	for i := range s.counters {
+		for j := range s.counters[i] {
+			s.counters[i][j] >>= 1
+		}
+	}
+
+	s.increments /= 2
+}
+
+// A TinyLFUConfig configures a TinyLFU admission filter.
+type TinyLFUConfig struct {
+	// The number of counters per row of the sketch. Defaults to 4096.
+	Counters int
+
+	// The number of accesses after which the sketch ages. Defaults to ten
+	// times the counters.
+	SampleSize int
+}
+
+// TinyLFUStats counts the admission decisions of a TinyLFU filter.
+type TinyLFUStats struct {
+	Admitted uint64
+	Rejected uint64
+}
+
+// TinyLFU is a frequency-based admission filter (Einziger et al., ACM TOS
+// 2017) in front of the directory. Every visit counts the line in a
+// FrequencySketch. When a miss would evict a valid line, the missing line is
+// admitted only if its estimated frequency, including the miss, is higher
+// than the frequency of the victim. Otherwise the fill is marked
+// InsertBypass: a controller that can bypass the cache finds it with
+// DirectoryImpl.PendingInsertion and does not allocate the line, and the
+// directory inserts it as the next victim, so that a scan only churns one
+// way. The recency window of W-TinyLFU is left to the victim finder, which
+// chooses the victim that the missing line competes with.
+type TinyLFU struct {
+	sketch *FrequencySketch
+	stats  TinyLFUStats
+}
+
+// NewTinyLFU creates a TinyLFU admission filter with the configuration.
+func NewTinyLFU(config TinyLFUConfig) *TinyLFU {
+	if config.Counters <= 0 {
+		config.Counters = 4096
+	}
+
+	if config.SampleSize <= 0 {
+		config.SampleSize = 10 * config.Counters
+	}
+
+	return &TinyLFU{
+		sketch: NewFrequencySketch(config.Counters, config.SampleSize),
+	}
+}
+
+// Sketch returns the frequency sketch of the filter.
+func (t *TinyLFU) Sketch() *FrequencySketch {
+	return t.sketch
+}
+
+// Stats returns the admission decisions of the filter.
+func (t *TinyLFU) Stats() TinyLFUStats {
+	return t.stats
+}
+
+// Admit tells if the missing line is worth evicting the victim, and counts
+// the decision. Fills of invalid blocks are always admitted.
+func (t *TinyLFU) Admit(line uint64, victim *Block) bool {
+	if victim == nil || !victim.IsValid {
+		return true
+	}
+
+	if t.sketch.Estimate(line)+1 > t.sketch.Estimate(victim.Tag) {
+		t.stats.Admitted++
+		return true
+	}
+
+	t.stats.Rejected++
+
+	return false
+}
+
+// SetTinyLFU puts the admission filter in front of the directory. Passing
+// nil removes it.
+func (d *DirectoryImpl) SetTinyLFU(t *TinyLFU) {
+	d.tinyLFU = t
+}
+
+// TinyLFU returns the admission filter of the directory, if any.
+func (d *DirectoryImpl) TinyLFU() *TinyLFU {
+	return d.tinyLFU
+}
+
+// applyAdmission marks the pending fill of the set as a bypass if the
+// admission filter rejects the line.
+func (d *DirectoryImpl) applyAdmission(addr uint64, setID int, victim *Block) {
+	if d.tinyLFU == nil || d.tinyLFU.Admit(addr, victim) {
+		return
+	}
+
+	d.pendingContext[setID].insertion = InsertBypass
+}
+
+func (t *TinyLFU) recordAccess(block *Block) {
+	if t == nil {
+		return
+	}
+
+	t.sketch.Increment(block.Tag)
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FrequencySketch", func() {
+	It("should never underestimate before aging", func() {
+		s := NewFrequencySketch(64, 1<<20)
+
+		for line := uint64(0); line < 256; line++ {
+			for i := uint64(0); i < line%8; i++ {
+				s.Increment(line * 64)
+			}
+		}
+
+		for line := uint64(0); line < 256; line++ {
+			Expect(s.Estimate(line * 64)).To(BeNumerically(">=", int(line%8)))
+		}
+	})
+
+	It("should saturate the counters", func() {
+		s := NewFrequencySketch(64, 1<<20)
+		for i := 0; i < 100; i++ {
+			s.Increment(0x40)
+		}
+
+		Expect(s.Estimate(0x40)).To(Equal(15))
+	})
+
+	It("should halve the counters after the sample size", func() {
+		s := NewFrequencySketch(1024, 10)
+		for i := 0; i < 9; i++ {
+			s.Increment(0x40)
+		}
+		Expect(s.Estimate(0x40)).To(Equal(9))
+
+		s.Increment(0x80)
+		Expect(s.Estimate(0x40)).To(Equal(4))
+	})
+})
+
+var _ = Describe("TinyLFU", func() {
+	var (
+		d      *DirectoryImpl
+		filter *TinyLFU
+	)
+
+	access := func(tag uint64) *Block {
+		if block := d.Lookup(0, tag); block != nil {
+			d.Visit(block)
+			return block
+		}
+
+		block := d.FindVictimWithContext(tag, &VictimContext{Address: tag})
+		block.Tag = tag
+		block.IsValid = true
+		d.Visit(block)
+
+		return block
+	}
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 2, 64, NewLRUVictimFinder())
+		filter = NewTinyLFU(TinyLFUConfig{})
+		d.SetTinyLFU(filter)
+	})
+
+	It("should confine a scan to the next victim", func() {
+		for i := 0; i < 3; i++ {
+			access(0x0)
+			access(0x40)
+		}
+
+		scanWay := d.FindVictim(0x80).WayID
+		for tag := uint64(0x80); tag < 0x800; tag += 0x40 {
+			Expect(access(tag).WayID).To(Equal(scanWay))
+		}
+
+		Expect(d.Lookup(0, 0x40)).NotTo(BeNil())
+		Expect(filter.Stats().Rejected).To(BeNumerically(">", 0))
+		Expect(filter.Stats().Admitted).To(BeZero())
+	})
+
+	It("should admit a line more frequent than the victim", func() {
+		access(0x0)
+		access(0x40)
+
+		filter.Sketch().Increment(0x80)
+		filter.Sketch().Increment(0x80)
+
+		access(0x80)
+		Expect(filter.Stats()).To(Equal(TinyLFUStats{Admitted: 1}))
+
+		victim := d.FindVictim(0xc0)
+		Expect(victim.Tag).NotTo(Equal(uint64(0x80)))
+	})
+
+	It("should mark the rejected fills as bypasses", func() {
+		access(0x0)
+		access(0x40)
+
+		victim := d.FindVictimWithContext(0x80, &VictimContext{Address: 0x80})
+		Expect(d.PendingInsertion(victim)).To(Equal(InsertBypass))
+
+		other := d.Sets[0].Blocks[1-victim.WayID]
+		Expect(d.PendingInsertion(other)).To(Equal(InsertMRU))
+	})
+
+	It("should always admit fills of invalid blocks", func() {
+		Expect(filter.Admit(0x0, &Block{})).To(BeTrue())
+		Expect(filter.Stats()).To(Equal(TinyLFUStats{}))
+	})
+
+	It("should be removable", func() {
+		d.SetTinyLFU(nil)
+		Expect(d.TinyLFU()).To(BeNil())
+
+		access(0x0)
+	})
+})
@@ -0,0 +1,110 @@
+package cache
+
+import "fmt"
+
+// TrainingBusStats counts the outcomes carried by a TrainingBus.
+type TrainingBusStats struct {
+	Published uint64 // Outcomes reported by the levels
+	Delivered uint64 // Outcomes passed to the connected predictors
+}
+
+// A TrainingBus shares the reuse outcomes of the cache levels of a hierarchy,
+// so that a predictor can learn the global reuse behavior of the lines rather
+// than only the behavior seen by its own level. Every predictor attached with
+// PerceptronVictimFinder.SetTrainingBus reports its hits and evictions as
+// outcomes of its level, and the bus trains the predictors connected to that
+// level with them.
+//
+// Forwarded outcomes are not forwarded again, so the routes may form cycles,
+// such as L1 to L2 and L2 to L1.
+type TrainingBus struct {
+	// routes[level] are the predictors that learn from the level.
+	routes     [][]ReuseTrainer
+	delivering bool
+	stats      TrainingBusStats
+}
+
+// NewTrainingBus creates a training bus without routes.
+func NewTrainingBus() *TrainingBus {
+	return &TrainingBus{}
+}
+
+// Connect makes the predictor learn from the outcomes reported by the level.
+// A predictor never learns twice from its own outcomes.
+func (b *TrainingBus) Connect(from int, to ReuseTrainer) {
+	if from < 0 {
+		panic(fmt.Sprintf("cache level %d is negative", from))
+	}
+
+	for len(b.routes) <= from {
+		b.routes = append(b.routes, nil)
+	}
+
+	b.routes[from] = append(b.routes[from], to)
+}
+
+// Stats returns the outcome counts.
+func (b *TrainingBus) Stats() TrainingBusStats {
+	return b.stats
+}
+
+// publish trains the predictors connected to the level with an outcome of the
+// source. It ignores the outcomes reported while it is delivering another.
+func (b *TrainingBus) publish(
+	source ReuseTrainer,
+	level int,
+	ctx *VictimContext,
+	hit bool,
+) {
+	if b == nil || b.delivering {
+		return
+	}
+
+	b.stats.Published++
+	if level >= len(b.routes) {
+		return
+	}
+
+	b.delivering = true
+	defer func() { b.delivering = false }()
+
+	for _, to := range b.routes[level] {
+		if to == source {
+			continue
+		}
+
+		b.stats.Delivered++
+		if hit {
+			to.TrainOnHitWithContext(ctx)
+		} else {
+			to.TrainOnEvictionWithContext(ctx)
+		}
+	}
+}
+
+// SetTrainingBus makes the perceptron report its hits and evictions to the
+// bus as outcomes of the cache level. Passing nil detaches the perceptron.
+// To also learn from other levels, the perceptron must be connected with
+// TrainingBus.Connect.
+func (p *PerceptronVictimFinder) SetTrainingBus(bus *TrainingBus, level int) {
+	p.trainingBus = bus
+	p.busLevel = level
+}
+
+// TrainingBus returns the training bus and the level of the perceptron.
+func (p *PerceptronVictimFinder) TrainingBus() (*TrainingBus, int) {
+	return p.trainingBus, p.busLevel
+}
+
+// busContext returns the context of the outcome being trained.
+func (p *PerceptronVictimFinder) busContext(addr, pc uint64) *VictimContext {
+	if p.trainingBus == nil {
+		return nil
+	}
+
+	if p.featureContext != nil {
+		return p.featureContext
+	}
+
+	return &VictimContext{Address: addr, PC: pc}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TrainingBus", func() {
+	var (
+		bus    *TrainingBus
+		l1, l2 *PerceptronVictimFinder
+	)
+
+	newPerceptron := func() *PerceptronVictimFinder {
+		p := MakePerceptronBuilder().
+			WithTheta(16).
+			WithTrainingSampleRate(1).
+			Build()
+		p.SetStrictMode(true)
+
+		return p
+	}
+
+	BeforeEach(func() {
+		bus = NewTrainingBus()
+		l1 = newPerceptron()
+		l2 = newPerceptron()
+		l1.SetTrainingBus(bus, 1)
+		l2.SetTrainingBus(bus, 2)
+	})
+
+	It("should train the L2 with the outcomes of the L1", func() {
+		bus.Connect(1, l2)
+
+		for i := 0; i < 10; i++ {
+			l1.TrainOnEvictionWithContext(&VictimContext{Address: 0x1000})
+		}
+
+		dead, _ := l2.PredictDead(0x1000, nil)
+		Expect(dead).To(BeTrue())
+		Expect(bus.Stats()).To(Equal(TrainingBusStats{
+			Published: 10,
+			Delivered: 10,
+		}))
+	})
+
+	It("should not forward outcomes back to their level", func() {
+		bus.Connect(1, l2)
+		bus.Connect(2, l1)
+		bus.Connect(2, l2)
+
+		l1.TrainOnHit(0x1000)
+		Expect(bus.Stats()).To(Equal(TrainingBusStats{
+			Published: 1,
+			Delivered: 1,
+		}))
+
+		l2.TrainOnHit(0x1000)
+		Expect(bus.Stats()).To(Equal(TrainingBusStats{
+			Published: 2,
+			Delivered: 2,
+		}))
+	})
+
+	It("should connect the levels of a hierarchy", func() {
+		c := HierarchyConfig{
+			BlockSize: 64,
+			NumL1s:    2,
+			L1: HierarchyLevelConfig{
+				NumSets: 1, NumWays: 2, Policy: "perceptron",
+			},
+			L2: HierarchyLevelConfig{
+				NumSets: 1, NumWays: 4, Policy: "perceptron",
+			},
+			SharedTraining: []TrainingRoute{{From: 1, To: 2}},
+		}
+
+		h, err := NewHierarchy(c)
+		Expect(err).NotTo(HaveOccurred())
+
+		for i := uint64(0); i < 8; i++ {
+			h.Access(0, 1, i*64)
+		}
+
+		Expect(h.TrainingBus().Stats().Delivered).To(BeNumerically(">", 0))
+
+		c.L1.Policy = "lru"
+		_, err = NewHierarchy(c)
+		Expect(err).To(MatchError(ContainSubstring("level 1")))
+	})
+})
@@ -0,0 +1,95 @@
+package cache
+
+import "sort"
+
+// TrueLRUVictimFinder evicts the exact least recently used block. The
+// directory stamps Block.LastAccess on every visit, so the policy costs a
+// counter per block instead of the PseudoLRU tree. It is meant as a research
+// baseline for PseudoLRU and the perceptron under the same directory.
+type TrueLRUVictimFinder struct {
+	now uint64
+}
+
+// NewTrueLRUVictimFinder returns a new exact LRU victim finder.
+func NewTrueLRUVictimFinder() *TrueLRUVictimFinder {
+	return &TrueLRUVictimFinder{}
+}
+
+// Touch marks the block as the most recently used one.
+func (t *TrueLRUVictimFinder) Touch(_ *Set, block *Block) {
+	t.now++
+	block.LastAccess = t.now
+}
+
+// FindVictim returns an invalid block if there is one, and the least recently
+// used unlocked block otherwise.
+func (t *TrueLRUVictimFinder) FindVictim(set *Set) *Block {
+	return trueLRUVictims.FindVictim(set)
+}
+
+// FindVictimWithContext returns the same victim as FindVictim.
+func (t *TrueLRUVictimFinder) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return t.FindVictim(set)
+}
+
+// FindVictims returns up to n candidates from the least to the most recently
+// used.
+func (t *TrueLRUVictimFinder) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	return rankCandidates(set, trueLRUOrder(set), n)
+}
+
+// trueLRUPolicy selects the block with the oldest access stamp, regardless of
+// its state.
+type trueLRUPolicy struct{}
+
+func (trueLRUPolicy) FindVictim(set *Set) *Block {
+	var victim *Block
+
+	for _, block := range set.Blocks {
+		if victim == nil || block.LastAccess < victim.LastAccess {
+			victim = block
+		}
+	}
+
+	return victim
+}
+
+func (p trueLRUPolicy) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return p.FindVictim(set)
+}
+
+func (trueLRUPolicy) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	return rankCandidates(set, trueLRUOrder(set), n)
+}
+
+// trueLRUOrder returns all the ways of the set from the least to the most
+// recently used.
+func trueLRUOrder(set *Set) []int {
+	ways := make([]int, len(set.Blocks))
+	for i := range ways {
+		ways[i] = i
+	}
+
+	sort.SliceStable(ways, func(i, j int) bool {
+		return set.Blocks[ways[i]].LastAccess < set.Blocks[ways[j]].LastAccess
+	})
+
+	return ways
+}
+
+var trueLRUVictims = ChainVictimFinder(trueLRUPolicy{},
+	PreferInvalidVictims, SkipLockedVictims)
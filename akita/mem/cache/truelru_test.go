@@ -0,0 +1,62 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TrueLRUVictimFinder", func() {
+	var (
+		vf *TrueLRUVictimFinder
+		d  *DirectoryImpl
+	)
+
+	BeforeEach(func() {
+		vf = NewTrueLRUVictimFinder()
+		d = NewDirectory(1, 4, 64, vf)
+		for _, b := range d.Sets[0].Blocks {
+			b.IsValid = true
+		}
+	})
+
+	It("should evict the least recently used block", func() {
+		blocks := d.Sets[0].Blocks
+		for _, way := range []int{2, 0, 3, 1, 0} {
+			d.Visit(blocks[way])
+		}
+
+		Expect(vf.FindVictim(&d.Sets[0])).To(BeIdenticalTo(blocks[2]))
+		Expect(vf.FindVictims(&d.Sets[0], nil, 4)).To(Equal([]*Block{
+			blocks[2], blocks[3], blocks[1], blocks[0],
+		}))
+	})
+
+	It("should skip locked blocks and fill invalid blocks first", func() {
+		blocks := d.Sets[0].Blocks
+		for _, way := range []int{2, 0, 3, 1} {
+			d.Visit(blocks[way])
+		}
+
+		blocks[2].IsLocked = true
+		Expect(vf.FindVictim(&d.Sets[0])).To(BeIdenticalTo(blocks[0]))
+
+		blocks[1].IsValid = false
+		Expect(vf.FindVictim(&d.Sets[0])).To(BeIdenticalTo(blocks[1]))
+	})
+
+	It("should match the exact LRU reference on every trace", func() {
+		for name, trace := range differentialTraces(16) {
+			result := replayLRUDifferential(NewTrueLRUVictimFinder(), 4, 4, trace)
+
+			Expect(result.fullSetMisses).To(BeNumerically(">", 0), name)
+			Expect(result.divergences).To(BeZero(), name)
+		}
+	})
+
+	It("should be selectable by name", func() {
+		vf, err := NewVictimFinderByName("true-lru", PolicyConfig{})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vf).To(BeAssignableToTypeOf(&TrueLRUVictimFinder{}))
+	})
+})
@@ -0,0 +1,218 @@
+package cache
+
+// A VictimFilter wraps a victim finder into another one that restricts or
+// overrides its choices. Filters are composed with ChainVictimFinder, so that
+// the candidate-filtering rules are written once instead of in every policy.
+type VictimFilter func(next VictimFinder) VictimFinder
+
+// ChainVictimFinder wraps the base policy with the filters. The first filter
+// is the outermost one, so it has the final say.
+func ChainVictimFinder(base VictimFinder, filters ...VictimFilter) VictimFinder {
+	vf := base
+	for i := len(filters) - 1; i >= 0; i-- {
+		vf = filters[i](vf)
+	}
+
+	return vf
+}
+
+// preferInvalid selects an invalid, unlocked block before asking the wrapped
+// finder.
+type preferInvalid struct {
+	next VictimFinder
+}
+
+// PreferInvalidVictims is a filter that fills an invalid block, if the set has
+// one, without consulting the wrapped policy.
+func PreferInvalidVictims(next VictimFinder) VictimFinder {
+	return &preferInvalid{next: next}
+}
+
+func (f *preferInvalid) FindVictim(set *Set) *Block {
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	return f.next.FindVictim(set)
+}
+
+func (f *preferInvalid) FindVictimWithContext(
+	set *Set,
+	context *VictimContext,
+) *Block {
+	if b := firstInvalidBlock(set); b != nil {
+		return b
+	}
+
+	return f.next.FindVictimWithContext(set, context)
+}
+
+func (f *preferInvalid) FindVictims(
+	set *Set,
+	context *VictimContext,
+	n int,
+) []*Block {
+	return FindVictims(f.next, set, context, n)
+}
+
+func firstInvalidBlock(set *Set) *Block {
+	for _, block := range set.Blocks {
+		if !block.IsValid && !block.IsLocked {
+			return block
+		}
+	}
+
+	return nil
+}
+
+// hasBlock tells if the set holds an unlocked block for which match returns
+// true. It lets the victim adjustments skip ranking when no block can replace
+// the victim.
+func hasBlock(set *Set, match func(*Block) bool) bool {
+	for _, b := range set.Blocks {
+		if !b.IsLocked && match(b) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// excludeVictims replaces an excluded victim with the best candidate of the
+// wrapped finder that is not excluded.
+type excludeVictims struct {
+	next     VictimFinder
+	excluded func(*Block) bool
+}
+
+// ExcludeVictims returns a filter that never selects the blocks for which
+// excluded returns true, e.g., pinned blocks or blocks of another partition.
+// If the wrapped finder selects an excluded block, the next candidate in its
+// ranking is used instead. If every candidate is excluded, nil is returned and
+// the caller has to retry later.
+func ExcludeVictims(excluded func(*Block) bool) VictimFilter {
+	return func(next VictimFinder) VictimFinder {
+		return &excludeVictims{next: next, excluded: excluded}
+	}
+}
+
+// SkipLockedVictims is a filter that never selects a locked block.
+func SkipLockedVictims(next VictimFinder) VictimFinder {
+	return ExcludeVictims(func(b *Block) bool { return b.IsLocked })(next)
+}
+
+func (f *excludeVictims) FindVictim(set *Set) *Block {
+	return f.filter(set, nil, f.next.FindVictim(set))
+}
+
+func (f *excludeVictims) FindVictimWithContext(
+	set *Set,
+	context *VictimContext,
+) *Block {
+	return f.filter(set, context, f.next.FindVictimWithContext(set, context))
+}
+
+func (f *excludeVictims) filter(
+	set *Set,
+	context *VictimContext,
+	victim *Block,
+) *Block {
+	if victim != nil && !f.excluded(victim) {
+		return victim
+	}
+
+	for _, b := range FindVictims(f.next, set, context, len(set.Blocks)) {
+		if !f.excluded(b) {
+			return b
+		}
+	}
+
+	return nil
+}
+
+func (f *excludeVictims) FindVictims(
+	set *Set,
+	context *VictimContext,
+	n int,
+) []*Block {
+	all := FindVictims(f.next, set, context, len(set.Blocks))
+
+	candidates := make([]*Block, 0, n)
+	for _, b := range all {
+		if len(candidates) == n {
+			break
+		}
+
+		if !f.excluded(b) {
+			candidates = append(candidates, b)
+		}
+	}
+
+	return candidates
+}
+
+// pseudoLRUPolicy selects the PseudoLRU victim, regardless of its state.
+type pseudoLRUPolicy struct{}
+
+func (pseudoLRUPolicy) FindVictim(set *Set) *Block {
+	numWays := len(set.Blocks)
+	if numWays == 0 {
+		return nil
+	}
+
+	return set.Blocks[getPseudoLRUVictim(set, numWays)]
+}
+
+func (p pseudoLRUPolicy) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return p.FindVictim(set)
+}
+
+func (pseudoLRUPolicy) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	return rankCandidates(set, pseudoLRUOrder(set), n)
+}
+
+// wayOrderPolicy selects the blocks in way order.
+type wayOrderPolicy struct{}
+
+func (wayOrderPolicy) FindVictim(set *Set) *Block {
+	if len(set.Blocks) == 0 {
+		return nil
+	}
+
+	return set.Blocks[0]
+}
+
+func (p wayOrderPolicy) FindVictimWithContext(
+	set *Set,
+	_ *VictimContext,
+) *Block {
+	return p.FindVictim(set)
+}
+
+func (wayOrderPolicy) FindVictims(
+	set *Set,
+	_ *VictimContext,
+	n int,
+) []*Block {
+	ways := make([]int, len(set.Blocks))
+	for i := range ways {
+		ways[i] = i
+	}
+
+	return rankCandidates(set, ways, n)
+}
+
+// The base policies with the standard invalid and locked handling.
+var (
+	pseudoLRUVictims = ChainVictimFinder(pseudoLRUPolicy{},
+		PreferInvalidVictims, SkipLockedVictims)
+	wayOrderVictims = ChainVictimFinder(wayOrderPolicy{},
+		PreferInvalidVictims, SkipLockedVictims)
+)
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Victim filters", func() {
+	var (
+		set *Set
+	)
+
+	BeforeEach(func() {
+		set = makeTestSet(4)
+		for _, b := range set.Blocks {
+			b.IsValid = true
+		}
+	})
+
+	It("should fill invalid blocks first", func() {
+		set.Blocks[3].IsValid = false
+
+		vf := ChainVictimFinder(wayOrderPolicy{}, PreferInvalidVictims)
+
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[3]))
+	})
+
+	It("should replace a locked victim with the next candidate", func() {
+		set.Blocks[0].IsLocked = true
+
+		vf := ChainVictimFinder(wayOrderPolicy{}, SkipLockedVictims)
+
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[1]))
+		Expect(vf.FindVictimWithContext(set, &VictimContext{})).
+			To(BeIdenticalTo(set.Blocks[1]))
+	})
+
+	It("should walk the PseudoLRU tree past a locked victim", func() {
+		set.PseudoLRUBits = 1
+		Expect(pseudoLRUPolicy{}.FindVictim(set)).To(BeIdenticalTo(set.Blocks[2]))
+
+		set.Blocks[2].IsLocked = true
+		Expect(pseudoLRUVictims.FindVictim(set)).To(BeIdenticalTo(set.Blocks[3]))
+
+		set.Blocks[3].IsLocked = true
+		Expect(pseudoLRUVictims.FindVictim(set)).To(BeIdenticalTo(set.Blocks[0]))
+
+		set.Blocks[0].IsLocked = true
+		set.Blocks[1].IsLocked = true
+		Expect(pseudoLRUVictims.FindVictim(set)).To(BeNil())
+	})
+
+	It("should return nil if every block is excluded", func() {
+		pinned := ExcludeVictims(func(b *Block) bool { return b.Tag == 0 })
+
+		vf := ChainVictimFinder(NewClockVictimFinder(), pinned)
+
+		Expect(vf.FindVictim(set)).To(BeNil())
+	})
+
+	It("should apply the outermost filter last", func() {
+		set.Blocks[2].IsValid = false
+		set.Blocks[2].Tag = 0x80
+		notTag := func(tag uint64) VictimFilter {
+			return ExcludeVictims(func(b *Block) bool { return b.Tag == tag })
+		}
+
+		vf := ChainVictimFinder(wayOrderPolicy{},
+			notTag(0x80), PreferInvalidVictims)
+
+		Expect(vf.FindVictim(set)).To(BeIdenticalTo(set.Blocks[0]))
+	})
+
+	It("should filter the ranking", func() {
+		set.Blocks[1].IsLocked = true
+
+		victims := FindVictims(pseudoLRUVictims, set, nil, 4)
+
+		Expect(victims).To(HaveLen(3))
+		Expect(victims).NotTo(ContainElement(set.Blocks[1]))
+	})
+})
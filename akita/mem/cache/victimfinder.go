@@ -1,6 +1,8 @@
 package cache
 
-// A VictimFinder decides with block should be evicted
+// A VictimFinder decides with block should be evicted. Locked blocks are
+// never selected; if every block of the set is locked, nil is returned and the
+// caller has to retry later.
 type VictimFinder interface {
 	FindVictim(set *Set) *Block
 	FindVictimWithContext(set *Set, context *VictimContext) *Block
@@ -18,27 +20,10 @@ func NewLRUVictimFinder() *LRUVictimFinder {
 
 // FindVictim returns the least recently used block in a set
 func (e *LRUVictimFinder) FindVictim(set *Set) *Block {
-	// First try evicting an empty block
-	for _, block := range set.Blocks {
-		if !block.IsValid && !block.IsLocked {
-			return block
-		}
-	}
-
-	// Use PseudoLRU: efficient bit-based LRU approximation
-	numWays := len(set.Blocks)
-	victimWay := getPseudoLRUVictim(set, numWays)
-
-	// Return the victim block if it's not locked
-	if victimWay < numWays && !set.Blocks[victimWay].IsLocked {
-		return set.Blocks[victimWay]
-	}
-
-	// Final fallback
-	if len(set.Blocks) > 0 {
-		return set.Blocks[0]
-	}
-	return nil
+	// Use PseudoLRU: efficient bit-based LRU approximation. Invalid blocks
+	// are filled first, and a locked victim is replaced by the next unlocked
+	// block in PseudoLRU order.
+	return pseudoLRUVictims.FindVictim(set)
 }
 
 // FindVictimWithContext implements the VictimFinder interface
@@ -48,59 +33,27 @@ func (e *LRUVictimFinder) FindVictimWithContext(set *Set, context *VictimContext
 }
 
 // getPseudoLRUVictim returns the way ID of the PseudoLRU victim (shared implementation)
+// The tree is stored in heap layout: node i has children 2i+1 and 2i+2, and
+// splits its ways in half, with the extra way of an odd split on the right. A
+// zero bit points to the left subtree as the next victim. Any associativity
+// up to 64 ways fits in the 63 bits of the tree; a larger set returns its
+// least recently used way.
 func getPseudoLRUVictim(set *Set, numWays int) int {
-	switch numWays {
-	case 2:
-		if (set.PseudoLRUBits & 1) == 0 {
-			return 0
-		}
-		return 1
-	case 4:
-		if (set.PseudoLRUBits & 1) == 0 {
-			if (set.PseudoLRUBits & (1 << 1)) == 0 {
-				return 0
-			}
-			return 1
-		} else {
-			if (set.PseudoLRUBits & (1 << 2)) == 0 {
-				return 2
-			}
-			return 3
-		}
-	case 8:
-		return getPseudoLRUVictim8Way(set)
-	default:
-		return int(set.PseudoLRUBits % uint64(numWays))
+	if numWays > maxPseudoLRUWays {
+		return set.lruWays()[0]
 	}
-}
 
-// getPseudoLRUVictim8Way returns victim way for 8-way associative cache (shared implementation)
-func getPseudoLRUVictim8Way(set *Set) int {
-	bits := set.PseudoLRUBits
+	node, lo, hi := 0, 0, numWays
 
-	if (bits & 1) == 0 {
-		if (bits & (1 << 1)) == 0 {
-			if (bits & (1 << 3)) == 0 {
-				return 0
-			}
-			return 1
-		} else {
-			if (bits & (1 << 4)) == 0 {
-				return 2
-			}
-			return 3
-		}
-	} else {
-		if (bits & (1 << 2)) == 0 {
-			if (bits & (1 << 5)) == 0 {
-				return 4
-			}
-			return 5
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+
+		if set.PseudoLRUBits&(1<<uint(node)) == 0 {
+			node, hi = 2*node+1, mid
 		} else {
-			if (bits & (1 << 6)) == 0 {
-				return 6
-			}
-			return 7
+			node, lo = 2*node+2, mid
 		}
 	}
+
+	return lo
 }
@@ -0,0 +1,167 @@
+package cache
+
+// A MultiVictimFinder can rank several eviction candidates at once.
+type MultiVictimFinder interface {
+	VictimFinder
+
+	// FindVictims returns up to n blocks of the set in eviction-preference
+	// order. Locked blocks are never returned. Ranking must leave the state
+	// of the policy unchanged.
+	FindVictims(set *Set, context *VictimContext, n int) []*Block
+}
+
+// FindVictims returns up to n eviction candidates of the set in preference
+// order. If the victim finder cannot rank candidates itself, the unlocked
+// blocks are returned in PseudoLRU order with invalid blocks first.
+//
+// Ranking must not change the state of the policy. The fallback therefore
+// never asks the victim finder for a victim, since selecting one trains
+// predictors, moves dueling counters and advances random sources.
+func FindVictims(
+	vf VictimFinder,
+	set *Set,
+	context *VictimContext,
+	n int,
+) []*Block {
+	if mvf, ok := vf.(MultiVictimFinder); ok {
+		return mvf.FindVictims(set, context, n)
+	}
+
+	return rankCandidates(set, pseudoLRUOrder(set), n)
+}
+
+// rankCandidates returns up to n unlocked and unpinned blocks of the set.
+// Invalid blocks come first; valid blocks follow in the order given by ways.
+// Duplicated ways are ignored.
+func rankCandidates(set *Set, ways []int, n int) []*Block {
+	if n <= 0 {
+		return nil
+	}
+
+	candidates := make([]*Block, 0, n)
+	picked := make([]bool, len(set.Blocks))
+
+	for _, block := range set.Blocks {
+		if len(candidates) == n {
+			return candidates
+		}
+
+		if !block.IsValid && !block.IsLocked {
+			candidates = append(candidates, block)
+			picked[block.WayID] = true
+		}
+	}
+
+	for _, way := range ways {
+		if len(candidates) == n {
+			break
+		}
+
+		block := set.Blocks[way]
+		if picked[way] || block.IsLocked || isPinned(block) {
+			continue
+		}
+
+		candidates = append(candidates, block)
+		picked[way] = true
+	}
+
+	return candidates
+}
+
+// pseudoLRUOrder returns all the ways of the set ordered from the PseudoLRU
+// victim to the most recently used way.
+func pseudoLRUOrder(set *Set) []int {
+	numWays := len(set.Blocks)
+	order := make([]int, 0, numWays)
+
+	if numWays == 0 {
+		return order
+	}
+
+	if numWays > maxPseudoLRUWays {
+		return append(order, set.lruWays()...)
+	}
+
+	return appendPseudoLRUTreeOrder(order, set.PseudoLRUBits, 0, 0, numWays)
+}
+
+// appendPseudoLRUTreeOrder walks the PseudoLRU tree stored in heap layout.
+// A zero bit points to the left subtree as the next victim.
+func appendPseudoLRUTreeOrder(
+	order []int,
+	bits uint64,
+	node, lo, hi int,
+) []int {
+	if hi-lo == 1 {
+		return append(order, lo)
+	}
+
+	mid := (lo + hi) / 2
+	left, right := 2*node+1, 2*node+2
+
+	if bits&(1<<uint(node)) == 0 {
+		order = appendPseudoLRUTreeOrder(order, bits, left, lo, mid)
+		return appendPseudoLRUTreeOrder(order, bits, right, mid, hi)
+	}
+
+	order = appendPseudoLRUTreeOrder(order, bits, right, mid, hi)
+
+	return appendPseudoLRUTreeOrder(order, bits, left, lo, mid)
+}
+
+// FindVictims returns up to n candidates in PseudoLRU order.
+func (e *LRUVictimFinder) FindVictims(
+	set *Set,
+	context *VictimContext,
+	n int,
+) []*Block {
+	return rankCandidates(set, pseudoLRUOrder(set), n)
+}
+
+// FindVictims returns up to n candidates ranked by the perceptron. When the
+// perceptron confidently predicts no reuse, the valid blocks are ranked from
+// the least to the most likely to be reused; otherwise, they are ranked in
+// PseudoLRU order. With reuse buckets, they are ranked from the highest to
+// the lowest RRPV. Unused prefetches come first if they are preferred.
+// Ranking does not update the prediction statistics or charge the weight
+// reads.
+func (p *PerceptronVictimFinder) FindVictims(
+	set *Set,
+	context *VictimContext,
+	n int,
+) []*Block {
+	if p.buckets != nil {
+		return rankCandidates(set,
+			p.withUnusedPrefetches(set, p.bucketOrder(set)), n)
+	}
+
+	if context == nil {
+		ways := make([]int, len(set.Blocks))
+		for i := range ways {
+			ways[i] = i
+		}
+
+		return rankCandidates(set, p.withUnusedPrefetches(set, ways), n)
+	}
+
+	ways := pseudoLRUOrder(set)
+
+	sum := p.predictionSum(context.Address, context.PC)
+	if abs(sum) >= p.theta && sum >= p.threshold {
+		ways = p.deadBlockOrder(set)
+	}
+
+	return rankCandidates(set, p.withUnusedPrefetches(set, ways), n)
+}
+
+// FindVictims returns up to n eviction candidates for the address in
+// preference order.
+func (d *DirectoryImpl) FindVictims(
+	addr uint64,
+	context *VictimContext,
+	n int,
+) []*Block {
+	set, _ := d.getSet(addr)
+	return FindVictims(d.victimFinder, set, context, n)
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	gomock "go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Victim ranking", func() {
+	It("should start the PseudoLRU order at the PseudoLRU victim", func() {
+		for _, numWays := range []int{2, 4, 8, 6, 16, 48, 64} {
+			set := makeTestSet(numWays)
+			for bits := uint64(0); bits < 128; bits++ {
+				set.PseudoLRUBits = bits * 0x9e3779b97f4a7c15
+
+				order := pseudoLRUOrder(set)
+
+				Expect(order).To(HaveLen(numWays))
+				Expect(order[0]).To(Equal(getPseudoLRUVictim(set, numWays)))
+			}
+		}
+	})
+
+	It("should evict the first way after a sequential round", func() {
+		for _, numWays := range []int{4, 6, 16, 48, 64, 128} {
+			d := NewDirectory(1, numWays, 64, NewLRUVictimFinder())
+			set := &d.Sets[0]
+
+			for way := 0; way < numWays; way++ {
+				d.Visit(set.Blocks[way])
+			}
+
+			Expect(getPseudoLRUVictim(set, numWays)).To(BeZero())
+		}
+	})
+
+	It("should keep the LRU order of the sets too large for the tree", func() {
+		d := NewDirectory(1, 2*maxPseudoLRUWays, 64, NewLRUVictimFinder())
+		set := &d.Sets[0]
+		for _, block := range set.Blocks {
+			block.IsValid = true
+			d.Visit(block)
+		}
+
+		d.Visit(set.Blocks[0])
+
+		Expect(d.FindVictim(0)).To(BeIdenticalTo(set.Blocks[1]))
+		Expect(pseudoLRUOrder(set)[len(set.Blocks)-1]).To(Equal(0))
+
+		victim := d.FindVictim(0)
+		d.Visit(victim)
+
+		Expect(d.FindVictim(0)).To(BeIdenticalTo(set.Blocks[2]))
+	})
+
+	It("should never rank the most recently used way first", func() {
+		d := NewDirectory(1, 8, 64, NewLRUVictimFinder())
+		set := &d.Sets[0]
+
+		for _, way := range []int{3, 0, 7, 5, 1} {
+			d.Visit(set.Blocks[way])
+
+			order := pseudoLRUOrder(set)
+
+			Expect(order[0]).NotTo(Equal(way))
+		}
+	})
+
+	It("should rank invalid blocks first and skip locked blocks", func() {
+		set := makeTestSet(4)
+		for _, b := range set.Blocks {
+			b.IsValid = true
+		}
+		set.Blocks[2].IsValid = false
+		set.Blocks[0].IsLocked = true
+
+		victims := NewLRUVictimFinder().FindVictims(set, nil, 4)
+
+		Expect(victims).To(HaveLen(3))
+		Expect(victims[0]).To(BeIdenticalTo(set.Blocks[2]))
+		Expect(victims).NotTo(ContainElement(set.Blocks[0]))
+	})
+
+	It("should agree with the perceptron victim", func() {
+		p := NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		set := makeTestSet(8)
+		for _, b := range set.Blocks {
+			b.IsValid = true
+		}
+
+		for _, e := range perceptronTestStream(200) {
+			ctx := &VictimContext{Address: e.addr}
+			set.PseudoLRUBits = e.addr >> 7
+
+			victims := p.FindVictims(set, ctx, 3)
+
+			Expect(victims).To(HaveLen(3))
+			Expect(victims[0]).
+				To(BeIdenticalTo(p.FindVictimWithContext(set, ctx)))
+			p.TrainOnEviction(e.addr)
+		}
+	})
+
+	It("should fall back to PseudoLRU order without selecting a victim", func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		defer mockCtrl.Finish()
+
+		set := makeTestSet(4)
+		for _, b := range set.Blocks {
+			b.IsValid = true
+		}
+		set.PseudoLRUBits = 0x5
+
+		victims := FindVictims(NewMockVictimFinder(mockCtrl), set, nil, 4)
+
+		Expect(victims).To(Equal(
+			rankCandidates(set, pseudoLRUOrder(set), 4)))
+		Expect(victims[0].WayID).To(Equal(getPseudoLRUVictim(set, 4)))
+	})
+})
+
+var _ = Describe("Way scores", func() {
+	var (
+		set *Set
+	)
+
+	BeforeEach(func() {
+		set = makeTestSet(4)
+		for i, b := range set.Blocks {
+			b.IsValid = true
+			b.Tag = uint64(i+1) << 6
+		}
+	})
+
+	It("should score the PseudoLRU victim highest", func() {
+		set.PseudoLRUBits = 0x5
+
+		scores := NewLRUVictimFinder().ScoreWays(set, nil)
+
+		Expect(scores[getPseudoLRUVictim(set, 4)]).To(Equal(3.0))
+	})
+
+	It("should mark invalid and locked blocks", func() {
+		set.Blocks[1].IsValid = false
+		set.Blocks[2].IsLocked = true
+
+		scores := NewPerceptronVictimFinder().ScoreWays(set, nil)
+
+		Expect(math.IsInf(scores[1], 1)).To(BeTrue())
+		Expect(math.IsInf(scores[2], -1)).To(BeTrue())
+	})
+
+	It("should score with the perceptron sum of the resident tag", func() {
+		p := NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		p.TrainOnEviction(set.Blocks[3].Tag)
+
+		scores := p.ScoreWays(set, nil)
+
+		Expect(scores[3]).To(BeNumerically(">", scores[0]))
+	})
+
+	It("should derive scores from the ranking for other finders", func() {
+		scores := ScoreWays(
+			struct{ VictimFinder }{NewLRUVictimFinder()}, set, nil)
+
+		Expect(scores[getPseudoLRUVictim(set, 4)]).To(Equal(3.0))
+	})
+})
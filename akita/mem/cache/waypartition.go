@@ -0,0 +1,155 @@
+package cache
+
+import "github.com/sarchlab/akita/v4/mem/vm"
+
+// PartitionKey selects what a WayPartition partitions the ways by.
+type PartitionKey int
+
+// Partition keys.
+const (
+	// PartitionByPID gives every process its own ways.
+	PartitionByPID PartitionKey = iota
+
+	// PartitionByCU gives every compute unit its own ways; see
+	// VictimContext.AccessOrigin.
+	PartitionByCU
+)
+
+// WayPartitionStats counts the fills restricted by a WayPartition.
+type WayPartitionStats struct {
+	// Fills whose victim was replaced because its way was not allowed.
+	Redirected uint64
+
+	// Fills that found no victim in the allowed ways.
+	Blocked uint64
+}
+
+// A WayPartition restricts the ways that every process or requester may fill.
+// The ways are given as a bit mask, bit i standing for way i, so only the first
+// 64 ways can be partitioned. Requesters without a mask, and accesses without
+// a victim context, may fill every way.
+// Lookups are not restricted: a requester hits on a line in any way.
+//
+// The partition can be changed at any time. Lines already in ways that are no
+// longer allowed stay until they are evicted.
+type WayPartition struct {
+	Key PartitionKey
+
+	masks map[uint64]uint64
+	stats WayPartitionStats
+}
+
+// NewWayPartition creates a partition by the key, in which every requester
+// may fill every way.
+func NewWayPartition(key PartitionKey) *WayPartition {
+	return &WayPartition{
+		Key:   key,
+		masks: make(map[uint64]uint64),
+	}
+}
+
+// SetPIDWays sets the ways that the process may fill. A zero mask allows all
+// the ways.
+func (p *WayPartition) SetPIDWays(pid vm.PID, mask uint64) {
+	p.setWays(uint64(pid), mask)
+}
+
+// SetCUWays sets the ways that the compute unit may fill. A zero mask allows
+// all the ways.
+func (p *WayPartition) SetCUWays(cu int, mask uint64) {
+	p.setWays(uint64(cu), mask)
+}
+
+func (p *WayPartition) setWays(id, mask uint64) {
+	if mask == 0 {
+		delete(p.masks, id)
+		return
+	}
+
+	p.masks[id] = mask
+}
+
+// Stats returns the fill statistics.
+func (p *WayPartition) Stats() WayPartitionStats {
+	return p.stats
+}
+
+// allowedWays returns the mask of the ways that the access may fill, or 0 if
+// it may fill every way. An access without a context has no requester, so it
+// is not restricted.
+func (p *WayPartition) allowedWays(context *VictimContext) uint64 {
+	if context == nil {
+		return 0
+	}
+
+	var id uint64
+
+	switch p.Key {
+	case PartitionByPID:
+		id = uint64(context.PID)
+	case PartitionByCU:
+		id = uint64(context.CUID)
+	}
+
+	return p.masks[id]
+}
+
+// SetWayPartition restricts the ways that fills may use. Passing nil removes
+// the restriction.
+func (d *DirectoryImpl) SetWayPartition(p *WayPartition) {
+	d.wayPartition = p
+}
+
+// WayPartition returns the way partition, if any.
+func (d *DirectoryImpl) WayPartition() *WayPartition {
+	return d.wayPartition
+}
+
+// applyWayPartition replaces the victim with the highest ranked candidate in
+// a way that the access may fill, and returns the victim finder restricted to
+// those ways. The other victim adjustments rank their candidates with the
+// restricted finder, so that they stay in the partition.
+func (d *DirectoryImpl) applyWayPartition(
+	set *Set,
+	context *VictimContext,
+	victim *Block,
+) (VictimFinder, *Block) {
+	p := d.wayPartition
+	if p == nil {
+		return d.victimFinder, victim
+	}
+
+	mask := p.allowedWays(context)
+	if mask == 0 {
+		return d.victimFinder, victim
+	}
+
+	allowed := func(b *Block) bool {
+		return b.WayID < 64 && mask&(1<<uint(b.WayID)) != 0
+	}
+	vf := ChainVictimFinder(d.victimFinder,
+		ExcludeVictims(func(b *Block) bool { return !allowed(b) }))
+
+	if victim == nil || allowed(victim) {
+		return vf, victim
+	}
+
+	candidates := FindVictims(vf, set, context, 1)
+	if len(candidates) == 0 {
+		return vf, nil
+	}
+
+	p.stats.Redirected++
+
+	return vf, candidates[0]
+}
+
+// recordVictim counts the fills of a restricted access that found no victim
+// in the allowed ways.
+func (p *WayPartition) recordVictim(context *VictimContext, victim *Block) {
+	if p == nil || victim != nil || p.allowedWays(context) == 0 {
+		return
+	}
+
+	p.stats.Blocked++
+}
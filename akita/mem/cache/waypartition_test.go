@@ -0,0 +1,80 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/mem"
+)
+
+var _ = Describe("WayPartition", func() {
+	var (
+		d *DirectoryImpl
+		p *WayPartition
+	)
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 4, 64, NewLRUVictimFinder())
+		p = NewWayPartition(PartitionByPID)
+		d.SetWayPartition(p)
+	})
+
+	It("should only fill the ways of the process", func() {
+		p.SetPIDWays(1, 0b1100)
+
+		Expect(d.FindVictimWithContext(0, &VictimContext{PID: 1}).WayID).
+			To(Equal(2))
+		Expect(d.FindVictimWithContext(0, &VictimContext{PID: 2}).WayID).
+			To(Equal(0))
+		Expect(p.Stats().Redirected).To(Equal(uint64(1)))
+	})
+
+	It("should not restrict the fills without a context", func() {
+		p.SetPIDWays(0, 0b1000)
+
+		Expect(d.FindVictim(0).WayID).To(Equal(0))
+		Expect(d.FindVictimWithContext(0, &VictimContext{}).WayID).
+			To(Equal(3))
+		Expect(p.Stats().Redirected).To(Equal(uint64(1)))
+	})
+
+	It("should apply partition changes at run time", func() {
+		p.SetPIDWays(1, 0b1000)
+		Expect(d.FindVictimWithContext(0, &VictimContext{PID: 1}).WayID).
+			To(Equal(3))
+
+		p.SetPIDWays(1, 0)
+		Expect(d.FindVictimWithContext(0, &VictimContext{PID: 1}).WayID).
+			To(Equal(0))
+	})
+
+	It("should return no victim if the allowed ways are locked", func() {
+		p.SetPIDWays(1, 0b0010)
+		d.Sets[0].Blocks[1].IsLocked = true
+
+		Expect(d.FindVictimWithContext(0, &VictimContext{PID: 1})).To(BeNil())
+		Expect(p.Stats().Blocked).To(Equal(uint64(1)))
+	})
+
+	It("should keep the choices of the other victim adjustments", func() {
+		for _, block := range d.Sets[0].Blocks {
+			block.IsValid = true
+			block.PID = 1
+			block.Tag = uint64(block.WayID) * 64
+		}
+		hints := NewEvictionHints()
+		hints.Add(EvictionHint{PID: 1, Start: 0x80, Size: 0x40, Kind: HintKeep})
+		d.SetEvictionHints(hints)
+		p.SetPIDWays(1, 0b1100)
+
+		Expect(d.FindVictimWithContext(0, &VictimContext{PID: 1}).WayID).
+			To(Equal(3))
+	})
+
+	It("should partition by compute unit", func() {
+		p.Key = PartitionByCU
+		p.SetCUWays(3, 0b0100)
+
+		ctx := &VictimContext{AccessOrigin: mem.AccessOrigin{CUID: 3}}
+		Expect(d.FindVictimWithContext(0, ctx).WayID).To(Equal(2))
+	})
+})
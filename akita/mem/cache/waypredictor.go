@@ -0,0 +1,235 @@
+package cache
+
+import "fmt"
+
+// A WayPredictor guesses the way of a set that holds a line before the tags
+// are compared. A set-associative lookup that probes the predicted way first
+// only needs to compare the other tags when the prediction is wrong.
+type WayPredictor interface {
+	// PredictWay returns the way of the set expected to hold the line. The
+	// line is the address divided by the block size.
+	PredictWay(setID int, line uint64) int
+
+	// Train records the way that holds the line after a hit or a fill.
+	Train(setID int, line uint64, way int)
+}
+
+// WayPredictionStats counts the outcomes of the way predictions.
+type WayPredictionStats struct {
+	Correct   uint64 // Hits in the predicted way
+	Incorrect uint64 // Hits in another way
+	Misses    uint64 // Lookups of lines that are not resident
+
+	// Tags compared by the lookups. A correct prediction compares one tag;
+	// otherwise, every tag of the set is compared. A lookup without way
+	// prediction compares every tag of the set.
+	TagCompares uint64
+}
+
+// Accuracy returns the fraction of the hits found in the predicted way.
+func (s WayPredictionStats) Accuracy() float64 {
+	return ratio(s.Correct, s.Correct+s.Incorrect)
+}
+
+// An MRUWayPredictor predicts the most recently used way of every set.
+type MRUWayPredictor struct {
+	ways []int
+}
+
+// NewMRUWayPredictor creates an MRU way predictor for numSets sets.
+func NewMRUWayPredictor(numSets int) *MRUWayPredictor {
+	if numSets <= 0 {
+		panic(fmt.Sprintf("number of sets %d must be positive", numSets))
+	}
+
+	return &MRUWayPredictor{ways: make([]int, numSets)}
+}
+
+// PredictWay returns the most recently used way of the set.
+func (p *MRUWayPredictor) PredictWay(setID int, _ uint64) int {
+	return p.ways[setID]
+}
+
+// Train makes the way the most recently used way of the set.
+func (p *MRUWayPredictor) Train(setID int, _ uint64, way int) {
+	p.ways[setID] = way
+}
+
+const (
+	wayPredictorTableSize = 256
+	wayPredictorWeightMax = 31
+	wayPredictorWeightMin = -32
+	wayPredictorTheta     = 8
+)
+
+// wayPredictorShifts select the address bits of the features: the line, and
+// the regions of 64 and 4096 lines around it.
+var wayPredictorShifts = [...]uint{0, 6, 12}
+
+// A PerceptronWayPredictor keeps one perceptron per way. The weights of every
+// perceptron are read from hashed tables indexed by features of the line
+// address, and the way whose perceptron has the largest sum is predicted.
+type PerceptronWayPredictor struct {
+	numWays int
+
+	// weights[feature][entry][way]
+	weights [len(wayPredictorShifts)][wayPredictorTableSize][]int32
+}
+
+// NewPerceptronWayPredictor creates a perceptron way predictor for sets of
+// numWays ways.
+func NewPerceptronWayPredictor(numWays int) *PerceptronWayPredictor {
+	if numWays <= 0 {
+		panic(fmt.Sprintf("number of ways %d must be positive", numWays))
+	}
+
+	p := &PerceptronWayPredictor{numWays: numWays}
+	for f := range p.weights {
+		for e := range p.weights[f] {
+			p.weights[f][e] = make([]int32, numWays)
+		}
+	}
+
+	return p
+}
+
+// PredictWay returns the way whose perceptron has the largest sum. Ties go
+// to the lower way.
+func (p *PerceptronWayPredictor) PredictWay(_ int, line uint64) int {
+	way, _ := p.predict(line)
+	return way
+}
+
+// Train strengthens the perceptron of the way. If another way was predicted,
+// or was close to it, its perceptron is weakened.
+func (p *PerceptronWayPredictor) Train(_ int, line uint64, way int) {
+	if way < 0 || way >= p.numWays {
+		return
+	}
+
+	sums := p.sums(line)
+
+	rival, rivalSum := -1, int32(0)
+	for w, sum := range sums {
+		if w != way && (rival < 0 || sum > rivalSum) {
+			rival, rivalSum = w, sum
+		}
+	}
+
+	if rival >= 0 && sums[way]-rivalSum >= wayPredictorTheta {
+		return
+	}
+
+	for f, shift := range wayPredictorShifts {
+		row := p.weights[f][wayPredictorEntry(line, shift)]
+		row[way] = min(row[way]+1, wayPredictorWeightMax)
+
+		if rival >= 0 {
+			row[rival] = max(row[rival]-1, wayPredictorWeightMin)
+		}
+	}
+}
+
+func (p *PerceptronWayPredictor) predict(line uint64) (way int, sum int32) {
+	for w, s := range p.sums(line) {
+		if w == 0 || s > sum {
+			way, sum = w, s
+		}
+	}
+
+	return way, sum
+}
+
+func (p *PerceptronWayPredictor) sums(line uint64) []int32 {
+	sums := make([]int32, p.numWays)
+
+	for f, shift := range wayPredictorShifts {
+		row := p.weights[f][wayPredictorEntry(line, shift)]
+		for w, weight := range row {
+			sums[w] += weight
+		}
+	}
+
+	return sums
+}
+
+func wayPredictorEntry(line uint64, shift uint) int {
+	return int(mixLineHash(line>>shift^uint64(shift)) % wayPredictorTableSize)
+}
+
+// wayPrediction runs the way predictor of a directory. A nil wayPrediction
+// ignores all the accesses.
+type wayPrediction struct {
+	predictor WayPredictor
+	stats     WayPredictionStats
+}
+
+// SetWayPredictor attaches a way predictor to the directory. Every lookup is
+// checked against the prediction; see WayPredictionStats. Passing nil
+// detaches the predictor.
+func (d *DirectoryImpl) SetWayPredictor(p WayPredictor) {
+	if p == nil {
+		d.wayPrediction = nil
+		return
+	}
+
+	d.wayPrediction = &wayPrediction{predictor: p}
+}
+
+// WayPredictor returns the way predictor, if any.
+func (d *DirectoryImpl) WayPredictor() WayPredictor {
+	if d.wayPrediction == nil {
+		return nil
+	}
+
+	return d.wayPrediction.predictor
+}
+
+// WayPredictionStats returns the outcomes of the way predictions.
+func (d *DirectoryImpl) WayPredictionStats() WayPredictionStats {
+	if d.wayPrediction == nil {
+		return WayPredictionStats{}
+	}
+
+	return d.wayPrediction.stats
+}
+
+func (w *wayPrediction) recordLookup(
+	d *DirectoryImpl,
+	addr uint64,
+	block *Block,
+) {
+	if w == nil {
+		return
+	}
+
+	set, setID := d.getSet(addr)
+	line := addr / uint64(d.BlockSize)
+	predicted := w.predictor.PredictWay(setID, line)
+
+	ways := uint64(len(set.Blocks))
+
+	switch {
+	case block == nil:
+		w.stats.Misses++
+		w.stats.TagCompares += ways
+
+		return
+	case block.WayID == predicted:
+		w.stats.Correct++
+		w.stats.TagCompares++
+	default:
+		w.stats.Incorrect++
+		w.stats.TagCompares += ways
+	}
+
+	w.predictor.Train(setID, line, block.WayID)
+}
+
+func (w *wayPrediction) recordFill(d *DirectoryImpl, block *Block) {
+	if w == nil {
+		return
+	}
+
+	w.predictor.Train(block.SetID, block.Tag/uint64(d.BlockSize), block.WayID)
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Way prediction", func() {
+	var d *DirectoryImpl
+
+	fill := func(addr uint64) {
+		block := d.FindVictim(addr)
+		block.Tag = addr
+		block.IsValid = true
+		d.Visit(block)
+	}
+
+	BeforeEach(func() {
+		d = NewDirectory(1, 4, 64, NewLRUVictimFinder())
+	})
+
+	It("should predict the most recently used way", func() {
+		d.SetWayPredictor(NewMRUWayPredictor(1))
+		for i := uint64(0); i < 4; i++ {
+			fill(i * 64)
+		}
+
+		Expect(d.Lookup(0, 192)).NotTo(BeNil())
+		Expect(d.Lookup(0, 0)).NotTo(BeNil())
+		Expect(d.Lookup(0, 0)).NotTo(BeNil())
+		Expect(d.Lookup(0, 0x1000)).To(BeNil())
+
+		Expect(d.WayPredictionStats()).To(Equal(WayPredictionStats{
+			Correct:     2,
+			Incorrect:   1,
+			Misses:      1,
+			TagCompares: 1 + 4 + 1 + 4,
+		}))
+		Expect(d.WayPredictionStats().Accuracy()).To(BeNumerically("~", 2.0/3))
+	})
+
+	It("should learn the ways of the lines with perceptrons", func() {
+		d.SetWayPredictor(NewPerceptronWayPredictor(4))
+		for i := uint64(0); i < 4; i++ {
+			fill(i * 64)
+		}
+
+		for round := 0; round < 8; round++ {
+			for i := uint64(0); i < 4; i++ {
+				d.Lookup(0, i*64)
+			}
+		}
+
+		before := d.WayPredictionStats()
+		for i := uint64(0); i < 4; i++ {
+			Expect(d.Lookup(0, i*64)).NotTo(BeNil())
+		}
+
+		after := d.WayPredictionStats()
+		Expect(after.Correct - before.Correct).To(Equal(uint64(4)))
+		Expect(after.Incorrect).To(Equal(before.Incorrect))
+	})
+
+	It("should not count the lookups without a predictor", func() {
+		d.SetWayPredictor(NewMRUWayPredictor(1))
+		d.SetWayPredictor(nil)
+		fill(0)
+
+		Expect(d.Lookup(0, 0)).NotTo(BeNil())
+		Expect(d.WayPredictor()).To(BeNil())
+		Expect(d.WayPredictionStats()).To(Equal(WayPredictionStats{}))
+	})
+})
@@ -0,0 +1,85 @@
+package cache
+
+import "math"
+
+// A WayScorer reports how attractive each way of a set is as an eviction
+// candidate. Higher scores mean the block is less valuable. Invalid blocks
+// score +Inf and locked blocks score -Inf.
+type WayScorer interface {
+	ScoreWays(set *Set, context *VictimContext) []float64
+}
+
+// ScoreWays returns a score per way of the set using the victim finder. If the
+// victim finder cannot score ways itself, the scores are derived from the
+// ranking returned by FindVictims.
+func ScoreWays(vf VictimFinder, set *Set, context *VictimContext) []float64 {
+	if scorer, ok := vf.(WayScorer); ok {
+		return scorer.ScoreWays(set, context)
+	}
+
+	ranked := FindVictims(vf, set, context, len(set.Blocks))
+	scores := make([]float64, len(set.Blocks))
+	for way := range scores {
+		scores[way] = math.Inf(-1)
+	}
+
+	for i, block := range ranked {
+		scores[block.WayID] = float64(len(ranked) - 1 - i)
+	}
+
+	return fixedWayScores(set, scores)
+}
+
+// fixedWayScores overrides the scores of invalid and locked blocks.
+func fixedWayScores(set *Set, scores []float64) []float64 {
+	for way, block := range set.Blocks {
+		switch {
+		case block.IsLocked:
+			scores[way] = math.Inf(-1)
+		case !block.IsValid:
+			scores[way] = math.Inf(1)
+		}
+	}
+
+	return scores
+}
+
+// ScoreWays scores each way by its PseudoLRU recency rank. The PseudoLRU
+// victim scores numWays-1 and the most protected way scores 0.
+func (e *LRUVictimFinder) ScoreWays(set *Set, context *VictimContext) []float64 {
+	order := pseudoLRUOrder(set)
+	scores := make([]float64, len(set.Blocks))
+
+	for rank, way := range order {
+		scores[way] = float64(len(order) - 1 - rank)
+	}
+
+	return fixedWayScores(set, scores)
+}
+
+// ScoreWays scores each way by the perceptron sum of its resident tag. A
+// higher sum means the block is predicted not to be reused. Scoring does not
+// charge the weight reads.
+func (p *PerceptronVictimFinder) ScoreWays(
+	set *Set,
+	context *VictimContext,
+) []float64 {
+	scores := make([]float64, len(set.Blocks))
+
+	for way, block := range set.Blocks {
+		if block.IsValid && !block.IsLocked {
+			scores[way] = float64(p.predictionSum(block.Tag, block.PC))
+		}
+	}
+
+	return fixedWayScores(set, scores)
+}
+
+// ScoreWays returns the per-way scores of the set that the address maps to.
+func (d *DirectoryImpl) ScoreWays(
+	addr uint64,
+	context *VictimContext,
+) []float64 {
+	set, _ := d.getSet(addr)
+	return ScoreWays(d.victimFinder, set, context)
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// PerceptronWeightsVersion is the version of the weight file format.
+const PerceptronWeightsVersion = 1
+
+// PerceptronWeights is the learned state of a perceptron, along with the
+// parameters needed to interpret it. It can warm-start a predictor with the
+// weights of a previous run, checkpoint a simulation, or be analyzed offline.
+type PerceptronWeights struct {
+	Version       int                    `json:"version"`
+	Threshold     int32                  `json:"threshold"`
+	Theta         int32                  `json:"theta"`
+	LearningRate  int32                  `json:"learning_rate"`
+	Bias          int32                  `json:"bias,omitempty"`
+	FeatureShift  uint                   `json:"feature_shift"`
+	FeatureSource FeatureSource          `json:"feature_source,omitempty"`
+	WeightConfig  PerceptronWeightConfig `json:"weight_config"`
+	Weights       []int32                `json:"weights"`
+	Hashed        bool                   `json:"hashed,omitempty"`
+	Tables        [][]int32              `json:"tables,omitempty"`
+	HistoryKind   HistoryKind            `json:"history_kind,omitempty"`
+	HistoryLength int                    `json:"history_length,omitempty"`
+}
+
+// Validate checks the version and the sizes of the weight vector and tables.
+func (w PerceptronWeights) Validate() error {
+	if w.Version > PerceptronWeightsVersion {
+		return fmt.Errorf("unsupported perceptron weights version %d",
+			w.Version)
+	}
+
+	config, err := w.WeightConfig.withDefaults()
+	if err != nil {
+		return err
+	}
+
+	if len(w.Weights) != config.NumWeights {
+		return fmt.Errorf("perceptron has %d weights, want %d",
+			len(w.Weights), config.NumWeights)
+	}
+
+	for i, t := range w.Tables {
+		if len(t) != PerceptronTableSize {
+			return fmt.Errorf("weight table %d has %d entries, want %d",
+				i, len(t), PerceptronTableSize)
+		}
+	}
+
+	return checkGlobalHistory(w.HistoryKind, w.HistoryLength)
+}
+
+// ExportWeights returns a copy of the learned state of the perceptron.
+func (p *PerceptronVictimFinder) ExportWeights() PerceptronWeights {
+	w := PerceptronWeights{
+		Version:       PerceptronWeightsVersion,
+		Threshold:     p.threshold,
+		Theta:         p.theta,
+		LearningRate:  p.learningRate,
+		Bias:          p.bias,
+		FeatureShift:  p.featureShift,
+		FeatureSource: p.featureSource,
+		WeightConfig:  p.weightConfig,
+		Weights: append([]int32(nil),
+			p.weights[:p.weightConfig.NumWeights]...),
+		Hashed: p.hashed,
+	}
+
+	w.HistoryKind, w.HistoryLength = p.GlobalHistory()
+
+	for i := range p.tables {
+		w.Tables = append(w.Tables, append([]int32(nil), p.tables[i][:]...))
+	}
+
+	return w
+}
+
+// ImportWeights replaces the learned state and the parameters of the
+// perceptron. The statistics and the feature extractor are kept.
+func (p *PerceptronVictimFinder) ImportWeights(w PerceptronWeights) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+
+	p.SetWeightConfig(w.WeightConfig)
+	copy(p.weights[:], w.Weights)
+
+	p.tables = make([][PerceptronTableSize]int32, len(w.Tables))
+	for i, t := range w.Tables {
+		copy(p.tables[i][:], t)
+	}
+
+	p.threshold = w.Threshold
+	p.theta = w.Theta
+	p.learningRate = w.LearningRate
+	p.bias = w.Bias
+	p.featureShift = w.FeatureShift
+	p.featureSource = w.FeatureSource
+	p.SetGlobalHistory(w.HistoryKind, w.HistoryLength)
+	p.hashed = w.Hashed
+	p.invalidatePredictions()
+
+	return nil
+}
+
+// ReadPerceptronWeights decodes and validates JSON perceptron weights.
+func ReadPerceptronWeights(r io.Reader) (PerceptronWeights, error) {
+	var w PerceptronWeights
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&w); err != nil {
+		return w, err
+	}
+
+	return w, w.Validate()
+}
+
+// WritePerceptronWeights encodes the weights as JSON.
+func WritePerceptronWeights(w io.Writer, weights PerceptronWeights) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(weights)
+}
+
+// LoadFromFile reads JSON weights from the file and imports them.
+func (p *PerceptronVictimFinder) LoadFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := ReadPerceptronWeights(f)
+	if err != nil {
+		return fmt.Errorf("reading perceptron weights %s: %w", path, err)
+	}
+
+	return p.ImportWeights(w)
+}
+
+// SaveToFile writes the exported weights of the perceptron to the file.
+func (p *PerceptronVictimFinder) SaveToFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = WritePerceptronWeights(f, p.ExportWeights())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
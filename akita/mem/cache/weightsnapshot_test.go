@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PerceptronWeights", func() {
+	var p *PerceptronVictimFinder
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinderWithParams(3, 68, 1)
+		p.SetStrictMode(true)
+		p.SetWeightConfig(PerceptronWeightConfig{NumWeights: 16, Bits: 5})
+		p.SetHashedTables(true)
+
+		for i := uint64(0); i < 64; i++ {
+			p.TrainOnEviction(i * 0x1040)
+			p.TrainOnHit(i * 0x2080)
+		}
+
+		p.SetHashedTables(false)
+
+		for i := uint64(0); i < 64; i++ {
+			p.TrainOnEviction(i * 0x10040)
+		}
+	})
+
+	It("should restore the exported state", func() {
+		restored := NewPerceptronVictimFinder()
+
+		Expect(restored.ImportWeights(p.ExportWeights())).To(Succeed())
+
+		Expect(restored.ExportWeights()).To(Equal(p.ExportWeights()))
+		Expect(restored.Weights()).To(Equal(p.Weights()))
+		Expect(restored.TableWeights()).To(Equal(p.TableWeights()))
+		Expect(restored.predictionSum(0x123456, 0)).
+			To(Equal(p.predictionSum(0x123456, 0)))
+	})
+
+	It("should not share memory with the export", func() {
+		w := p.ExportWeights()
+		w.Weights[0] = 1000
+		w.Tables[0][0] = 1000
+
+		Expect(p.Weights()[0]).NotTo(Equal(int32(1000)))
+		Expect(p.TableWeights()[0][0]).NotTo(Equal(int32(1000)))
+	})
+
+	It("should round trip through a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "weights.json")
+		Expect(p.SaveToFile(path)).To(Succeed())
+
+		restored := NewPerceptronVictimFinder()
+		Expect(restored.LoadFromFile(path)).To(Succeed())
+
+		Expect(restored.ExportWeights()).To(Equal(p.ExportWeights()))
+	})
+
+	It("should reject weights that do not match the config", func() {
+		w := p.ExportWeights()
+		w.Weights = w.Weights[:4]
+
+		Expect(p.ImportWeights(w)).To(MatchError(ContainSubstring("weights")))
+
+		w = p.ExportWeights()
+		w.WeightConfig.NumWeights = 3
+
+		Expect(p.ImportWeights(w)).NotTo(Succeed())
+	})
+
+	It("should reject newer versions and unknown fields", func() {
+		var buf bytes.Buffer
+
+		w := p.ExportWeights()
+		w.Version = PerceptronWeightsVersion + 1
+		Expect(WritePerceptronWeights(&buf, w)).To(Succeed())
+
+		_, err := ReadPerceptronWeights(&buf)
+		Expect(err).To(MatchError(ContainSubstring("version")))
+
+		_, err = ReadPerceptronWeights(strings.NewReader(`{"biases": []}`))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,134 @@
+package cache
+
+import "github.com/sarchlab/akita/v4/mem/vm"
+
+// A WorkingSetSample is the estimated number of unique lines touched in one
+// interval.
+type WorkingSetSample struct {
+	Interval uint64
+	Accesses uint64
+	Lines    uint64
+}
+
+// A WorkingSetReport compares the estimated working set with the capacity of
+// a cache.
+type WorkingSetReport struct {
+	EstimatedLines uint64
+	CapacityLines  uint64
+
+	// TooSmall is true if the working set does not fit in the cache, in which
+	// case no replacement policy can avoid capacity misses.
+	TooSmall bool
+}
+
+// A WorkingSetEstimator estimates the number of unique lines touched per
+// interval. Only the lines whose hash falls in a 1/2^SampleShift fraction of
+// the hash space are tracked, and the count is scaled back up.
+type WorkingSetEstimator struct {
+	interval    uint64
+	sampleShift uint
+
+	seen     map[uint64]struct{}
+	accesses uint64
+	history  []WorkingSetSample
+}
+
+// NewWorkingSetEstimator creates an estimator that closes an interval every
+// interval accesses and samples one out of every 2^sampleShift lines.
+func NewWorkingSetEstimator(
+	interval uint64,
+	sampleShift uint,
+) *WorkingSetEstimator {
+	if interval == 0 {
+		panic("working set interval must be positive")
+	}
+
+	if sampleShift >= 32 {
+		panic("working set sample shift must be less than 32")
+	}
+
+	return &WorkingSetEstimator{
+		interval:    interval,
+		sampleShift: sampleShift,
+		seen:        make(map[uint64]struct{}),
+	}
+}
+
+// SetWorkingSetEstimator attaches a working-set estimator that records every
+// visited line.
+func (d *DirectoryImpl) SetWorkingSetEstimator(e *WorkingSetEstimator) {
+	d.workingSet = e
+}
+
+// WorkingSetReport compares the estimated working set with the number of
+// blocks in the directory. It returns a zero report if no estimator is
+// attached.
+func (d *DirectoryImpl) WorkingSetReport() WorkingSetReport {
+	if d.workingSet == nil {
+		return WorkingSetReport{}
+	}
+
+	return d.workingSet.Report(d.NumSets * d.NumWays)
+}
+
+// Record registers an access to the line. Calls on a nil estimator are
+// ignored.
+func (e *WorkingSetEstimator) Record(pid vm.PID, lineAddr uint64) {
+	if e == nil {
+		return
+	}
+
+	h := mixLineHash(uint64(pid)<<48 ^ lineAddr)
+	if h&(1<<e.sampleShift-1) == 0 {
+		e.seen[h] = struct{}{}
+	}
+
+	e.accesses++
+	if e.accesses%e.interval == 0 {
+		e.history = append(e.history, WorkingSetSample{
+			Interval: e.accesses / e.interval,
+			Accesses: e.interval,
+			Lines:    uint64(len(e.seen)) << e.sampleShift,
+		})
+		e.seen = make(map[uint64]struct{})
+	}
+}
+
+// Estimate returns the working-set size of the last completed interval, or
+// the partial estimate of the current interval if none has completed.
+func (e *WorkingSetEstimator) Estimate() uint64 {
+	if len(e.history) > 0 {
+		return e.history[len(e.history)-1].Lines
+	}
+
+	return uint64(len(e.seen)) << e.sampleShift
+}
+
+// History returns the estimates of all the completed intervals.
+func (e *WorkingSetEstimator) History() []WorkingSetSample {
+	return e.history
+}
+
+// Report compares the current estimate with a cache of the given number of
+// lines.
+func (e *WorkingSetEstimator) Report(capacityLines int) WorkingSetReport {
+	estimate := e.Estimate()
+
+	return WorkingSetReport{
+		EstimatedLines: estimate,
+		CapacityLines:  uint64(capacityLines),
+		TooSmall:       estimate > uint64(capacityLines),
+	}
+}
+
+// mixLineHash is the splitmix64 finalizer. It spreads consecutive line
+// addresses over the hash space so that sampling by the low bits is unbiased.
+func mixLineHash(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WorkingSetEstimator", func() {
+	It("should count unique lines exactly without sampling", func() {
+		e := NewWorkingSetEstimator(100, 0)
+
+		for i := 0; i < 100; i++ {
+			e.Record(1, uint64(i%10)*64)
+		}
+
+		Expect(e.Estimate()).To(Equal(uint64(10)))
+		Expect(e.History()).To(HaveLen(1))
+	})
+
+	It("should approximate the unique lines when sampling", func() {
+		e := NewWorkingSetEstimator(40000, 3)
+
+		for i := 0; i < 40000; i++ {
+			e.Record(1, uint64(i%20000)*64)
+		}
+
+		Expect(e.Estimate()).To(BeNumerically("~", 20000, 2000))
+	})
+
+	It("should report a cache that is too small", func() {
+		d := NewDirectory(4, 2, 64, NewLRUVictimFinder())
+		d.SetWorkingSetEstimator(NewWorkingSetEstimator(1000, 0))
+
+		for addr := uint64(0); addr < 16*64; addr += 64 {
+			block := d.FindVictim(addr)
+			block.Tag = addr
+			block.IsValid = true
+			d.Visit(block)
+		}
+
+		report := d.WorkingSetReport()
+
+		Expect(report.EstimatedLines).To(Equal(uint64(16)))
+		Expect(report.CapacityLines).To(Equal(uint64(8)))
+		Expect(report.TooSmall).To(BeTrue())
+	})
+})
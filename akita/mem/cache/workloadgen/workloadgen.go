@@ -0,0 +1,254 @@
+// Package workloadgen generates synthetic memory access streams with known
+// reuse behavior, for tests and benchmarks of replacement policies.
+package workloadgen
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+
+	"github.com/sarchlab/akita/v4/mem/cache/replay"
+)
+
+// A Pattern is the shape of a generated access stream.
+type Pattern int
+
+// Access patterns.
+const (
+	// Sequential touches consecutive lines and never reuses one.
+	Sequential Pattern = iota
+
+	// Strided touches every Stride-th line and never reuses one.
+	Strided
+
+	// Random picks lines uniformly from the working set.
+	Random
+
+	// Zipf picks lines from the working set with a Zipf distribution, so a
+	// few lines take most of the accesses.
+	Zipf
+
+	// Loop walks the working set in order, over and over. LRU hits on every
+	// access once the working set fits in the cache, and on none once it
+	// does not.
+	Loop
+
+	// ScanReuse alternates between ReuseAccesses random accesses to the
+	// working set and a scan of ScanLength new lines that are never reused.
+	// The scans come from ScanPC and the reuses from PC, so a predictor can
+	// tell them apart.
+	ScanReuse
+)
+
+var patternNames = map[Pattern]string{
+	Sequential: "sequential",
+	Strided:    "strided",
+	Random:     "random",
+	Zipf:       "zipf",
+	Loop:       "loop",
+	ScanReuse:  "scan-reuse",
+}
+
+// String returns the name of the pattern.
+func (p Pattern) String() string {
+	if name, ok := patternNames[p]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Pattern(%d)", int(p))
+}
+
+// A Config describes a generated access stream. Zero values take the
+// defaults.
+type Config struct {
+	Pattern Pattern
+
+	// The number of accesses to generate. Defaults to 100000.
+	Accesses int
+
+	// The address of the first line. The scans of ScanReuse start after the
+	// working set.
+	Base uint64
+
+	// The size of a line in bytes. Defaults to 64.
+	LineSize uint64
+
+	// The distance between the lines of Strided, in lines. Defaults to 4.
+	Stride uint64
+
+	// The number of lines of the working set of Random, Zipf, Loop, and
+	// ScanReuse. Defaults to 1024.
+	WorkingSet int
+
+	// The exponent of Zipf, which must be greater than 1. Defaults to 1.2.
+	ZipfExponent float64
+
+	// The number of reuse accesses and of scanned lines in every phase of
+	// ScanReuse. Both default to the working set size.
+	ReuseAccesses int
+	ScanLength    int
+
+	// The PCs of the accesses and of the scans of ScanReuse. Default to
+	// 0x400 and 0x800.
+	PC     uint64
+	ScanPC uint64
+
+	// One access out of every WriteInterval is a write. Zero generates
+	// only reads.
+	WriteInterval int
+
+	// The seed of the random patterns.
+	Seed int64
+}
+
+func (c Config) withDefaults() Config {
+	if c.Accesses <= 0 {
+		c.Accesses = 100000
+	}
+
+	if c.LineSize == 0 {
+		c.LineSize = 64
+	}
+
+	if c.Stride == 0 {
+		c.Stride = 4
+	}
+
+	if c.WorkingSet <= 0 {
+		c.WorkingSet = 1024
+	}
+
+	if c.ZipfExponent <= 1 {
+		c.ZipfExponent = 1.2
+	}
+
+	if c.ReuseAccesses <= 0 {
+		c.ReuseAccesses = c.WorkingSet
+	}
+
+	if c.ScanLength <= 0 {
+		c.ScanLength = c.WorkingSet
+	}
+
+	if c.PC == 0 {
+		c.PC = 0x400
+	}
+
+	if c.ScanPC == 0 {
+		c.ScanPC = 0x800
+	}
+
+	return c
+}
+
+// A Generator produces the accesses of a Config, one instruction per
+// access. It is a replay.TraceReader, so the stream can be replayed directly.
+type Generator struct {
+	config Config
+	rand   *rand.Rand
+	zipf   *rand.Zipf
+	next   int
+
+	// The position in the current phase of ScanReuse, and the next line to
+	// scan.
+	phase    int
+	scanLine uint64
+}
+
+// New creates a generator of the stream.
+func New(config Config) *Generator {
+	config = config.withDefaults()
+
+	g := &Generator{
+		config: config,
+		rand:   rand.New(rand.NewSource(config.Seed)),
+	}
+
+	if config.Pattern == Zipf {
+		g.zipf = rand.NewZipf(g.rand, config.ZipfExponent, 1,
+			uint64(config.WorkingSet-1))
+	}
+
+	return g
+}
+
+// Config returns the configuration, with the defaults filled in.
+func (g *Generator) Config() Config {
+	return g.config
+}
+
+// Next returns the next access, or io.EOF after the last one.
+func (g *Generator) Next() (replay.Access, error) {
+	c := &g.config
+	if g.next >= c.Accesses {
+		return replay.Access{}, io.EOF
+	}
+
+	a := replay.Access{Instruction: uint64(g.next), PC: c.PC}
+	line := g.nextLine(&a)
+	a.Address = c.Base + line*c.LineSize
+	a.Write = c.WriteInterval > 0 && g.next%c.WriteInterval == c.WriteInterval-1
+
+	g.next++
+
+	return a, nil
+}
+
+// Instructions returns the number of accesses generated so far.
+func (g *Generator) Instructions() uint64 {
+	return uint64(g.next)
+}
+
+func (g *Generator) nextLine(a *replay.Access) uint64 {
+	c := &g.config
+	i := uint64(g.next)
+
+	switch c.Pattern {
+	case Sequential:
+		return i
+	case Strided:
+		return i * c.Stride
+	case Random:
+		return uint64(g.rand.Intn(c.WorkingSet))
+	case Zipf:
+		return g.zipf.Uint64()
+	case Loop:
+		return i % uint64(c.WorkingSet)
+	case ScanReuse:
+		return g.scanReuseLine(a)
+	default:
+		panic(fmt.Sprintf("unknown pattern %d", c.Pattern))
+	}
+}
+
+func (g *Generator) scanReuseLine(a *replay.Access) uint64 {
+	c := &g.config
+
+	phase := g.phase
+	g.phase = (g.phase + 1) % (c.ReuseAccesses + c.ScanLength)
+
+	if phase < c.ReuseAccesses {
+		return uint64(g.rand.Intn(c.WorkingSet))
+	}
+
+	a.PC = c.ScanPC
+	line := uint64(c.WorkingSet) + g.scanLine
+	g.scanLine++
+
+	return line
+}
+
+// Generate returns all the accesses of the stream.
+func Generate(config Config) []replay.Access {
+	g := New(config)
+	accesses := make([]replay.Access, 0, g.config.Accesses)
+
+	for {
+		a, err := g.Next()
+		if err != nil {
+			return accesses
+		}
+
+		accesses = append(accesses, a)
+	}
+}
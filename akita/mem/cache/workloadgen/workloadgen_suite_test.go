@@ -0,0 +1,13 @@
+package workloadgen
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestWorkloadgen(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Workload Generator Suite")
+}
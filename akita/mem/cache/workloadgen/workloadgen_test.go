@@ -0,0 +1,135 @@
+package workloadgen
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v4/mem/cache/replay"
+)
+
+func lines(accesses []replay.Access) []uint64 {
+	l := make([]uint64, len(accesses))
+	for i, a := range accesses {
+		l[i] = a.Address / 64
+	}
+
+	return l
+}
+
+var _ = Describe("Generator", func() {
+	It("should generate sequential and strided streams", func() {
+		Expect(lines(Generate(Config{Pattern: Sequential, Accesses: 4}))).
+			To(Equal([]uint64{0, 1, 2, 3}))
+		Expect(lines(Generate(Config{Pattern: Strided, Accesses: 3}))).
+			To(Equal([]uint64{0, 4, 8}))
+	})
+
+	It("should loop over the working set", func() {
+		accesses := Generate(Config{
+			Pattern:    Loop,
+			Accesses:   5,
+			WorkingSet: 2,
+			Base:       0x1000,
+		})
+
+		Expect(lines(accesses)).To(Equal([]uint64{64, 65, 64, 65, 64}))
+		Expect(accesses[4].Instruction).To(Equal(uint64(4)))
+	})
+
+	It("should stay in the working set", func() {
+		for _, p := range []Pattern{Random, Zipf} {
+			for _, line := range lines(Generate(Config{
+				Pattern:    p,
+				Accesses:   1000,
+				WorkingSet: 16,
+			})) {
+				Expect(line).To(BeNumerically("<", 16), p.String())
+			}
+		}
+	})
+
+	It("should skew Zipf toward the first lines", func() {
+		counts := make(map[uint64]int)
+		for _, line := range lines(Generate(Config{Pattern: Zipf})) {
+			counts[line]++
+		}
+
+		Expect(counts[0]).To(BeNumerically(">", counts[100]*10))
+	})
+
+	It("should alternate reuses and scans", func() {
+		accesses := Generate(Config{
+			Pattern:       ScanReuse,
+			Accesses:      10,
+			WorkingSet:    8,
+			ReuseAccesses: 3,
+			ScanLength:    2,
+		})
+
+		for i, a := range accesses {
+			if i%5 < 3 {
+				Expect(a.PC).To(Equal(uint64(0x400)))
+				Expect(a.Address / 64).To(BeNumerically("<", 8))
+			} else {
+				Expect(a.PC).To(Equal(uint64(0x800)))
+			}
+		}
+
+		Expect(lines(accesses)[3:5]).To(Equal([]uint64{8, 9}))
+		Expect(lines(accesses)[8:10]).To(Equal([]uint64{10, 11}))
+	})
+
+	It("should be deterministic and generate writes", func() {
+		config := Config{Pattern: Random, Accesses: 100, WriteInterval: 4}
+		accesses := Generate(config)
+
+		Expect(Generate(config)).To(Equal(accesses))
+		Expect(accesses[3].Write).To(BeTrue())
+		Expect(accesses[4].Write).To(BeFalse())
+
+		config.Seed = 1
+		Expect(Generate(config)).NotTo(Equal(accesses))
+	})
+
+	It("should name the patterns", func() {
+		Expect(ScanReuse.String()).To(Equal("scan-reuse"))
+		Expect(Pattern(42).String()).To(Equal("Pattern(42)"))
+	})
+})
+
+var _ = Describe("Policies on generated workloads", func() {
+	hitRates := func(config Config) (lru, perceptron float64) {
+		config.Accesses = 50000
+
+		results, err := replay.Run(New(config), replay.Config{
+			NumSets:  64,
+			NumWays:  8,
+			Policies: []string{"lru", "perceptron"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		return results[0].HitRate(), results[1].HitRate()
+	}
+
+	It("should beat LRU on a scan-heavy pattern", func() {
+		lru, perceptron := hitRates(Config{
+			Pattern:       ScanReuse,
+			WorkingSet:    256,
+			ReuseAccesses: 1000,
+			ScanLength:    512,
+		})
+
+		Expect(perceptron).To(BeNumerically(">", lru+0.05))
+	})
+
+	It("should not lose on LRU-friendly patterns", func() {
+		for _, config := range []Config{
+			{Pattern: Loop, WorkingSet: 448},
+			{Pattern: Random, WorkingSet: 384},
+		} {
+			lru, perceptron := hitRates(config)
+
+			Expect(perceptron).To(BeNumerically(">=", lru-0.01),
+				config.Pattern.String())
+		}
+	})
+})
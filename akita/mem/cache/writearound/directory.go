@@ -131,7 +131,7 @@ func (d *directory) processReadMiss(trans *transaction) bool {
 	cacheLineID := addr / blockSize * blockSize
 
 	victim := d.cache.directory.FindVictim(cacheLineID)
-	if victim.IsLocked || victim.ReadCount > 0 {
+	if victim == nil || victim.IsLocked || victim.ReadCount > 0 {
 		return false
 	}
 
@@ -35,6 +35,19 @@ type Builder struct {
 
 	addressMapperType string
 	usePerceptron     bool
+	perceptronPreset  *cache.PerceptronPreset
+	replacementPolicy string
+	writeMissPolicy   cache.WriteMissPolicy
+	cuckooDirectory   bool
+	skewedDirectory   bool
+	columnAssociative bool
+	dirtyWays         int
+	kernelPolicy      *cache.KernelBoundaryPolicy
+	predictorLatency  *cache.PredictorLatency
+	directoryPorts    *cache.DirectoryPortConfig
+	setIndexConverter mem.AddressConverter
+	indexFunction     cache.IndexFunction
+	partialWriteback  cache.PartialWritebackPolicy
 }
 
 // MakeBuilder creates a new builder with default configurations.
@@ -114,6 +127,30 @@ func (b Builder) WithInterleaving(
 	return b
 }
 
+// WithSetIndexConverter sets an address transformation, such as the ones in
+// the mem package, that is applied before the set index is extracted. If the
+// cache is also interleaved, the transformation is applied after the
+// interleaving bits are removed.
+func (b Builder) WithSetIndexConverter(c mem.AddressConverter) Builder {
+	b.setIndexConverter = c
+	return b
+}
+
+// WithIndexFunction sets how the lines are mapped to the sets of the
+// directory. The default is the line number modulo the number of sets. It
+// has no effect with the cuckoo and column-associative directories.
+func (b Builder) WithIndexFunction(f cache.IndexFunction) Builder {
+	b.indexFunction = f
+	return b
+}
+
+// WithPartialWriteback sets how much of an evicted dirty line is written
+// back. By default, the whole line is written back.
+func (b Builder) WithPartialWriteback(p cache.PartialWritebackPolicy) Builder {
+	b.partialWriteback = p
+	return b
+}
+
 // WithWriteBufferSize sets the number of cach lines that can reside in the
 // writebuffer.
 func (b Builder) WithWriteBufferSize(n int) Builder {
@@ -160,6 +197,91 @@ func (b Builder) WithPerceptronVictimFinder() Builder {
 	return b
 }
 
+// WithPerceptronPreset enables perceptron-based victim selection configured
+// by the named preset (e.g., "gpu-l1", "gpu-l2", "cpu-llc"). The preset also
+// sets the way associativity, which can be overridden by calling
+// WithWayAssociativity afterwards.
+func (b Builder) WithPerceptronPreset(name string) Builder {
+	preset, ok := cache.LookupPerceptronPreset(name)
+	if !ok {
+		panic(fmt.Sprintf("unknown perceptron preset %q", name))
+	}
+
+	b.usePerceptron = true
+	b.perceptronPreset = &preset
+	b.wayAssociativity = preset.WayAssociativity
+
+	return b
+}
+
+// WithReplacementPolicy selects the victim finder by the name it is
+// registered under (e.g., "lru", "srrip", "hawkeye"). See
+// cache.NewVictimFinderByName. It takes precedence over the perceptron
+// options.
+func (b Builder) WithReplacementPolicy(name string) Builder {
+	b.replacementPolicy = name
+	return b
+}
+
+// WithWriteMissPolicy sets whether write misses allocate blocks in the
+// cache. With cache.NoWriteAllocate, write misses bypass the cache and are
+// written directly to the lower-level memory.
+func (b Builder) WithWriteMissPolicy(p cache.WriteMissPolicy) Builder {
+	b.writeMissPolicy = p
+	return b
+}
+
+// WithCuckooDirectory makes the cache use the experimental cuckoo directory,
+// in which every line can be placed in two sets.
+func (b Builder) WithCuckooDirectory() Builder {
+	b.cuckooDirectory = true
+	return b
+}
+
+// WithSkewedDirectory makes the cache use a skewed-associative directory, in
+// which every way is indexed by a different hash. The default PseudoLRU
+// policy is replaced by exact LRU, since it needs per-set state.
+func (b Builder) WithSkewedDirectory() Builder {
+	b.skewedDirectory = true
+	return b
+}
+
+// WithColumnAssociativeDirectory makes the cache use a direct-mapped
+// column-associative directory. The way associativity is ignored.
+func (b Builder) WithColumnAssociativeDirectory() Builder {
+	b.columnAssociative = true
+	return b
+}
+
+// WithDirtyWays reserves the first n ways of every set for dirty data. See
+// cache.DirtyPartition.
+func (b Builder) WithDirtyWays(n int) Builder {
+	b.dirtyWays = n
+	return b
+}
+
+// WithKernelBoundaryPolicy sets the actions that the cache takes on its
+// predictor state when Comp.KernelLaunched and Comp.KernelCompleted are
+// called.
+func (b Builder) WithKernelBoundaryPolicy(p cache.KernelBoundaryPolicy) Builder {
+	b.kernelPolicy = &p
+	return b
+}
+
+// WithPredictorLatency sets the cycles that the replacement predictor adds to
+// each access in the directory stage. See cache.PredictorLatency.
+func (b Builder) WithPredictorLatency(l cache.PredictorLatency) Builder {
+	b.predictorLatency = &l
+	return b
+}
+
+// WithDirectoryPorts limits the lookups and victim selections that the
+// directory serves per cycle. See cache.DirectoryPorts.
+func (b Builder) WithDirectoryPorts(c cache.DirectoryPortConfig) Builder {
+	b.directoryPorts = &c
+	return b
+}
+
 func (b Builder) WithRemotePorts(ports ...sim.RemotePort) Builder {
 	if b.addressMapperType == "single" {
 		if len(ports) != 1 {
@@ -197,8 +319,25 @@ func (b Builder) Build(name string) *Comp {
 func (b *Builder) configureCache(cacheModule *Comp) {
 	blockSize := 1 << b.log2BlockSize
 
+	numSet := int(b.byteSize / uint64(b.wayAssociativity*blockSize))
+
 	var victimFinder cache.VictimFinder
-	if b.usePerceptron {
+	if b.replacementPolicy != "" {
+		vf, err := cache.NewVictimFinderByName(b.replacementPolicy,
+			cache.PolicyConfig{
+				NumSets:   numSet,
+				NumWays:   b.wayAssociativity,
+				BlockSize: blockSize,
+			})
+		if err != nil {
+			panic(err)
+		}
+
+		victimFinder = vf
+	} else if b.perceptronPreset != nil {
+		victimFinder = cache.NewPerceptronVictimFinderFromPreset(
+			*b.perceptronPreset)
+	} else if b.usePerceptron {
 		// Removed logging for performance
 		victimFinder = cache.NewPerceptronVictimFinder()
 	} else {
@@ -206,12 +345,45 @@ func (b *Builder) configureCache(cacheModule *Comp) {
 		victimFinder = cache.NewLRUVictimFinder()
 	}
 
-	numSet := int(b.byteSize / uint64(b.wayAssociativity*blockSize))
-	directory := cache.NewDirectory(
-		numSet, b.wayAssociativity, blockSize, victimFinder)
+	var (
+		directory     cache.Directory
+		directoryImpl *cache.DirectoryImpl
+	)
+
+	switch {
+	case b.columnAssociative:
+		column := cache.NewColumnAssociativeDirectory(
+			int(b.byteSize/uint64(blockSize)), blockSize)
+		directory, directoryImpl = column, column.DirectoryImpl
+	case b.cuckooDirectory:
+		cuckoo := cache.NewCuckooDirectory(
+			numSet, b.wayAssociativity, blockSize, victimFinder)
+		directory, directoryImpl = cuckoo, cuckoo.DirectoryImpl
+	case b.skewedDirectory:
+		vf := victimFinder
+		if _, ok := vf.(*cache.LRUVictimFinder); ok {
+			vf = nil
+		}
+
+		skewed := cache.NewSkewedDirectory(
+			numSet, b.wayAssociativity, blockSize, vf)
+		directory, directoryImpl = skewed, skewed.DirectoryImpl
+	default:
+		directoryImpl = cache.NewDirectory(
+			numSet, b.wayAssociativity, blockSize, victimFinder)
+		directoryImpl.SetIndexFunction(b.indexFunction)
+		directory = directoryImpl
+	}
+
+	hints := cache.NewEvictionHints()
+	directoryImpl.SetEvictionHints(hints)
+
+	if b.dirtyWays > 0 {
+		directoryImpl.SetDirtyPartition(cache.NewDirtyPartition(b.dirtyWays))
+	}
 
 	if b.interleaving {
-		directory.AddrConverter = &mem.InterleavingConverter{
+		directoryImpl.AddrConverter = &mem.InterleavingConverter{
 			InterleavingSize: uint64(b.numInterleavingBlock) *
 				(1 << b.log2BlockSize),
 			TotalNumOfElements:  b.interleavingUnitCount,
@@ -219,6 +391,16 @@ func (b *Builder) configureCache(cacheModule *Comp) {
 		}
 	}
 
+	if b.setIndexConverter != nil {
+		if directoryImpl.AddrConverter != nil {
+			directoryImpl.AddrConverter = mem.ChainConverter{
+				directoryImpl.AddrConverter, b.setIndexConverter,
+			}
+		} else {
+			directoryImpl.AddrConverter = b.setIndexConverter
+		}
+	}
+
 	mshr := cache.NewMSHR(b.numMSHREntry)
 	storage := mem.NewStorage(b.byteSize)
 
@@ -238,6 +420,23 @@ func (b *Builder) configureCache(cacheModule *Comp) {
 	cacheModule.addressToPortMapper = b.addressToPortMapper
 	cacheModule.state = cacheStateRunning
 	cacheModule.evictingList = make(map[uint64]bool)
+	cacheModule.writeMissPolicy = b.writeMissPolicy
+	cacheModule.hints = hints
+
+	if b.kernelPolicy != nil {
+		cacheModule.kernelHooks = cache.NewKernelBoundaryHooks(
+			directoryImpl, *b.kernelPolicy)
+	}
+
+	if b.predictorLatency != nil {
+		cacheModule.predictorTimer = cache.NewPredictorTimer(*b.predictorLatency)
+	}
+
+	cacheModule.writebackPlanner = cache.NewWritebackPlanner(b.partialWriteback)
+
+	if b.directoryPorts != nil {
+		cacheModule.directoryPorts = cache.NewDirectoryPorts(*b.directoryPorts)
+	}
 }
 
 func (b *Builder) createPorts(cache *Comp) {
@@ -35,11 +35,17 @@ func getAccessType(trans *transaction) string {
 
 // Helper function to create VictimContext from transaction
 func createVictimContext(trans *transaction, cacheLineID uint64) *cache.VictimContext {
+	pc, _ := mem.InstPC(trans.accessReq())
+	origin, _ := mem.Origin(trans.accessReq())
+
 	return &cache.VictimContext{
-		Address:     trans.accessReq().GetAddress(),
-		PID:         trans.accessReq().GetPID(),
-		AccessType:  getAccessType(trans),
-		CacheLineID: cacheLineID,
+		Address:      trans.accessReq().GetAddress(),
+		PID:          trans.accessReq().GetPID(),
+		AccessType:   getAccessType(trans),
+		CacheLineID:  cacheLineID,
+		PC:           pc,
+		AccessOrigin: origin,
+		AccessSize:   trans.accessReq().GetByteSize(),
 	}
 }
 
@@ -56,6 +62,8 @@ func (ds *directoryStage) Tick() (madeProgress bool) {
 func (ds *directoryStage) processTransaction() bool {
 	madeProgress := false
 
+	ds.cache.directoryPorts.NextCycle()
+
 	for i := 0; i < ds.cache.numReqPerCycle; i++ {
 		item := ds.buf.Peek()
 		if item == nil {
@@ -71,17 +79,110 @@ func (ds *directoryStage) processTransaction() bool {
 			break
 		}
 
+		if ds.waitForPorts(trans, cacheLineID) {
+			madeProgress = true
+			break
+		}
+
+		if ds.waitForPredictor(trans, cacheLineID) {
+			madeProgress = true
+			break
+		}
+
+		var done bool
 		if trans.read != nil {
-			madeProgress = ds.doRead(trans) || madeProgress
-			continue
+			done = ds.doRead(trans)
+		} else {
+			done = ds.doWrite(trans)
 		}
 
-		madeProgress = ds.doWrite(trans) || madeProgress
+		// A grant covers one attempt, so that a stalled transaction competes
+		// for the ports again.
+		trans.portsGranted = false
+		madeProgress = done || madeProgress
 	}
 
 	return madeProgress
 }
 
+// waitForPorts acquires the directory ports for the transaction. It returns
+// true while the transaction has to wait for a port.
+func (ds *directoryStage) waitForPorts(
+	trans *transaction,
+	cacheLineID uint64,
+) bool {
+	ports := ds.cache.directoryPorts
+	if ports == nil || trans.portsGranted {
+		return false
+	}
+
+	if trans.portRetryCycles > 0 {
+		trans.portRetryCycles--
+		return true
+	}
+
+	ops := []cache.DirectoryPortOp{cache.PortLookup}
+	if ds.needsVictim(trans, cacheLineID) {
+		ops = append(ops, cache.PortVictimSelection)
+	}
+
+	switch ports.Acquire(ops...) {
+	case cache.PortGranted:
+		trans.portsGranted = true
+		return false
+	case cache.PortRejected:
+		trans.portRetryCycles = ports.Config().RetryCycles
+	}
+
+	return true
+}
+
+// waitForPredictor charges the predictor latency to the transaction the first
+// time it reaches the head of the stage. It returns true while the
+// transaction has to wait.
+func (ds *directoryStage) waitForPredictor(
+	trans *transaction,
+	cacheLineID uint64,
+) bool {
+	if ds.cache.predictorTimer == nil {
+		return false
+	}
+
+	if !trans.predictorCharged {
+		trans.predictorCharged = true
+		trans.predictorCycles = ds.cache.predictorTimer.Charge(
+			ds.needsVictim(trans, cacheLineID))
+	}
+
+	if trans.predictorCycles == 0 {
+		return false
+	}
+
+	trans.predictorCycles--
+
+	return true
+}
+
+// needsVictim tells if the transaction will select a victim, which is the
+// case for accesses that miss both the MSHR and the directory.
+func (ds *directoryStage) needsVictim(
+	trans *transaction,
+	cacheLineID uint64,
+) bool {
+	pid := trans.accessReq().GetPID()
+	if ds.cache.mshr.Query(pid, cacheLineID) != nil {
+		return false
+	}
+
+	// Probe rather than look up, so that the lookup of the access is only
+	// counted once.
+	if prober, ok := ds.cache.directory.(cache.DirectoryProber); ok {
+		return prober.Probe(pid, cacheLineID) == nil
+	}
+
+	return ds.cache.directory.Lookup(pid, cacheLineID) == nil
+}
+
 func (ds *directoryStage) acceptNewTransaction() bool {
 	madeProgress := false
 
@@ -156,10 +257,10 @@ func (ds *directoryStage) handleReadHit(
 	}
 
 	// Train perceptron on cache hit (block was reused)
-	if perceptronVF, ok := ds.cache.directory.GetVictimFinder().(*cache.PerceptronVictimFinder); ok {
+	if trainer, ok := ds.cache.directory.GetVictimFinder().(cache.ReuseTrainer); ok {
 		cachelineID, _ := getCacheLineID(trans.read.Address, ds.cache.log2BlockSize)
 		context := createVictimContext(trans, cachelineID)
-		perceptronVF.TrainOnHit(context.Address)
+		trainer.TrainOnHitWithContext(context)
 	}
 
 	tracing.AddTaskStep(
@@ -191,7 +292,7 @@ func (ds *directoryStage) handleReadMiss(trans *transaction) bool {
 
 	context := createVictimContext(trans, cacheLineID)
 	victim := ds.cache.directory.FindVictimWithContext(cacheLineID, context)
-	if victim.IsLocked || victim.ReadCount > 0 {
+	if victim == nil || victim.IsLocked || victim.ReadCount > 0 {
 		return false
 	}
 
@@ -293,23 +394,65 @@ func (ds *directoryStage) doWriteHit(
 	}
 
 	// Train perceptron on cache hit (block was reused)
-	if perceptronVF, ok := ds.cache.directory.GetVictimFinder().(*cache.PerceptronVictimFinder); ok {
+	if trainer, ok := ds.cache.directory.GetVictimFinder().(cache.ReuseTrainer); ok {
 		cachelineID, _ := getCacheLineID(trans.write.Address, ds.cache.log2BlockSize)
 		context := createVictimContext(trans, cachelineID)
-		perceptronVF.TrainOnHit(context.Address)
+		trainer.TrainOnHitWithContext(context)
 	}
 
-	return ds.writeToBank(trans, block)
+	ok := ds.writeToBank(trans, block)
+	if ok {
+		ds.cache.writeStats.RecordHit()
+	}
+
+	return ok
 }
 
 func (ds *directoryStage) doWriteMiss(trans *transaction) bool {
 	write := trans.write
 
+	if ds.cache.writeMissPolicy == cache.NoWriteAllocate ||
+		ds.cache.hints.ShouldBypass(write.PID, write.Address) {
+		return ds.bypassWrite(trans)
+	}
+
+	var ok bool
 	if ds.isWritingFullLine(write) {
-		return ds.writeFullLineMiss(trans)
+		ok = ds.writeFullLineMiss(trans)
+	} else {
+		ok = ds.writePartialLineMiss(trans)
+	}
+
+	if ok {
+		ds.cache.writeStats.RecordAllocatedMiss()
 	}
 
-	return ds.writePartialLineMiss(trans)
+	return ok
+}
+
+// bypassWrite forwards a write miss to the write buffer without allocating a
+// block. The cache line is marked as evicting until the write completes so
+// that later accesses to the line cannot overtake the write.
+func (ds *directoryStage) bypassWrite(trans *transaction) bool {
+	if !ds.cache.writeBufferBuffer.CanPush() {
+		return false
+	}
+
+	write := trans.write
+	cachelineID, _ := getCacheLineID(write.Address, ds.cache.log2BlockSize)
+
+	trans.action = writeBufferBypass
+	trans.evictingPID = write.PID
+	trans.evictingAddr = write.Address
+	trans.evictingData = write.Data
+	trans.evictingDirtyMask = write.DirtyMask
+
+	ds.buf.Pop()
+	ds.cache.writeBufferBuffer.Push(trans)
+	ds.cache.evictingList[cachelineID] = true
+	ds.cache.writeStats.RecordBypassedMiss(uint64(len(write.Data)))
+
+	return true
 }
 
 func (ds *directoryStage) writeFullLineMiss(trans *transaction) bool {
@@ -318,7 +461,7 @@ func (ds *directoryStage) writeFullLineMiss(trans *transaction) bool {
 
 	context := createVictimContext(trans, cachelineID)
 	victim := ds.cache.directory.FindVictimWithContext(cachelineID, context)
-	if victim.IsLocked || victim.ReadCount > 0 {
+	if victim == nil || victim.IsLocked || victim.ReadCount > 0 {
 		return false
 	}
 
@@ -339,7 +482,7 @@ func (ds *directoryStage) writePartialLineMiss(trans *transaction) bool {
 
 	context := createVictimContext(trans, cachelineID)
 	victim := ds.cache.directory.FindVictimWithContext(cachelineID, context)
-	if victim.IsLocked || victim.ReadCount > 0 {
+	if victim == nil || victim.IsLocked || victim.ReadCount > 0 {
 		return false
 	}
 
@@ -371,7 +514,7 @@ func (ds *directoryStage) readFromBank(
 		return false
 	}
 
-	ds.cache.directory.Visit(block)
+	ds.visit(trans, block)
 
 	block.ReadCount++
 	trans.block = block
@@ -383,6 +526,20 @@ func (ds *directoryStage) readFromBank(
 	return true
 }
 
+// visit visits the block with the context of the transaction, so that the
+// policy can attribute a hit to the PC that hit.
+func (ds *directoryStage) visit(trans *transaction, block *cache.Block) {
+	v, ok := ds.cache.directory.(cache.DirectoryContextVisitor)
+	if !ok {
+		ds.cache.directory.Visit(block)
+		return
+	}
+
+	cacheLineID, _ := getCacheLineID(
+		trans.accessReq().GetAddress(), ds.cache.log2BlockSize)
+	v.VisitWithContext(block, createVictimContext(trans, cacheLineID))
+}
+
 func (ds *directoryStage) writeToBank(
 	trans *transaction,
 	block *cache.Block,
@@ -398,7 +555,7 @@ func (ds *directoryStage) writeToBank(
 	addr := trans.write.Address
 	cachelineID, _ := getCacheLineID(addr, ds.cache.log2BlockSize)
 
-	ds.cache.directory.Visit(block)
+	ds.visit(trans, block)
 	block.IsLocked = true
 	block.Tag = cachelineID
 	block.IsValid = true
@@ -440,8 +597,16 @@ func (ds *directoryStage) evict(
 	cacheLineID, _ := getCacheLineID(addr, ds.cache.log2BlockSize)
 
 	// Train perceptron on eviction (block was not reused)
-	if perceptronVF, ok := ds.cache.directory.GetVictimFinder().(*cache.PerceptronVictimFinder); ok {
-		perceptronVF.TrainOnEviction(victim.Tag)
+	if trainer, ok := ds.cache.directory.GetVictimFinder().(cache.ReuseTrainer); ok {
+		trainer.TrainOnEvictionWithContext(&cache.VictimContext{
+			Address:      victim.Tag,
+			PID:          victim.PID,
+			PC:           victim.PC,
+			AccessType:   victim.AccessType,
+			AccessOrigin: victim.Origin,
+			AccessSize:   victim.AccessSize,
+			IsPrefetch:   victim.IsPrefetched,
+		})
 	}
 
 	ds.updateTransForEviction(trans, victim, pid, cacheLineID)
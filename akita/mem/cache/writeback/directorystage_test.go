@@ -444,6 +444,73 @@ var _ = Describe("DirectoryStage", func() {
 			})
 		})
 
+		Context("miss, no write allocate", func() {
+			BeforeEach(func() {
+				cacheModule.writeMissPolicy = cache.NoWriteAllocate
+				write.Data = []byte{1, 2, 3, 4}
+
+				mshr.EXPECT().
+					Query(vm.PID(1), uint64(0x100)).
+					Return(nil)
+				directory.EXPECT().
+					Lookup(vm.PID(1), uint64(0x100)).
+					Return(nil)
+			})
+
+			It("should stall if the write buffer is full", func() {
+				writeBufferBuffer.EXPECT().CanPush().Return(false)
+
+				ret := ds.Tick()
+
+				Expect(ret).To(BeFalse())
+			})
+
+			It("should bypass the cache", func() {
+				writeBufferBuffer.EXPECT().CanPush().Return(true)
+				writeBufferBuffer.EXPECT().Push(trans)
+				buf.EXPECT().Pop()
+
+				ret := ds.Tick()
+
+				Expect(ret).To(BeTrue())
+				Expect(trans.action).To(Equal(writeBufferBypass))
+				Expect(trans.evictingAddr).To(Equal(uint64(0x100)))
+				Expect(cacheModule.evictingList).To(HaveKey(uint64(0x100)))
+				Expect(cacheModule.WriteTrafficStats().BypassedMisses).
+					To(Equal(uint64(1)))
+				Expect(cacheModule.WriteTrafficStats().BypassedBytes).
+					To(Equal(uint64(4)))
+			})
+		})
+
+		Context("miss, hinted no reuse", func() {
+			BeforeEach(func() {
+				cacheModule.hints = cache.NewEvictionHints()
+				cacheModule.hints.Add(cache.EvictionHint{
+					PID: 1, Start: 0x100, Size: 0x40, Kind: cache.HintNoReuse,
+				})
+				write.Data = []byte{1, 2, 3, 4}
+
+				mshr.EXPECT().
+					Query(vm.PID(1), uint64(0x100)).
+					Return(nil)
+				directory.EXPECT().
+					Lookup(vm.PID(1), uint64(0x100)).
+					Return(nil)
+			})
+
+			It("should bypass the cache", func() {
+				writeBufferBuffer.EXPECT().CanPush().Return(true)
+				writeBufferBuffer.EXPECT().Push(trans)
+				buf.EXPECT().Pop()
+
+				ret := ds.Tick()
+
+				Expect(ret).To(BeTrue())
+				Expect(trans.action).To(Equal(writeBufferBypass))
+			})
+		})
+
 		Context("miss, write full line, no eviction", func() {
 			var (
 				block *cache.Block
@@ -632,4 +699,162 @@ var _ = Describe("DirectoryStage", func() {
 			})
 		})
 	})
+
+	Context("predictor latency", func() {
+		var (
+			trans *transaction
+		)
+
+		BeforeEach(func() {
+			read := mem.ReadReqBuilder{}.
+				WithAddress(0x100).
+				WithPID(1).
+				WithByteSize(64).
+				Build()
+			trans = &transaction{read: read}
+			cacheModule.predictorTimer = cache.NewPredictorTimer(
+				cache.PredictorLatency{
+					LookupCycles:          1,
+					VictimSelectionCycles: 2,
+					OnCriticalPath:        true,
+				})
+		})
+
+		It("should stall a miss until the victim is selected", func() {
+			pipeline.EXPECT().CanAccept().Return(false).Times(3)
+			buf.EXPECT().Peek().Return(dirPipelineItem{trans: trans}).Times(3)
+			mshr.EXPECT().Query(vm.PID(1), uint64(0x100)).Return(nil)
+			directory.EXPECT().Lookup(vm.PID(1), uint64(0x100)).Return(nil)
+
+			Expect(ds.Tick()).To(BeTrue())
+			Expect(ds.Tick()).To(BeTrue())
+			Expect(ds.Tick()).To(BeTrue())
+
+			Expect(trans.predictorCycles).To(Equal(0))
+			Expect(cacheModule.PredictorLatencyStats()).To(Equal(
+				cache.PredictorLatencyStats{
+					Lookups:          1,
+					VictimSelections: 1,
+					BusyCycles:       3,
+					StallCycles:      3,
+				}))
+		})
+	})
+
+	Context("directory ports", func() {
+		It("should queue the lookups that find the ports busy", func() {
+			cacheModule.directoryPorts = cache.NewDirectoryPorts(
+				cache.DirectoryPortConfig{NumPorts: 1})
+			block := &cache.Block{Tag: 0x100, IsValid: true}
+			newTrans := func() *transaction {
+				return &transaction{read: mem.ReadReqBuilder{}.
+					WithAddress(0x100).
+					WithPID(1).
+					WithByteSize(64).
+					Build()}
+			}
+			first, second := newTrans(), newTrans()
+
+			pipeline.EXPECT().CanAccept().Return(false)
+			buf.EXPECT().Peek().Return(dirPipelineItem{trans: first})
+			buf.EXPECT().Peek().Return(dirPipelineItem{trans: second})
+			mshr.EXPECT().Query(vm.PID(1), uint64(0x100)).
+				Return(nil).AnyTimes()
+			directory.EXPECT().Lookup(vm.PID(1), uint64(0x100)).
+				Return(block).AnyTimes()
+			bankBuf.EXPECT().CanPush().Return(true)
+			bankBuf.EXPECT().Push(gomock.Any())
+			buf.EXPECT().Pop()
+			directory.EXPECT().Visit(block)
+			directory.EXPECT().GetVictimFinder().
+				Return(cache.NewLRUVictimFinder()).AnyTimes()
+
+			Expect(ds.Tick()).To(BeTrue())
+
+			Expect(first.action).To(Equal(bankReadHit))
+			Expect(second.portsGranted).To(BeFalse())
+			Expect(cacheModule.DirectoryPortStats()).To(Equal(
+				cache.DirectoryPortStats{Cycles: 1, Lookups: 1, Queued: 1}))
+		})
+
+		It("should make a stalled lookup compete for the ports again", func() {
+			cacheModule.numReqPerCycle = 2
+			cacheModule.directoryPorts = cache.NewDirectoryPorts(
+				cache.DirectoryPortConfig{NumPorts: 1})
+			block := &cache.Block{Tag: 0x100, IsValid: true}
+			newTrans := func() *transaction {
+				return &transaction{read: mem.ReadReqBuilder{}.
+					WithAddress(0x100).
+					WithPID(1).
+					WithByteSize(64).
+					Build()}
+			}
+			first, second := newTrans(), newTrans()
+
+			pipeline.EXPECT().CanAccept().Return(false).Times(2)
+			buf.EXPECT().Peek().Return(dirPipelineItem{trans: first}).Times(3)
+			buf.EXPECT().Peek().Return(dirPipelineItem{trans: second})
+			mshr.EXPECT().Query(vm.PID(1), uint64(0x100)).
+				Return(nil).AnyTimes()
+			directory.EXPECT().Lookup(vm.PID(1), uint64(0x100)).
+				Return(block).AnyTimes()
+			directory.EXPECT().GetVictimFinder().
+				Return(cache.NewLRUVictimFinder()).AnyTimes()
+			bankBuf.EXPECT().CanPush().Return(false)
+			bankBuf.EXPECT().CanPush().Return(true)
+			bankBuf.EXPECT().Push(gomock.Any())
+			buf.EXPECT().Pop()
+			directory.EXPECT().Visit(block)
+
+			ds.Tick()
+			Expect(first.portsGranted).To(BeFalse())
+
+			Expect(ds.Tick()).To(BeTrue())
+
+			Expect(first.action).To(Equal(bankReadHit))
+			Expect(second.portsGranted).To(BeFalse())
+			stats := cacheModule.DirectoryPortStats()
+			Expect(stats.Lookups).To(Equal(uint64(2)))
+			Expect(stats.Queued).To(Equal(uint64(2)))
+		})
+	})
+
+	Context("way prediction", func() {
+		It("should count one lookup per access", func() {
+			dir := cache.NewDirectory(1, 4, 64, cache.NewLRUVictimFinder())
+			dir.SetWayPredictor(cache.NewMRUWayPredictor(1))
+			block := dir.Sets[0].Blocks[2]
+			block.Tag = 0x100
+			block.PID = 1
+			block.IsValid = true
+			cacheModule.directory = dir
+			cacheModule.directoryPorts = cache.NewDirectoryPorts(
+				cache.DirectoryPortConfig{NumPorts: 1})
+			cacheModule.predictorTimer = cache.NewPredictorTimer(
+				cache.PredictorLatency{LookupCycles: 1, OnCriticalPath: true})
+			trans := &transaction{read: mem.ReadReqBuilder{}.
+				WithAddress(0x100).
+				WithPID(1).
+				WithByteSize(64).
+				Build()}
+
+			pipeline.EXPECT().CanAccept().Return(false).Times(2)
+			buf.EXPECT().Peek().Return(dirPipelineItem{trans: trans}).Times(2)
+			buf.EXPECT().Peek().Return(nil)
+			mshr.EXPECT().Query(vm.PID(1), uint64(0x100)).
+				Return(nil).AnyTimes()
+			bankBuf.EXPECT().CanPush().Return(true)
+			bankBuf.EXPECT().Push(gomock.Any())
+			buf.EXPECT().Pop()
+
+			Expect(ds.Tick()).To(BeTrue())
+			Expect(ds.Tick()).To(BeTrue())
+
+			Expect(trans.action).To(Equal(bankReadHit))
+			Expect(dir.WayPredictionStats()).To(Equal(cache.WayPredictionStats{
+				Incorrect:   1,
+				TagCompares: 4,
+			}))
+		})
+	})
 })
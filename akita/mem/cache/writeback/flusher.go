@@ -13,15 +13,16 @@ type flusher struct {
 
 	blockToEvict    []*cache.Block
 	processingFlush *cache.FlushReq
+	processingClean *cache.CleanReq
 }
 
 func (f *flusher) Tick() bool {
-	if f.processingFlush != nil && f.cache.state == cacheStatePreFlushing {
+	if f.isProcessing() && f.cache.state == cacheStatePreFlushing {
 		return f.processPreFlushing()
 	}
 
 	madeProgress := false
-	if f.processingFlush != nil && f.cache.state == cacheStateFlushing {
+	if f.isProcessing() && f.cache.state == cacheStateFlushing {
 		madeProgress = f.finalizeFlushing() || madeProgress
 		madeProgress = f.processFlush() || madeProgress
 
@@ -31,6 +32,10 @@ func (f *flusher) Tick() bool {
 	return f.extractFromPort()
 }
 
+func (f *flusher) isProcessing() bool {
+	return f.processingFlush != nil || f.processingClean != nil
+}
+
 func (f *flusher) processPreFlushing() bool {
 	if f.existInflightTransaction() {
 		return false
@@ -54,9 +59,16 @@ func (f *flusher) prepareBlockToFlushList() {
 				panic("all the blocks should be unlocked before flushing")
 			}
 
-			if block.IsValid && block.IsDirty {
-				f.blockToEvict = append(f.blockToEvict, block)
+			if !block.IsValid || !block.IsDirty {
+				continue
+			}
+
+			if f.processingClean != nil &&
+				!f.processingClean.Covers(block.Tag) {
+				continue
 			}
+
+			f.blockToEvict = append(f.blockToEvict, block)
 		}
 	}
 }
@@ -79,13 +91,22 @@ func (f *flusher) processFlush() bool {
 
 	trans := &transaction{
 		flush:             f.processingFlush,
+		clean:             f.processingClean,
 		victim:            block,
 		action:            bankEvict,
+		evictingPID:       block.PID,
 		evictingAddr:      block.Tag,
 		evictingDirtyMask: block.DirtyMask,
 	}
 	bankBuf.Push(trans)
 
+	if f.processingClean != nil {
+		// The data stays in the storage, so the bank can still read it for
+		// the writeback after the block is marked clean.
+		block.IsDirty = false
+		block.DirtyMask = nil
+	}
+
 	f.blockToEvict = f.blockToEvict[1:]
 
 	return true
@@ -100,6 +121,8 @@ func (f *flusher) extractFromPort() bool {
 	switch req := item.(type) {
 	case *cache.FlushReq:
 		return f.startProcessingFlush(req)
+	case *cache.CleanReq:
+		return f.startProcessingClean(req)
 	case *cache.RestartReq:
 		return f.handleCacheRestart(req)
 	default:
@@ -125,6 +148,18 @@ func (f *flusher) startProcessingFlush(
 	return true
 }
 
+func (f *flusher) startProcessingClean(
+	req *cache.CleanReq,
+) bool {
+	f.processingClean = req
+	f.cache.state = cacheStatePreFlushing
+	f.cache.controlPort.RetrieveIncoming()
+
+	tracing.TraceReqReceive(req, f.cache)
+
+	return true
+}
+
 func (f *flusher) handleCacheRestart(
 	req *cache.RestartReq,
 ) bool {
@@ -162,6 +197,10 @@ func (f *flusher) finalizeFlushing() bool {
 		return false
 	}
 
+	if f.processingClean != nil {
+		return f.finalizeCleaning()
+	}
+
 	rsp := cache.FlushRspBuilder{}.
 		WithSrc(f.cache.controlPort.AsRemote()).
 		WithDst(f.processingFlush.Src).
@@ -184,6 +223,22 @@ func (f *flusher) finalizeFlushing() bool {
 	return true
 }
 
+func (f *flusher) finalizeCleaning() bool {
+	rsp := cache.CleanRspBuilder{}.
+		WithSrc(f.cache.controlPort.AsRemote()).
+		WithDst(f.processingClean.Src).
+		WithRspTo(f.processingClean.ID).
+		Build()
+	f.cache.controlPort.Send(rsp)
+
+	f.cache.state = cacheStateRunning
+
+	tracing.TraceReqComplete(f.processingClean, f.cache)
+	f.processingClean = nil
+
+	return true
+}
+
 func (f *flusher) flushCompleted() bool {
 	for _, b := range f.cache.dirToBankBuffers {
 		if b.Size() > 0 {
@@ -283,6 +283,88 @@ var _ = Describe("Flusher", func() {
 		})
 	})
 
+	Context("clean", func() {
+		It("should start cleaning", func() {
+			req := cache.CleanReqBuilder{}.Build()
+			controlPort.EXPECT().PeekIncoming().Return(req)
+			controlPort.EXPECT().RetrieveIncoming().Return(nil).AnyTimes()
+
+			ret := f.Tick()
+
+			Expect(ret).To(BeTrue())
+			Expect(f.processingClean).To(BeIdenticalTo(req))
+			Expect(cacheModule.state).To(Equal(cacheStatePreFlushing))
+		})
+
+		It("should only clean the blocks in range", func() {
+			cacheModule.state = cacheStatePreFlushing
+			cacheModule.inFlightTransactions = nil
+			f.processingClean = cache.CleanReqBuilder{}.
+				WithAddressRange(0x1000, 0x1000).
+				Build()
+
+			sets := []cache.Set{
+				{Blocks: []*cache.Block{
+					{Tag: 0x1000, IsDirty: true, IsValid: true},
+					{Tag: 0x2000, IsDirty: true, IsValid: true},
+				}},
+			}
+			directory.EXPECT().GetSets().Return(sets)
+
+			ret := f.Tick()
+
+			Expect(ret).To(BeTrue())
+			Expect(f.blockToEvict).To(ConsistOf(sets[0].Blocks[0]))
+		})
+
+		It("should write back without invalidating", func() {
+			cacheModule.state = cacheStateFlushing
+			f.processingClean = cache.CleanReqBuilder{}.Build()
+			block := &cache.Block{
+				Tag:       0x1000,
+				IsValid:   true,
+				IsDirty:   true,
+				DirtyMask: []bool{true},
+			}
+			f.blockToEvict = []*cache.Block{block}
+
+			bankBuf.EXPECT().CanPush().Return(true)
+			bankBuf.EXPECT().Push(gomock.Any()).
+				Do(func(trans *transaction) {
+					Expect(trans.action).To(Equal(bankEvict))
+					Expect(trans.evictingDirtyMask).To(Equal([]bool{true}))
+					Expect(trans.req()).To(BeIdenticalTo(f.processingClean))
+				})
+
+			ret := f.Tick()
+
+			Expect(ret).To(BeTrue())
+			Expect(block.IsValid).To(BeTrue())
+			Expect(block.IsDirty).To(BeFalse())
+		})
+
+		It("should respond without resetting the directory", func() {
+			cacheModule.state = cacheStateFlushing
+			req := cache.CleanReqBuilder{}.Build()
+			f.processingClean = req
+			f.blockToEvict = []*cache.Block{}
+
+			bankBuf.EXPECT().Size().Return(0)
+			writeBufferBuf.EXPECT().Size().Return(0)
+			controlPort.EXPECT().CanSend().Return(true)
+			controlPort.EXPECT().Send(gomock.Any()).
+				Do(func(rsp *cache.CleanRsp) {
+					Expect(rsp.RspTo).To(Equal(req.ID))
+				})
+
+			ret := f.Tick()
+
+			Expect(ret).To(BeTrue())
+			Expect(f.processingClean).To(BeNil())
+			Expect(cacheModule.state).To(Equal(cacheStateRunning))
+		})
+	})
+
 	Context("flush with reset", func() {
 		It("should remove inflight state", func() {
 			req := cache.FlushReqBuilder{}.
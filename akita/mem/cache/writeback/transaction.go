@@ -21,6 +21,7 @@ const (
 	writeBufferEvictAndFetch
 	writeBufferEvictAndWrite
 	writeBufferFlush
+	writeBufferBypass
 )
 
 type transaction struct {
@@ -29,6 +30,7 @@ type transaction struct {
 	read              *mem.ReadReq
 	write             *mem.WriteReq
 	flush             *cache.FlushReq
+	clean             *cache.CleanReq
 	block             *cache.Block
 	victim            *cache.Block
 	fetchPID          vm.PID
@@ -41,6 +43,11 @@ type transaction struct {
 	evictingDirtyMask []bool
 	evictionWriteReq  *mem.WriteReq
 	mshrEntry         *cache.MSHREntry
+
+	predictorCharged bool
+	predictorCycles  int
+	portsGranted     bool
+	portRetryCycles  int
 }
 
 func (t transaction) accessReq() mem.AccessReq {
@@ -64,5 +71,9 @@ func (t transaction) req() sim.Msg {
 		return t.flush
 	}
 
+	if t.clean != nil {
+		return t.clean
+	}
+
 	return nil
 }
@@ -49,6 +49,17 @@ type Comp struct {
 	state                cacheState
 	inFlightTransactions []*transaction
 	evictingList         map[uint64]bool
+
+	writeMissPolicy cache.WriteMissPolicy
+	writeStats      cache.WriteTrafficStats
+
+	kernelHooks *cache.KernelBoundaryHooks
+	hints       *cache.EvictionHints
+
+	predictorTimer *cache.PredictorTimer
+	directoryPorts *cache.DirectoryPorts
+
+	writebackPlanner *cache.WritebackPlanner
 }
 
 // SetAddressToPortMapper sets the AddressToPortMapper used by the cache.
@@ -56,6 +67,57 @@ func (c *Comp) SetAddressToPortMapper(lmf mem.AddressToPortMapper) {
 	c.addressToPortMapper = lmf
 }
 
+// WriteMissPolicy returns the write-miss policy of the cache.
+func (c *Comp) WriteMissPolicy() cache.WriteMissPolicy {
+	return c.writeMissPolicy
+}
+
+// WriteTrafficStats returns the write traffic statistics of the cache.
+func (c *Comp) WriteTrafficStats() cache.WriteTrafficStats {
+	return c.writeStats
+}
+
+// EvictionHints returns the software hint table of the cache. Hints added to
+// it steer victim selection, and write misses to ranges hinted as not reused
+// bypass the cache.
+func (c *Comp) EvictionHints() *cache.EvictionHints {
+	return c.hints
+}
+
+// PredictorLatencyStats returns the predictor cycles charged to the accesses.
+// It returns zero counters unless the cache is built with a predictor latency.
+func (c *Comp) PredictorLatencyStats() cache.PredictorLatencyStats {
+	return c.predictorTimer.Stats()
+}
+
+// DirectoryPortStats returns the port usage of the directory. It returns zero
+// counters unless the cache is built with directory ports.
+func (c *Comp) DirectoryPortStats() cache.DirectoryPortStats {
+	return c.directoryPorts.Stats()
+}
+
+// WritebackTrafficStats returns the number of writebacks to the lower level
+// and the bytes that they move.
+func (c *Comp) WritebackTrafficStats() cache.WritebackTrafficStats {
+	return c.writebackPlanner.Stats()
+}
+
+// KernelLaunched notifies the cache that a kernel is launched. It has no
+// effect unless the cache is built with a kernel boundary policy.
+func (c *Comp) KernelLaunched(kernel string) {
+	if c.kernelHooks != nil {
+		c.kernelHooks.KernelLaunched(kernel)
+	}
+}
+
+// KernelCompleted notifies the cache that a kernel is completed. It has no
+// effect unless the cache is built with a kernel boundary policy.
+func (c *Comp) KernelCompleted(kernel string) {
+	if c.kernelHooks != nil {
+		c.kernelHooks.KernelCompleted(kernel)
+	}
+}
+
 func (c *Comp) Tick() bool {
 	return c.MiddlewareHolder.Tick()
 }
@@ -3,6 +3,7 @@ package writeback
 import (
 	"github.com/sarchlab/akita/v4/mem/cache"
 	"github.com/sarchlab/akita/v4/mem/mem"
+	"github.com/sarchlab/akita/v4/mem/vm"
 	"github.com/sarchlab/akita/v4/tracing"
 )
 
@@ -44,6 +45,8 @@ func (wb *writeBufferStage) processNewTransaction() bool {
 		return wb.processWriteBufferFetchAndEvict(trans)
 	case writeBufferFlush:
 		return wb.processWriteBufferFlush(trans, true)
+	case writeBufferBypass:
+		return wb.processWriteBufferFlush(trans, true)
 	default:
 		panic("unknown transaction action")
 	}
@@ -61,6 +64,10 @@ func (wb *writeBufferStage) processWriteBufferFetch(
 
 func (wb *writeBufferStage) findDataLocally(trans *transaction) bool {
 	for _, e := range wb.inflightEviction {
+		if e.action == writeBufferBypass {
+			continue
+		}
+
 		if e.evictingAddr == trans.fetchAddress {
 			trans.fetchedData = e.evictingData
 			return true
@@ -68,6 +75,10 @@ func (wb *writeBufferStage) findDataLocally(trans *transaction) bool {
 	}
 
 	for _, e := range wb.pendingEvictions {
+		if e.action == writeBufferBypass {
+			continue
+		}
+
 		if e.evictingAddr == trans.fetchAddress {
 			trans.fetchedData = e.evictingData
 			return true
@@ -243,6 +254,9 @@ func (wb *writeBufferStage) write() bool {
 		Build()
 	wb.cache.bottomPort.Send(write)
 
+	wb.cache.writebackPlanner.Plan(trans.evictingDirtyMask,
+		len(trans.evictingData), wb.predictsDead(trans.evictingAddr, trans.evictingPID))
+
 	trans.evictionWriteReq = write
 	wb.pendingEvictions = wb.pendingEvictions[1:]
 	wb.inflightEviction = append(wb.inflightEviction, trans)
@@ -262,6 +276,26 @@ func (wb *writeBufferStage) write() bool {
 	return true
 }
 
+// predictsDead asks the dead-block predictor, if the cache has one and the
+// writeback policy uses it, whether the evicted line will be reused.
+func (wb *writeBufferStage) predictsDead(addr uint64, pid vm.PID) bool {
+	if !wb.cache.writebackPlanner.NeedsPrediction() {
+		return false
+	}
+
+	p, ok := wb.cache.directory.GetVictimFinder().(cache.DeadBlockPredictor)
+	if !ok {
+		return false
+	}
+
+	dead, _ := p.PredictDead(addr, &cache.VictimContext{
+		Address: addr,
+		PID:     pid,
+	})
+
+	return dead
+}
+
 func (wb *writeBufferStage) processReturnRsp() bool {
 	msg := wb.cache.bottomPort.PeekIncoming()
 	if msg == nil {
@@ -374,6 +408,10 @@ func (wb *writeBufferStage) processWriteDoneRsp(
 	for i := len(wb.inflightEviction) - 1; i >= 0; i-- {
 		e := wb.inflightEviction[i]
 		if e.evictionWriteReq.ID == writeDone.RespondTo {
+			if e.action == writeBufferBypass {
+				return wb.finalizeBypassedWrite(i, e)
+			}
+
 			wb.inflightEviction = append(
 				wb.inflightEviction[:i],
 				wb.inflightEviction[i+1:]...,
@@ -397,6 +435,47 @@ func (wb *writeBufferStage) processWriteDoneRsp(
 	panic("write request not found")
 }
 
+func (wb *writeBufferStage) finalizeBypassedWrite(
+	index int,
+	trans *transaction,
+) bool {
+	if !wb.cache.topPort.CanSend() {
+		return false
+	}
+
+	wb.inflightEviction = append(
+		wb.inflightEviction[:index],
+		wb.inflightEviction[index+1:]...,
+	)
+	wb.cache.bottomPort.RetrieveIncoming()
+	tracing.TraceReqFinalize(trans.evictionWriteReq, wb.cache)
+
+	write := trans.write
+	done := mem.WriteDoneRspBuilder{}.
+		WithSrc(wb.cache.topPort.AsRemote()).
+		WithDst(write.Src).
+		WithRspTo(write.ID).
+		Build()
+	wb.cache.topPort.Send(done)
+
+	cachelineID, _ := getCacheLineID(write.Address, wb.cache.log2BlockSize)
+	delete(wb.cache.evictingList, cachelineID)
+
+	for i, t := range wb.cache.inFlightTransactions {
+		if t == trans {
+			wb.cache.inFlightTransactions = append(
+				wb.cache.inFlightTransactions[:i],
+				wb.cache.inFlightTransactions[i+1:]...)
+
+			break
+		}
+	}
+
+	tracing.TraceReqComplete(write, wb.cache)
+
+	return true
+}
+
 func (wb *writeBufferStage) writeBufferFull() bool {
 	numEntry := len(wb.pendingEvictions) + len(wb.inflightEviction)
 	return numEntry >= wb.writeBufferCapacity
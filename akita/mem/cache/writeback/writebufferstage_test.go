@@ -552,6 +552,35 @@ var _ = Describe("Write Buffer Stage", func() {
 			Expect(trans.evictionWriteReq).To(BeIdenticalTo(writeReq))
 			Expect(wbStage.pendingEvictions).NotTo(ContainElement(trans))
 			Expect(wbStage.inflightEviction).To(ContainElement(trans))
+			Expect(cacheModule.WritebackTrafficStats()).To(Equal(
+				cache.WritebackTrafficStats{
+					Writebacks:   1,
+					BytesWritten: 64,
+					DirtyBytes:   32,
+				}))
+		})
+
+		It("should only account the dirty sectors", func() {
+			cacheModule.writebackPlanner = cache.NewWritebackPlanner(
+				cache.PartialWritebackPolicy{
+					Granularity: cache.WritebackSectors,
+					SectorSize:  4,
+				})
+			addressToPortMapper.EXPECT().
+				Find(uint64(0x1000)).
+				Return(sim.RemotePort("DramPort"))
+			bottomPort.EXPECT().CanSend().Return(true)
+			bottomPort.EXPECT().Send(gomock.Any())
+
+			wbStage.write()
+
+			Expect(cacheModule.WritebackTrafficStats()).To(Equal(
+				cache.WritebackTrafficStats{
+					Writebacks:        1,
+					PartialWritebacks: 1,
+					BytesWritten:      32,
+					DirtyBytes:        32,
+				}))
 		})
 	})
 
@@ -597,6 +626,67 @@ var _ = Describe("Write Buffer Stage", func() {
 		})
 	})
 
+	Context("when a bypassed write is done", func() {
+		var (
+			topPort   *MockPort
+			write     *mem.WriteReq
+			bypass    *transaction
+			writeDone *mem.WriteDoneRsp
+		)
+
+		BeforeEach(func() {
+			topPort = NewMockPort(mockCtrl)
+			topPort.EXPECT().
+				AsRemote().
+				Return(sim.RemotePort("TopPort")).
+				AnyTimes()
+			cacheModule.topPort = topPort
+
+			write = mem.WriteReqBuilder{}.
+				WithAddress(0x104).
+				Build()
+			bottomWrite := mem.WriteReqBuilder{}.Build()
+			bypass = &transaction{
+				action:           writeBufferBypass,
+				write:            write,
+				evictionWriteReq: bottomWrite,
+			}
+			writeDone = mem.WriteDoneRspBuilder{}.
+				WithRspTo(bottomWrite.ID).
+				Build()
+
+			wbStage.inflightEviction = append(wbStage.inflightEviction, bypass)
+			cacheModule.inFlightTransactions = []*transaction{bypass}
+			cacheModule.evictingList[0x100] = true
+		})
+
+		It("should stall if cannot respond to the top", func() {
+			bottomPort.EXPECT().PeekIncoming().Return(writeDone)
+			topPort.EXPECT().CanSend().Return(false)
+
+			madeProgress := wbStage.processReturnRsp()
+
+			Expect(madeProgress).To(BeFalse())
+		})
+
+		It("should respond to the top", func() {
+			bottomPort.EXPECT().PeekIncoming().Return(writeDone)
+			bottomPort.EXPECT().RetrieveIncoming()
+			topPort.EXPECT().CanSend().Return(true)
+			topPort.EXPECT().Send(gomock.Any()).
+				Do(func(rsp *mem.WriteDoneRsp) {
+					Expect(rsp.RespondTo).To(Equal(write.ID))
+				})
+
+			madeProgress := wbStage.processReturnRsp()
+
+			Expect(madeProgress).To(BeTrue())
+			Expect(wbStage.inflightEviction).To(BeEmpty())
+			Expect(cacheModule.inFlightTransactions).To(BeEmpty())
+			Expect(cacheModule.evictingList).NotTo(HaveKey(uint64(0x100)))
+		})
+	})
+
 	Context("when received data-ready rsp", func() {
 		var (
 			read      *mem.ReadReq
@@ -0,0 +1,117 @@
+package cache
+
+import "sort"
+
+// A DeadBlockRank is the deadness prediction of a resident block.
+type DeadBlockRank struct {
+	Block      *Block
+	Dead       bool
+	Confidence int32
+}
+
+// residentContext returns the context of the fill of a resident block, with
+// which the predictor is asked about the block.
+func residentContext(block *Block) *VictimContext {
+	return &VictimContext{
+		Address:      block.Tag,
+		PID:          block.PID,
+		AccessType:   block.AccessType,
+		PC:           block.PC,
+		AccessOrigin: block.Origin,
+		AccessSize:   block.AccessSize,
+		IsPrefetch:   block.IsPrefetched,
+	}
+}
+
+// RankDeadBlocks ranks the valid, unlocked blocks of the set that the address
+// maps to by predicted deadness. The blocks predicted dead come first, the
+// most confident first, followed by the blocks predicted live, the least
+// confident first. Every block is predicted with the context of its fill. It
+// returns nil if the victim finder is not a DeadBlockPredictor.
+func (d *DirectoryImpl) RankDeadBlocks(addr uint64) []DeadBlockRank {
+	p, ok := d.victimFinder.(DeadBlockPredictor)
+	if !ok {
+		return nil
+	}
+
+	set, _ := d.getSet(addr)
+	ranks := predictResident(p, set, nil)
+	sortDeadBlockRanks(ranks)
+
+	return ranks
+}
+
+// WritebackCandidates returns up to n dirty blocks of the directory that are
+// predicted dead, the most confident first. A cache controller can write
+// them back early, during idle cycles, so that their eviction later does not
+// wait for a writeback. It returns nil if the victim finder is not a
+// DeadBlockPredictor.
+func (d *DirectoryImpl) WritebackCandidates(n int) []DeadBlockRank {
+	p, ok := d.victimFinder.(DeadBlockPredictor)
+	if !ok || n <= 0 {
+		return nil
+	}
+
+	var ranks []DeadBlockRank
+	for i := range d.Sets {
+		ranks = append(ranks, predictResident(p, &d.Sets[i],
+			func(b *Block) bool { return b.IsDirty })...)
+	}
+
+	dead := ranks[:0]
+	for _, r := range ranks {
+		if r.Dead {
+			dead = append(dead, r)
+		}
+	}
+
+	sortDeadBlockRanks(dead)
+
+	if len(dead) > n {
+		dead = dead[:n]
+	}
+
+	return dead
+}
+
+// predictResident predicts the valid, unlocked blocks of the set for which
+// keep, if not nil, returns true.
+func predictResident(
+	p DeadBlockPredictor,
+	set *Set,
+	keep func(*Block) bool,
+) []DeadBlockRank {
+	ranks := make([]DeadBlockRank, 0, len(set.Blocks))
+
+	for _, block := range set.Blocks {
+		if !block.IsValid || block.IsLocked || (keep != nil && !keep(block)) {
+			continue
+		}
+
+		dead, confidence := p.PredictDead(block.Tag, residentContext(block))
+		ranks = append(ranks, DeadBlockRank{
+			Block:      block,
+			Dead:       dead,
+			Confidence: confidence,
+		})
+	}
+
+	return ranks
+}
+
+// sortDeadBlockRanks sorts the ranks from the most to the least likely dead.
+// Equal ranks keep their order.
+func sortDeadBlockRanks(ranks []DeadBlockRank) {
+	sort.SliceStable(ranks, func(i, j int) bool {
+		a, b := ranks[i], ranks[j]
+		if a.Dead != b.Dead {
+			return a.Dead
+		}
+
+		if a.Dead {
+			return a.Confidence > b.Confidence
+		}
+
+		return a.Confidence < b.Confidence
+	})
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Writeback hints", func() {
+	var (
+		p          *PerceptronVictimFinder
+		d          *DirectoryImpl
+		dead, live uint64
+	)
+
+	BeforeEach(func() {
+		p = NewPerceptronVictimFinder()
+		p.SetStrictMode(true)
+		d = NewDirectory(1, 4, 64, p)
+
+		dead = 0x10001 << p.featureShift
+		live = 0x20002 << p.featureShift
+
+		for i := 0; i < 32; i++ {
+			p.TrainOnEviction(dead)
+			p.TrainOnHit(live)
+		}
+
+		blocks := d.Sets[0].Blocks
+		for i, tag := range []uint64{live, dead, 0x40000 << p.featureShift} {
+			blocks[i].Tag = tag
+			blocks[i].IsValid = true
+			blocks[i].IsDirty = true
+		}
+	})
+
+	It("should rank the resident blocks by predicted deadness", func() {
+		blocks := d.Sets[0].Blocks
+
+		ranks := d.RankDeadBlocks(0)
+
+		Expect(ranks).To(HaveLen(3))
+		Expect(ranks[0].Block).To(BeIdenticalTo(blocks[1]))
+		Expect(ranks[0].Dead).To(BeTrue())
+		Expect(ranks[1].Block).To(BeIdenticalTo(blocks[2]))
+		Expect(ranks[2].Block).To(BeIdenticalTo(blocks[0]))
+		Expect(ranks[2].Dead).To(BeFalse())
+		Expect(ranks[2].Confidence).To(BeNumerically(">", ranks[1].Confidence))
+	})
+
+	It("should return the dirty blocks predicted dead", func() {
+		blocks := d.Sets[0].Blocks
+
+		Expect(d.WritebackCandidates(4)).To(HaveLen(1))
+		Expect(d.WritebackCandidates(4)[0].Block).To(BeIdenticalTo(blocks[1]))
+
+		blocks[1].IsDirty = false
+		Expect(d.WritebackCandidates(4)).To(BeEmpty())
+	})
+
+	It("should not rank without a dead-block predictor", func() {
+		lru := NewDirectory(1, 4, 64, NewLRUVictimFinder())
+
+		Expect(lru.RankDeadBlocks(0)).To(BeNil())
+		Expect(lru.WritebackCandidates(1)).To(BeNil())
+	})
+})
@@ -0,0 +1,57 @@
+package cache
+
+// WriteMissPolicy decides whether a write miss allocates a block in the cache.
+type WriteMissPolicy int
+
+// The supported write-miss policies.
+const (
+	// WriteAllocate fetches the missing line (when needed) and stores the
+	// written data in the cache. Victim selection and predictor training
+	// happen as for a read miss.
+	WriteAllocate WriteMissPolicy = iota
+
+	// NoWriteAllocate forwards write misses to the lower-level memory without
+	// allocating a block. Since no victim is selected, bypassed writes do not
+	// produce eviction training signals; write hits still train the predictor.
+	NoWriteAllocate
+)
+
+// String returns the name of the write-miss policy.
+func (p WriteMissPolicy) String() string {
+	switch p {
+	case WriteAllocate:
+		return "write-allocate"
+	case NoWriteAllocate:
+		return "no-write-allocate"
+	default:
+		return "unknown"
+	}
+}
+
+// WriteTrafficStats counts the write traffic handled by a cache controller.
+type WriteTrafficStats struct {
+	WriteHits       uint64
+	WriteMisses     uint64
+	AllocatedMisses uint64
+	BypassedMisses  uint64
+	BypassedBytes   uint64
+}
+
+// RecordHit records a write hit.
+func (s *WriteTrafficStats) RecordHit() {
+	s.WriteHits++
+}
+
+// RecordAllocatedMiss records a write miss that allocates a block.
+func (s *WriteTrafficStats) RecordAllocatedMiss() {
+	s.WriteMisses++
+	s.AllocatedMisses++
+}
+
+// RecordBypassedMiss records a write miss that is forwarded to the lower
+// level without allocation.
+func (s *WriteTrafficStats) RecordBypassedMiss(byteSize uint64) {
+	s.WriteMisses++
+	s.BypassedMisses++
+	s.BypassedBytes += byteSize
+}
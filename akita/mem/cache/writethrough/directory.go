@@ -132,7 +132,7 @@ func (d *directory) processReadMiss(
 	cacheLineID := addr / blockSize * blockSize
 
 	victim := d.cache.directory.FindVictim(cacheLineID)
-	if victim.IsLocked || victim.ReadCount > 0 {
+	if victim == nil || victim.IsLocked || victim.ReadCount > 0 {
 		return false
 	}
 
@@ -221,7 +221,7 @@ func (d *directory) partialWriteMiss(
 	}
 
 	victim := d.cache.directory.FindVictim(cacheLineID)
-	if victim.ReadCount > 0 || victim.IsLocked {
+	if victim == nil || victim.ReadCount > 0 || victim.IsLocked {
 		return false
 	}
 
@@ -255,6 +255,9 @@ func (d *directory) fullLineWriteMiss(
 	blockSize := uint64(1 << d.cache.log2BlockSize)
 	cacheLineID := addr / blockSize * blockSize
 	block := d.cache.directory.FindVictim(cacheLineID)
+	if block == nil {
+		return false
+	}
 
 	return d.processWriteHit(trans, block)
 }
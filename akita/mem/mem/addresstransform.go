@@ -0,0 +1,119 @@
+package mem
+
+import "log"
+
+// BitSwapConverter swaps two address bits. It is its own inverse.
+type BitSwapConverter struct {
+	A, B uint
+}
+
+func (c BitSwapConverter) swap(addr uint64) uint64 {
+	a := (addr >> c.A) & 1
+	b := (addr >> c.B) & 1
+
+	if a == b {
+		return addr
+	}
+
+	return addr ^ (1 << c.A) ^ (1 << c.B)
+}
+
+// ConvertExternalToInternal swaps the bits.
+func (c BitSwapConverter) ConvertExternalToInternal(external uint64) uint64 {
+	return c.swap(external)
+}
+
+// ConvertInternalToExternal swaps the bits back.
+func (c BitSwapConverter) ConvertInternalToExternal(internal uint64) uint64 {
+	return c.swap(internal)
+}
+
+// XORFoldConverter XORs several Width-bit fields of the address into the field
+// that starts at bit Target. It is typically used to hash the upper address
+// bits into the set index. The source fields must not overlap the target
+// field, which makes the conversion its own inverse.
+type XORFoldConverter struct {
+	Target  uint
+	Width   uint
+	Sources []uint
+}
+
+func (c XORFoldConverter) fold(addr uint64) uint64 {
+	mask := uint64(1)<<c.Width - 1
+
+	for _, src := range c.Sources {
+		if src+c.Width > c.Target && c.Target+c.Width > src {
+			log.Panicf("xor fold source bit %d overlaps target bit %d",
+				src, c.Target)
+		}
+
+		addr ^= ((addr >> src) & mask) << c.Target
+	}
+
+	return addr
+}
+
+// ConvertExternalToInternal folds the source fields into the target field.
+func (c XORFoldConverter) ConvertExternalToInternal(external uint64) uint64 {
+	return c.fold(external)
+}
+
+// ConvertInternalToExternal undoes the fold.
+func (c XORFoldConverter) ConvertInternalToExternal(internal uint64) uint64 {
+	return c.fold(internal)
+}
+
+// StripBitsConverter removes the Count bits starting at bit Low, which select
+// the channel or bank in an interleaved address space, so that the remaining
+// bits of every element form a continuous internal address space. Index is the
+// value of the stripped bits for the current element; it is put back when
+// converting to an external address.
+type StripBitsConverter struct {
+	Low   uint
+	Count uint
+	Index uint64
+}
+
+// ConvertExternalToInternal removes the stripped bits.
+func (c StripBitsConverter) ConvertExternalToInternal(external uint64) uint64 {
+	lowMask := uint64(1)<<c.Low - 1
+	stripped := (external >> c.Low) & (uint64(1)<<c.Count - 1)
+
+	if stripped != c.Index {
+		log.Panicf("address 0x%x does not belong to element %d",
+			external, c.Index)
+	}
+
+	return (external>>(c.Low+c.Count))<<c.Low | external&lowMask
+}
+
+// ConvertInternalToExternal inserts the index of the element.
+func (c StripBitsConverter) ConvertInternalToExternal(internal uint64) uint64 {
+	lowMask := uint64(1)<<c.Low - 1
+
+	return (internal>>c.Low)<<(c.Low+c.Count) |
+		c.Index<<c.Low |
+		internal&lowMask
+}
+
+// ChainConverter applies the converters in order when converting to internal
+// addresses, and in reverse order when converting back.
+type ChainConverter []AddressConverter
+
+// ConvertExternalToInternal applies all the converters.
+func (c ChainConverter) ConvertExternalToInternal(external uint64) uint64 {
+	for _, conv := range c {
+		external = conv.ConvertExternalToInternal(external)
+	}
+
+	return external
+}
+
+// ConvertInternalToExternal undoes all the converters.
+func (c ChainConverter) ConvertInternalToExternal(internal uint64) uint64 {
+	for i := len(c) - 1; i >= 0; i-- {
+		internal = c[i].ConvertInternalToExternal(internal)
+	}
+
+	return internal
+}
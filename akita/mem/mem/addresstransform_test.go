@@ -0,0 +1,53 @@
+package mem
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Address transforms", func() {
+	roundTrip := func(c AddressConverter, addr uint64) uint64 {
+		return c.ConvertInternalToExternal(c.ConvertExternalToInternal(addr))
+	}
+
+	It("should swap bits", func() {
+		c := BitSwapConverter{A: 6, B: 12}
+
+		Expect(c.ConvertExternalToInternal(0x40)).To(Equal(uint64(0x1000)))
+		Expect(c.ConvertExternalToInternal(0x1040)).To(Equal(uint64(0x1040)))
+		Expect(roundTrip(c, 0x12345)).To(Equal(uint64(0x12345)))
+	})
+
+	It("should fold fields into the target", func() {
+		c := XORFoldConverter{Target: 6, Width: 4, Sources: []uint{16, 20}}
+
+		Expect(c.ConvertExternalToInternal(0x310000)).
+			To(Equal(uint64(0x310000 | 0x2<<6)))
+		Expect(roundTrip(c, 0xabcdef)).To(Equal(uint64(0xabcdef)))
+	})
+
+	It("should panic if a source overlaps the target", func() {
+		c := XORFoldConverter{Target: 6, Width: 4, Sources: []uint{8}}
+
+		Expect(func() { c.ConvertExternalToInternal(0) }).To(Panic())
+	})
+
+	It("should strip the channel bits", func() {
+		c := StripBitsConverter{Low: 12, Count: 2, Index: 1}
+
+		Expect(c.ConvertExternalToInternal(0x1040)).To(Equal(uint64(0x40)))
+		Expect(c.ConvertExternalToInternal(0x5040)).To(Equal(uint64(0x1040)))
+		Expect(roundTrip(c, 0x5040)).To(Equal(uint64(0x5040)))
+		Expect(func() { c.ConvertExternalToInternal(0x2000) }).To(Panic())
+	})
+
+	It("should chain converters", func() {
+		c := ChainConverter{
+			StripBitsConverter{Low: 12, Count: 2, Index: 1},
+			BitSwapConverter{A: 6, B: 7},
+		}
+
+		Expect(c.ConvertExternalToInternal(0x5040)).To(Equal(uint64(0x1080)))
+		Expect(roundTrip(c, 0x5040)).To(Equal(uint64(0x5040)))
+	})
+})
@@ -0,0 +1,70 @@
+package mem
+
+// A MemorySpace is the GPU address space of an access.
+type MemorySpace int
+
+// Memory spaces.
+const (
+	MemorySpaceGlobal MemorySpace = iota
+	MemorySpaceShared
+	MemorySpaceConstant
+	MemorySpacePrivate
+)
+
+// An AccessOrigin identifies the GPU requester of an access. Reuse behavior
+// depends strongly on the compute unit and the wavefront that issue an
+// access, which the address alone does not reveal.
+type AccessOrigin struct {
+	CUID        int
+	WavefrontID int
+	KernelID    uint64
+	MemorySpace MemorySpace
+}
+
+// withOrigin returns the info with the origin attached, creating an info map
+// if there is none. Info of other types is returned unchanged.
+func withOrigin(info interface{}, origin AccessOrigin) interface{} {
+	if info == nil {
+		info = make(map[string]interface{})
+	}
+
+	if infoMap, ok := info.(map[string]interface{}); ok {
+		infoMap["AccessOrigin"] = origin
+	}
+
+	return info
+}
+
+// WithOrigin sets the GPU requester of the request to build.
+func (b ReadReqBuilder) WithOrigin(origin AccessOrigin) ReadReqBuilder {
+	b.info = withOrigin(b.info, origin)
+	return b
+}
+
+// WithOrigin sets the GPU requester of the request to build.
+func (b WriteReqBuilder) WithOrigin(origin AccessOrigin) WriteReqBuilder {
+	b.info = withOrigin(b.info, origin)
+	return b
+}
+
+// Origin returns the GPU requester attached to a read or write request with
+// WithOrigin. It returns false if the request carries no origin.
+func Origin(req AccessReq) (AccessOrigin, bool) {
+	var info interface{}
+
+	switch req := req.(type) {
+	case *ReadReq:
+		info = req.Info
+	case *WriteReq:
+		info = req.Info
+	}
+
+	infoMap, ok := info.(map[string]interface{})
+	if !ok {
+		return AccessOrigin{}, false
+	}
+
+	origin, ok := infoMap["AccessOrigin"].(AccessOrigin)
+
+	return origin, ok
+}
@@ -0,0 +1,46 @@
+package mem
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Origin", func() {
+	origin := AccessOrigin{
+		CUID:        3,
+		WavefrontID: 17,
+		KernelID:    2,
+		MemorySpace: MemorySpaceShared,
+	}
+
+	It("should return the origin of a read along with its PC", func() {
+		read := ReadReqBuilder{}.
+			WithInstPC(0x40).
+			WithOrigin(origin).
+			Build()
+
+		got, ok := Origin(read)
+		Expect(ok).To(BeTrue())
+		Expect(got).To(Equal(origin))
+
+		pc, ok := InstPC(read)
+		Expect(ok).To(BeTrue())
+		Expect(pc).To(Equal(uint64(0x40)))
+	})
+
+	It("should return the origin of a write", func() {
+		write := WriteReqBuilder{}.WithOrigin(origin).Build()
+
+		got, ok := Origin(write)
+		Expect(ok).To(BeTrue())
+		Expect(got).To(Equal(origin))
+	})
+
+	It("should report requests without an origin", func() {
+		_, ok := Origin(ReadReqBuilder{}.WithInfo("other").Build())
+		Expect(ok).To(BeFalse())
+
+		_, ok = Origin(ReadReqBuilder{}.WithInstPC(0x40).Build())
+		Expect(ok).To(BeFalse())
+	})
+})
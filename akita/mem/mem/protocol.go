@@ -276,6 +276,28 @@ func (b WriteReqBuilder) WithInstPC(pc uint64) WriteReqBuilder {
 	return b
 }
 
+// InstPC returns the instruction PC attached to a read or write request with
+// WithInstPC. It returns false if the request carries no PC.
+func InstPC(req AccessReq) (uint64, bool) {
+	var info interface{}
+
+	switch req := req.(type) {
+	case *ReadReq:
+		info = req.Info
+	case *WriteReq:
+		info = req.Info
+	}
+
+	infoMap, ok := info.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+
+	pc, ok := infoMap["InstPC"].(uint64)
+
+	return pc, ok
+}
+
 // Build creates a new WriteReq
 func (b WriteReqBuilder) Build() *WriteReq {
 	r := &WriteReq{}
@@ -0,0 +1,34 @@
+package mem
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InstPC", func() {
+	It("should return the PC of a read", func() {
+		read := ReadReqBuilder{}.WithAddress(0x40).WithInstPC(0x1234).Build()
+
+		pc, ok := InstPC(read)
+
+		Expect(ok).To(BeTrue())
+		Expect(pc).To(Equal(uint64(0x1234)))
+	})
+
+	It("should return the PC of a write", func() {
+		write := WriteReqBuilder{}.WithInstPC(0x88).Build()
+
+		pc, ok := InstPC(write)
+
+		Expect(ok).To(BeTrue())
+		Expect(pc).To(Equal(uint64(0x88)))
+	})
+
+	It("should report requests without a PC", func() {
+		read := ReadReqBuilder{}.WithInfo("other").Build()
+
+		_, ok := InstPC(read)
+
+		Expect(ok).To(BeFalse())
+	})
+})